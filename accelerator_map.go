@@ -0,0 +1,255 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+const TypeAcceleratorMap CTypeTag = "cdk-accelerator-map"
+
+func init() {
+	_ = TypesManager.AddType(TypeAcceleratorMap, nil)
+}
+
+var (
+	// AcceleratorChordTimeout is the maximum delay between the key strokes of
+	// a multi-stroke accelerator sequence (chord)
+	AcceleratorChordTimeout = time.Second
+)
+
+// AcceleratorMap Hierarchy:
+//
+//	Object
+//	  +- AcceleratorMap
+//
+// An AcceleratorMap associates key sequences with named actions. Each Display
+// has an AcceleratorMap which is consulted before any normal event dispatch
+// happens and a SignalAccelerator is emitted by the Display when a complete
+// sequence is matched.
+type AcceleratorMap interface {
+	Object
+
+	AddAccelerator(accelerator string, action string) (err error)
+	RemoveAccelerator(accelerator string) (err error)
+	RemoveAction(action string)
+	LookupAccelerator(accelerator string) (action string, ok bool)
+	ListAccelerators(action string) (accelerators []string)
+	ClearAccelerators()
+	HasPendingChord() (pending bool)
+	ResetChord()
+	ProcessKey(evt *EventKey) (action string, consumed bool)
+}
+
+var _ AcceleratorMap = (*CAcceleratorMap)(nil)
+
+type cAcceleratorBinding struct {
+	sequence []KeyStroke
+	action   string
+}
+
+func (b *cAcceleratorBinding) String() string {
+	var parts []string
+	for _, stroke := range b.sequence {
+		parts = append(parts, stroke.String())
+	}
+	return strings.Join(parts, " ")
+}
+
+func (b *cAcceleratorBinding) matches(sequence []KeyStroke) (complete, prefix bool) {
+	if len(sequence) > len(b.sequence) {
+		return
+	}
+	for idx, stroke := range sequence {
+		if !b.sequence[idx].Equals(stroke) {
+			return
+		}
+	}
+	complete = len(sequence) == len(b.sequence)
+	prefix = !complete
+	return
+}
+
+type CAcceleratorMap struct {
+	CObject
+
+	bindings []*cAcceleratorBinding
+	pending  []KeyStroke
+	lastKey  time.Time
+}
+
+func newAcceleratorMap() (am *CAcceleratorMap) {
+	am = new(CAcceleratorMap)
+	am.Init()
+	return
+}
+
+func (am *CAcceleratorMap) Init() (already bool) {
+	if am.InitTypeItem(TypeAcceleratorMap, am) {
+		return true
+	}
+	am.CObject.Init()
+	am.bindings = make([]*cAcceleratorBinding, 0)
+	am.pending = nil
+	return false
+}
+
+func (am *CAcceleratorMap) findBinding(sequence []KeyStroke) (index int) {
+	for idx, binding := range am.bindings {
+		if complete, _ := binding.matches(sequence); complete {
+			return idx
+		}
+	}
+	return -1
+}
+
+// AddAccelerator parses the given accelerator string with ParseKeySequence and
+// associates the resulting sequence with the given action. Any existing action
+// bound to the same sequence is replaced.
+func (am *CAcceleratorMap) AddAccelerator(accelerator string, action string) (err error) {
+	var sequence []KeyStroke
+	if sequence, err = ParseKeySequence(accelerator); err != nil {
+		return
+	}
+	am.Lock()
+	defer am.Unlock()
+	if idx := am.findBinding(sequence); idx > -1 {
+		am.bindings[idx].action = action
+		return
+	}
+	am.bindings = append(am.bindings, &cAcceleratorBinding{
+		sequence: sequence,
+		action:   action,
+	})
+	return
+}
+
+// RemoveAccelerator removes the action bound to the given accelerator string
+func (am *CAcceleratorMap) RemoveAccelerator(accelerator string) (err error) {
+	var sequence []KeyStroke
+	if sequence, err = ParseKeySequence(accelerator); err != nil {
+		return
+	}
+	am.Lock()
+	defer am.Unlock()
+	if idx := am.findBinding(sequence); idx > -1 {
+		am.bindings = append(am.bindings[:idx], am.bindings[idx+1:]...)
+		return
+	}
+	return fmt.Errorf("accelerator not found: %q", accelerator)
+}
+
+// RemoveAction removes all accelerators bound to the given action
+func (am *CAcceleratorMap) RemoveAction(action string) {
+	am.Lock()
+	defer am.Unlock()
+	var bindings []*cAcceleratorBinding
+	for _, binding := range am.bindings {
+		if binding.action != action {
+			bindings = append(bindings, binding)
+		}
+	}
+	am.bindings = bindings
+}
+
+// LookupAccelerator returns the action bound to the given accelerator string
+func (am *CAcceleratorMap) LookupAccelerator(accelerator string) (action string, ok bool) {
+	if sequence, err := ParseKeySequence(accelerator); err == nil {
+		am.RLock()
+		defer am.RUnlock()
+		if idx := am.findBinding(sequence); idx > -1 {
+			action, ok = am.bindings[idx].action, true
+		}
+	}
+	return
+}
+
+// ListAccelerators returns the normalized accelerator strings bound to the
+// given action
+func (am *CAcceleratorMap) ListAccelerators(action string) (accelerators []string) {
+	am.RLock()
+	defer am.RUnlock()
+	for _, binding := range am.bindings {
+		if binding.action == action {
+			accelerators = append(accelerators, binding.String())
+		}
+	}
+	return
+}
+
+// ClearAccelerators removes all accelerators and resets any pending chord
+func (am *CAcceleratorMap) ClearAccelerators() {
+	am.Lock()
+	defer am.Unlock()
+	am.bindings = make([]*cAcceleratorBinding, 0)
+	am.pending = nil
+}
+
+// HasPendingChord returns true if one or more key strokes of a multi-stroke
+// sequence have been consumed and the map is waiting for the remainder
+func (am *CAcceleratorMap) HasPendingChord() (pending bool) {
+	am.RLock()
+	defer am.RUnlock()
+	return len(am.pending) > 0
+}
+
+// ResetChord discards any pending key strokes of a multi-stroke sequence
+func (am *CAcceleratorMap) ResetChord() {
+	am.Lock()
+	defer am.Unlock()
+	am.pending = nil
+}
+
+// ProcessKey consults the map with the given EventKey. When the key completes
+// an accelerator sequence, the action is returned and consumed is true. When
+// the key is part of an incomplete sequence, the key is consumed and the
+// action is empty. Keys which do not match any sequence are not consumed.
+// Key release events are never consumed.
+func (am *CAcceleratorMap) ProcessKey(evt *EventKey) (action string, consumed bool) {
+	am.Lock()
+	defer am.Unlock()
+	if len(am.bindings) == 0 || evt.Phase() == KeyRelease {
+		return
+	}
+	stroke := MakeKeyStroke(evt)
+	now := time.Now()
+	if len(am.pending) > 0 && now.Sub(am.lastKey) > AcceleratorChordTimeout {
+		am.pending = nil
+	}
+	am.lastKey = now
+	candidates := [][]KeyStroke{append(append([]KeyStroke{}, am.pending...), stroke)}
+	if len(am.pending) > 0 {
+		// a broken chord may still be the start of a new sequence
+		candidates = append(candidates, []KeyStroke{stroke})
+	}
+	am.pending = nil
+	for _, sequence := range candidates {
+		prefix := false
+		for _, binding := range am.bindings {
+			if complete, partial := binding.matches(sequence); complete {
+				return binding.action, true
+			} else if partial {
+				prefix = true
+			}
+		}
+		if prefix {
+			am.pending = sequence
+			return "", true
+		}
+	}
+	return
+}
@@ -0,0 +1,85 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAcceleratorMap(t *testing.T) {
+	Convey("Parsing key sequences", t, func() {
+		key, mods, err := ParseKeyMods("<Ctrl><Alt>x")
+		So(err, ShouldBeNil)
+		So(key, ShouldEqual, KeySmallX)
+		So(mods, ShouldEqual, ModCtrl|ModAlt)
+		seq, err := ParseKeySequence("<Ctrl>x <Ctrl>s")
+		So(err, ShouldBeNil)
+		So(seq, ShouldHaveLength, 2)
+		So(seq[0], ShouldResemble, KeyStroke{Key: KeySmallX, Mods: ModCtrl})
+		So(seq[1], ShouldResemble, KeyStroke{Key: KeySmallS, Mods: ModCtrl})
+		seq, err = ParseKeySequence("<Alt>F4")
+		So(err, ShouldBeNil)
+		So(seq, ShouldHaveLength, 1)
+		So(seq[0], ShouldResemble, KeyStroke{Key: KeyF4, Mods: ModAlt})
+		_, err = ParseKeySequence("<Ctrl>xs")
+		So(err, ShouldNotBeNil)
+		_, err = ParseKeySequence("<Nope>x")
+		So(err, ShouldNotBeNil)
+	})
+	Convey("Matching accelerators", t, func() {
+		am := newAcceleratorMap()
+		So(am.AddAccelerator("<Ctrl>x <Ctrl>s", "save"), ShouldBeNil)
+		So(am.AddAccelerator("<Alt>F4", "quit"), ShouldBeNil)
+		So(am.AddAccelerator("<Ctrl>x", "bad action"), ShouldBeNil)
+		So(am.RemoveAccelerator("<Ctrl>x"), ShouldBeNil)
+		So(am.RemoveAccelerator("<Ctrl>x"), ShouldNotBeNil)
+		action, ok := am.LookupAccelerator("<Alt>F4")
+		So(ok, ShouldBeTrue)
+		So(action, ShouldEqual, "quit")
+		So(am.ListAccelerators("save"), ShouldResemble, []string{"<Control>x <Control>s"})
+		// single stroke
+		action, consumed := am.ProcessKey(NewEventKey(KeyF4, 0, ModAlt))
+		So(consumed, ShouldBeTrue)
+		So(action, ShouldEqual, "quit")
+		// unbound key
+		action, consumed = am.ProcessKey(NewEventKey(KeyRune, 'q', ModNone))
+		So(consumed, ShouldBeFalse)
+		So(action, ShouldEqual, "")
+		// chord
+		action, consumed = am.ProcessKey(NewEventKey(KeyCtrlX, rune(KeyCtrlX), ModCtrl))
+		So(consumed, ShouldBeTrue)
+		So(action, ShouldEqual, "")
+		So(am.HasPendingChord(), ShouldBeTrue)
+		action, consumed = am.ProcessKey(NewEventKey(KeyCtrlS, rune(KeyCtrlS), ModCtrl))
+		So(consumed, ShouldBeTrue)
+		So(action, ShouldEqual, "save")
+		So(am.HasPendingChord(), ShouldBeFalse)
+		// broken chord falls through to a new sequence
+		_, consumed = am.ProcessKey(NewEventKey(KeyCtrlX, rune(KeyCtrlX), ModCtrl))
+		So(consumed, ShouldBeTrue)
+		action, consumed = am.ProcessKey(NewEventKey(KeyF4, 0, ModAlt))
+		So(consumed, ShouldBeTrue)
+		So(action, ShouldEqual, "quit")
+		_, consumed = am.ProcessKey(NewEventKey(KeyCtrlX, rune(KeyCtrlX), ModCtrl))
+		So(consumed, ShouldBeTrue)
+		_, consumed = am.ProcessKey(NewEventKey(KeyRune, 'q', ModNone))
+		So(consumed, ShouldBeFalse)
+		So(am.HasPendingChord(), ShouldBeFalse)
+		am.RemoveAction("save")
+		So(am.ListAccelerators("save"), ShouldBeEmpty)
+	})
+}
@@ -0,0 +1,182 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// SpeechDispatcherTimeout is how long to wait for a Speech Dispatcher server
+// to connect and to respond to each command
+var SpeechDispatcherTimeout = time.Second * 2
+
+// SpeechDispatcherAddress returns the network and address of the Speech
+// Dispatcher server, from the SPEECHD_ADDRESS environment variable or the
+// default per-user socket.
+func SpeechDispatcherAddress() (network, address string) {
+	if value := os.Getenv("SPEECHD_ADDRESS"); value != "" {
+		kind, rest, _ := strings.Cut(value, ":")
+		switch kind {
+		case "unix_socket":
+			return "unix", rest
+		case "inet_socket":
+			host, port, found := strings.Cut(rest, ":")
+			if !found {
+				port = "6560"
+			}
+			return "tcp", net.JoinHostPort(host, port)
+		}
+	}
+	if runtime := os.Getenv("XDG_RUNTIME_DIR"); runtime != "" {
+		return "unix", filepath.Join(runtime, "speech-dispatcher", "speechd.sock")
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		return "unix", filepath.Join(home, ".cache", "speech-dispatcher", "speechd.sock")
+	}
+	return "tcp", "127.0.0.1:6560"
+}
+
+// CSpeechDispatcher is an Announcer speaking the announcements through a
+// Speech Dispatcher server, which in turn drives the speech synthesizers and
+// braille displays configured by the user.
+type CSpeechDispatcher struct {
+	conn   net.Conn
+	reader *bufio.Reader
+
+	sync.Mutex
+}
+
+// NewSpeechDispatcher connects to the Speech Dispatcher server at the
+// SpeechDispatcherAddress, identifying as the named client.
+func NewSpeechDispatcher(client string) (s *CSpeechDispatcher, err error) {
+	network, address := SpeechDispatcherAddress()
+	return NewSpeechDispatcherWith(network, address, client)
+}
+
+// NewSpeechDispatcherWith connects to the Speech Dispatcher server at the
+// given network address, identifying as the named client.
+func NewSpeechDispatcherWith(network, address, client string) (s *CSpeechDispatcher, err error) {
+	var conn net.Conn
+	if conn, err = net.DialTimeout(network, address, SpeechDispatcherTimeout); err != nil {
+		return nil, fmt.Errorf("error connecting to speech dispatcher: %w", err)
+	}
+	s = &CSpeechDispatcher{conn: conn, reader: bufio.NewReader(conn)}
+	user := os.Getenv("USER")
+	if user == "" {
+		user = "unknown"
+	}
+	s.Lock()
+	defer s.Unlock()
+	if _, err = s.command("SET self CLIENT_NAME %s:%s:main", ssipField(user), ssipField(client)); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	return
+}
+
+// Announce speaks the text with the Speech Dispatcher priority matching the
+// AnnouncePriority.
+func (s *CSpeechDispatcher) Announce(text string, priority AnnouncePriority) (err error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.conn == nil {
+		return fmt.Errorf("speech dispatcher is closed")
+	}
+	if _, err = s.command("SET self PRIORITY %s", ssipPriority(priority)); err != nil {
+		return
+	}
+	if _, err = s.command("SPEAK"); err != nil {
+		return
+	}
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	for idx, line := range lines {
+		if strings.HasPrefix(line, ".") {
+			// escape lines which would end the message
+			lines[idx] = "." + line
+		}
+	}
+	_, err = s.command("%s\r\n.", strings.Join(lines, "\r\n"))
+	return
+}
+
+// Close ends the session with the Speech Dispatcher server.
+func (s *CSpeechDispatcher) Close() (err error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.conn == nil {
+		return nil
+	}
+	_, _ = s.command("QUIT")
+	err = s.conn.Close()
+	s.conn = nil
+	return
+}
+
+// command sends the SSIP command and returns the final line of the response,
+// returning an error unless the response code indicates success
+func (s *CSpeechDispatcher) command(format string, argv ...interface{}) (reply string, err error) {
+	_ = s.conn.SetDeadline(time.Now().Add(SpeechDispatcherTimeout))
+	if _, err = fmt.Fprintf(s.conn, format+"\r\n", argv...); err != nil {
+		return "", fmt.Errorf("error writing to speech dispatcher: %w", err)
+	}
+	for {
+		var line string
+		if line, err = s.reader.ReadString('\n'); err != nil {
+			return "", fmt.Errorf("error reading from speech dispatcher: %w", err)
+		}
+		line = strings.TrimRight(line, "\r\n")
+		if len(line) < 4 {
+			return "", fmt.Errorf("invalid speech dispatcher response: %q", line)
+		}
+		if line[3] == '-' {
+			// continued response
+			continue
+		}
+		if line[0] != '2' {
+			return line, fmt.Errorf("speech dispatcher error: %s", line)
+		}
+		return line, nil
+	}
+}
+
+// ssipPriority returns the Speech Dispatcher message priority
+func ssipPriority(priority AnnouncePriority) string {
+	switch {
+	case priority <= AnnounceLow:
+		return "notification"
+	case priority >= AnnounceHigh:
+		return "important"
+	}
+	return "text"
+}
+
+// ssipField removes the characters with special meaning from a client name
+func ssipField(value string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r == ':' || r == ' ' || r < 0x20:
+			return '_'
+		}
+		return r
+	}, value)
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+)
+
+// CTerminalAnnouncer is an Announcer sending announcements as desktop
+// notifications through the terminal of a Display, see: Screen.Notify
+type CTerminalAnnouncer struct {
+	display     Display
+	minPriority AnnouncePriority
+}
+
+// NewTerminalAnnouncer returns a new CTerminalAnnouncer for the Display,
+// sending only the announcements of at least the minimum priority given as
+// desktop notifications are intrusive.
+func NewTerminalAnnouncer(display Display, minPriority AnnouncePriority) *CTerminalAnnouncer {
+	return &CTerminalAnnouncer{display: display, minPriority: minPriority}
+}
+
+// Announce sends the text as a desktop notification titled with the Display
+// title.
+func (t *CTerminalAnnouncer) Announce(text string, priority AnnouncePriority) (err error) {
+	if priority < t.minPriority {
+		return nil
+	}
+	screen := t.display.Screen()
+	if screen == nil {
+		return fmt.Errorf("display is not captured")
+	}
+	return screen.Notify(t.display.GetTitle(), text)
+}
+
+// Close does nothing, the terminal belongs to the Display.
+func (t *CTerminalAnnouncer) Close() (err error) {
+	return nil
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/urfave/cli/v2"
 
 	"github.com/go-curses/cdk/env"
+	cid "github.com/go-curses/cdk/id"
 	"github.com/go-curses/cdk/lib/enums"
 	cfsorter "github.com/go-curses/cdk/lib/flag_sorter"
 	cpaths "github.com/go-curses/cdk/lib/paths"
@@ -64,13 +65,20 @@ type Application interface {
 	Description() string
 	Version() string
 	Reconfigure(name, usage, description, version, tag, title, ttyPath string)
-	AddFlag(flag cli.Flag)
+	AddFlag(flag cli.Flag) (err error)
+	AddNamespacedFlag(prefix string, flag cli.Flag) (err error)
 	RemoveFlag(flag cli.Flag) (removed bool)
-	AddFlags(flags []cli.Flag)
+	AddFlags(flags []cli.Flag) (err error)
 	AddCommand(command *cli.Command)
 	AddCommands(commands []*cli.Command)
+	LoadConfig(paths ...string) (err error)
+	GetConfig(key string) (value interface{}, ok bool)
+	ReloadConfig() (err error)
 	Display() *CDisplay
 	SetDisplay(d *CDisplay) (err error)
+	AddDisplay(d *CDisplay) (err error)
+	RemoveDisplay(d *CDisplay) (err error)
+	GetDisplays() (displays []*CDisplay)
 	NotifyStartupComplete()
 	StartupCompleted() bool
 	Run(args []string) (err error)
@@ -94,15 +102,21 @@ type CApplication struct {
 	title       string
 	ttyPath     string
 	display     *CDisplay
+	displays    []*CDisplay
+	displaysWG  *sync.WaitGroup
+	runCtx      context.Context
 	context     *cli.Context
 	cli         *cli.App
 	runFn       ApplicationRunFn
+	config      map[string]interface{}
+	configPaths []string
+	configKeys  map[string]bool
 	valid       bool
 	started     bool
 }
 
 func NewApplication(name, usage, description, version, tag, title, ttyPath string) *CApplication {
-	id, _ := uuid.NewV4()
+	id := cid.NewUUID()
 	app := &CApplication{
 		id:          id,
 		name:        name,
@@ -124,6 +138,8 @@ func (app *CApplication) Init() (already bool) {
 	}
 	app.CObject.Init()
 	app.started = false
+	app.displays = make([]*CDisplay, 0)
+	app.displaysWG = &sync.WaitGroup{}
 	app.cli = &cli.App{
 		Name:        app.name,
 		Usage:       app.usage,
@@ -160,6 +176,9 @@ func (app *CApplication) SetupDisplay() {
 			app.LogErr(err)
 		}
 	}
+	if err := app.applyDisplayConfig(app.display); err != nil {
+		app.LogErr(err)
+	}
 	app.Emit(SignalSetupDisplay, app.display)
 }
 
@@ -170,7 +189,12 @@ func (app *CApplication) Destroy() {
 	if app.display != nil {
 		app.display.Destroy()
 	}
+	for _, display := range app.displays {
+		display.Destroy()
+	}
 	app.display = nil
+	app.displays = nil
+	app.runCtx = nil
 	app.context = nil
 	app.cli = nil
 }
@@ -244,14 +268,60 @@ func (app *CApplication) Reconfigure(name, usage, description, version, tag, tit
 	}
 }
 
-func (app *CApplication) AddFlag(flag cli.Flag) {
+// AddFlag adds the command line flag to the application, returning an error
+// if any name of the flag is already used by another flag or is within a
+// reserved namespace, such as the "cdk-" flags, see: RegisterCliFlagNamespace.
+// The flag is not added when an error is returned.
+//
+// AddFlag and AddFlags did not return an error before flag collisions were
+// detected. Callers ignoring the error still build but may lose flags without
+// notice, and other implementations of Application must add the result.
+func (app *CApplication) AddFlag(flag cli.Flag) (err error) {
+	if flag != nil {
+		for _, name := range flag.Names() {
+			if prefix, namespaced := GetCliFlagNamespace(name); namespaced {
+				return fmt.Errorf("cli flag %v is within the reserved namespace %q", name, prefix)
+			}
+		}
+	}
+	return app.addFlag(flag)
+}
+
+// AddNamespacedFlag adds the command line flag of a library which reserved the
+// namespace with RegisterCliFlagNamespace, all names of the flag must be
+// within the namespace
+func (app *CApplication) AddNamespacedFlag(prefix string, flag cli.Flag) (err error) {
+	if flag != nil {
+		for _, name := range flag.Names() {
+			if ns, namespaced := GetCliFlagNamespace(name); !namespaced || ns != prefix {
+				return fmt.Errorf("cli flag %v is not within the namespace %q", name, prefix)
+			}
+		}
+	}
+	return app.addFlag(flag)
+}
+
+func (app *CApplication) addFlag(flag cli.Flag) (err error) {
+	app.Lock()
+	defer app.Unlock()
+	if err = checkCliFlag(app.cli.Flags, flag); err != nil {
+		return
+	}
 	app.cli.Flags = append(app.cli.Flags, flag)
+	return
 }
 
+// RemoveFlag removes the command line flag with the same name as the flag
+// given
 func (app *CApplication) RemoveFlag(flag cli.Flag) (removed bool) {
+	if flag == nil || len(flag.Names()) == 0 {
+		return false
+	}
+	app.Lock()
+	defer app.Unlock()
 	index := -1
 	for idx, f := range app.cli.Flags {
-		if f.String() == flag.String() {
+		if names := f.Names(); len(names) > 0 && names[0] == flag.Names()[0] {
 			index = idx
 			break
 		}
@@ -263,10 +333,15 @@ func (app *CApplication) RemoveFlag(flag cli.Flag) (removed bool) {
 	return
 }
 
-func (app *CApplication) AddFlags(flags []cli.Flag) {
+// AddFlags adds each of the flags, returning the first error encountered, see:
+// AddFlag
+func (app *CApplication) AddFlags(flags []cli.Flag) (err error) {
 	for _, f := range flags {
-		app.AddFlag(f)
+		if ee := app.AddFlag(f); ee != nil && err == nil {
+			err = ee
+		}
 	}
+	return
 }
 
 func (app *CApplication) AddCommand(command *cli.Command) {
@@ -310,6 +385,7 @@ func (app *CApplication) SetDisplay(d *CDisplay) (err error) {
 					app.LogInfo("application startup signal listener requested EVENT_STOP")
 					app.display.RequestQuit()
 				}
+				app.startDisplays(ctx)
 				return enums.EVENT_PASS
 			}
 			return enums.EVENT_STOP
@@ -326,6 +402,134 @@ func (app *CApplication) SetDisplay(d *CDisplay) (err error) {
 	return
 }
 
+// AddDisplay includes the given Display in the Application, in addition to
+// the primary Display. Each additional Display runs its own event loop, which
+// is started along with the primary Display (or immediately if the primary
+// Display is already running) and is stopped when the primary Display shuts
+// down. This allows a single process to drive multiple TTYs.
+func (app *CApplication) AddDisplay(d *CDisplay) (err error) {
+	if d == nil {
+		return fmt.Errorf("cannot add a nil Display")
+	}
+	app.Lock()
+	if d == app.display {
+		app.Unlock()
+		return fmt.Errorf("display is already the primary Display")
+	}
+	for _, display := range app.displays {
+		if display == d {
+			app.Unlock()
+			return fmt.Errorf("display is already added")
+		}
+	}
+	d.app = app
+	app.displays = append(app.displays, d)
+	ctx := app.runCtx
+	app.Unlock()
+	if f := app.Emit(SignalAddDisplay, d); f == enums.EVENT_STOP {
+		app.Lock()
+		app.displays = app.displays[:len(app.displays)-1]
+		app.Unlock()
+		return fmt.Errorf("add display signal listener requested EVENT_STOP")
+	}
+	if ctx != nil {
+		app.startDisplay(ctx, d)
+	}
+	return
+}
+
+// RemoveDisplay excludes the given additional Display from the Application.
+// Running displays cannot be removed, call RequestQuit on the Display first.
+func (app *CApplication) RemoveDisplay(d *CDisplay) (err error) {
+	if d != nil && d.IsRunning() {
+		return fmt.Errorf("cannot remove a running Display")
+	}
+	app.Lock()
+	for idx, display := range app.displays {
+		if display == d {
+			app.displays = append(app.displays[:idx], app.displays[idx+1:]...)
+			app.Unlock()
+			app.Emit(SignalRemoveDisplay, d)
+			return
+		}
+	}
+	app.Unlock()
+	return fmt.Errorf("display not found")
+}
+
+// GetDisplays returns the primary Display, if any, followed by all additional
+// displays.
+func (app *CApplication) GetDisplays() (displays []*CDisplay) {
+	app.RLock()
+	defer app.RUnlock()
+	if app.display != nil {
+		displays = append(displays, app.display)
+	}
+	displays = append(displays, app.displays...)
+	return
+}
+
+// startDisplays records the primary Display context and starts the event loops
+// of all additional displays
+func (app *CApplication) startDisplays(ctx context.Context) {
+	app.Lock()
+	if app.runCtx != nil {
+		app.Unlock()
+		return
+	}
+	app.runCtx = ctx
+	displays := append([]*CDisplay{}, app.displays...)
+	app.Unlock()
+	for _, display := range displays {
+		app.startDisplay(ctx, display)
+	}
+}
+
+// startDisplay runs the event loop of an additional Display, within its own
+// main context, until either the Display or the primary Display shuts down
+func (app *CApplication) startDisplay(parent context.Context, d *CDisplay) {
+	if d.IsRunning() {
+		return
+	}
+	d.Connect(
+		SignalDisplayStartup,
+		ApplicationDisplayStartupHandle,
+		func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			_ = d.Disconnect(SignalDisplayStartup, ApplicationDisplayStartupHandle)
+			if ctx, _, _, ok := DisplaySignalDisplayStartupArgv(argv...); ok {
+				d.StartupComplete()
+				Go(func() {
+					select {
+					case <-parent.Done():
+						d.RequestQuit()
+					case <-ctx.Done():
+					}
+				})
+				return enums.EVENT_PASS
+			}
+			return enums.EVENT_STOP
+		},
+	)
+	app.displaysWG.Add(1)
+	GoWithMainContext(
+		env.Get("USER", "nil"),
+		"localhost",
+		d,
+		app.Self(),
+		func() {
+			if err := d.Run(); err != nil {
+				app.LogErr(err)
+			}
+			app.displaysWG.Done()
+		},
+	)
+}
+
+// waitDisplays blocks until all additional displays have shut down
+func (app *CApplication) waitDisplays() {
+	app.displaysWG.Wait()
+}
+
 func (app *CApplication) StartupCompleted() bool {
 	// app.RLock()
 	// defer app.RUnlock()
@@ -355,6 +559,9 @@ func (app *CApplication) Run(args []string) (err error) {
 }
 
 func (app *CApplication) CliActionFn(ctx *cli.Context) (err error) {
+	if err := app.applyConfig(ctx); err != nil {
+		app.LogErr(err)
+	}
 	if f := app.Emit(SignalPrepare, app.Self(), ctx); f == enums.EVENT_STOP {
 		app.Emit(SignalShutdown)
 		return nil
@@ -389,6 +596,7 @@ func (app *CApplication) CliActionFn(ctx *cli.Context) (err error) {
 	)
 
 	wg.Wait()
+	app.waitDisplays()
 	return
 }
 
@@ -438,33 +646,17 @@ func (app *CApplication) MainInit(argv ...interface{}) (ok bool) {
 		}
 	}
 
-	if Build.LogLevel {
-		if v := app.context.String("cdk-log-level"); !cstrings.IsEmpty(v) {
-			env.Set("GO_CDK_LOG_LEVEL", v)
-		}
-		if Build.LogLevels {
-			if app.context.Bool("ctk-log-levels") {
-				for i := len(log.LogLevels) - 1; i >= 0; i-- {
-					fmt.Printf("%s\n", log.LogLevels[i])
-				}
-				return false
-			}
-		}
+	if err := app.applyConfig(app.context); err != nil {
+		app.LogErr(err)
 	}
-	if Build.LogFile {
-		if v := app.context.String("cdk-log-file"); !cstrings.IsEmpty(v) {
-			env.Set("GO_CDK_LOG_OUTPUT", "file")
-			env.Set("GO_CDK_LOG_FILE", v)
-		}
-	}
-	if Build.LogTimestamps {
-		if v := app.context.String("cdk-log-timestamps"); !cstrings.IsEmpty(v) && cstrings.IsBoolean(v) {
-			env.Set("GO_CDK_LOG_TIMESTAMPS", v)
-		}
-	}
-	if Build.LogTimestampFormat {
-		if v := app.context.String("cdk-log-timestamp-format"); !cstrings.IsEmpty(v) {
-			env.Set("GO_CDK_LOG_TIMESTAMP_FORMAT", v)
+
+	app.setLogEnv(app.context)
+	if Build.LogLevel && Build.LogLevels {
+		if app.context.Bool("cdk-log-levels") {
+			for i := len(log.LogLevels) - 1; i >= 0; i-- {
+				fmt.Printf("%s\n", log.LogLevels[i])
+			}
+			return false
 		}
 	}
 	profilePath := DefaultGoProfilePath
@@ -524,6 +716,41 @@ func (app *CApplication) MainInit(argv ...interface{}) (ok bool) {
 	return true
 }
 
+// setLogEnv sets the GO_CDK environment variables of the cdk-log flags given,
+// applied to the logging by log.StartRestart
+func (app *CApplication) setLogEnv(ctx *cli.Context) {
+	if Build.LogLevel {
+		if v := ctx.String("cdk-log-level"); !cstrings.IsEmpty(v) {
+			env.Set("GO_CDK_LOG_LEVEL", v)
+		}
+	}
+	if Build.LogFile {
+		if v := ctx.String("cdk-log-file"); !cstrings.IsEmpty(v) {
+			env.Set("GO_CDK_LOG_OUTPUT", "file")
+			env.Set("GO_CDK_LOG_FILE", v)
+		}
+		if v := ctx.String("cdk-log-file-max-size"); !cstrings.IsEmpty(v) {
+			env.Set("GO_CDK_LOG_FILE_MAX_SIZE", v)
+		}
+		if ctx.IsSet("cdk-log-file-max-backups") {
+			env.Set("GO_CDK_LOG_FILE_MAX_BACKUPS", fmt.Sprintf("%d", ctx.Int("cdk-log-file-max-backups")))
+		}
+		if ctx.IsSet("cdk-log-file-compress") {
+			env.Set("GO_CDK_LOG_FILE_COMPRESS", fmt.Sprintf("%v", ctx.Bool("cdk-log-file-compress")))
+		}
+	}
+	if Build.LogTimestamps {
+		if v := ctx.String("cdk-log-timestamps"); !cstrings.IsEmpty(v) && cstrings.IsBoolean(v) {
+			env.Set("GO_CDK_LOG_TIMESTAMPS", v)
+		}
+	}
+	if Build.LogTimestampFormat {
+		if v := ctx.String("cdk-log-timestamp-format"); !cstrings.IsEmpty(v) {
+			env.Set("GO_CDK_LOG_TIMESTAMP_FORMAT", v)
+		}
+	}
+}
+
 func (app *CApplication) MainRun(runner ApplicationMain) {
 	app.SetupDisplay()
 	display := app.Display()
@@ -545,12 +772,14 @@ func (app *CApplication) MainRun(runner ApplicationMain) {
 				app.LogInfo("application startup signal listener requested EVENT_STOP")
 				app.display.RequestQuit()
 			}
+			app.startDisplays(ctx)
 			if runner != nil {
 				runner(ctx, cancel, wg)
 			}
 		},
 	)
 	wg.Wait()
+	app.waitDisplays()
 	return
 }
 
@@ -583,6 +812,10 @@ const SignalPrepare Signal = "prepare"
 
 const SignalSetupDisplay Signal = "setup-display"
 
+const SignalAddDisplay Signal = "add-display"
+
+const SignalRemoveDisplay Signal = "remove-display"
+
 const SignalStartup Signal = "startup"
 
 const SignalActivate Signal = "activate"
@@ -591,6 +824,10 @@ const SignalShutdown Signal = "shutdown"
 
 const SignalNotifyStartupComplete Signal = "notify-startup-complete"
 
+// SignalReloadConfig is emitted by the Application, and then each Display,
+// once the config has been reloaded, see: Application.ReloadConfig
+const SignalReloadConfig Signal = "reload-config"
+
 const ApplicationDisplayStartupHandle = "application-display-startup-handler"
 
 const ApplicationDisplayShutdownHandle = "application-display-shutdown-handler"
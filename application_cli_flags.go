@@ -15,6 +15,10 @@
 package cdk
 
 import (
+	"fmt"
+	"strings"
+	"sync"
+
 	"github.com/urfave/cli/v2"
 
 	"github.com/go-curses/cdk/log"
@@ -42,6 +46,27 @@ var (
 		Usage:       "path to log file",
 		DefaultText: log.DefaultLogPath,
 	}
+	AppCliLogFileMaxSizeFlag = &cli.StringFlag{
+		Category:    "Go-Curses",
+		Name:        "cdk-log-file-max-size",
+		EnvVars:     []string{"GO_CDK_LOG_FILE_MAX_SIZE"},
+		Usage:       "rotate the log file when larger than this size, in bytes or with a K, M or G suffix",
+		DefaultText: "0 (never)",
+	}
+	AppCliLogFileMaxBackupsFlag = &cli.IntFlag{
+		Category:    "Go-Curses",
+		Name:        "cdk-log-file-max-backups",
+		EnvVars:     []string{"GO_CDK_LOG_FILE_MAX_BACKUPS"},
+		Usage:       "number of rotated log files to keep",
+		Value:       log.DefaultLogFileMaxBackups,
+		DefaultText: fmt.Sprintf("%d", log.DefaultLogFileMaxBackups),
+	}
+	AppCliLogFileCompressFlag = &cli.BoolFlag{
+		Category: "Go-Curses",
+		Name:     "cdk-log-file-compress",
+		EnvVars:  []string{"GO_CDK_LOG_FILE_COMPRESS"},
+		Usage:    "compress rotated log files with gzip",
+	}
 	AppCliLogLevelFlag = &cli.StringFlag{
 		Category:    "Go-Curses",
 		Name:        "cdk-log-level",
@@ -85,7 +110,7 @@ var (
 		Name:        "cdk-log-output",
 		EnvVars:     []string{"GO_CDK_LOG_OUTPUT"},
 		Value:       "file",
-		Usage:       "logging output type: stdout, stderr or file",
+		Usage:       "logging output type: stdout, stderr, syslog or file",
 		DefaultText: "file",
 	}
 	AppCliLogLevelsFlag = &cli.BoolFlag{
@@ -108,7 +133,7 @@ func GetApplicationCliFlags() (flags []cli.Flag) {
 		flags = append(flags, AppCliProfileFlag, AppCliProfilePathFlag)
 	}
 	if Build.LogFile {
-		flags = append(flags, AppCliLogFileFlag)
+		flags = append(flags, AppCliLogFileFlag, AppCliLogFileMaxSizeFlag, AppCliLogFileMaxBackupsFlag, AppCliLogFileCompressFlag)
 	}
 	if Build.LogFormat {
 		flags = append(flags, AppCliLogFormatFlag)
@@ -133,3 +158,114 @@ func GetApplicationCliFlags() (flags []cli.Flag) {
 	}
 	return
 }
+
+// CliFlagNamespace prefixes the names of all the command line flags of cdk and
+// is reserved by default, see: RegisterCliFlagNamespace
+const CliFlagNamespace = "cdk-"
+
+var (
+	cliFlagNamespaces     = []string{CliFlagNamespace}
+	cliFlagNamespacesLock = &sync.RWMutex{}
+)
+
+// RegisterCliFlagNamespace reserves the prefix for the command line flags of a
+// library, Application.AddFlag refuses flags with names in any reserved
+// namespace so that applications cannot override the flags of the libraries
+// they use. Flags within the namespace are added with
+// Application.AddNamespacedFlag instead.
+func RegisterCliFlagNamespace(prefix string) (err error) {
+	if prefix == "" || strings.HasPrefix(prefix, "-") {
+		return fmt.Errorf("invalid cli flag namespace: %q", prefix)
+	}
+	cliFlagNamespacesLock.Lock()
+	defer cliFlagNamespacesLock.Unlock()
+	for _, ns := range cliFlagNamespaces {
+		if ns == prefix {
+			return nil
+		}
+		if strings.HasPrefix(ns, prefix) || strings.HasPrefix(prefix, ns) {
+			return fmt.Errorf("cli flag namespace %q overlaps %q", prefix, ns)
+		}
+	}
+	cliFlagNamespaces = append(cliFlagNamespaces, prefix)
+	return nil
+}
+
+// GetCliFlagNamespace returns the reserved namespace the flag name is within,
+// if any
+func GetCliFlagNamespace(name string) (prefix string, namespaced bool) {
+	cliFlagNamespacesLock.RLock()
+	defer cliFlagNamespacesLock.RUnlock()
+	for _, ns := range cliFlagNamespaces {
+		if strings.HasPrefix(name, ns) {
+			return ns, true
+		}
+	}
+	return "", false
+}
+
+// checkCliFlag returns an error if any name of the flag is used by any of the
+// flags given, including aliases, or by the help and version flags
+func checkCliFlag(flags []cli.Flag, flag cli.Flag) (err error) {
+	if flag == nil || len(flag.Names()) == 0 {
+		return fmt.Errorf("cli flag has no name")
+	}
+	used := make(map[string]bool)
+	for _, f := range append(flags, cli.HelpFlag, cli.VersionFlag) {
+		if f != nil {
+			for _, name := range f.Names() {
+				used[name] = true
+			}
+		}
+	}
+	for _, name := range flag.Names() {
+		if used[name] {
+			return fmt.Errorf("cli flag already defined: %v", name)
+		}
+	}
+	return nil
+}
+
+// FilterCliArgs returns the command line arguments without the flags which
+// the remove function returns true for, along with their values. Arguments
+// are parsed the same way the cli parses the flags given: flags are named
+// with one or two leading dashes, with values given after an equals sign or
+// as the following argument for flags which take a value. Parsing stops at
+// the first argument which is not a flag, or at a "--" terminator, and the
+// first argument, the program name, is always kept.
+func FilterCliArgs(args []string, flags []cli.Flag, remove func(name string) bool) (filtered []string) {
+	if len(args) == 0 {
+		return
+	}
+	takesValue := make(map[string]bool)
+	for _, f := range flags {
+		takes := true
+		if df, ok := f.(cli.DocGenerationFlag); ok {
+			takes = df.TakesValue()
+		}
+		for _, name := range f.Names() {
+			takesValue[name] = takes
+		}
+	}
+	filtered = append(filtered, args[0])
+	for idx := 1; idx < len(args); idx++ {
+		arg := args[idx]
+		if arg == "--" || len(arg) < 2 || arg[0] != '-' {
+			return append(filtered, args[idx:]...)
+		}
+		name := strings.TrimPrefix(arg[1:], "-")
+		hasValue := false
+		if i := strings.Index(name, "="); i >= 0 {
+			name, hasValue = name[:i], true
+		}
+		end := idx
+		if takes, known := takesValue[name]; known && takes && !hasValue && idx+1 < len(args) {
+			end = idx + 1
+		}
+		if !remove(name) {
+			filtered = append(filtered, args[idx:end+1]...)
+		}
+		idx = end
+	}
+	return
+}
@@ -0,0 +1,428 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/urfave/cli/v2"
+	"gopkg.in/yaml.v3"
+
+	"github.com/go-curses/cdk/env"
+	"github.com/go-curses/cdk/log"
+)
+
+// The tables of an application config document which are not flags
+const (
+	// ConfigProperties is the table of Application properties to set
+	ConfigProperties = "properties"
+	// ConfigDisplay is the table of Display properties to set
+	ConfigDisplay = "display"
+	// ConfigThemeFile is the path of a theme definition file to load into the
+	// Display, see: Display.LoadThemeFile
+	ConfigThemeFile = "theme-file"
+)
+
+// ConfigFileNames are the names of the config files searched for by
+// Application.LoadConfig when no paths are given
+var ConfigFileNames = []string{"config.toml", "config.yaml", "config.yml", "config.json"}
+
+// ConfigPaths returns the locations searched for the config file of the
+// application with the given tag, within $XDG_CONFIG_HOME (or ~/.config) and
+// then each of $XDG_CONFIG_DIRS (or /etc/xdg)
+func ConfigPaths(tag string) (paths []string) {
+	var dirs []string
+	if home := env.Get("XDG_CONFIG_HOME", ""); home != "" {
+		dirs = append(dirs, home)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".config"))
+	}
+	for _, dir := range strings.Split(env.Get("XDG_CONFIG_DIRS", "/etc/xdg"), string(os.PathListSeparator)) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, dir := range dirs {
+		for _, name := range ConfigFileNames {
+			paths = append(paths, filepath.Join(dir, tag, name))
+		}
+	}
+	return
+}
+
+// LoadConfig reads the config files at the given paths, each overlaying the
+// settings of the previous ones, or the first config file found within the
+// ConfigPaths of the application tag if no paths are given. Config files are
+// TOML, YAML or JSON documents, by file extension, with the following
+// (all optional) keys:
+//
+//	<flag name>   the value of any registered cli flag, cdk-log-level for
+//	              example, or the GO_CDK environment variable of a cdk flag
+//	              which was not included in the build
+//	theme-file    a theme definition file to load into the Display
+//	[properties]  properties of the Application
+//	[display]     properties of the Display
+//
+// Flag values from the config take the lowest precedence, after those given
+// by environment variables and then on the command line. The config is
+// applied each time the application is run and the files are read once again
+// by ReloadConfig.
+func (app *CApplication) LoadConfig(paths ...string) (err error) {
+	if len(paths) == 0 {
+		for _, path := range ConfigPaths(app.Tag()) {
+			if info, ee := os.Stat(path); ee == nil && !info.IsDir() {
+				paths = append(paths, path)
+				break
+			}
+		}
+	}
+	config := make(map[string]interface{})
+	for _, path := range paths {
+		var tree map[string]interface{}
+		if tree, err = readConfigFile(path); err != nil {
+			return
+		}
+		mergeConfig(config, tree)
+	}
+	app.Lock()
+	app.config = config
+	app.configPaths = paths
+	app.Unlock()
+	return
+}
+
+// ReloadConfig reads the config files given to LoadConfig once again, or
+// searches the ConfigPaths if none were, and applies the changes while the
+// application is running: the flags and properties set by the config are
+// updated, logging is restarted to pick up any cdk-log settings changed and
+// the theme files are loaded into each Display again. SignalReloadConfig is
+// then emitted by each Display and the Application. Settings given by the
+// environment or on the command line remain in effect. A running Display
+// calls ReloadConfig when the process receives a SIGHUP while the terminal is
+// still present, see: EventReload.
+func (app *CApplication) ReloadConfig() (err error) {
+	app.RLock()
+	paths := app.configPaths
+	app.RUnlock()
+	if err = app.LoadConfig(paths...); err != nil {
+		return
+	}
+	return app.reloadConfig(true)
+}
+
+// reloadConfig applies the config loaded to the running application, see:
+// ReloadConfig
+func (app *CApplication) reloadConfig(restartLog bool) (err error) {
+	app.RLock()
+	ctx := app.context
+	app.RUnlock()
+	err = app.applyConfig(ctx)
+	if restartLog {
+		if ctx != nil {
+			app.setLogEnv(ctx)
+		}
+		if ee := log.StartRestart(); ee != nil && err == nil {
+			err = ee
+		}
+	}
+	for _, display := range app.GetDisplays() {
+		d := display
+		reload := func(_ Display) error {
+			if ee := app.applyDisplayConfig(d); ee != nil {
+				d.LogErr(ee)
+			}
+			d.reloadThemeFiles()
+			d.Emit(SignalReloadConfig, d)
+			return nil
+		}
+		if d.IsRunning() {
+			_ = d.AsyncCall(reload)
+		} else {
+			_ = reload(d)
+		}
+	}
+	app.Emit(SignalReloadConfig, app.Self())
+	return
+}
+
+// inheritConfig replaces the config of the application with the config
+// loaded by the other
+func (app *CApplication) inheritConfig(other *CApplication) {
+	other.RLock()
+	config, paths := other.config, other.configPaths
+	other.RUnlock()
+	app.Lock()
+	app.config, app.configPaths = config, paths
+	app.Unlock()
+}
+
+// GetConfig returns the value of the key in the config loaded, if present
+func (app *CApplication) GetConfig(key string) (value interface{}, ok bool) {
+	app.RLock()
+	defer app.RUnlock()
+	value, ok = app.config[key]
+	return
+}
+
+// applyConfig sets the registered flags given in the config which are not set
+// by the environment or on the command line, and the GO_CDK environment
+// variables of cdk flags not included in the build
+func (app *CApplication) applyConfig(ctx *cli.Context) (err error) {
+	app.RLock()
+	config, flags := app.config, app.cli.Flags
+	configured := make(map[string]bool, len(app.configKeys))
+	for key := range app.configKeys {
+		configured[key] = true
+	}
+	app.RUnlock()
+	if len(config) == 0 {
+		return
+	}
+	defer func() {
+		app.Lock()
+		app.configKeys = configured
+		app.Unlock()
+	}()
+	keys := make([]string, 0, len(config))
+	for key := range config {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		switch key {
+		case ConfigProperties:
+			if tree, ok := config[key].(map[string]interface{}); ok {
+				if ee := app.setPropertyTree(tree); ee != nil && err == nil {
+					err = ee
+				}
+			} else if err == nil {
+				err = fmt.Errorf("config %v is not a table", key)
+			}
+			continue
+		case ConfigDisplay, ConfigThemeFile:
+			// see: applyDisplayConfig
+			continue
+		}
+		var set bool
+		var ee error
+		if flag := findCliFlag(flags, key); flag != nil {
+			if ctx == nil {
+				continue
+			}
+			set, ee = setConfigFlag(ctx, flag, key, config[key], configured[key])
+		} else if flag = findCliFlag(cdkCliFlags(), key); flag != nil {
+			set, ee = setConfigEnv(flag, config[key], configured[key])
+		} else {
+			app.LogWarn("unknown config key: %v", key)
+		}
+		if set {
+			configured[key] = true
+		}
+		if ee != nil && err == nil {
+			err = ee
+		}
+	}
+	return
+}
+
+// applyDisplayConfig sets the Display properties and loads the theme file
+// given in the config
+func (app *CApplication) applyDisplayConfig(d *CDisplay) (err error) {
+	app.RLock()
+	config := app.config
+	app.RUnlock()
+	if d == nil || len(config) == 0 {
+		return
+	}
+	if value, ok := config[ConfigThemeFile]; ok {
+		if path, ok := value.(string); ok && path != "" {
+			_, err = d.LoadThemeFile(path)
+		} else {
+			err = fmt.Errorf("config %v is not a path", ConfigThemeFile)
+		}
+	}
+	if value, ok := config[ConfigDisplay]; ok {
+		var ee error
+		if tree, ok := value.(map[string]interface{}); ok {
+			ee = d.setPropertyTree(tree)
+		} else {
+			ee = fmt.Errorf("config %v is not a table", ConfigDisplay)
+		}
+		if ee != nil && err == nil {
+			err = ee
+		}
+	}
+	return
+}
+
+func readConfigFile(path string) (tree map[string]interface{}, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		_, err = toml.Decode(string(data), &tree)
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".json":
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		err = decoder.Decode(&tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file format: %v", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%v: %v", path, err)
+	}
+	return
+}
+
+// mergeConfig overlays the other config onto the config, merging the tables
+// present in both
+func mergeConfig(config, other map[string]interface{}) {
+	for key, value := range other {
+		if table, ok := value.(map[string]interface{}); ok {
+			if existing, ok := config[key].(map[string]interface{}); ok {
+				mergeConfig(existing, table)
+				continue
+			}
+			merged := make(map[string]interface{})
+			mergeConfig(merged, table)
+			value = merged
+		}
+		config[key] = value
+	}
+}
+
+func findCliFlag(flags []cli.Flag, name string) cli.Flag {
+	for _, flag := range flags {
+		for _, n := range flag.Names() {
+			if n == name {
+				return flag
+			}
+		}
+	}
+	return nil
+}
+
+// cdkCliFlags returns all the flags of cdk, whether included in the build or
+// not, see: GetApplicationCliFlags
+func cdkCliFlags() []cli.Flag {
+	return []cli.Flag{
+		AppCliTtyFlag,
+		AppCliProfileFlag,
+		AppCliProfilePathFlag,
+		AppCliLogFileFlag,
+		AppCliLogFileMaxSizeFlag,
+		AppCliLogFileMaxBackupsFlag,
+		AppCliLogFileCompressFlag,
+		AppCliLogFormatFlag,
+		AppCliLogFullPathsFlag,
+		AppCliLogLevelFlag,
+		AppCliLogTimestampsFlag,
+		AppCliLogTimestampFormatFlag,
+		AppCliLogOutputFlag,
+	}
+}
+
+// setConfigFlag sets the flag to the value from the config, unless given by
+// the environment or on the command line (and not previously by the config)
+func setConfigFlag(ctx *cli.Context, flag cli.Flag, name string, value interface{}, configured bool) (set bool, err error) {
+	if ctx.IsSet(name) && !configured {
+		return
+	}
+	if values, ok := value.([]interface{}); ok {
+		if configured {
+			// slice flags append each value set, replace those set previously
+			// with the serialized form of the slice instead
+			if err = ctx.Set(name, serializeConfigSlice(flag, values)); err != nil {
+				return false, fmt.Errorf("config %v: %v", name, err)
+			}
+			return true, nil
+		}
+		for _, v := range values {
+			if err = ctx.Set(name, configString(v)); err != nil {
+				return false, fmt.Errorf("config %v: %v", name, err)
+			}
+		}
+		return true, nil
+	}
+	if err = ctx.Set(name, configString(value)); err != nil {
+		return false, fmt.Errorf("config %v: %v", name, err)
+	}
+	return true, nil
+}
+
+// setConfigEnv sets the environment variable of the flag to the value from
+// the config, unless already set (and not previously by the config)
+func setConfigEnv(flag cli.Flag, value interface{}, configured bool) (set bool, err error) {
+	var envVars []string
+	if ef, ok := flag.(interface{ GetEnvVars() []string }); ok {
+		envVars = ef.GetEnvVars()
+	}
+	if len(envVars) == 0 {
+		return false, fmt.Errorf("config %v is not supported in this build", flag.Names()[0])
+	}
+	if !configured {
+		for _, name := range envVars {
+			if _, present := os.LookupEnv(name); present {
+				return
+			}
+		}
+	}
+	env.Set(envVars[0], configString(value))
+	return true, nil
+}
+
+// serializeConfigSlice returns the values in the form which the slice flags of
+// cli parse as a replacement of their values, see: cli.StringSlice.Serialize
+func serializeConfigSlice(flag cli.Flag, values []interface{}) string {
+	prefix := strings.TrimSuffix(cli.NewStringSlice().Serialize(), "[]")
+	var data []byte
+	if _, ok := flag.(*cli.StringSliceFlag); ok {
+		items := make([]string, len(values))
+		for idx, v := range values {
+			items[idx] = configString(v)
+		}
+		data, _ = json.Marshal(items)
+	} else {
+		data, _ = json.Marshal(values)
+	}
+	return prefix + string(data)
+}
+
+func configString(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []interface{}:
+		parts := make([]string, len(v))
+		for idx, item := range v {
+			parts[idx] = configString(item)
+		}
+		return strings.Join(parts, ",")
+	}
+	return fmt.Sprint(value)
+}
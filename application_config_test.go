@@ -0,0 +1,149 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/cli/v2"
+
+	"github.com/go-curses/cdk/env"
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestApplicationConfig(t *testing.T) {
+	Convey("Application config files", t, func() {
+		dir := t.TempDir()
+		base := filepath.Join(dir, "config.toml")
+		So(os.WriteFile(base, []byte(`
+name = "config"
+port = 2200
+mode = "config"
+hosts = [
+	"one",
+	"two",
+]
+
+[properties]
+debug = true
+
+[display]
+display-user = "base"
+`), 0600), ShouldBeNil)
+		overlay := filepath.Join(dir, "override.yaml")
+		So(os.WriteFile(overlay, []byte(`
+port: &port 2222
+display:
+  display-name: >-
+    overlay
+`), 0600), ShouldBeNil)
+
+		app := NewApplication("AppName", "AppUsage", "AppDesc", "v0.0.0", "app-tag", "AppTitle", OffscreenTtyPath)
+		defer app.Destroy()
+		So(app.AddFlags([]cli.Flag{
+			&cli.StringFlag{Name: "name"},
+			&cli.IntFlag{Name: "port"},
+			&cli.StringFlag{Name: "mode", EnvVars: []string{"CDK_TEST_CONFIG_MODE"}},
+			&cli.StringSliceFlag{Name: "hosts"},
+		}), ShouldBeNil)
+		So(app.LoadConfig(filepath.Join(dir, "missing.toml")), ShouldNotBeNil)
+		So(app.LoadConfig(filepath.Join(dir, "config.ini")), ShouldNotBeNil)
+		So(app.LoadConfig(base, overlay), ShouldBeNil)
+		port, ok := app.GetConfig("port")
+		So(ok, ShouldBeTrue)
+		So(port, ShouldEqual, 2222)
+
+		env.Set("CDK_TEST_CONFIG_MODE", "env")
+		defer func() {
+			_ = os.Unsetenv("CDK_TEST_CONFIG_MODE")
+			env.Reload()
+		}()
+		var ctx *cli.Context
+		app.CLI().Action = func(c *cli.Context) error {
+			ctx = c
+			app.context = c
+			return app.applyConfig(c)
+		}
+		So(app.CLI().Run([]string{"app", "--name", "cli"}), ShouldBeNil)
+		So(ctx, ShouldNotBeNil)
+		// config < env < cli
+		So(ctx.String("name"), ShouldEqual, "cli")
+		So(ctx.String("mode"), ShouldEqual, "env")
+		So(ctx.Int("port"), ShouldEqual, 2222)
+		So(ctx.StringSlice("hosts"), ShouldResemble, []string{"one", "two"})
+		debug, _ := app.GetBoolProperty(PropertyDebug)
+		So(debug, ShouldBeTrue)
+
+		d := NewDisplay("config", OffscreenTtyPath)
+		defer d.Destroy()
+		So(app.applyDisplayConfig(d), ShouldBeNil)
+		user, _ := d.GetStringProperty(PropertyDisplayUser)
+		So(user, ShouldEqual, "base")
+		name, _ := d.GetStringProperty(PropertyDisplayName)
+		So(name, ShouldEqual, "overlay")
+
+		Convey("reloaded while running", func() {
+			So(os.WriteFile(base, []byte(`
+name = "reloaded"
+port = 2300
+mode = "reloaded"
+hosts = ["three"]
+
+[display]
+display-user = "reloaded"
+`), 0600), ShouldBeNil)
+			So(app.AddDisplay(d), ShouldBeNil)
+			defer func() { _ = app.RemoveDisplay(d) }()
+			var appReloaded, displayReloaded bool
+			app.Connect(SignalReloadConfig, "test-reload-config", func(_ []interface{}, _ ...interface{}) enums.EventFlag {
+				appReloaded = true
+				return enums.EVENT_PASS
+			})
+			d.Connect(SignalReloadConfig, "test-reload-config", func(_ []interface{}, _ ...interface{}) enums.EventFlag {
+				displayReloaded = true
+				return enums.EVENT_PASS
+			})
+			So(app.ReloadConfig(), ShouldBeNil)
+			So(appReloaded, ShouldBeTrue)
+			So(displayReloaded, ShouldBeTrue)
+			// set by the command line and environment, not the config
+			So(ctx.String("name"), ShouldEqual, "cli")
+			So(ctx.String("mode"), ShouldEqual, "env")
+			// set by the config
+			So(ctx.Int("port"), ShouldEqual, 2222) // still given by the overlay
+			So(ctx.StringSlice("hosts"), ShouldResemble, []string{"three"})
+			user, _ := d.GetStringProperty(PropertyDisplayUser)
+			So(user, ShouldEqual, "reloaded")
+		})
+
+		Convey("found in the XDG config home", func() {
+			So(os.MkdirAll(filepath.Join(dir, "app-tag"), 0700), ShouldBeNil)
+			So(os.WriteFile(filepath.Join(dir, "app-tag", "config.yml"), []byte("name: xdg\n"), 0600), ShouldBeNil)
+			previous := env.Get("XDG_CONFIG_HOME", "")
+			env.Set("XDG_CONFIG_HOME", dir)
+			defer env.Set("XDG_CONFIG_HOME", previous)
+			So(ConfigPaths("app-tag")[0], ShouldEqual, filepath.Join(dir, "app-tag", "config.toml"))
+			So(app.LoadConfig(), ShouldBeNil)
+			name, ok := app.GetConfig("name")
+			So(ok, ShouldBeTrue)
+			So(name, ShouldEqual, "xdg")
+			_, ok = app.GetConfig("port")
+			So(ok, ShouldBeFalse)
+		})
+	})
+}
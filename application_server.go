@@ -16,18 +16,23 @@ package cdk
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/crypto/ssh"
 
 	"github.com/go-curses/cdk/env"
+	cid "github.com/go-curses/cdk/id"
 	"github.com/go-curses/cdk/lib/enums"
 	"github.com/go-curses/cdk/lib/exec"
 	"github.com/go-curses/cdk/lib/sync"
@@ -37,8 +42,6 @@ import (
 	"github.com/go-curses/cdk/log"
 )
 
-// TODO: cleanup clients when server RequestQuit
-
 const TypeApplicationServer CTypeTag = "cdk-application-server"
 
 func init() {
@@ -51,15 +54,30 @@ type ApplicationServer interface {
 	Init() (already bool)
 	GetClients() (clients []uuid.UUID)
 	GetClient(id uuid.UUID) (*CApplicationServerClient, error)
+	DisconnectClient(id uuid.UUID, reason string) (err error)
+	BroadcastEvent(evt Event)
 	App() (app *CApplication)
 	Display() (display *CDisplay)
 	SetListenAddress(address string)
 	GetListenAddress() (address string)
 	SetListenPort(port int)
 	GetListenPort() (port int)
+	SetListenUnix(path string)
+	GetListenUnix() (path string)
+	SetSystemdSocket(enabled bool)
+	GetSystemdSocket() (enabled bool)
+	SetTelnetListen(address string)
+	GetTelnetListen() (address string)
+	SetClipboardBridge(enabled bool)
+	SetPolicy(policy ApplicationServerPolicy)
+	GetPolicy() (policy ApplicationServerPolicy)
+	GetClipboardBridge() (enabled bool)
+	SetRecording(path string, input bool)
+	GetRecording() (path string, input bool)
 	Stop() (err error)
 	Daemon() (err error)
 	Start() (err error)
+	ReloadConfig() (err error)
 	ClearAuthHandlers()
 	InstallAuthHandler(handler ServerAuthHandler) (err error)
 	UnInstallAuthHandler(handler ServerAuthHandler) (err error)
@@ -81,16 +99,31 @@ type CApplicationServer struct {
 
 	listenAddress string
 	listenPort    int
+	listenUnix    string
+	systemdSocket bool
+	telnetListen  string
 
-	app     *CApplication
-	display *CDisplay
+	app        *CApplication
+	display    *CDisplay
+	flags      []cli.Flag
+	clientArgs []string
 
 	handlers []ServerAuthHandler
+	fallback ServerAuthHandler
 	config   *ssh.ServerConfig
 	listener net.Listener
 	clients  map[uuid.UUID]*CApplicationServerClient
 
-	daemonize bool
+	telnetListener net.Listener
+
+	policy   ApplicationServerPolicy
+	attempts map[string][]time.Time
+	idleStop chan struct{}
+
+	daemonize       bool
+	clipboardBridge bool
+	recordPath      string
+	recordInput     bool
 }
 
 func NewApplicationServer(name, usage, description, version, tag, title string, clientInitFn SignalListenerFn, serverInitFn SignalListenerFn, privateKeyPath string) *CApplicationServer {
@@ -117,44 +150,77 @@ func (s *CApplicationServer) Init() (already bool) {
 	}
 	s.CObject.Init()
 	s.clients = make(map[uuid.UUID]*CApplicationServerClient)
+	s.attempts = make(map[string][]time.Time)
+	s.fallback = NewDefaultServerAuthHandler()
 	s.handlers = []ServerAuthHandler{
-		NewDefaultServerAuthHandler(),
+		s.fallback,
 	}
 	s.app = NewApplication(s.name, s.usage, s.description, s.version, s.tag, s.title, "/dev/tty")
 	s.app.Connect(SignalStartup, "application-server-startup--server", func(data []interface{}, argv ...interface{}) enums.EventFlag {
 		return s.serverInitFn(data, argv...)
 	})
+	s.app.Connect(SignalReloadConfig, ApplicationServerReloadConfigHandle, func(data []interface{}, argv ...interface{}) enums.EventFlag {
+		s.reloadClients()
+		return enums.EVENT_PASS
+	})
 	s.app.runFn = s.runner
 	s.display = s.app.display
-	s.App().AddFlag(&cli.BoolFlag{
-		Name:  "daemon",
-		Usage: "start a server daemon instead of a server terminal",
-		Value: false,
-	})
-	s.App().AddFlag(&cli.StringFlag{
-		Name:        "listen-address",
-		Usage:       "sets the address for the server to listen on",
-		Value:       s.listenAddress,
-		DefaultText: s.listenAddress,
-	})
-	s.App().AddFlag(&cli.IntFlag{
-		Name:        "listen-port",
-		Usage:       "sets the port for the server to listen on",
-		Value:       s.listenPort,
-		DefaultText: fmt.Sprintf("%d", s.listenPort),
-	})
-	s.App().AddFlag(&cli.StringFlag{
-		Name:        "id-rsa",
-		Usage:       "sets the path to the server id_rsa file",
-		Value:       s.privateKeyPath,
-		DefaultText: s.privateKeyPath,
-	})
+	s.flags = []cli.Flag{
+		&cli.BoolFlag{
+			Name:  "daemon",
+			Usage: "start a server daemon instead of a server terminal",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:        "listen-address",
+			Usage:       "sets the address for the server to listen on",
+			Value:       s.listenAddress,
+			DefaultText: s.listenAddress,
+		},
+		&cli.IntFlag{
+			Name:        "listen-port",
+			Usage:       "sets the port for the server to listen on",
+			Value:       s.listenPort,
+			DefaultText: fmt.Sprintf("%d", s.listenPort),
+		},
+		&cli.StringFlag{
+			Name:  "listen-unix",
+			Usage: "sets the path of a unix domain socket for the server to listen on, instead of the address and port",
+		},
+		&cli.StringFlag{
+			Name:  "telnet-listen",
+			Usage: "also accept unencrypted telnet clients on the given address, for example 127.0.0.1:2323",
+		},
+		&cli.BoolFlag{
+			Name:  "systemd-socket",
+			Usage: "accept connections on the socket passed by systemd socket activation",
+			Value: false,
+		},
+		&cli.StringFlag{
+			Name:        "id-rsa",
+			Usage:       "sets the path to the server id_rsa file",
+			Value:       s.privateKeyPath,
+			DefaultText: s.privateKeyPath,
+		},
+		&cli.StringFlag{
+			Name:  "record-sessions",
+			Usage: "record each client session as an asciicast v2 file, the path may include {app}, {id}, {user}, {remote} and {time}",
+		},
+		&cli.BoolFlag{
+			Name:  "record-input",
+			Usage: "include the input of clients in session recordings",
+			Value: false,
+		},
+	}
+	if err := s.App().AddFlags(s.flags); err != nil {
+		s.LogErr(err)
+	}
 	return false
 }
 
 func (s *CApplicationServer) newClient(conn *ssh.ServerConn, channels <-chan ssh.NewChannel, requests <-chan *ssh.Request) (asc *CApplicationServerClient, err error) {
-	if id, err := uuid.NewV4(); err != nil {
-		return nil, err
+	if id := cid.NewUUID(); id == uuid.Nil {
+		return nil, fmt.Errorf("failed to allocate client id")
 	} else {
 		s.Lock()
 		defer s.Unlock()
@@ -164,6 +230,7 @@ func (s *CApplicationServer) newClient(conn *ssh.ServerConn, channels <-chan ssh
 			channels:    channels,
 			requests:    requests,
 			application: nil,
+			connected:   time.Now(),
 		}
 		s.clients[id] = asc
 		return asc, nil
@@ -188,6 +255,71 @@ func (s *CApplicationServer) GetClient(id uuid.UUID) (*CApplicationServerClient,
 	return nil, fmt.Errorf("client not found: %v", id)
 }
 
+// DisconnectClient ends the session of the client and closes the connection,
+// SignalServerClientDisconnected is emitted with the given reason
+func (s *CApplicationServer) DisconnectClient(id uuid.UUID, reason string) (err error) {
+	var asc *CApplicationServerClient
+	if asc, err = s.GetClient(id); err != nil {
+		return
+	}
+	asc.Lock()
+	asc.reason = reason
+	asc.Unlock()
+	if display := asc.display(); display != nil && display.IsRunning() {
+		// the connection is closed once the session has shutdown
+		display.RequestQuit()
+		return
+	}
+	s.closeClient(asc, reason)
+	return
+}
+
+// BroadcastEvent posts the event to the display of each client session, for
+// example to notify all users of server maintenance. The same event is given
+// to every display.
+func (s *CApplicationServer) BroadcastEvent(evt Event) {
+	for _, id := range s.GetClients() {
+		if asc, err := s.GetClient(id); err == nil {
+			if display := asc.display(); display != nil && display.IsRunning() {
+				if err := display.PostEvent(evt); err != nil {
+					s.LogErr(err)
+				}
+			}
+		}
+	}
+}
+
+// disconnectClients disconnects all clients with the given reason
+func (s *CApplicationServer) disconnectClients(reason string) {
+	for _, id := range s.GetClients() {
+		if err := s.DisconnectClient(id, reason); err != nil {
+			s.LogErr(err)
+		}
+	}
+}
+
+// closeClient closes the connection of the client and emits
+// SignalServerClientDisconnected, once, with the reason given to
+// DisconnectClient or otherwise the reason given
+func (s *CApplicationServer) closeClient(asc *CApplicationServerClient, reason string) {
+	asc.closing.Do(func() {
+		asc.Lock()
+		if asc.reason != "" {
+			reason = asc.reason
+		}
+		asc.reason = reason
+		asc.Unlock()
+		if err := asc.conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			log.ErrorF("error closing ssh connection: %v", err)
+		}
+		if err := s.freeClient(asc.id); err != nil {
+			log.ErrorF("error freeing app client: %v", err)
+		}
+		log.InfoF("SSH connection closed %s: %v", asc.String(), reason)
+		s.Emit(SignalServerClientDisconnected, s, asc, reason)
+	})
+}
+
 func (s *CApplicationServer) freeClient(id uuid.UUID) (err error) {
 	s.Lock()
 	defer s.Unlock()
@@ -238,6 +370,25 @@ func (s *CApplicationServer) GetListenPort() (port int) {
 	return
 }
 
+// SetClipboardBridge enables or disables the clipboard bridge with the SSH
+// client terminals. When enabled, each client display asks the client
+// terminal for its clipboard content on startup, using OSC 52, and tracks
+// whether the terminal responded, see: Screen.TermClipboardReadable. The
+// bridge reads the clipboard of the client into the application and is
+// therefore disabled by default, servers must opt in.
+func (s *CApplicationServer) SetClipboardBridge(enabled bool) {
+	s.Lock()
+	defer s.Unlock()
+	s.clipboardBridge = enabled
+}
+
+// GetClipboardBridge returns true if the clipboard bridge is enabled
+func (s *CApplicationServer) GetClipboardBridge() (enabled bool) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.clipboardBridge
+}
+
 func (s *CApplicationServer) Stop() (err error) {
 	s.Lock()
 	s.daemonize = false
@@ -282,11 +433,25 @@ func (s *CApplicationServer) ClearAuthHandlers() {
 	}
 	s.Lock()
 	s.handlers = make([]ServerAuthHandler, 0)
+	s.fallback = nil
 	s.Unlock()
 	return
 }
 
+// InstallAuthHandler adds the handler to those consulted, in the order
+// installed, when authenticating clients. The default handler, which accepts
+// any password, is only installed until the first call to InstallAuthHandler
+// and is removed by it.
 func (s *CApplicationServer) InstallAuthHandler(handler ServerAuthHandler) (err error) {
+	s.Lock()
+	fallback := s.fallback
+	s.fallback = nil
+	s.Unlock()
+	if fallback != nil {
+		if err = s.UnInstallAuthHandler(fallback); err != nil {
+			return
+		}
+	}
 	s.Lock()
 	s.handlers = append(s.handlers, handler)
 	s.Unlock()
@@ -313,13 +478,24 @@ func (s *CApplicationServer) UnInstallAuthHandler(handler ServerAuthHandler) (er
 	return
 }
 
+// isServerFlag returns true if the name is one of the command line flags of
+// the server
+func (s *CApplicationServer) isServerFlag(name string) bool {
+	for _, flag := range s.flags {
+		for _, n := range flag.Names() {
+			if n == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func (s *CApplicationServer) handlerHasArg(arg string) (has bool) {
 	s.RLock()
 	handlers := s.handlers
 	s.RUnlock()
-	if arg[:2] == "--" {
-		arg = arg[2:]
-	}
+	arg = strings.TrimLeft(arg, "-")
 	for _, h := range handlers {
 		if h.HasArgument(arg) {
 			has = true
@@ -336,43 +512,40 @@ func (s *CApplicationServer) runner(ctx *cli.Context) (err error) {
 	s.privateKeyPath = ctx.String("id-rsa")
 	s.listenAddress = ctx.String("listen-address")
 	s.listenPort = ctx.Int("listen-port")
-
-	var args []string
-	for _, arg := range os.Args {
-		switch arg {
-		case "--daemon":
-		case "--listen-address":
-		case "--listen-port":
-		case "--id-rsa":
-		default:
-			if !s.handlerHasArg(arg) {
-				args = append(args, arg)
-			}
-		}
+	if ctx.IsSet("listen-unix") {
+		s.listenUnix = ctx.String("listen-unix")
 	}
-	os.Args = args
-
-	var handler ServerAuthHandler
-	if len(s.handlers) > 0 {
-		handler = s.handlers[0]
-		for _, handler := range s.handlers {
-			if err := handler.Reload(ctx); err != nil {
-				log.Error(err)
-			}
-		}
+	if ctx.IsSet("systemd-socket") {
+		s.systemdSocket = ctx.Bool("systemd-socket")
+	}
+	if ctx.IsSet("telnet-listen") {
+		s.telnetListen = ctx.String("telnet-listen")
+	}
+	if ctx.IsSet("record-sessions") {
+		s.recordPath = ctx.String("record-sessions")
+	}
+	if ctx.IsSet("record-input") {
+		s.recordInput = ctx.Bool("record-input")
 	}
 
-	s.config = nil
-	if handler != nil {
-		if passwordHandler, ok := handler.(ServerAuthPasswordHandler); ok {
-			s.config = &ssh.ServerConfig{
-				PasswordCallback: passwordHandler.PasswordCallback,
-			}
+	// clients run with the arguments of the server, without those of the
+	// server itself
+	clientArgs := FilterCliArgs(os.Args, s.app.CLI().Flags, func(name string) bool {
+		return s.isServerFlag(name) || s.handlerHasArg(name)
+	})
+	s.Lock()
+	s.clientArgs = clientArgs
+	s.Unlock()
+
+	s.RLock()
+	handlers := s.handlers
+	s.RUnlock()
+	for _, handler := range handlers {
+		if err := handler.Reload(ctx); err != nil {
+			log.Error(err)
 		}
 	}
-	if s.config == nil {
-		s.config = &ssh.ServerConfig{}
-	}
+	s.config = s.newServerConfig()
 
 	var privateBytes []byte
 	privateBytes, err = ioutil.ReadFile(s.privateKeyPath)
@@ -386,36 +559,47 @@ func (s *CApplicationServer) runner(ctx *cli.Context) (err error) {
 	}
 	s.config.AddHostKey(private)
 
-	s.listener, err = net.Listen("tcp", fmt.Sprintf("%s:%d", s.listenAddress, s.listenPort))
-	if err != nil {
-		return fmt.Errorf("failed to listen on %s:%d (%v)", s.listenAddress, s.listenPort, err)
+	if s.listener, err = s.listen(); err != nil {
+		return
+	}
+	if err = s.listenTelnet(); err != nil {
+		_ = s.listener.Close()
+		return
 	}
 
 	done := make(chan bool, 1)
 
+	s.Lock()
+	s.idleStop = make(chan struct{})
+	idleStop := s.idleStop
+	s.Unlock()
+	Go(func() { s.idleWorker(idleStop) })
+
 	s.app.Display().Connect(SignalDisplayShutdown, "application-server-display-shutdown-handler", func(data []interface{}, argv ...interface{}) enums.EventFlag {
 		s.LogInfo("display shutting down")
-		for _, client := range s.clients {
-			if client.application != nil {
-				if display := client.application.Display(); display != nil {
-					s.LogInfo("shutting down client: %v", client.id)
-					display.RequestQuit()
-				}
-			}
-		}
+		s.shutdown()
 		return enums.EVENT_PASS
 	})
 
 	// Accept all connections
 	Go(func() {
-		log.InfoF("Listening on %s:%d", s.listenAddress, s.listenPort)
+		log.InfoF("Listening on %s", s.listener.Addr())
 	runnerListenerLoop:
 		for {
 			tcpConn, err := s.listener.Accept()
 			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					log.DebugF("listener closed, breaking runner listener loop")
+					break runnerListenerLoop
+				}
 				log.ErrorF("Failed to accept incoming connection (%s)", err)
 				continue
 			}
+			if reason := s.admitConnection(tcpConn.RemoteAddr(), time.Now()); reason != "" {
+				_ = tcpConn.Close()
+				s.rejectConnection(tcpConn.RemoteAddr(), reason)
+				continue
+			}
 			// Before use, a handshake must be performed on the incoming net.Conn.
 			var conn *ssh.ServerConn
 			var channels <-chan ssh.NewChannel
@@ -425,12 +609,18 @@ func (s *CApplicationServer) runner(ctx *cli.Context) (err error) {
 				log.ErrorF("Failed to handshake (%s)", err)
 				continue
 			}
+			if reason := s.admitUser(conn.User()); reason != "" {
+				_ = conn.Close()
+				s.rejectConnection(conn.RemoteAddr(), reason)
+				continue
+			}
 			var asc *CApplicationServerClient
 			if asc, err = s.newClient(conn, channels, requests); err != nil {
 				log.Error(err)
 				continue
 			}
 			log.InfoF("New SSH connection from %s (%s)", asc.String(), asc.conn.ClientVersion())
+			s.Emit(SignalServerClientConnected, s, asc)
 			// Discard all global out-of-band Requests
 			// go ssh.DiscardRequests(requests)
 			// Accept all channels
@@ -447,12 +637,21 @@ func (s *CApplicationServer) runner(ctx *cli.Context) (err error) {
 
 	if s.daemonize {
 		sig := make(chan os.Signal, 1)
-		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM)
-		select {
-		case rx := <-sig:
+		signal.Notify(sig, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
+		for rx := range sig {
+			if rx == syscall.SIGHUP {
+				log.InfoF("daemon caught signal: %v, reloading config", rx)
+				if err := s.ReloadConfig(); err != nil {
+					s.LogErr(err)
+				}
+				continue
+			}
 			log.InfoF("daemon caught signal: %v", rx)
-			done <- true
+			break
 		}
+		signal.Stop(sig)
+		done <- true
+		s.shutdown()
 		log.DebugF("daemon exiting")
 		return
 	}
@@ -484,11 +683,146 @@ func (s *CApplicationServer) runner(ctx *cli.Context) (err error) {
 	return err
 }
 
+// ReloadConfig reloads the config of the server application and then of each
+// client session, without disconnecting any clients. A daemon calls
+// ReloadConfig when the process receives a SIGHUP. SignalReloadConfig is
+// emitted with the server once the client sessions are updated, see:
+// Application.ReloadConfig
+func (s *CApplicationServer) ReloadConfig() (err error) {
+	return s.app.ReloadConfig()
+}
+
+// reloadClients applies the config reloaded by the server application to each
+// client session
+func (s *CApplicationServer) reloadClients() {
+	s.RLock()
+	clients := make([]*CApplicationServerClient, 0, len(s.clients))
+	for _, asc := range s.clients {
+		clients = append(clients, asc)
+	}
+	s.RUnlock()
+	for _, asc := range clients {
+		if app, ok := asc.Application().(*CApplication); ok && app != nil {
+			app.inheritConfig(s.app)
+			if err := app.reloadConfig(false); err != nil {
+				app.LogErr(err)
+			}
+		}
+	}
+	s.Emit(SignalReloadConfig, s)
+}
+
+// shutdown stops accepting connections and disconnects all clients
+func (s *CApplicationServer) shutdown() {
+	s.Lock()
+	listener, telnetListener := s.listener, s.telnetListener
+	if s.idleStop != nil {
+		close(s.idleStop)
+		s.idleStop = nil
+	}
+	s.Unlock()
+	for _, l := range []net.Listener{listener, telnetListener} {
+		if l != nil {
+			if err := l.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+				s.LogErr(err)
+			}
+		}
+	}
+	s.disconnectClients("server shutting down")
+}
+
+// newServerConfig returns the ssh.ServerConfig for the installed handlers. Each
+// authentication method supported by any of the handlers is enabled and the
+// handlers supporting the method are consulted in the order installed, until
+// one of them accepts the client.
+func (s *CApplicationServer) newServerConfig() (config *ssh.ServerConfig) {
+	s.RLock()
+	handlers := append([]ServerAuthHandler{}, s.handlers...)
+	s.RUnlock()
+	var passwords []ServerAuthPasswordHandler
+	var publicKeys []ServerAuthPublicKeyHandler
+	var interactives []ServerAuthKeyboardInteractiveHandler
+	for _, handler := range handlers {
+		if h, ok := handler.(ServerAuthPasswordHandler); ok {
+			passwords = append(passwords, h)
+		}
+		if h, ok := handler.(ServerAuthPublicKeyHandler); ok {
+			publicKeys = append(publicKeys, h)
+		}
+		if h, ok := handler.(ServerAuthKeyboardInteractiveHandler); ok {
+			interactives = append(interactives, h)
+		}
+	}
+	config = &ssh.ServerConfig{
+		AuthLogCallback: func(conn ssh.ConnMetadata, method string, err error) {
+			if method == "none" {
+				// clients start by asking which methods are supported
+				return
+			}
+			if err == nil {
+				s.Emit(SignalServerAuthSucceeded, s, conn, method)
+			} else {
+				s.Emit(SignalServerAuthFailed, s, conn, method, err)
+			}
+		},
+	}
+	if len(passwords) > 0 {
+		config.PasswordCallback = func(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+			return serverAuthChain(len(passwords), "password", func(i int) (*ssh.Permissions, error) {
+				return passwords[i].PasswordCallback(conn, password)
+			})
+		}
+	}
+	if len(publicKeys) > 0 {
+		config.PublicKeyCallback = func(conn ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+			permissions, err := serverAuthChain(len(publicKeys), "publickey", func(i int) (*ssh.Permissions, error) {
+				return publicKeys[i].PublicKeyCallback(conn, key)
+			})
+			if err == nil {
+				permissions.Extensions[ServerAuthExtensionFingerprint] = ssh.FingerprintSHA256(key)
+			}
+			return permissions, err
+		}
+	}
+	if len(interactives) > 0 {
+		config.KeyboardInteractiveCallback = func(conn ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+			return serverAuthChain(len(interactives), "keyboard-interactive", func(i int) (*ssh.Permissions, error) {
+				return interactives[i].KeyboardInteractiveCallback(conn, client)
+			})
+		}
+	}
+	return
+}
+
+// serverAuthChain calls each of the count callbacks in turn until one of them
+// succeeds, returning the permissions with the method recorded in the
+// ServerAuthExtensionMethod extension, or the error of the last callback
+func serverAuthChain(count int, method string, callback func(i int) (*ssh.Permissions, error)) (permissions *ssh.Permissions, err error) {
+	for i := 0; i < count; i++ {
+		if permissions, err = callback(i); err == nil {
+			if permissions == nil {
+				permissions = &ssh.Permissions{}
+			}
+			if permissions.Extensions == nil {
+				permissions.Extensions = make(map[string]string)
+			}
+			permissions.Extensions[ServerAuthExtensionMethod] = method
+			return
+		}
+	}
+	return nil, err
+}
+
 func (s *CApplicationServer) handleChannels(asc *CApplicationServerClient) {
 	// Service the incoming channel in goroutine
 	for newChannel := range asc.channels {
 		Go(func() { s.handleChannel(asc, newChannel) })
 	}
+	// the connection is gone, end any session still running
+	if display := asc.display(); display != nil && display.IsRunning() {
+		display.RequestQuit()
+	}
+	s.closeClient(asc, "connection closed")
 }
 
 func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channel ssh.NewChannel) {
@@ -508,6 +842,27 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 		s.LogError("Could not accept channel (%s)", err)
 		return
 	}
+	s.handleSession(asc, connection, requests)
+}
+
+// sessionChannel is the connection of a client session, an ssh.Channel for
+// SSH clients
+type sessionChannel interface {
+	io.ReadWriter
+	SendRequest(name string, wantReply bool, payload []byte) (bool, error)
+	Close() error
+}
+
+// handleSession runs a new application for the client session on the given
+// connection, with the out-of-band requests such as "pty-req" and "shell"
+// given on the requests channel
+func (s *CApplicationServer) handleSession(asc *CApplicationServerClient, connection sessionChannel, requests <-chan *ssh.Request) {
+	var err error
+	var device io.ReadWriter = connection
+	recorder := s.newSessionRecorder(asc)
+	if recorder != nil {
+		device = &cRecordingDevice{ReadWriter: connection, recorder: recorder}
+	}
 
 	app := NewApplication(
 		s.name,
@@ -518,7 +873,8 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 		s.title,
 		"",
 	)
-	asc.application = app
+	app.inheritConfig(s.app)
+	asc.setApplication(app)
 	app.Connect(SignalStartup, "application-server-startup--client", func(data []interface{}, argv ...interface{}) enums.EventFlag {
 		return s.clientInitFn(data, argv...)
 	})
@@ -531,7 +887,7 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 	once := &sync.Once{}
 
 	if cancel, resize, wg, err = exec.Spawn(
-		connection,
+		device,
 		func(in, out *os.File) (err error) {
 			display = NewDisplayWithHandle("display-service", out)
 			display.app = app
@@ -541,6 +897,15 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 			_ = display.SetStringProperty(PropertyDisplayName, displayname)
 			_ = display.SetStringProperty(PropertyDisplayUser, username)
 			_ = display.SetStringProperty(PropertyDisplayHost, asc.conn.RemoteAddr().String())
+			extensions := make(map[string]string)
+			if asc.conn.Permissions != nil {
+				for k, v := range asc.conn.Permissions.Extensions {
+					extensions[k] = v
+				}
+			}
+			_ = display.SetStringProperty(PropertyDisplayAuthMethod, extensions[ServerAuthExtensionMethod])
+			_ = display.SetStringProperty(PropertyDisplayAuthFingerprint, extensions[ServerAuthExtensionFingerprint])
+			_ = display.SetStructProperty(PropertyDisplayAuthExtensions, extensions)
 			valid = true
 			return
 		},
@@ -559,17 +924,20 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 			if err := connection.Close(); err != nil {
 				log.ErrorF("error closing ssh channel: %v", err)
 			}
-			if err := asc.conn.Close(); err != nil {
-				log.ErrorF("error closing ssh connection: %v", err)
-			}
-			if err := s.freeClient(asc.id); err != nil {
-				log.ErrorF("error freeing app client: %v", err)
+			if recorder != nil {
+				if err := recorder.close(); err != nil {
+					log.ErrorF("error closing session recording: %v", err)
+				}
 			}
+			s.closeClient(asc, "session ended")
 			log.DebugF("Session closed")
 			return
 		},
 	); err != nil {
 		s.LogErr(err)
+		if recorder != nil {
+			_ = recorder.close()
+		}
 		return
 	}
 
@@ -581,8 +949,10 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 		return enums.EVENT_PASS
 	})
 
-	// start display service
-	Go(func() {
+	// start display service, once the client has requested a shell so that the
+	// terminal type and environment of the client are known to the Display
+	startOnce := &sync.Once{}
+	start := func() {
 		GoWithMainContext(
 			asc.conn.User(),
 			asc.conn.RemoteAddr().String(),
@@ -596,22 +966,34 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 				}
 				display.Connect(SignalDisplayStartup, "application-signal-display-startup-handler", func(data []interface{}, argv ...interface{}) enums.EventFlag {
 					if ctx, dcancel, wg, ok := DisplaySignalDisplayStartupArgv(argv...); ok {
+						if s.GetClipboardBridge() {
+							if !display.GetClipboard().Request() {
+								display.LogDebug("client terminal clipboard not requested")
+							}
+						}
 						if f := app.Emit(SignalStartup, app.Self(), display, ctx, dcancel, wg); f == enums.EVENT_STOP {
 							app.LogInfo("application startup signal listener requested EVENT_STOP")
 							display.RequestQuit()
 							return enums.EVENT_STOP
 						}
+						s.Emit(SignalServerSessionStarted, s, asc, display)
 						return enums.EVENT_PASS
 					}
 					return enums.EVENT_STOP
 				})
-				if err := app.cli.Run(os.Args); err != nil {
+				s.RLock()
+				clientArgs := s.clientArgs
+				s.RUnlock()
+				if clientArgs == nil {
+					clientArgs = os.Args
+				}
+				if err := app.cli.Run(clientArgs); err != nil {
 					log.Error(err)
 				}
 				once.Do(cancel)
 			},
 		)
-	})
+	}
 
 	// Sessions have out-of-band requests such as "shell", "pty-req" and "env"
 	Go(func() {
@@ -621,6 +1003,7 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 				cmd := cterm.ParseValue(req.Payload)
 				log.DebugF("! out-of-band request: exec = %v", cmd)
 				_ = req.Reply(true, nil)
+				startOnce.Do(func() { Go(start) })
 			case "shell":
 				// only accept the default shell...
 				// (i.e. no command in the Payload)
@@ -631,19 +1014,32 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 				// accepting any shell, just not using the payload...
 				log.DebugF("! out-of-band request: shell = %v", req.Payload)
 				_ = req.Reply(true, nil)
+				startOnce.Do(func() { Go(start) })
 			case "pty-req":
 				termLen := req.Payload[3]
+				if name := cterm.ParseValue(req.Payload); name != "" {
+					display.Setenv("TERM", name)
+					if recorder != nil {
+						recorder.setTerm(name)
+					}
+				}
 				w, h := cterm.ParseDims(req.Payload[termLen+4:])
+				if recorder != nil {
+					recorder.resize(int(w), int(h))
+				}
 				if err := resize(w, h); err != nil {
 					log.Error(err)
 				}
 				_ = display.PostEvent(NewEventResize(int(w), int(h)))
 				// Responding true (OK) here will let the client
 				// know we have a pty ready for input
-				log.DebugF("! pty-req: w:%d, h:%d", w, h)
+				log.DebugF("! pty-req: term:%v, w:%d, h:%d", display.Getenv("TERM"), w, h)
 				_ = req.Reply(true, nil)
 			case "window-change":
 				w, h := cterm.ParseDims(req.Payload)
+				if recorder != nil {
+					recorder.resize(int(w), int(h))
+				}
 				if err := resize(w, h); err != nil {
 					log.Error(err)
 				}
@@ -654,13 +1050,78 @@ func (s *CApplicationServer) handleChannel(asc *CApplicationServerClient, channe
 			case "env":
 				k, v := cterm.ParseKeyValue(req.Payload)
 				log.DebugF("! env: %s => \"%s\"", k, v)
+				display.Setenv(k, v)
 				_ = req.Reply(true, nil)
 			default:
 				log.DebugF("! out-of-band request: %v - %v", req.Type, req.Payload)
 				_ = req.Reply(true, nil)
 			}
 		}
+		// the client went away without requesting a shell
+		startOnce.Do(func() {
+			wg.Done()
+			once.Do(cancel)
+		})
 	})
 }
 
 const ApplicationServerDisplayStartupHandle = "application-server-display-startup-handler"
+
+const ApplicationServerReloadConfigHandle = "application-server-reload-config-handler"
+
+const (
+	// SignalServerClientConnected is emitted with the server and the
+	// *CApplicationServerClient once a client has connected and authenticated
+	SignalServerClientConnected Signal = "server-client-connected"
+	// SignalServerAuthSucceeded is emitted with the server, the
+	// ssh.ConnMetadata and the name of the authentication method accepted
+	SignalServerAuthSucceeded Signal = "server-auth-succeeded"
+	// SignalServerAuthFailed is emitted with the server, the
+	// ssh.ConnMetadata, the name of the authentication method rejected and the
+	// error of the rejection
+	SignalServerAuthFailed Signal = "server-auth-failed"
+	// SignalServerSessionStarted is emitted with the server, the
+	// *CApplicationServerClient and the *CDisplay of the client session once
+	// the session application has started
+	SignalServerSessionStarted Signal = "server-session-started"
+	// SignalServerClientDisconnected is emitted with the server, the
+	// *CApplicationServerClient and the reason for the disconnection once the
+	// client connection is closed
+	SignalServerClientDisconnected Signal = "server-client-disconnected"
+	// SignalServerClientRejected is emitted with the server, the remote
+	// address and the reason when a connection is refused by the policy of
+	// the server, see: ApplicationServerPolicy
+	SignalServerClientRejected Signal = "server-client-rejected"
+	// SignalServerClientIdle is emitted with the server, the
+	// *CApplicationServerClient and the time remaining before the client is
+	// disconnected, once the client nears the IdleTimeout of the server policy
+	SignalServerClientIdle Signal = "server-client-idle"
+)
+
+// ApplicationServerSignalClientArgv returns the client given to the
+// SignalServerClientConnected, SignalServerSessionStarted,
+// SignalServerClientDisconnected and SignalServerClientIdle listeners
+func ApplicationServerSignalClientArgv(argv ...interface{}) (client *CApplicationServerClient, ok bool) {
+	if len(argv) >= 2 {
+		client, ok = argv[1].(*CApplicationServerClient)
+	}
+	return
+}
+
+// ApplicationServerSignalAuthArgv returns the arguments given to the
+// SignalServerAuthSucceeded and SignalServerAuthFailed listeners, err is nil
+// for SignalServerAuthSucceeded
+func ApplicationServerSignalAuthArgv(argv ...interface{}) (conn ssh.ConnMetadata, method string, err error, ok bool) {
+	if len(argv) >= 3 {
+		if conn, ok = argv[1].(ssh.ConnMetadata); ok {
+			if method, ok = argv[2].(string); ok {
+				if len(argv) >= 4 {
+					err, _ = argv[3].(error)
+				}
+				return
+			}
+			conn = nil
+		}
+	}
+	return
+}
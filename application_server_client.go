@@ -16,9 +16,12 @@ package cdk
 
 import (
 	"fmt"
+	"time"
 
 	"github.com/gofrs/uuid"
 	"golang.org/x/crypto/ssh"
+
+	"github.com/go-curses/cdk/lib/sync"
 )
 
 type CApplicationServerClient struct {
@@ -27,8 +30,71 @@ type CApplicationServerClient struct {
 	channels    <-chan ssh.NewChannel
 	requests    <-chan *ssh.Request
 	application Application
+	connected   time.Time
+	reason      string
+	idleWarned  bool
+	recording   string
+	closing     sync.Once
+
+	sync.RWMutex
 }
 
 func (asc *CApplicationServerClient) String() string {
 	return fmt.Sprintf("%s@%s", asc.conn.User(), asc.conn.RemoteAddr().String())
 }
+
+// ID returns the unique identifier of the client connection
+func (asc *CApplicationServerClient) ID() uuid.UUID {
+	return asc.id
+}
+
+// User returns the user name the client authenticated as
+func (asc *CApplicationServerClient) User() string {
+	return asc.conn.User()
+}
+
+// RemoteAddr returns the network address of the client
+func (asc *CApplicationServerClient) RemoteAddr() string {
+	return asc.conn.RemoteAddr().String()
+}
+
+// Permissions returns the permissions given by the authentication handler
+// which accepted the client, see: ServerAuthExtensionMethod
+func (asc *CApplicationServerClient) Permissions() *ssh.Permissions {
+	return asc.conn.Permissions
+}
+
+// Connected returns the time the client connected
+func (asc *CApplicationServerClient) Connected() time.Time {
+	return asc.connected
+}
+
+// Recording returns the path of the asciicast recording of the client
+// session, empty if the session is not recorded, see: SetRecording
+func (asc *CApplicationServerClient) Recording() string {
+	asc.RLock()
+	defer asc.RUnlock()
+	return asc.recording
+}
+
+// Application returns the application of the client session, nil if no
+// session has been started yet
+func (asc *CApplicationServerClient) Application() Application {
+	asc.RLock()
+	defer asc.RUnlock()
+	return asc.application
+}
+
+func (asc *CApplicationServerClient) setApplication(app Application) {
+	asc.Lock()
+	asc.application = app
+	asc.Unlock()
+}
+
+// display returns the display of the client session, if running
+func (asc *CApplicationServerClient) display() (display *CDisplay) {
+	if app := asc.Application(); app != nil {
+		display = app.Display()
+	}
+	return
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+
+	"github.com/go-curses/cdk/log"
+)
+
+// systemdListenFdsStart is the first file descriptor passed by systemd socket
+// activation, see: sd_listen_fds(3)
+var systemdListenFdsStart = 3
+
+// SetListenUnix changes the server to listen on a unix domain socket at the
+// given path instead of the listen address and port. Any stale socket left at
+// the path is removed. An empty path restores listening on TCP.
+func (s *CApplicationServer) SetListenUnix(path string) {
+	s.Lock()
+	s.listenUnix = path
+	s.Unlock()
+}
+
+// GetListenUnix returns the path of the unix domain socket the server listens
+// on, empty when listening on TCP
+func (s *CApplicationServer) GetListenUnix() (path string) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.listenUnix
+}
+
+// SetSystemdSocket changes the server to accept connections on the socket
+// passed by systemd socket activation (LISTEN_FDS) instead of opening its own,
+// taking precedence over SetListenUnix and the listen address and port
+func (s *CApplicationServer) SetSystemdSocket(enabled bool) {
+	s.Lock()
+	s.systemdSocket = enabled
+	s.Unlock()
+}
+
+// GetSystemdSocket returns true if the server uses systemd socket activation
+func (s *CApplicationServer) GetSystemdSocket() (enabled bool) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.systemdSocket
+}
+
+// listen opens the listener for the server, see: SetSystemdSocket and
+// SetListenUnix
+func (s *CApplicationServer) listen() (listener net.Listener, err error) {
+	s.RLock()
+	systemd, path := s.systemdSocket, s.listenUnix
+	address := fmt.Sprintf("%s:%d", s.listenAddress, s.listenPort)
+	s.RUnlock()
+	switch {
+	case systemd:
+		var listeners []net.Listener
+		if listeners, err = systemdListeners(); err != nil {
+			return nil, fmt.Errorf("failed to use systemd socket (%v)", err)
+		}
+		for _, extra := range listeners[1:] {
+			log.WarnF("ignoring additional systemd socket: %v", extra.Addr())
+			_ = extra.Close()
+		}
+		return listeners[0], nil
+	case path != "":
+		if info, err := os.Stat(path); err == nil && info.Mode()&os.ModeSocket != 0 {
+			if c, err := net.Dial("unix", path); err == nil {
+				_ = c.Close()
+				return nil, fmt.Errorf("failed to listen on %s (socket in use)", path)
+			}
+			// stale socket from a previous server
+			_ = os.Remove(path)
+		}
+		if listener, err = net.Listen("unix", path); err != nil {
+			return nil, fmt.Errorf("failed to listen on %s (%v)", path, err)
+		}
+		return
+	}
+	if listener, err = net.Listen("tcp", address); err != nil {
+		return nil, fmt.Errorf("failed to listen on %s (%v)", address, err)
+	}
+	return
+}
+
+// systemdListeners returns the listeners passed by systemd socket activation,
+// removing the LISTEN_ variables from the environment so that they are not
+// inherited by child processes
+func systemdListeners() (listeners []net.Listener, err error) {
+	defer func() {
+		_ = os.Unsetenv("LISTEN_PID")
+		_ = os.Unsetenv("LISTEN_FDS")
+		_ = os.Unsetenv("LISTEN_FDNAMES")
+	}()
+	if pid := os.Getenv("LISTEN_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return nil, fmt.Errorf("sockets were passed to process %v", pid)
+	}
+	count, _ := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if count <= 0 {
+		return nil, fmt.Errorf("no sockets passed, LISTEN_FDS is not set")
+	}
+	for fd := systemdListenFdsStart; fd < systemdListenFdsStart+count; fd++ {
+		file := os.NewFile(uintptr(fd), fmt.Sprintf("systemd-socket-%d", fd))
+		listener, ee := net.FileListener(file)
+		_ = file.Close()
+		if ee != nil {
+			for _, l := range listeners {
+				_ = l.Close()
+			}
+			return nil, fmt.Errorf("socket %d: %v", fd, ee)
+		}
+		listeners = append(listeners, listener)
+	}
+	return
+}
@@ -0,0 +1,98 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris zos
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestApplicationServerListen(t *testing.T) {
+	Convey("Server listeners", t, func() {
+		s := NewApplicationServer("test", "usage", "description", "0.0.1", "test", "Test", nil, nil, "")
+		So(s.GetListenUnix(), ShouldEqual, "")
+		So(s.GetSystemdSocket(), ShouldBeFalse)
+		Convey("tcp", func() {
+			s.SetListenAddress("127.0.0.1")
+			s.SetListenPort(0)
+			listener, err := s.listen()
+			So(err, ShouldBeNil)
+			So(listener.Addr().Network(), ShouldEqual, "tcp")
+			So(listener.Close(), ShouldBeNil)
+		})
+		Convey("unix domain socket", func() {
+			path := filepath.Join(t.TempDir(), "server.sock")
+			s.SetListenUnix(path)
+			So(s.GetListenUnix(), ShouldEqual, path)
+			listener, err := s.listen()
+			So(err, ShouldBeNil)
+			So(listener.Addr().Network(), ShouldEqual, "unix")
+			// a socket in use is left alone
+			_, err = s.listen()
+			So(err, ShouldNotBeNil)
+			conn, err := net.Dial("unix", path)
+			So(err, ShouldBeNil)
+			_ = conn.Close()
+			So(listener.Close(), ShouldBeNil)
+			// a stale socket is replaced
+			stale, err := net.Listen("unix", path)
+			So(err, ShouldBeNil)
+			stale.(*net.UnixListener).SetUnlinkOnClose(false)
+			So(stale.Close(), ShouldBeNil)
+			listener, err = s.listen()
+			So(err, ShouldBeNil)
+			So(listener.Close(), ShouldBeNil)
+		})
+		Convey("systemd socket activation", func() {
+			s.SetSystemdSocket(true)
+			So(s.GetSystemdSocket(), ShouldBeTrue)
+			_, err := s.listen()
+			So(err, ShouldNotBeNil)
+			passed, err := net.Listen("tcp", "127.0.0.1:0")
+			So(err, ShouldBeNil)
+			defer passed.Close()
+			file, err := passed.(*net.TCPListener).File()
+			So(err, ShouldBeNil)
+			// the passed socket is owned by the listener
+			fd, err := syscall.Dup(int(file.Fd()))
+			So(err, ShouldBeNil)
+			_ = file.Close()
+			start := systemdListenFdsStart
+			systemdListenFdsStart = fd
+			defer func() { systemdListenFdsStart = start }()
+			_ = os.Setenv("LISTEN_PID", "1")
+			_ = os.Setenv("LISTEN_FDS", "1")
+			_, err = s.listen()
+			So(err, ShouldNotBeNil)
+			_ = os.Setenv("LISTEN_PID", strconv.Itoa(os.Getpid()))
+			_ = os.Setenv("LISTEN_FDS", "1")
+			listener, err := s.listen()
+			So(err, ShouldBeNil)
+			So(listener.Addr().String(), ShouldEqual, passed.Addr().String())
+			_, ok := os.LookupEnv("LISTEN_FDS")
+			So(ok, ShouldBeFalse)
+			So(listener.Close(), ShouldBeNil)
+		})
+	})
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-curses/cdk/log"
+)
+
+// ServerIdleCheckPeriod is how often the ApplicationServer checks for idle
+// clients, when the policy has an IdleTimeout
+var ServerIdleCheckPeriod = time.Second
+
+// ApplicationServerPolicy limits the clients accepted by an ApplicationServer
+// and how long they may remain idle. The zero value imposes no limits.
+type ApplicationServerPolicy struct {
+	// MaxClients is the number of clients which may be connected at the same
+	// time, further connections are closed before the SSH handshake
+	MaxClients int
+	// MaxUserSessions is the number of clients which may be connected at the
+	// same time for any one user, further connections for the user are closed
+	// once authenticated
+	MaxUserSessions int
+	// IdleTimeout is how long a client session may go without any key, mouse
+	// or paste input before it is disconnected
+	IdleTimeout time.Duration
+	// IdleWarning is how long before the IdleTimeout the session is warned,
+	// with an EventIdle posted to the client display
+	IdleWarning time.Duration
+	// RateLimit is the number of connections accepted from any one remote host
+	// within the RatePeriod, further connections are closed before the SSH
+	// handshake
+	RateLimit  int
+	RatePeriod time.Duration
+}
+
+// idleState returns whether a session idle for the given time is to be warned
+// or has expired
+func (p ApplicationServerPolicy) idleState(idle time.Duration) (warn, expired bool) {
+	if p.IdleTimeout <= 0 {
+		return false, false
+	}
+	if idle >= p.IdleTimeout {
+		return false, true
+	}
+	return p.IdleWarning > 0 && idle >= p.IdleTimeout-p.IdleWarning, false
+}
+
+// SetPolicy replaces the policy of the server, existing clients are not
+// disconnected by new limits except for the IdleTimeout
+func (s *CApplicationServer) SetPolicy(policy ApplicationServerPolicy) {
+	s.Lock()
+	s.policy = policy
+	s.Unlock()
+}
+
+// GetPolicy returns the policy of the server
+func (s *CApplicationServer) GetPolicy() (policy ApplicationServerPolicy) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.policy
+}
+
+// admitConnection returns the reason for refusing the connection from the
+// given address, or an empty string if accepted
+func (s *CApplicationServer) admitConnection(addr net.Addr, now time.Time) (reason string) {
+	s.Lock()
+	defer s.Unlock()
+	if s.policy.MaxClients > 0 && len(s.clients) >= s.policy.MaxClients {
+		return fmt.Sprintf("maximum of %d clients reached", s.policy.MaxClients)
+	}
+	if s.policy.RateLimit > 0 && s.policy.RatePeriod > 0 {
+		host := addr.String()
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		var recent []time.Time
+		for _, attempt := range s.attempts[host] {
+			if now.Sub(attempt) < s.policy.RatePeriod {
+				recent = append(recent, attempt)
+			}
+		}
+		if len(recent) >= s.policy.RateLimit {
+			s.attempts[host] = recent
+			return fmt.Sprintf("rate limit of %d connections per %v reached", s.policy.RateLimit, s.policy.RatePeriod)
+		}
+		s.attempts[host] = append(recent, now)
+		// forget the hosts which have gone quiet
+		for h, attempts := range s.attempts {
+			if len(attempts) > 0 && now.Sub(attempts[len(attempts)-1]) >= s.policy.RatePeriod {
+				delete(s.attempts, h)
+			}
+		}
+	}
+	return
+}
+
+// admitUser returns the reason for refusing another session for the given
+// user, or an empty string if accepted
+func (s *CApplicationServer) admitUser(user string) (reason string) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.policy.MaxUserSessions > 0 {
+		count := 0
+		for _, asc := range s.clients {
+			if asc.User() == user {
+				count += 1
+			}
+		}
+		if count >= s.policy.MaxUserSessions {
+			return fmt.Sprintf("maximum of %d sessions reached for user: %v", s.policy.MaxUserSessions, user)
+		}
+	}
+	return
+}
+
+// rejectConnection logs the refusal of the connection from the given address
+// and emits SignalServerClientRejected
+func (s *CApplicationServer) rejectConnection(addr net.Addr, reason string) {
+	log.InfoF("connection refused %s: %v", addr.String(), reason)
+	s.Emit(SignalServerClientRejected, s, addr.String(), reason)
+}
+
+// idleWorker checks for idle clients until stop is closed
+func (s *CApplicationServer) idleWorker(stop chan struct{}) {
+	ticker := time.NewTicker(ServerIdleCheckPeriod)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			s.checkIdle()
+		}
+	}
+}
+
+// checkIdle warns the clients nearing the IdleTimeout of the policy, with an
+// EventIdle and SignalServerClientIdle, and disconnects those beyond it
+func (s *CApplicationServer) checkIdle() {
+	policy := s.GetPolicy()
+	if policy.IdleTimeout <= 0 {
+		return
+	}
+	for _, id := range s.GetClients() {
+		asc, err := s.GetClient(id)
+		if err != nil {
+			continue
+		}
+		display := asc.display()
+		if display == nil || !display.IsRunning() {
+			continue
+		}
+		idle := display.GetIdleTime()
+		warn, expired := policy.idleState(idle)
+		asc.Lock()
+		warned := asc.idleWarned
+		asc.idleWarned = warn
+		asc.Unlock()
+		if expired {
+			if err := s.DisconnectClient(id, "idle timeout"); err != nil {
+				s.LogErr(err)
+			}
+		} else if warn && !warned {
+			remaining := policy.IdleTimeout - idle
+			if err := display.PostEvent(NewEventIdle(idle, remaining)); err != nil {
+				s.LogErr(err)
+			}
+			s.Emit(SignalServerClientIdle, s, asc, remaining)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+type testPolicyConn struct {
+	ssh.Conn
+	user string
+}
+
+func (c *testPolicyConn) User() string {
+	return c.user
+}
+
+func (c *testPolicyConn) RemoteAddr() net.Addr {
+	return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2200}
+}
+
+func TestApplicationServerPolicy(t *testing.T) {
+	Convey("Server policies", t, func() {
+		s := NewApplicationServer("test", "usage", "description", "0.0.1", "test", "Test", nil, nil, "")
+		So(s.GetPolicy(), ShouldResemble, ApplicationServerPolicy{})
+		addClient := func(user string) {
+			id := uuid.Must(uuid.NewV4())
+			s.clients[id] = &CApplicationServerClient{id: id, conn: &ssh.ServerConn{Conn: &testPolicyConn{user: user}}}
+		}
+		addr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 2200}
+		now := time.Now()
+		Convey("unlimited by default", func() {
+			for i := 0; i < 10; i++ {
+				So(s.admitConnection(addr, now), ShouldEqual, "")
+				addClient("user")
+			}
+			So(s.admitUser("user"), ShouldEqual, "")
+		})
+		Convey("maximum clients", func() {
+			s.SetPolicy(ApplicationServerPolicy{MaxClients: 2})
+			addClient("one")
+			So(s.admitConnection(addr, now), ShouldEqual, "")
+			addClient("two")
+			So(s.admitConnection(addr, now), ShouldNotEqual, "")
+		})
+		Convey("maximum sessions per user", func() {
+			s.SetPolicy(ApplicationServerPolicy{MaxUserSessions: 1})
+			So(s.admitUser("one"), ShouldEqual, "")
+			addClient("one")
+			So(s.admitUser("one"), ShouldNotEqual, "")
+			So(s.admitUser("two"), ShouldEqual, "")
+		})
+		Convey("connection rate limit", func() {
+			s.SetPolicy(ApplicationServerPolicy{RateLimit: 2, RatePeriod: time.Minute})
+			other := &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 2200}
+			So(s.admitConnection(addr, now), ShouldEqual, "")
+			addr.Port = 2201 // ports are ignored
+			So(s.admitConnection(addr, now.Add(time.Second)), ShouldEqual, "")
+			So(s.admitConnection(addr, now.Add(2*time.Second)), ShouldNotEqual, "")
+			So(s.admitConnection(other, now.Add(2*time.Second)), ShouldEqual, "")
+			So(s.admitConnection(addr, now.Add(time.Minute+time.Second)), ShouldEqual, "")
+		})
+		Convey("idle timeout", func() {
+			policy := ApplicationServerPolicy{IdleTimeout: 10 * time.Minute, IdleWarning: time.Minute}
+			warn, expired := policy.idleState(5 * time.Minute)
+			So(warn || expired, ShouldBeFalse)
+			warn, expired = policy.idleState(9*time.Minute + time.Second)
+			So(warn, ShouldBeTrue)
+			So(expired, ShouldBeFalse)
+			warn, expired = policy.idleState(10 * time.Minute)
+			So(warn, ShouldBeFalse)
+			So(expired, ShouldBeTrue)
+			warn, expired = ApplicationServerPolicy{}.idleState(time.Hour)
+			So(warn || expired, ShouldBeFalse)
+		})
+	})
+}
+
+func TestDisplayIdle(t *testing.T) {
+	Convey("Display idle time", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.started = true
+		cd.lastInput = time.Now().Add(-time.Hour)
+		cd.Unlock()
+		defer func() {
+			cd.Lock()
+			cd.started = false
+			cd.Unlock()
+		}()
+		So(d.GetIdleTime(), ShouldBeGreaterThanOrEqualTo, time.Hour)
+		d.ProcessEvent(NewEventInterrupt(nil))
+		So(d.GetIdleTime(), ShouldBeGreaterThanOrEqualTo, time.Hour)
+		d.ProcessEvent(NewEventKey(KeyRune, 'a', ModNone))
+		So(d.GetIdleTime(), ShouldBeLessThan, time.Minute)
+		var received *EventIdle
+		d.Connect(SignalEventIdle, "test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			if len(argv) == 2 {
+				received, _ = argv[1].(*EventIdle)
+			}
+			return enums.EVENT_STOP
+		})
+		So(d.ProcessEvent(NewEventIdle(9*time.Minute, time.Minute)), ShouldEqual, enums.EVENT_STOP)
+		So(received, ShouldNotBeNil)
+		So(received.Idle(), ShouldEqual, 9*time.Minute)
+		So(received.Remaining(), ShouldEqual, time.Minute)
+	}))
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-curses/cdk/lib/sync"
+	"github.com/go-curses/cdk/log"
+)
+
+var (
+	// ServerRecordingCoalesce is how long the session recorder gathers the
+	// output (or input) of a session into a single asciicast event
+	ServerRecordingCoalesce = 10 * time.Millisecond
+	// ServerRecordingEventSize is the most data gathered into a single
+	// asciicast event
+	ServerRecordingEventSize = 4096
+)
+
+// SetRecording enables the recording of each client session as an asciicast
+// v2 file, for auditing and replay with tools such as asciinema. The path is a
+// template where the following are replaced for each session:
+//
+//	{app}    the tag of the application
+//	{id}     the unique identifier of the client connection
+//	{user}   the user name the client authenticated as
+//	{remote} the network address of the client
+//	{time}   the start of the session, formatted as 20060102-150405
+//
+// Directories are created as needed. If input is true, what the client types
+// is recorded as well, which may include passwords typed into the session.
+// An empty path disables recording.
+func (s *CApplicationServer) SetRecording(path string, input bool) {
+	s.Lock()
+	s.recordPath = path
+	s.recordInput = input
+	s.Unlock()
+}
+
+// GetRecording returns the path template for session recordings and whether
+// input is recorded, see: SetRecording
+func (s *CApplicationServer) GetRecording() (path string, input bool) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.recordPath, s.recordInput
+}
+
+// newSessionRecorder returns a recorder for a session of the client, nil if
+// recording is disabled or the recording could not be created
+func (s *CApplicationServer) newSessionRecorder(asc *CApplicationServerClient) (recorder *cSessionRecorder) {
+	template, input := s.GetRecording()
+	if template == "" {
+		return nil
+	}
+	started := time.Now()
+	path := expandRecordingPath(template, s.tag, asc, started)
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		s.LogError("error creating session recording directory: %v", err)
+		return nil
+	}
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if err != nil {
+		s.LogError("error creating session recording: %v", err)
+		return nil
+	}
+	asc.Lock()
+	asc.recording = path
+	asc.Unlock()
+	log.InfoF("recording SSH session %s to: %v", asc.String(), path)
+	return newSessionRecorder(file, input, s.title, started)
+}
+
+func expandRecordingPath(template, tag string, asc *CApplicationServerClient, started time.Time) (path string) {
+	clean := func(value string) string {
+		return strings.NewReplacer("/", "_", "\\", "_", ":", "_").Replace(value)
+	}
+	return strings.NewReplacer(
+		"{app}", clean(tag),
+		"{id}", asc.ID().String(),
+		"{user}", clean(asc.User()),
+		"{remote}", clean(asc.RemoteAddr()),
+		"{time}", started.Format("20060102-150405"),
+	).Replace(template)
+}
+
+// cSessionRecorder writes the output, and optionally input, of a session as
+// an asciicast v2 file. The header is written with the first event, so that
+// the size and terminal type given by the client are known.
+type cSessionRecorder struct {
+	out     io.WriteCloser
+	input   bool
+	title   string
+	started time.Time
+	header  bool
+	width   int
+	height  int
+	term    string
+	pending []byte
+	kind    string
+	since   time.Time
+	failed  bool
+	closed  bool
+
+	sync.Mutex
+}
+
+func newSessionRecorder(out io.WriteCloser, input bool, title string, started time.Time) *cSessionRecorder {
+	return &cSessionRecorder{
+		out:     out,
+		input:   input,
+		title:   title,
+		started: started,
+		width:   80,
+		height:  24,
+	}
+}
+
+// setTerm records the terminal type of the client in the header
+func (r *cSessionRecorder) setTerm(term string) {
+	r.Lock()
+	r.term = term
+	r.Unlock()
+}
+
+// resize records the new size of the client terminal, as the size given in
+// the header when nothing has been recorded yet
+func (r *cSessionRecorder) resize(w, h int) {
+	r.Lock()
+	defer r.Unlock()
+	if r.closed {
+		return
+	}
+	// pending data was sent at the previous size
+	r.flush(false)
+	r.width, r.height = w, h
+	if r.header {
+		r.write(time.Now(), "r", fmt.Sprintf("%dx%d", w, h))
+	}
+}
+
+// record adds the data sent to ("o") or received from ("i") the client
+func (r *cSessionRecorder) record(kind string, data []byte) {
+	r.Lock()
+	defer r.Unlock()
+	if r.closed || r.failed || (kind == "i" && !r.input) {
+		return
+	}
+	now := time.Now()
+	if len(r.pending) > 0 && (r.kind != kind || now.Sub(r.since) >= ServerRecordingCoalesce || len(r.pending) >= ServerRecordingEventSize) {
+		r.flush(r.kind == kind)
+	}
+	if len(r.pending) == 0 {
+		r.since = now
+	}
+	r.kind = kind
+	r.pending = append(r.pending, data...)
+}
+
+// flush writes the pending data as an event, holding back an incomplete
+// UTF-8 sequence at the end when partial is true
+func (r *cSessionRecorder) flush(partial bool) {
+	if len(r.pending) == 0 {
+		return
+	}
+	size := len(r.pending)
+	if partial {
+		for i := 1; i < utf8.UTFMax && i <= size; i++ {
+			if utf8.RuneStart(r.pending[size-i]) {
+				if !utf8.FullRune(r.pending[size-i:]) {
+					size -= i
+				}
+				break
+			}
+		}
+	}
+	if size == 0 {
+		return
+	}
+	r.write(r.since, r.kind, string(r.pending[:size]))
+	r.pending = append(r.pending[:0], r.pending[size:]...)
+	r.since = time.Now()
+}
+
+func (r *cSessionRecorder) write(when time.Time, kind, data string) {
+	if r.failed {
+		return
+	}
+	var lines []byte
+	if !r.header {
+		r.header = true
+		header := struct {
+			Version   int               `json:"version"`
+			Width     int               `json:"width"`
+			Height    int               `json:"height"`
+			Timestamp int64             `json:"timestamp"`
+			Title     string            `json:"title,omitempty"`
+			Env       map[string]string `json:"env,omitempty"`
+		}{
+			Version:   2,
+			Width:     r.width,
+			Height:    r.height,
+			Timestamp: r.started.Unix(),
+			Title:     r.title,
+		}
+		if r.term != "" {
+			header.Env = map[string]string{"TERM": r.term}
+		}
+		encoded, _ := json.Marshal(header)
+		lines = append(encoded, '\n')
+	}
+	offset := math.Round(when.Sub(r.started).Seconds()*1e6) / 1e6
+	encoded, _ := json.Marshal([]interface{}{offset, kind, data})
+	lines = append(lines, encoded...)
+	lines = append(lines, '\n')
+	if _, err := r.out.Write(lines); err != nil {
+		log.ErrorF("error writing session recording, recording stopped: %v", err)
+		r.failed = true
+	}
+}
+
+// close writes any pending data and closes the recording
+func (r *cSessionRecorder) close() (err error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.flush(false)
+	r.closed = true
+	return r.out.Close()
+}
+
+// cRecordingDevice records the data passing through the client side of a
+// session, see: exec.Spawn
+type cRecordingDevice struct {
+	io.ReadWriter
+	recorder *cSessionRecorder
+}
+
+func (d *cRecordingDevice) Read(p []byte) (n int, err error) {
+	if n, err = d.ReadWriter.Read(p); n > 0 {
+		d.recorder.record("i", p[:n])
+	}
+	return
+}
+
+func (d *cRecordingDevice) Write(p []byte) (n int, err error) {
+	if n, err = d.ReadWriter.Write(p); n > 0 {
+		d.recorder.record("o", p[:n])
+	}
+	return
+}
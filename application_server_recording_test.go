@@ -0,0 +1,128 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ssh"
+)
+
+type testRecording struct {
+	bytes.Buffer
+	closed bool
+}
+
+func (r *testRecording) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *testRecording) lines() (lines [][]interface{}, header map[string]interface{}) {
+	scanner := bufio.NewScanner(bytes.NewReader(r.Bytes()))
+	for scanner.Scan() {
+		if header == nil {
+			_ = json.Unmarshal(scanner.Bytes(), &header)
+			continue
+		}
+		var line []interface{}
+		_ = json.Unmarshal(scanner.Bytes(), &line)
+		lines = append(lines, line)
+	}
+	return
+}
+
+func TestSessionRecording(t *testing.T) {
+	Convey("Session recordings", t, func() {
+		out := &testRecording{}
+		started := time.Now()
+		r := newSessionRecorder(out, false, "Test", started)
+		r.setTerm("xterm-256color")
+		r.resize(100, 30)
+		So(out.Len(), ShouldEqual, 0)
+		for _, b := range []byte("hello") {
+			r.record("o", []byte{b})
+		}
+		r.record("i", []byte("ignored"))
+		// an incomplete rune is held back
+		r.record("o", []byte("\xe2\x94"))
+		r.Lock()
+		r.since = r.since.Add(-time.Second)
+		r.Unlock()
+		r.record("o", []byte("\x80"))
+		r.resize(120, 40)
+		So(r.close(), ShouldBeNil)
+		So(out.closed, ShouldBeTrue)
+		lines, header := out.lines()
+		So(header["version"], ShouldEqual, 2)
+		So(header["width"], ShouldEqual, 100)
+		So(header["height"], ShouldEqual, 30)
+		So(header["title"], ShouldEqual, "Test")
+		So(header["timestamp"], ShouldEqual, started.Unix())
+		So(header["env"], ShouldResemble, map[string]interface{}{"TERM": "xterm-256color"})
+		So(lines, ShouldHaveLength, 3)
+		So(lines[0][1:], ShouldResemble, []interface{}{"o", "hello"})
+		So(lines[1][1:], ShouldResemble, []interface{}{"o", "─"})
+		So(lines[2][1:], ShouldResemble, []interface{}{"r", "120x40"})
+		// nothing is recorded once closed
+		r.record("o", []byte("closed"))
+		So(r.close(), ShouldBeNil)
+		So(out.String(), ShouldNotContainSubstring, "closed")
+	})
+	Convey("Session recordings with input", t, func() {
+		out := &testRecording{}
+		r := newSessionRecorder(out, true, "", time.Now())
+		r.record("o", []byte("$ "))
+		r.record("i", []byte("ls"))
+		r.record("o", []byte("ls"))
+		So(r.close(), ShouldBeNil)
+		lines, header := out.lines()
+		So(header["width"], ShouldEqual, 80)
+		So(header, ShouldNotContainKey, "env")
+		So(lines, ShouldHaveLength, 3)
+		So(lines[1][1:], ShouldResemble, []interface{}{"i", "ls"})
+	})
+	Convey("Session recording files", t, func() {
+		s := NewApplicationServer("test", "usage", "description", "0.0.1", "test", "Test", nil, nil, "")
+		id := uuid.Must(uuid.NewV4())
+		asc := &CApplicationServerClient{id: id, conn: &ssh.ServerConn{Conn: &testPolicyConn{user: "user"}}}
+		So(s.newSessionRecorder(asc), ShouldBeNil)
+		dir := t.TempDir()
+		s.SetRecording(filepath.Join(dir, "{app}", "{user}-{remote}-{id}.cast"), false)
+		path, input := s.GetRecording()
+		So(path, ShouldEndWith, ".cast")
+		So(input, ShouldBeFalse)
+		r := s.newSessionRecorder(asc)
+		So(r, ShouldNotBeNil)
+		expected := filepath.Join(dir, "test", "user-127.0.0.1_2200-"+id.String()+".cast")
+		So(asc.Recording(), ShouldEqual, expected)
+		device := &cRecordingDevice{ReadWriter: &bytes.Buffer{}, recorder: r}
+		_, _ = device.Write([]byte("output"))
+		So(r.close(), ShouldBeNil)
+		data, err := os.ReadFile(expected)
+		So(err, ShouldBeNil)
+		So(string(data), ShouldContainSubstring, `"o","output"`)
+		// existing recordings are not overwritten
+		So(s.newSessionRecorder(asc), ShouldBeNil)
+	})
+}
@@ -0,0 +1,610 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh"
+
+	"github.com/go-curses/cdk/lib/sync"
+	"github.com/go-curses/cdk/log"
+)
+
+// Telnet commands and options, see: RFC 854, RFC 857 (ECHO), RFC 858
+// (SUPPRESS-GO-AHEAD), RFC 1073 (NAWS) and RFC 1091 (TERMINAL-TYPE)
+const (
+	telnetSE        byte = 240
+	telnetSB        byte = 250
+	telnetWILL      byte = 251
+	telnetWONT      byte = 252
+	telnetDO        byte = 253
+	telnetDONT      byte = 254
+	telnetIAC       byte = 255
+	telnetOptEcho   byte = 1
+	telnetOptSGA    byte = 3
+	telnetOptTType  byte = 24
+	telnetOptNAWS   byte = 31
+	telnetTTypeIs   byte = 0
+	telnetTTypeSend byte = 1
+)
+
+var (
+	// TelnetLoginAttempts is the number of times a telnet client may try to
+	// log in before the connection is closed
+	TelnetLoginAttempts = 3
+	// TelnetNegotiationTimeout is how long to wait for a telnet client to
+	// report the terminal type and window size before starting the session
+	TelnetNegotiationTimeout = time.Second
+	// TelnetMaxLine is the longest login name or password accepted
+	TelnetMaxLine = 256
+)
+
+var ErrTelnetClosed = errors.New("telnet connection closed")
+
+// SetTelnetListen enables a plain telnet listener on the given address, for
+// example "127.0.0.1:2323", in addition to the SSH listener. Telnet sends
+// everything, including passwords, in the clear and is only meant for lab and
+// retro environments. Clients log in with the installed password and
+// keyboard-interactive authentication handlers, see: InstallAuthHandler. An
+// empty address disables telnet.
+func (s *CApplicationServer) SetTelnetListen(address string) {
+	s.Lock()
+	s.telnetListen = address
+	s.Unlock()
+}
+
+// GetTelnetListen returns the address of the telnet listener, empty if
+// telnet is disabled
+func (s *CApplicationServer) GetTelnetListen() (address string) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.telnetListen
+}
+
+// listenTelnet opens the telnet listener, if enabled, and accepts clients until
+// the listener is closed
+func (s *CApplicationServer) listenTelnet() (err error) {
+	address := s.GetTelnetListen()
+	if address == "" {
+		return nil
+	}
+	var listener net.Listener
+	if listener, err = net.Listen("tcp", address); err != nil {
+		return fmt.Errorf("failed to listen for telnet on %s (%v)", address, err)
+	}
+	s.Lock()
+	s.telnetListener = listener
+	s.Unlock()
+	Go(func() {
+		log.InfoF("Listening for telnet on %s", listener.Addr())
+		for {
+			netConn, err := listener.Accept()
+			if err != nil {
+				if errors.Is(err, net.ErrClosed) {
+					log.DebugF("telnet listener closed")
+					return
+				}
+				log.ErrorF("Failed to accept incoming telnet connection (%s)", err)
+				continue
+			}
+			if reason := s.admitConnection(netConn.RemoteAddr(), time.Now()); reason != "" {
+				_ = netConn.Close()
+				s.rejectConnection(netConn.RemoteAddr(), reason)
+				continue
+			}
+			Go(func() { s.handleTelnet(newTelnetConn(netConn)) })
+		}
+	})
+	return
+}
+
+// handleTelnet logs in the telnet client and runs the client session the same
+// as for SSH clients, see: handleSession
+func (s *CApplicationServer) handleTelnet(tc *cTelnetConn) {
+	tc.negotiate()
+	permissions, err := s.telnetLogin(tc)
+	if err != nil {
+		log.InfoF("telnet login failed from %v: %v", tc.RemoteAddr(), err)
+		_ = tc.Close()
+		return
+	}
+	if reason := s.admitUser(tc.User()); reason != "" {
+		_, _ = tc.Write([]byte(reason + "\r\n"))
+		_ = tc.Close()
+		s.rejectConnection(tc.RemoteAddr(), reason)
+		return
+	}
+	tc.awaitNegotiation(TelnetNegotiationTimeout)
+	var asc *CApplicationServerClient
+	if asc, err = s.newClient(&ssh.ServerConn{Conn: tc, Permissions: permissions}, nil, nil); err != nil {
+		log.Error(err)
+		_ = tc.Close()
+		return
+	}
+	log.InfoF("New telnet connection from %s", asc.String())
+	s.Emit(SignalServerClientConnected, s, asc)
+
+	requests := tc.startRequests()
+	Go(func() { s.handleSession(asc, &cTelnetChannel{tc}, requests) })
+	_ = tc.Wait()
+	tc.stopRequests()
+	// the connection is gone, end any session still running
+	if display := asc.display(); display != nil && display.IsRunning() {
+		display.RequestQuit()
+	}
+	s.closeClient(asc, "connection closed")
+}
+
+// telnetLogin prompts the client for their login name and authenticates them
+// with the keyboard-interactive handlers, or the password handlers when there
+// are none, returning the permissions given by the accepting handler
+func (s *CApplicationServer) telnetLogin(tc *cTelnetConn) (permissions *ssh.Permissions, err error) {
+	s.RLock()
+	handlers := append([]ServerAuthHandler{}, s.handlers...)
+	s.RUnlock()
+	var passwords []ServerAuthPasswordHandler
+	var interactives []ServerAuthKeyboardInteractiveHandler
+	for _, handler := range handlers {
+		if h, ok := handler.(ServerAuthPasswordHandler); ok {
+			passwords = append(passwords, h)
+		}
+		if h, ok := handler.(ServerAuthKeyboardInteractiveHandler); ok {
+			interactives = append(interactives, h)
+		}
+	}
+	if len(passwords) == 0 && len(interactives) == 0 {
+		_, _ = tc.Write([]byte("telnet login is not available\r\n"))
+		return nil, fmt.Errorf("no password or keyboard-interactive authentication handlers")
+	}
+	for attempt := 0; attempt < TelnetLoginAttempts; attempt++ {
+		var user string
+		_, _ = tc.Write([]byte("login: "))
+		if user, err = tc.readLine(true); err != nil {
+			return
+		}
+		tc.setUser(user)
+		var method string
+		if len(interactives) > 0 {
+			method = "keyboard-interactive"
+			permissions, err = serverAuthChain(len(interactives), method, func(i int) (*ssh.Permissions, error) {
+				return interactives[i].KeyboardInteractiveCallback(tc, tc.challenge)
+			})
+		} else {
+			var password string
+			_, _ = tc.Write([]byte("Password: "))
+			if password, err = tc.readLine(false); err != nil {
+				return
+			}
+			method = "password"
+			permissions, err = serverAuthChain(len(passwords), method, func(i int) (*ssh.Permissions, error) {
+				return passwords[i].PasswordCallback(tc, []byte(password))
+			})
+		}
+		if err == nil {
+			s.Emit(SignalServerAuthSucceeded, s, ssh.ConnMetadata(tc), method)
+			return
+		}
+		if tc.closed() {
+			return nil, ErrTelnetClosed
+		}
+		s.Emit(SignalServerAuthFailed, s, ssh.ConnMetadata(tc), method, err)
+		_, _ = tc.Write([]byte("\r\nLogin incorrect\r\n"))
+	}
+	_, _ = tc.Write([]byte("Too many login attempts\r\n"))
+	return nil, fmt.Errorf("too many login attempts")
+}
+
+// cTelnetConn is a telnet client connection, implementing ssh.Conn so that
+// the client is managed the same as SSH clients. Reading returns the data sent
+// by the client with the telnet commands removed, which are handled to track
+// the terminal type and window size of the client. Writing escapes any IAC
+// bytes.
+type cTelnetConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	pending  []byte
+	carriage bool
+	user     string
+	term     string
+	width    int
+	height   int
+	ttype    bool
+	naws     bool
+	ready    chan struct{}
+	requests chan *ssh.Request
+	done     chan struct{}
+	closing  sync.Once
+
+	writing sync.Mutex
+	sync.RWMutex
+}
+
+func newTelnetConn(conn net.Conn) *cTelnetConn {
+	return &cTelnetConn{
+		conn:   conn,
+		reader: bufio.NewReader(conn),
+		width:  80,
+		height: 24,
+		ready:  make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+}
+
+func (t *cTelnetConn) User() string {
+	t.RLock()
+	defer t.RUnlock()
+	return t.user
+}
+
+func (t *cTelnetConn) setUser(user string) {
+	t.Lock()
+	t.user = user
+	t.Unlock()
+}
+
+func (t *cTelnetConn) SessionID() []byte {
+	return nil
+}
+
+func (t *cTelnetConn) ClientVersion() []byte {
+	return []byte("telnet")
+}
+
+func (t *cTelnetConn) ServerVersion() []byte {
+	return []byte("telnet")
+}
+
+func (t *cTelnetConn) RemoteAddr() net.Addr {
+	return t.conn.RemoteAddr()
+}
+
+func (t *cTelnetConn) LocalAddr() net.Addr {
+	return t.conn.LocalAddr()
+}
+
+func (t *cTelnetConn) SendRequest(name string, wantReply bool, payload []byte) (bool, []byte, error) {
+	return false, nil, nil
+}
+
+func (t *cTelnetConn) OpenChannel(name string, data []byte) (ssh.Channel, <-chan *ssh.Request, error) {
+	return nil, nil, fmt.Errorf("telnet does not support channels")
+}
+
+func (t *cTelnetConn) Close() (err error) {
+	t.closing.Do(func() {
+		close(t.done)
+		err = t.conn.Close()
+	})
+	return
+}
+
+func (t *cTelnetConn) Wait() error {
+	<-t.done
+	return nil
+}
+
+func (t *cTelnetConn) closed() bool {
+	select {
+	case <-t.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// Read returns the data sent by the client, the end of a line is returned as a
+// single carriage return, as if typed in a local terminal
+func (t *cTelnetConn) Read(p []byte) (n int, err error) {
+	for len(t.pending) == 0 {
+		if err = t.receive(); err != nil {
+			_ = t.Close()
+			return 0, err
+		}
+	}
+	n = copy(p, t.pending)
+	t.pending = t.pending[n:]
+	return
+}
+
+func (t *cTelnetConn) Write(p []byte) (n int, err error) {
+	data := p
+	if bytes.IndexByte(p, telnetIAC) > -1 {
+		data = bytes.ReplaceAll(p, []byte{telnetIAC}, []byte{telnetIAC, telnetIAC})
+	}
+	t.writing.Lock()
+	defer t.writing.Unlock()
+	if _, err = t.conn.Write(data); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (t *cTelnetConn) command(command ...byte) {
+	t.writing.Lock()
+	defer t.writing.Unlock()
+	_, _ = t.conn.Write(append([]byte{telnetIAC}, command...))
+}
+
+// negotiate asks the client for its terminal type and window size and puts
+// the client in character mode, with the server echoing
+func (t *cTelnetConn) negotiate() {
+	t.command(telnetDO, telnetOptTType)
+	t.command(telnetDO, telnetOptNAWS)
+	t.command(telnetWILL, telnetOptEcho)
+	t.command(telnetWILL, telnetOptSGA)
+	t.command(telnetDO, telnetOptSGA)
+}
+
+// awaitNegotiation waits until the client has reported its terminal type and
+// window size, or refused to, for at most the given timeout
+func (t *cTelnetConn) awaitNegotiation(timeout time.Duration) {
+	select {
+	case <-t.ready:
+	case <-t.done:
+	case <-time.After(timeout):
+	}
+}
+
+// terminal returns the terminal type and window size of the client
+func (t *cTelnetConn) terminal() (term string, w, h int) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.term, t.width, t.height
+}
+
+// receive decodes the next byte or command sent by the client
+func (t *cTelnetConn) receive() (err error) {
+	var b byte
+	if b, err = t.reader.ReadByte(); err != nil {
+		return
+	}
+	if b != telnetIAC {
+		if t.carriage {
+			t.carriage = false
+			if b == 0 || b == '\n' {
+				// CR NUL and CR LF are both the end of a line
+				return
+			}
+		}
+		t.carriage = b == '\r'
+		t.pending = append(t.pending, b)
+		return
+	}
+	var command byte
+	if command, err = t.reader.ReadByte(); err != nil {
+		return
+	}
+	switch command {
+	case telnetIAC:
+		t.pending = append(t.pending, telnetIAC)
+	case telnetDO, telnetDONT, telnetWILL, telnetWONT:
+		var option byte
+		if option, err = t.reader.ReadByte(); err != nil {
+			return
+		}
+		t.option(command, option)
+	case telnetSB:
+		var data []byte
+		if data, err = t.subnegotiation(); err != nil {
+			return
+		}
+		t.subnegotiate(data)
+	}
+	return
+}
+
+// subnegotiation reads the data of a subnegotiation, up to IAC SE
+func (t *cTelnetConn) subnegotiation() (data []byte, err error) {
+	var b byte
+	for {
+		if b, err = t.reader.ReadByte(); err != nil {
+			return
+		}
+		if b == telnetIAC {
+			if b, err = t.reader.ReadByte(); err != nil {
+				return
+			}
+			if b == telnetSE {
+				return
+			}
+		}
+		if len(data) < TelnetMaxLine {
+			data = append(data, b)
+		}
+	}
+}
+
+// option handles the answers of the client to negotiate, refusing any other
+// options the client asks for
+func (t *cTelnetConn) option(command, option byte) {
+	switch command {
+	case telnetWILL:
+		switch option {
+		case telnetOptTType:
+			t.command(telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE)
+		case telnetOptNAWS, telnetOptSGA:
+		default:
+			t.command(telnetDONT, option)
+		}
+	case telnetWONT:
+		switch option {
+		case telnetOptTType:
+			t.negotiated(true, false)
+		case telnetOptNAWS:
+			t.negotiated(false, true)
+		}
+	case telnetDO:
+		switch option {
+		case telnetOptEcho, telnetOptSGA:
+		default:
+			t.command(telnetWONT, option)
+		}
+	}
+}
+
+// subnegotiate handles the terminal type and window size reported by the
+// client, posting window-change requests once the session has started
+func (t *cTelnetConn) subnegotiate(data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	switch data[0] {
+	case telnetOptTType:
+		if len(data) > 1 && data[1] == telnetTTypeIs {
+			t.Lock()
+			t.term = strings.ToLower(string(data[2:]))
+			t.Unlock()
+			t.negotiated(true, false)
+		}
+	case telnetOptNAWS:
+		if len(data) == 5 {
+			w := int(data[1])<<8 | int(data[2])
+			h := int(data[3])<<8 | int(data[4])
+			if w > 0 && h > 0 {
+				t.Lock()
+				t.width, t.height = w, h
+				t.Unlock()
+				t.request("window-change", ssh.Marshal(struct {
+					Columns, Rows, Width, Height uint32
+				}{uint32(w), uint32(h), 0, 0}))
+			}
+			t.negotiated(false, true)
+		}
+	}
+}
+
+func (t *cTelnetConn) negotiated(ttype, naws bool) {
+	t.Lock()
+	defer t.Unlock()
+	if t.ttype && t.naws {
+		return
+	}
+	t.ttype = t.ttype || ttype
+	t.naws = t.naws || naws
+	if t.ttype && t.naws {
+		close(t.ready)
+	}
+}
+
+// startRequests returns the requests for a new session, starting with the
+// "pty-req" and "shell" requests an SSH client would send
+func (t *cTelnetConn) startRequests() (requests <-chan *ssh.Request) {
+	term, w, h := t.terminal()
+	if term == "" {
+		term = "vt100"
+	}
+	t.Lock()
+	t.requests = make(chan *ssh.Request, 16)
+	t.Unlock()
+	t.request("pty-req", ssh.Marshal(struct {
+		Term                         string
+		Columns, Rows, Width, Height uint32
+		Modes                        string
+	}{term, uint32(w), uint32(h), 0, 0, ""}))
+	t.request("shell", nil)
+	t.RLock()
+	defer t.RUnlock()
+	return t.requests
+}
+
+// stopRequests closes the requests of the session
+func (t *cTelnetConn) stopRequests() {
+	t.Lock()
+	defer t.Unlock()
+	if t.requests != nil {
+		close(t.requests)
+		t.requests = nil
+	}
+}
+
+func (t *cTelnetConn) request(name string, payload []byte) {
+	t.RLock()
+	defer t.RUnlock()
+	if t.requests != nil {
+		select {
+		case t.requests <- &ssh.Request{Type: name, Payload: payload}:
+		default:
+			log.WarnF("telnet session requests full, dropping: %v", name)
+		}
+	}
+}
+
+// readLine reads a line typed by the client, echoing it back if echo is true
+func (t *cTelnetConn) readLine(echo bool) (line string, err error) {
+	var buf []byte
+	b := make([]byte, 1)
+	for {
+		if _, err = t.Read(b); err != nil {
+			return
+		}
+		switch c := b[0]; {
+		case c == '\r' || c == '\n':
+			_, _ = t.Write([]byte("\r\n"))
+			return string(buf), nil
+		case c == 3 || c == 4:
+			_, _ = t.Write([]byte("\r\n"))
+			return "", ErrTelnetClosed
+		case c == 8 || c == 127:
+			if len(buf) > 0 {
+				_, size := utf8.DecodeLastRune(buf)
+				buf = buf[:len(buf)-size]
+				if echo {
+					_, _ = t.Write([]byte("\b \b"))
+				}
+			}
+		case c >= 32 && len(buf) < TelnetMaxLine:
+			buf = append(buf, c)
+			if echo {
+				_, _ = t.Write(b)
+			}
+		}
+	}
+}
+
+// challenge asks the client the questions of a keyboard-interactive handler,
+// see: ssh.KeyboardInteractiveChallenge
+func (t *cTelnetConn) challenge(name, instruction string, questions []string, echos []bool) (answers []string, err error) {
+	for _, text := range []string{name, instruction} {
+		if text != "" {
+			_, _ = t.Write([]byte(strings.ReplaceAll(text, "\n", "\r\n") + "\r\n"))
+		}
+	}
+	for i, question := range questions {
+		_, _ = t.Write([]byte(question))
+		var answer string
+		if answer, err = t.readLine(i < len(echos) && echos[i]); err != nil {
+			return nil, err
+		}
+		answers = append(answers, answer)
+	}
+	return
+}
+
+// cTelnetChannel is the session of a telnet client, see: sessionChannel
+type cTelnetChannel struct {
+	*cTelnetConn
+}
+
+func (c *cTelnetChannel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	return false, nil
+}
@@ -0,0 +1,207 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofrs/uuid"
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+// testTelnetPair returns a server side telnet connection and the client side
+// of the connection
+func testTelnetPair() (tc *cTelnetConn, client net.Conn, err error) {
+	var listener net.Listener
+	if listener, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		return
+	}
+	defer listener.Close()
+	accepted := make(chan net.Conn, 1)
+	Go(func() {
+		conn, _ := listener.Accept()
+		accepted <- conn
+	})
+	if client, err = net.Dial("tcp", listener.Addr().String()); err != nil {
+		return
+	}
+	return newTelnetConn(<-accepted), client, nil
+}
+
+// testTelnetRead reads from the client until the output contains the text
+func testTelnetRead(client net.Conn, text string) (output string) {
+	buf := make([]byte, 256)
+	_ = client.SetReadDeadline(time.Now().Add(time.Second))
+	for !strings.Contains(output, text) {
+		n, err := client.Read(buf)
+		output += string(buf[:n])
+		if err != nil {
+			break
+		}
+	}
+	return
+}
+
+func TestTelnet(t *testing.T) {
+	Convey("Telnet negotiation", t, func() {
+		tc, client, err := testTelnetPair()
+		So(err, ShouldBeNil)
+		defer client.Close()
+		tc.negotiate()
+		So(testTelnetRead(client, string([]byte{telnetIAC, telnetDO, telnetOptSGA})), ShouldStartWith, string([]byte{telnetIAC, telnetDO, telnetOptTType}))
+		_, _ = client.Write([]byte{telnetIAC, telnetWILL, telnetOptTType, telnetIAC, telnetWILL, telnetOptNAWS, telnetIAC, telnetWILL, 99})
+		_, _ = client.Write([]byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeIs})
+		_, _ = client.Write([]byte("XTERM-256COLOR"))
+		_, _ = client.Write([]byte{telnetIAC, telnetSE, telnetIAC, telnetSB, telnetOptNAWS, 0, 100, 0, 30, telnetIAC, telnetSE})
+		_, _ = client.Write([]byte("a\r\x00b\r\nc\xff\xff"))
+		data := make([]byte, 1)
+		var received []byte
+		for len(received) < 5 {
+			_, err := tc.Read(data)
+			So(err, ShouldBeNil)
+			received = append(received, data...)
+		}
+		So(received, ShouldResemble, []byte("a\rb\rc\xff")[:5])
+		_, _ = tc.Read(data)
+		So(data[0], ShouldEqual, telnetIAC)
+		// asked for the terminal type and refused the unknown option
+		So(testTelnetRead(client, string([]byte{telnetIAC, telnetDONT, 99})), ShouldContainSubstring, string([]byte{telnetIAC, telnetSB, telnetOptTType, telnetTTypeSend, telnetIAC, telnetSE}))
+		tc.awaitNegotiation(time.Second)
+		term, w, h := tc.terminal()
+		So(term, ShouldEqual, "xterm-256color")
+		So(w, ShouldEqual, 100)
+		So(h, ShouldEqual, 30)
+		// the IAC byte is escaped
+		_, _ = tc.Write([]byte{'x', telnetIAC})
+		So(testTelnetRead(client, "x"+string([]byte{telnetIAC, telnetIAC})), ShouldEndWith, string([]byte{telnetIAC, telnetIAC}))
+		// session requests
+		requests := tc.startRequests()
+		req := <-requests
+		So(req.Type, ShouldEqual, "pty-req")
+		So(req.Payload[3], ShouldEqual, len("xterm-256color"))
+		So((<-requests).Type, ShouldEqual, "shell")
+		_, _ = client.Write([]byte{telnetIAC, telnetSB, telnetOptNAWS, 0, 120, 0, 40, telnetIAC, telnetSE, 'z'})
+		_, _ = tc.Read(data)
+		So(data[0], ShouldEqual, 'z')
+		req = <-requests
+		So(req.Type, ShouldEqual, "window-change")
+		So(req.Payload[:8], ShouldResemble, []byte{0, 0, 0, 120, 0, 0, 0, 40})
+		tc.stopRequests()
+		_, ok := <-requests
+		So(ok, ShouldBeFalse)
+		// the client went away
+		_ = client.Close()
+		_, err = tc.Read(data)
+		So(err, ShouldEqual, io.EOF)
+		So(tc.Wait(), ShouldBeNil)
+	})
+	Convey("Telnet login", t, func() {
+		s := NewApplicationServer("test", "usage", "description", "0.0.1", "test", "Test", nil, nil, "")
+		ph := &testPasswordHandler{password: "secret"}
+		ph.Init()
+		s.handlers = []ServerAuthHandler{ph}
+		var events []string
+		for _, signal := range []Signal{SignalServerAuthSucceeded, SignalServerAuthFailed} {
+			signal := signal
+			s.Connect(signal, "test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				if conn, method, _, ok := ApplicationServerSignalAuthArgv(argv...); ok {
+					events = append(events, string(signal)+":"+method+":"+conn.User())
+				}
+				return enums.EVENT_PASS
+			})
+		}
+		tc, client, err := testTelnetPair()
+		So(err, ShouldBeNil)
+		defer client.Close()
+		reader := bufio.NewReader(client)
+		_, _ = client.Write([]byte("user\r\nwrong\r\nuser\r\nsecr\x7fret\r\n"))
+		permissions, err := s.telnetLogin(tc)
+		So(err, ShouldBeNil)
+		So(permissions.Extensions[ServerAuthExtensionMethod], ShouldEqual, "password")
+		So(tc.User(), ShouldEqual, "user")
+		So(events, ShouldResemble, []string{
+			"server-auth-failed:password:user",
+			"server-auth-succeeded:password:user",
+		})
+		_ = client.SetReadDeadline(time.Now().Add(time.Second))
+		output, _ := io.ReadAll(io.LimitReader(reader, int64(len("login: user\r\nPassword: \r\n\r\nLogin incorrect\r\nlogin: "))))
+		So(string(output), ShouldEqual, "login: user\r\nPassword: \r\n\r\nLogin incorrect\r\nlogin: ")
+
+		// keyboard-interactive handlers take precedence
+		ki := &testKeyboardInteractiveHandler{answer: "42"}
+		ki.Init()
+		s.handlers = []ServerAuthHandler{ph, ki}
+		tc, client, err = testTelnetPair()
+		So(err, ShouldBeNil)
+		defer client.Close()
+		_, _ = client.Write([]byte("other\r\n42\r\n"))
+		permissions, err = s.telnetLogin(tc)
+		So(err, ShouldBeNil)
+		So(permissions.Extensions["code"], ShouldEqual, "42")
+		So(testTelnetRead(client, "Code: 42\r\n"), ShouldEqual, "login: other\r\nother\r\nCode: 42\r\n")
+
+		// too many attempts
+		tc, client, err = testTelnetPair()
+		So(err, ShouldBeNil)
+		defer client.Close()
+		_, _ = client.Write(bytes.Repeat([]byte("user\r\n1\r\n"), TelnetLoginAttempts))
+		_, err = s.telnetLogin(tc)
+		So(err, ShouldNotBeNil)
+
+		// no handlers
+		s.handlers = nil
+		tc, client, err = testTelnetPair()
+		So(err, ShouldBeNil)
+		defer client.Close()
+		_, err = s.telnetLogin(tc)
+		So(err, ShouldNotBeNil)
+		So(testTelnetRead(client, "\r\n"), ShouldContainSubstring, "not available")
+	})
+	Convey("Telnet clients", t, func() {
+		s := NewApplicationServer("test", "usage", "description", "0.0.1", "test", "Test", nil, nil, "")
+		So(s.GetTelnetListen(), ShouldEqual, "")
+		So(s.listenTelnet(), ShouldBeNil)
+		s.SetTelnetListen("127.0.0.1:0")
+		So(s.GetTelnetListen(), ShouldEqual, "127.0.0.1:0")
+		So(s.listenTelnet(), ShouldBeNil)
+		defer s.shutdown()
+		s.SetPolicy(ApplicationServerPolicy{MaxUserSessions: 1})
+		id := newTestTelnetClient(s, "busy")
+		client, err := net.Dial("tcp", s.telnetListener.Addr().String())
+		So(err, ShouldBeNil)
+		defer client.Close()
+		_, _ = client.Write([]byte("busy\r\nanything\r\n"))
+		So(testTelnetRead(client, "user: busy"), ShouldContainSubstring, "maximum of 1 sessions reached")
+		So(s.GetClients(), ShouldResemble, []uuid.UUID{id})
+	})
+}
+
+// newTestTelnetClient registers a client for the user
+func newTestTelnetClient(s *CApplicationServer, user string) uuid.UUID {
+	conn, _ := net.Pipe()
+	tc := newTelnetConn(conn)
+	tc.setUser(user)
+	asc, _ := s.newClient(&ssh.ServerConn{Conn: tc}, nil, nil)
+	return asc.ID()
+}
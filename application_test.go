@@ -18,6 +18,7 @@ import (
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+	"github.com/urfave/cli/v2"
 )
 
 func TestCdk(t *testing.T) {
@@ -40,6 +41,31 @@ func TestCdk(t *testing.T) {
 			So(app.CLI(), ShouldNotBeNil)
 			app.Destroy()
 		})
+		Convey("managing multiple displays", func() {
+			app := NewApplication(
+				"AppName", "AppUsage",
+				"AppDesc", "v0.0.0",
+				"app-tag", "AppTitle",
+				OffscreenTtyPath,
+			)
+			So(app.GetDisplays(), ShouldHaveLength, 0)
+			primary := NewDisplay("primary", OffscreenTtyPath)
+			So(app.SetDisplay(primary), ShouldBeNil)
+			So(app.AddDisplay(primary), ShouldNotBeNil)
+			So(app.AddDisplay(nil), ShouldNotBeNil)
+			status := NewDisplay("status", OffscreenTtyPath)
+			So(app.AddDisplay(status), ShouldBeNil)
+			So(app.AddDisplay(status), ShouldNotBeNil)
+			So(status.App(), ShouldEqual, app)
+			displays := app.GetDisplays()
+			So(displays, ShouldHaveLength, 2)
+			So(displays[0], ShouldEqual, primary)
+			So(displays[1], ShouldEqual, status)
+			So(app.RemoveDisplay(status), ShouldBeNil)
+			So(app.RemoveDisplay(status), ShouldNotBeNil)
+			So(app.GetDisplays(), ShouldHaveLength, 1)
+			app.Destroy()
+		})
 		// Convey("with no content", WithApp(
 		// 	TestingMakesNoContent,
 		// 	func(d Application) {
@@ -49,3 +75,60 @@ func TestCdk(t *testing.T) {
 		// ))
 	})
 }
+
+func TestApplicationCliFlags(t *testing.T) {
+	Convey("Application command line flags", t, func() {
+		app := NewApplication("AppName", "AppUsage", "AppDesc", "v0.0.0", "app-tag", "AppTitle", OffscreenTtyPath)
+		defer app.Destroy()
+		Convey("collisions are refused", func() {
+			So(app.AddFlag(&cli.StringFlag{Name: "listen-port"}), ShouldBeNil)
+			So(app.AddFlag(&cli.StringFlag{Name: "listen-port"}), ShouldNotBeNil)
+			So(app.AddFlag(&cli.StringFlag{Name: "port", Aliases: []string{"listen-port"}}), ShouldNotBeNil)
+			So(app.AddFlag(&cli.BoolFlag{Name: "help"}), ShouldNotBeNil)
+			So(app.AddFlag(&cli.BoolFlag{Name: "version"}), ShouldNotBeNil)
+			So(app.AddFlag(nil), ShouldNotBeNil)
+			So(app.RemoveFlag(&cli.StringFlag{Name: "listen-port"}), ShouldBeTrue)
+			So(app.AddFlag(&cli.IntFlag{Name: "listen-port"}), ShouldBeNil)
+		})
+		Convey("namespaces are reserved", func() {
+			So(app.AddFlag(&cli.StringFlag{Name: "cdk-log-level"}), ShouldNotBeNil)
+			So(app.AddFlag(&cli.StringFlag{Name: "cdk-anything"}), ShouldNotBeNil)
+			So(app.AddFlag(&cli.StringFlag{Name: "log-level"}), ShouldBeNil)
+			So(RegisterCliFlagNamespace(""), ShouldNotBeNil)
+			So(RegisterCliFlagNamespace("cdk-extra-"), ShouldNotBeNil)
+			So(RegisterCliFlagNamespace("testlib-"), ShouldBeNil)
+			So(RegisterCliFlagNamespace("testlib-"), ShouldBeNil)
+			prefix, namespaced := GetCliFlagNamespace("testlib-mode")
+			So(namespaced, ShouldBeTrue)
+			So(prefix, ShouldEqual, "testlib-")
+			So(app.AddFlag(&cli.StringFlag{Name: "testlib-mode"}), ShouldNotBeNil)
+			So(app.AddNamespacedFlag("testlib-", &cli.StringFlag{Name: "testlib-mode"}), ShouldBeNil)
+			So(app.AddNamespacedFlag("testlib-", &cli.StringFlag{Name: "testlib-mode"}), ShouldNotBeNil)
+			So(app.AddNamespacedFlag("testlib-", &cli.StringFlag{Name: "mode"}), ShouldNotBeNil)
+			So(app.AddNamespacedFlag("cdk-", &cli.StringFlag{Name: "testlib-other"}), ShouldNotBeNil)
+		})
+		Convey("arguments are filtered", func() {
+			flags := []cli.Flag{
+				&cli.BoolFlag{Name: "daemon"},
+				&cli.IntFlag{Name: "listen-port", Aliases: []string{"p"}},
+				&cli.StringFlag{Name: "name"},
+			}
+			remove := func(name string) bool {
+				return name == "daemon" || name == "listen-port" || name == "p"
+			}
+			So(FilterCliArgs(nil, flags, remove), ShouldBeEmpty)
+			So(FilterCliArgs(
+				[]string{"app", "--daemon", "--listen-port", "2222", "--name", "daemon", "-p=1", "command"},
+				flags, remove,
+			), ShouldResemble, []string{"app", "--name", "daemon", "command"})
+			So(FilterCliArgs(
+				[]string{"app", "--listen-port=2222", "-daemon=false", "--unknown", "--name=x", "--", "--daemon"},
+				flags, remove,
+			), ShouldResemble, []string{"app", "--unknown", "--name=x", "--", "--daemon"})
+			So(FilterCliArgs(
+				[]string{"app", "command", "--daemon"},
+				flags, remove,
+			), ShouldResemble, []string{"app", "command", "--daemon"})
+		})
+	})
+}
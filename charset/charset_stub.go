@@ -21,3 +21,7 @@ package charset
 func Get() string {
 	return ""
 }
+
+func GetFromEnv(lookup func(key string) (value string, ok bool)) string {
+	return ""
+}
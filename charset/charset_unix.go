@@ -30,13 +30,23 @@ var (
 )
 
 func Get() string {
+	return GetFromEnv(os.LookupEnv)
+}
+
+// GetFromEnv returns the character set described by the environment variables
+// found with the given lookup function, for example those of a remote client
+func GetFromEnv(lookup func(key string) (value string, ok bool)) string {
+	getenv := func(key string) (value string) {
+		value, _ = lookup(key)
+		return
+	}
 	// Determine the character set.  This can help us later.
 	// Per POSIX, we search for LC_ALL first, then LC_CTYPE, and
 	// finally LANG.  First one set wins.
 	locale := ""
-	if locale = os.Getenv("LC_ALL"); locale == "" {
-		if locale = os.Getenv("LC_CTYPE"); locale == "" {
-			locale = os.Getenv("LANG")
+	if locale = getenv("LC_ALL"); locale == "" {
+		if locale = getenv("LC_CTYPE"); locale == "" {
+			locale = getenv("LANG")
 		}
 	}
 	if locale == "POSIX" || locale == "C" || locale == "US-ASCII" {
@@ -36,5 +36,17 @@ func TestCharsetUnix(t *testing.T) {
 		os.Setenv("LANG", "en_CA.UTF-8")
 		c = Get()
 		So(c, ShouldEqual, "UTF-8")
+		env := map[string]string{"LANG": "en_CA.ISO-8859-1"}
+		c = GetFromEnv(func(key string) (value string, ok bool) {
+			value, ok = env[key]
+			return
+		})
+		So(c, ShouldEqual, "ISO-8859-1")
+		env["LC_ALL"] = "C"
+		c = GetFromEnv(func(key string) (value string, ok bool) {
+			value, ok = env[key]
+			return
+		})
+		So(c, ShouldEqual, "US-ASCII")
 	})
 }
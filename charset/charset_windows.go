@@ -21,3 +21,7 @@ package charset
 func Get() string {
 	return "UTF-16"
 }
+
+func GetFromEnv(lookup func(key string) (value string, ok bool)) string {
+	return "UTF-16"
+}
@@ -14,6 +14,12 @@
 
 package cdk
 
+import (
+	"fmt"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
 const TypeClipboard CTypeTag = "cdk-clipboard"
 
 func init() {
@@ -24,6 +30,13 @@ func init() {
 //
 //	Object
 //	  +- Clipboard
+//
+// A Clipboard holds the content of the clipboard and primary selections, each
+// in one or more targets (forms) such as TargetTextPlain and TargetURIList,
+// along with the Object which owns the content. Content set by the terminal
+// or host clipboard has no owner. The text of both selections is exchanged
+// with the host clipboard, when enabled, or the terminal using OSC 52. The
+// primary selection is what users select to copy and middle-click to paste.
 type Clipboard interface {
 	Object
 
@@ -31,14 +44,28 @@ type Clipboard interface {
 	SetText(text string)
 	Copy(text string)
 	Paste(text string)
+	Request() (requested bool)
+	GetPrimaryText() (text string)
+	RequestPrimary() (requested bool)
+	SetData(selection ClipboardSelection, data ClipboardData, owner Object)
+	GetData(selection ClipboardSelection) (data ClipboardData)
+	GetTargets(selection ClipboardSelection) (targets []ClipboardTarget)
+	GetOwner(selection ClipboardSelection) (owner Object)
+	Clear(selection ClipboardSelection)
 }
 
 var _ Clipboard = (*CClipboard)(nil)
 
+type cClipboardSelection struct {
+	data  ClipboardData
+	owner Object
+}
+
 type CClipboard struct {
 	CObject
 
-	screen Screen
+	screen     Screen
+	selections map[ClipboardSelection]*cClipboardSelection
 }
 
 func newClipboard(screen Screen) (clipboard *CClipboard) {
@@ -53,6 +80,7 @@ func (c *CClipboard) Init() (already bool) {
 		return true
 	}
 	c.CObject.Init()
+	c.selections = make(map[ClipboardSelection]*cClipboardSelection)
 	_ = c.InstallProperty(PropertyText, StringProperty, true, "")
 	return false
 }
@@ -62,14 +90,17 @@ func (c *CClipboard) SetText(text string) {
 	if err := c.SetStringProperty(PropertyText, text); err != nil {
 		c.LogErr(err)
 	}
+	c.store(SelectionClipboard, NewClipboardText(text), nil)
 }
 
 // GetText retrieves the clipboard's cache of pasted content
 func (c *CClipboard) GetText() (text string) {
-	if c.screen.HostClipboardEnabled() {
+	if c.screen != nil && c.screen.HostClipboardEnabled() {
 		if v, ok := c.screen.PasteFromClipboard(); ok {
 			c.LogDebug("updated from host clipboard value: \"%v\"", v)
-			c.SetText(v)
+			if v != c.GetData(SelectionClipboard).Text() {
+				c.SetText(v)
+			}
 			text = v
 			return
 		}
@@ -88,19 +119,205 @@ func (c *CClipboard) Copy(text string) {
 	c.SetText(text)
 	c.Emit(SignalCopy, c, text)
 	c.LogDebug("text: \"%v\"", text)
-	c.screen.CopyToClipboard(text)
+	c.copyToBackend(SelectionClipboard, text)
 }
 
 // Paste updates the clipboard's cache of pasted content and emits a "Paste"
 // event itself
 func (c *CClipboard) Paste(text string) {
-	c.SetText(text)
-	c.Emit(SignalPaste, c, text)
-	c.LogDebug("text: \"%v\"", text)
+	c.pasteSelection(SelectionClipboard, text)
+}
+
+// pasteSelection updates the selection with content from outside the
+// application, emitting SignalPaste for the clipboard selection
+func (c *CClipboard) pasteSelection(selection ClipboardSelection, text string) {
+	if selection == SelectionClipboard {
+		c.SetText(text)
+		c.Emit(SignalPaste, c, text)
+	} else {
+		c.store(selection, NewClipboardText(text), nil)
+	}
+	c.LogDebug("%v text: \"%v\"", selection, text)
+}
+
+// Request asks the terminal for the content of its clipboard, using OSC 52.
+// This is how served applications, running under an ApplicationServer, read
+// the clipboard of the SSH client's terminal. When the terminal responds, the
+// clipboard is updated and emits a "paste" signal with the text. Returns false
+// if the request could not be made.
+func (c *CClipboard) Request() (requested bool) {
+	return c.screen.RequestClipboard()
+}
+
+// GetPrimaryText returns the text of the primary selection, read from the host
+// when the host clipboard is enabled. Otherwise, this is the text last selected
+// within the application or reported by the terminal, see: RequestPrimary.
+func (c *CClipboard) GetPrimaryText() (text string) {
+	if c.screen != nil && c.screen.HostClipboardEnabled() {
+		if v, ok := c.screen.PasteFromPrimary(); ok {
+			if v != c.GetData(SelectionPrimary).Text() {
+				c.store(SelectionPrimary, NewClipboardText(v), nil)
+			}
+			return v
+		}
+	}
+	return c.GetData(SelectionPrimary).Text()
+}
+
+// RequestPrimary asks the terminal for the content of its primary selection,
+// using OSC 52. When the terminal responds, the primary selection is updated
+// without emitting a "paste" signal. Returns false if the request could not be
+// made.
+func (c *CClipboard) RequestPrimary() (requested bool) {
+	if c.screen == nil {
+		return false
+	}
+	return c.screen.RequestPrimary()
+}
+
+// SetData replaces the content of the selection, owned by the given object
+// which may be nil. The text of the content, if any, is passed on to the host
+// clipboard or terminal. SignalClipboardOwnerChanged is emitted if the owner
+// changes and the selection is released when the owner is destroyed.
+func (c *CClipboard) SetData(selection ClipboardSelection, data ClipboardData, owner Object) {
+	data = data.Clone()
+	if selection == SelectionClipboard {
+		if err := c.SetStringProperty(PropertyText, data.Text()); err != nil {
+			c.LogErr(err)
+		}
+	}
+	c.store(selection, data, owner)
+	if data.Has(TargetTextPlain) {
+		c.copyToBackend(selection, data.Text())
+	}
+}
+
+// GetData returns a copy of the content of the selection, nil if empty
+func (c *CClipboard) GetData(selection ClipboardSelection) (data ClipboardData) {
+	c.RLock()
+	defer c.RUnlock()
+	if s, ok := c.selections[selection]; ok {
+		data = s.data.Clone()
+	}
+	return
+}
+
+// GetTargets returns the targets the content of the selection is held in
+func (c *CClipboard) GetTargets(selection ClipboardSelection) (targets []ClipboardTarget) {
+	c.RLock()
+	defer c.RUnlock()
+	if s, ok := c.selections[selection]; ok {
+		targets = s.data.Targets()
+	}
+	return
+}
+
+// GetOwner returns the object owning the content of the selection, nil if
+// the content came from outside the application or the selection is empty
+func (c *CClipboard) GetOwner(selection ClipboardSelection) (owner Object) {
+	c.RLock()
+	defer c.RUnlock()
+	if s, ok := c.selections[selection]; ok {
+		owner = s.owner
+	}
+	return
+}
+
+// Clear empties the selection, releasing any owner
+func (c *CClipboard) Clear(selection ClipboardSelection) {
+	if selection == SelectionClipboard {
+		if err := c.SetStringProperty(PropertyText, ""); err != nil {
+			c.LogErr(err)
+		}
+	}
+	c.store(selection, nil, nil)
+}
+
+// store replaces the content of the selection and tracks its ownership
+func (c *CClipboard) store(selection ClipboardSelection, data ClipboardData, owner Object) {
+	c.Lock()
+	s, ok := c.selections[selection]
+	if !ok {
+		s = &cClipboardSelection{}
+		c.selections[selection] = s
+	}
+	previous := s.owner
+	s.data, s.owner = data, owner
+	c.Unlock()
+	if sameObject(previous, owner) {
+		return
+	}
+	handle := c.ownerHandle(selection)
+	if previous != nil {
+		_ = previous.Disconnect(SignalDestroy, handle)
+	}
+	if owner != nil {
+		owner.Connect(SignalDestroy, handle, func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			c.releaseOwner(selection, owner)
+			return enums.EVENT_PASS
+		})
+	}
+	c.Emit(SignalClipboardOwnerChanged, c, selection, previous, owner)
+}
+
+// releaseOwner removes the ownership of the selection by the destroyed owner,
+// the content is kept
+func (c *CClipboard) releaseOwner(selection ClipboardSelection, owner Object) {
+	c.Lock()
+	s, ok := c.selections[selection]
+	if !ok || !sameObject(s.owner, owner) {
+		c.Unlock()
+		return
+	}
+	s.owner = nil
+	c.Unlock()
+	c.Emit(SignalClipboardOwnerChanged, c, selection, owner, nil)
+}
+
+func (c *CClipboard) ownerHandle(selection ClipboardSelection) string {
+	return fmt.Sprintf("%v-%v-%v", ClipboardOwnerHandle, selection, c.ObjectID())
+}
+
+// copyToBackend passes the text of the selection on to the host clipboard or
+// the terminal
+func (c *CClipboard) copyToBackend(selection ClipboardSelection, text string) {
+	if c.screen == nil {
+		return
+	}
+	switch selection {
+	case SelectionClipboard:
+		c.screen.CopyToClipboard(text)
+	case SelectionPrimary:
+		c.screen.CopyToPrimary(text)
+	}
+}
+
+func sameObject(a, b Object) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return a.ObjectID() == b.ObjectID()
+}
+
+// ClipboardSignalOwnerChangedArgv returns the selection and its previous and
+// new owners, either of which may be nil, from the arguments of
+// SignalClipboardOwnerChanged
+func ClipboardSignalOwnerChangedArgv(argv ...interface{}) (selection ClipboardSelection, previous, owner Object, ok bool) {
+	if len(argv) == 4 {
+		if selection, ok = argv[1].(ClipboardSelection); ok {
+			previous, _ = argv[2].(Object)
+			owner, _ = argv[3].(Object)
+		}
+	}
+	return
 }
 
 const SignalCopy Signal = "copy"
 
 const SignalPaste Signal = "paste"
 
+const SignalClipboardOwnerChanged Signal = "clipboard-owner-changed"
+
+const ClipboardOwnerHandle = "clipboard-owner-handle"
+
 const PropertyText Property = "text"
@@ -0,0 +1,118 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"sort"
+	"strings"
+)
+
+// ClipboardSelection names one of the selections held by a Clipboard
+type ClipboardSelection string
+
+const (
+	// SelectionClipboard is the explicit copy and paste clipboard
+	SelectionClipboard ClipboardSelection = "clipboard"
+	// SelectionPrimary is the X11 style primary selection, set by selecting
+	// text and pasted with the middle mouse button
+	SelectionPrimary ClipboardSelection = "primary"
+)
+
+// selectionFromOSC52 returns the selection of an OSC 52 selection parameter,
+// the clipboard for any other than the primary selection
+func selectionFromOSC52(parameter string) ClipboardSelection {
+	if strings.HasPrefix(parameter, "p") {
+		return SelectionPrimary
+	}
+	return SelectionClipboard
+}
+
+// ClipboardTarget is the MIME type, or application defined name, of a form of
+// the content of a clipboard selection
+type ClipboardTarget string
+
+const (
+	// TargetTextPlain is UTF-8 text, the only target exchanged with the
+	// terminal and host clipboards
+	TargetTextPlain ClipboardTarget = "text/plain"
+	// TargetURIList is a list of URIs, one per line as defined by RFC 2483
+	TargetURIList ClipboardTarget = "text/uri-list"
+)
+
+// ClipboardData is the content of a clipboard selection in one or more
+// forms, keyed by target
+type ClipboardData map[ClipboardTarget][]byte
+
+// NewClipboardText returns ClipboardData holding the text as TargetTextPlain
+func NewClipboardText(text string) (data ClipboardData) {
+	return ClipboardData{TargetTextPlain: []byte(text)}
+}
+
+// NewClipboardURIs returns ClipboardData holding the URIs as TargetURIList,
+// and as TargetTextPlain with one URI per line
+func NewClipboardURIs(uris ...string) (data ClipboardData) {
+	return ClipboardData{
+		TargetURIList:   []byte(strings.Join(uris, "\r\n") + "\r\n"),
+		TargetTextPlain: []byte(strings.Join(uris, "\n")),
+	}
+}
+
+// Get returns the content of the given target
+func (c ClipboardData) Get(target ClipboardTarget) (content []byte, ok bool) {
+	content, ok = c[target]
+	return
+}
+
+// Has returns true if the content includes the given target
+func (c ClipboardData) Has(target ClipboardTarget) bool {
+	_, ok := c[target]
+	return ok
+}
+
+// Targets returns the targets of the content, sorted
+func (c ClipboardData) Targets() (targets []ClipboardTarget) {
+	for target := range c {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i] < targets[j] })
+	return
+}
+
+// Text returns the TargetTextPlain content
+func (c ClipboardData) Text() (text string) {
+	return string(c[TargetTextPlain])
+}
+
+// URIs returns the TargetURIList content, skipping comments and blank lines
+func (c ClipboardData) URIs() (uris []string) {
+	for _, line := range strings.Split(string(c[TargetURIList]), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			uris = append(uris, line)
+		}
+	}
+	return
+}
+
+// Clone returns a copy of the content
+func (c ClipboardData) Clone() (clone ClipboardData) {
+	if c == nil {
+		return nil
+	}
+	clone = make(ClipboardData, len(c))
+	for target, content := range c {
+		clone[target] = append([]byte(nil), content...)
+	}
+	return
+}
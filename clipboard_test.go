@@ -0,0 +1,75 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestClipboard(t *testing.T) {
+	Convey("Clipboard data", t, func() {
+		data := NewClipboardURIs("file:///tmp/a", "https://example.com")
+		So(data.Targets(), ShouldResemble, []ClipboardTarget{TargetTextPlain, TargetURIList})
+		So(data.URIs(), ShouldResemble, []string{"file:///tmp/a", "https://example.com"})
+		So(data.Text(), ShouldEqual, "file:///tmp/a\nhttps://example.com")
+		clone := data.Clone()
+		clone[TargetTextPlain][0] = 'F'
+		So(data.Text()[0], ShouldEqual, 'f')
+		So(NewClipboardText("x").Has(TargetURIList), ShouldBeFalse)
+		So(selectionFromOSC52("p"), ShouldEqual, SelectionPrimary)
+		So(selectionFromOSC52("c"), ShouldEqual, SelectionClipboard)
+	})
+	Convey("Clipboard selections and ownership", t, func() {
+		clipboard := newClipboard(NewOffScreen("UTF-8"))
+		var changes [][2]Object
+		clipboard.Connect(SignalClipboardOwnerChanged, "clipboard-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			_, previous, owner, ok := ClipboardSignalOwnerChangedArgv(argv...)
+			So(ok, ShouldBeTrue)
+			changes = append(changes, [2]Object{previous, owner})
+			return enums.EVENT_PASS
+		})
+		owner := &CObject{}
+		owner.Init()
+		clipboard.SetData(SelectionClipboard, ClipboardData{TargetTextPlain: []byte("text"), "application/x-demo": []byte{1, 2}}, owner)
+		So(clipboard.GetOwner(SelectionClipboard), ShouldEqual, owner)
+		So(clipboard.GetTargets(SelectionClipboard), ShouldResemble, []ClipboardTarget{"application/x-demo", TargetTextPlain})
+		So(clipboard.GetText(), ShouldEqual, "text")
+		So(clipboard.GetData(SelectionPrimary), ShouldBeNil)
+
+		clipboard.pasteSelection(SelectionPrimary, "selected")
+		So(clipboard.GetData(SelectionPrimary).Text(), ShouldEqual, "selected")
+		So(clipboard.GetOwner(SelectionPrimary), ShouldBeNil)
+		So(clipboard.GetText(), ShouldEqual, "text")
+
+		// pasted content from outside has no owner
+		clipboard.Paste("external")
+		So(clipboard.GetOwner(SelectionClipboard), ShouldBeNil)
+		So(clipboard.GetTargets(SelectionClipboard), ShouldResemble, []ClipboardTarget{TargetTextPlain})
+		So(changes, ShouldResemble, [][2]Object{{nil, owner}, {owner, nil}})
+
+		// destroying the owner releases the selection but keeps the content
+		clipboard.SetData(SelectionPrimary, NewClipboardText("owned"), owner)
+		owner.Destroy()
+		So(clipboard.GetOwner(SelectionPrimary), ShouldBeNil)
+		So(clipboard.GetData(SelectionPrimary).Text(), ShouldEqual, "owned")
+		So(changes, ShouldHaveLength, 4)
+		clipboard.Clear(SelectionPrimary)
+		So(clipboard.GetData(SelectionPrimary), ShouldBeNil)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// cdk-compat runs a CDK application, built as a Go plugin, on an offscreen
+// display and reports the rendering problems it would have on a number of
+// degraded terminals: monochrome and 16-color palettes, terminals without
+// unicode or an alternate character set, and small screens.
+//
+//	cdk-compat [--profile mono,tiny-20x6] [--settle 250ms] app.so [-- app args]
+//
+// The exit status is 1 if any problems were found.
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"github.com/go-curses/cdk"
+)
+
+func main() {
+	var names []string
+	for _, profile := range cdk.DefaultCompatProfiles {
+		names = append(names, profile.Name)
+	}
+	app := &cli.App{
+		Name:      "cdk-compat",
+		Usage:     "check a cdk application plugin against degraded terminal profiles",
+		ArgsUsage: "app.so [-- app args]",
+		Flags: []cli.Flag{
+			&cli.StringSliceFlag{
+				Name:  "profile",
+				Usage: "profiles to check: " + strings.Join(names, ", "),
+			},
+			&cli.StringFlag{
+				Name:  "export",
+				Usage: "name of the exported cdk.Application symbol",
+				Value: "CdkApp",
+			},
+			&cli.DurationFlag{
+				Name:  "settle",
+				Usage: "time allowed for the application to draw after each change",
+				Value: cdk.DefaultCompatSettle,
+			},
+		},
+		Action: run,
+	}
+	if err := app.Run(os.Args); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+func run(ctx *cli.Context) (err error) {
+	if ctx.NArg() < 1 {
+		return fmt.Errorf("missing application plugin path")
+	}
+	var profiles []cdk.CompatProfile
+	for _, name := range ctx.StringSlice("profile") {
+		found := false
+		for _, profile := range cdk.DefaultCompatProfiles {
+			if profile.Name == name {
+				profiles = append(profiles, profile)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("unknown profile: %v", name)
+		}
+	}
+	var app cdk.Application
+	if app, err = cdk.LoadApplicationFromPluginWithExport(ctx.String("export"), ctx.Args().First()); err != nil {
+		return
+	}
+	args := append([]string{app.Name()}, ctx.Args().Tail()...)
+	var report cdk.CompatReport
+	if report, err = cdk.RunCompatCheck(app, args, profiles, ctx.Duration("settle")); err != nil {
+		return
+	}
+	fmt.Print(report.String())
+	if report.HasProblems() {
+		os.Exit(1)
+	}
+	return
+}
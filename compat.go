@@ -0,0 +1,340 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// CompatProfile describes the limitations of a terminal to check the output
+// of an application against
+type CompatProfile struct {
+	// Name identifies the profile in reports
+	Name string
+	// Colors is the size of the terminal palette, zero for monochrome and
+	// 1<<24 or more for true color
+	Colors int
+	// Charset is the character set of the terminal, as given to NewOffScreen
+	Charset string
+	// NoACS is true if the terminal has no alternate character set, so line
+	// drawing runes the charset cannot encode have no substitute
+	NoACS bool
+	// Size is the size of the terminal, zero to use the reference size
+	Size ptypes.Rectangle
+}
+
+// DefaultCompatProfiles are the profiles checked by RunCompatCheck when none
+// are given
+var DefaultCompatProfiles = []CompatProfile{
+	{Name: "mono", Colors: 0, Charset: "UTF-8"},
+	{Name: "16-color", Colors: 16, Charset: "UTF-8"},
+	{Name: "256-color", Colors: 256, Charset: "UTF-8"},
+	{Name: "no-unicode", Colors: 256, Charset: "US-ASCII"},
+	{Name: "no-acs", Colors: 256, Charset: "US-ASCII", NoACS: true},
+	{Name: "tiny-40x12", Colors: 256, Charset: "UTF-8", Size: ptypes.MakeRectangle(40, 12)},
+	{Name: "tiny-20x6", Colors: 256, Charset: "UTF-8", Size: ptypes.MakeRectangle(20, 6)},
+}
+
+// DefaultCompatSettle is the time allowed for an application to draw after
+// starting and after each resize
+var DefaultCompatSettle = 250 * time.Millisecond
+
+// CompatMinimumContrast is the WCAG contrast ratio below which text is
+// reported as CompatLowContrast
+var CompatMinimumContrast = 1.5
+
+// CompatProblemKind categorizes the problems found by CheckCompat
+type CompatProblemKind string
+
+const (
+	// CompatUnmappedRune is a rune the terminal cannot display and has no
+	// fallback for, which is drawn as a question mark
+	CompatUnmappedRune CompatProblemKind = "unmapped-rune"
+	// CompatTruncated is content which reaches the edge of the screen where
+	// the reference rendering continues beyond it
+	CompatTruncated CompatProblemKind = "truncated"
+	// CompatLowContrast is text which, once the colors are reduced to the
+	// terminal palette, is difficult or impossible to read
+	CompatLowContrast CompatProblemKind = "low-contrast"
+)
+
+// CompatProblem is a rendering problem found at a position on the screen
+type CompatProblem struct {
+	Kind     CompatProblemKind
+	Position ptypes.Point2I
+	Detail   string
+}
+
+func (p CompatProblem) String() string {
+	return fmt.Sprintf("%v at %v,%v: %v", p.Kind, p.Position.X, p.Position.Y, p.Detail)
+}
+
+// CompatCapture is the content of an OffScreen, see: OffScreen.GetContents
+type CompatCapture struct {
+	Cells []OffscreenCell
+	W, H  int
+}
+
+// CaptureCompat returns a copy of the current content of the OffScreen
+func CaptureCompat(screen OffScreen) (capture CompatCapture) {
+	cells, w, h := screen.GetContents()
+	capture.Cells = make([]OffscreenCell, len(cells))
+	copy(capture.Cells, cells)
+	capture.W, capture.H = w, h
+	return
+}
+
+// cell returns the rune and style at the position, a space if empty or out
+// of bounds
+func (c CompatCapture) cell(x, y int) (r rune, style paint.Style) {
+	if x < 0 || y < 0 || x >= c.W || y >= c.H || y*c.W+x >= len(c.Cells) {
+		return ' ', paint.StyleDefault
+	}
+	cell := c.Cells[y*c.W+x]
+	if len(cell.Runes) == 0 || cell.Runes[0] == 0 {
+		return ' ', cell.Style
+	}
+	return cell.Runes[0], cell.Style
+}
+
+// hasContent returns true if any cell within the bounds is not a space
+func (c CompatCapture) hasContent(x0, y0, x1, y1 int) bool {
+	for y := y0; y < y1; y++ {
+		for x := x0; x < x1; x++ {
+			if r, _ := c.cell(x, y); r != ' ' {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// CompatResult is the outcome of checking a profile
+type CompatResult struct {
+	Profile  CompatProfile
+	Problems []CompatProblem
+}
+
+// CompatReport is the outcome of checking a number of profiles
+type CompatReport []CompatResult
+
+// HasProblems returns true if any problems were found
+func (r CompatReport) HasProblems() bool {
+	for _, result := range r {
+		if len(result.Problems) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// String formats the report for display, listing the problems found for each
+// profile
+func (r CompatReport) String() string {
+	var sb strings.Builder
+	for _, result := range r {
+		status := "ok"
+		if count := len(result.Problems); count > 0 {
+			status = fmt.Sprintf("%d problems", count)
+		}
+		sb.WriteString(fmt.Sprintf("%v: %v\n", result.Profile.Name, status))
+		for _, problem := range result.Problems {
+			sb.WriteString(fmt.Sprintf("\t%v\n", problem))
+		}
+	}
+	return sb.String()
+}
+
+// CheckCompat inspects the capture of an application as it would appear on a
+// terminal with the given profile. The reference is a capture of the same
+// application at a comfortable size, used to recognize truncated content,
+// and may be the capture itself.
+func CheckCompat(profile CompatProfile, capture, reference CompatCapture) (problems []CompatProblem, err error) {
+	var screen OffScreen
+	if screen, err = MakeOffScreen(profile.Charset); err != nil {
+		return nil, err
+	}
+	defer screen.Close()
+	acs := make(map[rune]bool, len(vtACSNames))
+	if !profile.NoACS {
+		for _, r := range vtACSNames {
+			acs[r] = true
+		}
+	}
+	var palette []paint.Color
+	if profile.Colors > 0 && profile.Colors < 1<<24 {
+		for i := 0; i < profile.Colors && i < 256; i++ {
+			palette = append(palette, paint.PaletteColor(i))
+		}
+	}
+	for y := 0; y < capture.H; y++ {
+		for x := 0; x < capture.W; x++ {
+			r, style := capture.cell(x, y)
+			if r != ' ' && !acs[r] && !screen.CanDisplay(r, true) {
+				problems = append(problems, CompatProblem{
+					Kind:     CompatUnmappedRune,
+					Position: ptypes.MakePoint2I(x, y),
+					Detail:   fmt.Sprintf("%q (%U)", r, r),
+				})
+			}
+			if r != ' ' && profile.Colors > 0 {
+				if ratio, ok := compatContrast(style, palette); ok && ratio < CompatMinimumContrast {
+					problems = append(problems, CompatProblem{
+						Kind:     CompatLowContrast,
+						Position: ptypes.MakePoint2I(x, y),
+						Detail:   fmt.Sprintf("%q has a contrast ratio of %.2f", r, ratio),
+					})
+				}
+			}
+		}
+	}
+	// content touching the edges, where the reference continues past them
+	if capture.W < reference.W {
+		x := capture.W - 1
+		for y := 0; y < capture.H; y++ {
+			if r, _ := capture.cell(x, y); r == ' ' {
+				continue
+			}
+			if reference.hasContent(capture.W, y, reference.W, y+1) {
+				problems = append(problems, CompatProblem{
+					Kind:     CompatTruncated,
+					Position: ptypes.MakePoint2I(x, y),
+					Detail:   "content continues beyond the right edge",
+				})
+			}
+		}
+	}
+	if capture.H < reference.H {
+		y := capture.H - 1
+		for x := 0; x < capture.W; x++ {
+			if r, _ := capture.cell(x, y); r == ' ' {
+				continue
+			}
+			if reference.hasContent(x, capture.H, x+1, reference.H) {
+				problems = append(problems, CompatProblem{
+					Kind:     CompatTruncated,
+					Position: ptypes.MakePoint2I(x, y),
+					Detail:   "content continues beyond the bottom edge",
+				})
+				break
+			}
+		}
+	}
+	return
+}
+
+// compatContrast returns the contrast ratio of the style's colors as seen
+// with the given palette, ok is false if either color is the terminal default
+func compatContrast(style paint.Style, palette []paint.Color) (ratio float64, ok bool) {
+	fg, bg, attrs := style.Decompose()
+	if attrs.IsReverse() {
+		fg, bg = bg, fg
+	}
+	if !fg.Valid() || !bg.Valid() || fg == paint.ColorReset || bg == paint.ColorReset {
+		return 0, false
+	}
+	if palette != nil {
+		fg, bg = paint.FindColor(fg, palette), paint.FindColor(bg, palette)
+	}
+	l1, l2 := compatLuminance(fg), compatLuminance(bg)
+	if l1 < l2 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05), true
+}
+
+// compatLuminance is the WCAG relative luminance of the color
+func compatLuminance(c paint.Color) float64 {
+	r, g, b := c.RGB()
+	linear := func(v int32) float64 {
+		s := float64(v) / 255
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+// RunCompatCheck runs the application on an offscreen display and checks its
+// output against each of the profiles, or DefaultCompatProfiles if none are
+// given. The application is captured once it has settled for the given
+// duration after startup, as the reference, and again after resizing the
+// display for each profile with a Size.
+func RunCompatCheck(app Application, args []string, profiles []CompatProfile, settle time.Duration) (report CompatReport, err error) {
+	if len(profiles) == 0 {
+		profiles = DefaultCompatProfiles
+	}
+	if settle <= 0 {
+		settle = DefaultCompatSettle
+	}
+	app.Reconfigure(app.Name(), app.Usage(), app.Description(), app.Version(), app.Tag(), app.Title(), OffscreenTtyPath)
+	done := make(chan error, 1)
+	Go(func() {
+		done <- app.Run(args)
+	})
+	// wait for the application to start
+	var screen OffScreen
+	for deadline := time.Now().Add(settle * 20); screen == nil; {
+		select {
+		case err = <-done:
+			if err == nil {
+				err = fmt.Errorf("application exited before startup completed")
+			}
+			return nil, err
+		case <-time.After(settle / 10):
+		}
+		if d := app.Display(); d != nil && d.IsRunning() && app.StartupCompleted() {
+			screen, _ = d.Screen().(OffScreen)
+		}
+		if screen == nil && time.Now().After(deadline) {
+			return nil, fmt.Errorf("application startup did not complete")
+		}
+	}
+	d := app.Display()
+	defer func() {
+		d.RequestQuit()
+		select {
+		case <-done:
+		case <-time.After(settle * 10):
+		}
+	}()
+	time.Sleep(settle)
+	reference := CaptureCompat(screen)
+	for _, profile := range profiles {
+		capture := reference
+		if size := profile.Size; size.W > 0 && size.H > 0 {
+			screen.SetSize(size.W, size.H)
+			_ = d.PostEvent(NewEventResize(size.W, size.H))
+			time.Sleep(settle)
+			capture = CaptureCompat(screen)
+			screen.SetSize(reference.W, reference.H)
+			_ = d.PostEvent(NewEventResize(reference.W, reference.H))
+			time.Sleep(settle)
+		}
+		result := CompatResult{Profile: profile}
+		if result.Problems, err = CheckCompat(profile, capture, reference); err != nil {
+			return nil, fmt.Errorf("%v: %w", profile.Name, err)
+		}
+		report = append(report, result)
+	}
+	return
+}
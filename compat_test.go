@@ -0,0 +1,100 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+func testCompatCapture(t *testing.T, w, h int, rows ...string) CompatCapture {
+	screen := NewTestingScreen(t, "UTF-8")
+	screen.SetSize(w, h)
+	for y, row := range rows {
+		for x, r := range []rune(row) {
+			screen.SetContent(x, y, r, nil, paint.StyleDefault)
+		}
+	}
+	screen.Show()
+	return CaptureCompat(screen)
+}
+
+func testCompatKinds(problems []CompatProblem) (kinds []CompatProblemKind) {
+	for _, problem := range problems {
+		kinds = append(kinds, problem.Kind)
+	}
+	return
+}
+
+func TestCompatCheck(t *testing.T) {
+	Convey("Checking runes against profiles", t, func() {
+		capture := testCompatCapture(t, 6, 1, "a─═␉")
+		profiles := map[string]CompatProfile{}
+		for _, profile := range DefaultCompatProfiles {
+			profiles[profile.Name] = profile
+		}
+		problems, err := CheckCompat(profiles["256-color"], capture, capture)
+		So(err, ShouldBeNil)
+		So(problems, ShouldBeEmpty)
+		// the double line has neither an ACS glyph nor a fallback
+		problems, err = CheckCompat(profiles["no-unicode"], capture, capture)
+		So(err, ShouldBeNil)
+		So(problems, ShouldHaveLength, 1)
+		So(problems[0].Kind, ShouldEqual, CompatUnmappedRune)
+		So(problems[0].Position.X, ShouldEqual, 2)
+		// without ACS the tab symbol has no substitute either
+		problems, err = CheckCompat(profiles["no-acs"], capture, capture)
+		So(err, ShouldBeNil)
+		So(problems, ShouldHaveLength, 2)
+		So(problems[1].Position.X, ShouldEqual, 3)
+		_, err = CheckCompat(CompatProfile{Name: "bogus", Charset: "no-such-charset"}, capture, capture)
+		So(err, ShouldNotBeNil)
+	})
+	Convey("Checking contrast against palettes", t, func() {
+		screen := NewTestingScreen(t, "UTF-8")
+		screen.SetSize(2, 1)
+		// distinct in true color, the same color once reduced to 16 colors
+		style := paint.StyleDefault.Foreground(paint.NewRGBColor(0, 0, 0x80)).Background(paint.NewRGBColor(0, 0, 0x88))
+		screen.SetContent(0, 0, 'x', nil, style)
+		screen.SetContent(1, 0, 'y', nil, paint.StyleDefault.Foreground(paint.ColorWhite).Background(paint.ColorBlack))
+		screen.Show()
+		capture := CaptureCompat(screen)
+		problems, err := CheckCompat(CompatProfile{Name: "16", Colors: 16, Charset: "UTF-8"}, capture, capture)
+		So(err, ShouldBeNil)
+		So(testCompatKinds(problems), ShouldResemble, []CompatProblemKind{CompatLowContrast})
+		problems, err = CheckCompat(CompatProfile{Name: "mono", Charset: "UTF-8"}, capture, capture)
+		So(err, ShouldBeNil)
+		So(problems, ShouldBeEmpty)
+	})
+	Convey("Checking for truncated content", t, func() {
+		reference := testCompatCapture(t, 8, 3, "a long", "", "footer")
+		tiny := testCompatCapture(t, 4, 2, "a lo", "")
+		problems, err := CheckCompat(CompatProfile{Name: "tiny", Colors: 256, Charset: "UTF-8"}, tiny, reference)
+		So(err, ShouldBeNil)
+		So(testCompatKinds(problems), ShouldResemble, []CompatProblemKind{CompatTruncated})
+		So(problems[0].Position.X, ShouldEqual, 3)
+		tiny = testCompatCapture(t, 4, 2, "a lo", "more")
+		problems, err = CheckCompat(CompatProfile{Name: "tiny", Colors: 256, Charset: "UTF-8"}, tiny, reference)
+		So(err, ShouldBeNil)
+		So(testCompatKinds(problems), ShouldResemble, []CompatProblemKind{CompatTruncated, CompatTruncated})
+		report := CompatReport{{Profile: CompatProfile{Name: "tiny"}, Problems: problems}, {Profile: CompatProfile{Name: "ok"}}}
+		So(report.HasProblems(), ShouldBeTrue)
+		So(report.String(), ShouldStartWith, "tiny: 2 problems\n\ttruncated at 3,0: ")
+		So(report.String(), ShouldEndWith, "ok: ok\n")
+	})
+}
@@ -36,6 +36,8 @@ type TypeItem interface {
 	ObjectName() string
 	DestroyObject() (err error)
 	LogTag() string
+	LogFields() log.Fields
+	LogWithFields(fields log.Fields) *log.Entry
 	LogTrace(format string, argv ...interface{})
 	LogDebug(format string, argv ...interface{})
 	LogInfo(format string, argv ...interface{})
@@ -211,26 +213,61 @@ func (o *CTypeItem) LogTag() string {
 	return fmt.Sprintf("[%v]", o.ObjectName())
 }
 
+// logDisplayNamer is implemented by objects which know the name of the
+// Display they are on, without locking, see: Display.LogDisplayName
+type logDisplayNamer interface {
+	LogDisplayName() (name string)
+}
+
+// LogFields returns the structured fields describing the object, included in
+// all messages logged by the object: the object ID, type tag and name, and
+// the name of the Display the object is on, if known
+func (o *CTypeItem) LogFields() log.Fields {
+	fields := log.Fields{
+		log.FieldObjectID:   o.ObjectID().String(),
+		log.FieldObjectType: o.GetTypeTag().String(),
+		log.FieldObjectName: o.GetName(),
+	}
+	if namer, ok := o.Self().(logDisplayNamer); ok {
+		if name := namer.LogDisplayName(); name != "" {
+			fields[log.FieldDisplay] = name
+		}
+	}
+	return fields
+}
+
+// LogWithFields returns a log Entry with the fields of the object and the
+// given fields, for logging messages with additional context
+func (o *CTypeItem) LogWithFields(fields log.Fields) *log.Entry {
+	return log.WithFields(o.LogFields()).WithFields(fields)
+}
+
+// logEntry returns a log Entry gathering the fields of the object only when
+// a message is actually logged
+func (o *CTypeItem) logEntry() *log.Entry {
+	return log.WithFieldsFunc(o.LogFields)
+}
+
 func (o *CTypeItem) LogTrace(format string, argv ...interface{}) {
-	log.TraceDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
+	o.logEntry().TraceDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
 }
 
 func (o *CTypeItem) LogDebug(format string, argv ...interface{}) {
-	log.DebugDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
+	o.logEntry().DebugDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
 }
 
 func (o *CTypeItem) LogInfo(format string, argv ...interface{}) {
-	log.InfoDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
+	o.logEntry().InfoDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
 }
 
 func (o *CTypeItem) LogWarn(format string, argv ...interface{}) {
-	log.WarnDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
+	o.logEntry().WarnDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
 }
 
 func (o *CTypeItem) LogError(format string, argv ...interface{}) {
-	log.ErrorDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
+	o.logEntry().ErrorDF(1, fmt.Sprintf("%s %s", o.LogTag(), format), argv...)
 }
 
 func (o *CTypeItem) LogErr(err error) {
-	log.ErrorDF(1, err.Error())
+	o.logEntry().ErrorDF(1, err.Error())
 }
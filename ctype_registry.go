@@ -20,6 +20,7 @@ import (
 
 	"github.com/gofrs/uuid"
 
+	cid "github.com/go-curses/cdk/id"
 	"github.com/go-curses/cdk/lib/sync"
 	"github.com/go-curses/cdk/log"
 )
@@ -184,7 +185,7 @@ func (r *CTypeRegistry) AddTypeItem(tag TypeTag, item interface{}) (id uuid.UUID
 	r.registryLock.RUnlock()
 	r.registryLock.Lock()
 	r.register[tag].Add(item)
-	id, _ = uuid.NewV4()
+	id = cid.NewUUID()
 	r.registryLock.Unlock()
 	return
 }
@@ -19,10 +19,13 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
-	"syscall"
+	"runtime/debug"
+	"sort"
+	"sync/atomic"
 	"time"
 
-	cterm "github.com/go-curses/term"
+	"github.com/gofrs/uuid"
+	"golang.org/x/text/language"
 
 	"github.com/go-curses/cdk/env"
 	"github.com/go-curses/cdk/lib/enums"
@@ -71,6 +74,8 @@ type Display interface {
 	SetTtyHandle(ttyHandle *os.File)
 	GetCompressEvents() bool
 	SetCompressEvents(compress bool)
+	GetEventCompressor() (compressor EventCompressor)
+	SetEventCompressor(compressor EventCompressor)
 	Screen() Screen
 	DisplayCaptured() bool
 	CaptureDisplay() (err error)
@@ -78,12 +83,55 @@ type Display interface {
 	CallEnabled() (enabled bool, err error)
 	Call(fn cexec.Callback) (err error)
 	Command(name string, argv ...string) (err error)
+	CommandCaptured(name string, argv ...string) (cmd CapturedCommand, err error)
+	CommandCapturedWith(options CommandOptions, name string, argv ...string) (cmd CapturedCommand, err error)
 	IsMonochrome() bool
 	Colors() (numberOfColors int)
+	Notify(title, body string) (err error)
+	SetBellStyle(style BellStyle)
+	GetBellStyle() (style BellStyle)
+	Bell() (err error)
+	SetUrgency(w Window, urgent bool)
+	IsUrgent(w Window) (urgent bool)
+	GetUrgentWindows() (windows []Window)
 	CaptureCtrlC()
 	ReleaseCtrlC()
 	CapturedCtrlC() bool
+	EnableKeyPhases()
+	DisableKeyPhases()
+	KeyPhasesEnabled() bool
+	SetUnicodeInputTrigger(accelerator string) (err error)
+	GetUnicodeInputState() (state UnicodeInputState)
+	CancelUnicodeInput()
+	GetPreedit() (text string, cursor int, active bool)
+	SetFrameRate(fps int)
+	GetFrameRate() (fps int)
+	SetRenderWorkers(workers int)
+	GetRenderWorkers() (workers int)
+	SetWindowQueues(enabled bool)
+	GetWindowQueues() (enabled bool)
+	GetFrameStats() (stats FrameStats)
+	Stats() (stats DisplayStats)
+	EventSequence() (sequence uint64)
+	PendingCalls() (queue, mains int)
+	GetTerminalProfile() (profile string)
+	GetTerminalPrefs() (prefs TerminalPrefs)
+	SetTerminalPrefs(prefs TerminalPrefs) (err error)
+	SetTerminalPrefsStore(store TerminalPrefsStore)
+	SetCursorIndicator(indicator CursorIndicator, color paint.Color)
+	GetCursorIndicator() (indicator CursorIndicator, color paint.Color)
+	SetInputMethodArea(region ptypes.Region)
+	ClearInputMethodArea()
 	GetClipboard() (clipboard Clipboard)
+	LogDisplayName() (name string)
+	PastePrimary() (pasted bool)
+	Getenv(key string) (value string)
+	LookupEnv(key string) (value string, ok bool)
+	Setenv(key, value string)
+	Environ() (environ []string)
+	GetLocale() (locale language.Tag)
+	SetLocale(locale language.Tag)
+	GetAcceleratorMap() (accelerators AcceleratorMap)
 	FocusedWindow() Window
 	FocusWindow(w Window)
 	FocusNextWindow()
@@ -92,24 +140,99 @@ type Display interface {
 	MapWindowWithRegion(w Window, region ptypes.Region)
 	UnmapWindow(w Window)
 	IsMappedWindow(w Window) (mapped bool)
+	IsWindowConstrained(w Window) (violated bool)
+	ZoomWindow(w Window)
+	Unzoom()
+	GetZoomedWindow() (w Window)
+	SetWindowFrameHistory(w Window, frames int)
+	GetWindowFrameHistory(w Window) (frames []*WindowFrame)
+	GetWindowFrame(w Window, back int) (frame *WindowFrame, ok bool)
+	InspectWindowFrame(w Window, back int) (err error)
+	StepWindowFrame(delta int) (err error)
+	GetInspectedWindowFrame() (w Window, back int, inspecting bool)
+	StopInspectingWindowFrame()
+	GetRootPane() (root *Pane)
+	SplitPane(pane *Pane, split PaneSplit, ratio float64, name string) (first, second *Pane, err error)
+	ClosePane(pane *Pane)
+	GetPanes() (panes []*Pane)
+	FocusPane(pane *Pane)
+	GetFocusedPane() (pane *Pane)
+	ResizePane(pane *Pane, split PaneSplit, delta int)
+	LoadThemeFile(path string) (names []paint.ThemeName, err error)
+	WatchThemeFile(path string, interval time.Duration) (err error)
+	StopWatchingThemeFile(path string)
+	AddMirror(screen OffScreen, options MirrorOptions) DisplayMirror
+	RemoveMirror(mirror DisplayMirror)
+	GetMirrors() (mirrors []DisplayMirror)
+	AddAnnouncer(announcer Announcer)
+	RemoveAnnouncer(announcer Announcer)
+	GetAnnouncers() (announcers []Announcer)
+	Announce(text string, priority AnnouncePriority) (err error)
+	SetFocusDescriber(fn FocusDescriberFn)
+	AnnounceFocus(focus interface{})
+	ShareWith(viewer Display, options ShareOptions) (share DisplayShare, err error)
+	GetViewing() (share DisplayShare)
+	SetWatchdog(period time.Duration, notice bool)
+	GetWatchdog() (period time.Duration, notice bool)
+	IsDetached() (detached bool)
+	Detach() (err error)
+	Reattach(ttyPath string) (err error)
+	SetDetachTimeout(timeout time.Duration)
+	GetDetachTimeout() (timeout time.Duration)
+	GetIdleTime() (idle time.Duration)
 	GetWindows() (windows []Window)
 	GetWindowAtPoint(point ptypes.Point2I) (window Window)
 	CursorPosition() (position ptypes.Point2I, moving bool)
 	SetEventFocus(widget Object) error
 	GetEventFocus() (widget Object)
+	GrabPointer(obj Object) (err error)
+	UngrabPointer()
+	GetPointerGrab() (obj Object, implicit bool)
+	FocusChainAppend(obj Object)
+	FocusChainRemove(obj Object)
+	GetFocusChain() (chain []Object)
+	GetChainFocus() (focus Object)
+	FocusChainSet(obj Object) (err error)
+	FocusChainNext() (focus Object)
+	FocusChainPrevious() (focus Object)
+	GetFocusHistory() (history []Object)
+	BeginMoveWindow(w Window, start *EventMouse) (err error)
+	BeginResizeWindow(w Window, edge WindowEdge, start *EventMouse) (err error)
+	EndWindowDrag()
+	GetWindowDrag() (w Window, resizing bool, edge WindowEdge)
+	AddDropTarget(w Window, targets ...ClipboardTarget)
+	RemoveDropTarget(w Window)
+	GetDropTargets(w Window) (targets []ClipboardTarget, ok bool)
+	StartDrag(source Object, data ClipboardData, icon memphis.Surface) (err error)
+	CancelDrag() (cancelled bool)
+	GetDrag() (drag DragContext, dragging bool)
+	MapWindowWithState(w Window, region ptypes.Region, state WindowState)
+	GetWindowState(w Window) (state WindowState)
+	SetWindowState(w Window, state WindowState)
+	IconifyWindow(w Window)
+	DeiconifyWindow(w Window)
+	GetWindowList() (list []WindowListEntry)
 	GetPriorEvent() (event Event)
 	ProcessEvent(evt Event) enums.EventFlag
 	RequestDraw()
 	RequestShow()
 	RequestSync()
+	ForceRepaint()
 	RequestQuit()
 	IsRunning() bool
 	StartupComplete()
 	AsyncCall(fn DisplayCallbackFn) error
 	AwaitCall(fn DisplayCallbackFn) error
+	AwaitCallCtx(ctx context.Context, fn DisplayCallbackFn) error
 	AsyncCallMain(fn DisplayCallbackFn) error
 	AwaitCallMain(fn DisplayCallbackFn) error
+	AwaitCallMainCtx(ctx context.Context, fn DisplayCallbackFn) error
 	PostEvent(evt Event) error
+	SetEventOverflowPolicy(queue EventQueue, policy EventOverflowPolicy)
+	GetEventOverflowPolicy(queue EventQueue) (policy EventOverflowPolicy)
+	DroppedEvents(queue EventQueue) (dropped uint64)
+	WatchFD(fd uintptr, events IOCondition, fn IOWatchFn) (id int, err error)
+	UnwatchFD(id int) (found bool)
 	Run() (err error)
 	Startup() (ctx context.Context, cancel context.CancelFunc, wg *sync.WaitGroup, err error)
 	Main(ctx context.Context, cancel context.CancelFunc, wg *sync.WaitGroup) (err error)
@@ -126,33 +249,83 @@ type CDisplay struct {
 	title string
 
 	captureCtrlC bool
+	keyPhases    bool
+	unicodeInput *cUnicodeInput
+	render       *cRenderScheduler
+	zoom         *cWindowZoom
+	frameHistory map[uuid.UUID]*cWindowFrames
+	windowQueues map[uuid.UUID]*cWindowQueue
+	queueWindows bool
+	inspect      *cWindowFrameInspect
+	themeWatch   map[string]chan bool
+	mirrors      []*CDisplayMirror
+	announcers   []Announcer
+	fdWatcher    *cFDWatcher
+	bellStyle    BellStyle
+	urgent       map[uuid.UUID]Window
+	describer    FocusDescriberFn
+	viewing      *CDisplayShare
+	watchdog     *cDisplayWatchdog
+	workers      int
+	overflows    map[EventQueue]*cEventOverflow
+	detachTime   time.Duration
+	detachTimer  *time.Timer
+	lastInput    time.Time
+	panes        *Pane
+	paneFocus    *Pane
+	paneDrag     *Pane
+	paneGrab     *Pane
+	grabTarget   Object
+	grabImplicit bool
+	focusChain   cFocusChain
+	windowDrag   *cWindowDrag
+	dnd          cDragAndDrop
+	stats        *cDisplayStats
+	sequencer    *cEventSequencer
+	prefs        TerminalPrefs
+	prefsStore   TerminalPrefsStore
+	prefsProfile string
+	modes        *ScreenModes
+	preedit      *EventPreedit
 	clipboard    *CClipboard
+	accelerators *CAcceleratorMap
+	environ      map[string]string
+	locale       language.Tag
+	logName      atomic.Value
 
-	windows []Window
+	windows  []Window
+	geometry map[uuid.UUID]*cWindowGeometry
 
 	app        *CApplication
 	ttyPath    string
 	ttyHandle  *os.File
+	ttyScreen  Screen
 	screen     Screen
 	captured   bool
 	started    bool
+	resized    bool
 	eventFocus Object
 	priorEvent Event
 
 	cursor       *ptypes.Point2I
 	cursorMoving bool
-
-	running  bool
-	closing  sync.Once
-	done     chan bool
-	queue    chan DisplayCallbackFn
-	mains    chan DisplayCallbackFn
-	events   chan Event
-	buffer   []interface{}
-	inbound  chan Event
-	compress bool
-	lastLoop time.Time
-	loopNow  chan bool
+	indicator    CursorIndicator
+	cursorColor  paint.Color
+
+	running    bool
+	closing    sync.Once
+	done       chan bool
+	calls      *cDisplayCalls
+	queue      chan DisplayCallbackFn
+	mains      chan DisplayCallbackFn
+	events     chan Event
+	buffer     []interface{}
+	inbound    chan Event
+	fdReady    chan *cFDWatch
+	compress   bool
+	compressor EventCompressor
+	lastLoop   time.Time
+	loopNow    chan bool
 
 	notifyLoopNow bool
 
@@ -178,6 +351,18 @@ func NewDisplayWithHandle(title string, ttyHandle *os.File) (d *CDisplay) {
 	return d
 }
 
+// NewDisplayWithScreen returns a Display which captures the given Screen
+// instead of opening a terminal, the Screen is initialized by CaptureDisplay
+func NewDisplayWithScreen(title string, screen Screen) (d *CDisplay) {
+	d = new(CDisplay)
+	d.title = title
+	d.ttyPath = ""
+	d.ttyHandle = nil
+	d.ttyScreen = screen
+	d.Init()
+	return d
+}
+
 func (d *CDisplay) Init() (already bool) {
 	if d.InitTypeItem(TypeDisplayManager, d) {
 		return true
@@ -189,17 +374,32 @@ func (d *CDisplay) Init() (already bool) {
 	_ = d.InstallProperty(PropertyDisplayName, StringProperty, true, displayname)
 	_ = d.InstallProperty(PropertyDisplayUser, StringProperty, true, username)
 	_ = d.InstallProperty(PropertyDisplayHost, StringProperty, true, "/dev/tty")
+	_ = d.InstallProperty(PropertyDisplayAuthMethod, StringProperty, true, "")
+	_ = d.InstallProperty(PropertyDisplayAuthFingerprint, StringProperty, true, "")
+	_ = d.InstallProperty(PropertyDisplayAuthExtensions, StructProperty, true, map[string]string{})
+	d.logName.Store(displayname)
+	d.Connect(NotifySignal(PropertyDisplayName), DisplayLogNameHandle, func(data []interface{}, argv ...interface{}) enums.EventFlag {
+		if _, _, _, value, ok := ArgvSignalNotifyProperty(argv...); ok {
+			if name, ok := value.(string); ok {
+				d.logName.Store(name)
+			}
+		}
+		return enums.EVENT_PASS
+	})
 
 	d.captured = false
 	d.started = false
 	d.running = false
-	d.done = make(chan bool)
+	d.done = make(chan bool, 1)
 	d.queue = make(chan DisplayCallbackFn, DisplayCallCapacity)
 	d.mains = make(chan DisplayCallbackFn, DisplayMainsCapacity)
+	d.calls = newDisplayCalls()
 	d.events = make(chan Event, DisplayEventCapacity)
 	d.buffer = make([]interface{}, 0)
 	d.inbound = make(chan Event, DisplayInboundCapacity)
+	d.fdReady = make(chan *cFDWatch, DisplayWatchCapacity)
 	d.compress = true
+	d.compressor = NewEventCompressor()
 	d.lastLoop = time.Unix(0, 0)
 	d.loopNow = make(chan bool, DisplayLoopCapacity)
 
@@ -207,10 +407,28 @@ func (d *CDisplay) Init() (already bool) {
 	d.cursorMoving = false
 
 	d.clipboard = nil
+	d.accelerators = newAcceleratorMap()
+	d.unicodeInput = newUnicodeInput()
+	d.render = newRenderScheduler(DefaultFrameRate)
+	d.workers = DefaultRenderWorkers
+	d.stats = &cDisplayStats{}
+	d.sequencer = newEventSequencer()
+	d.watchdog = newDisplayWatchdog()
+	d.overflows = d.newEventOverflows()
+	d.detachTime = DefaultDetachTimeout
+	d.lastInput = time.Now()
+	d.prefsStore = DefaultTerminalPrefsStore
+	d.environ = make(map[string]string)
+	d.locale, _ = LocaleFromEnv(os.LookupEnv)
 
 	d.priorEvent = nil
 	d.eventFocus = nil
+	d.grabTarget = nil
 	d.windows = make([]Window, 0)
+	d.geometry = make(map[uuid.UUID]*cWindowGeometry)
+	d.frameHistory = make(map[uuid.UUID]*cWindowFrames)
+	d.windowQueues = make(map[uuid.UUID]*cWindowQueue)
+	d.themeWatch = make(map[string]chan bool)
 
 	d.eventMutex = &sync.Mutex{}
 	d.drawMutex = &sync.Mutex{}
@@ -226,16 +444,43 @@ func (d *CDisplay) App() *CApplication {
 }
 
 func (d *CDisplay) Destroy() {
+	d.stopWorkers()
+	d.Lock()
+	if d.detachTimer != nil {
+		d.detachTimer.Stop()
+		d.detachTimer = nil
+	}
+	d.Unlock()
 	d.setRunning(false)
 	d.ReleaseDisplay()
 	d.closeChannels()
 }
 
+// stopWorkers stops the theme file watchers, mirrors, announcers, file
+// descriptor watchers and window queues, all of which run goroutines of their
+// own
+func (d *CDisplay) stopWorkers() {
+	d.stopWatchingThemeFiles()
+	d.closeMirrors()
+	d.closeAnnouncers()
+	d.stopWatchingFDs()
+	d.stopWindowQueues()
+}
+
+// signalDone asks Main to shut down without blocking, repeated requests are
+// dropped as one is already pending
+func (d *CDisplay) signalDone() {
+	defer func() { _ = recover() }() // done may already be closed
+	select {
+	case d.done <- true:
+	default:
+	}
+}
+
 func (d *CDisplay) closeChannels() {
 	d.closing.Do(func() {
 		close(d.done)
-		close(d.queue)
-		close(d.mains)
+		d.calls.close(d.queue, d.mains)
 		close(d.inbound)
 	})
 }
@@ -288,6 +533,25 @@ func (d *CDisplay) SetCompressEvents(compress bool) {
 	d.Unlock()
 }
 
+// GetEventCompressor returns the EventCompressor used to reduce the pending
+// event buffer when compressing events is enabled
+func (d *CDisplay) GetEventCompressor() (compressor EventCompressor) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.compressor
+}
+
+// SetEventCompressor replaces the EventCompressor used to reduce the pending
+// event buffer, a nil compressor restores the default CEventCompressor
+func (d *CDisplay) SetEventCompressor(compressor EventCompressor) {
+	if compressor == nil {
+		compressor = NewEventCompressor()
+	}
+	d.Lock()
+	d.compressor = compressor
+	d.Unlock()
+}
+
 func (d *CDisplay) Screen() Screen {
 	d.RLock()
 	defer d.RUnlock()
@@ -302,15 +566,29 @@ func (d *CDisplay) DisplayCaptured() bool {
 
 func (d *CDisplay) CaptureDisplay() (err error) {
 	d.Lock()
-	if d.ttyPath == OffscreenTtyPath {
+	if d.ttyScreen != nil {
+		d.screen = d.ttyScreen
+	} else if d.ttyPath == OffscreenTtyPath {
 		d.screen = NewOffScreen("UTF-8")
+	} else if backend, address, ok := LookupScreenBackend(d.ttyPath); ok {
+		if d.screen, err = backend(address); err != nil {
+			d.Unlock()
+			return fmt.Errorf("error getting new screen: %v", err)
+		}
 	} else {
-		if d.screen, err = NewScreen(); err != nil {
+		// the terminal of a remote client is described by the Display
+		// environment rather than that of the process
+		if d.screen, err = NewScreenWithEnv(d.environLookup()); err != nil {
 			d.Unlock()
 			return fmt.Errorf("error getting new screen: %v", err)
 		}
 	}
-	if d.ttyHandle != nil {
+	if d.ttyScreen != nil {
+		if err = d.screen.Init(); err != nil {
+			d.Unlock()
+			return fmt.Errorf("error initializing screen: %v", err)
+		}
+	} else if d.ttyHandle != nil {
 		if err = d.screen.InitWithFileHandle(d.ttyHandle); err != nil {
 			d.Unlock()
 			return fmt.Errorf("error initializing new tty handle screen: %v", err)
@@ -324,8 +602,25 @@ func (d *CDisplay) CaptureDisplay() (err error) {
 	theme, _ := paint.GetTheme(paint.DisplayTheme)
 	enabled, _ := d.CallEnabled()
 	d.screen.TtyCloseWithStiRead(enabled)
-	d.screen.EnableMouse()
+	d.applyEventOverflow()
+	if d.prefsStore != nil && d.ttyPath != OffscreenTtyPath {
+		d.prefsProfile = d.getTerminalProfile()
+		if prefs, found, err := d.prefsStore.LoadTerminalPrefs(d.prefsProfile); err != nil {
+			d.LogErr(err)
+		} else if found {
+			d.prefs = prefs
+		}
+	}
+	d.prefs.apply(d.screen)
+	d.applyCursorIndicator()
 	d.screen.EnablePaste()
+	if d.keyPhases {
+		d.screen.EnableKeyPhases()
+	}
+	if d.modes != nil {
+		// re-apply the terminal modes enabled before the display was released
+		d.screen.ApplyModes(*d.modes)
+	}
 	d.screen.SetStyle(theme.Content.Normal)
 	d.screen.Clear()
 	d.captured = true
@@ -339,6 +634,8 @@ func (d *CDisplay) CaptureDisplay() (err error) {
 func (d *CDisplay) ReleaseDisplay() {
 	if d.DisplayCaptured() {
 		d.Lock()
+		modes := d.screen.Modes()
+		d.modes = &modes
 		d.screen.Close()
 		d.screen = nil
 		d.captured = false
@@ -381,12 +678,10 @@ func (d *CDisplay) Call(fn cexec.Callback) (err error) {
 	var callTty *os.File
 
 	if d.ttyHandle != nil {
-		var dupeFd int
-		if dupeFd, err = syscall.Dup(int(d.ttyHandle.Fd())); err != nil {
-			return fmt.Errorf("syscall.Dup error: %v", err)
+		if callTty, err = dupTty(d.ttyHandle); err != nil {
+			return err
 		}
-		callTty = os.NewFile(uintptr(dupeFd), d.ttyHandle.Name())
-		d.LogDebug("callTty = os.NewFile(%v, %v)", dupeFd, callTty.Name())
+		d.LogDebug("callTty = os.NewFile(%v, %v)", callTty.Fd(), callTty.Name())
 	} else {
 		ttyPath := "/dev/tty"
 		if d.ttyPath != "" {
@@ -403,7 +698,7 @@ func (d *CDisplay) Call(fn cexec.Callback) (err error) {
 	}
 
 	d.LogDebug("sending Tiocsti: %v", callTty.Name())
-	if err := cterm.Tiocsti(callTty.Fd(), " "); err != nil {
+	if err := tiocsti(callTty.Fd(), " "); err != nil {
 		log.Error(err)
 		d.LogDebug("[trying again] writing Tiocsti: %v", callTty.Name())
 		if _, err := callTty.Write([]byte(" ")); err != nil {
@@ -440,6 +735,21 @@ func (d *CDisplay) Command(name string, argv ...string) (err error) {
 	})
 }
 
+// Notify sends a desktop notification through the terminal, see:
+// Screen.Notify. A SignalNotify listener returning EVENT_STOP handles the
+// notification instead, for example by presenting it within the application
+// or with a notification service of the host.
+func (d *CDisplay) Notify(title, body string) (err error) {
+	if f := d.Emit(SignalNotify, d, title, body); f == enums.EVENT_STOP {
+		return nil
+	}
+	screen := d.Screen()
+	if screen == nil {
+		return fmt.Errorf("display is not captured")
+	}
+	return screen.Notify(title, body)
+}
+
 func (d *CDisplay) IsMonochrome() bool {
 	return d.Colors() == 0
 }
@@ -470,6 +780,276 @@ func (d *CDisplay) CapturedCtrlC() bool {
 	return d.captureCtrlC
 }
 
+// EnableKeyPhases requests that the screen report key repeat and key release
+// events in addition to key presses. See: EventKey.Phase
+func (d *CDisplay) EnableKeyPhases() {
+	d.Lock()
+	defer d.Unlock()
+	d.keyPhases = true
+	if d.screen != nil {
+		d.screen.EnableKeyPhases()
+	}
+}
+
+func (d *CDisplay) DisableKeyPhases() {
+	d.Lock()
+	defer d.Unlock()
+	d.keyPhases = false
+	if d.screen != nil {
+		d.screen.DisableKeyPhases()
+	}
+}
+
+func (d *CDisplay) KeyPhasesEnabled() bool {
+	d.RLock()
+	defer d.RUnlock()
+	return d.keyPhases
+}
+
+// SetUnicodeInputTrigger changes the key sequence which starts the ISO 14755
+// unicode input mode, where hexadecimal digits are accumulated until Enter or
+// Space is pressed and the resulting rune is processed as a single KeyRune
+// event. An empty accelerator disables unicode input mode.
+// See: DefaultUnicodeInputTrigger
+func (d *CDisplay) SetUnicodeInputTrigger(accelerator string) (err error) {
+	d.Lock()
+	defer d.Unlock()
+	return d.unicodeInput.setTrigger(accelerator)
+}
+
+// GetUnicodeInputState returns the current unicode input mode state
+func (d *CDisplay) GetUnicodeInputState() (state UnicodeInputState) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.unicodeInput.state()
+}
+
+// CancelUnicodeInput stops any unicode input in progress, emitting
+// SignalUnicodeInput if it was active
+func (d *CDisplay) CancelUnicodeInput() {
+	d.Lock()
+	active := d.unicodeInput.active
+	d.unicodeInput.reset()
+	state := d.unicodeInput.state()
+	d.Unlock()
+	if active {
+		d.Emit(SignalUnicodeInput, d, state)
+	}
+}
+
+// GetTerminalProfile returns the key of the terminal preferences of this
+// Display, based on the capabilities of the Screen once probed and on the
+// Display environment until then, see: TerminalProfile
+func (d *CDisplay) GetTerminalProfile() (profile string) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.getTerminalProfile()
+}
+
+func (d *CDisplay) getTerminalProfile() (profile string) {
+	var capabilities Capabilities
+	if d.screen != nil {
+		capabilities = d.screen.Capabilities()
+	}
+	return TerminalProfile(capabilities, d.environLookup())
+}
+
+// reloadTerminalPrefs loads the preferences again when the capability probe
+// changed the terminal profile, keeping the preferences of the previous
+// profile when none are saved for the new one
+func (d *CDisplay) reloadTerminalPrefs() {
+	d.Lock()
+	store := d.prefsStore
+	if store == nil || d.screen == nil || !d.captured || d.ttyPath == OffscreenTtyPath {
+		d.Unlock()
+		return
+	}
+	profile := d.getTerminalProfile()
+	if profile == d.prefsProfile {
+		d.Unlock()
+		return
+	}
+	d.prefsProfile = profile
+	d.Unlock()
+	// the store may read a file, which is done with the Display unlocked
+	prefs, found, err := store.LoadTerminalPrefs(profile)
+	if err != nil {
+		d.LogErr(err)
+		return
+	}
+	d.Lock()
+	defer d.Unlock()
+	// the profile may have changed again in the meantime
+	if found && profile == d.prefsProfile && d.screen != nil && d.captured {
+		d.prefs = prefs
+		prefs.apply(d.screen)
+		d.applyCursorIndicator()
+	}
+}
+
+// environLookup returns a function looking up a copy of the environment of the
+// Display, falling back to the process environment
+func (d *CDisplay) environLookup() func(key string) (value string, ok bool) {
+	environ := make(map[string]string, len(d.environ))
+	for key, value := range d.environ {
+		environ[key] = value
+	}
+	return func(key string) (value string, ok bool) {
+		if value, ok = environ[key]; !ok {
+			value, ok = os.LookupEnv(key)
+		}
+		return
+	}
+}
+
+func (d *CDisplay) GetTerminalPrefs() (prefs TerminalPrefs) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.prefs
+}
+
+// SetTerminalPrefs applies the given preferences to the Screen, if captured,
+// and saves them for the current terminal profile
+func (d *CDisplay) SetTerminalPrefs(prefs TerminalPrefs) (err error) {
+	d.Lock()
+	d.prefs = prefs
+	if d.screen != nil && d.captured {
+		prefs.apply(d.screen)
+		d.applyCursorIndicator()
+	}
+	store, profile := d.prefsStore, d.getTerminalProfile()
+	d.Unlock()
+	if store != nil {
+		err = store.SaveTerminalPrefs(profile, prefs)
+	}
+	return
+}
+
+// SetCursorIndicator changes how the application shows the cursor, see:
+// CursorIndicator. A CursorIndicator in the TerminalPrefs takes precedence,
+// so that users can choose an indicator they can see regardless of the
+// application.
+func (d *CDisplay) SetCursorIndicator(indicator CursorIndicator, color paint.Color) {
+	d.Lock()
+	d.indicator = indicator
+	d.cursorColor = color
+	if d.screen != nil && d.captured {
+		d.applyCursorIndicator()
+	}
+	d.Unlock()
+	d.RequestShow()
+}
+
+// GetCursorIndicator returns how the cursor is shown, taking the
+// TerminalPrefs into account
+func (d *CDisplay) GetCursorIndicator() (indicator CursorIndicator, color paint.Color) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.getCursorIndicator()
+}
+
+func (d *CDisplay) getCursorIndicator() (indicator CursorIndicator, color paint.Color) {
+	indicator, color = d.indicator, d.cursorColor
+	if d.prefs.CursorIndicator != CursorIndicatorDefault {
+		indicator = d.prefs.CursorIndicator
+	}
+	if d.prefs.CursorColor != "" {
+		if c, ok := paint.ParseColor(d.prefs.CursorColor); ok {
+			color = c
+		}
+	}
+	if color == paint.ColorDefault {
+		color = DefaultCursorIndicatorColor
+	}
+	return
+}
+
+// applyCursorIndicator configures the screen, the Display must be locked
+func (d *CDisplay) applyCursorIndicator() {
+	d.screen.SetCursorIndicator(d.getCursorIndicator())
+}
+
+// SetTerminalPrefsStore changes where terminal preferences are loaded from and
+// saved to, nil disables persistence. Preferences are loaded when the Display
+// is captured.
+func (d *CDisplay) SetTerminalPrefsStore(store TerminalPrefsStore) {
+	d.Lock()
+	defer d.Unlock()
+	d.prefsStore = store
+}
+
+// SetFrameRate limits the number of frames rendered per second, coalescing
+// render requests which arrive sooner than the next frame. A value of zero
+// renders every request immediately.
+func (d *CDisplay) SetFrameRate(fps int) {
+	d.render.setFrameRate(fps)
+}
+
+func (d *CDisplay) GetFrameRate() (fps int) {
+	return d.render.frameRate()
+}
+
+// SetRenderWorkers changes the number of goroutines compositing the windows
+// onto the display surface, each compositing a horizontal band of the display.
+// Values less than two composite serially on the UI thread, which is usually
+// fastest unless the screen is large.
+func (d *CDisplay) SetRenderWorkers(workers int) {
+	d.Lock()
+	defer d.Unlock()
+	d.workers = workers
+}
+
+func (d *CDisplay) GetRenderWorkers() (workers int) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.workers
+}
+
+// GetFrameStats returns the frame count, dropped render requests and frame
+// durations measured so far
+func (d *CDisplay) GetFrameStats() (stats FrameStats) {
+	return d.render.getStats()
+}
+
+// GetPreedit returns the current input method composition text and cursor
+// offset, with active false when no composition is in progress
+func (d *CDisplay) GetPreedit() (text string, cursor int, active bool) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.preedit != nil {
+		text, cursor, active = d.preedit.Text(), d.preedit.Cursor(), true
+	}
+	return
+}
+
+// SetInputMethodArea reports the region of the text being composed to the
+// Screen, typically the insertion point of the focused text widget, so that
+// input method popups can be placed near it
+func (d *CDisplay) SetInputMethodArea(region ptypes.Region) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.screen != nil {
+		d.screen.SetInputMethodArea(region.X, region.Y, region.W, region.H)
+	}
+}
+
+func (d *CDisplay) ClearInputMethodArea() {
+	d.RLock()
+	defer d.RUnlock()
+	if d.screen != nil {
+		d.screen.ClearInputMethodArea()
+	}
+}
+
+// LogDisplayName returns the PropertyDisplayName of the display without
+// locking, this is included in the messages logged by the display and its
+// windows so that the logs of each client of an ApplicationServer can be told
+// apart, see: TypeItem.LogFields
+func (d *CDisplay) LogDisplayName() (name string) {
+	name, _ = d.logName.Load().(string)
+	return
+}
+
 func (d *CDisplay) GetClipboard() (clipboard Clipboard) {
 	d.RLock()
 	defer d.RUnlock()
@@ -485,6 +1065,82 @@ func (d *CDisplay) GetClipboard() (clipboard Clipboard) {
 	return d.clipboard
 }
 
+// GetAcceleratorMap returns the AcceleratorMap consulted by ProcessEvent for
+// all EventKey events, before any other dispatching takes place
+func (d *CDisplay) GetAcceleratorMap() (accelerators AcceleratorMap) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.accelerators
+}
+
+// Getenv returns the value of the environment variable named by the key. See:
+// LookupEnv
+func (d *CDisplay) Getenv(key string) (value string) {
+	value, _ = d.LookupEnv(key)
+	return
+}
+
+// LookupEnv returns the value of the environment variable named by the key.
+// Variables set on the Display, such as those sent by an ApplicationServer
+// client, take precedence over those of the process environment.
+func (d *CDisplay) LookupEnv(key string) (value string, ok bool) {
+	d.RLock()
+	value, ok = d.environ[key]
+	d.RUnlock()
+	if !ok {
+		value, ok = os.LookupEnv(key)
+	}
+	return
+}
+
+// Setenv sets the value of the environment variable named by the key for this
+// Display only, without modifying the process environment. Setting any of the
+// LocaleEnvironmentKeys updates the locale of the Display.
+func (d *CDisplay) Setenv(key, value string) {
+	d.Lock()
+	d.environ[key] = value
+	d.Unlock()
+	for _, name := range LocaleEnvironmentKeys {
+		if key == name {
+			if locale, ok := LocaleFromEnv(d.LookupEnv); ok {
+				d.SetLocale(locale)
+			}
+			break
+		}
+	}
+}
+
+// Environ returns a copy of the environment variables set on this Display, in
+// the form "key=value"
+func (d *CDisplay) Environ() (environ []string) {
+	d.RLock()
+	defer d.RUnlock()
+	for key, value := range d.environ {
+		environ = append(environ, key+"="+value)
+	}
+	sort.Strings(environ)
+	return
+}
+
+// GetLocale returns the locale used when translating messages for this Display.
+// See: Tr
+func (d *CDisplay) GetLocale() (locale language.Tag) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.locale
+}
+
+// SetLocale updates the locale used when translating messages for this
+// Display, emitting SignalSetLocale first.
+func (d *CDisplay) SetLocale(locale language.Tag) {
+	if f := d.Emit(SignalSetLocale, d, locale); f == enums.EVENT_PASS {
+		d.Lock()
+		d.locale = locale
+		d.Unlock()
+		d.RequestDraw()
+	}
+}
+
 func (d *CDisplay) SetTheme(theme paint.Theme) {
 	d.CObject.SetTheme(theme)
 	d.Lock()
@@ -534,7 +1190,7 @@ func (d *CDisplay) FocusedWindow() Window {
 	defer d.RUnlock()
 	if numWindows := len(d.windows); numWindows > 0 {
 		for i := 0; i < numWindows; i++ {
-			if d.windows[i].GetWindowType() == enums.WINDOW_TOPLEVEL {
+			if d.windows[i].GetWindowType() == enums.WINDOW_TOPLEVEL && !d.isWindowMinimized(d.windows[i]) {
 				return d.windows[i]
 			}
 		}
@@ -554,6 +1210,8 @@ func (d *CDisplay) FocusWindow(w Window) {
 		d.MapWindow(w)
 	}
 	d.Emit(SignalFocusedWindow, w)
+	d.SetUrgency(w, false)
+	d.AnnounceFocus(w)
 }
 
 func (d *CDisplay) FocusNextWindow() {
@@ -588,46 +1246,274 @@ func (d *CDisplay) MapWindow(w Window) {
 	}
 	d.RUnlock()
 	region := ptypes.MakeRegion(0, 0, width, height)
-	d.MapWindowWithRegion(w, region)
+	d.mapWindowWithRegion(w, region, true)
 }
 
+// MapWindowWithRegion maps the given window to the region, constrained by the
+// window's geometry hints. If the display is too small for the window's
+// minimum size, SignalWindowConstraintViolation is emitted and a "terminal too
+// small" placeholder is rendered instead of the window.
 func (d *CDisplay) MapWindowWithRegion(w Window, region ptypes.Region) {
-	log.DebugDF(1, "mapping window: %v, with region: %v", w.ObjectName(), region)
+	d.mapWindowWithRegion(w, region, false)
+}
+
+func (d *CDisplay) mapWindowWithRegion(w Window, region ptypes.Region, fill bool) {
+	log.DebugDF(2, "mapping window: %v, with region: %v", w.ObjectName(), region)
 	index := d.findMappedWindowIndex(w)
 	w.SetDisplay(d)
+	geometry := &cWindowGeometry{region: region, fill: fill}
+	available := d.availableSize()
+	hints := w.GetGeometryHints()
+	if hints.IsSet() {
+		var ok bool
+		region, ok = hints.Constrain(region, available)
+		geometry.violated = !ok
+	}
 	style := w.GetTheme().Content.Normal
-	if err := memphis.MakeConfigureSurface(w.ObjectID(), region.Origin(), region.Size(), style); err != nil {
+	if err := MakeObjectSurface(w, region.Origin(), region.Size(), style); err != nil {
 		d.LogErr(err)
 	}
 	d.Lock()
 	if index > -1 {
 		d.windows = append(d.windows[:index], d.windows[index+1:]...)
 	}
-	d.windows = append([]Window{w}, d.windows...)
+	d.windows = append([]Window{w}, d.windows...)
+	d.geometry[w.ObjectID()] = geometry
+	d.Unlock()
+	d.RequestDraw()
+	d.RequestShow()
+	w.Emit(SignalMappedWindow, d)
+	d.RLock()
+	resized := d.resized
+	d.RUnlock()
+	if resized {
+		_ = d.PostEvent(NewEventAllocate(w, region))
+	}
+	if geometry.violated {
+		d.Emit(SignalWindowConstraintViolation, d, w, hints, available)
+	}
+}
+
+func (d *CDisplay) availableSize() (size ptypes.Rectangle) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.startedAndCaptured() {
+		size.W, size.H = d.screen.Size()
+	}
+	return
+}
+
+// screenSize returns the size of the screen captured, regardless of whether
+// the startup of the display has completed
+func (d *CDisplay) screenSize() (size ptypes.Rectangle) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.screen != nil {
+		size.W, size.H = d.screen.Size()
+	}
+	return
+}
+
+// constrainWindows applies the geometry hints of all mapped windows to the
+// given display size, emitting SignalWindowConstraintViolation for each window
+// which no longer fits. Windows without geometry hints are left unchanged.
+func (d *CDisplay) constrainWindows(available ptypes.Rectangle) {
+	for _, w := range d.GetWindows() {
+		hints := w.GetGeometryHints()
+		if !hints.IsSet() && d.GetWindowState(w) == WindowStateNormal {
+			continue
+		}
+		d.Lock()
+		geometry, ok := d.geometry[w.ObjectID()]
+		if !ok {
+			geometry = &cWindowGeometry{fill: true}
+			d.geometry[w.ObjectID()] = geometry
+		}
+		region := geometry.region
+		if geometry.fill {
+			region = ptypes.MakeRegion(0, 0, available.W, available.H)
+		}
+		ok = true
+		if hints.IsSet() {
+			region, ok = hints.Constrain(region, available)
+		}
+		region = geometry.state.apply(region, available)
+		wasViolated := geometry.violated
+		geometry.violated = !ok
+		d.Unlock()
+		style := w.GetTheme().Content.Normal
+		if err := MakeObjectSurface(w, region.Origin(), region.Size(), style); err != nil {
+			d.LogErr(err)
+		}
+		if !ok && !wasViolated {
+			d.Emit(SignalWindowConstraintViolation, d, w, hints, available)
+		}
+	}
+}
+
+// windowAllocation returns the region of the display, of the given size,
+// allocated to the Window
+func (d *CDisplay) windowAllocation(w Window, available ptypes.Rectangle) (region ptypes.Region) {
+	region = d.mappedWindowRegion(w, available)
+	return d.GetWindowState(w).apply(region, available)
+}
+
+// mappedWindowRegion returns the region of the display, of the given size,
+// the Window is mapped to regardless of its WindowState
+func (d *CDisplay) mappedWindowRegion(w Window, available ptypes.Rectangle) (region ptypes.Region) {
+	region = ptypes.MakeRegion(0, 0, available.W, available.H)
+	d.RLock()
+	if geometry, ok := d.geometry[w.ObjectID()]; ok && !geometry.fill {
+		region = geometry.region
+	}
+	d.RUnlock()
+	if hints := w.GetGeometryHints(); hints.IsSet() {
+		region, _ = hints.Constrain(region, available)
+	}
+	return
+}
+
+// allocateWindow delivers the EventAllocate to its Window and emits
+// SignalEventAllocate
+func (d *CDisplay) allocateWindow(e *EventAllocate) {
+	d.processWindowEvent(e.Window(), e)
+	d.Emit(SignalEventAllocate, d, e)
+}
+
+// IsWindowConstrained returns true if the given window is mapped and the
+// display is too small for the window's minimum size
+func (d *CDisplay) IsWindowConstrained(w Window) (violated bool) {
+	d.RLock()
+	defer d.RUnlock()
+	if geometry, ok := d.geometry[w.ObjectID()]; ok {
+		violated = geometry.violated
+	}
+	return
+}
+
+func (d *CDisplay) UnmapWindow(w Window) {
+	if idx := d.findMappedWindowIndex(w); idx > -1 {
+		d.LogDebug("unmapping window: %v", w.ObjectName())
+		d.stopWindowQueue(w)
+		ReleaseObjectSurface(w)
+		d.Lock()
+		d.windows = append(d.windows[:idx], d.windows[idx+1:]...)
+		delete(d.geometry, w.ObjectID())
+		delete(d.frameHistory, w.ObjectID())
+		delete(d.urgent, w.ObjectID())
+		d.releasePointerGrab(w)
+		d.releaseDragTarget(w)
+		if d.windowDrag != nil && d.windowDrag.window.ObjectID() == w.ObjectID() {
+			d.windowDrag = nil
+		}
+		if d.inspect != nil && d.inspect.window.ObjectID() == w.ObjectID() {
+			d.inspect = nil
+		}
+		var restoreFocusedWindow Window
+		if len(d.windows) > 0 {
+			restoreFocusedWindow = d.windows[0]
+		}
+		zoomed := d.zoom != nil && d.zoom.window.ObjectID() == w.ObjectID()
+		d.Unlock()
+		d.RequestDraw()
+		d.RequestShow()
+		w.Emit(SignalUnmappedWindow, d)
+		if zoomed {
+			d.Unzoom()
+			return
+		}
+		if restoreFocusedWindow != nil {
+			d.FocusWindow(restoreFocusedWindow)
+		}
+	}
+}
+
+// ZoomWindow temporarily maps the given window to the full size of the
+// display, above all others, recording the regions and stacking order of all
+// mapped windows so that Unzoom can restore them. Zooming a window while
+// another is zoomed first restores the layout.
+func (d *CDisplay) ZoomWindow(w Window) {
+	if zoomed := d.GetZoomedWindow(); zoomed != nil {
+		if zoomed.ObjectID() == w.ObjectID() {
+			return
+		}
+		d.Unzoom()
+	}
+	zoom := &cWindowZoom{
+		window:  w,
+		regions: make(map[uuid.UUID]cWindowGeometry),
+	}
+	d.RLock()
+	zoom.windows = append(zoom.windows, d.windows...)
+	for id, geometry := range d.geometry {
+		zoom.regions[id] = *geometry
+	}
+	d.RUnlock()
+	d.MapWindow(w)
+	d.Lock()
+	d.zoom = zoom
+	d.Unlock()
+	d.Emit(SignalWindowZoomed, d, w, true)
+}
+
+// Unzoom restores the regions and stacking order recorded by ZoomWindow.
+// Windows mapped while zoomed remain above the restored windows.
+func (d *CDisplay) Unzoom() {
+	d.Lock()
+	zoom := d.zoom
+	d.zoom = nil
+	d.Unlock()
+	if zoom == nil {
+		return
+	}
+	mapped := d.GetWindows()
+	for i := len(zoom.windows) - 1; i >= 0; i-- {
+		w := zoom.windows[i]
+		if d.findMappedWindowIndex(w) < 0 {
+			continue // unmapped while zoomed
+		}
+		if geometry, ok := zoom.regions[w.ObjectID()]; ok && !geometry.fill {
+			d.mapWindowWithRegion(w, geometry.region, false)
+		} else {
+			d.MapWindow(w)
+		}
+	}
+	if !zoom.recorded(zoom.window) {
+		// the zoomed window was not previously mapped
+		d.UnmapWindow(zoom.window)
+	}
+	d.Lock()
+	var added []Window
+	for _, w := range mapped {
+		if !zoom.recorded(w) && w.ObjectID() != zoom.window.ObjectID() {
+			added = append(added, w)
+		}
+	}
+	if len(added) > 0 {
+		var windows []Window
+		for _, w := range d.windows {
+			if !zoom.recorded(w) {
+				continue
+			}
+			windows = append(windows, w)
+		}
+		d.windows = append(added, windows...)
+	}
 	d.Unlock()
 	d.RequestDraw()
 	d.RequestShow()
-	w.Emit(SignalMappedWindow, d)
+	d.Emit(SignalWindowZoomed, d, zoom.window, false)
 }
 
-func (d *CDisplay) UnmapWindow(w Window) {
-	if idx := d.findMappedWindowIndex(w); idx > -1 {
-		d.LogDebug("unmapping window: %v", w.ObjectName())
-		d.Lock()
-		memphis.RemoveSurface(w.ObjectID())
-		d.windows = append(d.windows[:idx], d.windows[idx+1:]...)
-		var restoreFocusedWindow Window
-		if len(d.windows) > 0 {
-			restoreFocusedWindow = d.windows[0]
-		}
-		d.Unlock()
-		d.RequestDraw()
-		d.RequestShow()
-		w.Emit(SignalUnmappedWindow, d)
-		if restoreFocusedWindow != nil {
-			d.FocusWindow(restoreFocusedWindow)
-		}
+// GetZoomedWindow returns the window zoomed with ZoomWindow, or nil if no
+// window is zoomed
+func (d *CDisplay) GetZoomedWindow() (w Window) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.zoom != nil {
+		w = d.zoom.window
 	}
+	return
 }
 
 func (d *CDisplay) IsMappedWindow(w Window) (mapped bool) {
@@ -647,6 +1533,9 @@ func (d *CDisplay) GetWindows() (windows []Window) {
 func (d *CDisplay) GetWindowAtPoint(point ptypes.Point2I) (window Window) {
 	d.RLock()
 	for i := 0; i < len(d.windows); i++ {
+		if d.isWindowMinimized(d.windows[i]) {
+			continue
+		}
 		if surface, err := memphis.GetSurface(d.windows[i].ObjectID()); err != nil {
 			d.LogErr(err)
 		} else {
@@ -682,6 +1571,9 @@ func (d *CDisplay) SetEventFocus(widget Object) error {
 		d.Lock()
 		d.eventFocus = widget
 		d.Unlock()
+		if widget != nil {
+			d.AnnounceFocus(widget)
+		}
 	}
 	return nil
 }
@@ -699,12 +1591,24 @@ func (d *CDisplay) GetPriorEvent() (event Event) {
 	return d.priorEvent
 }
 
+// GetIdleTime returns how long it has been since the user last pressed a key,
+// used the mouse or pasted text, or since the Display was created if the user
+// has yet to do any of these
+func (d *CDisplay) GetIdleTime() (idle time.Duration) {
+	d.RLock()
+	defer d.RUnlock()
+	return time.Since(d.lastInput)
+}
+
 // ProcessEvent handles events sent from the Screen instance and manages passing
 // those events to the active window
 func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 	if !d.startedAndCaptured() {
 		return enums.EVENT_PASS
 	}
+	// events processed directly arrive here
+	d.stampEvent(evt)
+	d.stats.eventProcessed()
 
 	d.eventMutex.Lock()
 	defer func() {
@@ -714,8 +1618,13 @@ func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 		d.eventMutex.Unlock()
 	}()
 
+	return d.processEvent(evt)
+}
+
+// processEvent handles the event, the eventMutex must be held by the caller
+func (d *CDisplay) processEvent(evt Event) enums.EventFlag {
 	if _, ok := evt.(*EventQuit); ok {
-		d.done <- true
+		d.signalDone()
 		return enums.EVENT_STOP
 	}
 
@@ -724,6 +1633,12 @@ func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 		hasScreen := d.screen != nil
 		d.RUnlock()
 		if hasScreen {
+			if req = d.render.schedule(req, time.Now(), func(req *EventRender) { _ = d.PostEvent(req) }); req == nil {
+				// deferred to the next frame
+				return enums.EVENT_STOP
+			}
+			started := time.Now()
+			defer func() { d.render.frameDone(time.Since(started)) }()
 			if req.Draw() {
 				d.renderScreen()
 			}
@@ -731,19 +1646,46 @@ func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 				d.RLock()
 				if d.screen != nil {
 					d.screen.Sync()
+					d.stats.screenDone(d.screen.GetDrawStats())
 				}
 				d.RUnlock()
+				d.publishMirrors()
 			} else if req.Show() {
 				d.RLock()
 				if d.screen != nil {
 					d.screen.Show()
+					d.stats.screenDone(d.screen.GetDrawStats())
 				}
 				d.RUnlock()
+				d.publishMirrors()
 			}
 		}
 		return enums.EVENT_STOP
 	}
 
+	if e, ok := evt.(*EventDetach); ok {
+		return d.processDetach(e)
+	}
+
+	if _, ok := evt.(*EventReload); ok {
+		return d.processReload()
+	}
+
+	switch evt.(type) {
+	case *EventKey, *EventMouse, *EventPaste, *EventRaw:
+		d.Lock()
+		d.lastInput = time.Now()
+		d.Unlock()
+	}
+
+	if e, ok := evt.(*cShareFrame); ok {
+		return d.processShareFrame(e)
+	}
+
+	if f, viewing := d.processShareInput(evt); viewing {
+		return f
+	}
+
 	if d.eventFocus != nil {
 		if sensitive, ok := d.eventFocus.Self().(Sensitive); ok {
 			return sensitive.ProcessEvent(evt)
@@ -754,8 +1696,11 @@ func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 
 	switch e := evt.(type) {
 	case *EventPaste:
+		if e.text != "" {
+			return d.processPasteText(e)
+		}
 		if w := d.FocusedWindow(); w != nil {
-			if f := w.ProcessEvent(e); f == enums.EVENT_STOP {
+			if f := d.processWindowEvent(w, e); f == enums.EVENT_STOP {
 				d.RequestDraw()
 				d.RequestShow()
 				return enums.EVENT_STOP
@@ -768,10 +1713,74 @@ func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 		}
 		return enums.EVENT_PASS
 
+	case *EventRaw:
+		if w := d.FocusedWindow(); w != nil {
+			if f := d.processWindowEvent(w, e); f == enums.EVENT_STOP {
+				d.RequestDraw()
+				d.RequestShow()
+				return enums.EVENT_STOP
+			}
+		}
+		if f := d.Emit(SignalEventRaw, d, e); f == enums.EVENT_STOP {
+			d.RequestDraw()
+			d.RequestShow()
+			return enums.EVENT_STOP
+		}
+		return enums.EVENT_PASS
+
+	case *EventClipboard:
+		if clipboard, ok := d.GetClipboard().(*CClipboard); ok && clipboard != nil {
+			clipboard.pasteSelection(selectionFromOSC52(e.Selection()), e.Text())
+		}
+		if f := d.Emit(SignalEventClipboard, d, e); f == enums.EVENT_STOP {
+			d.RequestDraw()
+			d.RequestShow()
+			return enums.EVENT_STOP
+		}
+		return enums.EVENT_PASS
+
+	case *EventCommand:
+		if f := d.Emit(SignalEventCommand, d, e); f == enums.EVENT_STOP {
+			d.RequestDraw()
+			d.RequestShow()
+			return enums.EVENT_STOP
+		}
+		return enums.EVENT_PASS
+
+	case *EventCapabilities:
+		// colors may be drawn differently now, see: Screen.Capabilities
+		d.reloadTerminalPrefs()
+		d.Emit(SignalEventCapabilities, d, e)
+		d.RequestDraw()
+		d.RequestSync()
+		return enums.EVENT_STOP
+
+	case *EventPreedit:
+		d.Lock()
+		if e.Done() {
+			d.preedit = nil
+		} else {
+			d.preedit = e
+		}
+		d.Unlock()
+		if w := d.FocusedWindow(); w != nil {
+			if f := d.processWindowEvent(w, e); f == enums.EVENT_STOP {
+				d.RequestDraw()
+				d.RequestShow()
+				return enums.EVENT_STOP
+			}
+		}
+		if f := d.Emit(SignalEventPreedit, d, e); f == enums.EVENT_STOP {
+			d.RequestDraw()
+			d.RequestShow()
+			return enums.EVENT_STOP
+		}
+		return enums.EVENT_PASS
+
 	case *EventError:
 		d.LogError("EventError: %v", e)
 		if w := d.FocusedWindow(); w != nil {
-			if f := w.ProcessEvent(e); f == enums.EVENT_STOP {
+			if f := d.processWindowEvent(w, e); f == enums.EVENT_STOP {
 				d.RequestDraw()
 				d.RequestShow()
 				return enums.EVENT_STOP
@@ -796,27 +1805,60 @@ func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 				return enums.EVENT_STOP
 			}
 		}
-		if w := d.FocusedWindow(); w != nil {
-			if f := w.ProcessEvent(e); f == enums.EVENT_STOP {
-				d.RequestDraw()
-				d.RequestShow()
-				return enums.EVENT_STOP
-			}
+		if e.Key() == KeyEscape && d.CancelDrag() {
+			return enums.EVENT_STOP
 		}
-		if f := d.Emit(SignalEventKey, d, e); f == enums.EVENT_STOP {
+		d.Lock()
+		state, consumed, changed := d.unicodeInput.processKey(e)
+		replay := d.unicodeInput.takeReplay()
+		d.Unlock()
+		if changed {
+			d.Emit(SignalUnicodeInput, d, state)
 			d.RequestDraw()
 			d.RequestShow()
+		}
+		for _, key := range replay {
+			d.processKey(key)
+		}
+		if state.Committed {
+			e = NewEventKey(KeyRune, state.Rune, ModNone)
+		} else if consumed {
 			return enums.EVENT_STOP
 		}
-		return enums.EVENT_PASS
+		return d.processKey(e)
 
 	case *EventMouse:
+		if d.GetTerminalPrefs().InvertWheel {
+			e = e.CloneWithInvertedWheel()
+		}
 		d.Lock()
 		d.cursor.Set(e.Position())
 		d.cursorMoving = e.IsMoving() || e.IsDragging()
 		d.Unlock()
-		if w := d.FocusedWindow(); w != nil {
-			if f := w.ProcessEvent(e); f == enums.EVENT_STOP {
+		if f, dragging := d.processDrag(e); dragging {
+			return f
+		}
+		if f, dragging := d.processWindowDrag(e); dragging {
+			return f
+		}
+		if f, grabbed := d.processPointerGrab(e); grabbed {
+			if f == enums.EVENT_STOP {
+				d.RequestDraw()
+				d.RequestShow()
+			}
+			return f
+		}
+		// panes are beneath the windows, only those not covered by a window
+		// are given the mouse
+		if d.paneHasPointer(e) {
+			if f := d.processPaneMouse(e); f == enums.EVENT_STOP {
+				d.RequestDraw()
+				d.RequestShow()
+				return enums.EVENT_STOP
+			}
+		}
+		if w := d.pointerWindow(e); w != nil {
+			if f := d.processWindowEvent(w, e); f == enums.EVENT_STOP {
 				d.RequestDraw()
 				d.RequestShow()
 				return enums.EVENT_STOP
@@ -827,30 +1869,72 @@ func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 			d.RequestShow()
 			return enums.EVENT_STOP
 		}
+		if d.isPrimaryPasteClick(e) && d.PastePrimary() {
+			return enums.EVENT_STOP
+		}
 		return enums.EVENT_PASS
 
 	case *EventResize:
+		d.Lock()
+		if !e.initial && !d.resized {
+			// superseded by the initial resize, which is yet to be processed
+			d.Unlock()
+			return enums.EVENT_PASS
+		}
+		if e.initial && !d.resized {
+			// the size may have changed since startup completed
+			e.w, e.h = d.screen.Size()
+		}
+		d.resized = true
+		d.Unlock()
 		origin := ptypes.MakePoint2I(0, 0)
 		alloc := ptypes.MakeRectangle(e.Size())
 		style := d.GetTheme().Content.Normal
 		if err := memphis.MakeConfigureSurface(d.ObjectID(), origin, alloc, style); err != nil {
 			d.LogErr(err)
 		}
+		d.constrainWindows(alloc)
 		// all windows get resize event
 		for _, window := range d.GetWindows() {
-			window.ProcessEvent(e)
+			d.processWindowEvent(window, e)
 		}
 		f := d.Emit(SignalEventResize, d, e)
+		for _, window := range d.GetWindows() {
+			d.allocateWindow(NewEventAllocate(window, d.windowAllocation(window, alloc)))
+		}
 		d.RequestDraw()
 		d.RequestSync()
 		return f
 
+	case *EventIdle:
+		if w := d.FocusedWindow(); w != nil {
+			if f := d.processWindowEvent(w, e); f == enums.EVENT_STOP {
+				d.RequestDraw()
+				d.RequestShow()
+				return enums.EVENT_STOP
+			}
+		}
+		if f := d.Emit(SignalEventIdle, d, e); f == enums.EVENT_STOP {
+			d.RequestDraw()
+			d.RequestShow()
+			return enums.EVENT_STOP
+		}
+		return enums.EVENT_PASS
+
+	case *EventAllocate:
+		if d.findMappedWindowIndex(e.Window()) > -1 {
+			d.allocateWindow(e)
+			d.RequestDraw()
+			d.RequestShow()
+		}
+		return enums.EVENT_PASS
+
 	default:
 		d.LogWarn("processing unknown event: (%T)%v", e, e)
 	}
 
 	if w := d.FocusedWindow(); w != nil {
-		if f := w.ProcessEvent(evt); f == enums.EVENT_STOP {
+		if f := d.processWindowEvent(w, evt); f == enums.EVENT_STOP {
 			d.RequestDraw()
 			d.RequestShow()
 			return enums.EVENT_STOP
@@ -864,24 +1948,95 @@ func (d *CDisplay) ProcessEvent(evt Event) enums.EventFlag {
 	return enums.EVENT_PASS
 }
 
+// processKey passes the key to the accelerators, the focused window, the panes
+// and finally the SignalEventKey listeners, until one of them handles it
+func (d *CDisplay) processKey(e *EventKey) enums.EventFlag {
+	if action, consumed := d.GetAcceleratorMap().ProcessKey(e); consumed {
+		if action != "" {
+			d.LogTrace("display accelerator: %v", action)
+			d.Emit(SignalAccelerator, d, action, e)
+			d.RequestDraw()
+			d.RequestShow()
+		}
+		return enums.EVENT_STOP
+	}
+	if w := d.FocusedWindow(); w != nil {
+		if filter := w.GetInputFilter(); filter != nil && filter.HasFilters() {
+			var ok bool
+			if e, ok = filter.FilterEventKey("", e); !ok {
+				return enums.EVENT_STOP
+			}
+		}
+		if f := d.processWindowEvent(w, e); f == enums.EVENT_STOP {
+			d.RequestDraw()
+			d.RequestShow()
+			return enums.EVENT_STOP
+		}
+	}
+	// panes are beneath the windows, keys fall back to them
+	if f := d.processPaneKey(e); f == enums.EVENT_STOP {
+		d.RequestDraw()
+		d.RequestShow()
+		return enums.EVENT_STOP
+	}
+	if f := d.Emit(SignalEventKey, d, e); f == enums.EVENT_STOP {
+		d.RequestDraw()
+		d.RequestShow()
+		return enums.EVENT_STOP
+	}
+	return enums.EVENT_PASS
+}
+
 func (d *CDisplay) renderScreen() enums.EventFlag {
-	if !d.DisplayCaptured() || !d.IsRunning() {
+	if !d.DisplayCaptured() || !d.IsRunning() || d.IsDetached() || d.GetViewing() != nil {
 		return enums.EVENT_PASS
 	}
 	d.drawMutex.Lock()
 	defer d.drawMutex.Unlock()
 	d.Lock()
 	windows := d.windows
+	if d.zoom != nil {
+		// only the zoomed window is visible
+		windows = []Window{d.zoom.window}
+	}
 	d.Unlock()
 	if surface, err := memphis.GetSurface(d.ObjectID()); err == nil {
+		started := time.Now()
 		theme := d.GetTheme()
 		surface.Fill(theme)
+		d.drawPanes(surface, theme)
+		size := surface.GetSize()
+		sources := make([]*memphis.CSurface, 0, len(windows))
 		for i := len(windows) - 1; i >= 0; i-- {
-			windows[i].Draw()
-			if err := surface.Composite(windows[i].ObjectID()); err != nil {
-				d.LogErr(err)
+			if d.GetWindowState(windows[i]).Has(WindowStateMinimized) {
+				continue
 			}
+			if frame, ok := d.inspectedFrame(windows[i].ObjectID()); ok {
+				// present the recorded frame instead of drawing the window
+				sources = append(sources, frame.Surface)
+				continue
+			}
+			if d.IsWindowConstrained(windows[i]) {
+				if ws, err := memphis.GetSurface(windows[i].ObjectID()); err == nil {
+					DrawTerminalTooSmall(ws, windows[i].GetGeometryHints().MinSize, size, theme)
+				}
+			} else {
+				d.drawWindow(windows[i])
+			}
+			d.recordFrame(windows[i].ObjectID())
+			if ws, err := memphis.GetSurface(windows[i].ObjectID()); err == nil {
+				sources = append(sources, ws)
+			}
+		}
+		if icon := d.dragIconSurface(); icon != nil {
+			sources = append(sources, icon)
+		}
+		// windows are drawn before compositing, bottom window first
+		if err := memphis.CompositeSurfaces(surface, sources, d.GetRenderWorkers()); err != nil {
+			d.LogErr(err)
 		}
+		d.drawWatchdogNotice(surface, theme)
+		d.stats.drawDone(time.Since(started), len(windows))
 		d.Lock()
 		if d.screen != nil {
 			if err := surface.Render(d.screen); err != nil {
@@ -906,9 +2061,18 @@ func (d *CDisplay) RequestShow() {
 	_ = d.PostEvent(NewEventShow())
 }
 
-// RequestSync asks the Display to render everything in the Screen
+// RequestSync asks the Display to render everything in the Screen, not just
+// the changes since the last show
 func (d *CDisplay) RequestSync() {
-	_ = d.PostEvent(NewEventShow())
+	_ = d.PostEvent(NewEventSync())
+}
+
+// ForceRepaint asks the Display to draw all windows and then invalidate and
+// render everything in the Screen, recovering from a terminal which has been
+// garbled by other output. Unlike the other render requests, the repaint is
+// not deferred until the next frame, see: SetFrameRate
+func (d *CDisplay) ForceRepaint() {
+	_ = d.PostEvent(NewEventRenderRequest(RenderDraw | RenderSync | renderForce))
 }
 
 // RequestQuit asks the Display to quit nicely
@@ -929,11 +2093,16 @@ func (d *CDisplay) setRunning(isRunning bool) {
 	d.running = isRunning
 }
 
-// StartupComplete emits SignalStartupComplete
+// StartupComplete emits SignalStartupComplete and then posts the initial
+// EventResize, which is processed with the actual size of the screen. Events
+// are not processed until SignalStartupComplete has been emitted and any other
+// EventResize received before the initial one is discarded, so the initial
+// EventResize, followed by an EventAllocate for each mapped Window, is always
+// the first resize seen by the Display and its Windows.
 func (d *CDisplay) StartupComplete() {
 	w, h := d.resizeWindowSurfacesOnStartupCompleted()
 	d.Emit(SignalStartupComplete)
-	_ = d.PostEvent(NewEventResize(w, h))
+	_ = d.PostEvent(newInitialEventResize(w, h))
 }
 
 // AsyncCall runs the given DisplayCallbackFn on the UI thread, non-blocking
@@ -941,24 +2110,20 @@ func (d *CDisplay) AsyncCall(fn DisplayCallbackFn) error {
 	if !d.IsRunning() {
 		return fmt.Errorf("application not running")
 	}
-	d.queue <- fn
-	return nil
+	return d.calls.send(d.queue, fn)
 }
 
 // AwaitCall runs the given DisplayCallbackFn on the UI thread, blocking
 func (d *CDisplay) AwaitCall(fn DisplayCallbackFn) error {
-	if !d.IsRunning() {
-		return fmt.Errorf("application not running")
-	}
-	var err error
-	done := make(chan bool)
-	d.queue <- func(d Display) error {
-		err = fn(d)
-		done <- true
-		return nil
-	}
-	<-done
-	return err
+	return d.awaitCall(context.Background(), d.queue, fn)
+}
+
+// AwaitCallCtx runs the given DisplayCallbackFn on the UI thread, blocking
+// until it returns or the context is done. When the context is done first,
+// ctx.Err() is returned and the callback is skipped if it has not started
+// yet. A callback accepted before the Display shuts down is always run.
+func (d *CDisplay) AwaitCallCtx(ctx context.Context, fn DisplayCallbackFn) error {
+	return d.awaitCall(ctx, d.queue, fn)
 }
 
 // AsyncCallMain will run the given DisplayCallbackFn on the main runner thread,
@@ -967,25 +2132,63 @@ func (d *CDisplay) AsyncCallMain(fn DisplayCallbackFn) error {
 	if !d.IsRunning() {
 		return fmt.Errorf("application not running")
 	}
-	d.mains <- fn
-	return nil
+	return d.calls.send(d.mains, fn)
 }
 
 // AwaitCallMain will run the given DisplayCallbackFn on the main runner thread,
 // blocking
 func (d *CDisplay) AwaitCallMain(fn DisplayCallbackFn) error {
+	return d.awaitCall(context.Background(), d.mains, fn)
+}
+
+// AwaitCallMainCtx will run the given DisplayCallbackFn on the main runner
+// thread, blocking until it returns or the context is done, see: AwaitCallCtx
+func (d *CDisplay) AwaitCallMainCtx(ctx context.Context, fn DisplayCallbackFn) error {
+	return d.awaitCall(ctx, d.mains, fn)
+}
+
+// awaitCall sends the callback to the channel and waits for the result. The
+// result channel is buffered so that a callback run after the waiter has
+// given up, such as while Main drains the channels during shutdown, never
+// blocks the thread running it.
+func (d *CDisplay) awaitCall(ctx context.Context, ch chan DisplayCallbackFn, fn DisplayCallbackFn) error {
 	if !d.IsRunning() {
 		return fmt.Errorf("application not running")
 	}
-	var err error
-	done := make(chan bool)
-	d.mains <- func(d Display) error {
-		err = fn(d)
-		done <- true
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	result := make(chan error, 1)
+	if e := d.calls.sendContext(ctx, ch, func(d Display) error {
+		if err := ctx.Err(); err != nil {
+			result <- err
+			return nil
+		}
+		completed := false
+		defer func() {
+			if !completed {
+				result <- ErrDisplayCallPanic
+			}
+		}()
+		err := fn(d)
+		completed = true
+		result <- err
 		return nil
+	}); e != nil {
+		return e
 	}
-	<-done
-	return err
+	select {
+	case err := <-result:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PendingCalls returns the number of AsyncCall/AwaitCall and
+// AsyncCallMain/AwaitCallMain callbacks waiting to be run
+func (d *CDisplay) PendingCalls() (queue, mains int) {
+	return len(d.queue), len(d.mains)
 }
 
 // PostEvent sends the given Event to the Display Screen for processing. This
@@ -995,18 +2198,24 @@ func (d *CDisplay) PostEvent(evt Event) error {
 	if !d.IsRunning() {
 		return fmt.Errorf("application not running")
 	}
-	d.events <- evt
-	return nil
+	d.stampEvent(evt)
+	return d.overflows[EventQueuePosted].post(d.events, evt, nil)
 }
 
 func (d *CDisplay) pollEventWorker(ctx context.Context) {
 	// this happens in its own go thread
 pollEventWorkerLoop:
 	for d.IsRunning() {
-		if d.DisplayCaptured() {
+		// the screen is released by Destroy once shutting down
+		if screen := d.Screen(); screen != nil && d.DisplayCaptured() {
 			select {
-			case evt := <-d.screen.PollEventChan():
-				d.inbound <- evt
+			case evt := <-screen.PollEventChan():
+				if ctx.Err() != nil {
+					// inbound is closed once shutting down
+					break pollEventWorkerLoop
+				}
+				d.stampEvent(evt)
+				_ = d.overflows[EventQueueInbound].post(d.inbound, evt, ctx.Done())
 			case <-ctx.Done():
 				break pollEventWorkerLoop
 			}
@@ -1043,10 +2252,16 @@ processEventWorkerLoop:
 
 		case fn, ok := <-d.queue:
 			if ok {
-				if err := fn(d); err != nil {
+				if err := d.callSafely(fn); err != nil {
 					log.ErrorF("async/await handler error: %v", err)
 				}
 			}
+
+		case watch := <-d.fdReady:
+			_ = d.callSafely(func(_ Display) error {
+				d.dispatchFDWatch(watch)
+				return nil
+			})
 		}
 	}
 }
@@ -1135,13 +2350,23 @@ func (d *CDisplay) Main(ctx context.Context, cancel context.CancelFunc, wg *sync
 		d.processEventWorker(ctx)
 		wg.Done()
 	})
+	wg.Add(1)
+	Go(func() {
+		d.renderStatsWorker(ctx)
+		wg.Done()
+	})
+	wg.Add(1)
+	Go(func() {
+		d.watchdogWorker(ctx)
+		wg.Done()
+	})
 mainForLoop:
 	for d.IsRunning() {
 		select {
 		case fn, ok := <-d.mains:
 			if ok {
 				if d.DisplayCaptured() {
-					if err := fn(d); err != nil {
+					if err := d.callSafely(fn); err != nil {
 						log.Error(err)
 					}
 				}
@@ -1156,35 +2381,72 @@ mainForLoop:
 			}
 		case <-d.done:
 			d.setRunning(false)
+			d.render.stop()
 			CancelAllTimeouts()
 			cancel() // notify threads to exit
+			// stop accepting calls, all accepted calls remain buffered
+			d.calls.close(d.queue, d.mains)
 			// guarantee main calls
-			for i := 0; i < len(d.mains); i++ {
-				if fn, ok := <-d.mains; ok {
-					if err := fn(d); err != nil {
-						log.Error(err)
-					}
+			for fn := range d.mains {
+				if err := d.callSafely(fn); err != nil {
+					log.Error(err)
 				}
 			}
 			// guarantee async calls
-			for i := 0; i < len(d.queue); i++ {
-				if fn, ok := <-d.queue; ok {
-					if err := fn(d); err != nil {
-						log.ErrorF("async/await handler error: %v", err)
-					}
+			for fn := range d.queue {
+				if err := d.callSafely(fn); err != nil {
+					log.ErrorF("async/await handler error: %v", err)
 				}
 			}
 			break mainForLoop
 		}
 	}
+	// the goroutines of the workers only exit once stopped
+	d.stopWorkers()
+	d.waitGoroutines()
 	d.Destroy()
-	if p := recover(); p != nil {
-		panic(p)
-	}
 	d.Emit(SignalDisplayShutdown)
 	return nil
 }
 
+// callSafely runs the given DisplayCallbackFn, recovering from any panic
+func (d *CDisplay) callSafely(fn DisplayCallbackFn) (err error) {
+	defer d.watchdog.leave(d.watchdog.enter())
+	defer d.recoverPanic()
+	err = fn(d)
+	return
+}
+
+// processEventSafely calls ProcessEvent, recovering from any panic
+func (d *CDisplay) processEventSafely(evt Event) (flag enums.EventFlag) {
+	defer d.watchdog.leave(d.watchdog.enter())
+	defer d.recoverPanic()
+	flag = d.ProcessEvent(evt)
+	return
+}
+
+// recoverPanic must be deferred directly, see: handlePanic
+func (d *CDisplay) recoverPanic() {
+	if value := recover(); value != nil {
+		d.handlePanic(value, debug.Stack())
+	}
+}
+
+// handlePanic logs the recovered value with the stack trace and emits a
+// SignalDisplayPanic. If a listener returns EVENT_STOP, the panic is considered
+// handled and the Display continues running (for example, to present a crash
+// dialog). Otherwise the terminal is restored and the Display shuts down.
+func (d *CDisplay) handlePanic(value interface{}, stack []byte) {
+	d.LogError("recovered from panic: %v\n%s", value, stack)
+	if f := d.Emit(SignalDisplayPanic, value, stack); f == enums.EVENT_STOP {
+		return
+	}
+	d.ReleaseDisplay()
+	if d.IsRunning() {
+		d.signalDone()
+	}
+}
+
 // MainFinish cleans up any pending internal processes remaining after Main()
 // has completed processing.
 func (d *CDisplay) MainFinish() {
@@ -1214,8 +2476,8 @@ func (d *CDisplay) HasBufferedEvents() (hasEvents bool) {
 	return
 }
 
-// IterateBufferedEvents compresses the pending event buffer by reducing
-// multiple events of the same type to just the last ones received. Each
+// IterateBufferedEvents compresses the pending event buffer using the
+// EventCompressor of the Display, see SetEventCompressor. Each
 // remaining pending event is then processed. If any of the events return
 // EVENT_STOP from their signal listeners, draw and show requests are made to
 // refresh the display contents.
@@ -1230,60 +2492,45 @@ func (d *CDisplay) IterateBufferedEvents() (refreshed bool) {
 	d.Unlock()
 
 	var render *EventRender
-	pending := make([]interface{}, 0)
+	pending := make([]Event, 0)
+
+	var compressor EventCompressor
+	if d.GetCompressEvents() {
+		compressor = d.GetEventCompressor()
+	}
 
 	for _, e := range buffer {
 		switch t := e.(type) {
-		case *EventPaste, *EventKey:
-			// never compress paste or keys
-			pending = append(pending, t)
-
 		case *EventRender:
 			// always compress render into a single request event
-			if render == nil {
-				render = t
-			} else if t.Draw() && !render.Draw() {
-				render = NewEventRender(true, render.Show(), render.Sync())
-			} else if t.Show() && !render.Show() && !render.Sync() {
-				render = NewEventRender(render.Draw(), true, render.Sync())
-			} else if t.Sync() && !render.Sync() {
-				render = NewEventRender(render.Draw(), false, true)
-			}
+			render = mergeEventRender(render, t)
 
-		default:
-			if d.GetCompressEvents() {
-				last := len(pending) - 1
-				if last <= 0 {
-					pending = append(pending, t)
-				} else {
-					// only compressing repeats
-					thisType := fmt.Sprintf("%T", t)
-					lastType := fmt.Sprintf("%T", pending[last])
-					if thisType == lastType {
-						pending[last] = t
-					} else {
-						pending = append(pending, t)
-					}
+		case *cShareFrame:
+			// never compress share frames, each only has the changed cells
+			pending = append(pending, t)
+
+		case Event:
+			if last := len(pending) - 1; compressor != nil && last >= 0 {
+				if merged, ok := compressor.Compress(pending[last], t); ok {
+					pending[last] = merged
+					continue
 				}
-			} else {
-				pending = append(pending, t)
 			}
+			pending = append(pending, t)
 		}
 	}
 
 	buffer = nil
 
 	stopped := false
-	for _, e := range pending {
-		if evt, ok := e.(Event); ok {
-			if f := d.ProcessEvent(evt); f == enums.EVENT_STOP {
-				stopped = true
-			}
+	for _, evt := range pending {
+		if f := d.processEventSafely(evt); f == enums.EVENT_STOP {
+			stopped = true
 		}
 	}
 
 	if render != nil {
-		d.ProcessEvent(render)
+		d.processEventSafely(render)
 		return true
 	} else if stopped {
 		d.RequestDraw()
@@ -1302,14 +2549,50 @@ const (
 	SignalEventKey            Signal = "event-key"
 	SignalEventMouse          Signal = "event-mouse"
 	SignalEventResize         Signal = "event-resize"
+	SignalEventAllocate       Signal = "event-allocate"
 	SignalEventPaste          Signal = "event-paste"
+	SignalEventPreedit        Signal = "event-preedit"
+	SignalEventClipboard      Signal = "event-clipboard"
+	SignalEventCapabilities   Signal = "event-capabilities"
+	SignalEventRaw            Signal = "event-raw"
+	SignalEventCommand        Signal = "event-command"
+	SignalEventOverflow       Signal = "event-overflow"
+	SignalAnnounce            Signal = "announce"
+	SignalAnnounceFocus       Signal = "announce-focus"
+	SignalNotify              Signal = "notify"
+	SignalBell                Signal = "bell"
+	SignalWindowUrgency       Signal = "window-urgency"
+	SignalEventIdle           Signal = "event-idle"
+	SignalAccelerator         Signal = "accelerator"
+	SignalSetLocale           Signal = "set-locale"
+	SignalUnicodeInput        Signal = "unicode-input"
 	SignalSetEventFocus       Signal = "set-event-focus"
+	SignalGrabPointer         Signal = "grab-pointer"
+	SignalUngrabPointer       Signal = "ungrab-pointer"
+	SignalFocusChanged        Signal = "focus-changed"
+	SignalDragStart           Signal = "drag-start"
+	SignalDragDrop            Signal = "drag-drop"
+	SignalDragCancel          Signal = "drag-cancel"
 	SignalStartupComplete     Signal = "startup-complete"
 	SignalDisplayStartup      Signal = "display-startup"
 	SignalDisplayShutdown     Signal = "display-shutdown"
+	SignalDisplayPanic        Signal = "display-panic"
+	SignalWindowZoomed        Signal = "window-zoomed"
+	SignalWindowMoved         Signal = "window-moved"
+	SignalWindowResized       Signal = "window-resized"
+	SignalWindowStateChanged  Signal = "window-state-changed"
+	SignalWindowFrameInspect  Signal = "window-frame-inspect"
+	SignalThemeChanged        Signal = "theme-changed"
+	SignalRenderStats         Signal = "render-stats"
 	SignalMappedWindow        Signal = "mapped-window"
+	SignalUIStall             Signal = "ui-stall"
+	SignalDisplayDetached     Signal = "display-detached"
+	SignalDisplayReattached   Signal = "display-reattached"
+	SignalShareStarted        Signal = "share-started"
+	SignalShareEnded          Signal = "share-ended"
 	SignalUnmappedWindow      Signal = "unmapped-window"
 	SignalFocusedWindow       Signal = "focused-window"
+	SignalFocusedPane         Signal = "focused-pane"
 	SignalFocusNextWindow     Signal = "focus-next-window"
 	SignalFocusPreviousWindow Signal = "focus-previous-window"
 )
@@ -1318,10 +2601,21 @@ const (
 	PropertyDisplayName Property = "display-name"
 	PropertyDisplayUser Property = "display-user"
 	PropertyDisplayHost Property = "display-host"
+	// PropertyDisplayAuthMethod is the method used to authenticate the client
+	// of an ApplicationServer, see: ServerAuthExtensionMethod
+	PropertyDisplayAuthMethod Property = "display-auth-method"
+	// PropertyDisplayAuthFingerprint is the fingerprint of the public key used
+	// to authenticate the client of an ApplicationServer, if any
+	PropertyDisplayAuthFingerprint Property = "display-auth-fingerprint"
+	// PropertyDisplayAuthExtensions is the map[string]string of permissions
+	// extensions given by the ServerAuthHandler which authenticated the client
+	// of an ApplicationServer
+	PropertyDisplayAuthExtensions Property = "display-auth-extensions"
 )
 
 const (
 	DisplayStartupCompleteHandle = "display-screen-startup-complete-handler"
+	DisplayLogNameHandle         = "display-log-name-handler"
 )
 
 type DisplayCallbackFn = func(d Display) error
@@ -1342,3 +2636,25 @@ func DisplaySignalDisplayStartupArgv(argv ...interface{}) (ctx context.Context,
 	}
 	return
 }
+
+func DisplaySignalDisplayPanicArgv(argv ...interface{}) (value interface{}, stack []byte, ok bool) {
+	if len(argv) == 2 {
+		if stack, ok = argv[1].([]byte); ok {
+			value = argv[0]
+			return
+		}
+	}
+	return
+}
+
+func DisplaySignalUIStallArgv(argv ...interface{}) (stalled time.Duration, stacks []byte, ok bool) {
+	if len(argv) == 3 {
+		if stalled, ok = argv[1].(time.Duration); ok {
+			if stacks, ok = argv[2].([]byte); ok {
+				return
+			}
+			stalled = 0
+		}
+	}
+	return
+}
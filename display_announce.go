@@ -0,0 +1,187 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+// AnnouncePriority indicates how urgently an announcement is to be presented
+// to the user by the accessibility backends.
+type AnnouncePriority int
+
+const (
+	// AnnounceLow is for progress and other background information which may
+	// be dropped when the backend is busy
+	AnnounceLow AnnouncePriority = iota
+	// AnnounceNormal is for regular information, such as describing the
+	// focused element, which replaces any pending normal announcement
+	AnnounceNormal
+	// AnnounceHigh is for important information which interrupts any other
+	// announcement in progress
+	AnnounceHigh
+)
+
+func (p AnnouncePriority) String() string {
+	switch p {
+	case AnnounceLow:
+		return "low"
+	case AnnounceNormal:
+		return "normal"
+	case AnnounceHigh:
+		return "high"
+	}
+	return fmt.Sprintf("AnnouncePriority(%d)", int(p))
+}
+
+// Announcer is an accessibility backend presenting announcements to the user,
+// such as a speech synthesizer or braille display.
+type Announcer interface {
+	Announce(text string, priority AnnouncePriority) (err error)
+	Close() (err error)
+}
+
+// Accessible is implemented by objects able to describe themselves to the
+// user of an accessibility backend, used when they receive the focus.
+type Accessible interface {
+	AccessibleDescription() (description string)
+}
+
+// FocusDescriberFn returns the description of the given focused object, an
+// empty description is not announced.
+type FocusDescriberFn = func(focus interface{}) (description string)
+
+// AddAnnouncer adds the Announcer to the accessibility backends receiving the
+// announcements made with Announce
+func (d *CDisplay) AddAnnouncer(announcer Announcer) {
+	d.Lock()
+	d.announcers = append(d.announcers, announcer)
+	d.Unlock()
+}
+
+// RemoveAnnouncer removes and closes the Announcer
+func (d *CDisplay) RemoveAnnouncer(announcer Announcer) {
+	d.Lock()
+	for idx, a := range d.announcers {
+		if a == announcer {
+			d.announcers = append(d.announcers[:idx], d.announcers[idx+1:]...)
+			break
+		}
+	}
+	d.Unlock()
+	if err := announcer.Close(); err != nil {
+		d.LogErr(err)
+	}
+}
+
+// GetAnnouncers returns the accessibility backends added to the Display
+func (d *CDisplay) GetAnnouncers() (announcers []Announcer) {
+	d.RLock()
+	defer d.RUnlock()
+	announcers = append(announcers, d.announcers...)
+	return
+}
+
+// Announce presents the text to the user with each of the accessibility
+// backends. A SignalAnnounce listener returning EVENT_STOP suppresses the
+// announcement. The first of any backend errors is returned.
+func (d *CDisplay) Announce(text string, priority AnnouncePriority) (err error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return nil
+	}
+	if f := d.Emit(SignalAnnounce, d, text, priority); f == enums.EVENT_STOP {
+		return nil
+	}
+	for _, announcer := range d.GetAnnouncers() {
+		if e := announcer.Announce(text, priority); e != nil {
+			d.LogErr(e)
+			if err == nil {
+				err = e
+			}
+		}
+	}
+	return
+}
+
+// SetFocusDescriber sets the function describing focused objects, allowing
+// widget toolkits to describe their elements. A nil describer restores the
+// default, which uses the Accessible interface or the title of a Window.
+func (d *CDisplay) SetFocusDescriber(fn FocusDescriberFn) {
+	d.Lock()
+	d.describer = fn
+	d.Unlock()
+}
+
+// AnnounceFocus describes the newly focused object to the user. The Display
+// announces focused windows and event focus changes, widget toolkits call this
+// when the focus moves between the elements within a window. A
+// SignalAnnounceFocus listener returning EVENT_STOP suppresses the
+// announcement.
+func (d *CDisplay) AnnounceFocus(focus interface{}) {
+	if focus == nil {
+		return
+	}
+	d.RLock()
+	describer := d.describer
+	enabled := len(d.announcers) > 0
+	d.RUnlock()
+	if !enabled && !d.HasListeners(SignalAnnounceFocus) {
+		return
+	}
+	var description string
+	if describer != nil {
+		description = describer(focus)
+	} else {
+		description = describeFocus(focus)
+	}
+	if description == "" {
+		return
+	}
+	if f := d.Emit(SignalAnnounceFocus, d, focus, description); f == enums.EVENT_STOP {
+		return
+	}
+	_ = d.Announce(description, AnnounceNormal)
+}
+
+// closeAnnouncers closes and removes all accessibility backends
+func (d *CDisplay) closeAnnouncers() {
+	d.Lock()
+	announcers := d.announcers
+	d.announcers = nil
+	d.Unlock()
+	for _, a := range announcers {
+		if err := a.Close(); err != nil {
+			d.LogErr(err)
+		}
+	}
+}
+
+// describeFocus is the default FocusDescriberFn
+func describeFocus(focus interface{}) (description string) {
+	if o, ok := focus.(Object); ok && o.Self() != nil {
+		focus = o.Self()
+	}
+	switch f := focus.(type) {
+	case Accessible:
+		return f.AccessibleDescription()
+	case Window:
+		return f.GetTitle()
+	}
+	return ""
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bufio"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+type cTestAnnouncer struct {
+	texts      []string
+	priorities []AnnouncePriority
+	closed     bool
+}
+
+func (a *cTestAnnouncer) Announce(text string, priority AnnouncePriority) (err error) {
+	a.texts = append(a.texts, text)
+	a.priorities = append(a.priorities, priority)
+	return nil
+}
+
+func (a *cTestAnnouncer) Close() (err error) {
+	a.closed = true
+	return nil
+}
+
+type cTestAccessible struct{}
+
+func (a *cTestAccessible) AccessibleDescription() string {
+	return "accessible button"
+}
+
+// serveTestSSIP accepts one connection, replying to each SSIP command and
+// sending the lines received on the returned channel once disconnected
+func serveTestSSIP(listener net.Listener) (received chan []string) {
+	received = make(chan []string, 1)
+	go func() {
+		var lines []string
+		defer func() { received <- lines }()
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer func() { _ = conn.Close() }()
+		reader := bufio.NewReader(conn)
+		speaking := false
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			lines = append(lines, line)
+			switch {
+			case speaking && line == ".":
+				speaking = false
+				_, _ = conn.Write([]byte("225-21\r\n225 OK MESSAGE QUEUED\r\n"))
+			case speaking:
+			case line == "SPEAK":
+				speaking = true
+				_, _ = conn.Write([]byte("230 OK RECEIVING DATA\r\n"))
+			case line == "QUIT":
+				_, _ = conn.Write([]byte("231 HAPPY HACKING\r\n"))
+				return
+			default:
+				_, _ = conn.Write([]byte("208 OK\r\n"))
+			}
+		}
+	}()
+	return
+}
+
+func TestDisplayAnnounce(t *testing.T) {
+	Convey("Announce priorities", t, func() {
+		So(AnnounceLow.String(), ShouldEqual, "low")
+		So(AnnounceHigh.String(), ShouldEqual, "high")
+		So(AnnouncePriority(7).String(), ShouldEqual, "AnnouncePriority(7)")
+		So(ssipPriority(AnnounceLow), ShouldEqual, "notification")
+		So(ssipPriority(AnnounceNormal), ShouldEqual, "text")
+		So(ssipPriority(AnnounceHigh), ShouldEqual, "important")
+	})
+	Convey("Speech Dispatcher announcer", t, func() {
+		path := filepath.Join(t.TempDir(), "speechd.sock")
+		listener, err := net.Listen("unix", path)
+		So(err, ShouldBeNil)
+		defer func() { _ = listener.Close() }()
+		received := serveTestSSIP(listener)
+		s, err := NewSpeechDispatcherWith("unix", path, "my app")
+		So(err, ShouldBeNil)
+		So(s.Announce("hello\n.world", AnnounceHigh), ShouldBeNil)
+		So(s.Close(), ShouldBeNil)
+		So(s.Close(), ShouldBeNil)
+		So(s.Announce("closed", AnnounceNormal), ShouldNotBeNil)
+		lines := <-received
+		So(len(lines), ShouldEqual, 7)
+		So(lines[0], ShouldEndWith, ":my_app:main")
+		So(lines[1:], ShouldResemble, []string{
+			"SET self PRIORITY important",
+			"SPEAK",
+			"hello",
+			"..world",
+			".",
+			"QUIT",
+		})
+	})
+	Convey("Speech Dispatcher address", t, func() {
+		t.Setenv("SPEECHD_ADDRESS", "unix_socket:/tmp/speechd.sock")
+		network, address := SpeechDispatcherAddress()
+		So(network, ShouldEqual, "unix")
+		So(address, ShouldEqual, "/tmp/speechd.sock")
+		t.Setenv("SPEECHD_ADDRESS", "inet_socket:localhost")
+		network, address = SpeechDispatcherAddress()
+		So(network, ShouldEqual, "tcp")
+		So(address, ShouldEqual, "localhost:6560")
+		t.Setenv("SPEECHD_ADDRESS", "")
+		t.Setenv("XDG_RUNTIME_DIR", "/run/user/1000")
+		network, address = SpeechDispatcherAddress()
+		So(network, ShouldEqual, "unix")
+		So(address, ShouldEqual, "/run/user/1000/speech-dispatcher/speechd.sock")
+	})
+	Convey("Display announcements", t, WithDisplayManager(func(d Display) {
+		a := &cTestAnnouncer{}
+		d.AddAnnouncer(a)
+		So(d.GetAnnouncers(), ShouldHaveLength, 1)
+		So(d.Announce("  saved  ", AnnounceHigh), ShouldBeNil)
+		So(d.Announce(" ", AnnounceHigh), ShouldBeNil)
+		So(a.texts, ShouldResemble, []string{"saved"})
+		So(a.priorities, ShouldResemble, []AnnouncePriority{AnnounceHigh})
+		d.Connect(SignalAnnounce, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return enums.EVENT_STOP
+		})
+		So(d.Announce("suppressed", AnnounceNormal), ShouldBeNil)
+		So(a.texts, ShouldHaveLength, 1)
+		_ = d.Disconnect(SignalAnnounce, "testing")
+		Convey("focus changes are described", func() {
+			w := NewOffscreenWindow("main window")
+			d.FocusWindow(w)
+			So(a.texts, ShouldResemble, []string{"saved", "main window"})
+			So(a.priorities[1], ShouldEqual, AnnounceNormal)
+			d.AnnounceFocus(&cTestAccessible{})
+			So(a.texts[2], ShouldEqual, "accessible button")
+			d.AnnounceFocus("undescribed")
+			So(a.texts, ShouldHaveLength, 3)
+			d.SetFocusDescriber(func(focus interface{}) string {
+				return "described " + focus.(string)
+			})
+			d.AnnounceFocus("item")
+			So(a.texts[3], ShouldEqual, "described item")
+			d.SetFocusDescriber(nil)
+			var described string
+			d.Connect(SignalAnnounceFocus, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				described = argv[2].(string)
+				return enums.EVENT_STOP
+			})
+			d.AnnounceFocus(&cTestAccessible{})
+			So(described, ShouldEqual, "accessible button")
+			So(a.texts, ShouldHaveLength, 4)
+		})
+		Convey("removed announcers are closed", func() {
+			d.RemoveAnnouncer(a)
+			So(a.closed, ShouldBeTrue)
+			So(d.GetAnnouncers(), ShouldHaveLength, 0)
+		})
+	}))
+	Convey("Terminal announcer", t, WithDisplayManager(func(d Display) {
+		So(encodeNotify(NotifyOSC777, "a;b\x1b", "c;d\x07"), ShouldEqual, "\x1b]777;notify;a:b;c;d\x1b\\")
+		d.SetTitle("title")
+		ta := NewTerminalAnnouncer(d, AnnounceNormal)
+		So(ta.Announce("ignored", AnnounceLow), ShouldBeNil)
+		So(ta.Announce("hello", AnnounceNormal), ShouldBeNil)
+		So(ta.Close(), ShouldBeNil)
+		screen := d.Screen().(*COffScreen)
+		So(screen.notices, ShouldResemble, []string{"\x1b]777;notify;title;hello\x1b\\"})
+		d.ReleaseDisplay()
+		So(ta.Announce("released", AnnounceHigh), ShouldNotBeNil)
+	}))
+}
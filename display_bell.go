@@ -0,0 +1,145 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+// BellStyle is how Display.Bell alerts the user
+type BellStyle uint8
+
+const (
+	// BellAudible rings the terminal bell
+	BellAudible BellStyle = iota
+	// BellVisual flashes the screen, see: Screen.VisualBell
+	BellVisual
+	// BellBoth rings the terminal bell and flashes the screen
+	BellBoth
+	// BellNone does nothing, the SignalBell is still emitted
+	BellNone
+)
+
+func (b BellStyle) String() string {
+	switch b {
+	case BellAudible:
+		return "audible"
+	case BellVisual:
+		return "visual"
+	case BellBoth:
+		return "both"
+	case BellNone:
+		return "none"
+	}
+	return fmt.Sprintf("BellStyle(%d)", int(b))
+}
+
+// SetBellStyle changes how Bell alerts the user
+func (d *CDisplay) SetBellStyle(style BellStyle) {
+	d.Lock()
+	d.bellStyle = style
+	d.Unlock()
+}
+
+// GetBellStyle returns how Bell alerts the user
+func (d *CDisplay) GetBellStyle() (style BellStyle) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.bellStyle
+}
+
+// Bell alerts the user with the BellStyle of the Display. A SignalBell
+// listener returning EVENT_STOP handles the bell instead, for example by
+// flashing a single widget.
+func (d *CDisplay) Bell() (err error) {
+	style := d.GetBellStyle()
+	if f := d.Emit(SignalBell, d, style); f == enums.EVENT_STOP {
+		return nil
+	}
+	screen := d.Screen()
+	if screen == nil {
+		return fmt.Errorf("display is not captured")
+	}
+	switch style {
+	case BellAudible:
+		err = screen.Beep()
+	case BellVisual:
+		err = screen.VisualBell(0)
+	case BellBoth:
+		if err = screen.Beep(); err == nil {
+			err = screen.VisualBell(0)
+		}
+	}
+	return
+}
+
+// SetUrgency marks the window as requiring the attention of the user, or not.
+// Terminals map the bell to their urgency hint, such as the taskbar flashing,
+// so the terminal bell is rung when a window becomes urgent. The urgency of a
+// window is cleared once it is focused. SignalWindowUrgency is emitted when
+// the urgency of a window changes.
+func (d *CDisplay) SetUrgency(w Window, urgent bool) {
+	if w == nil {
+		return
+	}
+	d.Lock()
+	if _, ok := d.urgent[w.ObjectID()]; ok == urgent {
+		d.Unlock()
+		return
+	}
+	if urgent {
+		if d.urgent == nil {
+			d.urgent = make(map[uuid.UUID]Window)
+		}
+		d.urgent[w.ObjectID()] = w
+	} else {
+		delete(d.urgent, w.ObjectID())
+	}
+	screen := d.screen
+	d.Unlock()
+	d.Emit(SignalWindowUrgency, d, w, urgent)
+	if urgent && screen != nil {
+		if err := screen.Beep(); err != nil {
+			d.LogErr(err)
+		}
+	}
+}
+
+// IsUrgent returns true if the window requires the attention of the user
+func (d *CDisplay) IsUrgent(w Window) (urgent bool) {
+	if w == nil {
+		return false
+	}
+	d.RLock()
+	defer d.RUnlock()
+	_, urgent = d.urgent[w.ObjectID()]
+	return
+}
+
+// GetUrgentWindows returns the windows requiring the attention of the user
+func (d *CDisplay) GetUrgentWindows() (windows []Window) {
+	d.RLock()
+	defer d.RUnlock()
+	for _, w := range d.windows {
+		if _, ok := d.urgent[w.ObjectID()]; ok {
+			windows = append(windows, w)
+		}
+	}
+	return
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestDisplayBell(t *testing.T) {
+	Convey("Screen visual bell", t, func() {
+		env := map[string]string{"TERM": "xterm"}
+		s, err := NewScreenWithEnv(func(key string) (value string, ok bool) {
+			value, ok = env[key]
+			return
+		})
+		So(err, ShouldBeNil)
+		cs := s.(*CScreen)
+		cs.buffering = true
+		So(s.VisualBell(time.Millisecond*20), ShouldBeNil)
+		So(s.VisualBell(time.Millisecond*20), ShouldBeNil)
+		output := func() string {
+			cs.Lock()
+			defer cs.Unlock()
+			return cs.buf.String()
+		}
+		So(output(), ShouldEqual, "\x1b[?5h")
+		for i := 0; i < 100 && !strings.HasSuffix(output(), "\x1b[?5l"); i++ {
+			time.Sleep(time.Millisecond * 5)
+		}
+		So(output(), ShouldEqual, "\x1b[?5h\x1b[?5l")
+	})
+	Convey("Display bell styles", t, WithDisplayManager(func(d Display) {
+		screen := d.Screen().(*COffScreen)
+		So(d.GetBellStyle(), ShouldEqual, BellAudible)
+		So(d.Bell(), ShouldBeNil)
+		So(screen.beeps, ShouldEqual, 1)
+		So(screen.flashes, ShouldEqual, 0)
+		d.SetBellStyle(BellVisual)
+		So(d.Bell(), ShouldBeNil)
+		So(screen.beeps, ShouldEqual, 1)
+		So(screen.flashes, ShouldEqual, 1)
+		d.SetBellStyle(BellBoth)
+		So(d.Bell(), ShouldBeNil)
+		So(screen.beeps, ShouldEqual, 2)
+		So(screen.flashes, ShouldEqual, 2)
+		d.SetBellStyle(BellNone)
+		So(d.Bell(), ShouldBeNil)
+		So(screen.beeps, ShouldEqual, 2)
+		So(screen.flashes, ShouldEqual, 2)
+		So(BellNone.String(), ShouldEqual, "none")
+		d.SetBellStyle(BellAudible)
+		var style BellStyle = BellNone
+		d.Connect(SignalBell, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			style = argv[1].(BellStyle)
+			return enums.EVENT_STOP
+		})
+		So(d.Bell(), ShouldBeNil)
+		So(style, ShouldEqual, BellAudible)
+		So(screen.beeps, ShouldEqual, 2)
+	}))
+	Convey("Window urgency", t, WithDisplayManager(func(d Display) {
+		screen := d.Screen().(*COffScreen)
+		main := NewOffscreenWindow("main")
+		other := NewOffscreenWindow("other")
+		d.MapWindow(main)
+		d.MapWindow(other)
+		var changes []bool
+		d.Connect(SignalWindowUrgency, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			So(argv[1], ShouldEqual, other)
+			changes = append(changes, argv[2].(bool))
+			return enums.EVENT_PASS
+		})
+		d.SetUrgency(other, true)
+		d.SetUrgency(other, true)
+		So(d.IsUrgent(other), ShouldBeTrue)
+		So(d.IsUrgent(main), ShouldBeFalse)
+		So(d.GetUrgentWindows(), ShouldResemble, []Window{other})
+		So(changes, ShouldResemble, []bool{true})
+		So(screen.beeps, ShouldEqual, 1)
+		d.FocusWindow(other)
+		So(d.IsUrgent(other), ShouldBeFalse)
+		So(d.GetUrgentWindows(), ShouldBeEmpty)
+		So(changes, ShouldResemble, []bool{true, false})
+		So(screen.beeps, ShouldEqual, 1)
+	}))
+}
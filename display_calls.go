@@ -0,0 +1,82 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// ErrDisplayShutdown is returned by the Display call methods when the Display
+// is shutting down and no longer accepts callbacks
+var ErrDisplayShutdown = errors.New("display is shutting down")
+
+// ErrDisplayCallPanic is returned by the Display await call methods when the
+// callback panicked, the panic itself is handled by the Display
+var ErrDisplayCallPanic = errors.New("display callback panicked")
+
+// cDisplayCalls guards the Display call channels so that they can be closed
+// safely while senders are active. Senders are either accepted, and the
+// callback is guaranteed to be received, or rejected with ErrDisplayShutdown.
+// Once closed, receivers drain the channels until they are empty.
+type cDisplayCalls struct {
+	closing chan struct{}
+	closed  bool
+	once    sync.Once
+
+	sync.RWMutex
+}
+
+func newDisplayCalls() (c *cDisplayCalls) {
+	return &cDisplayCalls{closing: make(chan struct{})}
+}
+
+// send delivers the callback to the channel, blocking while the channel is
+// full, unless the calls are being closed
+func (c *cDisplayCalls) send(ch chan DisplayCallbackFn, fn DisplayCallbackFn) (err error) {
+	return c.sendContext(context.Background(), ch, fn)
+}
+
+// sendContext is send, giving up with ctx.Err() once the context is done
+func (c *cDisplayCalls) sendContext(ctx context.Context, ch chan DisplayCallbackFn, fn DisplayCallbackFn) (err error) {
+	c.RLock()
+	defer c.RUnlock()
+	if c.closed {
+		return ErrDisplayShutdown
+	}
+	select {
+	case ch <- fn:
+		return nil
+	case <-c.closing:
+		return ErrDisplayShutdown
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// close rejects blocked and future senders, then closes the given channels
+func (c *cDisplayCalls) close(channels ...chan DisplayCallbackFn) {
+	c.once.Do(func() {
+		close(c.closing)
+		c.Lock()
+		defer c.Unlock()
+		c.closed = true
+		for _, ch := range channels {
+			close(ch)
+		}
+	})
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestDisplayCalls(t *testing.T) {
+	Convey("Display call channels", t, func() {
+		c := newDisplayCalls()
+		ch := make(chan DisplayCallbackFn, 1)
+		noop := func(d Display) error { return nil }
+		So(c.send(ch, noop), ShouldBeNil)
+		So(len(ch), ShouldEqual, 1)
+		// channel is full, the next sender blocks until closed
+		blocked := make(chan error)
+		go func() { blocked <- c.send(ch, noop) }()
+		select {
+		case <-blocked:
+			t.Fatal("sender should be blocked")
+		case <-time.After(time.Millisecond * 10):
+		}
+		c.close(ch)
+		So(<-blocked, ShouldEqual, ErrDisplayShutdown)
+		So(c.send(ch, noop), ShouldEqual, ErrDisplayShutdown)
+		// accepted calls are drained
+		count := 0
+		for range ch {
+			count++
+		}
+		So(count, ShouldEqual, 1)
+		// closing again is safe
+		c.close(ch)
+	})
+	Convey("Display pending calls", t, WithDisplayManager(func(d Display) {
+		queue, mains := d.PendingCalls()
+		So(queue, ShouldEqual, 0)
+		So(mains, ShouldEqual, 0)
+		So(d.AsyncCall(func(d Display) error { return nil }), ShouldNotBeNil)
+	}))
+}
+
+// runTestDisplay runs an offscreen Display until RequestQuit, returning a
+// channel closed once Run has returned
+func runTestDisplay() (d *CDisplay, finished chan struct{}) {
+	d = NewDisplay("testing", OffscreenTtyPath)
+	finished = make(chan struct{})
+	started := make(chan struct{})
+	d.Connect(SignalDisplayStartup, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+		d.StartupComplete()
+		close(started)
+		return enums.EVENT_PASS
+	})
+	go func() {
+		_ = d.Run()
+		close(finished)
+	}()
+	<-started
+	return
+}
+
+func TestDisplayAwaitCallCtx(t *testing.T) {
+	Convey("Await calls with a context", t, func() {
+		d, finished := runTestDisplay()
+		ran := false
+		So(d.AwaitCallCtx(context.Background(), func(d Display) error {
+			ran = true
+			return nil
+		}), ShouldBeNil)
+		So(ran, ShouldBeTrue)
+		So(d.AwaitCallMainCtx(context.Background(), func(d Display) error {
+			return ErrDisplayShutdown
+		}), ShouldEqual, ErrDisplayShutdown)
+		// cancelled contexts are not sent
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		So(d.AwaitCallCtx(ctx, func(d Display) error {
+			t.Error("cancelled call was run")
+			return nil
+		}), ShouldEqual, context.Canceled)
+		// panics are reported to the waiter
+		d.Connect(SignalDisplayPanic, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return enums.EVENT_STOP
+		})
+		So(d.AwaitCall(func(d Display) error {
+			panic("testing")
+		}), ShouldEqual, ErrDisplayCallPanic)
+		_ = d.Disconnect(SignalDisplayPanic, "testing")
+
+		// block the UI thread
+		release := make(chan struct{})
+		So(d.AsyncCall(func(d Display) error {
+			<-release
+			return nil
+		}), ShouldBeNil)
+		ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond*20)
+		skipped := true
+		So(d.AwaitCallCtx(ctx, func(d Display) error {
+			skipped = false
+			return nil
+		}), ShouldEqual, context.DeadlineExceeded)
+		cancel()
+
+		// waiters are released by the shutdown while the UI thread is blocked
+		var wg sync.WaitGroup
+		results := make(chan error, 20)
+		for i := 0; i < 10; i++ {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				results <- d.AwaitCall(func(d Display) error { return nil })
+			}()
+			go func() {
+				defer wg.Done()
+				results <- d.AwaitCallMain(func(d Display) error { return nil })
+			}()
+		}
+		time.Sleep(time.Millisecond * 10)
+		d.RequestQuit()
+		d.RequestQuit()
+		close(release)
+		waited := make(chan struct{})
+		go func() {
+			wg.Wait()
+			close(waited)
+		}()
+		select {
+		case <-waited:
+		case <-time.After(time.Second * 5):
+			t.Fatal("await calls blocked by the shutdown")
+		}
+		close(results)
+		for err := range results {
+			if err != nil {
+				So(err, ShouldEqual, ErrDisplayShutdown)
+			}
+		}
+		select {
+		case <-finished:
+		case <-time.After(time.Second * 5):
+			t.Fatal("display did not shut down")
+		}
+		So(skipped, ShouldBeTrue)
+		So(d.AwaitCallCtx(context.Background(), func(d Display) error { return nil }), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,249 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/creack/pty"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// ErrCommandForeground is returned by CapturedCommand.Foreground when the
+// command is already in the foreground
+var ErrCommandForeground = errors.New("command is already in the foreground")
+
+// CommandOptions configures how Display.CommandCapturedWith runs a command.
+type CommandOptions struct {
+	// Output, when not nil, receives the output of the command instead of it
+	// being posted as EventCommand events. The final EventCommand is posted
+	// regardless.
+	Output io.Writer
+	// Dir is the working directory of the command, empty for the current
+	// working directory.
+	Dir string
+	// Env is the environment of the command, nil for the current environment.
+	Env []string
+	// Columns and Rows set the pty size, zero values use the Display size.
+	Columns int
+	Rows    int
+	// TakeOver releases the Display and attaches the command to the terminal
+	// as soon as it is started, see CapturedCommand.Foreground.
+	TakeOver bool
+}
+
+// CapturedCommand is a command started by Display.CommandCaptured, running on
+// a pty in the background while the Display remains captured.
+type CapturedCommand interface {
+	Cmd() *exec.Cmd
+	Pid() int
+	Write(p []byte) (n int, err error)
+	Resize(columns, rows int) (err error)
+	Foreground() (err error)
+	Kill() (err error)
+	Done() <-chan struct{}
+	Wait() (err error)
+}
+
+// CCapturedCommand is the concrete implementation of CapturedCommand.
+type CCapturedCommand struct {
+	display *CDisplay
+	cmd     *exec.Cmd
+	ptmx    *os.File
+	output  io.Writer
+	attach  io.Writer
+	done    chan struct{}
+	err     error
+
+	sync.RWMutex
+}
+
+// CommandCaptured starts the named command on a pty without releasing the
+// Display. Output is posted as EventCommand events, and a final EventCommand
+// is posted once the command exits. See CommandCapturedWith for more control.
+func (d *CDisplay) CommandCaptured(name string, argv ...string) (cmd CapturedCommand, err error) {
+	return d.CommandCapturedWith(CommandOptions{}, name, argv...)
+}
+
+// CommandCapturedWith starts the named command on a pty, configured with the
+// given CommandOptions.
+func (d *CDisplay) CommandCapturedWith(options CommandOptions, name string, argv ...string) (cmd CapturedCommand, err error) {
+	if enabled, err := d.CallEnabled(); !enabled {
+		return nil, err
+	}
+	columns, rows := options.Columns, options.Rows
+	if screen := d.Screen(); screen != nil && (columns <= 0 || rows <= 0) {
+		w, h := screen.Size()
+		if columns <= 0 {
+			columns = w
+		}
+		if rows <= 0 {
+			rows = h
+		}
+	}
+	if columns <= 0 {
+		columns = 80
+	}
+	if rows <= 0 {
+		rows = 24
+	}
+	c := &CCapturedCommand{
+		display: d,
+		cmd:     exec.Command(name, argv...),
+		output:  options.Output,
+		done:    make(chan struct{}),
+	}
+	c.cmd.Dir = options.Dir
+	c.cmd.Env = options.Env
+	d.LogDebug("invoking captured exec.Command: %v %v", name, argv)
+	if c.ptmx, err = pty.StartWithSize(c.cmd, &pty.Winsize{Cols: uint16(columns), Rows: uint16(rows)}); err != nil {
+		return nil, fmt.Errorf("pty.Start error: %v", err)
+	}
+	Go(c.readLoop)
+	if options.TakeOver {
+		Go(func() {
+			if err := c.Foreground(); err != nil {
+				d.LogErr(err)
+			}
+		})
+	}
+	return c, nil
+}
+
+// Cmd returns the underlying exec.Cmd.
+func (c *CCapturedCommand) Cmd() *exec.Cmd {
+	return c.cmd
+}
+
+// Pid returns the process ID of the command.
+func (c *CCapturedCommand) Pid() int {
+	if c.cmd.Process != nil {
+		return c.cmd.Process.Pid
+	}
+	return 0
+}
+
+// Write sends the given input to the command.
+func (c *CCapturedCommand) Write(p []byte) (n int, err error) {
+	return c.ptmx.Write(p)
+}
+
+// Resize changes the size of the command's pty.
+func (c *CCapturedCommand) Resize(columns, rows int) (err error) {
+	return pty.Setsize(c.ptmx, &pty.Winsize{Cols: uint16(columns), Rows: uint16(rows)})
+}
+
+// Foreground releases the Display and attaches the running command to the
+// terminal, blocking until the command exits and the Display is captured
+// again. Output continues to be captured while in the foreground.
+func (c *CCapturedCommand) Foreground() (err error) {
+	c.Lock()
+	if c.attach != nil {
+		c.Unlock()
+		return ErrCommandForeground
+	}
+	c.Unlock()
+	select {
+	case <-c.done:
+		return nil
+	default:
+	}
+	var size *pty.Winsize
+	if size, err = pty.GetsizeFull(c.ptmx); err != nil {
+		size = nil
+	}
+	err = c.display.Call(func(in, out *os.File) (err error) {
+		if e := pty.InheritSize(out, c.ptmx); e != nil {
+			c.display.LogErr(e)
+		}
+		c.Lock()
+		c.attach = out
+		c.Unlock()
+		Go(func() {
+			// ends when Call closes the tty handles
+			_, _ = io.Copy(c.ptmx, in)
+		})
+		<-c.done
+		c.Lock()
+		c.attach = nil
+		c.Unlock()
+		return nil
+	})
+	if size != nil {
+		_ = pty.Setsize(c.ptmx, size)
+	}
+	return
+}
+
+// Kill terminates the command.
+func (c *CCapturedCommand) Kill() (err error) {
+	if c.cmd.Process == nil {
+		return fmt.Errorf("command not started")
+	}
+	return c.cmd.Process.Kill()
+}
+
+// Done returns a channel which is closed once the command has exited.
+func (c *CCapturedCommand) Done() <-chan struct{} {
+	return c.done
+}
+
+// Wait blocks until the command has exited and returns its exit error.
+func (c *CCapturedCommand) Wait() (err error) {
+	<-c.done
+	c.RLock()
+	defer c.RUnlock()
+	return c.err
+}
+
+func (c *CCapturedCommand) readLoop() {
+	buf := make([]byte, 4096)
+	for {
+		n, err := c.ptmx.Read(buf)
+		if n > 0 {
+			chunk := make([]byte, n)
+			copy(chunk, buf[:n])
+			c.RLock()
+			attach := c.attach
+			c.RUnlock()
+			if attach != nil {
+				_, _ = attach.Write(chunk)
+			}
+			if c.output != nil {
+				if _, e := c.output.Write(chunk); e != nil {
+					c.display.LogErr(e)
+				}
+			} else {
+				_ = c.display.PostEvent(NewEventCommandOutput(c, chunk))
+			}
+		}
+		if err != nil {
+			// linux returns EIO once the child closes the pty
+			break
+		}
+	}
+	err := c.cmd.Wait()
+	_ = c.ptmx.Close()
+	c.Lock()
+	c.err = err
+	c.Unlock()
+	close(c.done)
+	_ = c.display.PostEvent(NewEventCommandDone(c, err))
+}
@@ -0,0 +1,92 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris zos
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+
+package cdk
+
+import (
+	"bytes"
+	"os/exec"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestDisplayCommandCaptured(t *testing.T) {
+	Convey("Display captured commands", t, WithDisplayManager(func(d Display) {
+		restore := Build.DisableLocalCall
+		Build.DisableLocalCall = false
+		defer func() { Build.DisableLocalCall = restore }()
+		Convey("output is written to the given io.Writer", func() {
+			buf := &bytes.Buffer{}
+			cmd, err := d.CommandCapturedWith(
+				CommandOptions{Output: buf, Columns: 40, Rows: 10},
+				"sh", "-c", "stty size; echo captured; exit 3",
+			)
+			So(err, ShouldBeNil)
+			So(cmd.Pid(), ShouldBeGreaterThan, 0)
+			select {
+			case <-cmd.Done():
+			case <-time.After(time.Second * 5):
+				t.Fatal("command did not exit")
+			}
+			err = cmd.Wait()
+			So(err, ShouldNotBeNil)
+			exitErr, ok := err.(*exec.ExitError)
+			So(ok, ShouldBeTrue)
+			So(exitErr.ExitCode(), ShouldEqual, 3)
+			So(buf.String(), ShouldContainSubstring, "10 40")
+			So(buf.String(), ShouldContainSubstring, "captured")
+		})
+		Convey("input is written to the command", func() {
+			buf := &bytes.Buffer{}
+			cmd, err := d.CommandCapturedWith(CommandOptions{Output: buf}, "sh", "-c", "read line; echo got $line")
+			So(err, ShouldBeNil)
+			_, err = cmd.Write([]byte("input\n"))
+			So(err, ShouldBeNil)
+			So(cmd.Wait(), ShouldBeNil)
+			So(buf.String(), ShouldContainSubstring, "got input")
+		})
+		Convey("missing commands fail to start", func() {
+			cmd, err := d.CommandCaptured("/nonexistent/command")
+			So(err, ShouldNotBeNil)
+			So(cmd, ShouldBeNil)
+		})
+		Convey("command events are emitted", func() {
+			var seen *EventCommand
+			d.Connect(SignalEventCommand, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				if len(argv) > 1 {
+					seen, _ = argv[1].(*EventCommand)
+				}
+				return enums.EVENT_PASS
+			})
+			defer func() { _ = d.Disconnect(SignalEventCommand, "testing") }()
+			cd := d.(*CDisplay)
+			cd.Lock()
+			cd.started = true
+			cd.Unlock()
+			evt := NewEventCommandOutput(nil, []byte("chunk"))
+			So(cd.processEventSafely(evt), ShouldEqual, enums.EVENT_PASS)
+			So(seen, ShouldEqual, evt)
+			So(string(seen.Output()), ShouldEqual, "chunk")
+			So(seen.Done(), ShouldBeFalse)
+			done := NewEventCommandDone(nil, nil)
+			So(done.Done(), ShouldBeTrue)
+			So(done.Err(), ShouldBeNil)
+		})
+	}))
+}
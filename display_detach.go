@@ -0,0 +1,116 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+// DefaultDetachTimeout is how long a Display waits for a detached terminal to
+// return before quitting, zero to wait indefinitely, see: SetDetachTimeout
+var DefaultDetachTimeout = time.Minute * 5
+
+// IsDetached returns true if the terminal of the Display has gone away, see:
+// EventDetach
+func (d *CDisplay) IsDetached() (detached bool) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.screen != nil && d.screen.Detached()
+}
+
+// SetDetachTimeout changes how long the Display waits for a detached terminal
+// to return before quitting. Hangups no longer terminate the process, as the
+// terminal may return (for example, when switching virtual terminals) and so
+// the timeout ensures that applications do not linger forever once their
+// terminal is truly gone. Zero waits indefinitely.
+func (d *CDisplay) SetDetachTimeout(timeout time.Duration) {
+	d.Lock()
+	defer d.Unlock()
+	d.detachTime = timeout
+}
+
+// GetDetachTimeout returns how long the Display waits for a detached terminal
+// to return, see: SetDetachTimeout
+func (d *CDisplay) GetDetachTimeout() (timeout time.Duration) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.detachTime
+}
+
+// Detach leaves the terminal of the Display, which continues running without
+// one until Reattach gives it a terminal again, the content of the Screen is
+// kept and drawn in full once reattached. Unlike a terminal going away, a
+// Display detached on request waits indefinitely, see: Screen.Detach
+func (d *CDisplay) Detach() (err error) {
+	d.RLock()
+	screen := d.screen
+	d.RUnlock()
+	if screen == nil {
+		return ErrNoDisplay
+	}
+	return screen.Detach()
+}
+
+// Reattach gives the Display the terminal at the given path, or the terminal
+// last used when the path is empty, restoring the terminal modes and content
+// of the Screen. An attached Display moves to the new terminal, see:
+// Screen.Reattach
+func (d *CDisplay) Reattach(ttyPath string) (err error) {
+	d.RLock()
+	screen := d.screen
+	d.RUnlock()
+	if screen == nil {
+		return ErrNoDisplay
+	}
+	return screen.Reattach(ttyPath)
+}
+
+// processDetach pauses rendering while detached and restores the terminal
+// modes and content once reattached
+func (d *CDisplay) processDetach(e *EventDetach) enums.EventFlag {
+	d.Lock()
+	if d.detachTimer != nil {
+		d.detachTimer.Stop()
+		d.detachTimer = nil
+	}
+	if !e.Attached() {
+		if timeout := d.detachTime; timeout > 0 && !errors.Is(e.Cause(), ErrDetached) {
+			d.detachTimer = time.AfterFunc(timeout, func() {
+				if d.IsDetached() {
+					d.LogError("terminal did not return within %v, quitting", timeout)
+					d.RequestQuit()
+				}
+			})
+		}
+		d.Unlock()
+		d.LogWarn("display detached: %v", e.Cause())
+		d.Emit(SignalDisplayDetached, d, e.Cause())
+		return enums.EVENT_STOP
+	}
+	if d.screen != nil && d.captured {
+		modes := d.screen.Modes()
+		d.prefs.apply(d.screen)
+		d.applyCursorIndicator()
+		d.screen.ApplyModes(modes)
+	}
+	d.Unlock()
+	d.LogInfo("display reattached")
+	d.Emit(SignalDisplayReattached, d)
+	_ = d.PostEvent(NewEventDrawAndSync())
+	return enums.EVENT_STOP
+}
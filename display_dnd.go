@@ -0,0 +1,337 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+const DisplayDropTargetHandle = "display-drop-target-handler"
+
+// DragContext describes a drag-and-drop operation started with
+// Display.StartDrag and is given to each of the drag-and-drop signals
+type DragContext struct {
+	// Source is the object the data is dragged from
+	Source Object
+	// Data is the content being dragged
+	Data ClipboardData
+	// Icon is drawn next to the pointer while dragging, may be nil
+	Icon memphis.Surface
+	// Position is the pointer position within the Display
+	Position ptypes.Point2I
+	// Target is the drop target window under the pointer, if any
+	Target Window
+	// Accepted is true if the Target accepts a drop at the Position
+	Accepted bool
+}
+
+// tracks the drag in progress and the windows registered as drop targets
+type cDragAndDrop struct {
+	drag    *DragContext
+	targets map[uuid.UUID][]ClipboardTarget
+}
+
+// AddDropTarget registers the window as a drop target for data held in any of
+// the given targets, or any data at all if none are given. While data is
+// dragged over the window, it receives SignalDragEnter, SignalDragMotion and
+// SignalDragLeave, and SignalDrop when the data is dropped on it. Only
+// SignalDragMotion listeners returning EVENT_STOP accept a drop at the
+// position given. The window is removed as a drop target when destroyed.
+func (d *CDisplay) AddDropTarget(w Window, targets ...ClipboardTarget) {
+	if w == nil {
+		return
+	}
+	d.Lock()
+	if d.dnd.targets == nil {
+		d.dnd.targets = make(map[uuid.UUID][]ClipboardTarget)
+	}
+	_, registered := d.dnd.targets[w.ObjectID()]
+	d.dnd.targets[w.ObjectID()] = append([]ClipboardTarget{}, targets...)
+	d.Unlock()
+	if !registered {
+		w.Connect(SignalDestroy, d.dropTargetHandle(), func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			d.RemoveDropTarget(w)
+			return enums.EVENT_PASS
+		})
+	}
+}
+
+// RemoveDropTarget stops the window from being a drop target
+func (d *CDisplay) RemoveDropTarget(w Window) {
+	if w == nil {
+		return
+	}
+	d.Lock()
+	_, registered := d.dnd.targets[w.ObjectID()]
+	delete(d.dnd.targets, w.ObjectID())
+	d.releaseDragTarget(w)
+	d.Unlock()
+	if registered {
+		_ = w.Disconnect(SignalDestroy, d.dropTargetHandle())
+	}
+}
+
+// GetDropTargets returns the targets the window was registered to accept
+// with AddDropTarget, ok is false if the window is not a drop target
+func (d *CDisplay) GetDropTargets(w Window) (targets []ClipboardTarget, ok bool) {
+	d.RLock()
+	defer d.RUnlock()
+	if w != nil {
+		if targets, ok = d.dnd.targets[w.ObjectID()]; ok {
+			targets = append([]ClipboardTarget{}, targets...)
+		}
+	}
+	return
+}
+
+// StartDrag begins dragging the data from the source object, usually in
+// response to a mouse drag starting within the source. The drag takes over
+// the pointer, ending any implicit pointer grab of the button press, and
+// follows the mouse until all buttons are released. The data is then dropped
+// on the drop target under the pointer, if it accepts the data there, emitting
+// SignalDragDrop, otherwise SignalDragCancel is emitted. Pressing Escape, or
+// calling CancelDrag, also cancels the drag. The icon, which may be nil, is
+// drawn next to the pointer. A SignalDragStart listener returning EVENT_STOP
+// refuses the drag.
+func (d *CDisplay) StartDrag(source Object, data ClipboardData, icon memphis.Surface) (err error) {
+	if source == nil {
+		return fmt.Errorf("cannot drag from nil object")
+	}
+	if len(data) == 0 {
+		return fmt.Errorf("no data to drag")
+	}
+	d.RLock()
+	dragging := d.dnd.drag != nil
+	position := d.cursor.Clone()
+	d.RUnlock()
+	if dragging {
+		return fmt.Errorf("drag already in progress")
+	}
+	drag := &DragContext{
+		Source:   source,
+		Data:     data.Clone(),
+		Icon:     icon,
+		Position: position,
+	}
+	if f := d.Emit(SignalDragStart, d, *drag); f == enums.EVENT_STOP {
+		return fmt.Errorf("drag refused: %v", source)
+	}
+	d.Lock()
+	d.dnd.drag = drag
+	if d.grabImplicit {
+		d.grabTarget, d.grabImplicit = nil, false
+	}
+	d.paneGrab = nil
+	d.Unlock()
+	d.moveDrag(drag.Position)
+	return nil
+}
+
+// CancelDrag ends the drag in progress without dropping the data, emitting
+// SignalDragCancel. Returns false if nothing is being dragged.
+func (d *CDisplay) CancelDrag() (cancelled bool) {
+	d.Lock()
+	drag := d.dnd.drag
+	d.dnd.drag = nil
+	d.Unlock()
+	if drag == nil {
+		return false
+	}
+	if drag.Target != nil {
+		drag.Target.Emit(SignalDragLeave, drag.Target, *drag, d.windowPoint(drag.Target, drag.Position))
+	}
+	d.Emit(SignalDragCancel, d, *drag)
+	d.RequestDraw()
+	d.RequestShow()
+	return true
+}
+
+// GetDrag returns the drag in progress, if any
+func (d *CDisplay) GetDrag() (drag DragContext, dragging bool) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.dnd.drag != nil {
+		return *d.dnd.drag, true
+	}
+	return
+}
+
+// processDrag moves the drag in progress with the mouse and drops the data
+// once all buttons are released, returning false if nothing is being dragged
+func (d *CDisplay) processDrag(e *EventMouse) (f enums.EventFlag, dragging bool) {
+	d.RLock()
+	dragging = d.dnd.drag != nil
+	d.RUnlock()
+	if !dragging {
+		return enums.EVENT_PASS, false
+	}
+	if e.IsWheelImpulse() {
+		return enums.EVENT_STOP, true
+	}
+	drag := d.moveDrag(e.Point2I())
+	if drag != nil && e.ButtonPressed() == ButtonNone {
+		d.dropDrag(drag)
+	}
+	d.RequestDraw()
+	d.RequestShow()
+	return enums.EVENT_STOP, true
+}
+
+// moveDrag updates the position and the target of the drag, emitting the
+// motion feedback signals on the drop targets
+func (d *CDisplay) moveDrag(position ptypes.Point2I) (drag *DragContext) {
+	target := d.dropTargetAt(position)
+	d.Lock()
+	if drag = d.dnd.drag; drag == nil {
+		d.Unlock()
+		return
+	}
+	drag.Position = position
+	previous := drag.Target
+	drag.Target = target
+	drag.Accepted = false
+	current := *drag
+	d.Unlock()
+	if previous != nil && (target == nil || previous.ObjectID() != target.ObjectID()) {
+		previous.Emit(SignalDragLeave, previous, current, d.windowPoint(previous, position))
+		previous = nil
+	}
+	if target == nil {
+		return
+	}
+	point := d.windowPoint(target, position)
+	if previous == nil {
+		target.Emit(SignalDragEnter, target, current, point)
+	}
+	accepted := target.Emit(SignalDragMotion, target, current, point) == enums.EVENT_STOP
+	d.Lock()
+	if d.dnd.drag == drag {
+		drag.Accepted = accepted
+	}
+	d.Unlock()
+	return
+}
+
+// dropDrag ends the drag, dropping the data on the target if it accepted the
+// drop, otherwise cancelling the drag
+func (d *CDisplay) dropDrag(drag *DragContext) {
+	d.Lock()
+	if d.dnd.drag != drag {
+		d.Unlock()
+		return
+	}
+	d.dnd.drag = nil
+	dropped := *drag
+	d.Unlock()
+	if dropped.Target == nil || !dropped.Accepted {
+		if dropped.Target != nil {
+			dropped.Target.Emit(SignalDragLeave, dropped.Target, dropped, d.windowPoint(dropped.Target, dropped.Position))
+		}
+		d.Emit(SignalDragCancel, d, dropped)
+		return
+	}
+	dropped.Target.Emit(SignalDrop, dropped.Target, dropped, d.windowPoint(dropped.Target, dropped.Position))
+	d.Emit(SignalDragDrop, d, dropped)
+}
+
+// dropTargetAt returns the window at the position if it is a drop target
+// accepting the data being dragged
+func (d *CDisplay) dropTargetAt(position ptypes.Point2I) (target Window) {
+	w := d.GetWindowAtPoint(position)
+	if w == nil {
+		return nil
+	}
+	d.RLock()
+	defer d.RUnlock()
+	if d.dnd.drag == nil {
+		return nil
+	}
+	accepts, ok := d.dnd.targets[w.ObjectID()]
+	if !ok {
+		return nil
+	}
+	if len(accepts) == 0 {
+		return w
+	}
+	for _, accept := range accepts {
+		if d.dnd.drag.Data.Has(accept) {
+			return w
+		}
+	}
+	return nil
+}
+
+// windowPoint returns the position relative to the origin of the window
+func (d *CDisplay) windowPoint(w Window, position ptypes.Point2I) ptypes.Point2I {
+	if surface, err := memphis.GetSurface(w.ObjectID()); err == nil {
+		origin := surface.GetOrigin()
+		position.SubPoint(origin)
+	}
+	return position
+}
+
+// releaseDragTarget forgets the window as the target of the drag in progress,
+// the Display must be locked by the caller
+func (d *CDisplay) releaseDragTarget(w Window) {
+	if drag := d.dnd.drag; drag != nil && drag.Target != nil && drag.Target.ObjectID() == w.ObjectID() {
+		drag.Target, drag.Accepted = nil, false
+	}
+}
+
+// dragIconSurface returns the icon of the drag in progress, placed next to
+// the pointer, nil if there is none to draw
+func (d *CDisplay) dragIconSurface() (icon *memphis.CSurface) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.dnd.drag == nil || d.dnd.drag.Icon == nil {
+		return nil
+	}
+	var ok bool
+	if icon, ok = d.dnd.drag.Icon.(*memphis.CSurface); ok {
+		icon.SetOrigin(ptypes.MakePoint2I(d.dnd.drag.Position.X+1, d.dnd.drag.Position.Y+1))
+	}
+	return
+}
+
+func (d *CDisplay) dropTargetHandle() string {
+	return fmt.Sprintf("%v-%v", DisplayDropTargetHandle, d.ObjectID())
+}
+
+// DisplaySignalDragArgv returns the drag from the arguments of
+// SignalDragStart, SignalDragDrop and SignalDragCancel
+func DisplaySignalDragArgv(argv ...interface{}) (drag DragContext, ok bool) {
+	if len(argv) == 2 {
+		drag, ok = argv[1].(DragContext)
+	}
+	return
+}
+
+// WindowSignalDragArgv returns the drag and the pointer position relative to
+// the window from the arguments of SignalDragEnter, SignalDragMotion,
+// SignalDragLeave and SignalDrop
+func WindowSignalDragArgv(argv ...interface{}) (drag DragContext, point ptypes.Point2I, ok bool) {
+	if len(argv) == 3 {
+		if drag, ok = argv[1].(DragContext); ok {
+			point, ok = argv[2].(ptypes.Point2I)
+		}
+	}
+	return
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestDisplayDragAndDrop(t *testing.T) {
+	Convey("Dragging data between windows", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.Unlock()
+		source := NewOffscreenWindow("source")
+		target := NewOffscreenWindow("target")
+		d.MapWindowWithRegion(target, ptypes.MakeRegion(10, 0, 5, 5))
+		d.MapWindowWithRegion(source, ptypes.MakeRegion(0, 0, 5, 5))
+		d.FocusWindow(source)
+
+		var feedback []string
+		var dropped []ptypes.Point2I
+		accept := true
+		track := func(signal Signal) {
+			target.Connect(signal, "dnd-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				drag, point, ok := WindowSignalDragArgv(argv...)
+				So(ok, ShouldBeTrue)
+				So(drag.Source, ShouldEqual, source)
+				feedback = append(feedback, string(signal))
+				if signal == SignalDrop {
+					dropped = append(dropped, point)
+				}
+				if signal == SignalDragMotion && accept {
+					return enums.EVENT_STOP
+				}
+				return enums.EVENT_PASS
+			})
+		}
+		for _, signal := range []Signal{SignalDragEnter, SignalDragMotion, SignalDragLeave, SignalDrop} {
+			track(signal)
+		}
+		var ended []Signal
+		for _, signal := range []Signal{SignalDragDrop, SignalDragCancel} {
+			signal := signal
+			d.Connect(signal, "dnd-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				_, ok := DisplaySignalDragArgv(argv...)
+				So(ok, ShouldBeTrue)
+				ended = append(ended, signal)
+				return enums.EVENT_PASS
+			})
+		}
+		d.AddDropTarget(target, TargetURIList)
+		targets, ok := d.GetDropTargets(target)
+		So(ok, ShouldBeTrue)
+		So(targets, ShouldResemble, []ClipboardTarget{TargetURIList})
+
+		Convey("drops on an accepting target", func() {
+			So(d.StartDrag(nil, NewClipboardText("x"), nil), ShouldNotBeNil)
+			So(d.StartDrag(source, nil, nil), ShouldNotBeNil)
+			d.ProcessEvent(NewEventMouse(1, 1, Button1, ModNone))
+			So(d.StartDrag(source, NewClipboardURIs("file:///tmp/a"), nil), ShouldBeNil)
+			So(d.StartDrag(source, NewClipboardURIs("file:///tmp/b"), nil), ShouldNotBeNil)
+			grab, _ := d.GetPointerGrab()
+			So(grab, ShouldBeNil)
+			d.ProcessEvent(NewEventMouse(11, 1, Button1, ModNone))
+			drag, dragging := d.GetDrag()
+			So(dragging, ShouldBeTrue)
+			So(drag.Target, ShouldEqual, target)
+			So(drag.Accepted, ShouldBeTrue)
+			d.ProcessEvent(NewEventMouse(12, 2, ButtonNone, ModNone))
+			_, dragging = d.GetDrag()
+			So(dragging, ShouldBeFalse)
+			So(feedback, ShouldResemble, []string{"drag-enter", "drag-motion", "drag-motion", "drop"})
+			So(dropped, ShouldResemble, []ptypes.Point2I{ptypes.MakePoint2I(2, 2)})
+			So(ended, ShouldResemble, []Signal{SignalDragDrop})
+		})
+
+		Convey("cancels without an accepting target", func() {
+			accept = false
+			d.ProcessEvent(NewEventMouse(1, 1, Button1, ModNone))
+			So(d.StartDrag(source, NewClipboardURIs("file:///tmp/a"), nil), ShouldBeNil)
+			d.ProcessEvent(NewEventMouse(11, 1, Button1, ModNone))
+			d.ProcessEvent(NewEventMouse(1, 1, Button1, ModNone))
+			d.ProcessEvent(NewEventMouse(1, 1, ButtonNone, ModNone))
+			So(feedback, ShouldResemble, []string{"drag-enter", "drag-motion", "drag-leave"})
+			So(ended, ShouldResemble, []Signal{SignalDragCancel})
+		})
+
+		Convey("ignores targets not accepting the data", func() {
+			So(d.StartDrag(source, NewClipboardText("plain"), nil), ShouldBeNil)
+			d.ProcessEvent(NewEventMouse(11, 1, Button1, ModNone))
+			drag, _ := d.GetDrag()
+			So(drag.Target, ShouldBeNil)
+			So(d.ProcessEvent(NewEventKey(KeyEscape, 0, ModNone)), ShouldEqual, enums.EVENT_STOP)
+			So(d.CancelDrag(), ShouldBeFalse)
+			d.ProcessEvent(NewEventMouse(11, 1, ButtonNone, ModNone))
+			So(feedback, ShouldBeEmpty)
+			So(ended, ShouldResemble, []Signal{SignalDragCancel})
+		})
+
+		Convey("forgets destroyed targets", func() {
+			target.Destroy()
+			_, ok = d.GetDropTargets(target)
+			So(ok, ShouldBeFalse)
+		})
+	}))
+}
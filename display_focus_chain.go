@@ -0,0 +1,288 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+var (
+	// FocusHistoryLimit is the number of previously focused objects the focus
+	// chain of a Display remembers
+	FocusHistoryLimit = 32
+)
+
+const DisplayFocusChainHandle = "display-focus-chain-handle"
+
+// FocusChainMember may be implemented by the objects of a focus chain which
+// are temporarily unable to take the focus, for example while hidden or
+// insensitive, to be skipped by FocusChainNext and FocusChainPrevious
+type FocusChainMember interface {
+	CanFocus() bool
+}
+
+// cFocusChain is the keyboard traversal order of a Display, the Display must be
+// locked while it is used. Members are asked whether they can take the focus
+// on a snapshot, with the Display unlocked, as CanFocus may use the Display.
+type cFocusChain struct {
+	chain   []Object
+	focus   Object
+	history []Object
+}
+
+// snapshot returns a copy of the focus chain which can be used without the
+// Display being locked
+func (c *cFocusChain) snapshot() (snapshot *cFocusChain) {
+	return &cFocusChain{
+		chain:   append([]Object{}, c.chain...),
+		focus:   c.focus,
+		history: append([]Object{}, c.history...),
+	}
+}
+
+func (c *cFocusChain) index(obj Object) int {
+	for idx, member := range c.chain {
+		if member.ObjectID() == obj.ObjectID() {
+			return idx
+		}
+	}
+	return -1
+}
+
+func (c *cFocusChain) remember(obj Object) {
+	if obj == nil {
+		return
+	}
+	c.forget(obj)
+	c.history = append([]Object{obj}, c.history...)
+	if limit := FocusHistoryLimit; limit >= 0 && len(c.history) > limit {
+		c.history = c.history[:limit]
+	}
+}
+
+func (c *cFocusChain) forget(obj Object) {
+	for idx, previous := range c.history {
+		if previous.ObjectID() == obj.ObjectID() {
+			c.history = append(c.history[:idx], c.history[idx+1:]...)
+			return
+		}
+	}
+}
+
+// step returns the next member able to take the focus in the given direction,
+// starting from the focused member, or nil if there is none, see: snapshot
+func (c *cFocusChain) step(forward bool) (next Object) {
+	count := len(c.chain)
+	if count == 0 {
+		return nil
+	}
+	start := -1
+	if c.focus != nil {
+		start = c.index(c.focus)
+	}
+	if start < 0 && !forward {
+		start = count
+	}
+	for i := 1; i <= count; i++ {
+		var idx int
+		if forward {
+			idx = (start + i) % count
+		} else {
+			idx = (start - i + count) % count
+		}
+		if canFocus(c.chain[idx]) {
+			return c.chain[idx]
+		}
+	}
+	return nil
+}
+
+func canFocus(obj Object) bool {
+	if member, ok := obj.(FocusChainMember); ok {
+		return member.CanFocus()
+	}
+	if member, ok := obj.Self().(FocusChainMember); ok {
+		return member.CanFocus()
+	}
+	return true
+}
+
+func (d *CDisplay) focusChainHandle() string {
+	return fmt.Sprintf("%v-%v", DisplayFocusChainHandle, d.ObjectID())
+}
+
+// FocusChainAppend adds the object to the end of the focus chain, objects
+// already in the chain are moved to the end. Objects are removed from the
+// chain when destroyed.
+func (d *CDisplay) FocusChainAppend(obj Object) {
+	if obj == nil {
+		return
+	}
+	d.Lock()
+	if idx := d.focusChain.index(obj); idx > -1 {
+		d.focusChain.chain = append(d.focusChain.chain[:idx], d.focusChain.chain[idx+1:]...)
+	}
+	d.focusChain.chain = append(d.focusChain.chain, obj)
+	d.Unlock()
+	if handle := d.focusChainHandle(); !obj.Handled(SignalDestroy, handle) {
+		obj.Connect(SignalDestroy, handle, func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			d.FocusChainRemove(obj)
+			return enums.EVENT_PASS
+		})
+	}
+}
+
+// FocusChainRemove removes the object from the focus chain and the focus
+// history. If the object has the focus, the focus returns to the most recently
+// focused object still in the chain.
+func (d *CDisplay) FocusChainRemove(obj Object) {
+	if obj == nil {
+		return
+	}
+	d.Lock()
+	idx := d.focusChain.index(obj)
+	if idx < 0 {
+		d.Unlock()
+		return
+	}
+	d.focusChain.chain = append(d.focusChain.chain[:idx], d.focusChain.chain[idx+1:]...)
+	d.focusChain.forget(obj)
+	var candidates []Object
+	focused := d.focusChain.focus != nil && d.focusChain.focus.ObjectID() == obj.ObjectID()
+	if focused {
+		d.focusChain.focus = nil
+		for _, previous := range d.focusChain.history {
+			if d.focusChain.index(previous) > -1 {
+				candidates = append(candidates, previous)
+			}
+		}
+	}
+	d.Unlock()
+	_ = obj.Disconnect(SignalDestroy, d.focusChainHandle())
+	if focused {
+		var restore Object
+		for _, previous := range candidates {
+			if canFocus(previous) {
+				restore = previous
+				break
+			}
+		}
+		d.changeChainFocus(obj, restore)
+	}
+}
+
+// GetFocusChain returns the objects of the focus chain in traversal order
+func (d *CDisplay) GetFocusChain() (chain []Object) {
+	d.RLock()
+	defer d.RUnlock()
+	chain = append(chain, d.focusChain.chain...)
+	return
+}
+
+// GetChainFocus returns the object of the focus chain which has the focus,
+// nil if none do
+func (d *CDisplay) GetChainFocus() (focus Object) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.focusChain.focus
+}
+
+// FocusChainSet gives the focus to the given member of the focus chain
+func (d *CDisplay) FocusChainSet(obj Object) (err error) {
+	if obj == nil {
+		return fmt.Errorf("cannot focus nil object")
+	}
+	d.RLock()
+	previous := d.focusChain.focus
+	member := d.focusChain.index(obj) > -1
+	d.RUnlock()
+	if !member {
+		return fmt.Errorf("object is not in the focus chain: %v", obj)
+	}
+	if previous != nil && previous.ObjectID() == obj.ObjectID() {
+		return nil
+	}
+	d.changeChainFocus(previous, obj)
+	return nil
+}
+
+// FocusChainNext gives the focus to the member of the focus chain following
+// the focused one, wrapping around to the first, skipping any unable to take
+// the focus. Returns the newly focused object, nil if none can be focused.
+func (d *CDisplay) FocusChainNext() (focus Object) {
+	return d.stepChainFocus(true)
+}
+
+// FocusChainPrevious gives the focus to the member of the focus chain
+// preceding the focused one, wrapping around to the last, skipping any unable
+// to take the focus. Returns the newly focused object, nil if none can be
+// focused.
+func (d *CDisplay) FocusChainPrevious() (focus Object) {
+	return d.stepChainFocus(false)
+}
+
+// GetFocusHistory returns the objects which previously had the focus, most
+// recent first and not including the object which has the focus now
+func (d *CDisplay) GetFocusHistory() (history []Object) {
+	d.RLock()
+	defer d.RUnlock()
+	history = append(history, d.focusChain.history...)
+	return
+}
+
+func (d *CDisplay) stepChainFocus(forward bool) (focus Object) {
+	d.RLock()
+	snapshot := d.focusChain.snapshot()
+	d.RUnlock()
+	previous := snapshot.focus
+	focus = snapshot.step(forward)
+	if focus != nil && (previous == nil || previous.ObjectID() != focus.ObjectID()) {
+		d.changeChainFocus(previous, focus)
+	}
+	return
+}
+
+// changeChainFocus moves the focus and emits SignalFocusChanged with the
+// previous and newly focused objects, either of which may be nil
+func (d *CDisplay) changeChainFocus(previous, focus Object) {
+	d.Lock()
+	if previous != nil {
+		if d.focusChain.index(previous) > -1 {
+			d.focusChain.remember(previous)
+		}
+	}
+	if focus != nil {
+		d.focusChain.forget(focus)
+	}
+	d.focusChain.focus = focus
+	d.Unlock()
+	d.Emit(SignalFocusChanged, d, previous, focus)
+	if focus != nil {
+		d.AnnounceFocus(focus)
+	}
+}
+
+// DisplaySignalFocusChangedArgv returns the previously and newly focused
+// objects from the arguments of SignalFocusChanged, either may be nil
+func DisplaySignalFocusChangedArgv(argv ...interface{}) (previous, focus Object, ok bool) {
+	if len(argv) == 3 {
+		previous, _ = argv[1].(Object)
+		focus, _ = argv[2].(Object)
+		ok = true
+	}
+	return
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+type testFocusMember struct {
+	CObject
+	focusable bool
+	display   Display
+}
+
+func (m *testFocusMember) CanFocus() bool {
+	if m.display != nil {
+		// members may consult the display, which must not be locked
+		return m.display.GetChainFocus() != nil || m.focusable
+	}
+	return m.focusable
+}
+
+func newTestFocusMember(focusable bool) (m *testFocusMember) {
+	m = &testFocusMember{focusable: focusable}
+	m.Init()
+	return
+}
+
+func TestDisplayFocusChain(t *testing.T) {
+	Convey("Focus chain traversal", t, WithDisplayManager(func(d Display) {
+		a, b, c := newTestFocusMember(true), newTestFocusMember(false), newTestFocusMember(true)
+		var changes [][2]Object
+		d.Connect(SignalFocusChanged, "focus-chain-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			previous, focus, ok := DisplaySignalFocusChangedArgv(argv...)
+			So(ok, ShouldBeTrue)
+			changes = append(changes, [2]Object{previous, focus})
+			return enums.EVENT_PASS
+		})
+		So(d.FocusChainNext(), ShouldBeNil)
+		d.FocusChainAppend(a)
+		d.FocusChainAppend(c)
+		d.FocusChainAppend(b)
+		d.FocusChainAppend(c)
+		So(d.GetFocusChain(), ShouldResemble, []Object{a, b, c})
+
+		So(d.FocusChainNext(), ShouldEqual, a)
+		So(d.FocusChainNext(), ShouldEqual, c)
+		So(d.FocusChainNext(), ShouldEqual, a)
+		So(d.FocusChainPrevious(), ShouldEqual, c)
+		So(d.GetChainFocus(), ShouldEqual, c)
+		So(d.GetFocusHistory(), ShouldResemble, []Object{a})
+		So(changes, ShouldHaveLength, 4)
+		So(changes[0], ShouldResemble, [2]Object{nil, a})
+		So(changes[3], ShouldResemble, [2]Object{a, c})
+
+		b.focusable = true
+		So(d.FocusChainPrevious(), ShouldEqual, b)
+		So(d.FocusChainSet(a), ShouldBeNil)
+		So(d.GetFocusHistory(), ShouldResemble, []Object{b, c})
+		So(d.FocusChainSet(newTestFocusMember(true)), ShouldNotBeNil)
+
+		// removing the focused object restores the most recent focus
+		d.FocusChainRemove(a)
+		So(d.GetChainFocus(), ShouldEqual, b)
+		So(d.GetFocusHistory(), ShouldResemble, []Object{c})
+		// destroyed objects leave the chain
+		b.Destroy()
+		So(d.GetFocusChain(), ShouldResemble, []Object{c})
+		So(d.GetChainFocus(), ShouldEqual, c)
+		So(d.GetFocusHistory(), ShouldBeEmpty)
+	}))
+	Convey("Focus chain members using the display", t, WithDisplayManager(func(d Display) {
+		a, b := newTestFocusMember(true), newTestFocusMember(true)
+		a.display, b.display = d, d
+		d.FocusChainAppend(a)
+		d.FocusChainAppend(b)
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			d.FocusChainNext()
+			d.FocusChainNext()
+			d.FocusChainPrevious()
+			d.FocusChainRemove(a)
+		}()
+		finished := false
+		select {
+		case <-done:
+			finished = true
+		case <-time.After(time.Second):
+		}
+		So(finished, ShouldBeTrue)
+		So(d.GetChainFocus(), ShouldEqual, b)
+	}))
+}
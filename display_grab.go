@@ -0,0 +1,114 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+// GrabPointer sends all mouse events to the given object, which must
+// implement Sensitive, until UngrabPointer is called. Mouse events the object
+// passes on are emitted as SignalEventMouse without going to any window or
+// pane. A SignalGrabPointer listener returning EVENT_STOP refuses the grab.
+//
+// Without an explicit grab, the Display implicitly grabs the pointer while
+// any mouse button is held down: the window and pane receiving the button
+// press receive all mouse events until every button is released, even when
+// the pointer is dragged outside of them.
+func (d *CDisplay) GrabPointer(obj Object) (err error) {
+	if obj == nil {
+		return fmt.Errorf("cannot grab pointer for nil object")
+	}
+	if _, ok := obj.Self().(Sensitive); !ok {
+		return fmt.Errorf("object does not implement Sensitive: %v (%T)", obj, obj)
+	}
+	if f := d.Emit(SignalGrabPointer, d, obj); f == enums.EVENT_STOP {
+		return fmt.Errorf("pointer grab refused: %v", obj)
+	}
+	d.Lock()
+	d.grabTarget, d.grabImplicit = obj, false
+	d.paneGrab = nil
+	d.Unlock()
+	return nil
+}
+
+// UngrabPointer releases the pointer grabbed by GrabPointer, or the implicit
+// grab of a button press in progress
+func (d *CDisplay) UngrabPointer() {
+	d.Lock()
+	obj := d.grabTarget
+	d.grabTarget, d.grabImplicit = nil, false
+	d.paneGrab = nil
+	d.Unlock()
+	if obj != nil {
+		d.Emit(SignalUngrabPointer, d, obj)
+	}
+}
+
+// GetPointerGrab returns the object receiving all mouse events, if any, and
+// whether it was grabbed implicitly by a button press
+func (d *CDisplay) GetPointerGrab() (obj Object, implicit bool) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.grabTarget, d.grabImplicit
+}
+
+// processPointerGrab delivers the mouse event to the object of an explicit
+// pointer grab, returning false if there is none
+func (d *CDisplay) processPointerGrab(e *EventMouse) (f enums.EventFlag, grabbed bool) {
+	d.RLock()
+	obj, implicit := d.grabTarget, d.grabImplicit
+	d.RUnlock()
+	if obj == nil || implicit {
+		return enums.EVENT_PASS, false
+	}
+	if sensitive, ok := obj.Self().(Sensitive); ok {
+		if f = sensitive.ProcessEvent(e); f == enums.EVENT_STOP {
+			return f, true
+		}
+	}
+	return d.Emit(SignalEventMouse, d, e), true
+}
+
+// pointerWindow returns the window receiving the mouse event, starting an
+// implicit pointer grab of the focused window with the first button pressed
+// and ending it once all buttons are released
+func (d *CDisplay) pointerWindow(e *EventMouse) (w Window) {
+	focused := d.FocusedWindow()
+	pressed := e.ButtonPressed() != ButtonNone
+	d.Lock()
+	defer d.Unlock()
+	if d.grabImplicit && d.grabTarget != nil {
+		w, _ = d.grabTarget.(Window)
+		if !pressed {
+			d.grabTarget, d.grabImplicit = nil, false
+		}
+		return
+	}
+	if pressed && focused != nil && d.grabTarget == nil {
+		d.grabTarget, d.grabImplicit = focused, true
+	}
+	return focused
+}
+
+// releasePointerGrab ends any pointer grab of the given window, the Display
+// must be locked by the caller
+func (d *CDisplay) releasePointerGrab(w Window) {
+	if d.grabTarget != nil && d.grabTarget.ObjectID() == w.ObjectID() {
+		d.grabTarget, d.grabImplicit = nil, false
+	}
+}
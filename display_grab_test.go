@@ -0,0 +1,98 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestDisplayPointerGrab(t *testing.T) {
+	Convey("Pointer grabs", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.Unlock()
+		var received []string
+		track := func(w Window) Window {
+			w.Connect(SignalEvent, "grab-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				if _, ok := argv[1].(*EventMouse); ok {
+					received = append(received, w.GetName())
+				}
+				return enums.EVENT_STOP
+			})
+			return w
+		}
+		one := track(NewOffscreenWindow("one"))
+		two := track(NewOffscreenWindow("two"))
+		one.SetName("one")
+		two.SetName("two")
+		d.MapWindowWithRegion(two, ptypes.MakeRegion(10, 0, 5, 5))
+		d.MapWindowWithRegion(one, ptypes.MakeRegion(0, 0, 5, 5))
+		d.FocusWindow(one)
+		So(d.FocusedWindow(), ShouldEqual, one)
+
+		Convey("implicitly during a button press", func() {
+			d.ProcessEvent(NewEventMouse(1, 1, Button1, ModNone))
+			grab, implicit := d.GetPointerGrab()
+			So(grab, ShouldEqual, one)
+			So(implicit, ShouldBeTrue)
+			d.FocusWindow(two)
+			d.ProcessEvent(NewEventMouse(11, 1, Button1, ModNone))
+			d.ProcessEvent(NewEventMouse(12, 1, ButtonNone, ModNone))
+			grab, _ = d.GetPointerGrab()
+			So(grab, ShouldBeNil)
+			d.ProcessEvent(NewEventMouse(12, 2, ButtonNone, ModNone))
+			So(received, ShouldResemble, []string{"one", "one", "one", "two"})
+		})
+
+		Convey("explicitly until released", func() {
+			So(d.GrabPointer(nil), ShouldNotBeNil)
+			So(d.GrabPointer(two), ShouldBeNil)
+			grab, implicit := d.GetPointerGrab()
+			So(grab, ShouldEqual, two)
+			So(implicit, ShouldBeFalse)
+			d.ProcessEvent(NewEventMouse(1, 1, Button1, ModNone))
+			d.ProcessEvent(NewEventMouse(1, 1, ButtonNone, ModNone))
+			grab, _ = d.GetPointerGrab()
+			So(grab, ShouldEqual, two)
+			ungrabbed := false
+			d.Connect(SignalUngrabPointer, "grab-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				ungrabbed = true
+				return enums.EVENT_PASS
+			})
+			d.UngrabPointer()
+			So(ungrabbed, ShouldBeTrue)
+			d.ProcessEvent(NewEventMouse(1, 2, ButtonNone, ModNone))
+			So(received, ShouldResemble, []string{"two", "two", "one"})
+			d.Connect(SignalGrabPointer, "grab-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				return enums.EVENT_STOP
+			})
+			So(d.GrabPointer(two), ShouldNotBeNil)
+		})
+
+		Convey("released when the window is unmapped", func() {
+			So(d.GrabPointer(two), ShouldBeNil)
+			d.UnmapWindow(two)
+			grab, _ := d.GetPointerGrab()
+			So(grab, ShouldBeNil)
+		})
+	}))
+}
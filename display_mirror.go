@@ -0,0 +1,259 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"sync"
+	"time"
+)
+
+// MirrorBackPressure determines what happens to the frames of a Display when
+// the consumer of a mirror cannot keep up with them
+type MirrorBackPressure uint8
+
+const (
+	// MirrorDropFrames holds at most one pending frame, replacing it with
+	// each newer frame, so that a slow consumer always receives the latest
+	// frame and the Display is never delayed
+	MirrorDropFrames MirrorBackPressure = iota
+	// MirrorBufferFrames holds up to MirrorOptions.Buffer pending frames,
+	// dropping the oldest pending frame when full
+	MirrorBufferFrames
+	// MirrorBlock holds up to MirrorOptions.Buffer pending frames and then
+	// delays the Display until the consumer has caught up, so that no frames
+	// are lost
+	MirrorBlock
+)
+
+// DefaultMirrorBuffer is the number of pending frames held by mirrors using
+// MirrorBufferFrames or MirrorBlock when no MirrorOptions.Buffer is given
+var DefaultMirrorBuffer = 8
+
+// MirrorOptions configure a mirror added with Display.AddMirror
+type MirrorOptions struct {
+	// BackPressure is how the mirror handles a consumer which is slower than
+	// the Display
+	BackPressure MirrorBackPressure
+	// Buffer is the number of pending frames held, see: DefaultMirrorBuffer
+	Buffer int
+	// OnFrame, if not nil, is called after each frame is shown on the mirror
+	// OffScreen. OnFrame is called on the mirror's own goroutine and the time
+	// it takes is what makes a consumer slow.
+	OnFrame func(screen OffScreen)
+}
+
+// MirrorStats are the running totals of a mirror
+type MirrorStats struct {
+	// Frames is the number of frames shown on the mirror OffScreen
+	Frames int
+	// Dropped is the number of frames discarded due to back-pressure
+	Dropped int
+	// Blocked is the number of times the Display waited for the consumer
+	Blocked int
+	// BlockedFor is the total time the Display waited for the consumer
+	BlockedFor time.Duration
+	// Pending is the number of frames waiting to be shown
+	Pending int
+}
+
+// DisplayMirror is an OffScreen receiving a copy of every frame shown by a
+// Display, see: Display.AddMirror
+type DisplayMirror interface {
+	Screen() OffScreen
+	Options() MirrorOptions
+	Stats() MirrorStats
+	Close()
+}
+
+// CDisplayMirror is the concrete implementation of the DisplayMirror interface
+type CDisplayMirror struct {
+	screen  OffScreen
+	options MirrorOptions
+	frames  chan *CellBuffer
+	done    chan struct{}
+	closing sync.Once
+	stats   MirrorStats
+
+	sync.Mutex
+}
+
+func newDisplayMirror(screen OffScreen, options MirrorOptions) (m *CDisplayMirror) {
+	if options.BackPressure == MirrorDropFrames {
+		options.Buffer = 1
+	} else if options.Buffer <= 0 {
+		options.Buffer = DefaultMirrorBuffer
+	}
+	m = &CDisplayMirror{
+		screen:  screen,
+		options: options,
+		frames:  make(chan *CellBuffer, options.Buffer),
+		done:    make(chan struct{}),
+	}
+	Go(m.worker)
+	return
+}
+
+// Screen returns the OffScreen the frames are shown on
+func (m *CDisplayMirror) Screen() OffScreen {
+	return m.screen
+}
+
+// Options returns the options of the mirror, with defaults applied
+func (m *CDisplayMirror) Options() MirrorOptions {
+	return m.options
+}
+
+// Stats returns the running totals of the mirror
+func (m *CDisplayMirror) Stats() (stats MirrorStats) {
+	m.Lock()
+	stats = m.stats
+	m.Unlock()
+	stats.Pending = len(m.frames)
+	return
+}
+
+// Close stops the mirror, pending frames are discarded
+func (m *CDisplayMirror) Close() {
+	m.closing.Do(func() {
+		close(m.done)
+	})
+}
+
+// publish queues the frame according to the back-pressure behaviour
+func (m *CDisplayMirror) publish(frame *CellBuffer) {
+	select {
+	case <-m.done:
+		return
+	case m.frames <- frame:
+		return
+	default:
+	}
+	if m.options.BackPressure == MirrorBlock {
+		started := time.Now()
+		select {
+		case <-m.done:
+		case m.frames <- frame:
+		}
+		m.Lock()
+		m.stats.Blocked++
+		m.stats.BlockedFor += time.Since(started)
+		m.Unlock()
+		return
+	}
+	// full, make room by dropping the oldest pending frame
+	for {
+		select {
+		case <-m.frames:
+			m.Lock()
+			m.stats.Dropped++
+			m.Unlock()
+		default:
+		}
+		select {
+		case m.frames <- frame:
+			return
+		default:
+		}
+	}
+}
+
+func (m *CDisplayMirror) worker() {
+	for {
+		select {
+		case <-m.done:
+			return
+		case frame := <-m.frames:
+			w, h := frame.Size()
+			if sw, sh := m.screen.Size(); sw != w || sh != h {
+				m.screen.SetSize(w, h)
+			}
+			// every cell of the frame is drawn
+			frame.Invalidate()
+			m.screen.Import(frame)
+			m.screen.Show()
+			m.Lock()
+			m.stats.Frames++
+			m.Unlock()
+			if m.options.OnFrame != nil {
+				m.options.OnFrame(m.screen)
+			}
+		}
+	}
+}
+
+// AddMirror starts showing a copy of every frame of the Display on the given
+// OffScreen, for screen sharing, recording and similar. Each mirror has its
+// own goroutine and consumers slower than the Display are handled according to
+// the back-pressure behaviour of the options.
+func (d *CDisplay) AddMirror(screen OffScreen, options MirrorOptions) DisplayMirror {
+	m := newDisplayMirror(screen, options)
+	d.Lock()
+	d.mirrors = append(d.mirrors, m)
+	d.Unlock()
+	d.RequestShow()
+	return m
+}
+
+// RemoveMirror closes the mirror and stops showing frames on its OffScreen
+func (d *CDisplay) RemoveMirror(mirror DisplayMirror) {
+	d.Lock()
+	for idx, m := range d.mirrors {
+		if m == mirror {
+			d.mirrors = append(d.mirrors[:idx], d.mirrors[idx+1:]...)
+			break
+		}
+	}
+	d.Unlock()
+	mirror.Close()
+}
+
+// GetMirrors returns the mirrors added to the Display
+func (d *CDisplay) GetMirrors() (mirrors []DisplayMirror) {
+	d.RLock()
+	defer d.RUnlock()
+	for _, m := range d.mirrors {
+		mirrors = append(mirrors, m)
+	}
+	return
+}
+
+// publishMirrors gives each of the mirrors a copy of the current frame
+func (d *CDisplay) publishMirrors() {
+	d.RLock()
+	mirrors := d.mirrors
+	screen := d.screen
+	d.RUnlock()
+	if len(mirrors) == 0 || screen == nil {
+		return
+	}
+	frame := screen.Export()
+	for idx, m := range mirrors {
+		if idx > 0 {
+			// each mirror imports its own copy
+			frame = screen.Export()
+		}
+		m.publish(frame)
+	}
+}
+
+func (d *CDisplay) closeMirrors() {
+	d.Lock()
+	mirrors := d.mirrors
+	d.mirrors = nil
+	d.Unlock()
+	for _, m := range mirrors {
+		m.Close()
+	}
+}
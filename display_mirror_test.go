@@ -0,0 +1,120 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+func newMirrorTestFrame(r rune) (frame *CellBuffer) {
+	frame = NewCellBuffer()
+	frame.Resize(4, 2)
+	frame.SetCell(0, 0, r, nil, paint.StyleDefault)
+	return
+}
+
+func newMirrorTestScreen() (screen OffScreen) {
+	screen, _ = MakeOffScreen("UTF-8")
+	_ = screen.Init()
+	return
+}
+
+func TestDisplayMirror(t *testing.T) {
+	Convey("Display mirrors", t, func() {
+		Convey("show published frames", func() {
+			shown := make(chan CompatCapture, 1)
+			m := newDisplayMirror(newMirrorTestScreen(), MirrorOptions{
+				OnFrame: func(screen OffScreen) {
+					shown <- CaptureCompat(screen)
+				},
+			})
+			defer m.Close()
+			So(m.Options().Buffer, ShouldEqual, 1)
+			m.publish(newMirrorTestFrame('x'))
+			select {
+			case capture := <-shown:
+				So(capture.W, ShouldEqual, 4)
+				So(capture.H, ShouldEqual, 2)
+				r, _ := capture.cell(0, 0)
+				So(r, ShouldEqual, 'x')
+			case <-time.After(time.Second):
+				So("timeout", ShouldBeEmpty)
+			}
+			So(m.Stats().Frames, ShouldEqual, 1)
+		})
+		Convey("drop frames for slow consumers", func() {
+			release := make(chan bool)
+			m := newDisplayMirror(newMirrorTestScreen(), MirrorOptions{
+				BackPressure: MirrorDropFrames,
+				OnFrame:      func(_ OffScreen) { <-release },
+			})
+			defer m.Close()
+			m.publish(newMirrorTestFrame('a'))
+			time.Sleep(50 * time.Millisecond) // worker is now waiting on release
+			for _, r := range "bcd" {
+				m.publish(newMirrorTestFrame(r))
+			}
+			stats := m.Stats()
+			So(stats.Dropped, ShouldEqual, 2)
+			So(stats.Pending, ShouldEqual, 1)
+			close(release)
+		})
+		Convey("buffer frames for slow consumers", func() {
+			release := make(chan bool)
+			m := newDisplayMirror(newMirrorTestScreen(), MirrorOptions{
+				BackPressure: MirrorBufferFrames,
+				Buffer:       2,
+				OnFrame:      func(_ OffScreen) { <-release },
+			})
+			defer m.Close()
+			m.publish(newMirrorTestFrame('a'))
+			time.Sleep(50 * time.Millisecond)
+			for _, r := range "bcde" {
+				m.publish(newMirrorTestFrame(r))
+			}
+			stats := m.Stats()
+			So(stats.Dropped, ShouldEqual, 2)
+			So(stats.Pending, ShouldEqual, 2)
+			close(release)
+		})
+		Convey("block for slow consumers", func() {
+			m := newDisplayMirror(newMirrorTestScreen(), MirrorOptions{
+				BackPressure: MirrorBlock,
+				Buffer:       1,
+				OnFrame:      func(_ OffScreen) { time.Sleep(20 * time.Millisecond) },
+			})
+			defer m.Close()
+			for _, r := range "abcd" {
+				m.publish(newMirrorTestFrame(r))
+			}
+			stats := m.Stats()
+			So(stats.Dropped, ShouldEqual, 0)
+			So(stats.Blocked, ShouldBeGreaterThan, 0)
+			So(stats.BlockedFor, ShouldBeGreaterThan, 0)
+		})
+		Convey("stop when closed", func() {
+			m := newDisplayMirror(newMirrorTestScreen(), MirrorOptions{BackPressure: MirrorBlock})
+			m.Close()
+			m.Close()
+			m.publish(newMirrorTestFrame('a'))
+			So(m.Options().Buffer, ShouldEqual, DefaultMirrorBuffer)
+		})
+	})
+}
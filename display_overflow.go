@@ -0,0 +1,95 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+// newEventOverflows returns the overflow handling of each event queue, the
+// screen drops events, window queues coalesce events so as not to hold up the
+// main thread, while the others block as they always have
+func (d *CDisplay) newEventOverflows() (overflows map[EventQueue]*cEventOverflow) {
+	overflows = map[EventQueue]*cEventOverflow{
+		EventQueueScreen:  {policy: EventOverflowPolicy{Mode: OverflowDrop}},
+		EventQueuePosted:  {policy: EventOverflowPolicy{Mode: OverflowBlock}},
+		EventQueueInbound: {policy: EventOverflowPolicy{Mode: OverflowBlock}},
+		EventQueueWindow:  {policy: EventOverflowPolicy{Mode: OverflowCoalesce}},
+	}
+	for queue, overflow := range overflows {
+		queue := queue
+		overflow.handler = func(dropped Event) {
+			d.Emit(SignalEventOverflow, d, queue, dropped)
+		}
+	}
+	return
+}
+
+// applyEventOverflow configures the screen event queue, the Display must be
+// locked by the caller
+func (d *CDisplay) applyEventOverflow() {
+	if d.screen == nil {
+		return
+	}
+	overflow := d.overflows[EventQueueScreen]
+	d.screen.SetEventOverflowPolicy(overflow.getPolicy())
+	d.screen.SetEventOverflowHandler(func(dropped Event) {
+		overflow.drop(dropped)
+	})
+}
+
+// SetEventOverflowPolicy changes how the given event queue handles being
+// full. By default, events read from the terminal are dropped while posted and
+// inbound events wait for room in their queues. Each event discarded is
+// counted and SignalEventOverflow is emitted, from the goroutine which posted
+// the event.
+func (d *CDisplay) SetEventOverflowPolicy(queue EventQueue, policy EventOverflowPolicy) {
+	overflow, ok := d.overflows[queue]
+	if !ok {
+		d.LogError("unknown event queue: %v", queue)
+		return
+	}
+	overflow.setPolicy(policy)
+	if queue == EventQueueScreen {
+		if screen := d.Screen(); screen != nil {
+			screen.SetEventOverflowPolicy(policy)
+		}
+	}
+}
+
+// GetEventOverflowPolicy returns the overflow policy of the given event queue
+func (d *CDisplay) GetEventOverflowPolicy(queue EventQueue) (policy EventOverflowPolicy) {
+	if overflow, ok := d.overflows[queue]; ok {
+		policy = overflow.getPolicy()
+	}
+	return
+}
+
+// DroppedEvents returns the number of events discarded by the given event
+// queue since the Display was created
+func (d *CDisplay) DroppedEvents(queue EventQueue) (dropped uint64) {
+	if overflow, ok := d.overflows[queue]; ok {
+		dropped = overflow.getDropped()
+	}
+	return
+}
+
+// DisplaySignalEventOverflowArgv returns the event queue and the event dropped
+// from the arguments of SignalEventOverflow
+func DisplaySignalEventOverflowArgv(argv ...interface{}) (queue EventQueue, dropped Event, ok bool) {
+	if len(argv) == 3 {
+		if queue, ok = argv[1].(EventQueue); ok {
+			dropped, _ = argv[2].(Event)
+			return
+		}
+	}
+	return
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+// PastePrimary delivers the text of the primary selection to the focused
+// window as a bracketed paste, the same as a paste from the terminal: an
+// EventPaste start, an EventKey for each rune and an EventPaste end. This is
+// done when the middle mouse button is pressed and the press is not handled,
+// unless TerminalPrefs.DisablePrimaryPaste is set. The primary selection is
+// read in the background, as reading the host selection runs a command, and
+// the text is posted as a single event. Nothing is pasted if the primary
+// selection is empty. Returns false if the Display has no clipboard.
+func (d *CDisplay) PastePrimary() (pasted bool) {
+	clipboard := d.GetClipboard()
+	if clipboard == nil {
+		return false
+	}
+	Go(func() {
+		if text := clipboard.GetPrimaryText(); text != "" {
+			if err := d.PostEvent(newEventPasteText(text)); err != nil {
+				d.LogErr(err)
+			}
+		}
+	})
+	return true
+}
+
+// processPasteText delivers the text of the EventPaste as a bracketed paste,
+// processing each event directly instead of posting them, see: PastePrimary
+func (d *CDisplay) processPasteText(e *EventPaste) enums.EventFlag {
+	flag := enums.EVENT_PASS
+	for _, evt := range primaryPasteEvents(e.text) {
+		d.stampEvent(evt)
+		if f := d.processEvent(evt); f == enums.EVENT_STOP {
+			flag = enums.EVENT_STOP
+		}
+	}
+	return flag
+}
+
+// primaryPasteEvents returns the events of a bracketed paste of the text,
+// newlines are sent as the enter key as terminals do
+func primaryPasteEvents(text string) (events []Event) {
+	events = append(events, NewEventPaste(true))
+	for _, r := range text {
+		if r == '\n' {
+			r = '\r'
+		}
+		events = append(events, NewEventKey(KeyRune, r, ModNone))
+	}
+	return append(events, NewEventPaste(false))
+}
+
+// isPrimaryPasteClick returns true if the mouse event is a press of the
+// middle button which should paste the primary selection
+func (d *CDisplay) isPrimaryPasteClick(e *EventMouse) bool {
+	return e.IsPressed() && e.Button() == ButtonMiddle && !d.GetTerminalPrefs().DisablePrimaryPaste
+}
@@ -0,0 +1,105 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestDisplayPastePrimary(t *testing.T) {
+	Convey("Middle-click pasting the primary selection", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.Unlock()
+		// draw and show requests are posted too
+		posted := func() (events []Event) {
+			for {
+				select {
+				case evt := <-cd.events:
+					switch evt.(type) {
+					case *EventPaste, *EventKey:
+						events = append(events, evt)
+					}
+				default:
+					return
+				}
+			}
+		}
+
+		// mouse states follow on from the previous mouse event
+		d.ProcessEvent(NewEventMouse(0, 0, ButtonNone, ModNone))
+		// nothing is posted for an empty primary selection
+		So(d.PastePrimary(), ShouldBeTrue)
+		time.Sleep(time.Millisecond * 50)
+		So(posted(), ShouldBeEmpty)
+		d.GetClipboard().SetData(SelectionPrimary, NewClipboardText("a\nb"), nil)
+		So(d.GetClipboard().GetPrimaryText(), ShouldEqual, "a\nb")
+
+		So(d.ProcessEvent(NewEventMouse(1, 1, ButtonMiddle, ModNone)), ShouldEqual, enums.EVENT_STOP)
+		d.ProcessEvent(NewEventMouse(1, 1, ButtonNone, ModNone))
+		// read in the background and posted as a single event
+		var events []Event
+		for deadline := time.Now().Add(time.Second); len(events) == 0 && time.Now().Before(deadline); {
+			events = posted()
+		}
+		So(events, ShouldHaveLength, 1)
+		paste, ok := events[0].(*EventPaste)
+		So(ok, ShouldBeTrue)
+		So(paste.text, ShouldEqual, "a\nb")
+		// delivered directly as a bracketed paste
+		var delivered []Event
+		for _, signal := range []Signal{SignalEventPaste, SignalEventKey} {
+			d.Connect(signal, "primary-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				delivered = append(delivered, argv[1].(Event))
+				return enums.EVENT_PASS
+			})
+		}
+		d.ProcessEvent(paste)
+		So(delivered, ShouldHaveLength, 5)
+		So(delivered[0].(*EventPaste).Start(), ShouldBeTrue)
+		So(delivered[1].(*EventKey).Rune(), ShouldEqual, 'a')
+		So(delivered[2].(*EventKey).Rune(), ShouldEqual, '\r')
+		So(delivered[3].(*EventKey).Rune(), ShouldEqual, 'b')
+		So(delivered[4].(*EventPaste).End(), ShouldBeTrue)
+		So(posted(), ShouldBeEmpty)
+		_ = d.Disconnect(SignalEventPaste, "primary-test")
+		_ = d.Disconnect(SignalEventKey, "primary-test")
+
+		// other buttons and handled presses do not paste
+		d.ProcessEvent(NewEventMouse(1, 1, ButtonPrimary, ModNone))
+		d.ProcessEvent(NewEventMouse(1, 1, ButtonNone, ModNone))
+		d.Connect(SignalEventMouse, "primary-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return enums.EVENT_STOP
+		})
+		d.ProcessEvent(NewEventMouse(1, 1, ButtonMiddle, ModNone))
+		d.ProcessEvent(NewEventMouse(1, 1, ButtonNone, ModNone))
+		_ = d.Disconnect(SignalEventMouse, "primary-test")
+		So(posted(), ShouldBeEmpty)
+
+		prefs := d.GetTerminalPrefs()
+		prefs.DisablePrimaryPaste = true
+		So(d.SetTerminalPrefs(prefs), ShouldBeNil)
+		d.ProcessEvent(NewEventMouse(1, 1, ButtonMiddle, ModNone))
+		d.ProcessEvent(NewEventMouse(1, 1, ButtonNone, ModNone))
+		So(posted(), ShouldBeEmpty)
+	}))
+}
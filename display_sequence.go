@@ -0,0 +1,78 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// cEventSequencer gives each Event received by a Display its EventStamp
+type cEventSequencer struct {
+	last uint64
+
+	sync.Mutex
+}
+
+func newEventSequencer() (s *cEventSequencer) {
+	return &cEventSequencer{}
+}
+
+// stamp gives the event the next sequence number if it has not been stamped
+// already, returning false for events which cannot be stamped or were already
+func (s *cEventSequencer) stamp(evt Event) (stamp EventStamp, ok bool) {
+	if evt == nil {
+		return
+	}
+	stamper, can := evt.(interface{ SetStamp(stamp EventStamp) })
+	if !can || evt.Stamp().Valid() {
+		return
+	}
+	s.Lock()
+	s.last++
+	stamp = EventStamp{Sequence: s.last, Arrived: time.Now()}
+	s.Unlock()
+	if when := evt.When(); !when.IsZero() {
+		if stamp.Latency = stamp.Arrived.Sub(when); stamp.Latency < 0 {
+			stamp.Latency = 0
+		}
+	}
+	stamper.SetStamp(stamp)
+	return stamp, true
+}
+
+func (s *cEventSequencer) getLast() (sequence uint64) {
+	s.Lock()
+	defer s.Unlock()
+	return s.last
+}
+
+// stampEvent gives the event its EventStamp as it is received by the Display,
+// recording the arrival latency of user input
+func (d *CDisplay) stampEvent(evt Event) {
+	if stamp, ok := d.sequencer.stamp(evt); ok {
+		switch evt.(type) {
+		case *EventKey, *EventMouse, *EventPaste, *EventRaw:
+			d.stats.inputArrived(stamp.Latency)
+		}
+	}
+}
+
+// EventSequence returns the Sequence of the most recent EventStamp given by
+// the Display, zero if no events have been received yet
+func (d *CDisplay) EventSequence() (sequence uint64) {
+	return d.sequencer.getLast()
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDisplayEventSequence(t *testing.T) {
+	Convey("Event stamps", t, func() {
+		key := NewEventKey(KeyRune, 'a', ModNone)
+		So(key.Stamp().Valid(), ShouldBeFalse)
+		sequencer := newEventSequencer()
+		first, ok := sequencer.stamp(key)
+		So(ok, ShouldBeTrue)
+		So(first.Sequence, ShouldEqual, 1)
+		So(key.Stamp(), ShouldResemble, first)
+		_, ok = sequencer.stamp(key)
+		So(ok, ShouldBeFalse)
+		mouse := NewEventMouse(1, 1, Button1, ModNone)
+		mouse.t = time.Now().Add(-time.Second)
+		second, _ := sequencer.stamp(mouse)
+		So(first.Before(second), ShouldBeTrue)
+		So(second.Since(first), ShouldBeGreaterThanOrEqualTo, 0)
+		So(second.Latency, ShouldBeGreaterThanOrEqualTo, time.Second)
+		So(mouse.CloneForPosition(2, 2).Stamp(), ShouldResemble, second)
+		So(sequencer.getLast(), ShouldEqual, 2)
+	})
+	Convey("Display sequencing", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		So(d.EventSequence(), ShouldEqual, 0)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.Unlock()
+		posted := NewEventInterrupt(nil)
+		So(d.PostEvent(posted), ShouldBeNil)
+		So(posted.Stamp().Sequence, ShouldEqual, 1)
+		key := NewEventKey(KeyRune, 'a', ModNone)
+		key.t = time.Now().Add(-time.Millisecond * 5)
+		d.ProcessEvent(key)
+		So(key.Stamp().Sequence, ShouldEqual, 2)
+		So(d.EventSequence(), ShouldEqual, 2)
+		stats := d.Stats()
+		So(stats.Inputs, ShouldEqual, 1)
+		So(stats.LastInputLatency, ShouldBeGreaterThanOrEqualTo, time.Millisecond*5)
+		So(stats.AverageInputLatency, ShouldEqual, stats.LastInputLatency)
+		d.ProcessEvent(posted)
+		So(posted.Stamp().Sequence, ShouldEqual, 1)
+		So(d.EventSequence(), ShouldEqual, 2)
+	}))
+}
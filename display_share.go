@@ -0,0 +1,286 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	cid "github.com/go-curses/cdk/id"
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// ShareOptions configure the sharing of a Display with Display.ShareWith
+type ShareOptions struct {
+	// SharedInput forwards the key, mouse and paste input of the viewer to
+	// the shared Display, otherwise the viewer is read-only and their input is
+	// discarded
+	SharedInput bool
+}
+
+// DisplayShare is a Display being shown on the Screen of another Display,
+// the viewer, see: Display.ShareWith
+type DisplayShare interface {
+	ID() uuid.UUID
+	Source() Display
+	Viewer() Display
+	Options() ShareOptions
+	Stats() MirrorStats
+	Close()
+}
+
+// CDisplayShare is the concrete implementation of the DisplayShare interface
+type CDisplayShare struct {
+	id      uuid.UUID
+	source  *CDisplay
+	viewer  *CDisplay
+	options ShareOptions
+	mirror  DisplayMirror
+	sent    [][]cShareCell
+	closing sync.Once
+
+	sync.Mutex
+}
+
+// cShareCell is the content of a cell sent to the viewer
+type cShareCell struct {
+	x     int
+	y     int
+	mainc rune
+	combc []rune
+	style paint.Style
+}
+
+func (c cShareCell) equals(o cShareCell) bool {
+	if c.mainc != o.mainc || c.style != o.style || len(c.combc) != len(o.combc) {
+		return false
+	}
+	for idx, r := range c.combc {
+		if o.combc[idx] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// cShareFrame is posted to the viewer with the cells which changed since the
+// previous frame, or all of them when full
+type cShareFrame struct {
+	EventStamper
+
+	t     time.Time
+	share *CDisplayShare
+	full  bool
+	w     int
+	h     int
+	cells []cShareCell
+}
+
+func (f *cShareFrame) When() time.Time {
+	return f.t
+}
+
+// ShareWith shows the Display on the Screen of the viewer, for screen sharing
+// between local Displays or with the Display of an SSH client session. Only
+// the cells which changed are sent to the viewer, which stops drawing its own
+// windows until the share is closed. The share is closed when either Display
+// shuts down.
+func (d *CDisplay) ShareWith(viewer Display, options ShareOptions) (share DisplayShare, err error) {
+	v, ok := viewer.(*CDisplay)
+	if !ok || v == nil {
+		return nil, fmt.Errorf("viewer is not a *CDisplay: %T", viewer)
+	}
+	if v == d {
+		return nil, fmt.Errorf("a display cannot share with itself")
+	}
+	s := &CDisplayShare{
+		id:      cid.NewUUID(),
+		source:  d,
+		viewer:  v,
+		options: options,
+	}
+	v.Lock()
+	if v.viewing != nil {
+		v.Unlock()
+		return nil, fmt.Errorf("viewer is already viewing a shared display")
+	}
+	v.viewing = s
+	v.Unlock()
+	handle := "display-share-" + s.id.String()
+	for _, display := range []*CDisplay{d, v} {
+		display.Connect(SignalDisplayShutdown, handle, func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			s.Close()
+			return enums.EVENT_PASS
+		})
+	}
+	screen, _ := MakeOffScreen("UTF-8")
+	_ = screen.Init()
+	s.mirror = d.AddMirror(screen, MirrorOptions{
+		BackPressure: MirrorDropFrames,
+		OnFrame:      s.update,
+	})
+	d.Emit(SignalShareStarted, d, s)
+	return s, nil
+}
+
+// GetViewing returns the share shown on the Display, nil if not viewing a
+// shared Display
+func (d *CDisplay) GetViewing() (share DisplayShare) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.viewing != nil {
+		return d.viewing
+	}
+	return nil
+}
+
+// ID returns the unique identifier of the share
+func (s *CDisplayShare) ID() uuid.UUID {
+	return s.id
+}
+
+// Source returns the Display being shared
+func (s *CDisplayShare) Source() Display {
+	return s.source
+}
+
+// Viewer returns the Display showing the shared Display
+func (s *CDisplayShare) Viewer() Display {
+	return s.viewer
+}
+
+// Options returns the options of the share
+func (s *CDisplayShare) Options() ShareOptions {
+	return s.options
+}
+
+// Stats returns the running totals of the frames shared
+func (s *CDisplayShare) Stats() MirrorStats {
+	return s.mirror.Stats()
+}
+
+// Close stops sharing, the viewer resumes drawing its own windows
+func (s *CDisplayShare) Close() {
+	s.closing.Do(func() {
+		s.source.RemoveMirror(s.mirror)
+		handle := "display-share-" + s.id.String()
+		for _, display := range []*CDisplay{s.source, s.viewer} {
+			_ = display.Disconnect(SignalDisplayShutdown, handle)
+		}
+		s.viewer.Lock()
+		if s.viewer.viewing == s {
+			s.viewer.viewing = nil
+		}
+		s.viewer.Unlock()
+		if s.viewer.IsRunning() {
+			s.viewer.RequestDraw()
+			s.viewer.RequestSync()
+		}
+		s.source.Emit(SignalShareEnded, s.source, s)
+	})
+}
+
+// resend makes the next frame sent to the viewer a full frame
+func (s *CDisplayShare) resend() {
+	s.Lock()
+	s.sent = nil
+	s.Unlock()
+	s.source.RequestShow()
+}
+
+// update sends the cells of the frame which changed since the previous frame
+// to the viewer, called on the goroutine of the mirror
+func (s *CDisplayShare) update(screen OffScreen) {
+	w, h := screen.Size()
+	s.Lock()
+	full := len(s.sent) != h || (h > 0 && len(s.sent[0]) != w)
+	if full {
+		s.sent = make([][]cShareCell, h)
+		for y := range s.sent {
+			s.sent[y] = make([]cShareCell, w)
+		}
+	}
+	frame := &cShareFrame{t: time.Now(), share: s, full: full, w: w, h: h}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			mainc, combc, style, _ := screen.GetContent(x, y)
+			cell := cShareCell{x: x, y: y, mainc: mainc, combc: combc, style: style}
+			if full || !cell.equals(s.sent[y][x]) {
+				s.sent[y][x] = cell
+				frame.cells = append(frame.cells, cell)
+			}
+		}
+	}
+	s.Unlock()
+	if len(frame.cells) == 0 && !full {
+		return
+	}
+	if err := s.viewer.PostEvent(frame); err != nil {
+		// the viewer missed this frame, send everything next time
+		s.Lock()
+		s.sent = nil
+		s.Unlock()
+	}
+}
+
+// processShareFrame shows the cells of the frame on the Screen of the viewer
+func (d *CDisplay) processShareFrame(frame *cShareFrame) enums.EventFlag {
+	d.RLock()
+	viewing, screen := d.viewing, d.screen
+	d.RUnlock()
+	if viewing != frame.share || screen == nil || d.IsDetached() {
+		return enums.EVENT_STOP
+	}
+	if frame.full {
+		screen.Clear()
+	}
+	w, h := screen.Size()
+	for _, cell := range frame.cells {
+		if cell.x < w && cell.y < h {
+			screen.SetContent(cell.x, cell.y, cell.mainc, cell.combc, cell.style)
+		}
+	}
+	screen.Show()
+	return enums.EVENT_STOP
+}
+
+// processShareInput handles the input of a viewer, forwarding it to the shared
+// Display when the input is shared, returning false if not viewing
+func (d *CDisplay) processShareInput(evt Event) (f enums.EventFlag, viewing bool) {
+	d.RLock()
+	share := d.viewing
+	d.RUnlock()
+	if share == nil {
+		return enums.EVENT_PASS, false
+	}
+	switch evt.(type) {
+	case *EventKey, *EventMouse, *EventPaste:
+		if share.options.SharedInput {
+			if err := share.source.PostEvent(evt); err != nil {
+				d.LogErr(err)
+			}
+		}
+		return enums.EVENT_STOP, true
+	case *EventResize:
+		// the windows of the viewer are still resized, the shared content
+		// is sent again in full
+		share.resend()
+	}
+	return enums.EVENT_PASS, false
+}
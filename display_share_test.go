@@ -0,0 +1,118 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+func TestDisplayShare(t *testing.T) {
+	Convey("Display sharing", t, WithDisplayManager(func(d Display) {
+		source := d.(*CDisplay)
+		viewer := NewDisplay("viewer", OffscreenTtyPath)
+		So(viewer.CaptureDisplay(), ShouldBeNil)
+		defer viewer.ReleaseDisplay()
+		for _, display := range []*CDisplay{source, viewer} {
+			display.Lock()
+			display.started = true
+			display.Unlock()
+			display.setRunning(true)
+		}
+		defer func() {
+			for _, display := range []*CDisplay{source, viewer} {
+				display.setRunning(false)
+			}
+		}()
+		drain := func(display *CDisplay) (events []Event) {
+			for len(display.events) > 0 {
+				events = append(events, <-display.events)
+			}
+			return
+		}
+
+		_, err := source.ShareWith(source, ShareOptions{})
+		So(err, ShouldNotBeNil)
+		var signals []Signal
+		for _, signal := range []Signal{SignalShareStarted, SignalShareEnded} {
+			signal := signal
+			source.Connect(signal, "test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				signals = append(signals, signal)
+				return enums.EVENT_PASS
+			})
+		}
+		share, err := source.ShareWith(viewer, ShareOptions{SharedInput: true})
+		So(err, ShouldBeNil)
+		So(viewer.GetViewing(), ShouldEqual, share)
+		So(source.GetMirrors(), ShouldHaveLength, 1)
+		_, err = source.ShareWith(viewer, ShareOptions{})
+		So(err, ShouldNotBeNil)
+		drain(source)
+		drain(viewer)
+
+		// frames are sent in full and then only the changes
+		frame, _ := MakeOffScreen("UTF-8")
+		_ = frame.Init()
+		frame.SetSize(4, 2)
+		frame.SetContent(0, 0, 'a', nil, paint.StyleDefault)
+		s := share.(*CDisplayShare)
+		s.update(frame)
+		events := drain(viewer)
+		So(events, ShouldHaveLength, 1)
+		full := events[0].(*cShareFrame)
+		So(full.full, ShouldBeTrue)
+		So(full.cells, ShouldHaveLength, 8)
+		So(viewer.ProcessEvent(full), ShouldEqual, enums.EVENT_STOP)
+		mainc, _, _, _ := viewer.Screen().GetContent(0, 0)
+		So(mainc, ShouldEqual, 'a')
+		s.update(frame)
+		So(drain(viewer), ShouldBeEmpty)
+		frame.SetContent(1, 1, 'b', nil, paint.StyleDefault)
+		s.update(frame)
+		events = drain(viewer)
+		So(events, ShouldHaveLength, 1)
+		diff := events[0].(*cShareFrame)
+		So(diff.full, ShouldBeFalse)
+		So(diff.cells, ShouldHaveLength, 1)
+		viewer.ProcessEvent(diff)
+		mainc, _, _, _ = viewer.Screen().GetContent(1, 1)
+		So(mainc, ShouldEqual, 'b')
+
+		// the viewer does not draw its own windows
+		So(viewer.renderScreen(), ShouldEqual, enums.EVENT_PASS)
+
+		// input is forwarded to the shared display
+		key := NewEventKey(KeyRune, 'x', ModNone)
+		So(viewer.ProcessEvent(key), ShouldEqual, enums.EVENT_STOP)
+		So(drain(source), ShouldResemble, []Event{key})
+		s.options.SharedInput = false
+		So(viewer.ProcessEvent(key), ShouldEqual, enums.EVENT_STOP)
+		So(drain(source), ShouldBeEmpty)
+
+		share.Close()
+		share.Close()
+		So(viewer.GetViewing(), ShouldBeNil)
+		So(source.GetMirrors(), ShouldBeEmpty)
+		So(signals, ShouldResemble, []Signal{SignalShareStarted, SignalShareEnded})
+		// frames of a closed share are ignored
+		viewer.ProcessEvent(full)
+		mainc, _, _, _ = viewer.Screen().GetContent(1, 1)
+		So(mainc, ShouldEqual, 'b')
+	}))
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+var (
+	// RenderStatsInterval is the period between SignalRenderStats emissions
+	// while a Display is running, zero to disable
+	RenderStatsInterval = time.Second * 5
+)
+
+// DisplayStats are counters describing the workload of a Display, intended
+// for diagnosing sluggish applications
+type DisplayStats struct {
+	FrameStats
+
+	// Events is the number of events processed
+	Events uint64
+	// Draws is the number of times all windows were drawn and composited
+	Draws uint64
+	// LastDraw is how long the most recent draw took
+	LastDraw time.Duration
+	// AverageDraw is the mean time taken to draw
+	AverageDraw time.Duration
+	// Composites is the number of window surfaces composited
+	Composites uint64
+	// LastComposites is the number of window surfaces composited by the most
+	// recent draw
+	LastComposites int
+	// Cells is the number of cells written to the Screen
+	Cells uint64
+	// LastCells is the number of cells written to the Screen by the most
+	// recent frame
+	LastCells int
+	// LastBytes is the number of bytes written to the terminal by the most
+	// recent frame
+	LastBytes int
+	// Inputs is the number of key, mouse, paste and raw input events received
+	Inputs uint64
+	// LastInputLatency is the time between the most recent input event being
+	// created and its arrival at the Display, see EventStamp
+	LastInputLatency time.Duration
+	// AverageInputLatency is the mean arrival latency of input events
+	AverageInputLatency time.Duration
+}
+
+func (s DisplayStats) String() string {
+	return fmt.Sprintf(
+		"{events=%d,frames=%d,dropped=%d,frame=%v/%v,draws=%d,draw=%v/%v,composites=%d/%d,cells=%d/%d,bytes=%d,inputs=%d,latency=%v/%v}",
+		s.Events, s.Frames, s.Dropped, s.LastFrame, s.AverageFrame,
+		s.Draws, s.LastDraw, s.AverageDraw,
+		s.LastComposites, s.Composites,
+		s.LastCells, s.Cells, s.LastBytes,
+		s.Inputs, s.LastInputLatency, s.AverageInputLatency,
+	)
+}
+
+type cDisplayStats struct {
+	stats   DisplayStats
+	total   time.Duration
+	latency time.Duration
+
+	sync.Mutex
+}
+
+func (s *cDisplayStats) eventProcessed() {
+	s.Lock()
+	s.stats.Events++
+	s.Unlock()
+}
+
+func (s *cDisplayStats) drawDone(duration time.Duration, composites int) {
+	s.Lock()
+	defer s.Unlock()
+	s.stats.Draws++
+	s.stats.LastDraw = duration
+	s.total += duration
+	s.stats.AverageDraw = s.total / time.Duration(s.stats.Draws)
+	s.stats.Composites += uint64(composites)
+	s.stats.LastComposites = composites
+}
+
+func (s *cDisplayStats) screenDone(cells, bytes int) {
+	s.Lock()
+	defer s.Unlock()
+	s.stats.Cells += uint64(cells)
+	s.stats.LastCells = cells
+	s.stats.LastBytes = bytes
+}
+
+func (s *cDisplayStats) inputArrived(latency time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.stats.Inputs++
+	s.stats.LastInputLatency = latency
+	s.latency += latency
+	s.stats.AverageInputLatency = s.latency / time.Duration(s.stats.Inputs)
+}
+
+func (s *cDisplayStats) get() (stats DisplayStats) {
+	s.Lock()
+	defer s.Unlock()
+	return s.stats
+}
+
+// Stats returns the counters gathered since the Display was created
+func (d *CDisplay) Stats() (stats DisplayStats) {
+	stats = d.stats.get()
+	stats.FrameStats = d.render.getStats()
+	return
+}
+
+// renderStatsWorker periodically emits SignalRenderStats on the UI thread
+func (d *CDisplay) renderStatsWorker(ctx context.Context) {
+	if RenderStatsInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(RenderStatsInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			_ = d.AsyncCall(func(d Display) error {
+				d.Emit(SignalRenderStats, d, d.Stats())
+				return nil
+			})
+		}
+	}
+}
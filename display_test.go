@@ -0,0 +1,171 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestDisplayPanicRecovery(t *testing.T) {
+	Convey("Display panic recovery", t, func() {
+		Convey("handled by a listener", WithDisplayManager(func(d Display) {
+			var recovered interface{}
+			var trace []byte
+			d.Connect(SignalDisplayPanic, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				recovered, trace, _ = DisplaySignalDisplayPanicArgv(argv...)
+				return enums.EVENT_STOP
+			})
+			err := d.(*CDisplay).callSafely(func(d Display) error {
+				panic("oops")
+			})
+			So(err, ShouldBeNil)
+			So(recovered, ShouldEqual, "oops")
+			So(string(trace), ShouldContainSubstring, "callSafely")
+			So(d.DisplayCaptured(), ShouldBeTrue)
+		}))
+		Convey("restores the terminal", WithDisplayManager(func(d Display) {
+			err := d.(*CDisplay).callSafely(func(d Display) error {
+				panic(fmt.Errorf("oops"))
+			})
+			So(err, ShouldBeNil)
+			So(d.DisplayCaptured(), ShouldBeFalse)
+		}))
+		Convey("passes through without panics", WithDisplayManager(func(d Display) {
+			err := d.(*CDisplay).callSafely(func(d Display) error {
+				return fmt.Errorf("error")
+			})
+			So(err, ShouldNotBeNil)
+			So(d.DisplayCaptured(), ShouldBeTrue)
+		}))
+	})
+}
+
+func TestDisplayStats(t *testing.T) {
+	Convey("Display stats", t, WithDisplayManager(func(d Display) {
+		stats := d.Stats()
+		So(stats.Events, ShouldEqual, 0)
+		So(stats.Frames, ShouldEqual, 0)
+		cd := d.(*CDisplay)
+		cd.stats.eventProcessed()
+		cd.stats.drawDone(time.Millisecond*2, 3)
+		cd.stats.drawDone(time.Millisecond*4, 1)
+		cd.stats.screenDone(10, 100)
+		stats = d.Stats()
+		So(stats.Events, ShouldEqual, 1)
+		So(stats.Draws, ShouldEqual, 2)
+		So(stats.LastDraw, ShouldEqual, time.Millisecond*4)
+		So(stats.AverageDraw, ShouldEqual, time.Millisecond*3)
+		So(stats.Composites, ShouldEqual, 4)
+		So(stats.LastComposites, ShouldEqual, 1)
+		So(stats.Cells, ShouldEqual, 10)
+		So(stats.LastBytes, ShouldEqual, 100)
+		So(stats.String(), ShouldContainSubstring, "events=1")
+	}))
+}
+
+func TestDisplayRenderWorkers(t *testing.T) {
+	Convey("Display render workers", t, WithDisplayManager(func(d Display) {
+		So(d.GetRenderWorkers(), ShouldEqual, DefaultRenderWorkers)
+		d.SetRenderWorkers(4)
+		So(d.GetRenderWorkers(), ShouldEqual, 4)
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.resized = true
+		cd.Unlock()
+		d.ProcessEvent(NewEventResize(20, 10))
+		w := NewOffscreenWindow("testing")
+		d.MapWindowWithRegion(w, ptypes.MakeRegion(2, 2, 5, 3))
+		So(cd.renderScreen(), ShouldEqual, enums.EVENT_STOP)
+	}))
+}
+
+type testRenderScreen struct {
+	OffScreen
+
+	shows, syncs int
+}
+
+func (s *testRenderScreen) Show() {
+	s.shows++
+	s.OffScreen.Show()
+}
+
+func (s *testRenderScreen) Sync() {
+	s.syncs++
+	s.OffScreen.Sync()
+}
+
+func TestDisplayRenderRequests(t *testing.T) {
+	Convey("Display render requests", t, func() {
+		Convey("request constructors", func() {
+			So(NewEventDraw().Request(), ShouldEqual, RenderDraw)
+			So(NewEventShow().Request(), ShouldEqual, RenderShow)
+			So(NewEventSync().Request(), ShouldEqual, RenderSync)
+			So(NewEventDrawAndShow().Request(), ShouldEqual, RenderDraw|RenderShow)
+			So(NewEventDrawAndSync().Request(), ShouldEqual, RenderDraw|RenderSync)
+			So(NewEventRender(false, true, true).Request(), ShouldEqual, RenderSync)
+			So(NewEventShow().Merge(NewEventDraw()).Request(), ShouldEqual, RenderDraw|RenderShow)
+		})
+		Convey("screen calls", WithDisplayManager(func(d Display) {
+			cd := d.(*CDisplay)
+			cd.SetFrameRate(0)
+			cd.Lock()
+			cd.running = true
+			cd.started = true
+			cd.resized = true
+			screen := &testRenderScreen{OffScreen: cd.screen.(OffScreen)}
+			cd.screen = screen
+			cd.Unlock()
+			d.ProcessEvent(NewEventResize(20, 10))
+			for len(cd.events) > 0 {
+				<-cd.events
+			}
+			screen.shows, screen.syncs = 0, 0
+			process := func(request func()) {
+				request()
+				select {
+				case evt := <-cd.events:
+					d.ProcessEvent(evt)
+				default:
+					t.Fatal("render request not posted")
+				}
+			}
+			process(d.RequestDraw)
+			So(screen.shows, ShouldEqual, 0)
+			So(screen.syncs, ShouldEqual, 0)
+			process(d.RequestShow)
+			So(screen.shows, ShouldEqual, 1)
+			So(screen.syncs, ShouldEqual, 0)
+			process(d.RequestSync)
+			So(screen.shows, ShouldEqual, 1)
+			So(screen.syncs, ShouldEqual, 1)
+			frames := cd.render.getStats().Frames
+			cd.SetFrameRate(1)
+			process(d.ForceRepaint)
+			process(d.ForceRepaint)
+			So(screen.syncs, ShouldEqual, 3)
+			So(cd.render.getStats().Frames, ShouldEqual, frames+2)
+		}))
+	})
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"os"
+	"sort"
+	"time"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// DefaultThemeWatchInterval is how often watched theme files are checked for
+// changes when no interval is given to WatchThemeFile
+var DefaultThemeWatchInterval = time.Second
+
+// LoadThemeFile parses the theme definition file at the given path (see:
+// paint.ThemeFile) and registers the themes, borders and arrows it defines.
+// SignalThemeChanged is emitted with the path and the names of the themes
+// loaded so that widgets can look up their themes again and restyle.
+func (d *CDisplay) LoadThemeFile(path string) (names []paint.ThemeName, err error) {
+	var tf *paint.ThemeFile
+	if tf, err = paint.LoadThemeFile(path); err != nil {
+		return
+	}
+	tf.Register()
+	names = tf.ThemeNames()
+	d.Emit(SignalThemeChanged, d, path, names)
+	d.RequestDraw()
+	d.RequestShow()
+	return
+}
+
+// WatchThemeFile loads the theme definition file at the given path and then
+// checks the file for changes at the given interval, loading it again each
+// time it is modified. Errors encountered reloading the file are logged and
+// the previously loaded themes remain registered. Watching stops with
+// StopWatchingThemeFile or when the Display is destroyed.
+func (d *CDisplay) WatchThemeFile(path string, interval time.Duration) (err error) {
+	if _, err = d.LoadThemeFile(path); err != nil {
+		return
+	}
+	var last os.FileInfo
+	if last, err = os.Stat(path); err != nil {
+		return
+	}
+	if interval <= 0 {
+		interval = DefaultThemeWatchInterval
+	}
+	d.StopWatchingThemeFile(path)
+	stop := make(chan bool)
+	d.Lock()
+	d.themeWatch[path] = stop
+	d.Unlock()
+	Go(func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			info, e := os.Stat(path)
+			if e != nil || (info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size()) {
+				continue
+			}
+			last = info
+			reload := func(_ Display) error {
+				if _, e := d.LoadThemeFile(path); e != nil {
+					d.LogErr(e)
+				}
+				return nil
+			}
+			if d.IsRunning() {
+				_ = d.AsyncCall(reload)
+			} else {
+				_ = reload(d)
+			}
+		}
+	})
+	return
+}
+
+// StopWatchingThemeFile stops checking the theme definition file at the given
+// path for changes, the themes loaded remain registered
+func (d *CDisplay) StopWatchingThemeFile(path string) {
+	d.Lock()
+	defer d.Unlock()
+	if stop, ok := d.themeWatch[path]; ok {
+		close(stop)
+		delete(d.themeWatch, path)
+	}
+}
+
+func (d *CDisplay) stopWatchingThemeFiles() {
+	d.Lock()
+	defer d.Unlock()
+	for path, stop := range d.themeWatch {
+		close(stop)
+		delete(d.themeWatch, path)
+	}
+}
+
+// reloadThemeFiles loads each of the theme files watched once again
+func (d *CDisplay) reloadThemeFiles() {
+	d.RLock()
+	paths := make([]string, 0, len(d.themeWatch))
+	for path := range d.themeWatch {
+		paths = append(paths, path)
+	}
+	d.RUnlock()
+	sort.Strings(paths)
+	for _, path := range paths {
+		if _, err := d.LoadThemeFile(path); err != nil {
+			d.LogErr(err)
+		}
+	}
+}
+
+// processReload reloads the config of the Application, or the theme files
+// watched by a Display without one, see: EventReload
+func (d *CDisplay) processReload() enums.EventFlag {
+	d.LogInfo("reload requested")
+	if app := d.App(); app != nil {
+		Go(func() {
+			if err := app.ReloadConfig(); err != nil {
+				app.LogErr(err)
+			}
+		})
+		return enums.EVENT_STOP
+	}
+	d.reloadThemeFiles()
+	d.Emit(SignalReloadConfig, d)
+	return enums.EVENT_STOP
+}
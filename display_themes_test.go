@@ -0,0 +1,115 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/log"
+)
+
+func TestDisplayThemeFiles(t *testing.T) {
+	Convey("Loading and watching theme files", t, WithDisplayManager(func(d Display) {
+		path := filepath.Join(t.TempDir(), "theme.toml")
+		write := func(fill string) {
+			So(os.WriteFile(path, []byte("[themes.test-watched.content]\nfill-rune = \""+fill+"\"\n"), 0600), ShouldBeNil)
+		}
+		changed := make(chan []paint.ThemeName, 4)
+		d.Connect(SignalThemeChanged, "test-theme-changed", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			if len(argv) == 3 {
+				if names, ok := argv[2].([]paint.ThemeName); ok {
+					changed <- names
+				}
+			}
+			return enums.EVENT_PASS
+		})
+
+		_, err := d.LoadThemeFile(path)
+		So(err, ShouldNotBeNil)
+
+		write("a")
+		So(d.WatchThemeFile(path, 10*time.Millisecond), ShouldBeNil)
+		So(<-changed, ShouldResemble, []paint.ThemeName{"test-watched"})
+		theme, ok := paint.GetTheme("test-watched")
+		So(ok, ShouldBeTrue)
+		So(theme.Content.FillRune, ShouldEqual, 'a')
+
+		// ensure the modification time differs on coarse filesystems
+		later := time.Now().Add(time.Second)
+		write("b")
+		So(os.Chtimes(path, later, later), ShouldBeNil)
+		select {
+		case <-changed:
+		case <-time.After(2 * time.Second):
+		}
+		theme, _ = paint.GetTheme("test-watched")
+		So(theme.Content.FillRune, ShouldEqual, 'b')
+
+		d.StopWatchingThemeFile(path)
+		write("c")
+		later = later.Add(time.Second)
+		So(os.Chtimes(path, later, later), ShouldBeNil)
+		time.Sleep(50 * time.Millisecond)
+		theme, _ = paint.GetTheme("test-watched")
+		So(theme.Content.FillRune, ShouldEqual, 'b')
+		d.Disconnect(SignalThemeChanged, "test-theme-changed")
+	}))
+}
+
+func TestDisplayThemeFileShutdown(t *testing.T) {
+	Convey("Watching theme files does not delay shutdown", t, func() {
+		path := filepath.Join(t.TempDir(), "theme.toml")
+		So(os.WriteFile(path, []byte("[themes.test-shutdown.content]\nfill-rune = \"a\"\n"), 0600), ShouldBeNil)
+		warnings := bytes.NewBufferString("")
+		So(log.AddSink("test-shutdown", log.Sink{Writer: warnings, Level: log.LevelWarn}), ShouldBeNil)
+		defer log.RemoveSink("test-shutdown")
+		// run within the context of the display, as applications do, so that
+		// the watcher is one of its goroutines
+		d := NewDisplay("testing", OffscreenTtyPath)
+		finished := make(chan struct{})
+		started := make(chan struct{})
+		d.Connect(SignalDisplayStartup, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			d.StartupComplete()
+			close(started)
+			return enums.EVENT_PASS
+		})
+		go GoWithMainContext("", "", d, nil, func() {
+			_ = d.Run()
+			close(finished)
+		})
+		<-started
+		So(d.AwaitCall(func(d Display) error {
+			return d.WatchThemeFile(path, time.Hour)
+		}), ShouldBeNil)
+		quit := time.Now()
+		d.RequestQuit()
+		select {
+		case <-finished:
+		case <-time.After(5 * time.Second):
+			t.Fatal("display did not shut down")
+		}
+		So(time.Since(quit), ShouldBeLessThan, GoroutineShutdownTimeout/2)
+		So(warnings.String(), ShouldNotContainSubstring, "goroutine leaked")
+		So(warnings.String(), ShouldNotContainSubstring, "still running after shutdown")
+	})
+}
@@ -0,0 +1,41 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris zos
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	cterm "github.com/go-curses/term"
+)
+
+// dupTty returns a duplicate of the tty file handle, to be closed by the
+// caller independently of the original
+func dupTty(tty *os.File) (dupe *os.File, err error) {
+	var fd int
+	if fd, err = syscall.Dup(int(tty.Fd())); err != nil {
+		return nil, fmt.Errorf("syscall.Dup error: %v", err)
+	}
+	return os.NewFile(uintptr(fd), tty.Name()), nil
+}
+
+// tiocsti injects the input into the tty, unblocking any pending reads
+func tiocsti(fd uintptr, input string) error {
+	return cterm.Tiocsti(fd, input)
+}
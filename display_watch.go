@@ -0,0 +1,288 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/go-curses/cdk/lib/sync"
+	"github.com/go-curses/cdk/log"
+)
+
+// ErrWatchUnsupported is returned by Display.WatchFD on platforms without
+// support for polling file descriptors
+var ErrWatchUnsupported = errors.New("watching file descriptors is not supported")
+
+// DisplayWatchCapacity is the number of ready file descriptors buffered for
+// the Display event processor
+var DisplayWatchCapacity = 64
+
+// IOCondition is a set of file descriptor conditions, as in poll(2)
+type IOCondition uint16
+
+const (
+	// IOIn is data available to read
+	IOIn IOCondition = 1 << iota
+	// IOOut is writing possible without blocking
+	IOOut
+	// IOPri is urgent data available to read
+	IOPri
+	// IOErr is an error condition, always reported
+	IOErr
+	// IOHup is the other end hung up, always reported
+	IOHup
+	// IONval is an invalid file descriptor, such as one closed while
+	// watched, always reported
+	IONval
+)
+
+func (c IOCondition) String() string {
+	var names []string
+	for _, flag := range []struct {
+		c    IOCondition
+		name string
+	}{
+		{IOIn, "in"},
+		{IOOut, "out"},
+		{IOPri, "pri"},
+		{IOErr, "err"},
+		{IOHup, "hup"},
+		{IONval, "nval"},
+	} {
+		if c&flag.c != 0 {
+			names = append(names, flag.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "|")
+}
+
+// IOWatchFn is called on the Display event processor thread when a watched
+// file descriptor is ready, returning false stops watching it
+type IOWatchFn = func(condition IOCondition) (keep bool)
+
+type cFDWatch struct {
+	id        int
+	fd        uintptr
+	events    IOCondition
+	fn        IOWatchFn
+	condition IOCondition
+	armed     bool
+	removed   bool
+}
+
+// cFDWatcher polls the watched file descriptors in its own go thread, sending
+// those which are ready to the Display event processor. A watch is not polled
+// again until its IOWatchFn has returned, so that level triggered conditions
+// are reported only once for each time the IOWatchFn is called.
+type cFDWatcher struct {
+	watches map[int]*cFDWatch
+	nextID  int
+	ready   chan *cFDWatch
+	wakeR   *os.File
+	wakeW   *os.File
+	stopped bool
+	done    chan struct{}
+
+	sync.Mutex
+}
+
+func newFDWatcher(ready chan *cFDWatch) (w *cFDWatcher, err error) {
+	w = &cFDWatcher{
+		watches: make(map[int]*cFDWatch),
+		ready:   ready,
+		done:    make(chan struct{}),
+	}
+	if w.wakeR, w.wakeW, err = os.Pipe(); err != nil {
+		return nil, fmt.Errorf("error creating watch pipe: %w", err)
+	}
+	Go(w.pollLoop)
+	return
+}
+
+func (w *cFDWatcher) add(fd uintptr, events IOCondition, fn IOWatchFn) (id int) {
+	w.Lock()
+	w.nextID++
+	id = w.nextID
+	w.watches[id] = &cFDWatch{id: id, fd: fd, events: events, fn: fn, armed: true}
+	w.Unlock()
+	w.wake()
+	return
+}
+
+func (w *cFDWatcher) remove(id int) (found bool) {
+	w.Lock()
+	var watch *cFDWatch
+	if watch, found = w.watches[id]; found {
+		watch.removed = true
+		delete(w.watches, id)
+	}
+	w.Unlock()
+	if found {
+		w.wake()
+	}
+	return
+}
+
+// dispatch calls the IOWatchFn of the ready watch, re-arming it if kept
+func (w *cFDWatcher) dispatch(watch *cFDWatch) {
+	w.Lock()
+	removed, condition := watch.removed, watch.condition
+	w.Unlock()
+	if removed {
+		return
+	}
+	keep := watch.fn(condition)
+	w.Lock()
+	if !keep {
+		watch.removed = true
+		delete(w.watches, watch.id)
+	} else if !watch.removed {
+		watch.armed = true
+	}
+	w.Unlock()
+	w.wake()
+}
+
+func (w *cFDWatcher) wake() {
+	_, _ = w.wakeW.Write([]byte{0})
+}
+
+func (w *cFDWatcher) stop() {
+	w.Lock()
+	if w.stopped {
+		w.Unlock()
+		return
+	}
+	w.stopped = true
+	w.Unlock()
+	close(w.done)
+	w.wake()
+}
+
+func (w *cFDWatcher) pollLoop() {
+	defer func() {
+		_ = w.wakeR.Close()
+		_ = w.wakeW.Close()
+	}()
+	drain := make([]byte, 64)
+	for {
+		w.Lock()
+		if w.stopped {
+			w.Unlock()
+			return
+		}
+		var armed []*cFDWatch
+		var fds []uintptr
+		var events []IOCondition
+		for _, watch := range w.watches {
+			if watch.armed {
+				armed = append(armed, watch)
+				fds = append(fds, watch.fd)
+				events = append(events, watch.events)
+			}
+		}
+		w.Unlock()
+
+		conditions, woke, err := pollFDs(w.wakeR.Fd(), fds, events)
+		if err != nil {
+			log.ErrorF("error polling watched file descriptors: %v", err)
+			return
+		}
+		if woke {
+			_, _ = w.wakeR.Read(drain)
+		}
+		for idx, condition := range conditions {
+			if condition == 0 {
+				continue
+			}
+			watch := armed[idx]
+			w.Lock()
+			if watch.removed {
+				w.Unlock()
+				continue
+			}
+			watch.armed = false
+			watch.condition = condition
+			w.Unlock()
+			select {
+			case w.ready <- watch:
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// WatchFD calls the IOWatchFn on the Display event processor thread, the same
+// thread handling the Screen events and AsyncCall callbacks, whenever the file
+// descriptor meets any of the conditions. The IOErr, IOHup and IONval
+// conditions are always reported. Watching stops when the IOWatchFn returns
+// false or UnwatchFD is called with the id returned. The file descriptor is
+// not closed by the Display.
+func (d *CDisplay) WatchFD(fd uintptr, events IOCondition, fn IOWatchFn) (id int, err error) {
+	if fn == nil {
+		return 0, fmt.Errorf("watch function is nil")
+	}
+	if !fdWatchSupported {
+		return 0, ErrWatchUnsupported
+	}
+	d.Lock()
+	if d.fdWatcher == nil {
+		if d.fdWatcher, err = newFDWatcher(d.fdReady); err != nil {
+			d.Unlock()
+			return 0, err
+		}
+	}
+	watcher := d.fdWatcher
+	d.Unlock()
+	return watcher.add(fd, events, fn), nil
+}
+
+// UnwatchFD stops watching the file descriptor, returning false if the id is
+// not being watched
+func (d *CDisplay) UnwatchFD(id int) (found bool) {
+	d.RLock()
+	watcher := d.fdWatcher
+	d.RUnlock()
+	if watcher == nil {
+		return false
+	}
+	return watcher.remove(id)
+}
+
+func (d *CDisplay) dispatchFDWatch(watch *cFDWatch) {
+	d.RLock()
+	watcher := d.fdWatcher
+	d.RUnlock()
+	if watcher != nil {
+		watcher.dispatch(watch)
+	}
+}
+
+func (d *CDisplay) stopWatchingFDs() {
+	d.Lock()
+	watcher := d.fdWatcher
+	d.fdWatcher = nil
+	d.Unlock()
+	if watcher != nil {
+		watcher.stop()
+	}
+}
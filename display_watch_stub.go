@@ -0,0 +1,24 @@
+//go:build js || nacl || plan9 || windows
+// +build js nacl plan9 windows
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+const fdWatchSupported = false
+
+func pollFDs(_ uintptr, _ []uintptr, _ []IOCondition) (conditions []IOCondition, woke bool, err error) {
+	return nil, false, ErrWatchUnsupported
+}
@@ -0,0 +1,99 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris zos
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDisplayWatchFD(t *testing.T) {
+	Convey("IO conditions", t, func() {
+		So(IOCondition(0).String(), ShouldEqual, "none")
+		So((IOIn | IOHup).String(), ShouldEqual, "in|hup")
+	})
+	Convey("Display watched file descriptors", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.setRunning(true)
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			cd.processEventWorker(ctx)
+			close(done)
+		}()
+		defer func() {
+			cancel()
+			<-done
+			cd.setRunning(false)
+			cd.stopWatchingFDs()
+		}()
+
+		r, w, err := os.Pipe()
+		So(err, ShouldBeNil)
+		defer func() { _ = r.Close() }()
+		got := make(chan string, 10)
+		id, err := d.WatchFD(r.Fd(), IOIn, func(condition IOCondition) bool {
+			buf := make([]byte, 16)
+			n, _ := r.Read(buf)
+			got <- condition.String() + ":" + string(buf[:n])
+			return n > 0
+		})
+		So(err, ShouldBeNil)
+		So(id, ShouldBeGreaterThan, 0)
+		next := func() string {
+			select {
+			case s := <-got:
+				return s
+			case <-time.After(time.Second * 2):
+				return "timeout"
+			}
+		}
+		_, _ = w.Write([]byte("one"))
+		So(next(), ShouldEqual, "in:one")
+		_, _ = w.Write([]byte("two"))
+		So(next(), ShouldEqual, "in:two")
+		// the writer hanging up ends the watch with a zero length read
+		_ = w.Close()
+		hup := next()
+		So(hup, ShouldContainSubstring, "hup")
+		So(hup, ShouldEndWith, ":")
+		So(d.UnwatchFD(id), ShouldBeFalse)
+
+		r2, w2, err := os.Pipe()
+		So(err, ShouldBeNil)
+		defer func() { _ = r2.Close(); _ = w2.Close() }()
+		id, err = d.WatchFD(r2.Fd(), IOIn, func(condition IOCondition) bool {
+			got <- "unexpected"
+			return true
+		})
+		So(err, ShouldBeNil)
+		So(d.UnwatchFD(id), ShouldBeTrue)
+		_, _ = w2.Write([]byte("ignored"))
+		select {
+		case s := <-got:
+			So(s, ShouldEqual, "")
+		case <-time.After(time.Millisecond * 100):
+		}
+		_, err = d.WatchFD(r2.Fd(), IOIn, nil)
+		So(err, ShouldNotBeNil)
+	}))
+}
@@ -0,0 +1,70 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris zos
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"errors"
+
+	"golang.org/x/sys/unix"
+)
+
+const fdWatchSupported = true
+
+var ioConditionPollEvents = []struct {
+	condition IOCondition
+	events    int16
+}{
+	{IOIn, unix.POLLIN},
+	{IOOut, unix.POLLOUT},
+	{IOPri, unix.POLLPRI},
+	{IOErr, unix.POLLERR},
+	{IOHup, unix.POLLHUP},
+	{IONval, unix.POLLNVAL},
+}
+
+// pollFDs blocks until the wake file descriptor or any of the others are
+// ready, returning the conditions met by each of the others
+func pollFDs(wake uintptr, fds []uintptr, events []IOCondition) (conditions []IOCondition, woke bool, err error) {
+	pfds := make([]unix.PollFd, len(fds)+1)
+	pfds[0] = unix.PollFd{Fd: int32(wake), Events: unix.POLLIN}
+	for idx, fd := range fds {
+		pfds[idx+1].Fd = int32(fd)
+		for _, pe := range ioConditionPollEvents {
+			if events[idx]&pe.condition != 0 {
+				pfds[idx+1].Events |= pe.events
+			}
+		}
+	}
+	for {
+		if _, err = unix.Poll(pfds, -1); err == nil {
+			break
+		} else if !errors.Is(err, unix.EINTR) {
+			return nil, false, err
+		}
+	}
+	woke = pfds[0].Revents != 0
+	conditions = make([]IOCondition, len(fds))
+	for idx := range fds {
+		for _, pe := range ioConditionPollEvents {
+			if pfds[idx+1].Revents&pe.events != 0 {
+				conditions[idx] |= pe.condition
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/lib/sync"
+	"github.com/go-curses/cdk/memphis"
+)
+
+var (
+	// DefaultWatchdogPeriod is how long the Display may spend processing a
+	// single event or call before it is considered stalled, zero to disable
+	DefaultWatchdogPeriod = time.Second * 5
+	// WatchdogNoticeDuration is how long the "not responding" notice is shown
+	// once a stalled Display recovers, see: SetWatchdog
+	WatchdogNoticeDuration = time.Second * 3
+)
+
+// cDisplayWatchdog tracks the events and calls in progress on the UI thread
+type cDisplayWatchdog struct {
+	period   time.Duration
+	notice   bool
+	next     uint64
+	active   map[uint64]time.Time
+	reported uint64
+	stalled  time.Time
+	showing  time.Duration
+	until    time.Time
+
+	sync.Mutex
+}
+
+func newDisplayWatchdog() (w *cDisplayWatchdog) {
+	return &cDisplayWatchdog{
+		period: DefaultWatchdogPeriod,
+		active: make(map[uint64]time.Time),
+	}
+}
+
+// enter records the start of work on the UI thread, the token returned must
+// be given to leave when the work is done
+func (w *cDisplayWatchdog) enter() (token uint64) {
+	w.Lock()
+	w.next++
+	token = w.next
+	w.active[token] = time.Now()
+	w.Unlock()
+	return
+}
+
+func (w *cDisplayWatchdog) leave(token uint64) {
+	w.Lock()
+	delete(w.active, token)
+	w.Unlock()
+}
+
+// oldest returns the token and start of the longest running work in progress
+func (w *cDisplayWatchdog) oldest() (token uint64, started time.Time) {
+	for t, s := range w.active {
+		if token == 0 || s.Before(started) {
+			token, started = t, s
+		}
+	}
+	return
+}
+
+// check returns the duration of a newly detected stall, and of a stall which
+// has ended (for which the notice is to be shown)
+func (w *cDisplayWatchdog) check(now time.Time) (stall, recovered time.Duration) {
+	w.Lock()
+	defer w.Unlock()
+	token, started := w.oldest()
+	if w.reported != 0 && w.reported != token {
+		if _, ok := w.active[w.reported]; !ok {
+			recovered = now.Sub(w.stalled)
+			w.reported = 0
+			if w.notice {
+				w.showing = recovered
+				w.until = now.Add(WatchdogNoticeDuration)
+			}
+		}
+	}
+	if token != 0 && token != w.reported && w.period > 0 && now.Sub(started) >= w.period {
+		w.reported = token
+		w.stalled = started
+		stall = now.Sub(started)
+	}
+	return
+}
+
+// expire hides the notice once shown for long enough, returning true if the
+// notice was hidden
+func (w *cDisplayWatchdog) expire(now time.Time) (expired bool) {
+	w.Lock()
+	defer w.Unlock()
+	if w.showing > 0 && now.After(w.until) {
+		w.showing = 0
+		return true
+	}
+	return false
+}
+
+// SetWatchdog configures the detection of a stalled UI thread. When processing
+// a single event or call takes longer than the period, the stacks of all
+// goroutines are logged and SignalUIStall is emitted. With notice enabled, a
+// "not responding" message is drawn over the windows for a few moments once the
+// UI thread recovers, telling the user why the application appeared frozen. A
+// period of zero disables the watchdog.
+func (d *CDisplay) SetWatchdog(period time.Duration, notice bool) {
+	d.watchdog.Lock()
+	d.watchdog.period = period
+	d.watchdog.notice = notice
+	d.watchdog.Unlock()
+}
+
+// GetWatchdog returns the period and notice settings of the watchdog
+func (d *CDisplay) GetWatchdog() (period time.Duration, notice bool) {
+	d.watchdog.Lock()
+	defer d.watchdog.Unlock()
+	return d.watchdog.period, d.watchdog.notice
+}
+
+// watchdogWorker checks for stalls until the context is done. SignalUIStall is
+// emitted from this goroutine as the UI thread is, by definition, busy.
+func (d *CDisplay) watchdogWorker(ctx context.Context) {
+	period, _ := d.GetWatchdog()
+	interval := period / 4
+	if interval <= 0 || interval > time.Second {
+		interval = time.Second
+	} else if interval < time.Millisecond*10 {
+		interval = time.Millisecond * 10
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		now := time.Now()
+		stall, recovered := d.watchdog.check(now)
+		if stall > 0 {
+			stacks := allGoroutineStacks()
+			d.LogError("UI thread stalled for %v\n%s", stall, stacks)
+			d.Emit(SignalUIStall, d, stall, stacks)
+		}
+		if recovered > 0 {
+			d.LogWarn("UI thread recovered after %v", recovered)
+			if _, notice := d.GetWatchdog(); notice {
+				d.RequestDraw()
+				d.RequestShow()
+			}
+		}
+		if d.watchdog.expire(now) {
+			d.RequestDraw()
+			d.RequestShow()
+		}
+	}
+}
+
+// drawWatchdogNotice draws the "not responding" notice, if showing
+func (d *CDisplay) drawWatchdogNotice(surface memphis.Surface, theme paint.Theme) {
+	d.watchdog.Lock()
+	showing := d.watchdog.showing
+	d.watchdog.Unlock()
+	if showing <= 0 {
+		return
+	}
+	text := fmt.Sprintf(" %s ", Tr("not responding for %v", showing.Round(time.Millisecond*100)))
+	size := surface.GetSize()
+	w, h := len(text)+2, 3
+	if w > size.W {
+		w = size.W
+	}
+	if size.W <= 2 || size.H < h {
+		return
+	}
+	pos := ptypes.MakePoint2I((size.W-w)/2, (size.H-h)/2)
+	surface.BoxWithTheme(pos, ptypes.MakeRectangle(w, h), true, true, theme)
+	surface.DrawSingleLineText(ptypes.MakePoint2I(pos.X+1, pos.Y+1), w-2, true, enums.JUSTIFY_CENTER, theme.Content.Normal, false, false, text)
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDisplayWatchdog(t *testing.T) {
+	Convey("Stalled UI thread detection", t, func() {
+		w := newDisplayWatchdog()
+		w.period = time.Millisecond * 50
+		w.notice = true
+		start := time.Now()
+		outer := w.enter()
+		stall, recovered := w.check(start)
+		So(stall, ShouldEqual, 0)
+		So(recovered, ShouldEqual, 0)
+		// nested work does not reset the stall
+		w.leave(w.enter())
+		stall, _ = w.check(start.Add(time.Millisecond * 60))
+		So(stall, ShouldBeGreaterThanOrEqualTo, time.Millisecond*50)
+		// a stall is only reported once
+		stall, _ = w.check(start.Add(time.Millisecond * 120))
+		So(stall, ShouldEqual, 0)
+		w.leave(outer)
+		now := time.Now()
+		stall, recovered = w.check(now)
+		So(stall, ShouldEqual, 0)
+		So(recovered, ShouldBeGreaterThan, 0)
+		So(w.showing, ShouldEqual, recovered)
+		So(w.expire(now), ShouldBeFalse)
+		So(w.expire(now.Add(WatchdogNoticeDuration+time.Millisecond)), ShouldBeTrue)
+		So(w.expire(now.Add(WatchdogNoticeDuration*2)), ShouldBeFalse)
+	})
+	Convey("Disabled watchdog", t, func() {
+		w := newDisplayWatchdog()
+		w.period = 0
+		w.enter()
+		stall, _ := w.check(time.Now().Add(time.Hour))
+		So(stall, ShouldEqual, 0)
+	})
+	Convey("Display watchdog settings", t, WithDisplayManager(func(d Display) {
+		period, notice := d.GetWatchdog()
+		So(period, ShouldEqual, DefaultWatchdogPeriod)
+		So(notice, ShouldBeFalse)
+		d.SetWatchdog(time.Second, true)
+		period, notice = d.GetWatchdog()
+		So(period, ShouldEqual, time.Second)
+		So(notice, ShouldBeTrue)
+		stall, stacks, ok := DisplaySignalUIStallArgv(d, time.Second, []byte("stacks"))
+		So(ok, ShouldBeTrue)
+		So(stall, ShouldEqual, time.Second)
+		So(string(stacks), ShouldEqual, "stacks")
+	}))
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// WindowEdge identifies the edges of a Window moved by BeginResizeWindow,
+// corners are the combination of two edges
+type WindowEdge uint8
+
+const (
+	WindowEdgeNone WindowEdge = 0
+	WindowEdgeTop  WindowEdge = 1 << iota
+	WindowEdgeBottom
+	WindowEdgeLeft
+	WindowEdgeRight
+	WindowEdgeTopLeft     = WindowEdgeTop | WindowEdgeLeft
+	WindowEdgeTopRight    = WindowEdgeTop | WindowEdgeRight
+	WindowEdgeBottomLeft  = WindowEdgeBottom | WindowEdgeLeft
+	WindowEdgeBottomRight = WindowEdgeBottom | WindowEdgeRight
+)
+
+// Has returns true if the edge includes the given edge
+func (e WindowEdge) Has(edge WindowEdge) bool {
+	return e&edge != 0
+}
+
+func (e WindowEdge) String() string {
+	var names []string
+	for _, edge := range []struct {
+		edge WindowEdge
+		name string
+	}{
+		{WindowEdgeTop, "top"},
+		{WindowEdgeBottom, "bottom"},
+		{WindowEdgeLeft, "left"},
+		{WindowEdgeRight, "right"},
+	} {
+		if e.Has(edge.edge) {
+			names = append(names, edge.name)
+		}
+	}
+	if len(names) == 0 {
+		return "none"
+	}
+	return strings.Join(names, "-")
+}
+
+// tracks a Window being moved or resized with the mouse
+type cWindowDrag struct {
+	window Window
+	edge   WindowEdge
+	resize bool
+	start  ptypes.Point2I
+	region ptypes.Region
+}
+
+// BeginMoveWindow starts moving the window with the mouse. The pointer is
+// grabbed and each mouse event moves the window by the distance the pointer
+// has travelled since the start event, keeping the window within the
+// Display, until all mouse buttons are released. SignalWindowMoved is emitted
+// with each new region. If the start event is nil, the current cursor
+// position is used.
+func (d *CDisplay) BeginMoveWindow(w Window, start *EventMouse) (err error) {
+	return d.beginWindowDrag(w, WindowEdgeNone, false, start)
+}
+
+// BeginResizeWindow starts resizing the window with the mouse, moving the
+// given edges by the distance the pointer has travelled since the start event
+// while respecting the window's geometry hints, until all mouse buttons are
+// released. The pointer is grabbed for the duration and SignalWindowResized is
+// emitted with each new region. If the start event is nil, the current cursor
+// position is used.
+func (d *CDisplay) BeginResizeWindow(w Window, edge WindowEdge, start *EventMouse) (err error) {
+	if edge == WindowEdgeNone {
+		return fmt.Errorf("no window edge to resize")
+	}
+	return d.beginWindowDrag(w, edge, true, start)
+}
+
+// EndWindowDrag stops moving or resizing a window, leaving it where it is
+func (d *CDisplay) EndWindowDrag() {
+	d.Lock()
+	drag := d.windowDrag
+	d.windowDrag = nil
+	var released Object
+	if drag != nil && !d.grabImplicit && d.grabTarget != nil && d.grabTarget.ObjectID() == drag.window.ObjectID() {
+		released = d.grabTarget
+		d.grabTarget, d.paneGrab = nil, nil
+	}
+	d.Unlock()
+	if released != nil {
+		d.Emit(SignalUngrabPointer, d, released)
+	}
+}
+
+// GetWindowDrag returns the window being moved or resized, if any, and the
+// edges being resized
+func (d *CDisplay) GetWindowDrag() (w Window, resizing bool, edge WindowEdge) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.windowDrag != nil {
+		w, resizing, edge = d.windowDrag.window, d.windowDrag.resize, d.windowDrag.edge
+	}
+	return
+}
+
+func (d *CDisplay) beginWindowDrag(w Window, edge WindowEdge, resize bool, start *EventMouse) (err error) {
+	if w == nil {
+		return fmt.Errorf("cannot drag nil window")
+	}
+	if !d.IsMappedWindow(w) {
+		return fmt.Errorf("window is not mapped: %v", w.ObjectName())
+	}
+	region := d.mappedWindowRegion(w, d.availableSize())
+	d.RLock()
+	position := d.cursor.Clone()
+	d.RUnlock()
+	if start != nil {
+		position = start.Point2I()
+	}
+	if err = d.GrabPointer(w); err != nil {
+		return
+	}
+	d.Lock()
+	d.windowDrag = &cWindowDrag{
+		window: w,
+		edge:   edge,
+		resize: resize,
+		start:  position,
+		region: region,
+	}
+	d.Unlock()
+	return
+}
+
+// processWindowDrag moves or resizes the window being dragged, returning false
+// if no window is being dragged
+func (d *CDisplay) processWindowDrag(e *EventMouse) (f enums.EventFlag, dragging bool) {
+	d.RLock()
+	drag := d.windowDrag
+	d.RUnlock()
+	if drag == nil {
+		return enums.EVENT_PASS, false
+	}
+	if e.ButtonPressed() == ButtonNone {
+		defer d.EndWindowDrag()
+	}
+	x, y := e.Position()
+	dx, dy := x-drag.start.X, y-drag.start.Y
+	available := d.availableSize()
+	hints := drag.window.GetGeometryHints()
+	var region ptypes.Region
+	if drag.resize {
+		region = resizeWindowRegion(drag.region, drag.edge, dx, dy, hints)
+	} else {
+		region = drag.region
+		region.X, region.Y = region.X+dx, region.Y+dy
+	}
+	region, _ = hints.Constrain(region, available)
+	if current := d.mappedWindowRegion(drag.window, available); current != region {
+		d.setWindowRegion(drag.window, region)
+		if drag.resize {
+			d.Emit(SignalWindowResized, d, drag.window, region)
+		} else {
+			d.Emit(SignalWindowMoved, d, drag.window, region)
+		}
+	}
+	return enums.EVENT_STOP, true
+}
+
+// resizeWindowRegion returns the region with the given edges moved, keeping
+// the opposite edges in place when the minimum size is reached
+func resizeWindowRegion(region ptypes.Region, edge WindowEdge, dx, dy int, hints WindowGeometryHints) ptypes.Region {
+	minW, minH := 1, 1
+	if hints.MinSize.W > minW {
+		minW = hints.MinSize.W
+	}
+	if hints.MinSize.H > minH {
+		minH = hints.MinSize.H
+	}
+	right, bottom := region.X+region.W, region.Y+region.H
+	if edge.Has(WindowEdgeLeft) {
+		if region.X = region.X + dx; right-region.X < minW {
+			region.X = right - minW
+		}
+		region.W = right - region.X
+	} else if edge.Has(WindowEdgeRight) {
+		if region.W = region.W + dx; region.W < minW {
+			region.W = minW
+		}
+	}
+	if edge.Has(WindowEdgeTop) {
+		if region.Y = region.Y + dy; bottom-region.Y < minH {
+			region.Y = bottom - minH
+		}
+		region.H = bottom - region.Y
+	} else if edge.Has(WindowEdgeBottom) {
+		if region.H = region.H + dy; region.H < minH {
+			region.H = minH
+		}
+	}
+	return region
+}
+
+// setWindowRegion maps the window to the region it was dragged to
+func (d *CDisplay) setWindowRegion(w Window, region ptypes.Region) {
+	d.Lock()
+	geometry, ok := d.geometry[w.ObjectID()]
+	if !ok {
+		geometry = &cWindowGeometry{}
+		d.geometry[w.ObjectID()] = geometry
+	}
+	geometry.region, geometry.fill, geometry.violated = region, false, false
+	state := geometry.state
+	d.Unlock()
+	region = state.apply(region, d.availableSize())
+	style := w.GetTheme().Content.Normal
+	if err := MakeObjectSurface(w, region.Origin(), region.Size(), style); err != nil {
+		d.LogErr(err)
+	}
+	d.allocateWindow(NewEventAllocate(w, region))
+	d.RequestDraw()
+	d.RequestShow()
+}
+
+// DisplaySignalWindowDragArgv returns the window and its new region from the
+// arguments of SignalWindowMoved and SignalWindowResized
+func DisplaySignalWindowDragArgv(argv ...interface{}) (w Window, region ptypes.Region, ok bool) {
+	if len(argv) == 3 {
+		if w, ok = argv[1].(Window); ok {
+			region, ok = argv[2].(ptypes.Region)
+		}
+	}
+	return
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+func TestDisplayWindowDrag(t *testing.T) {
+	Convey("Window edges", t, func() {
+		So(WindowEdgeBottomRight.String(), ShouldEqual, "bottom-right")
+		So(WindowEdgeNone.String(), ShouldEqual, "none")
+		So(WindowEdgeTopLeft.Has(WindowEdgeLeft), ShouldBeTrue)
+		hints := WindowGeometryHints{MinSize: ptypes.MakeRectangle(4, 3)}
+		So(resizeWindowRegion(ptypes.MakeRegion(2, 2, 10, 5), WindowEdgeTopLeft, 20, 20, hints), ShouldResemble, ptypes.MakeRegion(8, 4, 4, 3))
+		So(resizeWindowRegion(ptypes.MakeRegion(2, 2, 10, 5), WindowEdgeRight, -2, 5, hints), ShouldResemble, ptypes.MakeRegion(2, 2, 8, 5))
+	})
+	Convey("Dragging windows", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.Unlock()
+		w := NewOffscreenWindow("dragged")
+		d.MapWindowWithRegion(w, ptypes.MakeRegion(2, 2, 10, 5))
+		var moved, resized []ptypes.Region
+		d.Connect(SignalWindowMoved, "drag-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			_, region, ok := DisplaySignalWindowDragArgv(argv...)
+			So(ok, ShouldBeTrue)
+			moved = append(moved, region)
+			return enums.EVENT_PASS
+		})
+		d.Connect(SignalWindowResized, "drag-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			_, region, _ := DisplaySignalWindowDragArgv(argv...)
+			resized = append(resized, region)
+			return enums.EVENT_PASS
+		})
+		var ungrabbed []interface{}
+		d.Connect(SignalUngrabPointer, "drag-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			ungrabbed = append(ungrabbed, argv[1])
+			return enums.EVENT_PASS
+		})
+		regionOf := func() ptypes.Region {
+			surface, err := memphis.GetSurface(w.ObjectID())
+			So(err, ShouldBeNil)
+			return surface.GetRegion()
+		}
+
+		So(d.BeginMoveWindow(NewOffscreenWindow("unmapped"), nil), ShouldNotBeNil)
+		So(d.BeginResizeWindow(w, WindowEdgeNone, nil), ShouldNotBeNil)
+
+		press := NewEventMouse(3, 3, Button1, ModNone)
+		So(d.BeginMoveWindow(w, press), ShouldBeNil)
+		dragged, resizing, _ := d.GetWindowDrag()
+		So(dragged, ShouldEqual, w)
+		So(resizing, ShouldBeFalse)
+		grab, _ := d.GetPointerGrab()
+		So(grab, ShouldEqual, w)
+		So(d.ProcessEvent(NewEventMouse(6, 4, Button1, ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(regionOf(), ShouldResemble, ptypes.MakeRegion(5, 3, 10, 5))
+		// windows are kept within the display
+		d.ProcessEvent(NewEventMouse(-20, -20, Button1, ModNone))
+		So(regionOf(), ShouldResemble, ptypes.MakeRegion(0, 0, 10, 5))
+		d.ProcessEvent(NewEventMouse(4, 4, ButtonNone, ModNone))
+		So(moved, ShouldResemble, []ptypes.Region{
+			ptypes.MakeRegion(5, 3, 10, 5),
+			ptypes.MakeRegion(0, 0, 10, 5),
+			ptypes.MakeRegion(3, 3, 10, 5),
+		})
+		dragged, _, _ = d.GetWindowDrag()
+		So(dragged, ShouldBeNil)
+		grab, _ = d.GetPointerGrab()
+		So(grab, ShouldBeNil)
+
+		So(d.BeginResizeWindow(w, WindowEdgeBottomRight, NewEventMouse(12, 7, Button1, ModNone)), ShouldBeNil)
+		d.ProcessEvent(NewEventMouse(15, 9, Button1, ModNone))
+		So(regionOf(), ShouldResemble, ptypes.MakeRegion(3, 3, 13, 7))
+		d.EndWindowDrag()
+		So(resized, ShouldResemble, []ptypes.Region{ptypes.MakeRegion(3, 3, 13, 7)})
+		grab, _ = d.GetPointerGrab()
+		So(grab, ShouldBeNil)
+		So(ungrabbed, ShouldHaveLength, 2)
+		So(ungrabbed[1], ShouldPointTo, w)
+	}))
+}
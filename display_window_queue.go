@@ -0,0 +1,244 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"github.com/gofrs/uuid"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// DefaultWindowQueueCapacity is the number of events held by the private event
+// queue of each window, see: Display.SetWindowQueues
+var DefaultWindowQueueCapacity = 64
+
+// cWindowQueue is the private event queue of a mapped window, processed by a
+// goroutine of its own
+type cWindowQueue struct {
+	window Window
+	events chan Event
+	stop   chan struct{}
+	// backlog holds the input which did not fit in the queue, and all events
+	// after it, so that the input of a slow window is never lost
+	backlog []Event
+	more    chan struct{}
+	lock    sync.Mutex
+	// busy is held while the window processes an event, the window is not
+	// drawn while busy, see: CDisplay.renderScreen
+	busy sync.Mutex
+}
+
+// isWindowInput returns true for the events which are never discarded by a
+// window queue
+func isWindowInput(evt Event) bool {
+	switch evt.(type) {
+	case *EventKey, *EventPaste, *EventRaw:
+		return true
+	}
+	return false
+}
+
+// queueBacklog queues the event unless it is to be posted as usual, returning
+// false for events other than input while there is no backlog. Input which
+// does not fit in the queue starts the backlog, which grows as needed.
+func (q *cWindowQueue) queueBacklog(evt Event) (queued bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.backlog) == 0 {
+		if !isWindowInput(evt) {
+			return false
+		}
+		select {
+		case q.events <- evt:
+			return true
+		default:
+		}
+	}
+	q.backlog = append(q.backlog, evt)
+	select {
+	case q.more <- struct{}{}:
+	default:
+	}
+	return true
+}
+
+// nextBacklog removes and returns the oldest event of the backlog
+func (q *cWindowQueue) nextBacklog() (evt Event, ok bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if len(q.backlog) == 0 {
+		return nil, false
+	}
+	evt = q.backlog[0]
+	q.backlog[0] = nil
+	q.backlog = q.backlog[1:]
+	return evt, true
+}
+
+// SetWindowQueues changes whether each mapped window processes its events on
+// a private event queue, with a goroutine of its own, instead of on the main
+// thread of the Display. This way, a window which is slow to handle an event
+// does not hold up the input of the other windows. Rendering remains on the
+// main thread and a window is not drawn while busy processing an event, the
+// previous content of the window is shown instead. Events queued for a window
+// are consumed by the window, the Display signals for those events are not
+// emitted when the window does not handle them. Events given to the event
+// focus or a pointer grab are always processed on the main thread. When the
+// queue of a window is full, the EventQueueWindow overflow policy applies to
+// all but input events, which are kept until the window processes them.
+func (d *CDisplay) SetWindowQueues(enabled bool) {
+	d.Lock()
+	d.queueWindows = enabled
+	d.Unlock()
+	if !enabled {
+		d.stopWindowQueues()
+	}
+}
+
+// GetWindowQueues returns true if each mapped window processes its events on a
+// private event queue, see: SetWindowQueues
+func (d *CDisplay) GetWindowQueues() (enabled bool) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.queueWindows
+}
+
+// windowQueue returns the event queue of the mapped window, started as needed,
+// or nil when window queues are not enabled
+func (d *CDisplay) windowQueue(w Window) (q *cWindowQueue) {
+	if !d.GetWindowQueues() || d.findMappedWindowIndex(w) < 0 {
+		return nil
+	}
+	d.Lock()
+	defer d.Unlock()
+	if q = d.windowQueues[w.ObjectID()]; q == nil {
+		q = &cWindowQueue{
+			window: w,
+			events: make(chan Event, DefaultWindowQueueCapacity),
+			stop:   make(chan struct{}),
+			more:   make(chan struct{}, 1),
+		}
+		d.windowQueues[w.ObjectID()] = q
+		Go(func() { d.runWindowQueue(q) })
+	}
+	return
+}
+
+// lookupWindowQueue returns the event queue of the window, if started
+func (d *CDisplay) lookupWindowQueue(id uuid.UUID) (q *cWindowQueue) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.windowQueues[id]
+}
+
+// processWindowEvent gives the event to the window, directly or by way of the
+// private event queue of the window. Queued events return EVENT_STOP, unless
+// discarded by the overflow policy. Input is never discarded, the queue grows
+// as needed instead.
+func (d *CDisplay) processWindowEvent(w Window, evt Event) enums.EventFlag {
+	q := d.windowQueue(w)
+	if q == nil {
+		return w.ProcessEvent(evt)
+	}
+	if q.queueBacklog(evt) {
+		return enums.EVENT_STOP
+	}
+	if err := d.overflows[EventQueueWindow].post(q.events, evt, q.stop); err != nil {
+		return enums.EVENT_PASS
+	}
+	return enums.EVENT_STOP
+}
+
+// runWindowQueue processes the events of the window queue until stopped, the
+// backlog is processed once the events queued before it are
+func (d *CDisplay) runWindowQueue(q *cWindowQueue) {
+	process := func(evt Event) {
+		if f := d.processQueuedEvent(q, evt); f == enums.EVENT_STOP {
+			d.RequestDraw()
+			d.RequestShow()
+		}
+	}
+	for {
+		select {
+		case <-q.stop:
+			return
+		case evt := <-q.events:
+			process(evt)
+		case <-q.more:
+			for {
+				select {
+				case <-q.stop:
+					return
+				case evt := <-q.events:
+					process(evt)
+					continue
+				default:
+				}
+				evt, ok := q.nextBacklog()
+				if !ok {
+					break
+				}
+				process(evt)
+			}
+		}
+	}
+}
+
+// processQueuedEvent gives the event to the window of the queue, recovering
+// from any panic
+func (d *CDisplay) processQueuedEvent(q *cWindowQueue, evt Event) (flag enums.EventFlag) {
+	q.busy.Lock()
+	defer q.busy.Unlock()
+	defer d.recoverPanic()
+	flag = q.window.ProcessEvent(evt)
+	return
+}
+
+// stopWindowQueue stops the event queue of the window, any events still queued
+// are discarded
+func (d *CDisplay) stopWindowQueue(w Window) {
+	d.Lock()
+	q, ok := d.windowQueues[w.ObjectID()]
+	delete(d.windowQueues, w.ObjectID())
+	d.Unlock()
+	if ok {
+		close(q.stop)
+	}
+}
+
+// stopWindowQueues stops the event queues of all windows
+func (d *CDisplay) stopWindowQueues() {
+	d.Lock()
+	queues := d.windowQueues
+	d.windowQueues = make(map[uuid.UUID]*cWindowQueue)
+	d.Unlock()
+	for _, q := range queues {
+		close(q.stop)
+	}
+}
+
+// drawWindow draws the window, unless it is busy processing an event on its
+// private event queue, returning false if the window was not drawn
+func (d *CDisplay) drawWindow(w Window) (drawn bool) {
+	if q := d.lookupWindowQueue(w.ObjectID()); q != nil {
+		if !q.busy.TryLock() {
+			return false
+		}
+		defer q.busy.Unlock()
+	}
+	w.Draw()
+	return true
+}
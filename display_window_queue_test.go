@@ -0,0 +1,116 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestDisplayWindowQueues(t *testing.T) {
+	Convey("Per-window event queues", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.Unlock()
+		slow, fast := NewOffscreenWindow("slow"), NewOffscreenWindow("fast")
+		d.MapWindowWithRegion(slow, ptypes.MakeRegion(0, 0, 10, 5))
+		d.MapWindowWithRegion(fast, ptypes.MakeRegion(10, 0, 10, 5))
+		release := make(chan struct{})
+		started := make(chan struct{}, 1)
+		slow.Connect(SignalEvent, "queue-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			started <- struct{}{}
+			<-release
+			return enums.EVENT_STOP
+		})
+		processed := make(chan struct{}, 1)
+		fast.Connect(SignalEvent, "queue-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			processed <- struct{}{}
+			return enums.EVENT_STOP
+		})
+		waitFor := func(ch chan struct{}) bool {
+			select {
+			case <-ch:
+				return true
+			case <-time.After(time.Second):
+				return false
+			}
+		}
+
+		So(d.GetWindowQueues(), ShouldBeFalse)
+		So(cd.processWindowEvent(fast, NewEventKey(KeyRune, 'a', ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(waitFor(processed), ShouldBeTrue)
+		So(cd.lookupWindowQueue(fast.ObjectID()), ShouldBeNil)
+
+		d.SetWindowQueues(true)
+		So(d.GetWindowQueues(), ShouldBeTrue)
+		So(cd.processWindowEvent(slow, NewEventKey(KeyRune, 'b', ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(waitFor(started), ShouldBeTrue)
+		// the slow window does not hold up the others
+		So(cd.processWindowEvent(fast, NewEventKey(KeyRune, 'c', ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(waitFor(processed), ShouldBeTrue)
+		// nor is the slow window drawn while busy
+		So(cd.drawWindow(slow), ShouldBeFalse)
+		So(cd.drawWindow(fast), ShouldBeTrue)
+		close(release)
+		So(cd.lookupWindowQueue(slow.ObjectID()), ShouldNotBeNil)
+		d.UnmapWindow(slow)
+		So(cd.lookupWindowQueue(slow.ObjectID()), ShouldBeNil)
+
+		Convey("never losing the input of a stalled window", func() {
+			stalled := NewOffscreenWindow("stalled")
+			d.MapWindowWithRegion(stalled, ptypes.MakeRegion(0, 5, 10, 5))
+			resume := make(chan struct{})
+			received := make(chan rune, DefaultWindowQueueCapacity*4)
+			stalled.Connect(SignalEvent, "queue-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				if evt, ok := argv[1].(*EventKey); ok {
+					<-resume
+					received <- evt.Rune()
+				}
+				return enums.EVENT_STOP
+			})
+			count := DefaultWindowQueueCapacity * 3
+			for i := 0; i < count; i++ {
+				So(cd.processWindowEvent(stalled, NewEventKey(KeyRune, rune('0'+i), ModNone)), ShouldEqual, enums.EVENT_STOP)
+				if i == DefaultWindowQueueCapacity*2 {
+					// other events are queued after the input
+					cd.processWindowEvent(stalled, NewEventResize(1, 1))
+				}
+			}
+			close(resume)
+			for i := 0; i < count; i++ {
+				select {
+				case r := <-received:
+					So(r, ShouldEqual, rune('0'+i))
+				case <-time.After(time.Second):
+					So(i, ShouldEqual, count)
+					return
+				}
+			}
+			d.UnmapWindow(stalled)
+		})
+
+		d.SetWindowQueues(false)
+		So(cd.lookupWindowQueue(fast.ObjectID()), ShouldBeNil)
+		So(EventQueueWindow.String(), ShouldEqual, "window")
+		So(d.GetEventOverflowPolicy(EventQueueWindow).Mode, ShouldEqual, OverflowCoalesce)
+	}))
+}
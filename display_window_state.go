@@ -0,0 +1,207 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strings"
+
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// WindowState flags describe how a mapped Window is presented by the Display
+type WindowState uint8
+
+const (
+	// WindowStateNormal windows are shown with the region they were mapped to
+	WindowStateNormal WindowState = 0
+	// WindowStateMinimized windows are not drawn, do not receive events and
+	// are at the bottom of the window stack
+	WindowStateMinimized WindowState = 1 << iota
+	// WindowStateShaded windows are reduced to their first line
+	WindowStateShaded
+	// WindowStateFullscreen windows cover the whole Display
+	WindowStateFullscreen
+)
+
+// Has returns true if all the given flags are set
+func (s WindowState) Has(state WindowState) bool {
+	return s&state == state && state != WindowStateNormal
+}
+
+func (s WindowState) String() string {
+	var names []string
+	if s.Has(WindowStateMinimized) {
+		names = append(names, "minimized")
+	}
+	if s.Has(WindowStateShaded) {
+		names = append(names, "shaded")
+	}
+	if s.Has(WindowStateFullscreen) {
+		names = append(names, "fullscreen")
+	}
+	if len(names) == 0 {
+		return "normal"
+	}
+	return strings.Join(names, "|")
+}
+
+// apply returns the region presented for a window mapped to the given region
+func (s WindowState) apply(region ptypes.Region, available ptypes.Rectangle) ptypes.Region {
+	if s.Has(WindowStateFullscreen) && available.W > 0 && available.H > 0 {
+		region = ptypes.MakeRegion(0, 0, available.W, available.H)
+	}
+	if s.Has(WindowStateShaded) && region.H > 1 {
+		region.H = 1
+	}
+	return region
+}
+
+// WindowListEntry describes a mapped Window for building window switchers
+// and task bars, see: Display.GetWindowList
+type WindowListEntry struct {
+	Window  Window
+	Title   string
+	State   WindowState
+	Focused bool
+	Urgent  bool
+}
+
+// MapWindowWithState maps the window to the region, like MapWindowWithRegion,
+// presenting it with the given state
+func (d *CDisplay) MapWindowWithState(w Window, region ptypes.Region, state WindowState) {
+	d.MapWindowWithRegion(w, region)
+	if state != WindowStateNormal {
+		d.SetWindowState(w, state)
+	}
+}
+
+// GetWindowState returns the state of the mapped window, WindowStateNormal for
+// windows which are not mapped
+func (d *CDisplay) GetWindowState(w Window) (state WindowState) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.windowStateLocked(w)
+}
+
+func (d *CDisplay) windowStateLocked(w Window) (state WindowState) {
+	if geometry, ok := d.geometry[w.ObjectID()]; ok {
+		state = geometry.state
+	}
+	return
+}
+
+// SetWindowState changes how the mapped window is presented, emitting
+// SignalWindowStateChanged with the previous and new states. Minimizing the
+// focused window focuses the next window which is not minimized while
+// restoring a minimized window focuses it.
+func (d *CDisplay) SetWindowState(w Window, state WindowState) {
+	if !d.IsMappedWindow(w) {
+		d.LogError("cannot change state of unmapped window: %v", w.ObjectName())
+		return
+	}
+	d.Lock()
+	geometry, ok := d.geometry[w.ObjectID()]
+	if !ok {
+		geometry = &cWindowGeometry{fill: true}
+		d.geometry[w.ObjectID()] = geometry
+	}
+	previous := geometry.state
+	geometry.state = state
+	d.Unlock()
+	if previous == state {
+		return
+	}
+	wasFocused := d.FocusedWindow()
+	if state.Has(WindowStateMinimized) && !previous.Has(WindowStateMinimized) {
+		d.lowerWindow(w)
+		if wasFocused != nil && wasFocused.ObjectID() == w.ObjectID() {
+			if next := d.FocusedWindow(); next != nil {
+				d.FocusWindow(next)
+			}
+		}
+	}
+	available := d.availableSize()
+	region := d.windowAllocation(w, available)
+	style := w.GetTheme().Content.Normal
+	if err := MakeObjectSurface(w, region.Origin(), region.Size(), style); err != nil {
+		d.LogErr(err)
+	}
+	if !state.Has(WindowStateMinimized) {
+		d.allocateWindow(NewEventAllocate(w, region))
+		if previous.Has(WindowStateMinimized) {
+			d.FocusWindow(w)
+		}
+	}
+	d.RequestDraw()
+	d.RequestShow()
+	d.Emit(SignalWindowStateChanged, d, w, previous, state)
+}
+
+// IconifyWindow minimizes the mapped window
+func (d *CDisplay) IconifyWindow(w Window) {
+	d.SetWindowState(w, d.GetWindowState(w)|WindowStateMinimized)
+}
+
+// DeiconifyWindow restores the minimized window and focuses it
+func (d *CDisplay) DeiconifyWindow(w Window) {
+	d.SetWindowState(w, d.GetWindowState(w)&^WindowStateMinimized)
+}
+
+// GetWindowList returns an entry for each mapped window, in stacking order
+// with the focused window first, suitable for building window switchers
+func (d *CDisplay) GetWindowList() (list []WindowListEntry) {
+	focused := d.FocusedWindow()
+	for _, w := range d.GetWindows() {
+		list = append(list, WindowListEntry{
+			Window:  w,
+			Title:   w.GetTitle(),
+			State:   d.GetWindowState(w),
+			Focused: focused != nil && focused.ObjectID() == w.ObjectID(),
+			Urgent:  d.IsUrgent(w),
+		})
+	}
+	return
+}
+
+// lowerWindow moves the window to the bottom of the window stack
+func (d *CDisplay) lowerWindow(w Window) {
+	d.Lock()
+	defer d.Unlock()
+	for idx, window := range d.windows {
+		if window.ObjectID() == w.ObjectID() {
+			d.windows = append(append(d.windows[:idx:idx], d.windows[idx+1:]...), window)
+			return
+		}
+	}
+}
+
+// isWindowMinimized returns true if the window is minimized, the Display must
+// be locked by the caller
+func (d *CDisplay) isWindowMinimized(w Window) bool {
+	return d.windowStateLocked(w).Has(WindowStateMinimized)
+}
+
+// DisplaySignalWindowStateChangedArgv returns the window and its previous and
+// new states from the arguments of SignalWindowStateChanged
+func DisplaySignalWindowStateChangedArgv(argv ...interface{}) (w Window, previous, state WindowState, ok bool) {
+	if len(argv) == 4 {
+		if w, ok = argv[1].(Window); ok {
+			if previous, ok = argv[2].(WindowState); ok {
+				state, ok = argv[3].(WindowState)
+			}
+		}
+	}
+	return
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+func TestDisplayWindowState(t *testing.T) {
+	Convey("Window state flags", t, func() {
+		state := WindowStateShaded | WindowStateFullscreen
+		So(state.String(), ShouldEqual, "shaded|fullscreen")
+		So(WindowStateNormal.String(), ShouldEqual, "normal")
+		So(state.Has(WindowStateShaded), ShouldBeTrue)
+		So(state.Has(WindowStateMinimized), ShouldBeFalse)
+		So(state.Has(WindowStateNormal), ShouldBeFalse)
+		So(state.apply(ptypes.MakeRegion(2, 2, 10, 5), ptypes.MakeRectangle(80, 24)), ShouldResemble, ptypes.MakeRegion(0, 0, 80, 1))
+	})
+	Convey("Managing window states", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.Unlock()
+		width, height := d.Screen().Size()
+		var changes []WindowState
+		d.Connect(SignalWindowStateChanged, "state-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			_, _, state, ok := DisplaySignalWindowStateChangedArgv(argv...)
+			So(ok, ShouldBeTrue)
+			changes = append(changes, state)
+			return enums.EVENT_PASS
+		})
+		regionOf := func(w Window) ptypes.Region {
+			surface, err := memphis.GetSurface(w.ObjectID())
+			So(err, ShouldBeNil)
+			return surface.GetRegion()
+		}
+		a := NewOffscreenWindow("a")
+		b := NewOffscreenWindow("b")
+		a.SetTitle("Alpha")
+		b.SetTitle("Beta")
+		d.MapWindowWithRegion(a, ptypes.MakeRegion(1, 1, 10, 5))
+		d.MapWindowWithState(b, ptypes.MakeRegion(2, 2, 10, 5), WindowStateShaded)
+		So(d.GetWindowState(b), ShouldEqual, WindowStateShaded)
+		So(regionOf(b), ShouldResemble, ptypes.MakeRegion(2, 2, 10, 1))
+		So(d.FocusedWindow(), ShouldEqual, b)
+
+		d.IconifyWindow(b)
+		So(d.GetWindowState(b), ShouldEqual, WindowStateShaded|WindowStateMinimized)
+		So(d.FocusedWindow(), ShouldEqual, a)
+		So(d.GetWindowAtPoint(ptypes.MakePoint2I(2, 2)), ShouldEqual, a)
+		So(d.GetWindowList(), ShouldResemble, []WindowListEntry{
+			{Window: a, Title: "Alpha", State: WindowStateNormal, Focused: true},
+			{Window: b, Title: "Beta", State: WindowStateShaded | WindowStateMinimized},
+		})
+
+		d.DeiconifyWindow(b)
+		So(d.FocusedWindow(), ShouldEqual, b)
+		d.SetWindowState(b, WindowStateFullscreen)
+		So(regionOf(b), ShouldResemble, ptypes.MakeRegion(0, 0, width, height))
+		d.SetWindowState(b, WindowStateNormal)
+		So(regionOf(b), ShouldResemble, ptypes.MakeRegion(2, 2, 10, 5))
+		So(changes, ShouldResemble, []WindowState{
+			WindowStateShaded,
+			WindowStateShaded | WindowStateMinimized,
+			WindowStateShaded,
+			WindowStateFullscreen,
+			WindowStateNormal,
+		})
+	}))
+}
@@ -24,11 +24,16 @@ import (
 type Event interface {
 	// When reports the time when the event was generated.
 	When() time.Time
+	// Stamp reports the order and time of the event's arrival at the Display,
+	// see EventStamp.
+	Stamp() EventStamp
 }
 
 // EventTime is a simple base event class, suitable for easy reuse.
 // It can be used to deliver actual timer events as well.
 type EventTime struct {
+	EventStamper
+
 	when time.Time
 }
 
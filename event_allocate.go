@@ -0,0 +1,54 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// EventAllocate is sent to a Window with the region of the display allocated
+// to it. Each Window receives one immediately after every EventResize, the
+// initial one included, and one whenever it is mapped while the Display is
+// running, so the region never needs to be derived from the screen size.
+type EventAllocate struct {
+	EventStamper
+
+	t      time.Time
+	window Window
+	region ptypes.Region
+}
+
+// NewEventAllocate returns a new EventAllocate for the Window and region.
+func NewEventAllocate(window Window, region ptypes.Region) *EventAllocate {
+	return &EventAllocate{t: time.Now(), window: window, region: region}
+}
+
+// When returns the time when this EventAllocate was created.
+func (ev *EventAllocate) When() time.Time {
+	return ev.t
+}
+
+// Window returns the Window the region is allocated to.
+func (ev *EventAllocate) Window() Window {
+	return ev.window
+}
+
+// Region returns the region of the display allocated to the Window, after
+// applying the Window's geometry hints.
+func (ev *EventAllocate) Region() ptypes.Region {
+	return ev.region
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestAllocateEvent(t *testing.T) {
+	Convey("Allocate events", t, func() {
+		w := NewOffscreenWindow("allocate")
+		region := ptypes.MakeRegion(1, 2, 3, 4)
+		ea := NewEventAllocate(w, region)
+		So(ea, ShouldHaveSameTypeAs, &EventAllocate{})
+		So(ea.Window(), ShouldEqual, w)
+		So(ea.Region(), ShouldResemble, region)
+		So(ea.When().IsZero(), ShouldBeFalse)
+	})
+	Convey("Initial resize ordering", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		var seen []string
+		d.Connect(SignalStartupComplete, "test-startup", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			cd.Lock()
+			cd.started = true
+			cd.Unlock()
+			seen = append(seen, "startup-complete")
+			return enums.EVENT_PASS
+		})
+		record := func(w Window) {
+			w.Connect(SignalEvent, "test-allocate", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				switch e := argv[1].(type) {
+				case *EventResize:
+					width, height := e.Size()
+					seen = append(seen, fmt.Sprintf("%v resize %dx%d initial=%v", w.GetTitle(), width, height, e.Initial()))
+				case *EventAllocate:
+					seen = append(seen, fmt.Sprintf("%v allocate %v", w.GetTitle(), e.Region()))
+				}
+				return enums.EVENT_PASS
+			})
+		}
+		drain := func() {
+			for len(cd.events) > 0 {
+				d.ProcessEvent(<-cd.events)
+			}
+		}
+		one := NewOffscreenWindow("one")
+		record(one)
+		d.MapWindowWithRegion(one, ptypes.MakeRegion(0, 0, 5, 2))
+		cd.setRunning(true)
+		defer cd.setRunning(false)
+
+		// resizes before the initial one are discarded
+		cd.Lock()
+		cd.started = true
+		cd.Unlock()
+		So(d.ProcessEvent(NewEventResize(1, 1)), ShouldEqual, enums.EVENT_PASS)
+		So(seen, ShouldBeEmpty)
+		cd.Lock()
+		cd.started = false
+		cd.Unlock()
+
+		d.StartupComplete()
+		drain()
+		width, height := d.Screen().Size()
+		So(seen, ShouldResemble, []string{
+			"startup-complete",
+			fmt.Sprintf("one resize %dx%d initial=true", width, height),
+			fmt.Sprintf("one allocate %v", ptypes.MakeRegion(0, 0, 5, 2)),
+		})
+
+		// windows mapped later are allocated as well
+		seen = nil
+		two := NewOffscreenWindow("two")
+		record(two)
+		d.MapWindowWithRegion(two, ptypes.MakeRegion(1, 1, 3, 3))
+		drain()
+		So(seen, ShouldResemble, []string{fmt.Sprintf("two allocate %v", ptypes.MakeRegion(1, 1, 3, 3))})
+
+		// subsequent resizes allocate every window
+		seen = nil
+		d.ProcessEvent(NewEventResize(20, 10))
+		So(seen, ShouldContain, "one resize 20x10 initial=false")
+		So(seen, ShouldContain, fmt.Sprintf("one allocate %v", ptypes.MakeRegion(0, 0, 5, 2)))
+		So(seen, ShouldContain, fmt.Sprintf("two allocate %v", ptypes.MakeRegion(1, 1, 3, 3)))
+	}))
+}
@@ -0,0 +1,43 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+)
+
+// EventCapabilities is delivered once the terminal has answered the
+// capability queries sent when the Screen was initialized.
+type EventCapabilities struct {
+	EventStamper
+
+	t            time.Time
+	capabilities Capabilities
+}
+
+// When returns the time when this EventCapabilities was created.
+func (ev *EventCapabilities) When() time.Time {
+	return ev.t
+}
+
+// Capabilities returns the capabilities of the terminal.
+func (ev *EventCapabilities) Capabilities() Capabilities {
+	return ev.capabilities
+}
+
+// NewEventCapabilities returns a new EventCapabilities.
+func NewEventCapabilities(capabilities Capabilities) *EventCapabilities {
+	return &EventCapabilities{t: time.Now(), capabilities: capabilities}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+)
+
+// EventClipboard delivers the content of a terminal clipboard selection, as
+// reported by the terminal in response to Screen.RequestClipboard.
+type EventClipboard struct {
+	EventStamper
+
+	t         time.Time
+	selection string
+	text      string
+}
+
+// When returns the time when this EventClipboard was created.
+func (ev *EventClipboard) When() time.Time {
+	return ev.t
+}
+
+// Selection returns the OSC 52 selection parameter reported by the terminal,
+// typically "c" for the clipboard.
+func (ev *EventClipboard) Selection() string {
+	return ev.selection
+}
+
+// Text returns the content of the clipboard selection.
+func (ev *EventClipboard) Text() string {
+	return ev.text
+}
+
+// NewEventClipboard returns a new EventClipboard.
+func NewEventClipboard(selection, text string) *EventClipboard {
+	return &EventClipboard{t: time.Now(), selection: selection, text: text}
+}
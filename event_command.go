@@ -0,0 +1,69 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+)
+
+// EventCommand is posted by a CapturedCommand for each chunk of output read
+// from the command's pty and once more when the command has exited. The
+// final event has no output, Done returns true and Err returns the result of
+// waiting on the command.
+type EventCommand struct {
+	EventStamper
+
+	t       time.Time
+	command CapturedCommand
+	output  []byte
+	done    bool
+	err     error
+}
+
+// When returns the time when this EventCommand was created.
+func (ev *EventCommand) When() time.Time {
+	return ev.t
+}
+
+// Command returns the CapturedCommand which posted this event.
+func (ev *EventCommand) Command() CapturedCommand {
+	return ev.command
+}
+
+// Output returns the chunk of output read from the command, if any.
+func (ev *EventCommand) Output() []byte {
+	return ev.output
+}
+
+// Done returns true if the command has exited.
+func (ev *EventCommand) Done() bool {
+	return ev.done
+}
+
+// Err returns the exit error of the command, only valid when Done is true.
+func (ev *EventCommand) Err() error {
+	return ev.err
+}
+
+// NewEventCommandOutput returns a new EventCommand with the given output.
+func NewEventCommandOutput(command CapturedCommand, output []byte) *EventCommand {
+	return &EventCommand{t: time.Now(), command: command, output: output}
+}
+
+// NewEventCommandDone returns a new EventCommand signaling the command has
+// exited with the given error.
+func NewEventCommandDone(command CapturedCommand, err error) *EventCommand {
+	return &EventCommand{t: time.Now(), command: command, done: true, err: err}
+}
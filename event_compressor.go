@@ -0,0 +1,250 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"reflect"
+	"sync"
+)
+
+// EventKind identifies the concrete type of Event without reflection
+type EventKind uint8
+
+const (
+	EventKindUnknown EventKind = iota
+	EventKindAllocate
+	EventKindCapabilities
+	EventKindClipboard
+	EventKindCommand
+	EventKindDetach
+	EventKindError
+	EventKindIdle
+	EventKindInterrupt
+	EventKindKey
+	EventKindMouse
+	EventKindPaste
+	EventKindPreedit
+	EventKindQuit
+	EventKindRaw
+	EventKindReload
+	EventKindRender
+	EventKindResize
+	EventKindTime
+)
+
+var eventKindNames = map[EventKind]string{
+	EventKindUnknown:      "unknown",
+	EventKindAllocate:     "allocate",
+	EventKindCapabilities: "capabilities",
+	EventKindClipboard:    "clipboard",
+	EventKindCommand:      "command",
+	EventKindDetach:       "detach",
+	EventKindError:        "error",
+	EventKindIdle:         "idle",
+	EventKindInterrupt:    "interrupt",
+	EventKindKey:          "key",
+	EventKindMouse:        "mouse",
+	EventKindPaste:        "paste",
+	EventKindPreedit:      "preedit",
+	EventKindQuit:         "quit",
+	EventKindRaw:          "raw",
+	EventKindReload:       "reload",
+	EventKindRender:       "render",
+	EventKindResize:       "resize",
+	EventKindTime:         "time",
+}
+
+func (k EventKind) String() string {
+	if name, ok := eventKindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// KindOfEvent returns the EventKind of the given Event, EventKindUnknown is
+// returned for nil and for any Event type not provided by this package
+func KindOfEvent(evt Event) EventKind {
+	switch evt.(type) {
+	case *EventAllocate:
+		return EventKindAllocate
+	case *EventCapabilities:
+		return EventKindCapabilities
+	case *EventClipboard:
+		return EventKindClipboard
+	case *EventCommand:
+		return EventKindCommand
+	case *EventDetach:
+		return EventKindDetach
+	case *EventError:
+		return EventKindError
+	case *EventIdle:
+		return EventKindIdle
+	case *EventInterrupt:
+		return EventKindInterrupt
+	case *EventKey:
+		return EventKindKey
+	case *EventMouse:
+		return EventKindMouse
+	case *EventPaste:
+		return EventKindPaste
+	case *EventPreedit:
+		return EventKindPreedit
+	case *EventQuit:
+		return EventKindQuit
+	case *EventRaw:
+		return EventKindRaw
+	case *EventReload:
+		return EventKindReload
+	case *EventRender:
+		return EventKindRender
+	case *EventResize:
+		return EventKindResize
+	case *EventTime:
+		return EventKindTime
+	}
+	return EventKindUnknown
+}
+
+// CompressPolicy describes how consecutive buffered events of the same
+// EventKind are reduced before being processed
+type CompressPolicy uint8
+
+const (
+	// CompressNever processes every event received
+	CompressNever CompressPolicy = iota
+	// CompressLast keeps only the last of consecutive events
+	CompressLast
+	// CompressMotion keeps only the last of consecutive mouse motion events
+	// which share the same state, buttons and modifiers, presses, releases
+	// and wheel impulses are never compressed
+	CompressMotion
+)
+
+func (p CompressPolicy) String() string {
+	switch p {
+	case CompressNever:
+		return "never"
+	case CompressLast:
+		return "last"
+	case CompressMotion:
+		return "motion"
+	}
+	return "unknown"
+}
+
+// EventCompressor reduces the pending event buffer of a Display. Compress is
+// given the last pending event and the next buffered event, if the two can be
+// merged the resulting event is returned with ok set to true and replaces the
+// last pending event, otherwise next is appended to the pending events.
+type EventCompressor interface {
+	Compress(last, next Event) (merged Event, ok bool)
+}
+
+// EventCompressorFn is an adapter allowing ordinary functions to be used as
+// an EventCompressor
+type EventCompressorFn func(last, next Event) (merged Event, ok bool)
+
+func (fn EventCompressorFn) Compress(last, next Event) (merged Event, ok bool) {
+	return fn(last, next)
+}
+
+// CEventCompressor is the default EventCompressor, applying a CompressPolicy
+// per EventKind. Key, paste, raw and command events are never compressed,
+// mouse events only have their motion compressed and all other kinds keep the
+// last of consecutive events.
+type CEventCompressor struct {
+	policies map[EventKind]CompressPolicy
+	fallback CompressPolicy
+
+	sync.RWMutex
+}
+
+// NewEventCompressor returns a new CEventCompressor with the default policies
+func NewEventCompressor() (c *CEventCompressor) {
+	c = &CEventCompressor{
+		policies: map[EventKind]CompressPolicy{
+			EventKindCommand: CompressNever,
+			EventKindKey:     CompressNever,
+			EventKindMouse:   CompressMotion,
+			EventKindPaste:   CompressNever,
+			EventKindRaw:     CompressNever,
+			EventKindReload:  CompressLast,
+			EventKindResize:  CompressLast,
+		},
+		fallback: CompressLast,
+	}
+	return
+}
+
+// SetPolicy changes the CompressPolicy used for events of the given kind
+func (c *CEventCompressor) SetPolicy(kind EventKind, policy CompressPolicy) {
+	c.Lock()
+	c.policies[kind] = policy
+	c.Unlock()
+}
+
+// GetPolicy returns the CompressPolicy used for events of the given kind
+func (c *CEventCompressor) GetPolicy(kind EventKind) (policy CompressPolicy) {
+	c.RLock()
+	defer c.RUnlock()
+	if p, ok := c.policies[kind]; ok {
+		return p
+	}
+	return c.fallback
+}
+
+// SetFallbackPolicy changes the CompressPolicy used for events of any kind
+// without a specific policy, including EventKindUnknown
+func (c *CEventCompressor) SetFallbackPolicy(policy CompressPolicy) {
+	c.Lock()
+	c.fallback = policy
+	c.Unlock()
+}
+
+// Compress merges next into last when both are of the same EventKind and the
+// policy of that kind allows it. Events of unknown kind are only merged when
+// both are of the same concrete type.
+func (c *CEventCompressor) Compress(last, next Event) (merged Event, ok bool) {
+	if last == nil || next == nil {
+		return nil, false
+	}
+	kind := KindOfEvent(next)
+	if KindOfEvent(last) != kind {
+		return nil, false
+	}
+	if kind == EventKindUnknown && reflect.TypeOf(last) != reflect.TypeOf(next) {
+		// foreign event types are only alike when of the same type
+		return nil, false
+	}
+	switch c.GetPolicy(kind) {
+	case CompressLast:
+		return next, true
+	case CompressMotion:
+		lm, lok := last.(*EventMouse)
+		nm, nok := next.(*EventMouse)
+		if lok && nok && isMouseMotion(lm) && lm.State() == nm.State() && lm.Buttons() == nm.Buttons() && lm.Modifiers() == nm.Modifiers() {
+			return next, true
+		}
+	}
+	return nil, false
+}
+
+func isMouseMotion(evt *EventMouse) bool {
+	switch evt.State() {
+	case MOUSE_MOVE, DRAG_MOVE:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+type testForeignEventA struct {
+	EventStamper
+}
+
+func (ev *testForeignEventA) When() time.Time {
+	return time.Time{}
+}
+
+type testForeignEventB struct {
+	EventStamper
+}
+
+func (ev *testForeignEventB) When() time.Time {
+	return time.Time{}
+}
+
+func TestEventCompressor(t *testing.T) {
+	Convey("Event kinds", t, func() {
+		So(KindOfEvent(nil), ShouldEqual, EventKindUnknown)
+		So(KindOfEvent(NewEventResize(1, 1)), ShouldEqual, EventKindResize)
+		So(KindOfEvent(NewEventKey(KeyRune, 'a', ModNone)), ShouldEqual, EventKindKey)
+		So(KindOfEvent(&EventMouse{}), ShouldEqual, EventKindMouse)
+		So(EventKindMouse.String(), ShouldEqual, "mouse")
+		So(CompressMotion.String(), ShouldEqual, "motion")
+	})
+	Convey("Default compression policies", t, func() {
+		c := NewEventCompressor()
+		first, second := NewEventResize(1, 1), NewEventResize(2, 2)
+		merged, ok := c.Compress(first, second)
+		So(ok, ShouldBeTrue)
+		So(merged, ShouldEqual, second)
+		_, ok = c.Compress(first, NewEventKey(KeyRune, 'a', ModNone))
+		So(ok, ShouldBeFalse)
+		_, ok = c.Compress(NewEventKey(KeyRune, 'a', ModNone), NewEventKey(KeyRune, 'b', ModNone))
+		So(ok, ShouldBeFalse)
+		moveA := &EventMouse{x: 1, s: MOUSE_MOVE}
+		moveB := &EventMouse{x: 2, s: MOUSE_MOVE}
+		merged, ok = c.Compress(moveA, moveB)
+		So(ok, ShouldBeTrue)
+		So(merged, ShouldEqual, moveB)
+		_, ok = c.Compress(moveA, &EventMouse{x: 2, s: MOUSE_MOVE, mod: ModShift})
+		So(ok, ShouldBeFalse)
+		_, ok = c.Compress(moveA, &EventMouse{x: 2, s: BUTTON_PRESS, btn: Button1})
+		So(ok, ShouldBeFalse)
+		_, ok = c.Compress(&EventMouse{s: BUTTON_PRESS, btn: Button1}, &EventMouse{s: BUTTON_PRESS, btn: Button1})
+		So(ok, ShouldBeFalse)
+		c.SetPolicy(EventKindResize, CompressNever)
+		So(c.GetPolicy(EventKindResize), ShouldEqual, CompressNever)
+		_, ok = c.Compress(first, second)
+		So(ok, ShouldBeFalse)
+		So(c.GetPolicy(EventKindIdle), ShouldEqual, CompressLast)
+		c.SetFallbackPolicy(CompressNever)
+		So(c.GetPolicy(EventKindIdle), ShouldEqual, CompressNever)
+	})
+	Convey("Foreign event types", t, func() {
+		c := NewEventCompressor()
+		first, second := &testForeignEventA{}, &testForeignEventB{}
+		So(KindOfEvent(first), ShouldEqual, EventKindUnknown)
+		So(KindOfEvent(second), ShouldEqual, EventKindUnknown)
+		_, ok := c.Compress(first, second)
+		So(ok, ShouldBeFalse)
+		third := &testForeignEventA{}
+		merged, ok := c.Compress(first, third)
+		So(ok, ShouldBeTrue)
+		So(merged, ShouldPointTo, third)
+		// reloads are only merged with reloads
+		reload := NewEventReload()
+		So(KindOfEvent(reload), ShouldEqual, EventKindReload)
+		So(c.GetPolicy(EventKindReload), ShouldEqual, CompressLast)
+		_, ok = c.Compress(first, reload)
+		So(ok, ShouldBeFalse)
+		_, ok = c.Compress(reload, first)
+		So(ok, ShouldBeFalse)
+		next := NewEventReload()
+		merged, ok = c.Compress(reload, next)
+		So(ok, ShouldBeTrue)
+		So(merged, ShouldPointTo, next)
+	})
+	Convey("Display event compression", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.started = true
+		cd.resized = true
+		cd.Unlock()
+		var sizes []int
+		d.Connect(SignalEventResize, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			w, _ := argv[1].(*EventResize).Size()
+			sizes = append(sizes, w)
+			return enums.EVENT_PASS
+		})
+		post := func(events ...Event) {
+			cd.Lock()
+			for _, evt := range events {
+				cd.buffer = append(cd.buffer, evt)
+			}
+			cd.Unlock()
+		}
+		post(NewEventResize(10, 5), NewEventResize(20, 5), NewEventResize(30, 5))
+		d.IterateBufferedEvents()
+		So(sizes, ShouldResemble, []int{30})
+		sizes = nil
+		calls := 0
+		d.SetEventCompressor(EventCompressorFn(func(last, next Event) (merged Event, ok bool) {
+			calls++
+			return nil, false
+		}))
+		post(NewEventResize(10, 5), NewEventResize(20, 5))
+		d.IterateBufferedEvents()
+		So(calls, ShouldEqual, 1)
+		So(sizes, ShouldResemble, []int{10, 20})
+		sizes = nil
+		var foreign []Event
+		d.Connect(SignalEvent, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			switch evt := argv[1].(type) {
+			case *testForeignEventA:
+				foreign = append(foreign, evt)
+			case *testForeignEventB:
+				foreign = append(foreign, evt)
+			}
+			return enums.EVENT_PASS
+		})
+		first, second := &testForeignEventA{}, &testForeignEventB{}
+		d.SetEventCompressor(nil)
+		post(first, second)
+		d.IterateBufferedEvents()
+		So(foreign, ShouldResemble, []Event{first, second})
+		d.SetEventCompressor(nil)
+		So(d.GetEventCompressor(), ShouldHaveSameTypeAs, &CEventCompressor{})
+		d.SetCompressEvents(false)
+		post(NewEventResize(10, 5), NewEventResize(20, 5))
+		d.IterateBufferedEvents()
+		So(sizes, ShouldResemble, []int{10, 20})
+	}))
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+)
+
+// EventDetach is posted by the Screen when the controlling terminal goes away,
+// for example when the session is hung up, and again when it returns. While
+// detached, nothing is written to the terminal and the content of the Screen is
+// preserved, to be drawn in full once reattached.
+type EventDetach struct {
+	EventStamper
+
+	t        time.Time
+	attached bool
+	cause    error
+}
+
+// When returns the time when this event was created.
+func (ev *EventDetach) When() time.Time {
+	return ev.t
+}
+
+// Attached returns true if the terminal has returned.
+func (ev *EventDetach) Attached() bool {
+	return ev.attached
+}
+
+// Cause returns the error which revealed the terminal had gone away, nil
+// when reattached.
+func (ev *EventDetach) Cause() error {
+	return ev.cause
+}
+
+// NewEventDetach creates an EventDetach for a terminal which has gone away.
+func NewEventDetach(cause error) *EventDetach {
+	return &EventDetach{t: time.Now(), cause: cause}
+}
+
+// NewEventReattach creates an EventDetach for a terminal which has returned.
+func NewEventReattach() *EventDetach {
+	return &EventDetach{t: time.Now(), attached: true}
+}
@@ -46,6 +46,10 @@ var (
 	// the environment is UTF-8 or UTF-16.
 	ErrNoCharset = errors.New("character set not supported")
 
+	// ErrDetached indicates that the terminal was left on request, and
+	// is given as the cause of the EventDetach posted by Screen.Detach.
+	ErrDetached = errors.New("terminal detached on request")
+
 	// ErrEventQFull indicates that the event queue is full, and
 	// cannot accept more events.
 	ErrEventQFull = errors.New("event queue full")
@@ -54,6 +58,8 @@ var (
 // An EventError is an event representing some sort of error, and carries
 // an error payload.
 type EventError struct {
+	EventStamper
+
 	t   time.Time
 	err error
 }
@@ -0,0 +1,52 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+)
+
+// EventIdle is posted to warn that the user has been idle for some time and
+// that the session will be ended soon unless they return, for example by the
+// ApplicationServer when a client approaches the IdleTimeout of the server
+// policy.
+type EventIdle struct {
+	EventStamper
+
+	t         time.Time
+	idle      time.Duration
+	remaining time.Duration
+}
+
+// When returns the time when this event was created.
+func (ev *EventIdle) When() time.Time {
+	return ev.t
+}
+
+// Idle returns how long the user has been idle.
+func (ev *EventIdle) Idle() time.Duration {
+	return ev.idle
+}
+
+// Remaining returns how long until the session is ended.
+func (ev *EventIdle) Remaining() time.Duration {
+	return ev.remaining
+}
+
+// NewEventIdle creates an EventIdle with the time the user has been idle and
+// the time remaining before the session is ended.
+func NewEventIdle(idle, remaining time.Duration) *EventIdle {
+	return &EventIdle{t: time.Now(), idle: idle, remaining: remaining}
+}
@@ -22,6 +22,8 @@ import (
 // EventInterrupt is a generic wakeup event.  Its can be used to
 // to request a redraw.  It can carry an arbitrary payload, as well.
 type EventInterrupt struct {
+	EventStamper
+
 	t time.Time
 	v interface{}
 }
@@ -43,11 +43,40 @@ import (
 // activity than graphical applications.  Hence, they should avoid depending
 // overly much on availability of modifiers, or the availability of any
 // specific keys.
+//
+// When the terminal supports the kitty keyboard protocol and key phases are
+// enabled (see Display.EnableKeyPhases), key repeat and key release events are
+// also reported and the Phase() method can be used to distinguish them.
 type EventKey struct {
-	t   time.Time
-	mod ModMask
-	key Key
-	ch  rune
+	EventStamper
+
+	t     time.Time
+	mod   ModMask
+	key   Key
+	ch    rune
+	phase KeyPhase
+}
+
+// KeyPhase describes whether an EventKey is an initial key press, an
+// automatic key repeat or a key release.
+type KeyPhase uint8
+
+const (
+	KeyPress KeyPhase = iota
+	KeyRepeat
+	KeyRelease
+)
+
+func (p KeyPhase) String() string {
+	switch p {
+	case KeyPress:
+		return "press"
+	case KeyRepeat:
+		return "repeat"
+	case KeyRelease:
+		return "release"
+	}
+	return fmt.Sprintf("KeyPhase(%d)", p)
 }
 
 // When returns the time when this Event was created, which should closely
@@ -87,6 +116,12 @@ func (ev *EventKey) Modifiers() ModMask {
 	return ev.mod
 }
 
+// Phase returns whether this is a key press, repeat or release event. Unless
+// the terminal reports key event types, this is always KeyPress.
+func (ev *EventKey) Phase() KeyPhase {
+	return ev.phase
+}
+
 // Name returns a printable value or the key stroke.  This can be used
 // when printing the event, for example.
 func (ev *EventKey) Name() string {
@@ -150,3 +185,11 @@ func NewEventKey(k Key, ch rune, mod ModMask) *EventKey {
 	}
 	return &EventKey{t: time.Now(), key: k, ch: ch, mod: mod}
 }
+
+// NewEventKeyWithPhase is the same as NewEventKey except the KeyPhase of the
+// event is also specified.
+func NewEventKeyWithPhase(k Key, ch rune, mod ModMask, phase KeyPhase) *EventKey {
+	ev := NewEventKey(k, ch, mod)
+	ev.phase = phase
+	return ev
+}
@@ -67,4 +67,48 @@ func TestKey(t *testing.T) {
 		ek = NewEventKey(KeyCtrlSpace, rune(KeyCtrlSpace), ModCtrl)
 		So(ek.Name(), ShouldEqual, "Ctrl+Space")
 	})
+	Convey("EventKey phase checks", t, func() {
+		ek := NewEventKey(KeyRune, 'a', ModNone)
+		So(ek.Phase(), ShouldEqual, KeyPress)
+		ek = NewEventKeyWithPhase(KeyRune, 'a', ModNone, KeyRelease)
+		So(ek.Phase(), ShouldEqual, KeyRelease)
+		So(ek.Phase().String(), ShouldEqual, "release")
+		// plain rune, press
+		ek, n, partial := parseKittyKeySequence([]byte("\x1b[97u"))
+		So(partial, ShouldBeFalse)
+		So(n, ShouldEqual, 5)
+		So(ek.Key(), ShouldEqual, KeyRune)
+		So(ek.Rune(), ShouldEqual, 'a')
+		So(ek.Phase(), ShouldEqual, KeyPress)
+		// shift + a, repeat
+		ek, _, _ = parseKittyKeySequence([]byte("\x1b[97;2:2u"))
+		So(ek.Rune(), ShouldEqual, 'A')
+		So(ek.Modifiers(), ShouldEqual, ModNone)
+		So(ek.Phase(), ShouldEqual, KeyRepeat)
+		// ctrl + c, release
+		ek, _, _ = parseKittyKeySequence([]byte("\x1b[99;5:3u"))
+		So(ek.Key(), ShouldEqual, KeySmallC)
+		So(ek.Rune(), ShouldEqual, KeyCtrlC)
+		So(ek.Modifiers(), ShouldEqual, ModCtrl)
+		So(ek.Phase(), ShouldEqual, KeyRelease)
+		// arrow up, release
+		ek, n, _ = parseKittyKeySequence([]byte("\x1b[1;1:3Axyz"))
+		So(n, ShouldEqual, 8)
+		So(ek.Key(), ShouldEqual, KeyUp)
+		So(ek.Phase(), ShouldEqual, KeyRelease)
+		// F5 with alt, press
+		ek, _, _ = parseKittyKeySequence([]byte("\x1b[15;3:1~"))
+		So(ek.Key(), ShouldEqual, KeyF5)
+		So(ek.Modifiers(), ShouldEqual, ModAlt)
+		// modifier keys are consumed without an event
+		ek, n, _ = parseKittyKeySequence([]byte("\x1b[57441;2u"))
+		So(ek, ShouldBeNil)
+		So(n, ShouldEqual, 10)
+		// legacy sequences are left alone
+		_, n, _ = parseKittyKeySequence([]byte("\x1b[1;5A"))
+		So(n, ShouldEqual, 0)
+		_, n, partial = parseKittyKeySequence([]byte("\x1b[97;"))
+		So(n, ShouldEqual, 0)
+		So(partial, ShouldBeTrue)
+	})
 }
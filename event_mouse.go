@@ -37,8 +37,11 @@ import (
 // and some cannot report motion events unless a button is pressed.
 //
 // Applications can inspect the time between events to resolve double or
-// triple clicks.
+// triple clicks, preferably using the Arrived times of their Stamp as these
+// are monotonic and ordered regardless of where the events came from.
 type EventMouse struct {
+	EventStamper
+
 	t   time.Time
 	btn ButtonMask
 	mod ModMask
@@ -88,6 +91,8 @@ func NewEventMouse(x, y int, btn ButtonMask, mod ModMask) *EventMouse {
 
 func (ev *EventMouse) Clone() Event {
 	return &EventMouse{
+		EventStamper: ev.EventStamper,
+
 		t:   ev.t,
 		x:   ev.x,
 		y:   ev.y,
@@ -100,6 +105,8 @@ func (ev *EventMouse) Clone() Event {
 
 func (ev *EventMouse) CloneForPosition(x, y int) Event {
 	return &EventMouse{
+		EventStamper: ev.EventStamper,
+
 		t:   ev.t,
 		x:   x,
 		y:   y,
@@ -110,6 +117,29 @@ func (ev *EventMouse) CloneForPosition(x, y int) Event {
 	}
 }
 
+// CloneWithInvertedWheel returns a copy of the event with the WheelUp and
+// WheelDown buttons swapped.
+func (ev *EventMouse) CloneWithInvertedWheel() *EventMouse {
+	swap := func(b ButtonMask) ButtonMask {
+		switch {
+		case b&WheelUp != 0 && b&WheelDown == 0:
+			return b&^WheelUp | WheelDown
+		case b&WheelDown != 0 && b&WheelUp == 0:
+			return b&^WheelDown | WheelUp
+		}
+		return b
+	}
+	return &EventMouse{
+		t:   ev.t,
+		x:   ev.x,
+		y:   ev.y,
+		btn: swap(ev.btn),
+		mod: ev.mod,
+		s:   ev.s,
+		b:   swap(ev.b),
+	}
+}
+
 // When returns the time when this EventMouse was created.
 func (ev *EventMouse) When() time.Time {
 	return ev.t
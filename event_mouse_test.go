@@ -69,6 +69,10 @@ func TestEventMouseWheel(t *testing.T) {
 		So(em.WheelImpulse(), ShouldEqual, WheelDown)
 		So(em.ButtonPressed(), ShouldEqual, ButtonNone)
 		So(em.IsWheelImpulse(), ShouldEqual, true)
+		inverted := em.CloneWithInvertedWheel()
+		So(inverted.WheelImpulse(), ShouldEqual, WheelUp)
+		So(inverted.IsWheelImpulse(), ShouldEqual, true)
+		So(inverted.CloneWithInvertedWheel().WheelImpulse(), ShouldEqual, WheelDown)
 	})
 }
 
@@ -0,0 +1,235 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// EventOverflowMode describes what happens when an event is posted to a full
+// event queue
+type EventOverflowMode uint8
+
+const (
+	// OverflowDrop discards the event being posted
+	OverflowDrop EventOverflowMode = iota
+	// OverflowBlock waits for room in the queue, up to the policy Timeout
+	// after which the event being posted is discarded, a zero Timeout waits
+	// for as long as it takes
+	OverflowBlock
+	// OverflowDropOldest discards the oldest queued events to make room
+	OverflowDropOldest
+	// OverflowCoalesce compresses the queued events with the policy
+	// Compressor to make room, discarding the event being posted if nothing
+	// could be merged
+	OverflowCoalesce
+)
+
+func (m EventOverflowMode) String() string {
+	switch m {
+	case OverflowDrop:
+		return "drop"
+	case OverflowBlock:
+		return "block"
+	case OverflowDropOldest:
+		return "drop-oldest"
+	case OverflowCoalesce:
+		return "coalesce"
+	}
+	return "unknown"
+}
+
+// EventOverflowPolicy configures the handling of a full event queue
+type EventOverflowPolicy struct {
+	Mode EventOverflowMode
+	// Timeout limits how long OverflowBlock waits
+	Timeout time.Duration
+	// Compressor is used by OverflowCoalesce, the default CEventCompressor
+	// is used when nil
+	Compressor EventCompressor
+}
+
+// EventQueue identifies one of the event queues of a Display
+type EventQueue uint8
+
+const (
+	// EventQueueScreen is the queue of events read from the terminal, this is
+	// where key presses are lost when the Display is too busy to keep up
+	EventQueueScreen EventQueue = iota
+	// EventQueuePosted is the queue of events given to Display.PostEvent
+	EventQueuePosted
+	// EventQueueInbound is the queue of events waiting to be buffered for
+	// processing by the Display
+	EventQueueInbound
+	// EventQueueWindow is the private event queue of each window, see:
+	// Display.SetWindowQueues
+	EventQueueWindow
+)
+
+func (q EventQueue) String() string {
+	switch q {
+	case EventQueueScreen:
+		return "screen"
+	case EventQueuePosted:
+		return "posted"
+	case EventQueueInbound:
+		return "inbound"
+	case EventQueueWindow:
+		return "window"
+	}
+	return "unknown"
+}
+
+// EventOverflowFn is called with each event discarded by an event queue
+type EventOverflowFn = func(dropped Event)
+
+// cEventOverflow applies an EventOverflowPolicy to posting events on a
+// channel, counting and reporting the events discarded
+type cEventOverflow struct {
+	policy  EventOverflowPolicy
+	handler EventOverflowFn
+	dropped uint64
+
+	// posting serializes the producers while events are moved around
+	posting sync.Mutex
+	sync.RWMutex
+}
+
+func (o *cEventOverflow) setPolicy(policy EventOverflowPolicy) {
+	o.Lock()
+	o.policy = policy
+	o.Unlock()
+}
+
+func (o *cEventOverflow) getPolicy() (policy EventOverflowPolicy) {
+	o.RLock()
+	defer o.RUnlock()
+	return o.policy
+}
+
+func (o *cEventOverflow) setHandler(fn EventOverflowFn) {
+	o.Lock()
+	o.handler = fn
+	o.Unlock()
+}
+
+func (o *cEventOverflow) getDropped() (dropped uint64) {
+	o.RLock()
+	defer o.RUnlock()
+	return o.dropped
+}
+
+func (o *cEventOverflow) drop(evt Event) {
+	o.Lock()
+	o.dropped++
+	fn := o.handler
+	o.Unlock()
+	if fn != nil {
+		fn(evt)
+	}
+}
+
+// post sends the event on the channel, applying the overflow policy when the
+// channel is full. The done channel aborts an OverflowBlock wait, returning
+// without discarding the event. ErrEventQFull is returned when the event
+// being posted is discarded.
+func (o *cEventOverflow) post(ch chan Event, evt Event, done <-chan struct{}) (err error) {
+	policy := o.getPolicy()
+	if policy.Mode == OverflowDropOldest || policy.Mode == OverflowCoalesce {
+		// these modes take events off the channel and put some back, an event
+		// sent meanwhile would be put ahead of them
+		o.posting.Lock()
+		defer o.posting.Unlock()
+	}
+	select {
+	case ch <- evt:
+		return nil
+	default:
+	}
+
+	switch policy.Mode {
+	case OverflowBlock:
+		var timeout <-chan time.Time
+		if policy.Timeout > 0 {
+			timer := time.NewTimer(policy.Timeout)
+			defer timer.Stop()
+			timeout = timer.C
+		}
+		select {
+		case ch <- evt:
+			return nil
+		case <-done:
+			return nil
+		case <-timeout:
+		}
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case ch <- evt:
+				return nil
+			default:
+			}
+			select {
+			case oldest := <-ch:
+				o.drop(oldest)
+			default:
+			}
+		}
+
+	case OverflowCoalesce:
+		compressor := policy.Compressor
+		if compressor == nil {
+			compressor = NewEventCompressor()
+		}
+		var queued []Event
+	drainLoop:
+		for {
+			select {
+			case e := <-ch:
+				queued = append(queued, e)
+			default:
+				break drainLoop
+			}
+		}
+		pending := make([]Event, 0, len(queued)+1)
+		for _, e := range append(queued, evt) {
+			if last := len(pending) - 1; last >= 0 {
+				if merged, ok := compressor.Compress(pending[last], e); ok {
+					pending[last] = merged
+					continue
+				}
+			}
+			pending = append(pending, e)
+		}
+		for idx, e := range pending {
+			select {
+			case ch <- e:
+			default:
+				// nothing merged, the newest events do not fit
+				for _, lost := range pending[idx:] {
+					o.drop(lost)
+				}
+				return ErrEventQFull
+			}
+		}
+		return nil
+	}
+
+	o.drop(evt)
+	return ErrEventQFull
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+func TestEventOverflow(t *testing.T) {
+	drain := func(ch chan Event) (events []Event) {
+		for len(ch) > 0 {
+			events = append(events, <-ch)
+		}
+		return
+	}
+	Convey("Dropping the posted event", t, func() {
+		o := &cEventOverflow{}
+		var lost []Event
+		o.setHandler(func(dropped Event) { lost = append(lost, dropped) })
+		ch := make(chan Event, 1)
+		first, second := NewEventResize(1, 1), NewEventResize(2, 2)
+		So(o.post(ch, first, nil), ShouldBeNil)
+		So(o.post(ch, second, nil), ShouldEqual, ErrEventQFull)
+		So(o.getDropped(), ShouldEqual, 1)
+		So(lost, ShouldResemble, []Event{second})
+		So(drain(ch), ShouldResemble, []Event{first})
+	})
+	Convey("Blocking with a timeout", t, func() {
+		o := &cEventOverflow{}
+		o.setPolicy(EventOverflowPolicy{Mode: OverflowBlock, Timeout: time.Millisecond * 10})
+		ch := make(chan Event, 1)
+		So(o.post(ch, NewEventResize(1, 1), nil), ShouldBeNil)
+		So(o.post(ch, NewEventResize(2, 2), nil), ShouldEqual, ErrEventQFull)
+		So(o.getDropped(), ShouldEqual, 1)
+		go func() {
+			time.Sleep(time.Millisecond * 5)
+			<-ch
+		}()
+		o.setPolicy(EventOverflowPolicy{Mode: OverflowBlock})
+		So(o.post(ch, NewEventResize(3, 3), nil), ShouldBeNil)
+		So(o.getDropped(), ShouldEqual, 1)
+	})
+	Convey("Dropping the oldest event", t, func() {
+		o := &cEventOverflow{}
+		o.setPolicy(EventOverflowPolicy{Mode: OverflowDropOldest})
+		ch := make(chan Event, 2)
+		a, b, c := NewEventKey(KeyRune, 'a', ModNone), NewEventKey(KeyRune, 'b', ModNone), NewEventKey(KeyRune, 'c', ModNone)
+		So(o.post(ch, a, nil), ShouldBeNil)
+		So(o.post(ch, b, nil), ShouldBeNil)
+		So(o.post(ch, c, nil), ShouldBeNil)
+		So(o.getDropped(), ShouldEqual, 1)
+		So(drain(ch), ShouldResemble, []Event{b, c})
+	})
+	Convey("Coalescing queued events", t, func() {
+		o := &cEventOverflow{}
+		o.setPolicy(EventOverflowPolicy{Mode: OverflowCoalesce})
+		ch := make(chan Event, 3)
+		key := NewEventKey(KeyRune, 'a', ModNone)
+		moveA, moveB := &EventMouse{x: 1, s: MOUSE_MOVE}, &EventMouse{x: 2, s: MOUSE_MOVE}
+		So(o.post(ch, key, nil), ShouldBeNil)
+		So(o.post(ch, moveA, nil), ShouldBeNil)
+		So(o.post(ch, moveB, nil), ShouldBeNil)
+		other := NewEventKey(KeyRune, 'b', ModNone)
+		So(o.post(ch, other, nil), ShouldBeNil)
+		So(o.getDropped(), ShouldEqual, 0)
+		So(drain(ch), ShouldResemble, []Event{key, moveB, other})
+		for _, r := range "abc" {
+			So(o.post(ch, NewEventKey(KeyRune, r, ModNone), nil), ShouldBeNil)
+		}
+		So(o.post(ch, other, nil), ShouldEqual, ErrEventQFull)
+		So(o.getDropped(), ShouldEqual, 1)
+		So(drain(ch), ShouldHaveLength, 3)
+	})
+	Convey("Posting while coalescing", t, func() {
+		o := &cEventOverflow{}
+		ch := make(chan Event, 2)
+		a, b, c, d := NewEventKey(KeyRune, 'a', ModNone), NewEventKey(KeyRune, 'b', ModNone), NewEventKey(KeyRune, 'c', ModNone), NewEventKey(KeyRune, 'd', ModNone)
+		var once sync.Once
+		posted := make(chan struct{})
+		o.setPolicy(EventOverflowPolicy{Mode: OverflowCoalesce, Compressor: EventCompressorFn(func(last, next Event) (Event, bool) {
+			// another producer posts while the queued events are off the channel
+			once.Do(func() {
+				go func() {
+					_ = o.post(ch, c, nil)
+					close(posted)
+				}()
+				select {
+				case <-posted:
+				case <-time.After(time.Millisecond * 50):
+				}
+			})
+			return nil, false
+		})})
+		So(o.post(ch, a, nil), ShouldBeNil)
+		So(o.post(ch, b, nil), ShouldBeNil)
+		So(o.post(ch, d, nil), ShouldEqual, ErrEventQFull)
+		<-posted
+		So(drain(ch), ShouldResemble, []Event{a, b})
+	})
+	Convey("Display overflow policies", t, WithDisplayManager(func(d Display) {
+		So(d.GetEventOverflowPolicy(EventQueueScreen).Mode, ShouldEqual, OverflowDrop)
+		So(d.GetEventOverflowPolicy(EventQueuePosted).Mode, ShouldEqual, OverflowBlock)
+		d.SetEventOverflowPolicy(EventQueueScreen, EventOverflowPolicy{Mode: OverflowDropOldest})
+		So(d.Screen().GetEventOverflowPolicy().Mode, ShouldEqual, OverflowDropOldest)
+		var queues []EventQueue
+		d.Connect(SignalEventOverflow, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			if queue, _, ok := DisplaySignalEventOverflowArgv(argv...); ok {
+				queues = append(queues, queue)
+			}
+			return enums.EVENT_PASS
+		})
+		d.SetEventOverflowPolicy(EventQueueScreen, EventOverflowPolicy{Mode: OverflowDrop})
+		screen := d.Screen()
+		for i := 0; i < 64; i++ {
+			_ = screen.PostEvent(NewEventResize(i, i))
+		}
+		So(d.DroppedEvents(EventQueueScreen), ShouldBeGreaterThan, 0)
+		So(d.DroppedEvents(EventQueueScreen), ShouldEqual, screen.DroppedEvents())
+		So(queues, ShouldNotBeEmpty)
+		So(queues[0], ShouldEqual, EventQueueScreen)
+		So(OverflowCoalesce.String(), ShouldEqual, "coalesce")
+		So(EventQueueInbound.String(), ShouldEqual, "inbound")
+	}))
+}
@@ -24,7 +24,10 @@ import (
 // Then a number of keys will be sent to indicate that the content
 // is pasted in.  At the end, an event with .Start() false will be sent.
 type EventPaste struct {
+	EventStamper
+
 	start bool
+	text  string
 	t     time.Time
 }
 
@@ -47,3 +50,9 @@ func (ev *EventPaste) End() bool {
 func NewEventPaste(start bool) *EventPaste {
 	return &EventPaste{t: time.Now(), start: start}
 }
+
+// newEventPasteText returns an EventPaste carrying all of the text of a paste,
+// which the Display delivers as a bracketed paste, see: Display.PastePrimary
+func newEventPasteText(text string) *EventPaste {
+	return &EventPaste{t: time.Now(), text: text}
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+)
+
+// EventPreedit delivers input method (IME) pre-edit composition text. Each
+// event replaces any previous pre-edit text, which text widgets typically draw
+// underlined at the insertion point. An event with .Done() true ends the
+// composition; the committed text (if any) arrives separately as EventKey or
+// EventPaste events.
+type EventPreedit struct {
+	EventStamper
+
+	t      time.Time
+	text   string
+	cursor int
+	done   bool
+}
+
+// When returns the time when this EventPreedit was created.
+func (ev *EventPreedit) When() time.Time {
+	return ev.t
+}
+
+// Text returns the current composition string.
+func (ev *EventPreedit) Text() string {
+	return ev.text
+}
+
+// Cursor returns the rune offset of the cursor within the composition string.
+func (ev *EventPreedit) Cursor() int {
+	return ev.cursor
+}
+
+// Done returns true if this event ends the composition.
+func (ev *EventPreedit) Done() bool {
+	return ev.done
+}
+
+// NewEventPreedit returns a new EventPreedit with the given composition text
+// and cursor offset, clamped to the length of the text.
+func NewEventPreedit(text string, cursor int) *EventPreedit {
+	if length := len([]rune(text)); cursor > length {
+		cursor = length
+	} else if cursor < 0 {
+		cursor = 0
+	}
+	return &EventPreedit{t: time.Now(), text: text, cursor: cursor}
+}
+
+// NewEventPreeditDone returns a new EventPreedit ending the composition.
+func NewEventPreeditDone() *EventPreedit {
+	return &EventPreedit{t: time.Now(), done: true}
+}
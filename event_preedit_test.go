@@ -0,0 +1,41 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEventPreedit(t *testing.T) {
+	Convey("EventPreedit basics", t, func() {
+		then := time.Now()
+		ep := NewEventPreedit("にほん", 2)
+		So(ep, ShouldHaveSameTypeAs, &EventPreedit{})
+		now := time.Now()
+		So(ep.When().UnixNano(), ShouldBeGreaterThanOrEqualTo, then.UnixNano())
+		So(ep.When().UnixNano(), ShouldBeLessThanOrEqualTo, now.UnixNano())
+		So(ep.Text(), ShouldEqual, "にほん")
+		So(ep.Cursor(), ShouldEqual, 2)
+		So(ep.Done(), ShouldEqual, false)
+		So(NewEventPreedit("にほん", 10).Cursor(), ShouldEqual, 3)
+		So(NewEventPreedit("にほん", -1).Cursor(), ShouldEqual, 0)
+		ep = NewEventPreeditDone()
+		So(ep.Text(), ShouldEqual, "")
+		So(ep.Done(), ShouldEqual, true)
+	})
+}
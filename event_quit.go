@@ -20,6 +20,8 @@ import (
 
 // EventQuit is sent when the display needs to render the screen
 type EventQuit struct {
+	EventStamper
+
 	when time.Time
 }
 
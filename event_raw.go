@@ -0,0 +1,43 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+)
+
+// EventRaw delivers the bytes read from the terminal as-is, while the raw
+// input mode of the Screen is enabled. See: Screen.SetRawInput
+type EventRaw struct {
+	EventStamper
+
+	t    time.Time
+	data []byte
+}
+
+// When returns the time when this EventRaw was created.
+func (ev *EventRaw) When() time.Time {
+	return ev.t
+}
+
+// Data returns the bytes read from the terminal.
+func (ev *EventRaw) Data() []byte {
+	return ev.data
+}
+
+// NewEventRaw returns a new EventRaw.
+func NewEventRaw(data []byte) *EventRaw {
+	return &EventRaw{t: time.Now(), data: data}
+}
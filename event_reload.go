@@ -0,0 +1,38 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+)
+
+// EventReload is posted by the Screen when the process receives a SIGHUP while
+// the terminal is still present, the conventional request for a daemon to
+// reload its configuration, see: Application.ReloadConfig
+type EventReload struct {
+	EventStamper
+
+	t time.Time
+}
+
+// When returns the time when this event was created.
+func (ev *EventReload) When() time.Time {
+	return ev.t
+}
+
+// NewEventReload creates an EventReload.
+func NewEventReload() *EventReload {
+	return &EventReload{t: time.Now()}
+}
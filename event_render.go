@@ -18,43 +18,90 @@ import (
 	"time"
 )
 
+// RenderRequest is the set of operations an EventRender asks of the Display
+type RenderRequest uint8
+
+const (
+	// RenderDraw draws the windows onto the Screen
+	RenderDraw RenderRequest = 1 << iota
+	// RenderShow outputs the changes to the Screen since the last show
+	RenderShow
+	// RenderSync invalidates the Screen and outputs everything, which
+	// supersedes RenderShow
+	RenderSync
+	// renderForce renders without waiting for the next frame, see:
+	// Display.ForceRepaint
+	renderForce
+)
+
+// Has returns true if all the operations of the other request are present
+func (r RenderRequest) Has(other RenderRequest) bool {
+	return r&other == other
+}
+
+// normalize drops a RenderShow superseded by a RenderSync
+func (r RenderRequest) normalize() RenderRequest {
+	if r.Has(RenderSync) {
+		r &^= RenderShow
+	}
+	return r
+}
+
 // EventRender is sent when the display needs to render the screen
 type EventRender struct {
-	when time.Time
-	draw bool
-	show bool
-	sync bool
+	EventStamper
+
+	when    time.Time
+	request RenderRequest
+}
+
+// NewEventRenderRequest creates an EventRender for the given operations, all
+// the other NewEvent* render constructors are shorthand for this one
+func NewEventRenderRequest(request RenderRequest) *EventRender {
+	return &EventRender{when: time.Now(), request: request.normalize()}
 }
 
+// NewEventRender creates an EventRender for the operations given as flags,
+// see: NewEventRenderRequest
 func NewEventRender(draw, show, sync bool) *EventRender {
-	return &EventRender{when: time.Now(), draw: draw, show: show, sync: sync}
+	var request RenderRequest
+	if draw {
+		request |= RenderDraw
+	}
+	if show {
+		request |= RenderShow
+	}
+	if sync {
+		request |= RenderSync
+	}
+	return NewEventRenderRequest(request)
 }
 
-// NewEventDraw creates an EventRender
+// NewEventDraw creates an EventRender configured to just draw the windows
 func NewEventDraw() *EventRender {
-	return &EventRender{when: time.Now(), draw: true, show: false, sync: false}
+	return NewEventRenderRequest(RenderDraw)
 }
 
 // NewEventShow creates an EventRender configured to just show the screen
 func NewEventShow() *EventRender {
-	return &EventRender{when: time.Now(), draw: false, show: true, sync: false}
+	return NewEventRenderRequest(RenderShow)
 }
 
 // NewEventSync creates an EventRender configured to just sync the screen
 func NewEventSync() *EventRender {
-	return &EventRender{when: time.Now(), draw: false, show: false, sync: true}
+	return NewEventRenderRequest(RenderSync)
 }
 
 // NewEventDrawAndShow creates an EventRender configured to also request a show
 // after the draw cycle completes
 func NewEventDrawAndShow() *EventRender {
-	return &EventRender{when: time.Now(), draw: true, show: true, sync: false}
+	return NewEventRenderRequest(RenderDraw | RenderShow)
 }
 
 // NewEventDrawAndSync creates an EventRender configured to also request a sync
 // after the draw cycle completes
 func NewEventDrawAndSync() *EventRender {
-	return &EventRender{when: time.Now(), draw: true, show: false, sync: true}
+	return NewEventRenderRequest(RenderDraw | RenderSync)
 }
 
 // When returns the time when the EventRender was created
@@ -62,20 +109,34 @@ func (ev *EventRender) When() time.Time {
 	return ev.when
 }
 
+// Request returns the operations requested
+func (ev *EventRender) Request() RenderRequest {
+	return ev.request
+}
+
+// Merge returns a new EventRender requesting the operations of both, other
+// may be nil
+func (ev *EventRender) Merge(other *EventRender) *EventRender {
+	if other == nil {
+		return ev
+	}
+	return NewEventRenderRequest(ev.request | other.request)
+}
+
 // Draw returns true when the EventRender was created with one of the
 // NewEventDraw*() functions
 func (ev *EventRender) Draw() bool {
-	return ev.draw
+	return ev.request.Has(RenderDraw)
 }
 
 // Show returns true when the EventRender was created with either of the
-// NewEventShow() or NewDrawAndShow() functions
+// NewEventShow() or NewDrawAndShow() functions, and not also a sync
 func (ev *EventRender) Show() bool {
-	return ev.show
+	return ev.request.Has(RenderShow)
 }
 
 // Sync returns true when the EventRender was created with either of the
 // NewEventSync() or NewDrawAndSync() functions
 func (ev *EventRender) Sync() bool {
-	return ev.sync
+	return ev.request.Has(RenderSync)
 }
@@ -19,11 +19,16 @@ import (
 	"time"
 )
 
-// EventResize is sent when the window size changes.
+// EventResize is sent when the window size changes. An initial EventResize,
+// with the actual size of the screen, is always sent after
+// SignalStartupComplete and before any other EventResize is processed.
 type EventResize struct {
-	t time.Time
-	w int
-	h int
+	EventStamper
+
+	t       time.Time
+	w       int
+	h       int
+	initial bool
 }
 
 // NewEventResize creates an EventResize with the new updated window size,
@@ -32,6 +37,11 @@ func NewEventResize(width, height int) *EventResize {
 	return &EventResize{t: time.Now(), w: width, h: height}
 }
 
+// newInitialEventResize creates the EventResize sent by StartupComplete.
+func newInitialEventResize(width, height int) *EventResize {
+	return &EventResize{t: time.Now(), w: width, h: height, initial: true}
+}
+
 // When returns the time when the Event was created.
 func (ev *EventResize) When() time.Time {
 	return ev.t
@@ -42,3 +52,8 @@ func (ev *EventResize) Size() (w, h int) {
 	w, h = ev.w, ev.h
 	return
 }
+
+// Initial returns true if this is the EventResize sent upon startup.
+func (ev *EventResize) Initial() bool {
+	return ev.initial
+}
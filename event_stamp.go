@@ -0,0 +1,75 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"time"
+)
+
+// EventStamp records the arrival of an Event at a Display. Events are created
+// by many sources (the terminal, GPM, timers, other goroutines) and their
+// When() times are not reliably ordered, while the Sequence of the EventStamp
+// always increases in the order the Display received them.
+type EventStamp struct {
+	// Sequence is the position of the event among all those received by the
+	// Display, starting from one. Events not yet received by a Display have a
+	// Sequence of zero.
+	Sequence uint64
+	// Arrived is when the Display received the event, including the monotonic
+	// clock reading of time.Now
+	Arrived time.Time
+	// Latency is the time between the event being created and its arrival,
+	// zero for events without a creation time
+	Latency time.Duration
+}
+
+// Valid returns true if the stamp was given by a Display
+func (s EventStamp) Valid() bool {
+	return s.Sequence > 0
+}
+
+// Before returns true if this stamp was given before the other
+func (s EventStamp) Before(other EventStamp) bool {
+	return s.Sequence < other.Sequence
+}
+
+// Since returns the monotonic time elapsed between the arrival of the other
+// stamp and this one
+func (s EventStamp) Since(other EventStamp) time.Duration {
+	return s.Arrived.Sub(other.Arrived)
+}
+
+func (s EventStamp) String() string {
+	return fmt.Sprintf("{seq=%d,latency=%v}", s.Sequence, s.Latency)
+}
+
+// EventStamper is embedded by Event implementations to hold the EventStamp
+// given by the Display receiving the event
+type EventStamper struct {
+	stamp EventStamp
+}
+
+// Stamp returns the EventStamp given to the event, which is not Valid until
+// the event has been received by a Display
+func (s *EventStamper) Stamp() EventStamp {
+	return s.stamp
+}
+
+// SetStamp replaces the EventStamp of the event, this is normally only done
+// by the Display receiving the event
+func (s *EventStamper) SetStamp(stamp EventStamp) {
+	s.stamp = stamp
+}
@@ -3,6 +3,7 @@ module github.com/go-curses/cdk
 go 1.21
 
 require (
+	github.com/BurntSushi/toml v1.3.2
 	github.com/atotto/clipboard v0.1.4
 	github.com/creack/pty v1.1.21
 	github.com/gdamore/encoding v1.0.0
@@ -25,6 +26,7 @@ require (
 	golang.org/x/sys v0.16.0
 	golang.org/x/term v0.16.0
 	golang.org/x/text v0.14.0
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
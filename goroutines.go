@@ -0,0 +1,241 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"runtime"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jtolio/gls"
+
+	"github.com/go-curses/cdk/lib/sync"
+	"github.com/go-curses/cdk/log"
+)
+
+var (
+	// GoroutineShutdownTimeout is how long a Display waits, when shutting
+	// down, for the goroutines started within its context to exit before
+	// reporting the remaining ones as leaked
+	GoroutineShutdownTimeout = time.Second
+)
+
+var goroutines = &cGoroutines{
+	items: make(map[uint64]*cGoroutine),
+}
+
+// Go starts the given function in a new goroutine which inherits the local
+// context of the caller. The goroutine is tracked using the name of the
+// calling function, see: GoNamed
+func Go(fn func()) {
+	name := "unknown"
+	if pc, _, _, ok := runtime.Caller(1); ok {
+		if details := runtime.FuncForPC(pc); details != nil {
+			name = details.Name()
+		}
+	}
+	GoNamed(name, fn)
+}
+
+// GoNamed starts the given function in a new goroutine which inherits the
+// local context of the caller. The goroutine is tracked with the given name,
+// and with the Display of the local context (if any), so that the Display can
+// wait for it to exit when shutting down. Goroutines still running after the
+// GoroutineShutdownTimeout are logged along with their stacks.
+func GoNamed(name string, fn func()) {
+	g := &cGoroutine{
+		name:    name,
+		started: time.Now(),
+		done:    make(chan struct{}),
+	}
+	if acd, err := GetLocalContext(); err == nil {
+		g.display = acd.Display
+	}
+	goroutines.add(g)
+	gls.Go(func() {
+		g.setGID(currentGoroutineID())
+		defer goroutines.remove(g)
+		fn()
+	})
+}
+
+// RunningGoroutines returns the names of all tracked goroutines which have not
+// yet exited, sorted by name.
+func RunningGoroutines() (names []string) {
+	for _, g := range goroutines.list(nil, false) {
+		names = append(names, g.name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// WaitGoroutines blocks until all tracked goroutines, other than the calling
+// one, have exited or the timeout is reached. The names of any goroutines
+// still running are returned, and are logged with their stacks.
+func WaitGoroutines(timeout time.Duration) (leaked []string) {
+	for _, g := range goroutines.wait(nil, false, timeout) {
+		leaked = append(leaked, g.name)
+	}
+	sort.Strings(leaked)
+	return
+}
+
+// waitGoroutines blocks until the goroutines started within the context of
+// this Display have exited, reporting any which remain after the
+// GoroutineShutdownTimeout
+func (d *CDisplay) waitGoroutines() {
+	if leaked := goroutines.wait(d, true, GoroutineShutdownTimeout); len(leaked) > 0 {
+		d.LogWarn("%d goroutine(s) still running after shutdown", len(leaked))
+	}
+}
+
+type cGoroutine struct {
+	seq     uint64
+	gid     uint64
+	name    string
+	display *CDisplay
+	started time.Time
+	done    chan struct{}
+
+	sync.RWMutex
+}
+
+func (g *cGoroutine) setGID(gid uint64) {
+	g.Lock()
+	g.gid = gid
+	g.Unlock()
+}
+
+func (g *cGoroutine) getGID() (gid uint64) {
+	g.RLock()
+	defer g.RUnlock()
+	return g.gid
+}
+
+type cGoroutines struct {
+	items map[uint64]*cGoroutine
+	last  uint64
+
+	sync.RWMutex
+}
+
+func (r *cGoroutines) add(g *cGoroutine) {
+	r.Lock()
+	defer r.Unlock()
+	r.last++
+	g.seq = r.last
+	r.items[g.seq] = g
+}
+
+func (r *cGoroutines) remove(g *cGoroutine) {
+	r.Lock()
+	defer r.Unlock()
+	delete(r.items, g.seq)
+	close(g.done)
+}
+
+// list returns the tracked goroutines, excluding the calling goroutine, which
+// belong to the given Display when filter is true
+func (r *cGoroutines) list(display *CDisplay, filter bool) (list []*cGoroutine) {
+	self := currentGoroutineID()
+	r.RLock()
+	defer r.RUnlock()
+	for _, g := range r.items {
+		if filter && g.display != display {
+			continue
+		}
+		if g.getGID() == self {
+			continue
+		}
+		list = append(list, g)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].seq < list[j].seq
+	})
+	return
+}
+
+func (r *cGoroutines) wait(display *CDisplay, filter bool, timeout time.Duration) (leaked []*cGoroutine) {
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+	expired := false
+	for _, g := range r.list(display, filter) {
+		if !expired {
+			select {
+			case <-g.done:
+				continue
+			case <-deadline.C:
+				expired = true
+			}
+		}
+		select {
+		case <-g.done:
+		default:
+			leaked = append(leaked, g)
+		}
+	}
+	if len(leaked) > 0 {
+		logGoroutineLeaks(leaked)
+	}
+	return
+}
+
+func logGoroutineLeaks(leaked []*cGoroutine) {
+	stacks := goroutineStacks()
+	for _, g := range leaked {
+		log.WarnF("goroutine leaked: %v (running for %v)\n%s", g.name, time.Since(g.started), stacks[g.getGID()])
+	}
+}
+
+// goroutineStacks returns the stack traces of all goroutines, indexed by the
+// runtime goroutine id
+func goroutineStacks() (stacks map[uint64][]byte) {
+	stacks = make(map[uint64][]byte)
+	for _, stack := range bytes.Split(allGoroutineStacks(), []byte("\n\n")) {
+		if gid := parseGoroutineID(stack); gid > 0 {
+			stacks[gid] = stack
+		}
+	}
+	return
+}
+
+// allGoroutineStacks returns the stack traces of all goroutines, as formatted
+// by runtime.Stack
+func allGoroutineStacks() (buf []byte) {
+	buf = make([]byte, 1<<16)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, len(buf)*2)
+	}
+}
+
+func currentGoroutineID() (gid uint64) {
+	buf := make([]byte, 64)
+	return parseGoroutineID(buf[:runtime.Stack(buf, false)])
+}
+
+// parseGoroutineID returns the id from a "goroutine 123 [running]:" header
+func parseGoroutineID(stack []byte) (gid uint64) {
+	stack = bytes.TrimPrefix(stack, []byte("goroutine "))
+	if idx := bytes.IndexByte(stack, ' '); idx > 0 {
+		gid, _ = strconv.ParseUint(string(stack[:idx]), 10, 64)
+	}
+	return
+}
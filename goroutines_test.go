@@ -0,0 +1,49 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestGoroutines(t *testing.T) {
+	Convey("Goroutine tracking", t, func() {
+		So(parseGoroutineID([]byte("goroutine 42 [running]:\nmain.main()")), ShouldEqual, 42)
+		So(parseGoroutineID([]byte("nope")), ShouldEqual, 0)
+		So(currentGoroutineID(), ShouldBeGreaterThan, 0)
+
+		release := make(chan bool)
+		started := make(chan bool)
+		GoNamed("test-blocked", func() {
+			started <- true
+			<-release
+		})
+		<-started
+		So(RunningGoroutines(), ShouldContain, "test-blocked")
+		So(WaitGoroutines(time.Millisecond*10), ShouldContain, "test-blocked")
+		close(release)
+		So(WaitGoroutines(time.Second), ShouldNotContain, "test-blocked")
+		So(RunningGoroutines(), ShouldNotContain, "test-blocked")
+
+		release = make(chan bool)
+		Go(func() { <-release })
+		So(RunningGoroutines(), ShouldContain, "github.com/go-curses/cdk.TestGoroutines.func1")
+		close(release)
+		So(WaitGoroutines(time.Second), ShouldNotContain, "github.com/go-curses/cdk.TestGoroutines.func1")
+	})
+}
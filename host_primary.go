@@ -0,0 +1,112 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// ErrNoHostPrimary is returned when none of the utilities used to access the
+// primary selection of the host are installed
+var ErrNoHostPrimary = errors.New("no primary selection utilities available, install xclip, xsel or wl-clipboard")
+
+// hostPrimaryCommand is a pair of command lines writing and reading the
+// primary selection of the host
+type hostPrimaryCommand struct {
+	copy  []string
+	paste []string
+}
+
+var (
+	wlPrimaryCommand = hostPrimaryCommand{
+		copy:  []string{"wl-copy", "--primary"},
+		paste: []string{"wl-paste", "--primary", "--no-newline"},
+	}
+	xclipPrimaryCommand = hostPrimaryCommand{
+		copy:  []string{"xclip", "-in", "-selection", "primary"},
+		paste: []string{"xclip", "-out", "-selection", "primary"},
+	}
+	xselPrimaryCommand = hostPrimaryCommand{
+		copy:  []string{"xsel", "--input", "--primary"},
+		paste: []string{"xsel", "--output", "--primary"},
+	}
+)
+
+// hostPrimaryCommands returns the commands to try, in order of preference,
+// wl-clipboard is only tried under Wayland
+func hostPrimaryCommands(wayland bool) (commands []hostPrimaryCommand) {
+	if wayland {
+		commands = append(commands, wlPrimaryCommand)
+	}
+	return append(commands, xclipPrimaryCommand, xselPrimaryCommand)
+}
+
+// findHostPrimaryCommand returns the first of the commands with both command
+// lines found by lookPath
+func findHostPrimaryCommand(commands []hostPrimaryCommand, lookPath func(file string) (string, error)) (command hostPrimaryCommand, err error) {
+	for _, command = range commands {
+		if _, err = lookPath(command.copy[0]); err != nil {
+			continue
+		}
+		if _, err = lookPath(command.paste[0]); err != nil {
+			continue
+		}
+		return command, nil
+	}
+	return hostPrimaryCommand{}, ErrNoHostPrimary
+}
+
+var (
+	hostPrimaryOnce    sync.Once
+	hostPrimaryFound   hostPrimaryCommand
+	hostPrimaryMissing error
+)
+
+// lookupHostPrimaryCommand returns the command to use, resolved only once
+func lookupHostPrimaryCommand() (command hostPrimaryCommand, err error) {
+	hostPrimaryOnce.Do(func() {
+		hostPrimaryFound, hostPrimaryMissing = findHostPrimaryCommand(hostPrimaryCommands(os.Getenv("WAYLAND_DISPLAY") != ""), exec.LookPath)
+	})
+	return hostPrimaryFound, hostPrimaryMissing
+}
+
+// writeHostPrimary sets the primary selection of the host to the text
+func writeHostPrimary(text string) (err error) {
+	var command hostPrimaryCommand
+	if command, err = lookupHostPrimaryCommand(); err != nil {
+		return
+	}
+	cmd := exec.Command(command.copy[0], command.copy[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	return cmd.Run()
+}
+
+// readHostPrimary returns the content of the primary selection of the host
+func readHostPrimary() (text string, err error) {
+	var command hostPrimaryCommand
+	if command, err = lookupHostPrimaryCommand(); err != nil {
+		return
+	}
+	var out []byte
+	if out, err = exec.Command(command.paste[0], command.paste[1:]...).Output(); err != nil {
+		return
+	}
+	return string(out), nil
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"golang.org/x/text/language"
+)
+
+var (
+	// DefaultLocale is used when no locale is configured in the environment
+	DefaultLocale = language.English
+
+	// LocaleEnvironmentKeys are checked, in order, for the locale of a Display
+	LocaleEnvironmentKeys = []string{"LC_ALL", "LC_MESSAGES", "LANG"}
+)
+
+var (
+	catalogs     = make(map[language.Tag]map[string]string)
+	catalogsTags = make([]language.Tag, 0)
+	catalogsLock = &sync.RWMutex{}
+)
+
+// ParseLocale parses POSIX locale values, such as "de_DE.UTF-8" or
+// "sr_RS@latin", as well as BCP 47 language tags. The "C" and "POSIX" locales
+// are reported as the DefaultLocale.
+func ParseLocale(value string) (tag language.Tag, err error) {
+	if idx := strings.IndexAny(value, ".@"); idx > -1 {
+		value = value[:idx]
+	}
+	switch value {
+	case "":
+		err = fmt.Errorf("empty locale value")
+		return
+	case "C", "POSIX":
+		return DefaultLocale, nil
+	}
+	return language.Parse(strings.ReplaceAll(value, "_", "-"))
+}
+
+// LocaleFromEnv returns the locale described by the first valid value of the
+// LocaleEnvironmentKeys found with the given lookup function
+func LocaleFromEnv(lookup func(key string) (value string, ok bool)) (tag language.Tag, ok bool) {
+	for _, key := range LocaleEnvironmentKeys {
+		if value, found := lookup(key); found {
+			if t, err := ParseLocale(value); err == nil {
+				return t, true
+			}
+		}
+	}
+	return DefaultLocale, false
+}
+
+// AddTranslations registers the given message translations for the locale,
+// merging with any translations previously added for the same locale
+func AddTranslations(locale language.Tag, messages map[string]string) {
+	catalogsLock.Lock()
+	defer catalogsLock.Unlock()
+	if _, ok := catalogs[locale]; !ok {
+		catalogs[locale] = make(map[string]string)
+		catalogsTags = append(catalogsTags, locale)
+	}
+	for msgid, msgstr := range messages {
+		catalogs[locale][msgid] = msgstr
+	}
+}
+
+// Translate returns the translation of msgid for the registered locale best
+// matching the one given, or msgid itself if there is no such translation
+func Translate(locale language.Tag, msgid string) (msgstr string) {
+	catalogsLock.RLock()
+	defer catalogsLock.RUnlock()
+	if len(catalogsTags) == 0 {
+		return msgid
+	}
+	matcher := language.NewMatcher(catalogsTags)
+	if _, idx, confidence := matcher.Match(locale); confidence != language.No {
+		if value, ok := catalogs[catalogsTags[idx]][msgid]; ok {
+			return value
+		}
+	}
+	return msgid
+}
+
+// Tr translates msgid using the locale of the Display in the current local
+// context, formatting the result with any arguments given
+func Tr(msgid string, argv ...interface{}) (msgstr string) {
+	locale := DefaultLocale
+	if acd, err := GetLocalContext(); err == nil && acd.Display != nil {
+		locale = acd.Display.GetLocale()
+	} else if tag, ok := LocaleFromEnv(os.LookupEnv); ok {
+		locale = tag
+	}
+	if msgstr = Translate(locale, msgid); len(argv) > 0 {
+		msgstr = fmt.Sprintf(msgstr, argv...)
+	}
+	return
+}
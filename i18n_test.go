@@ -0,0 +1,69 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	"golang.org/x/text/language"
+
+	"github.com/go-curses/cdk/lib/enums"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestI18n(t *testing.T) {
+	Convey("Parsing locales", t, func() {
+		tag, err := ParseLocale("de_DE.UTF-8")
+		So(err, ShouldBeNil)
+		So(tag, ShouldEqual, language.MustParse("de-DE"))
+		tag, err = ParseLocale("sr_RS@latin")
+		So(err, ShouldBeNil)
+		So(tag, ShouldEqual, language.MustParse("sr-RS"))
+		tag, err = ParseLocale("C")
+		So(err, ShouldBeNil)
+		So(tag, ShouldEqual, DefaultLocale)
+		_, err = ParseLocale("")
+		So(err, ShouldNotBeNil)
+		environ := map[string]string{"LANG": "fr_FR.UTF-8", "LC_MESSAGES": "es_ES"}
+		tag, ok := LocaleFromEnv(func(key string) (value string, ok bool) {
+			value, ok = environ[key]
+			return
+		})
+		So(ok, ShouldBeTrue)
+		So(tag, ShouldEqual, language.MustParse("es-ES"))
+	})
+	Convey("Translating messages", t, func() {
+		AddTranslations(language.German, map[string]string{"Hello": "Hallo"})
+		AddTranslations(language.French, map[string]string{"Hello": "Bonjour"})
+		So(Translate(language.MustParse("de-AT"), "Hello"), ShouldEqual, "Hallo")
+		So(Translate(language.French, "Hello"), ShouldEqual, "Bonjour")
+		So(Translate(language.French, "Goodbye"), ShouldEqual, "Goodbye")
+		So(Translate(language.Japanese, "Hello"), ShouldEqual, "Hello")
+	})
+	Convey("Per-display environment and locale", t, func() {
+		d := NewDisplay("testing", OffscreenTtyPath)
+		changed := 0
+		d.Connect(SignalSetLocale, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			changed++
+			return enums.EVENT_PASS
+		})
+		d.Setenv("CDK_TESTING", "yes")
+		So(d.Getenv("CDK_TESTING"), ShouldEqual, "yes")
+		So(d.Environ(), ShouldResemble, []string{"CDK_TESTING=yes"})
+		d.Setenv("LC_ALL", "de_DE.UTF-8")
+		So(changed, ShouldEqual, 1)
+		So(d.GetLocale(), ShouldEqual, language.MustParse("de-DE"))
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package id
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestId(t *testing.T) {
+	Convey("Identifiers", t, func() {
+		Convey("random by default", func() {
+			So(IsDeterministic(), ShouldBeFalse)
+			a, b := NewUUID(), NewUUID()
+			So(a, ShouldNotEqual, uuid.Nil)
+			So(a, ShouldNotEqual, b)
+			So(a.Version(), ShouldEqual, uuid.V4)
+		})
+		Convey("deterministic mode", func() {
+			SetDeterministic(42)
+			defer SetRandom()
+			So(IsDeterministic(), ShouldBeTrue)
+			first := []interface{}{NewUUID(), NewUUID(), Int63(), Intn(100)}
+			So(first[0], ShouldNotEqual, first[1])
+			So(first[0].(uuid.UUID).Version(), ShouldEqual, uuid.V4)
+			So(first[0].(uuid.UUID).Variant(), ShouldEqual, uuid.VariantRFC4122)
+			So(first[0].(uuid.UUID).String(), ShouldEqual, "00000000-0000-402a-8000-000000000001")
+			SetDeterministic(42)
+			second := []interface{}{NewUUID(), NewUUID(), Int63(), Intn(100)}
+			So(second, ShouldResemble, first)
+			SetDeterministic(7)
+			So(NewUUID(), ShouldNotEqual, first[0])
+		})
+	})
+}
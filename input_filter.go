@@ -0,0 +1,221 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"regexp"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	TypeInputFilterChain CTypeTag = "cdk-input-filter-chain"
+	SignalInputRejected  Signal   = "input-rejected"
+)
+
+func init() {
+	_ = TypesManager.AddType(TypeInputFilterChain, nil)
+}
+
+// InputFilter validates and optionally transforms text input. The current
+// argument is the existing value being edited and input is the text being
+// inserted. Filters return the (possibly transformed) input to insert or an
+// error describing why the input was rejected.
+type InputFilter interface {
+	Filter(current, input string) (output string, err error)
+}
+
+// InputFilterFn is an adapter allowing the use of ordinary functions as an
+// InputFilter
+type InputFilterFn func(current, input string) (output string, err error)
+
+func (fn InputFilterFn) Filter(current, input string) (output string, err error) {
+	return fn(current, input)
+}
+
+// NewMaxLengthInputFilter rejects input which would make the value longer
+// than the given number of runes
+func NewMaxLengthInputFilter(max int) InputFilter {
+	return InputFilterFn(func(current, input string) (output string, err error) {
+		if utf8.RuneCountInString(current)+utf8.RuneCountInString(input) > max {
+			err = fmt.Errorf("input exceeds maximum length of %d", max)
+			return
+		}
+		return input, nil
+	})
+}
+
+// NewNumericInputFilter rejects input containing anything other than decimal
+// digits
+func NewNumericInputFilter() InputFilter {
+	return InputFilterFn(func(current, input string) (output string, err error) {
+		for _, r := range input {
+			if !unicode.IsDigit(r) {
+				err = fmt.Errorf("input is not numeric: %q", r)
+				return
+			}
+		}
+		return input, nil
+	})
+}
+
+// NewRegexpMaskInputFilter rejects input which would result in a value that
+// does not match the given regular expression. As the value is checked with
+// each insertion, the expression must also accept partially entered values.
+func NewRegexpMaskInputFilter(rx *regexp.Regexp) InputFilter {
+	return InputFilterFn(func(current, input string) (output string, err error) {
+		if !rx.MatchString(current + input) {
+			err = fmt.Errorf("input does not match mask %q: %q", rx.String(), current+input)
+			return
+		}
+		return input, nil
+	})
+}
+
+// NewTransformInputFilter passes each rune of the input through the given
+// function, for example unicode.ToUpper
+func NewTransformInputFilter(fn func(r rune) rune) InputFilter {
+	return InputFilterFn(func(current, input string) (output string, err error) {
+		var runes []rune
+		for _, r := range input {
+			runes = append(runes, fn(r))
+		}
+		return string(runes), nil
+	})
+}
+
+// InputFilterChain Hierarchy:
+//
+//	Object
+//	  +- InputFilterChain
+//
+// An InputFilterChain applies a series of InputFilter instances, in the order
+// they were added, with the output of each filter being the input of the next.
+// When any filter rejects the input, SignalInputRejected is emitted with the
+// chain, the current value, the input and the error as arguments.
+//
+// Each Window has an InputFilterChain which the Display applies to all rune
+// EventKey events before they are delivered to the Window. As there is no
+// current value for raw key streams, the current value is always empty.
+type InputFilterChain interface {
+	Object
+
+	AddFilter(filter InputFilter)
+	ClearFilters()
+	HasFilters() (has bool)
+	Filter(current, input string) (output string, ok bool)
+	FilterEventKey(current string, evt *EventKey) (filtered *EventKey, ok bool)
+}
+
+var _ InputFilterChain = (*CInputFilterChain)(nil)
+
+type CInputFilterChain struct {
+	CObject
+
+	filters []InputFilter
+}
+
+func NewInputFilterChain(filters ...InputFilter) InputFilterChain {
+	ifc := new(CInputFilterChain)
+	ifc.Init()
+	ifc.filters = append(ifc.filters, filters...)
+	return ifc
+}
+
+func (ifc *CInputFilterChain) Init() (already bool) {
+	if ifc.InitTypeItem(TypeInputFilterChain, ifc) {
+		return true
+	}
+	ifc.CObject.Init()
+	ifc.filters = make([]InputFilter, 0)
+	return false
+}
+
+func (ifc *CInputFilterChain) AddFilter(filter InputFilter) {
+	ifc.Lock()
+	defer ifc.Unlock()
+	ifc.filters = append(ifc.filters, filter)
+}
+
+func (ifc *CInputFilterChain) ClearFilters() {
+	ifc.Lock()
+	defer ifc.Unlock()
+	ifc.filters = make([]InputFilter, 0)
+}
+
+func (ifc *CInputFilterChain) HasFilters() (has bool) {
+	ifc.RLock()
+	defer ifc.RUnlock()
+	return len(ifc.filters) > 0
+}
+
+// Filter applies all filters to the given input, returning the transformed
+// output and true if accepted. If rejected, SignalInputRejected is emitted and
+// false is returned.
+func (ifc *CInputFilterChain) Filter(current, input string) (output string, ok bool) {
+	ifc.RLock()
+	filters := make([]InputFilter, len(ifc.filters))
+	copy(filters, ifc.filters)
+	ifc.RUnlock()
+	output = input
+	for _, filter := range filters {
+		var err error
+		if output, err = filter.Filter(current, output); err != nil {
+			ifc.LogTrace("input rejected: %v", err)
+			ifc.Emit(SignalInputRejected, ifc, current, input, err)
+			return "", false
+		}
+	}
+	return output, true
+}
+
+// FilterEventKey applies all filters to the rune of the given EventKey. Keys
+// other than KeyRune, and runes with Ctrl, Alt or Meta modifiers, are always
+// accepted unchanged. If a filter transforms the rune, a new EventKey is
+// returned. Filters which expand or remove the rune cause the event to be
+// rejected.
+func (ifc *CInputFilterChain) FilterEventKey(current string, evt *EventKey) (filtered *EventKey, ok bool) {
+	if evt.Key() != KeyRune || evt.Modifiers().Has(ModCtrl|ModAlt|ModMeta) {
+		return evt, true
+	}
+	output, accepted := ifc.Filter(current, string(evt.Rune()))
+	if !accepted {
+		return nil, false
+	}
+	if utf8.RuneCountInString(output) != 1 {
+		ifc.Emit(SignalInputRejected, ifc, current, string(evt.Rune()), fmt.Errorf("transformed input is not a single rune: %q", output))
+		return nil, false
+	}
+	if r, _ := utf8.DecodeRuneInString(output); r != evt.Rune() {
+		return &EventKey{t: evt.When(), key: KeyRune, ch: r, mod: evt.Modifiers()}, true
+	}
+	return evt, true
+}
+
+// ArgvSignalInputRejected is a convenience function for unpacking the arguments of
+// SignalInputRejected listeners
+func ArgvSignalInputRejected(argv ...interface{}) (chain InputFilterChain, current, input string, err error, ok bool) {
+	if len(argv) == 4 {
+		if chain, ok = argv[0].(InputFilterChain); ok {
+			if current, ok = argv[1].(string); ok {
+				if input, ok = argv[2].(string); ok {
+					err, ok = argv[3].(error)
+				}
+			}
+		}
+	}
+	return
+}
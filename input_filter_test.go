@@ -0,0 +1,64 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"regexp"
+	"testing"
+	"unicode"
+
+	"github.com/go-curses/cdk/lib/enums"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInputFilter(t *testing.T) {
+	Convey("Input filter chains", t, func() {
+		rejected := 0
+		ifc := NewInputFilterChain(NewMaxLengthInputFilter(4), NewNumericInputFilter())
+		ifc.Connect(SignalInputRejected, "test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			_, _, _, err, ok := ArgvSignalInputRejected(argv...)
+			So(ok, ShouldBeTrue)
+			So(err, ShouldNotBeNil)
+			rejected++
+			return enums.EVENT_PASS
+		})
+		output, ok := ifc.Filter("12", "34")
+		So(ok, ShouldBeTrue)
+		So(output, ShouldEqual, "34")
+		_, ok = ifc.Filter("123", "45")
+		So(ok, ShouldBeFalse)
+		_, ok = ifc.Filter("", "a")
+		So(ok, ShouldBeFalse)
+		So(rejected, ShouldEqual, 2)
+		ifc.ClearFilters()
+		So(ifc.HasFilters(), ShouldBeFalse)
+		ifc.AddFilter(NewTransformInputFilter(unicode.ToUpper))
+		ifc.AddFilter(NewRegexpMaskInputFilter(regexp.MustCompile(`^[A-F]*$`)))
+		output, ok = ifc.Filter("AB", "cd")
+		So(ok, ShouldBeTrue)
+		So(output, ShouldEqual, "CD")
+		_, ok = ifc.Filter("AB", "x")
+		So(ok, ShouldBeFalse)
+		evt, ok := ifc.FilterEventKey("", NewEventKey(KeyRune, 'a', ModNone))
+		So(ok, ShouldBeTrue)
+		So(evt.Rune(), ShouldEqual, 'A')
+		evt, ok = ifc.FilterEventKey("", NewEventKey(KeyRune, 'z', ModNone))
+		So(ok, ShouldBeFalse)
+		So(evt, ShouldBeNil)
+		evt, ok = ifc.FilterEventKey("", NewEventKey(KeyF1, 0, ModNone))
+		So(ok, ShouldBeTrue)
+		So(evt.Key(), ShouldEqual, KeyF1)
+	})
+}
@@ -547,24 +547,21 @@ func ParseKeyMods(input string) (key Key, mods ModMask, err error) {
 		remainder := strings.TrimSpace(match[0])
 		if rxParseMods.MatchString(match[0]) {
 			remainder = rxParseMods.ReplaceAllString(remainder, "")
-			matches := rxParseMods.FindAllStringSubmatch(match[0], -1)
-			if len(matches) == 1 {
-				for i := 1; i < len(matches[0]); i++ {
-					switch strings.ToLower(matches[0][i]) {
-					case "control", "ctrl", "ctl":
-						mods |= ModCtrl
-					case "alternate", "alt":
-						mods |= ModAlt
-					case "meta":
-						mods |= ModMeta
-					case "shift":
-						mods |= ModShift
-					default:
-						key = KeyNUL
-						mods = ModNone
-						err = fmt.Errorf("error parsing modifier: %q", matches[0][i])
-						return
-					}
+			for _, matched := range rxParseMods.FindAllStringSubmatch(match[0], -1) {
+				switch strings.ToLower(matched[1]) {
+				case "control", "ctrl", "ctl":
+					mods |= ModCtrl
+				case "alternate", "alt":
+					mods |= ModAlt
+				case "meta":
+					mods |= ModMeta
+				case "shift":
+					mods |= ModShift
+				default:
+					key = KeyNUL
+					mods = ModNone
+					err = fmt.Errorf("error parsing modifier: %q", matched[1])
+					return
 				}
 			}
 		}
@@ -586,3 +583,68 @@ func ParseKeyMods(input string) (key Key, mods ModMask, err error) {
 	err = fmt.Errorf("error parsing string: %q", input)
 	return
 }
+
+// KeyStroke is a single Key press combined with the ModMask modifiers held at
+// the time of the press
+type KeyStroke struct {
+	Key  Key
+	Mods ModMask
+}
+
+// MakeKeyStroke returns the KeyStroke described by the given EventKey. Rune
+// events are translated to their ASCII Key equivalents so that a KeyStroke
+// parsed from a string can be compared to one made from an actual event.
+func MakeKeyStroke(evt *EventKey) (stroke KeyStroke) {
+	stroke.Key = evt.Key()
+	if stroke.Key == KeyRune {
+		stroke.Key = LookupKeyRune(evt.Rune())
+	}
+	stroke.Mods = evt.Modifiers()
+	return
+}
+
+// Equals returns true if the given KeyStroke has the same Key and ModMask
+func (s KeyStroke) Equals(other KeyStroke) bool {
+	return s.Key == other.Key && s.Mods == other.Mods
+}
+
+func (s KeyStroke) String() string {
+	if name, ok := KeyNames[s.Key]; ok {
+		return s.Mods.String() + name
+	}
+	return s.Mods.String() + string(rune(s.Key))
+}
+
+var rxParseKeySequence = regexp.MustCompile(`(?:<[a-zA-Z][a-zA-Z\d]+>\s*)*(?:[fF]\d{1,2}|[a-zA-Z\d])`)
+
+// ParseKeySequence parses the input string as a whitespace separated list of
+// keys with modifiers (ie: "<Ctrl>x <Ctrl>s"), returning a KeyStroke for each
+// of the keys. Each of the keys are parsed with ParseKeyMods.
+func ParseKeySequence(input string) (sequence []KeyStroke, err error) {
+	matches := rxParseKeySequence.FindAllStringIndex(input, -1)
+	if len(matches) == 0 {
+		err = fmt.Errorf("error parsing key sequence: %q", input)
+		return
+	}
+	last := 0
+	for idx, match := range matches {
+		between := input[last:match[0]]
+		if strings.TrimSpace(between) != "" || (idx > 0 && between == "") {
+			sequence = nil
+			err = fmt.Errorf("error parsing key sequence: %q", input)
+			return
+		}
+		var stroke KeyStroke
+		if stroke.Key, stroke.Mods, err = ParseKeyMods(input[match[0]:match[1]]); err != nil {
+			sequence = nil
+			return
+		}
+		sequence = append(sequence, stroke)
+		last = match[1]
+	}
+	if strings.TrimSpace(input[last:]) != "" {
+		sequence = nil
+		err = fmt.Errorf("error parsing key sequence: %q", input)
+	}
+	return
+}
@@ -19,12 +19,10 @@ import (
 	"fmt"
 	"os"
 	"os/signal"
-	"syscall"
 	"time"
 
 	"github.com/creack/pty"
 	"github.com/go-curses/cdk/log"
-	cterm "github.com/go-curses/term"
 	"golang.org/x/term"
 )
 
@@ -40,16 +38,19 @@ func CallWithTty(callTty *os.File, fn Callback) (err error) {
 		return fmt.Errorf("pty.Open error: %v", err)
 	}
 
+	inheritSize := func() {
+		if err := pty.InheritSize(callTty, ptmx); err != nil {
+			log.ErrorF("error propagating resize %v->%v: %s", callTty.Name(), ptmx.Name(), err)
+		}
+	}
 	resize := make(chan os.Signal, 1)
-	signal.Notify(resize, syscall.SIGWINCH)
+	notifyResize(resize)
 	Go(func() {
 		for range resize {
-			if err := pty.InheritSize(callTty, ptmx); err != nil {
-				log.ErrorF("error propagating resize %v->%v: %s", callTty.Name(), ptmx.Name(), err)
-			}
+			inheritSize()
 		}
 	})
-	resize <- syscall.SIGWINCH
+	inheritSize()
 
 	var oldState *term.State
 	if oldState, err = term.MakeRaw(int(callTty.Fd())); err != nil {
@@ -80,7 +81,7 @@ func CallWithTty(callTty *os.File, fn Callback) (err error) {
 	}
 
 	log.DebugF("sending Tiocsti: %v", callTty.Fd())
-	if e := cterm.Tiocsti(callTty.Fd(), " "); e != nil {
+	if e := tiocsti(callTty.Fd(), " "); e != nil {
 		log.ErrorF("cterm.Tiocsti error: %v", e)
 	}
 
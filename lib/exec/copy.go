@@ -21,7 +21,6 @@ import (
 	"time"
 
 	"github.com/go-curses/cdk/log"
-	cterm "github.com/go-curses/term"
 )
 
 func CopyWithCancel(tag string, src, dst *os.File) (cancel context.CancelFunc, err error) {
@@ -32,7 +31,7 @@ func CopyWithCancel(tag string, src, dst *os.File) (cancel context.CancelFunc, e
 		if waiting {
 			time.Sleep(time.Millisecond * 10)
 			log.DebugF("sending Tiocsti to: %v", src.Name())
-			if err := cterm.Tiocsti(src.Fd(), " "); err != nil {
+			if err := tiocsti(src.Fd(), " "); err != nil {
 				log.Error(err)
 				log.DebugF("[trying again] writing Tiocsti: %v", src.Name())
 				if _, err := src.Write([]byte(" ")); err != nil {
@@ -0,0 +1,32 @@
+//go:build !unix
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"errors"
+	"os"
+)
+
+var errNoTiocsti = errors.New("terminal input injection not supported")
+
+// notifyResize does nothing as there is no resize signal on this platform
+func notifyResize(_ chan<- os.Signal) {}
+
+// tiocsti is not supported, callers fall back to writing the input
+func tiocsti(_ uintptr, _ string) error {
+	return errNoTiocsti
+}
@@ -0,0 +1,35 @@
+//go:build unix
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package exec
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	cterm "github.com/go-curses/term"
+)
+
+// notifyResize relays the terminal resize signal to the channel
+func notifyResize(c chan<- os.Signal) {
+	signal.Notify(c, syscall.SIGWINCH)
+}
+
+// tiocsti injects the input into the terminal, unblocking pending reads
+func tiocsti(fd uintptr, input string) error {
+	return cterm.Tiocsti(fd, input)
+}
@@ -30,10 +30,11 @@ const (
 	AttrItalic
 	AttrStrike
 	AttrInvalid              // Mark the style or attributes invalid
+	AttrCursor               // Mark the cell as a cursor, drawn as the Screen draws its cursor
 	AttrNone    AttrMask = 0 // Just normal text.
 )
 
-const attrAll = AttrBlink | AttrBold | AttrReverse | AttrUnderline | AttrDim | AttrItalic | AttrStrike
+const attrAll = AttrBlink | AttrBold | AttrReverse | AttrUnderline | AttrDim | AttrItalic | AttrStrike | AttrCursor
 
 // check if the attributes are normal
 func (m AttrMask) IsNormal() bool {
@@ -75,6 +76,11 @@ func (m AttrMask) IsStrike() bool {
 	return m&AttrStrike != 0
 }
 
+// check if the attributes include cursor
+func (m AttrMask) IsCursor() bool {
+	return m&AttrCursor != 0
+}
+
 // return a normal attribute mask
 func (m AttrMask) Normal() AttrMask {
 	return m &^ attrAll
@@ -135,3 +141,11 @@ func (m AttrMask) Strike(v bool) AttrMask {
 	}
 	return m &^ AttrStrike
 }
+
+// return the attributes with (true) or without (false) cursor
+func (m AttrMask) Cursor(v bool) AttrMask {
+	if v {
+		return m | AttrCursor
+	}
+	return m &^ AttrCursor
+}
@@ -45,6 +45,11 @@ func TestAttr(t *testing.T) {
 		So(am.IsUnderline(), ShouldEqual, false)
 		am = am.Underline(true)
 		So(am.IsUnderline(), ShouldEqual, true)
+		// cursor
+		So(am.IsCursor(), ShouldEqual, false)
+		am = am.Cursor(true)
+		So(am.IsCursor(), ShouldEqual, true)
+		So(am.Normal().IsCursor(), ShouldEqual, false)
 	})
 	Convey("AttrMask modifiers", t, func() {
 		var am AttrMask
@@ -27,11 +27,17 @@ type BorderRuneSet struct {
 	BottomLeft  rune
 	Bottom      rune
 	BottomRight rune
+	// the joints used where lines meet, a zero rune set cannot be joined
+	TopTee    rune
+	LeftTee   rune
+	RightTee  rune
+	BottomTee rune
+	Cross     rune
 }
 
 func (b BorderRuneSet) String() string {
 	return fmt.Sprintf(
-		"{BorderRunes=%v,%v,%v,%v,%v,%v,%v,%v}",
+		"{BorderRunes=%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v,%v}",
 		b.TopRight,
 		b.Top,
 		b.TopLeft,
@@ -40,5 +46,110 @@ func (b BorderRuneSet) String() string {
 		b.Bottom,
 		b.BottomRight,
 		b.Right,
+		b.TopTee,
+		b.LeftTee,
+		b.RightTee,
+		b.BottomTee,
+		b.Cross,
 	)
 }
+
+// BorderJoint is the set of directions a line drawing rune extends in
+type BorderJoint uint8
+
+const (
+	BorderJointUp BorderJoint = 1 << iota
+	BorderJointDown
+	BorderJointLeft
+	BorderJointRight
+)
+
+// CanJoin returns true if the set includes the tee and cross runes needed to
+// join lines together
+func (b BorderRuneSet) CanJoin() bool {
+	return b.TopTee != 0 && b.LeftTee != 0 && b.RightTee != 0 && b.BottomTee != 0 && b.Cross != 0
+}
+
+// Joints returns the directions the given rune extends in, ok is false if the
+// rune is not one of the line drawing runes of the set
+func (b BorderRuneSet) Joints(r rune) (joints BorderJoint, ok bool) {
+	if r == 0 || r == ' ' {
+		return 0, false
+	}
+	ok = true
+	switch r {
+	case b.Top, b.Bottom:
+		joints = BorderJointLeft | BorderJointRight
+	case b.Left, b.Right:
+		joints = BorderJointUp | BorderJointDown
+	case b.TopLeft:
+		joints = BorderJointDown | BorderJointRight
+	case b.TopRight:
+		joints = BorderJointDown | BorderJointLeft
+	case b.BottomLeft:
+		joints = BorderJointUp | BorderJointRight
+	case b.BottomRight:
+		joints = BorderJointUp | BorderJointLeft
+	case b.TopTee:
+		joints = BorderJointDown | BorderJointLeft | BorderJointRight
+	case b.BottomTee:
+		joints = BorderJointUp | BorderJointLeft | BorderJointRight
+	case b.LeftTee:
+		joints = BorderJointUp | BorderJointDown | BorderJointRight
+	case b.RightTee:
+		joints = BorderJointUp | BorderJointDown | BorderJointLeft
+	case b.Cross:
+		joints = BorderJointUp | BorderJointDown | BorderJointLeft | BorderJointRight
+	default:
+		ok = false
+	}
+	return
+}
+
+// JointRune returns the rune of the set which extends in exactly the given
+// directions, ok is false if there is no such rune
+func (b BorderRuneSet) JointRune(joints BorderJoint) (r rune, ok bool) {
+	switch joints {
+	case BorderJointLeft | BorderJointRight:
+		r = b.Top
+	case BorderJointUp | BorderJointDown:
+		r = b.Left
+	case BorderJointDown | BorderJointRight:
+		r = b.TopLeft
+	case BorderJointDown | BorderJointLeft:
+		r = b.TopRight
+	case BorderJointUp | BorderJointRight:
+		r = b.BottomLeft
+	case BorderJointUp | BorderJointLeft:
+		r = b.BottomRight
+	case BorderJointDown | BorderJointLeft | BorderJointRight:
+		r = b.TopTee
+	case BorderJointUp | BorderJointLeft | BorderJointRight:
+		r = b.BottomTee
+	case BorderJointUp | BorderJointDown | BorderJointRight:
+		r = b.LeftTee
+	case BorderJointUp | BorderJointDown | BorderJointLeft:
+		r = b.RightTee
+	case BorderJointUp | BorderJointDown | BorderJointLeft | BorderJointRight:
+		r = b.Cross
+	}
+	return r, r != 0
+}
+
+// Join returns the rune to draw when the line drawing rune r is drawn over the
+// existing rune. Where both are runes of the set, the result extends in all the
+// directions of both, such as a tee where a corner meets a line. Otherwise, or
+// if the set cannot join lines, r is returned.
+func (b BorderRuneSet) Join(existing, r rune) rune {
+	if !b.CanJoin() {
+		return r
+	}
+	if have, ok := b.Joints(existing); ok {
+		if want, ok := b.Joints(r); ok {
+			if joined, ok := b.JointRune(have | want); ok {
+				return joined
+			}
+		}
+	}
+	return r
+}
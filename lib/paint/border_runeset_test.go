@@ -0,0 +1,51 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paint
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestBorderRuneSetJoin(t *testing.T) {
+	Convey("Joining border runes", t, func() {
+		stock, _ := GetDefaultBorderRunes(StockBorder)
+		So(stock.CanJoin(), ShouldBeTrue)
+		joints, ok := stock.Joints(RuneULCorner)
+		So(ok, ShouldBeTrue)
+		So(joints, ShouldEqual, BorderJointDown|BorderJointRight)
+		_, ok = stock.Joints('x')
+		So(ok, ShouldBeFalse)
+		So(stock.Join(RuneURCorner, RuneULCorner), ShouldEqual, RuneTTee)
+		So(stock.Join(RuneLRCorner, RuneLLCorner), ShouldEqual, RuneBTee)
+		So(stock.Join(RuneVLine, RuneHLine), ShouldEqual, RunePlus)
+		So(stock.Join(RuneVLine, RuneULCorner), ShouldEqual, RuneLTee)
+		So(stock.Join(RuneLTee, RuneRTee), ShouldEqual, RunePlus)
+		So(stock.Join(RuneVLine, RuneVLine), ShouldEqual, RuneVLine)
+		// not part of the set, the new rune replaces it
+		So(stock.Join('x', RuneHLine), ShouldEqual, RuneHLine)
+		double, _ := GetDefaultBorderRunes(DoubleBorder)
+		So(stock.Join(double.Left, RuneHLine), ShouldEqual, RuneHLine)
+		So(double.Join(double.Left, double.Top), ShouldEqual, RuneBoxDrawingsDoubleVerticalAndHorizontal)
+		// sets without the joints are never joined
+		empty, _ := GetDefaultBorderRunes(EmptyBorder)
+		So(empty.CanJoin(), ShouldBeFalse)
+		So(empty.Join(' ', ' '), ShouldEqual, ' ')
+		plain := stock
+		plain.Cross = 0
+		So(plain.Join(RuneVLine, RuneHLine), ShouldEqual, RuneHLine)
+	})
+}
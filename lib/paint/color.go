@@ -17,7 +17,9 @@ package paint
 
 import (
 	"fmt"
+	"math"
 	"strconv"
+	"strings"
 )
 
 // Color represents a color.  The low numeric values are the same as used
@@ -990,6 +992,8 @@ var ColorNames = map[string]Color{
 	"lightgrey":            ColorLightGray,
 	"lightslategrey":       ColorLightSlateGray,
 	"slategrey":            ColorSlateGray,
+	"cyan":                 ColorAqua,
+	"magenta":              ColorFuchsia,
 }
 
 // Valid indicates the color is a valid value (has been set).
@@ -1069,34 +1073,138 @@ func NewHexColor(v int32) Color {
 }
 
 // GetColor creates a Color from a color name (W3C name). A hex value may
-// be supplied as a string in the format "#ffffff".
+// be supplied as a string in the format "#ffffff". See ParseColor for all of
+// the forms accepted, ColorDefault is returned for unknown colors.
 func GetColor(name string) Color {
-	if c, ok := ColorNames[name]; ok {
-		return c
-	}
-	if len(name) == 7 && name[0] == '#' {
-		if v, e := strconv.ParseInt(name[1:], 16, 32); e == nil {
-			return NewHexColor(int32(v))
-		}
-	}
-	return ColorDefault
+	c, _ := ParseColor(name)
+	return c
 }
 
-// GetColor creates a Color from a color name (W3C name). A hex value may
-// be supplied as a string in the format "#ffffff".
+// ParseColor creates a Color from a CSS color string, returning false if the
+// string is not understood. The accepted forms are the CSS named colors, hex
+// values as "#rgb" or "#rrggbb", "rgb(r, g, b)" with each component either
+// 0-255 or a percentage and "hsl(h, s%, l%)" with the hue in degrees.
+// Components may also be separated by spaces and the "rgba" and "hsla" forms
+// are accepted, any alpha value given is ignored.
 func ParseColor(name string) (c Color, ok bool) {
 	if c, ok := ColorNames[name]; ok {
 		return c, true
 	}
-	if len(name) == 7 && name[0] == '#' {
-		if v, e := strconv.ParseInt(name[1:], 16, 32); e == nil {
-			return NewHexColor(int32(v)), true
+	value := strings.ToLower(strings.TrimSpace(name))
+	if c, ok := ColorNames[value]; ok {
+		return c, true
+	}
+	if len(value) > 0 && value[0] == '#' {
+		return parseHexColor(value[1:])
+	}
+	if idx := strings.IndexByte(value, '('); idx > 0 && value[len(value)-1] == ')' {
+		return parseColorFunction(strings.TrimSpace(value[:idx]), value[idx+1:len(value)-1])
+	}
+	return ColorDefault, false
+}
+
+func parseHexColor(value string) (c Color, ok bool) {
+	switch len(value) {
+	case 3:
+		value = string([]byte{value[0], value[0], value[1], value[1], value[2], value[2]})
+	case 6:
+	default:
+		return ColorDefault, false
+	}
+	if v, e := strconv.ParseUint(value, 16, 32); e == nil {
+		return NewHexColor(int32(v)), true
+	}
+	return ColorDefault, false
+}
+
+func parseColorFunction(function, arguments string) (c Color, ok bool) {
+	arguments = strings.NewReplacer(",", " ", "/", " ").Replace(arguments)
+	fields := strings.Fields(arguments)
+	if len(fields) < 3 || len(fields) > 4 {
+		return ColorDefault, false
+	}
+	switch function {
+	case "rgb", "rgba":
+		var rgb [3]int32
+		for i := 0; i < 3; i++ {
+			v, percent, ok := parseColorComponent(fields[i])
+			if !ok {
+				return ColorDefault, false
+			}
+			if percent {
+				v = v * 255.0 / 100.0
+			}
+			rgb[i] = int32(math.Round(math.Max(0, math.Min(255, v))))
 		}
+		return NewRGBColor(rgb[0], rgb[1], rgb[2]), true
+	case "hsl", "hsla":
+		h, _, hok := parseColorComponent(strings.TrimSuffix(fields[0], "deg"))
+		s, sPercent, sok := parseColorComponent(fields[1])
+		l, lPercent, lok := parseColorComponent(fields[2])
+		if !hok || !sok || !lok || !sPercent || !lPercent {
+			return ColorDefault, false
+		}
+		return FromHSL(h, s/100.0, l/100.0), true
 	}
 	return ColorDefault, false
 }
 
+func parseColorComponent(field string) (value float64, percent bool, ok bool) {
+	if percent = strings.HasSuffix(field, "%"); percent {
+		field = field[:len(field)-1]
+	}
+	var err error
+	if value, err = strconv.ParseFloat(field, 64); err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false, false
+	}
+	return value, percent, true
+}
+
 // PaletteColor creates a color based on the palette index.
 func PaletteColor(index int) Color {
 	return Color(index) | ColorValid
 }
+
+// MarshalText implements encoding.TextMarshaler, encoding the color as
+// "default", "reset", "#rrggbb" for RGB colors or "palette:N" for palette
+// colors, all of which are accepted by UnmarshalText.
+func (c Color) MarshalText() (text []byte, err error) {
+	switch {
+	case c == ColorDefault:
+		return []byte("default"), nil
+	case c == ColorReset:
+		return []byte("reset"), nil
+	case c&ColorSpecial != 0:
+		return nil, fmt.Errorf("special color cannot be marshalled: %d", uint64(c))
+	case c.IsRGB():
+		return []byte(fmt.Sprintf("#%06x", c.Hex())), nil
+	case c.Valid():
+		return []byte(fmt.Sprintf("palette:%d", uint64(c&^ColorValid))), nil
+	}
+	return nil, fmt.Errorf("invalid color cannot be marshalled: %d", uint64(c))
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the forms
+// produced by MarshalText as well as the W3C color names.
+func (c *Color) UnmarshalText(text []byte) error {
+	value := string(text)
+	switch value {
+	case "default", "":
+		*c = ColorDefault
+		return nil
+	case "reset":
+		*c = ColorReset
+		return nil
+	}
+	if len(value) > 8 && value[:8] == "palette:" {
+		if index, err := strconv.ParseUint(value[8:], 10, 32); err == nil {
+			*c = PaletteColor(int(index))
+			return nil
+		}
+	}
+	if parsed, ok := ParseColor(value); ok {
+		*c = parsed
+		return nil
+	}
+	return fmt.Errorf("invalid color value: %q", value)
+}
@@ -142,3 +142,94 @@ func TestColorRGB(t *testing.T) {
 		So(b, ShouldEqual, 0x33)
 	})
 }
+
+func TestColorText(t *testing.T) {
+	Convey("Color text encoding", t, func() {
+		for _, c := range []Color{ColorDefault, ColorReset, ColorRed, PaletteColor(200), NewRGBColor(0x11, 0x22, 0x33)} {
+			text, err := c.MarshalText()
+			So(err, ShouldBeNil)
+			var decoded Color
+			So(decoded.UnmarshalText(text), ShouldBeNil)
+			So(decoded, ShouldEqual, c)
+		}
+		text, _ := NewRGBColor(0x11, 0x22, 0x33).MarshalText()
+		So(string(text), ShouldEqual, "#112233")
+		text, _ = PaletteColor(200).MarshalText()
+		So(string(text), ShouldEqual, "palette:200")
+		var c Color
+		So(c.UnmarshalText([]byte("navy")), ShouldBeNil)
+		So(c, ShouldEqual, ColorNavy)
+		So(c.UnmarshalText([]byte("not-a-color")), ShouldNotBeNil)
+	})
+}
+
+func TestColorUtilities(t *testing.T) {
+	Convey("Mixing colors", t, func() {
+		So(ColorBlack.Mix(ColorWhite, 0.5), ShouldEqual, NewRGBColor(0x80, 0x80, 0x80))
+		So(ColorRed.Mix(ColorBlue, 0), ShouldEqual, ColorRed.TrueColor())
+		So(ColorRed.Mix(ColorBlue, 2), ShouldEqual, ColorBlue.TrueColor())
+		So(ColorDefault.Mix(ColorBlue, 0.5), ShouldEqual, ColorBlue.TrueColor())
+		So(ColorRed.Mix(ColorReset, 0.5), ShouldEqual, ColorRed.TrueColor())
+		So(ColorDefault.Mix(ColorReset, 0.5), ShouldEqual, ColorDefault)
+		So(NewRGBColor(100, 100, 100).Lighten(50), ShouldEqual, NewRGBColor(178, 178, 178))
+		So(NewRGBColor(100, 100, 100).Darken(50), ShouldEqual, NewRGBColor(50, 50, 50))
+		So(ColorWhite.Lighten(20), ShouldEqual, ColorWhite.TrueColor())
+	})
+	Convey("Luminance and contrast", t, func() {
+		So(ColorBlack.Luminance(), ShouldEqual, 0)
+		So(ColorWhite.Luminance(), ShouldAlmostEqual, 1.0, 0.0001)
+		So(ColorDefault.Luminance(), ShouldEqual, -1)
+		So(ColorBlack.ContrastRatio(ColorWhite), ShouldAlmostEqual, 21.0, 0.0001)
+		So(ColorWhite.ContrastRatio(ColorBlack), ShouldAlmostEqual, 21.0, 0.0001)
+		So(ColorNavy.ContrastRatio(ColorNavy), ShouldAlmostEqual, 1.0, 0.0001)
+		So(ColorWhite.ContrastRatio(NewRGBColor(0x76, 0x76, 0x76)), ShouldBeGreaterThan, 4.5)
+		So(ColorReset.ContrastRatio(ColorWhite), ShouldEqual, 0)
+		So(math.IsNaN(ColorGray.ContrastRatio(ColorSilver)), ShouldBeFalse)
+	})
+}
+
+func TestColorParsing(t *testing.T) {
+	Convey("CSS color strings", t, func() {
+		for value, expected := range map[string]Color{
+			"red":                      ColorRed,
+			" Cyan ":                   ColorAqua,
+			"magenta":                  ColorFuchsia,
+			"#0a0B0c":                  NewRGBColor(0x0a, 0x0b, 0x0c),
+			"#f80":                     NewRGBColor(0xff, 0x88, 0x00),
+			"rgb(255, 128, 0)":         NewRGBColor(255, 128, 0),
+			"rgb(100%, 50%, 0%)":       NewRGBColor(255, 128, 0),
+			"rgb(300 -5 0)":            NewRGBColor(255, 0, 0),
+			"rgba(0, 0, 255, 0.5)":     NewRGBColor(0, 0, 255),
+			"RGB(0 0 255 / 50%)":       NewRGBColor(0, 0, 255),
+			"hsl(120, 100%, 50%)":      NewRGBColor(0, 255, 0),
+			"hsl(240deg 100% 25%)":     NewRGBColor(0, 0, 128),
+			"hsla(-120, 100%, 50%, 1)": NewRGBColor(0, 0, 255),
+			"hsl(0, 0%, 100%)":         NewRGBColor(255, 255, 255),
+		} {
+			c, ok := ParseColor(value)
+			So(ok, ShouldBeTrue)
+			So(c, ShouldEqual, expected)
+		}
+		for _, value := range []string{"", "#12", "#12345g", "rgb(1, 2)", "rgb(a, b, c)", "hsl(120, 1, 0.5)", "cmyk(0, 0, 0, 0)", "rgb(1, 2, 3"} {
+			_, ok := ParseColor(value)
+			So(ok, ShouldBeFalse)
+		}
+		So(GetColor("rgb(0, 0, 0)"), ShouldEqual, NewRGBColor(0, 0, 0))
+		So(GetColor("nope"), ShouldEqual, ColorDefault)
+	})
+	Convey("HSL and HSV conversions", t, func() {
+		h, s, l := ColorRed.ToHSL()
+		So(h, ShouldAlmostEqual, 0.0, 0.001)
+		So(s, ShouldAlmostEqual, 1.0, 0.001)
+		So(l, ShouldAlmostEqual, 0.5, 0.001)
+		h, s, v := NewRGBColor(0, 0, 128).ToHSV()
+		So(h, ShouldAlmostEqual, 240.0, 0.001)
+		So(s, ShouldAlmostEqual, 1.0, 0.001)
+		So(v, ShouldAlmostEqual, 128.0/255.0, 0.001)
+		So(FromHSV(240, 1, 128.0/255.0), ShouldEqual, NewRGBColor(0, 0, 128))
+		So(FromHSL(ColorTeal.ToHSL()), ShouldEqual, ColorTeal.TrueColor())
+		So(FromHSL(360, 2, -1), ShouldEqual, NewRGBColor(0, 0, 0))
+		h, s, l = ColorDefault.ToHSL()
+		So(h+s+l, ShouldEqual, 0)
+	})
+}
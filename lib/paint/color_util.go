@@ -0,0 +1,137 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paint
+
+import (
+	"math"
+
+	"github.com/lucasb-eyer/go-colorful"
+)
+
+// Mix returns the RGB color found the given fraction of the way from this
+// color to the other, a fraction of 0 being this color and 1 being the other.
+// The fraction is clamped to the range 0-1. If either color cannot be broken
+// up into RGB components, the one that can is returned, or ColorDefault when
+// neither can.
+func (c Color) Mix(other Color, fraction float64) Color {
+	if c.Hex() < 0 {
+		if other.Hex() < 0 {
+			return ColorDefault
+		}
+		return other.TrueColor()
+	} else if other.Hex() < 0 {
+		return c.TrueColor()
+	}
+	fraction = math.Max(0, math.Min(1, fraction))
+	r1, g1, b1 := c.RGB()
+	r2, g2, b2 := other.RGB()
+	mix := func(a, b int32) int32 {
+		return int32(math.Round(float64(a) + float64(b-a)*fraction))
+	}
+	return NewRGBColor(mix(r1, r2), mix(g1, g2), mix(b1, b2))
+}
+
+// Lighten returns the color mixed towards white by the given percentage
+// (0-100)
+func (c Color) Lighten(percent float64) Color {
+	return c.Mix(ColorWhite, percent/100.0)
+}
+
+// Darken returns the color mixed towards black by the given percentage
+// (0-100)
+func (c Color) Darken(percent float64) Color {
+	return c.Mix(ColorBlack, percent/100.0)
+}
+
+// Luminance returns the relative luminance of the color as defined by WCAG
+// 2.x, from 0 for black to 1 for white. Colors which cannot be broken up into
+// RGB components return -1.
+func (c Color) Luminance() float64 {
+	if c.Hex() < 0 {
+		return -1
+	}
+	r, g, b := c.RGB()
+	linear := func(v int32) float64 {
+		s := float64(v) / 255.0
+		if s <= 0.03928 {
+			return s / 12.92
+		}
+		return math.Pow((s+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(r) + 0.7152*linear(g) + 0.0722*linear(b)
+}
+
+// ContrastRatio returns the WCAG 2.x contrast ratio between this color and
+// the other, from 1 (no contrast) to 21 (black and white). WCAG recommends a
+// ratio of at least 4.5 for normal text. If either color cannot be broken up
+// into RGB components, 0 is returned.
+func (c Color) ContrastRatio(other Color) float64 {
+	l1, l2 := c.Luminance(), other.Luminance()
+	if l1 < 0 || l2 < 0 {
+		return 0
+	}
+	if l2 > l1 {
+		l1, l2 = l2, l1
+	}
+	return (l1 + 0.05) / (l2 + 0.05)
+}
+
+// FromHSL returns the RGB color for the given hue (in degrees), saturation
+// and lightness (each in the range 0-1).
+func FromHSL(h, s, l float64) Color {
+	return fromColorful(colorful.Hsl(normalizeHue(h), clampUnit(s), clampUnit(l)))
+}
+
+// ToHSL returns the hue (in degrees 0-360), saturation and lightness (each in
+// the range 0-1) of the color. Colors which cannot be broken up into RGB
+// components return all zeroes.
+func (c Color) ToHSL() (h, s, l float64) {
+	if c.Hex() < 0 {
+		return 0, 0, 0
+	}
+	return colorfulColor(c).Hsl()
+}
+
+// FromHSV returns the RGB color for the given hue (in degrees), saturation
+// and value (each in the range 0-1).
+func FromHSV(h, s, v float64) Color {
+	return fromColorful(colorful.Hsv(normalizeHue(h), clampUnit(s), clampUnit(v)))
+}
+
+// ToHSV returns the hue (in degrees 0-360), saturation and value (each in the
+// range 0-1) of the color. Colors which cannot be broken up into RGB
+// components return all zeroes.
+func (c Color) ToHSV() (h, s, v float64) {
+	if c.Hex() < 0 {
+		return 0, 0, 0
+	}
+	return colorfulColor(c).Hsv()
+}
+
+func fromColorful(c colorful.Color) Color {
+	r, g, b := c.Clamped().RGB255()
+	return NewRGBColor(int32(r), int32(g), int32(b))
+}
+
+func normalizeHue(h float64) float64 {
+	if h = math.Mod(h, 360); h < 0 {
+		h += 360
+	}
+	return h
+}
+
+func clampUnit(v float64) float64 {
+	return math.Max(0, math.Min(1, v))
+}
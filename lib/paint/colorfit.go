@@ -21,33 +21,130 @@ import (
 	"github.com/lucasb-eyer/go-colorful"
 )
 
+// ColorDistance selects the formula used to measure how different two colors
+// appear when matching colors to a palette
+type ColorDistance int
+
+const (
+	// ColorDistanceCIE76 is the euclidean distance in CIE L*a*b* space, the
+	// fastest and the default
+	ColorDistanceCIE76 ColorDistance = iota
+	// ColorDistanceCIEDE2000 is the most accurate, and most expensive, of the
+	// CIE formulas
+	ColorDistanceCIEDE2000
+	// ColorDistanceOKLab is the euclidean distance in OKLab space, nearly as
+	// accurate as CIEDE2000 at a fraction of the cost
+	ColorDistanceOKLab
+)
+
+// ColorDither selects how colors which are not in the palette are spread
+// across neighbouring cells to approximate them
+type ColorDither int
+
+const (
+	// ColorDitherNone uses the nearest palette color for every cell
+	ColorDitherNone ColorDither = iota
+	// ColorDitherOrdered varies the palette color chosen by the position of
+	// the cell using a 4x4 Bayer matrix, which smooths gradients at the cost
+	// of a stippled appearance
+	ColorDitherOrdered
+)
+
+// ColorDownsampling is the policy used to display 24-bit colors on terminals
+// which only support a palette
+type ColorDownsampling struct {
+	Distance ColorDistance
+	Dither   ColorDither
+}
+
 // FindColor attempts to find a given color, or the best match possible for it,
 // from the palette given.  This is an expensive operation, so results should
 // be cached by the caller.
 func FindColor(c Color, palette []Color) Color {
-	match := ColorDefault
-	dist := float64(0)
-	r, g, b := c.RGB()
-	c1 := colorful.Color{
-		R: float64(r) / 255.0,
-		G: float64(g) / 255.0,
-		B: float64(b) / 255.0,
+	return FindColorWith(c, palette, ColorDistanceCIE76)
+}
+
+// FindColorWith is FindColor using the given color distance formula
+func FindColorWith(c Color, palette []Color, distance ColorDistance) Color {
+	match, _ := findColor(colorfulColor(c), palette, distance)
+	return match
+}
+
+// DitherColor returns the palette color to use for the given color in the
+// cell at the given position, see: ColorDitherOrdered. Colors which are not
+// RGB colors are matched without dithering.
+func DitherColor(c Color, palette []Color, distance ColorDistance, x, y int) Color {
+	c1 := colorfulColor(c)
+	match, dist := findColor(c1, palette, distance)
+	if !c.IsRGB() || dist == 0 || len(palette) == 0 {
+		return match
+	}
+	// offset the color by up to half the typical spacing between palette
+	// colors, which is the cube root of the palette size per channel
+	spread := 1 / math.Cbrt(float64(len(palette)))
+	offset := (float64(bayer4x4[y&3][x&3])+0.5)/16 - 0.5
+	c1.R = math.Max(0, math.Min(1, c1.R+offset*spread))
+	c1.G = math.Max(0, math.Min(1, c1.G+offset*spread))
+	c1.B = math.Max(0, math.Min(1, c1.B+offset*spread))
+	match, _ = findColor(c1, palette, distance)
+	return match
+}
+
+var bayer4x4 = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+func findColor(c1 colorful.Color, palette []Color, distance ColorDistance) (match Color, dist float64) {
+	match = ColorDefault
+	var l1, a1, b1 float64
+	if distance == ColorDistanceOKLab {
+		l1, a1, b1 = okLab(c1)
 	}
 	for _, d := range palette {
-		r, g, b = d.RGB()
-		c2 := colorful.Color{
-			R: float64(r) / 255.0,
-			G: float64(g) / 255.0,
-			B: float64(b) / 255.0,
+		c2 := colorfulColor(d)
+		var nd float64
+		switch distance {
+		case ColorDistanceCIEDE2000:
+			nd = c1.DistanceCIEDE2000(c2)
+		case ColorDistanceOKLab:
+			l2, a2, b2 := okLab(c2)
+			nd = math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+		default:
+			// CIE94 is more accurate, but really really expensive.
+			nd = c1.DistanceCIE76(c2)
 		}
-		// CIE94 is more accurate, but really really expensive.
-		nd := safe_nan(c1.DistanceCIE76(c2))
+		nd = safe_nan(nd)
 		if match == ColorDefault || nd < dist {
 			match = d
 			dist = nd
 		}
 	}
-	return match
+	return
+}
+
+func colorfulColor(c Color) colorful.Color {
+	r, g, b := c.RGB()
+	return colorful.Color{
+		R: float64(r) / 255.0,
+		G: float64(g) / 255.0,
+		B: float64(b) / 255.0,
+	}
+}
+
+// okLab converts the color to the OKLab color space, see:
+// https://bottosson.github.io/posts/oklab/
+func okLab(c colorful.Color) (l, a, b float64) {
+	r, g, bl := c.LinearRgb()
+	lc := math.Cbrt(0.4122214708*r + 0.5363325363*g + 0.0514459929*bl)
+	mc := math.Cbrt(0.2119034982*r + 0.6806995451*g + 0.1073969566*bl)
+	sc := math.Cbrt(0.0883024619*r + 0.2817188376*g + 0.6299787005*bl)
+	l = 0.2104542553*lc + 0.7936177850*mc - 0.0040720468*sc
+	a = 1.9779984951*lc - 2.4285922050*mc + 0.4505937099*sc
+	b = 0.0259040371*lc + 0.7827717662*mc - 0.8086757660*sc
+	return
 }
 
 func safe_nan(nd float64) float64 {
@@ -0,0 +1,67 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paint
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func testPalette(size int) (palette []Color) {
+	for i := 0; i < size; i++ {
+		palette = append(palette, Color(i)|ColorValid)
+	}
+	return
+}
+
+func TestFindColorWith(t *testing.T) {
+	Convey("Matching colors to a palette", t, func() {
+		palette := testPalette(256)
+		for _, distance := range []ColorDistance{ColorDistanceCIE76, ColorDistanceCIEDE2000, ColorDistanceOKLab} {
+			// exact palette colors always match themselves
+			So(FindColorWith(NewRGBColor(0xff, 0, 0), palette, distance), ShouldEqual, ColorRed)
+			So(FindColorWith(NewRGBColor(0x5f, 0x87, 0xaf), palette, distance), ShouldEqual, PaletteColor(67))
+		}
+		So(FindColor(NewRGBColor(0xfe, 0x01, 0x01), palette), ShouldEqual, ColorRed)
+		l, a, b := okLab(colorfulColor(ColorWhite))
+		So(l, ShouldAlmostEqual, 1.0, 0.001)
+		So(a, ShouldAlmostEqual, 0.0, 0.001)
+		So(b, ShouldAlmostEqual, 0.0, 0.001)
+	})
+}
+
+func TestDitherColor(t *testing.T) {
+	Convey("Ordered dithering", t, func() {
+		palette := testPalette(16)
+		// a gray between black and the palette grays dithers to more than one
+		// color across the matrix
+		gray := NewRGBColor(0x40, 0x40, 0x40)
+		seen := make(map[Color]bool)
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				seen[DitherColor(gray, palette, ColorDistanceOKLab, x, y)] = true
+			}
+		}
+		So(len(seen), ShouldBeGreaterThan, 1)
+		// exact and palette colors are not dithered
+		for y := 0; y < 4; y++ {
+			for x := 0; x < 4; x++ {
+				So(DitherColor(NewRGBColor(0xff, 0, 0), palette, ColorDistanceCIE76, x, y), ShouldEqual, ColorRed)
+				So(DitherColor(ColorNavy, palette, ColorDistanceCIE76, x, y), ShouldEqual, ColorNavy)
+			}
+		}
+	})
+}
@@ -34,6 +34,11 @@ var (
 		BottomLeft:  RuneLLCorner,
 		Bottom:      RuneHLine,
 		BottomRight: RuneLRCorner,
+		TopTee:      RuneTTee,
+		LeftTee:     RuneLTee,
+		RightTee:    RuneRTee,
+		BottomTee:   RuneBTee,
+		Cross:       RunePlus,
 	}
 	roundedBorderRune = BorderRuneSet{
 		TopLeft:     RuneULCornerRounded,
@@ -44,6 +49,11 @@ var (
 		BottomLeft:  RuneLLCornerRounded,
 		Bottom:      RuneHLine,
 		BottomRight: RuneLRCornerRounded,
+		TopTee:      RuneTTee,
+		LeftTee:     RuneLTee,
+		RightTee:    RuneRTee,
+		BottomTee:   RuneBTee,
+		Cross:       RunePlus,
 	}
 	doubleBorderRune = BorderRuneSet{
 		TopLeft:     RuneBoxDrawingsDoubleDownAndRight,
@@ -54,6 +64,11 @@ var (
 		BottomLeft:  RuneBoxDrawingsDoubleUpAndRight,
 		Bottom:      RuneBoxDrawingsDoubleHorizontal,
 		BottomRight: RuneBoxDrawingsDoubleUpAndLeft,
+		TopTee:      RuneBoxDrawingsDoubleDownAndHorizontal,
+		LeftTee:     RuneBoxDrawingsDoubleVerticalAndRight,
+		RightTee:    RuneBoxDrawingsDoubleVerticalAndLeft,
+		BottomTee:   RuneBoxDrawingsDoubleUpAndHorizontal,
+		Cross:       RuneBoxDrawingsDoubleVerticalAndHorizontal,
 	}
 	emptyBorderRune = BorderRuneSet{
 		TopLeft:     ' ',
@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"regexp"
 	"strconv"
+	"strings"
 )
 
 // StyleDefault represents a default style, based upon the context.
@@ -183,6 +184,13 @@ func (s Style) Strike(on bool) Style {
 	return s.setAttrs(AttrStrike, on)
 }
 
+// Cursor returns a new style based on s, with the cursor attribute set as
+// requested. Cells with the cursor attribute are drawn by the Screen in the
+// same way as the cursor, for surfaces which draw cursors of their own.
+func (s Style) Cursor(on bool) Style {
+	return s.setAttrs(AttrCursor, on)
+}
+
 // Attributes returns a new style based on s, with its attributes set as
 // specified.
 func (s Style) Attributes(attrs AttrMask) Style {
@@ -193,3 +201,38 @@ func (s Style) Attributes(attrs AttrMask) Style {
 		attrs: s.attrs,
 	}
 }
+
+// MarshalText implements encoding.TextMarshaler, encoding the style as
+// "fg,bg,attrs" with the colors encoded as with Color.MarshalText.
+func (s Style) MarshalText() (text []byte, err error) {
+	var fg, bg []byte
+	if fg, err = s.fg.MarshalText(); err != nil {
+		return
+	}
+	if bg, err = s.bg.MarshalText(); err != nil {
+		return
+	}
+	return []byte(fmt.Sprintf("%s,%s,%d", fg, bg, s.attrs)), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler, accepting the form
+// produced by MarshalText.
+func (s *Style) UnmarshalText(text []byte) error {
+	parts := strings.Split(string(text), ",")
+	if len(parts) != 3 {
+		return fmt.Errorf("invalid style value: %q", string(text))
+	}
+	var fg, bg Color
+	if err := fg.UnmarshalText([]byte(parts[0])); err != nil {
+		return err
+	}
+	if err := bg.UnmarshalText([]byte(parts[1])); err != nil {
+		return err
+	}
+	attrs, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return fmt.Errorf("invalid style attr value: %q", parts[2])
+	}
+	*s = Style{fg: fg, bg: bg, attrs: AttrMask(attrs)}
+	return nil
+}
@@ -0,0 +1,196 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paint
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// StyleClass names a style within a StyleSheet. Classes are dot separated,
+// from the most general to the most specific, and each class inherits from
+// the one before its last dot: "entry.focused" inherits from "entry".
+type StyleClass string
+
+// Parent returns the class this class inherits from, or an empty class
+func (c StyleClass) Parent() (parent StyleClass) {
+	if idx := strings.LastIndexByte(string(c), '.'); idx > -1 {
+		return c[:idx]
+	}
+	return ""
+}
+
+// Child returns the more specific class with the given name appended
+func (c StyleClass) Child(name string) (child StyleClass) {
+	if c == "" {
+		return StyleClass(name)
+	}
+	return c + "." + StyleClass(name)
+}
+
+// Lineage returns the class and all those it inherits from, from the most
+// general to the class itself
+func (c StyleClass) Lineage() (lineage []StyleClass) {
+	for class := c; class != ""; class = class.Parent() {
+		lineage = append([]StyleClass{class}, lineage...)
+	}
+	return
+}
+
+// StyleRule describes how a style class changes the style it inherits. Colors
+// left as ColorDefault are inherited, use ColorReset for the terminal default.
+type StyleRule struct {
+	Foreground Color
+	Background Color
+	// Set are the attributes turned on
+	Set AttrMask
+	// Clear are the attributes turned off
+	Clear AttrMask
+}
+
+// MakeStyleRule returns a rule replacing the colors of the inherited style and
+// adding the attributes of the given style
+func MakeStyleRule(style Style) StyleRule {
+	fg, bg, attrs := style.Decompose()
+	return StyleRule{Foreground: fg, Background: bg, Set: attrs}
+}
+
+// Apply returns the given style changed by the rule
+func (r StyleRule) Apply(style Style) Style {
+	if r.Foreground != ColorDefault {
+		style = style.Foreground(r.Foreground)
+	}
+	if r.Background != ColorDefault {
+		style = style.Background(r.Background)
+	}
+	if r.Clear != AttrNone {
+		style = style.setAttrs(r.Clear, false)
+	}
+	if r.Set != AttrNone {
+		style = style.setAttrs(r.Set, true)
+	}
+	return style
+}
+
+func (r StyleRule) String() string {
+	return fmt.Sprintf("{fg=%v;bg=%v;set=%v;clear=%v}", r.Foreground, r.Background, r.Set, r.Clear)
+}
+
+// StyleSheet is a layer of style rules for named style classes, overriding
+// the rules of the base StyleSheet it was created with. This allows for
+// application or user layers to change some of the styles of a theme without
+// repeating the others.
+type StyleSheet struct {
+	base  *StyleSheet
+	rules map[StyleClass]StyleRule
+
+	sync.RWMutex
+}
+
+// NewStyleSheet returns an empty StyleSheet layered over the given base, which
+// may be nil
+func NewStyleSheet(base *StyleSheet) (sheet *StyleSheet) {
+	return &StyleSheet{
+		base:  base,
+		rules: make(map[StyleClass]StyleRule),
+	}
+}
+
+// Base returns the StyleSheet this one overrides, nil if there is none
+func (s *StyleSheet) Base() (base *StyleSheet) {
+	return s.base
+}
+
+// Set changes the rule of the given class in this layer
+func (s *StyleSheet) Set(class StyleClass, rule StyleRule) {
+	s.Lock()
+	defer s.Unlock()
+	s.rules[class] = rule
+}
+
+// Get returns the rule of the given class in this layer only
+func (s *StyleSheet) Get(class StyleClass) (rule StyleRule, ok bool) {
+	s.RLock()
+	defer s.RUnlock()
+	rule, ok = s.rules[class]
+	return
+}
+
+// Remove discards the rule of the given class from this layer, any rule of
+// the base layers applies again
+func (s *StyleSheet) Remove(class StyleClass) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.rules, class)
+}
+
+// Classes returns the classes with rules in this or any base layer, sorted
+func (s *StyleSheet) Classes() (classes []StyleClass) {
+	found := make(map[StyleClass]bool)
+	for sheet := s; sheet != nil; sheet = sheet.base {
+		sheet.RLock()
+		for class := range sheet.rules {
+			if !found[class] {
+				found[class] = true
+				classes = append(classes, class)
+			}
+		}
+		sheet.RUnlock()
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i] < classes[j] })
+	return
+}
+
+// Resolve returns the style of the given class, starting from the given style
+// and applying the rules of the class lineage from the most general class to
+// the class itself. For each class, the rules of the base layers are applied
+// before those of this layer.
+func (s *StyleSheet) Resolve(class StyleClass, style Style) Style {
+	var layers []*StyleSheet
+	for sheet := s; sheet != nil; sheet = sheet.base {
+		layers = append([]*StyleSheet{sheet}, layers...)
+	}
+	for _, c := range class.Lineage() {
+		for _, layer := range layers {
+			if rule, ok := layer.Get(c); ok {
+				style = rule.Apply(style)
+			}
+		}
+	}
+	return style
+}
+
+var defaultStyleSheet = NewStyleSheet(nil)
+
+// GetDefaultStyleSheet returns the StyleSheet used by themes without one of
+// their own
+func GetDefaultStyleSheet() (sheet *StyleSheet) {
+	pkgLock.RLock()
+	defer pkgLock.RUnlock()
+	return defaultStyleSheet
+}
+
+// SetDefaultStyleSheet replaces the StyleSheet used by themes without one of
+// their own
+func SetDefaultStyleSheet(sheet *StyleSheet) {
+	if sheet == nil {
+		sheet = NewStyleSheet(nil)
+	}
+	pkgLock.Lock()
+	defer pkgLock.Unlock()
+	defaultStyleSheet = sheet
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paint
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStyleSheet(t *testing.T) {
+	Convey("Style classes", t, func() {
+		class := StyleClass("dialog.title.focused")
+		So(class.Parent(), ShouldEqual, StyleClass("dialog.title"))
+		So(StyleClass("dialog").Parent(), ShouldEqual, StyleClass(""))
+		So(class.Lineage(), ShouldResemble, []StyleClass{"dialog", "dialog.title", "dialog.title.focused"})
+		So(StyleClass("entry").Child("focused"), ShouldEqual, StyleClass("entry.focused"))
+		So(StyleClass("").Child("entry"), ShouldEqual, StyleClass("entry"))
+	})
+	Convey("Style rules", t, func() {
+		style := StyleDefault.Foreground(ColorWhite).Background(ColorNavy).Bold(true)
+		rule := StyleRule{Background: ColorRed, Set: AttrUnderline, Clear: AttrBold}
+		fg, bg, attrs := rule.Apply(style).Decompose()
+		So(fg, ShouldEqual, ColorWhite)
+		So(bg, ShouldEqual, ColorRed)
+		So(attrs, ShouldEqual, AttrUnderline)
+		So(MakeStyleRule(style).Apply(StyleDefault), ShouldResemble, style)
+	})
+	Convey("Inheritance and layers", t, func() {
+		base := NewStyleSheet(nil)
+		base.Set("entry", StyleRule{Foreground: ColorBlack, Background: ColorWhite})
+		base.Set("entry.focused", StyleRule{Set: AttrBold})
+		user := NewStyleSheet(base)
+		user.Set("entry", StyleRule{Background: ColorYellow})
+		So(user.Base(), ShouldEqual, base)
+		So(user.Classes(), ShouldResemble, []StyleClass{"entry", "entry.focused"})
+		fg, bg, attrs := user.Resolve("entry.focused", StyleDefault).Decompose()
+		So(fg, ShouldEqual, ColorBlack)
+		So(bg, ShouldEqual, ColorYellow)
+		So(attrs.IsBold(), ShouldBeTrue)
+		_, bg, attrs = base.Resolve("entry", StyleDefault).Decompose()
+		So(bg, ShouldEqual, ColorWhite)
+		So(attrs.IsBold(), ShouldBeFalse)
+		user.Remove("entry")
+		_, bg, _ = user.Resolve("entry", StyleDefault).Decompose()
+		So(bg, ShouldEqual, ColorWhite)
+		_, ok := user.Get("entry")
+		So(ok, ShouldBeFalse)
+	})
+	Convey("Resolving through a theme", t, func() {
+		theme := GetDefaultColorTheme()
+		So(theme.Style("unknown"), ShouldResemble, theme.Content.Normal)
+		sheet := NewStyleSheet(nil)
+		sheet.Set("dialog.title", StyleRule{Set: AttrBold})
+		theme.Styles = sheet
+		So(theme.Clone().StyleSheet(), ShouldEqual, sheet)
+		So(theme.Style("dialog.title"), ShouldResemble, theme.Content.Normal.Bold(true))
+		previous := GetDefaultStyleSheet()
+		defer SetDefaultStyleSheet(previous)
+		SetDefaultStyleSheet(sheet)
+		So(GetDefaultColorTheme().Style("dialog.title"), ShouldResemble, theme.Content.Normal.Bold(true))
+	})
+}
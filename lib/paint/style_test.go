@@ -62,3 +62,17 @@ func TestStyle(t *testing.T) {
 		So(attr, ShouldEqual, AttrReverse|AttrBold|AttrDim|AttrItalic|AttrStrike)
 	})
 }
+
+func TestStyleText(t *testing.T) {
+	Convey("Style text encoding", t, func() {
+		style := StyleDefault.Foreground(NewRGBColor(0xff, 0, 0)).Background(ColorNavy).Bold(true)
+		text, err := style.MarshalText()
+		So(err, ShouldBeNil)
+		So(string(text), ShouldEqual, "#ff0000,palette:4,1")
+		var decoded Style
+		So(decoded.UnmarshalText(text), ShouldBeNil)
+		So(decoded, ShouldResemble, style)
+		So(decoded.UnmarshalText([]byte("red,blue")), ShouldNotBeNil)
+		So(decoded.UnmarshalText([]byte("red,blue,x")), ShouldNotBeNil)
+	})
+}
@@ -21,6 +21,9 @@ import (
 type Theme struct {
 	Content ThemeAspect
 	Border  ThemeAspect
+	// Styles resolves named style classes, the default StyleSheet is used
+	// when nil
+	Styles *StyleSheet `json:"-"`
 }
 
 func (t Theme) String() string {
@@ -35,5 +38,21 @@ func (t Theme) Clone() Theme {
 	return Theme{
 		Content: t.Content,
 		Border:  t.Border,
+		Styles:  t.Styles,
 	}
 }
+
+// StyleSheet returns the StyleSheet of the theme, or the default StyleSheet
+// if the theme has none
+func (t Theme) StyleSheet() (sheet *StyleSheet) {
+	if t.Styles != nil {
+		return t.Styles
+	}
+	return GetDefaultStyleSheet()
+}
+
+// Style resolves the given style class, starting from the Content.Normal
+// style of the theme
+func (t Theme) Style(class StyleClass) Style {
+	return t.StyleSheet().Resolve(class, t.Content.Normal)
+}
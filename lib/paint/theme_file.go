@@ -0,0 +1,469 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paint
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// ThemeFile is the content of a user theme definition file. Theme files are
+// TOML or YAML documents with the following (all optional) tables, shown here
+// as TOML:
+//
+//	[colors]                  # named colors, usable in any style
+//	accent = "#ff8800"
+//
+//	[borders]                 # border rune sets, in BorderRuneSet field order
+//	fancy = "╭─╮││╰─╯"        # with or without the joints: "╭─╮││╰─╯┬├┤┴┼"
+//
+//	[arrows]                  # arrow rune sets, in ArrowRuneSet field order
+//	thin = "↑←↓→"
+//
+//	[themes.ocean]
+//	base = "color"            # registered theme to start from, default "color"
+//
+//	[themes.ocean.content]    # and [themes.ocean.border]
+//	normal = { fg = "white", bg = "navy", attrs = ["bold"] }
+//	selected = "accent,navy,0" # or any form accepted by Style.UnmarshalText
+//	fill-rune = " "
+//	border-runes = "fancy"    # a border name or eight runes
+//	arrow-runes = "thin"      # an arrow name or four runes
+//	overlay = false
+//
+// The same tables written as YAML mappings are, for example:
+//
+//	themes:
+//	  ocean:
+//	    content:
+//	      normal: { fg: white, bg: navy, attrs: [bold] }
+//
+// Style tables only change the fields given, so a theme need only describe
+// how it differs from its base.
+type ThemeFile struct {
+	Colors  map[string]Color
+	Borders map[BorderName]BorderRuneSet
+	Arrows  map[ArrowName]ArrowRuneSet
+	Themes  map[ThemeName]Theme
+}
+
+// LoadThemeFile reads and parses the theme definition file at the given path,
+// as YAML for the .yaml and .yml extensions and as TOML otherwise, see:
+// ParseThemeFile and ParseThemeFileYAML
+func LoadThemeFile(path string) (tf *ThemeFile, err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		tf, err = ParseThemeFileYAML(data)
+	default:
+		tf, err = ParseThemeFile(data)
+	}
+	if err != nil {
+		err = fmt.Errorf("%v: %w", path, err)
+	}
+	return
+}
+
+// ParseThemeFile parses a TOML theme definition document. Themes, borders and
+// arrows defined in the document may refer to each other and to those already
+// registered, however nothing is registered until Register is called.
+func ParseThemeFile(data []byte) (tf *ThemeFile, err error) {
+	var doc map[string]interface{}
+	if _, err = toml.Decode(string(data), &doc); err != nil {
+		return
+	}
+	return parseThemeFile(doc)
+}
+
+// ParseThemeFileYAML parses a YAML theme definition document, see:
+// ParseThemeFile
+func ParseThemeFileYAML(data []byte) (tf *ThemeFile, err error) {
+	var doc map[string]interface{}
+	if err = yaml.Unmarshal(data, &doc); err != nil {
+		return
+	}
+	return parseThemeFile(doc)
+}
+
+func parseThemeFile(doc map[string]interface{}) (tf *ThemeFile, err error) {
+	tf = &ThemeFile{
+		Colors:  make(map[string]Color),
+		Borders: make(map[BorderName]BorderRuneSet),
+		Arrows:  make(map[ArrowName]ArrowRuneSet),
+		Themes:  make(map[ThemeName]Theme),
+	}
+	for section := range doc {
+		switch section {
+		case "colors", "borders", "arrows", "themes":
+		default:
+			return nil, fmt.Errorf("unknown theme file section: %q", section)
+		}
+	}
+	var colors, borders, arrows, themes map[string]interface{}
+	if colors, err = themeFileTable(doc, "colors"); err != nil {
+		return nil, err
+	}
+	for name, value := range colors {
+		var c Color
+		if s, ok := value.(string); !ok {
+			return nil, fmt.Errorf("colors.%v: expected a string", name)
+		} else if err = c.UnmarshalText([]byte(s)); err != nil {
+			return nil, fmt.Errorf("colors.%v: %w", name, err)
+		}
+		tf.Colors[name] = c
+	}
+	if borders, err = themeFileTable(doc, "borders"); err != nil {
+		return nil, err
+	}
+	for name, value := range borders {
+		border, ok := themeFileBorderRunes(value)
+		if !ok {
+			return nil, fmt.Errorf("borders.%v: expected a string of eight or thirteen runes", name)
+		}
+		tf.Borders[BorderName(name)] = border
+	}
+	if arrows, err = themeFileTable(doc, "arrows"); err != nil {
+		return nil, err
+	}
+	for name, value := range arrows {
+		runes, ok := themeFileRunes(value, 4)
+		if !ok {
+			return nil, fmt.Errorf("arrows.%v: expected a string of four runes", name)
+		}
+		tf.Arrows[ArrowName(name)] = ArrowRuneSet{
+			Up:    runes[0],
+			Left:  runes[1],
+			Down:  runes[2],
+			Right: runes[3],
+		}
+	}
+	if themes, err = themeFileTable(doc, "themes"); err != nil {
+		return nil, err
+	}
+	// themes may be based upon others in the same file, so resolve them in
+	// dependency order
+	resolving := make(map[string]bool)
+	var resolve func(name string) (Theme, error)
+	resolve = func(name string) (theme Theme, err error) {
+		if theme, ok := tf.Themes[ThemeName(name)]; ok {
+			return theme, nil
+		}
+		node, ok := themes[name]
+		if !ok {
+			if theme, ok = GetTheme(ThemeName(name)); !ok {
+				return theme, fmt.Errorf("theme not found: %q", name)
+			}
+			return theme, nil
+		}
+		if resolving[name] {
+			return theme, fmt.Errorf("themes.%v: circular base theme", name)
+		}
+		resolving[name] = true
+		defer delete(resolving, name)
+		definition, ok := node.(map[string]interface{})
+		if !ok {
+			return theme, fmt.Errorf("themes.%v: expected a table", name)
+		}
+		base := string(ColorTheme)
+		if value, found := definition["base"]; found {
+			if base, ok = value.(string); !ok {
+				return theme, fmt.Errorf("themes.%v.base: expected a string", name)
+			}
+		}
+		if base == name {
+			if theme, ok = GetTheme(ThemeName(name)); !ok {
+				return theme, fmt.Errorf("themes.%v: circular base theme", name)
+			}
+		} else if theme, err = resolve(base); err != nil {
+			return theme, fmt.Errorf("themes.%v.base: %w", name, err)
+		}
+		theme = theme.Clone()
+		for key, value := range definition {
+			switch key {
+			case "base":
+			case "content":
+				err = tf.parseAspect(&theme.Content, value)
+			case "border":
+				err = tf.parseAspect(&theme.Border, value)
+			default:
+				err = fmt.Errorf("unknown field")
+			}
+			if err != nil {
+				return theme, fmt.Errorf("themes.%v.%v: %w", name, key, err)
+			}
+		}
+		tf.Themes[ThemeName(name)] = theme
+		return theme, nil
+	}
+	for _, name := range themeFileKeys(themes) {
+		if _, err = resolve(name); err != nil {
+			return nil, err
+		}
+	}
+	return tf, nil
+}
+
+// ThemeNames returns the names of the themes defined, sorted
+func (tf *ThemeFile) ThemeNames() (names []ThemeName) {
+	for name := range tf.Themes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i] < names[j] })
+	return
+}
+
+// Register makes the borders, arrows and themes defined available through
+// GetDefaultBorderRunes, GetArrows and GetTheme, replacing any previously
+// registered with the same names, including the built-in ones.
+func (tf *ThemeFile) Register() {
+	for name, border := range tf.Borders {
+		RegisterBorderRunes(name, border)
+	}
+	for name, arrow := range tf.Arrows {
+		RegisterArrows(name, arrow)
+	}
+	for name, theme := range tf.Themes {
+		RegisterTheme(name, theme)
+	}
+}
+
+func (tf *ThemeFile) parseAspect(aspect *ThemeAspect, node interface{}) (err error) {
+	definition, ok := node.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("expected a table")
+	}
+	for key, value := range definition {
+		switch key {
+		case "normal":
+			aspect.Normal, err = tf.parseStyle(aspect.Normal, value)
+		case "selected":
+			aspect.Selected, err = tf.parseStyle(aspect.Selected, value)
+		case "active":
+			aspect.Active, err = tf.parseStyle(aspect.Active, value)
+		case "prelight":
+			aspect.Prelight, err = tf.parseStyle(aspect.Prelight, value)
+		case "insensitive":
+			aspect.Insensitive, err = tf.parseStyle(aspect.Insensitive, value)
+		case "fill-rune":
+			if runes, ok := themeFileRunes(value, 1); ok {
+				aspect.FillRune = runes[0]
+			} else {
+				err = fmt.Errorf("expected a string of one rune")
+			}
+		case "border-runes":
+			aspect.BorderRunes, err = tf.parseBorderRunes(value)
+		case "arrow-runes":
+			aspect.ArrowRunes, err = tf.parseArrowRunes(value)
+		case "overlay":
+			if aspect.Overlay, ok = value.(bool); !ok {
+				err = fmt.Errorf("expected a boolean")
+			}
+		default:
+			err = fmt.Errorf("unknown field")
+		}
+		if err != nil {
+			return fmt.Errorf("%v: %w", key, err)
+		}
+	}
+	return
+}
+
+func (tf *ThemeFile) parseStyle(style Style, node interface{}) (Style, error) {
+	switch v := node.(type) {
+	case string:
+		err := style.UnmarshalText([]byte(tf.expandColors(v)))
+		return style, err
+	case map[string]interface{}:
+		for key, value := range v {
+			switch key {
+			case "fg", "bg":
+				s, ok := value.(string)
+				if !ok {
+					return style, fmt.Errorf("%v: expected a string", key)
+				}
+				c, err := tf.parseColor(s)
+				if err != nil {
+					return style, fmt.Errorf("%v: %w", key, err)
+				}
+				if key == "fg" {
+					style = style.Foreground(c)
+				} else {
+					style = style.Background(c)
+				}
+			case "attrs":
+				attrs, err := parseThemeFileAttrs(value)
+				if err != nil {
+					return style, fmt.Errorf("%v: %w", key, err)
+				}
+				style = style.Attributes(attrs)
+			default:
+				return style, fmt.Errorf("%v: unknown field", key)
+			}
+		}
+		return style, nil
+	}
+	return style, fmt.Errorf("expected a string or table")
+}
+
+func (tf *ThemeFile) parseColor(name string) (c Color, err error) {
+	if c, ok := tf.Colors[name]; ok {
+		return c, nil
+	}
+	err = c.UnmarshalText([]byte(name))
+	return
+}
+
+// expandColors substitutes named colors in a "fg,bg,attrs" style string
+func (tf *ThemeFile) expandColors(value string) string {
+	parts := strings.Split(value, ",")
+	if len(parts) == 3 {
+		for idx := 0; idx < 2; idx++ {
+			if c, ok := tf.Colors[parts[idx]]; ok {
+				if text, err := c.MarshalText(); err == nil {
+					parts[idx] = string(text)
+				}
+			}
+		}
+	}
+	return strings.Join(parts, ",")
+}
+
+func (tf *ThemeFile) parseBorderRunes(node interface{}) (border BorderRuneSet, err error) {
+	if name, ok := node.(string); ok {
+		if border, ok = tf.Borders[BorderName(name)]; ok {
+			return
+		}
+		if border, ok = GetDefaultBorderRunes(BorderName(name)); ok {
+			return
+		}
+		if border, ok = themeFileBorderRunes(node); ok {
+			return
+		}
+	}
+	return border, fmt.Errorf("expected a border name or a string of eight or thirteen runes")
+}
+
+func (tf *ThemeFile) parseArrowRunes(node interface{}) (arrow ArrowRuneSet, err error) {
+	if name, ok := node.(string); ok {
+		if arrow, ok = tf.Arrows[ArrowName(name)]; ok {
+			return
+		}
+		if arrow, ok = GetArrows(ArrowName(name)); ok {
+			return
+		}
+		if runes, ok := themeFileRunes(node, 4); ok {
+			return ArrowRuneSet{runes[0], runes[1], runes[2], runes[3]}, nil
+		}
+	}
+	return arrow, fmt.Errorf("expected an arrow name or a string of four runes")
+}
+
+func parseThemeFileAttrs(node interface{}) (attrs AttrMask, err error) {
+	list, ok := node.([]interface{})
+	if !ok {
+		return attrs, fmt.Errorf("expected an array of attribute names")
+	}
+	for _, item := range list {
+		name, _ := item.(string)
+		switch strings.ToLower(name) {
+		case "bold":
+			attrs |= AttrBold
+		case "blink":
+			attrs |= AttrBlink
+		case "reverse":
+			attrs |= AttrReverse
+		case "underline":
+			attrs |= AttrUnderline
+		case "dim":
+			attrs |= AttrDim
+		case "italic":
+			attrs |= AttrItalic
+		case "strike":
+			attrs |= AttrStrike
+		case "cursor":
+			attrs |= AttrCursor
+		case "none", "normal":
+		default:
+			return attrs, fmt.Errorf("unknown attribute: %q", name)
+		}
+	}
+	return
+}
+
+func themeFileTable(doc map[string]interface{}, name string) (table map[string]interface{}, err error) {
+	node, ok := doc[name]
+	if !ok {
+		return nil, nil
+	}
+	if table, ok = node.(map[string]interface{}); !ok {
+		return nil, fmt.Errorf("%v: expected a table", name)
+	}
+	return
+}
+
+func themeFileRunes(node interface{}, count int) (runes []rune, ok bool) {
+	var s string
+	if s, ok = node.(string); !ok {
+		return nil, false
+	}
+	runes = []rune(s)
+	return runes, len(runes) == count
+}
+
+// themeFileBorderRunes parses a string of the eight border runes, optionally
+// followed by the five joint runes, in BorderRuneSet field order
+func themeFileBorderRunes(node interface{}) (border BorderRuneSet, ok bool) {
+	var runes []rune
+	if runes, ok = themeFileRunes(node, 13); !ok {
+		if runes, ok = themeFileRunes(node, 8); !ok {
+			return
+		}
+	}
+	border = BorderRuneSet{
+		TopLeft:     runes[0],
+		Top:         runes[1],
+		TopRight:    runes[2],
+		Left:        runes[3],
+		Right:       runes[4],
+		BottomLeft:  runes[5],
+		Bottom:      runes[6],
+		BottomRight: runes[7],
+	}
+	if len(runes) == 13 {
+		border.TopTee = runes[8]
+		border.LeftTee = runes[9]
+		border.RightTee = runes[10]
+		border.BottomTee = runes[11]
+		border.Cross = runes[12]
+	}
+	return
+}
+
+func themeFileKeys(table map[string]interface{}) (keys []string) {
+	for key := range table {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package paint
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testThemeFile = `
+[colors]
+accent = "#ff8800"
+
+[borders]
+fancy = "╭─╮││╰─╯"
+joined = "╭─╮││╰─╯┬├┤┴┼"
+
+[themes.test-ocean]
+[themes.test-ocean.content]
+normal = { fg = "white", bg = "navy", attrs = ["bold"] }
+selected = "accent,navy,0"
+fill-rune = "."
+arrow-runes = "<^v>"
+
+[themes.test-ocean.border]
+border-runes = "fancy"
+prelight = { fg = "accent" }
+
+[themes.test-deep]
+base = "test-ocean"
+[themes.test-deep.content]
+overlay = true
+`
+
+const testThemeFileYAML = `
+colors:
+  accent: "#ff8800"
+borders:
+  joined: "╭─╮││╰─╯┬├┤┴┼"
+themes:
+  test-yaml-ocean:
+    content:
+      normal: { fg: white, bg: navy, attrs: [bold] }
+      selected: "accent,navy,0"
+      fill-rune: "."
+    border:
+      border-runes: joined
+  test-yaml-deep:
+    base: test-yaml-ocean
+    content:
+      overlay: true
+`
+
+func TestThemeFile(t *testing.T) {
+	Convey("Parsing theme files", t, func() {
+		tf, err := ParseThemeFile([]byte(testThemeFile))
+		So(err, ShouldBeNil)
+		So(tf.ThemeNames(), ShouldResemble, []ThemeName{"test-deep", "test-ocean"})
+		So(tf.Colors["accent"], ShouldEqual, NewRGBColor(0xff, 0x88, 0x00))
+
+		ocean := tf.Themes["test-ocean"]
+		base := GetDefaultColorTheme()
+		So(ocean.Content.Normal.Equals(base.Content.Normal.Foreground(ColorWhite).Background(ColorNavy).Attributes(AttrBold)), ShouldBeTrue)
+		So(ocean.Content.Selected.Equals(StyleDefault.Foreground(tf.Colors["accent"]).Background(ColorNavy)), ShouldBeTrue)
+		So(ocean.Content.FillRune, ShouldEqual, '.')
+		So(ocean.Content.ArrowRunes, ShouldResemble, ArrowRuneSet{'<', '^', 'v', '>'})
+		So(ocean.Content.Active.Equals(base.Content.Active), ShouldBeTrue)
+		So(ocean.Border.BorderRunes.TopLeft, ShouldEqual, '╭')
+		So(ocean.Border.BorderRunes.BottomRight, ShouldEqual, '╯')
+		So(ocean.Border.BorderRunes.CanJoin(), ShouldBeFalse)
+		So(tf.Borders["joined"].CanJoin(), ShouldBeTrue)
+		So(tf.Borders["joined"].Cross, ShouldEqual, '┼')
+		So(ocean.Border.Prelight.Equals(base.Border.Prelight.Foreground(tf.Colors["accent"])), ShouldBeTrue)
+
+		deep := tf.Themes["test-deep"]
+		So(deep.Content.Overlay, ShouldBeTrue)
+		So(deep.Content.FillRune, ShouldEqual, '.')
+
+		_, ok := GetTheme("test-ocean")
+		So(ok, ShouldBeFalse)
+		tf.Register()
+		registered, ok := GetTheme("test-ocean")
+		So(ok, ShouldBeTrue)
+		So(registered.Content.FillRune, ShouldEqual, '.')
+		border, ok := GetDefaultBorderRunes("fancy")
+		So(ok, ShouldBeTrue)
+		So(border.Top, ShouldEqual, '─')
+	})
+	Convey("Parsing YAML theme files", t, func() {
+		tf, err := ParseThemeFileYAML([]byte(testThemeFileYAML))
+		So(err, ShouldBeNil)
+		So(tf.ThemeNames(), ShouldResemble, []ThemeName{"test-yaml-deep", "test-yaml-ocean"})
+		ocean := tf.Themes["test-yaml-ocean"]
+		base := GetDefaultColorTheme()
+		So(ocean.Content.Normal.Equals(base.Content.Normal.Foreground(ColorWhite).Background(ColorNavy).Attributes(AttrBold)), ShouldBeTrue)
+		So(ocean.Content.Selected.Equals(StyleDefault.Foreground(tf.Colors["accent"]).Background(ColorNavy)), ShouldBeTrue)
+		So(ocean.Border.BorderRunes.Cross, ShouldEqual, '┼')
+		So(tf.Themes["test-yaml-deep"].Content.Overlay, ShouldBeTrue)
+		So(tf.Themes["test-yaml-deep"].Content.FillRune, ShouldEqual, '.')
+	})
+	Convey("Loading theme files by extension", t, func() {
+		dir := t.TempDir()
+		for name, doc := range map[string]string{
+			"theme.toml": testThemeFile,
+			"theme.yaml": testThemeFileYAML,
+			"theme.YML":  testThemeFileYAML,
+		} {
+			path := filepath.Join(dir, name)
+			So(os.WriteFile(path, []byte(doc), 0600), ShouldBeNil)
+			tf, err := LoadThemeFile(path)
+			So(err, ShouldBeNil)
+			So(tf.ThemeNames(), ShouldHaveLength, 2)
+		}
+		path := filepath.Join(dir, "yaml-as-toml.toml")
+		So(os.WriteFile(path, []byte(testThemeFileYAML), 0600), ShouldBeNil)
+		_, err := LoadThemeFile(path)
+		So(err, ShouldNotBeNil)
+	})
+	Convey("Theme file errors", t, func() {
+		for _, doc := range []string{
+			"[unknown]\nkey = 1\n",
+			"[borders]\nshort = \"abc\"\n",
+			"[themes.a]\nbase = \"b\"\n[themes.b]\nbase = \"a\"\n",
+			"[themes.a]\nbase = \"missing\"\n",
+			"[themes.a.content]\nnormal = { fg = \"nope\" }\n",
+			"[themes.a.content]\nnormal = { attrs = [\"shiny\"] }\n",
+			"[themes.a.content]\nunknown = 1\n",
+		} {
+			_, err := ParseThemeFile([]byte(doc))
+			So(err, ShouldNotBeNil)
+		}
+	})
+}
@@ -25,7 +25,7 @@ func TestTheme(t *testing.T) {
 		So(
 			GetDefaultMonoTheme().String(),
 			ShouldEqual,
-			"{Content={Normal={white[#ffffff],black[#000000],0},Selected={white[#ffffff],black[#000000],0},Active={white[#ffffff],black[#000000],4},Prelight={white[#ffffff],black[#000000],0},Insensitive={white[#ffffff],black[#000000],16},FillRune=32,BorderRunes={BorderRunes=9488,9472,9484,9474,9492,9472,9496,9474},ArrowRunes={ArrowRunes=8593,8592,8595,8594},Overlay=false},Border={Normal={white[#ffffff],black[#000000],0},Selected={white[#ffffff],black[#000000],0},Active={white[#ffffff],black[#000000],4},Prelight={white[#ffffff],black[#000000],0},Insensitive={white[#ffffff],black[#000000],16},FillRune=32,BorderRunes={BorderRunes=9488,9472,9484,9474,9492,9472,9496,9474},ArrowRunes={ArrowRunes=8593,8592,8595,8594},Overlay=false}}",
+			"{Content={Normal={white[#ffffff],black[#000000],0},Selected={white[#ffffff],black[#000000],0},Active={white[#ffffff],black[#000000],4},Prelight={white[#ffffff],black[#000000],0},Insensitive={white[#ffffff],black[#000000],16},FillRune=32,BorderRunes={BorderRunes=9488,9472,9484,9474,9492,9472,9496,9474,9516,9500,9508,9524,9532},ArrowRunes={ArrowRunes=8593,8592,8595,8594},Overlay=false},Border={Normal={white[#ffffff],black[#000000],0},Selected={white[#ffffff],black[#000000],0},Active={white[#ffffff],black[#000000],4},Prelight={white[#ffffff],black[#000000],0},Insensitive={white[#ffffff],black[#000000],16},FillRune=32,BorderRunes={BorderRunes=9488,9472,9484,9474,9492,9472,9496,9474,9516,9500,9508,9524,9532},ArrowRunes={ArrowRunes=8593,8592,8595,8594},Overlay=false}}",
 		)
 	})
 }
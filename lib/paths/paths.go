@@ -21,19 +21,8 @@ import (
 	"io/ioutil"
 	"os"
 	"time"
-
-	"golang.org/x/sys/unix"
 )
 
-func FileWritable(path string) (writable bool) {
-	if fi, err := os.Stat(path); err == nil {
-		if fi.Mode().IsRegular() {
-			writable = unix.Access(path, unix.W_OK) == nil
-		}
-	}
-	return
-}
-
 func IsPipe(fh *os.File) (piped bool) {
 	if fi, err := fh.Stat(); err == nil {
 		if mode := fi.Mode(); mode > 0 {
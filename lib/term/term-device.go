@@ -15,8 +15,6 @@
 package term
 
 import (
-	"syscall"
-
 	"golang.org/x/exp/constraints"
 )
 
@@ -27,16 +25,3 @@ func DeviceMajor[T constraints.Integer](rdev T) uint64 {
 func DeviceMinor[T constraints.Integer](rdev T) uint64 {
 	return uint64(rdev) & 0xff
 }
-
-// CharDeviceInfo uses syscall.Stat to test if the path exists, is a character device (mode has S_IFCHR), parses the
-// major and minor numbers out of syscall.Stat_t.Rdev and finally determines the TermType value based on the major and
-// minor values
-func CharDeviceInfo(path string) (major, minor uint64, ttyType TermType, yes bool) {
-	stat := syscall.Stat_t{}
-	if err := syscall.Stat(path, &stat); err == nil {
-		if yes = stat.Mode&(syscall.S_IFCHR) == syscall.S_IFCHR; yes {
-			major, minor, ttyType = ParseDeviceInfo(stat.Rdev)
-		}
-	}
-	return
-}
\ No newline at end of file
@@ -1,4 +1,4 @@
-//go:build !unix && !linux && !darwin
+//go:build !unix && !windows
 
 // Copyright (c) 2023  The Go-Curses Authors
 //
@@ -1,4 +1,4 @@
-//go:build !linux && !darwin
+//go:build unix && !linux && !darwin
 
 // Copyright (c) 2023  The Go-Curses Authors
 //
@@ -16,9 +16,6 @@ package term
 
 import (
 	"encoding/binary"
-	"fmt"
-	"syscall"
-	"unsafe"
 )
 
 // ParseDims extracts terminal dimensions (width x height) from the provided buffer.
@@ -38,43 +35,23 @@ func ParseValue(b []byte) (value string) {
 	return
 }
 
+// ParseKeyValue extracts the name and value of an SSH "env" request payload,
+// each of which is a length prefixed string
 func ParseKeyValue(b []byte) (key, value string) {
-	idx := 0
-	for i, c := range b[4:] {
-		if c < 32 || c > 126 {
-			idx = i + 4
-			break
-		}
-		key += string(c)
-	}
-	for _, c := range b[idx:] {
-		if c < 32 || c > 126 {
-			continue
-		}
-		value += string(c)
+	var ok bool
+	if key, b, ok = parseString(b); ok {
+		value, _, _ = parseString(b)
 	}
-	// fmt.Printf("key:%s, value:%s, bytes: %v\n", key, value, b)
 	return
 }
 
-// SetWinSz sets the width and height for the given tty fd
-func SetWinSz(fd uintptr, w, h uint32) (err error) {
-	ws := &struct {
-		Height uint16
-		Width  uint16
-		x      uint16 // unused
-		y      uint16 // unused
-	}{
-		Width:  uint16(w),
-		Height: uint16(h),
+func parseString(b []byte) (value string, rest []byte, ok bool) {
+	if len(b) < 4 {
+		return
 	}
-	_, _, errno := syscall.Syscall(
-		syscall.SYS_IOCTL,
-		fd, uintptr(syscall.TIOCSWINSZ),
-		uintptr(unsafe.Pointer(ws)),
-	)
-	if errno > 0 {
-		return fmt.Errorf("set tiocgwinsz error: [%v] %s", errno, errno.Error())
+	size := binary.BigEndian.Uint32(b)
+	if uint64(len(b)-4) < uint64(size) {
+		return
 	}
-	return nil
+	return string(b[4 : 4+size]), b[4+size:], true
 }
@@ -17,8 +17,6 @@ package cdk
 import (
 	"fmt"
 
-	"github.com/jtolio/gls"
-
 	"github.com/go-curses/cdk/lib/exec"
 	"github.com/go-curses/cdk/log"
 )
@@ -30,10 +28,6 @@ type CLocalContextData struct {
 	Data    interface{}
 }
 
-func Go(fn func()) {
-	gls.Go(fn)
-}
-
 func GetLocalContext() (acd *CLocalContextData, err error) {
 	var lc *exec.CLocalContext
 	if lc, err = exec.GetLocalContext(); err != nil {
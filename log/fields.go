@@ -0,0 +1,126 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	log "github.com/sirupsen/logrus"
+	prefixed "github.com/x-cray/logrus-prefixed-formatter"
+)
+
+// Fields are structured values logged with a message, included as separate
+// keys in FormatJson and FormatText output
+type Fields map[string]interface{}
+
+// The fields describing the object a message is logged by, FormatPretty
+// output leaves these out as messages logged by objects are already tagged
+// with the object name.
+const (
+	FieldObjectID   = "object-id"
+	FieldObjectType = "object-type"
+	FieldObjectName = "object-name"
+	FieldDisplay    = "display"
+)
+
+var objectFields = []string{FieldObjectID, FieldObjectType, FieldObjectName, FieldDisplay}
+
+// Entry logs messages with a set of Fields, see: WithFields
+type Entry struct {
+	fields Fields
+	lazy   func() Fields
+}
+
+// WithFields returns an Entry logging messages with the given fields
+func WithFields(fields Fields) (entry *Entry) {
+	return &Entry{fields: fields.merge(nil)}
+}
+
+// WithFieldsFunc returns an Entry logging messages with the fields returned
+// by the given function, which is only called when a message is logged
+func WithFieldsFunc(fn func() Fields) (entry *Entry) {
+	return &Entry{lazy: fn}
+}
+
+// WithFields returns a new Entry logging messages with the fields of this
+// entry and the given fields, which take precedence
+func (e *Entry) WithFields(fields Fields) (entry *Entry) {
+	if e.lazy != nil {
+		return &Entry{fields: e.fields.merge(fields), lazy: e.lazy}
+	}
+	return &Entry{fields: e.fields.merge(fields)}
+}
+
+// Fields returns a copy of the fields of the entry
+func (e *Entry) Fields() (fields Fields) {
+	if e.lazy != nil {
+		return e.lazy().merge(e.fields)
+	}
+	return e.fields.merge(nil)
+}
+
+func (e *Entry) TraceF(format string, argv ...interface{}) { e.TraceDF(1, format, argv...) }
+func (e *Entry) TraceDF(depth int, format string, argv ...interface{}) {
+	logfWithFields(log.TraceLevel, depth+1, e, format, argv...)
+}
+
+func (e *Entry) DebugF(format string, argv ...interface{}) { e.DebugDF(1, format, argv...) }
+func (e *Entry) DebugDF(depth int, format string, argv ...interface{}) {
+	logfWithFields(log.DebugLevel, depth+1, e, format, argv...)
+}
+
+func (e *Entry) InfoF(format string, argv ...interface{}) { e.InfoDF(1, format, argv...) }
+func (e *Entry) InfoDF(depth int, format string, argv ...interface{}) {
+	logfWithFields(log.InfoLevel, depth+1, e, format, argv...)
+}
+
+func (e *Entry) WarnF(format string, argv ...interface{}) { e.WarnDF(1, format, argv...) }
+func (e *Entry) WarnDF(depth int, format string, argv ...interface{}) {
+	logfWithFields(log.WarnLevel, depth+1, e, format, argv...)
+}
+
+func (e *Entry) Error(err error)                           { e.ErrorDF(1, err.Error()) }
+func (e *Entry) ErrorF(format string, argv ...interface{}) { e.ErrorDF(1, format, argv...) }
+func (e *Entry) ErrorDF(depth int, format string, argv ...interface{}) {
+	logfWithFields(log.ErrorLevel, depth+1, e, format, argv...)
+}
+
+// merge returns a copy of the fields with the other fields added
+func (f Fields) merge(other Fields) (merged Fields) {
+	merged = make(Fields, len(f)+len(other))
+	for k, v := range f {
+		merged[k] = v
+	}
+	for k, v := range other {
+		merged[k] = v
+	}
+	return
+}
+
+// logEntry logs the message with the fields the formatter of the logger
+// includes
+func logEntry(l *log.Logger, level log.Level, fields Fields, message string) {
+	if len(fields) > 0 {
+		if _, pretty := l.Formatter.(*prefixed.TextFormatter); pretty {
+			fields = fields.merge(nil)
+			for _, key := range objectFields {
+				delete(fields, key)
+			}
+		}
+	}
+	if len(fields) == 0 {
+		l.Log(level, message)
+		return
+	}
+	l.WithFields(log.Fields(fields)).Log(level, message)
+}
@@ -19,6 +19,7 @@ package log
 import (
 	"bytes"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"os"
 	"path"
@@ -58,11 +59,15 @@ const (
 	OutputStderr string = "stderr"
 	OutputStdout string = "stdout"
 	OutputFile   string = "file"
+	// OutputSyslog sends log messages to the local syslog daemon, tagged with
+	// GO_CDK_LOG_SYSLOG_TAG, which is also how they reach journald
+	OutputSyslog string = "syslog"
 )
 
 var (
 	logger        = log.New()
-	logFileHandle *os.File
+	logWriter     io.Writer
+	logFileHandle *rotatingFile
 	logFullPaths  = false
 	logBuffer     = bytes.NewBufferString("")
 
@@ -86,10 +91,66 @@ func SetExitFunc(fn func(int)) {
 }
 
 func StartRestart() error {
-	disableTimestamp := true
-	if v := env.Get("GO_CDK_LOG_TIMESTAMPS", "false"); v == "true" {
-		disableTimestamp = false
+	formatter := newFormatter(env.Get("GO_CDK_LOG_FORMAT", FormatPretty), env.Get("GO_CDK_LOG_TIMESTAMPS", "false") == "true")
+	switch env.Get("GO_CDK_LOG_FULL_PATHS", "false") {
+	case "true":
+		logFullPaths = true
+	default:
+		logFullPaths = false
+	}
+	logger.SetFormatter(formatter)
+	logger.SetLevel(parseLevel(env.Get("GO_CDK_LOG_LEVEL", LevelError)))
+	logger.ReplaceHooks(make(log.LevelHooks))
+	if logWriter != nil {
+		// set programmatically, see: SetWriter
+		_ = Stop()
+		logger.SetOutput(logWriter)
+		return nil
+	}
+	switch env.Get("GO_CDK_LOG_OUTPUT", OutputFile) {
+	case OutputStdout:
+		logger.SetOutput(os.Stdout)
+	case OutputStderr:
+		logger.SetOutput(os.Stderr)
+	case OutputSyslog:
+		_ = Stop()
+		hook, err := newSyslogHook(env.Get("GO_CDK_LOG_SYSLOG_TAG", ""))
+		if err != nil {
+			return err
+		}
+		logger.AddHook(hook)
+		logger.SetOutput(ioutil.Discard)
+	case OutputFile:
+		fallthrough
+	default:
+		_ = Stop()
+		if logfile := env.Get("GO_CDK_LOG_FILE", DefaultLogPath); !cstrings.IsEmpty(logfile) && logfile != "/dev/null" {
+			logFH, err := openRotatingFileFromEnv(logfile)
+			if err != nil {
+				return err
+			}
+			logFileHandle = logFH
+			_, _ = logFileHandle.WriteString(logBuffer.String())
+			logBuffer.Reset()
+			logger.SetOutput(logFileHandle)
+		} else {
+			logger.SetOutput(ioutil.Discard)
+		}
 	}
+	return nil
+}
+
+// SetWriter sends log messages to the given writer instead of the output
+// selected with GO_CDK_LOG_OUTPUT, the level and format are still configured
+// by the environment. Passing nil restores the output of the environment.
+func SetWriter(w io.Writer) (err error) {
+	logWriter = w
+	return StartRestart()
+}
+
+// newFormatter returns the formatter of the given format, one of FormatPretty,
+// FormatText or FormatJson, using the GO_CDK_LOG_TIMESTAMP_FORMAT setting
+func newFormatter(format string, timestamps bool) log.Formatter {
 	timestampFormat := DefaultTimestampFormat
 	if v := env.Get("GO_CDK_LOG_TIMESTAMP_FORMAT", ""); v != "" {
 		if v == "standard" {
@@ -100,75 +161,51 @@ func StartRestart() error {
 			timestampFormat = v
 		}
 	}
-	switch env.Get("GO_CDK_LOG_FULL_PATHS", "false") {
-	case "true":
-		logFullPaths = true
-	default:
-		logFullPaths = false
-	}
-	switch env.Get("GO_CDK_LOG_FORMAT", "pretty") {
+	switch format {
 	case FormatJson:
-		logger.SetFormatter(&log.JSONFormatter{
+		return &log.JSONFormatter{
 			TimestampFormat:  timestampFormat,
-			DisableTimestamp: disableTimestamp,
-		})
+			DisableTimestamp: !timestamps,
+		}
 	case FormatText:
-		logger.SetFormatter(&log.TextFormatter{
+		return &log.TextFormatter{
 			TimestampFormat:  timestampFormat,
-			DisableTimestamp: disableTimestamp,
+			DisableTimestamp: !timestamps,
 			DisableSorting:   true,
 			DisableColors:    true,
 			FullTimestamp:    true,
-		})
+		}
 	case FormatPretty:
 		fallthrough
 	default:
-		logger.SetFormatter(&prefixed.TextFormatter{
-			DisableTimestamp: disableTimestamp,
+		return &prefixed.TextFormatter{
+			DisableTimestamp: !timestamps,
 			TimestampFormat:  timestampFormat,
 			ForceFormatting:  true,
 			FullTimestamp:    true,
 			DisableSorting:   true,
 			DisableColors:    true,
-		})
+		}
 	}
-	switch env.Get("GO_CDK_LOG_LEVEL", LevelError) {
+}
+
+// parseLevel returns the logrus level of the given level name, one of the
+// LogLevels, unknown names are LevelError
+func parseLevel(level string) log.Level {
+	switch level {
 	case LevelTrace:
-		logger.SetLevel(log.TraceLevel)
+		return log.TraceLevel
 	case LevelDebug:
-		logger.SetLevel(log.DebugLevel)
+		return log.DebugLevel
 	case LevelInfo:
-		logger.SetLevel(log.InfoLevel)
+		return log.InfoLevel
 	case LevelWarn:
-		logger.SetLevel(log.WarnLevel)
+		return log.WarnLevel
 	case LevelError:
 		fallthrough
 	default:
-		logger.SetLevel(log.ErrorLevel)
-	}
-	switch env.Get("GO_CDK_LOG_OUTPUT", OutputFile) {
-	case OutputStdout:
-		logger.SetOutput(os.Stdout)
-	case OutputStderr:
-		logger.SetOutput(os.Stderr)
-	case OutputFile:
-		fallthrough
-	default:
-		_ = Stop()
-		if logfile := env.Get("GO_CDK_LOG_FILE", DefaultLogPath); !cstrings.IsEmpty(logfile) && logfile != "/dev/null" {
-			logFH, err := os.OpenFile(logfile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
-			if err != nil {
-				return err
-			}
-			logFileHandle = logFH
-			_, _ = logFileHandle.WriteString(logBuffer.String())
-			logBuffer.Reset()
-			logger.SetOutput(logFileHandle)
-		} else {
-			logger.SetOutput(ioutil.Discard)
-		}
+		return log.ErrorLevel
 	}
-	return nil
 }
 
 func Stop() error {
@@ -227,28 +264,28 @@ func getLogPrefix(depth int) string {
 
 func TraceF(format string, argv ...interface{}) { TraceDF(1, format, argv...) }
 func TraceDF(depth int, format string, argv ...interface{}) {
-	logger.Tracef(cstrings.NLSprintf("%s	%s", getLogPrefix(depth+1), format), argv...)
+	logf(log.TraceLevel, depth+1, format, argv...)
 }
 
 func DebugF(format string, argv ...interface{}) { DebugDF(1, format, argv...) }
 func DebugDF(depth int, format string, argv ...interface{}) {
-	logger.Debugf(cstrings.NLSprintf("%s	%s", getLogPrefix(depth+1), format), argv...)
+	logf(log.DebugLevel, depth+1, format, argv...)
 }
 
 func InfoF(format string, argv ...interface{}) { InfoDF(1, format, argv...) }
 func InfoDF(depth int, format string, argv ...interface{}) {
-	logger.Infof(cstrings.NLSprintf("%s	%s", getLogPrefix(depth+1), format), argv...)
+	logf(log.InfoLevel, depth+1, format, argv...)
 }
 
 func WarnF(format string, argv ...interface{}) { WarnDF(1, format, argv...) }
 func WarnDF(depth int, format string, argv ...interface{}) {
-	logger.Warnf(cstrings.NLSprintf("%s	%s", getLogPrefix(depth+1), format), argv...)
+	logf(log.WarnLevel, depth+1, format, argv...)
 }
 
 func Error(err error)                           { ErrorDF(1, err.Error()) }
 func ErrorF(format string, argv ...interface{}) { ErrorDF(1, format, argv...) }
 func ErrorDF(depth int, format string, argv ...interface{}) {
-	logger.Errorf(cstrings.NLSprintf("%s	%s", getLogPrefix(depth+1), format), argv...)
+	logf(log.ErrorLevel, depth+1, format, argv...)
 }
 
 func Fatal(err error)                           { FatalDF(1, err.Error()) }
@@ -258,6 +295,7 @@ func FatalDF(depth int, format string, argv ...interface{}) {
 	// 	dm.ReleaseDisplay()
 	// }
 	message := fmt.Sprintf(cstrings.NLSprintf("%s\t%s", getLogPrefix(depth+1), format), argv...)
+	logSinks(log.FatalLevel, nil, message)
 	logger.Fatalf(message)
 }
 
@@ -268,11 +306,31 @@ func PanicDF(depth int, format string, argv ...interface{}) {
 	// 	dm.ReleaseDisplay()
 	// }
 	message := fmt.Sprintf(cstrings.NLSprintf("%s\t%s", getLogPrefix(depth+1), format), argv...)
+	logSinks(log.ErrorLevel, nil, message)
 	logger.Errorf(message)
 	_ = Stop()
 	panic(message)
 }
 
+// logf sends the message to the output and each of the sinks enabled for the
+// level
+func logf(level log.Level, depth int, format string, argv ...interface{}) {
+	logfWithFields(level, depth+1, nil, format, argv...)
+}
+
+func logfWithFields(level log.Level, depth int, entry *Entry, format string, argv ...interface{}) {
+	if !logger.IsLevelEnabled(level) && !sinksEnabled(level) {
+		return
+	}
+	var fields Fields
+	if entry != nil {
+		fields = entry.Fields()
+	}
+	message := fmt.Sprintf(cstrings.NLSprintf("%s\t%s", getLogPrefix(depth+1), format), argv...)
+	logSinks(level, fields, message)
+	logEntry(logger, level, fields, message)
+}
+
 func Exit(code int) {
 	InfoDF(1, "exiting with code: %d", code)
 	logger.Exit(code)
@@ -15,9 +15,13 @@
 package log
 
 import (
+	"bytes"
 	ejson "encoding/json"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -282,3 +286,147 @@ func TestLoggingToFiles(t *testing.T) {
 		_ = StartRestart()
 	})
 }
+
+func TestLoggingSinks(t *testing.T) {
+	Convey("Logging writer and sink checks", t, func() {
+		env.Set("GO_CDK_LOG_FORMAT", "pretty")
+		env.Set("GO_CDK_LOG_LEVEL", "error")
+		output := bytes.NewBufferString("")
+		So(SetWriter(output), ShouldBeNil)
+		So(logFileHandle, ShouldBeNil)
+		debug := bytes.NewBufferString("")
+		warnings := bytes.NewBufferString("")
+		So(AddSink("nil", Sink{}), ShouldNotBeNil)
+		So(AddSink("debug", Sink{Writer: debug, Level: LevelDebug}), ShouldBeNil)
+		So(AddSink("warnings", Sink{Writer: warnings, Level: LevelWarn, Format: FormatJson}), ShouldBeNil)
+		So(GetSinkNames(), ShouldResemble, []string{"debug", "warnings"})
+		TraceF("tracing")
+		DebugF("debugging")
+		WarnF("warning")
+		ErrorF("failing")
+		So(output.String(), ShouldStartWith, "ERROR")
+		So(output.String(), ShouldNotContainSubstring, "warning")
+		So(debug.String(), ShouldStartWith, "level=debug")
+		So(debug.String(), ShouldNotContainSubstring, "tracing")
+		So(strings.Count(debug.String(), "\n"), ShouldEqual, 3)
+		So(strings.Count(warnings.String(), "\n"), ShouldEqual, 2)
+		decoded := make(map[string]interface{})
+		So(ejson.Unmarshal([]byte(strings.SplitN(warnings.String(), "\n", 2)[0]), &decoded), ShouldBeNil)
+		So(decoded["level"], ShouldEqual, "warning")
+		So(GetLevel(), ShouldEqual, LevelError)
+		RemoveSink("debug")
+		RemoveSink("warnings")
+		So(GetSinkNames(), ShouldBeEmpty)
+		DebugF("ignored")
+		So(debug.String(), ShouldNotContainSubstring, "ignored")
+		// restore default logging
+		So(SetWriter(nil), ShouldBeNil)
+	})
+}
+
+func TestLoggingFields(t *testing.T) {
+	Convey("Logging structured field checks", t, func() {
+		env.Set("GO_CDK_LOG_LEVEL", "error")
+		env.Set("GO_CDK_LOG_FORMAT", "json")
+		output := bytes.NewBufferString("")
+		So(SetWriter(output), ShouldBeNil)
+		entry := WithFields(Fields{FieldObjectID: "1234", FieldDisplay: "client"})
+		entry.WithFields(Fields{"session": 7}).ErrorF("testing %d", 1)
+		decoded := make(map[string]interface{})
+		So(ejson.Unmarshal(output.Bytes(), &decoded), ShouldBeNil)
+		So(decoded[FieldObjectID], ShouldEqual, "1234")
+		So(decoded[FieldDisplay], ShouldEqual, "client")
+		So(decoded["session"], ShouldEqual, 7)
+		So(decoded["msg"], ShouldContainSubstring, "log_test.go")
+		So(decoded["msg"], ShouldEndWith, "testing 1")
+		So(entry.Fields(), ShouldHaveLength, 2)
+
+		// pretty output leaves out the object fields
+		env.Set("GO_CDK_LOG_FORMAT", "pretty")
+		output.Reset()
+		So(SetWriter(output), ShouldBeNil)
+		entry.WithFields(Fields{"session": 7}).ErrorF("testing")
+		So(output.String(), ShouldNotContainSubstring, "client")
+		So(output.String(), ShouldContainSubstring, "session=7")
+		// restore default logging
+		So(SetWriter(nil), ShouldBeNil)
+	})
+}
+
+func TestLoggingRotation(t *testing.T) {
+	Convey("Logging file rotation checks", t, func() {
+		for size, expected := range map[string]int64{"0": 0, "100": 100, "2K": 2048, "10M": 10 << 20, "1gb": 1 << 30} {
+			bytes, err := parseSize(size)
+			So(err, ShouldBeNil)
+			So(bytes, ShouldEqual, expected)
+		}
+		_, err := parseSize("lots")
+		So(err, ShouldNotBeNil)
+		_, err = parseSize("-1")
+		So(err, ShouldNotBeNil)
+
+		dir, err := ioutil.TempDir("", "cdk-log-rotation")
+		So(err, ShouldBeNil)
+		defer func() { _ = os.RemoveAll(dir) }()
+		logfile := filepath.Join(dir, "cdk.log")
+		env.Set("GO_CDK_LOG_OUTPUT", "file")
+		env.Set("GO_CDK_LOG_FORMAT", "text")
+		env.Set("GO_CDK_LOG_LEVEL", "error")
+		env.Set("GO_CDK_LOG_FILE", logfile)
+		env.Set("GO_CDK_LOG_FILE_MAX_SIZE", "1K")
+		env.Set("GO_CDK_LOG_FILE_MAX_BACKUPS", "2")
+		env.Set("GO_CDK_LOG_FILE_COMPRESS", "true")
+		defer func() {
+			env.Set("GO_CDK_LOG_FILE_MAX_SIZE", "0")
+			env.Set("GO_CDK_LOG_FILE_MAX_BACKUPS", "")
+			env.Set("GO_CDK_LOG_FILE_COMPRESS", "false")
+			env.Set("GO_CDK_LOG_FILE", DefaultLogPath)
+			_ = StartRestart()
+		}()
+		So(SetWriter(nil), ShouldBeNil)
+		So(logFileHandle, ShouldNotBeNil)
+		// many clients logging at once
+		wg := &sync.WaitGroup{}
+		for i := 0; i < 8; i++ {
+			wg.Add(1)
+			go func(client int) {
+				defer wg.Done()
+				for j := 0; j < 50; j++ {
+					ErrorF("client %d message %d", client, j)
+				}
+			}(i)
+		}
+		wg.Wait()
+		So(Stop(), ShouldBeNil)
+		info, err := os.Stat(logfile)
+		So(err, ShouldBeNil)
+		So(info.Size(), ShouldBeGreaterThan, 0)
+		So(info.Size(), ShouldBeLessThanOrEqualTo, 1024)
+		for _, backup := range []string{".1.gz", ".2.gz"} {
+			So(cpaths.IsFile(logfile+backup), ShouldBeTrue)
+		}
+		for _, backup := range []string{".1", ".2", ".3", ".3.gz"} {
+			So(cpaths.IsFile(logfile+backup), ShouldBeFalse)
+		}
+		logged, err := ioutil.ReadFile(logfile)
+		So(err, ShouldBeNil)
+		So(strings.HasSuffix(string(logged), "\n"), ShouldBeTrue)
+		So(Rotate(), ShouldNotBeNil)
+
+		env.Set("GO_CDK_LOG_FILE_MAX_SIZE", "0")
+		env.Set("GO_CDK_LOG_FILE_COMPRESS", "false")
+		So(StartRestart(), ShouldBeNil)
+		So(Rotate(), ShouldBeNil)
+		ErrorF("after rotation")
+		So(Stop(), ShouldBeNil)
+		So(cpaths.IsFile(logfile+".1"), ShouldBeTrue)
+		So(cpaths.IsFile(logfile+".2.gz"), ShouldBeTrue)
+		logged, err = ioutil.ReadFile(logfile)
+		So(err, ShouldBeNil)
+		So(string(logged), ShouldContainSubstring, "after rotation")
+		So(string(logged), ShouldNotContainSubstring, "client")
+
+		env.Set("GO_CDK_LOG_FILE_MAX_BACKUPS", "none")
+		So(StartRestart(), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,254 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-curses/cdk/env"
+)
+
+const (
+	// DefaultLogFileMaxBackups is the number of rotated log files kept when
+	// GO_CDK_LOG_FILE_MAX_BACKUPS is not set
+	DefaultLogFileMaxBackups = 3
+)
+
+// rotatingFile is the GO_CDK_LOG_FILE output, appending to the log file until
+// writing a message would grow it past maxSize, at which point the log file
+// is renamed to the first backup (path.1), older backups are shifted along
+// (path.2, path.3, ...) and a new log file is started. Only maxBackups backups
+// are kept, compressed with gzip (path.1.gz) if compress is set. Each message
+// is written whole to a single file and writes are serialized, so rotating is
+// safe while many ApplicationServer clients are logging concurrently.
+type rotatingFile struct {
+	path       string
+	maxSize    int64
+	maxBackups int
+	compress   bool
+
+	file        *os.File
+	size        int64
+	compressing sync.WaitGroup
+	lock        sync.Mutex
+}
+
+// openRotatingFile opens the log file for appending, rotating it once it
+// grows past maxSize bytes, or never if maxSize is zero
+func openRotatingFile(path string, maxSize int64, maxBackups int, compress bool) (f *rotatingFile, err error) {
+	f = &rotatingFile{
+		path:       path,
+		maxSize:    maxSize,
+		maxBackups: maxBackups,
+		compress:   compress,
+	}
+	if err = f.open(); err != nil {
+		return nil, err
+	}
+	return
+}
+
+// openRotatingFileFromEnv opens the log file with the rotation settings of
+// GO_CDK_LOG_FILE_MAX_SIZE, GO_CDK_LOG_FILE_MAX_BACKUPS and
+// GO_CDK_LOG_FILE_COMPRESS
+func openRotatingFileFromEnv(path string) (f *rotatingFile, err error) {
+	var maxSize int64
+	if maxSize, err = parseSize(env.Get("GO_CDK_LOG_FILE_MAX_SIZE", "0")); err != nil {
+		return nil, err
+	}
+	maxBackups := DefaultLogFileMaxBackups
+	if v := env.Get("GO_CDK_LOG_FILE_MAX_BACKUPS", ""); v != "" {
+		if maxBackups, err = strconv.Atoi(v); err != nil || maxBackups < 0 {
+			return nil, fmt.Errorf("invalid GO_CDK_LOG_FILE_MAX_BACKUPS: %q", v)
+		}
+	}
+	compress := env.Get("GO_CDK_LOG_FILE_COMPRESS", "false") == "true"
+	return openRotatingFile(path, maxSize, maxBackups, compress)
+}
+
+func (f *rotatingFile) open() (err error) {
+	var fh *os.File
+	if fh, err = os.OpenFile(f.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600); err != nil {
+		return
+	}
+	var info os.FileInfo
+	if info, err = fh.Stat(); err != nil {
+		_ = fh.Close()
+		return
+	}
+	f.file, f.size = fh, info.Size()
+	return
+}
+
+// Write appends the message to the log file, rotating the log file first if
+// the message would grow it past the maximum size
+func (f *rotatingFile) Write(p []byte) (n int, err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return 0, os.ErrClosed
+	}
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err = f.rotate(); err != nil {
+			return 0, err
+		}
+	}
+	n, err = f.file.Write(p)
+	f.size += int64(n)
+	return
+}
+
+// WriteString appends the string to the log file, see: Write
+func (f *rotatingFile) WriteString(s string) (n int, err error) {
+	return f.Write([]byte(s))
+}
+
+// Rotate starts a new log file regardless of the size of the current one
+func (f *rotatingFile) Rotate() (err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	return f.rotate()
+}
+
+// Close closes the log file, waiting for any backup being compressed
+func (f *rotatingFile) Close() (err error) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.compressing.Wait()
+	if f.file == nil {
+		return os.ErrClosed
+	}
+	err = f.file.Close()
+	f.file = nil
+	return
+}
+
+// rotate moves the log file to the first backup and opens a new log file, the
+// caller must hold the lock
+func (f *rotatingFile) rotate() (err error) {
+	if err = f.file.Close(); err != nil {
+		return
+	}
+	f.file = nil
+	// backups are shifted by name, wait for the last one to be compressed
+	f.compressing.Wait()
+	if f.maxBackups > 0 {
+		f.removeBackup(f.maxBackups)
+		for i := f.maxBackups - 1; i > 0; i-- {
+			f.renameBackup(i, i+1)
+		}
+		backup := f.backupPath(1)
+		if err = os.Rename(f.path, backup); err == nil && f.compress {
+			f.compressing.Add(1)
+			go func() {
+				defer f.compressing.Done()
+				if ee := compressFile(backup); ee != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "error compressing log file: %v\n", ee)
+				}
+			}()
+		}
+	} else {
+		err = os.Remove(f.path)
+	}
+	// keep logging to the current file if it could not be moved aside
+	if ee := f.open(); err == nil {
+		err = ee
+	}
+	return
+}
+
+// Rotate starts a new GO_CDK_LOG_FILE, keeping the current one as a backup,
+// regardless of the GO_CDK_LOG_FILE_MAX_SIZE. Returns an error if the log
+// messages are not being written to a file.
+func Rotate() (err error) {
+	if fh := logFileHandle; fh != nil {
+		return fh.Rotate()
+	}
+	return fmt.Errorf("not logging to a file")
+}
+
+func (f *rotatingFile) backupPath(index int) string {
+	return f.path + "." + strconv.Itoa(index)
+}
+
+func (f *rotatingFile) removeBackup(index int) {
+	backup := f.backupPath(index)
+	_ = os.Remove(backup)
+	_ = os.Remove(backup + ".gz")
+}
+
+func (f *rotatingFile) renameBackup(from, to int) {
+	src, dst := f.backupPath(from), f.backupPath(to)
+	_ = os.Rename(src, dst)
+	_ = os.Rename(src+".gz", dst+".gz")
+}
+
+// compressFile replaces the file with a gzip compressed copy named path.gz
+func compressFile(path string) (err error) {
+	var src, dst *os.File
+	if src, err = os.Open(path); err != nil {
+		return
+	}
+	defer func() { _ = src.Close() }()
+	if dst, err = os.OpenFile(path+".gz", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600); err != nil {
+		return
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if ee := dst.Close(); err == nil {
+		err = ee
+	}
+	if err != nil {
+		_ = os.Remove(path + ".gz")
+		return
+	}
+	return os.Remove(path)
+}
+
+// parseSize returns the number of bytes of a size given as a number of bytes
+// with an optional K, M or G suffix (1024 based), for example: "10M"
+func parseSize(size string) (bytes int64, err error) {
+	value := strings.ToUpper(strings.TrimSpace(size))
+	value = strings.TrimSuffix(value, "B")
+	multiplier := int64(1)
+	if n := len(value); n > 0 {
+		switch value[n-1] {
+		case 'K':
+			multiplier = 1 << 10
+		case 'M':
+			multiplier = 1 << 20
+		case 'G':
+			multiplier = 1 << 30
+		}
+		if multiplier > 1 {
+			value = value[:n-1]
+		}
+	}
+	if bytes, err = strconv.ParseInt(value, 10, 64); err != nil || bytes < 0 {
+		return 0, fmt.Errorf("invalid size: %q", size)
+	}
+	return bytes * multiplier, nil
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Sink is an additional destination for log messages, receiving every message
+// at or above its own level regardless of the GO_CDK_LOG_LEVEL of the output.
+// This allows embedding applications to route log messages into their own
+// logging, see: AddSink.
+type Sink struct {
+	// Writer receives each formatted message in a single write
+	Writer io.Writer
+	// Level is one of the LogLevels, defaulting to LevelError
+	Level string
+	// Format is one of FormatPretty, FormatText or FormatJson, defaulting to
+	// FormatText
+	Format string
+	// Timestamps includes the time of each message, formatted with the
+	// GO_CDK_LOG_TIMESTAMP_FORMAT setting
+	Timestamps bool
+
+	hook log.Hook
+}
+
+var (
+	sinks     = make(map[string]*log.Logger)
+	sinksLock = &sync.RWMutex{}
+)
+
+// AddSink sends log messages to the sink, replacing any sink of the same name
+func AddSink(name string, sink Sink) (err error) {
+	if sink.Writer == nil && sink.hook == nil {
+		return fmt.Errorf("log sink has no writer: %v", name)
+	}
+	if sink.Format == "" {
+		sink.Format = FormatText
+	}
+	sl := log.New()
+	sl.SetFormatter(newFormatter(sink.Format, sink.Timestamps))
+	sl.SetLevel(parseLevel(sink.Level))
+	if sink.hook != nil {
+		sl.AddHook(sink.hook)
+		sl.SetOutput(ioutil.Discard)
+	} else {
+		sl.SetOutput(sink.Writer)
+	}
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	sinks[name] = sl
+	return nil
+}
+
+// RemoveSink stops sending log messages to the named sink
+func RemoveSink(name string) {
+	sinksLock.Lock()
+	defer sinksLock.Unlock()
+	delete(sinks, name)
+}
+
+// GetSinkNames returns the names of the sinks added, sorted
+func GetSinkNames() (names []string) {
+	sinksLock.RLock()
+	defer sinksLock.RUnlock()
+	for name := range sinks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return
+}
+
+// SyslogSink returns a Sink sending messages to the syslog daemon at the
+// network address, or the local daemon if both network and raddr are empty,
+// using the syslog severity of each message level. The systemd journal also
+// receives messages sent to the local daemon.
+func SyslogSink(network, raddr, tag, level string) (sink Sink, err error) {
+	if sink.hook, err = dialSyslogHook(network, raddr, tag); err != nil {
+		return
+	}
+	sink.Level = level
+	return
+}
+
+// sinksEnabled returns true if any sink receives messages of the level
+func sinksEnabled(level log.Level) bool {
+	sinksLock.RLock()
+	defer sinksLock.RUnlock()
+	for _, sl := range sinks {
+		if sl.IsLevelEnabled(level) {
+			return true
+		}
+	}
+	return false
+}
+
+// logSinks sends the message to each sink receiving messages of the level
+func logSinks(level log.Level, fields Fields, message string) {
+	sinksLock.RLock()
+	defer sinksLock.RUnlock()
+	for _, sl := range sinks {
+		if sl.IsLevelEnabled(level) {
+			logEntry(sl, level, fields, message)
+		}
+	}
+}
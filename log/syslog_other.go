@@ -0,0 +1,32 @@
+//go:build windows || plan9 || nacl
+// +build windows plan9 nacl
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+func newSyslogHook(tag string) (hook log.Hook, err error) {
+	return dialSyslogHook("", "", tag)
+}
+
+func dialSyslogHook(network, raddr, tag string) (hook log.Hook, err error) {
+	return nil, fmt.Errorf("syslog is not supported on this platform")
+}
@@ -0,0 +1,40 @@
+//go:build !windows && !plan9 && !nacl
+// +build !windows,!plan9,!nacl
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package log
+
+import (
+	"log/syslog"
+
+	log "github.com/sirupsen/logrus"
+	lsyslog "github.com/sirupsen/logrus/hooks/syslog"
+)
+
+// newSyslogHook returns a hook sending messages to the local syslog daemon
+func newSyslogHook(tag string) (hook log.Hook, err error) {
+	return dialSyslogHook("", "", tag)
+}
+
+// dialSyslogHook returns a hook sending messages to the syslog daemon at the
+// network address, using the user facility
+func dialSyslogHook(network, raddr, tag string) (hook log.Hook, err error) {
+	var h *lsyslog.SyslogHook
+	if h, err = lsyslog.NewSyslogHook(network, raddr, syslog.LOG_USER|syslog.LOG_DEBUG, tag); err != nil {
+		return nil, err
+	}
+	return h, nil
+}
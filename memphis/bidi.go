@@ -0,0 +1,425 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"unicode"
+
+	"golang.org/x/text/unicode/bidi"
+)
+
+// TextDirection is the base direction of a paragraph of text
+type TextDirection uint8
+
+const (
+	// TextDirectionAuto uses the direction of the first strong character of
+	// each paragraph, left-to-right if there are none
+	TextDirectionAuto TextDirection = iota
+	TextDirectionLTR
+	TextDirectionRTL
+)
+
+func (d TextDirection) String() string {
+	switch d {
+	case TextDirectionAuto:
+		return "auto"
+	case TextDirectionLTR:
+		return "ltr"
+	case TextDirectionRTL:
+		return "rtl"
+	}
+	return "unknown"
+}
+
+// firstRTLRune is the first code point with a right-to-left bidi class, every
+// rune before it is either left-to-right or neutral
+const firstRTLRune = 0x0590
+
+// DetectTextDirection returns the direction of the first strong character in
+// the text, following rules P2 and P3 of the Unicode Bidirectional Algorithm
+// (UAX #9), or TextDirectionLTR if there is none
+func DetectTextDirection(text string) TextDirection {
+	for _, r := range text {
+		if r == '\n' {
+			break
+		}
+		if dir, ok := strongDirection(r); ok {
+			return dir
+		}
+	}
+	return TextDirectionLTR
+}
+
+func strongDirection(r rune) (dir TextDirection, ok bool) {
+	if r < firstRTLRune {
+		if unicode.IsLetter(r) {
+			return TextDirectionLTR, true
+		}
+		return
+	}
+	switch bidiClass(r) {
+	case bidi.L:
+		return TextDirectionLTR, true
+	case bidi.R, bidi.AL:
+		return TextDirectionRTL, true
+	}
+	return
+}
+
+func bidiClass(r rune) bidi.Class {
+	p, _ := bidi.LookupRune(r)
+	return p.Class()
+}
+
+// isRTLRune returns true if the rune is right-to-left or an Arabic number
+func isRTLRune(r rune) bool {
+	if r >= firstRTLRune {
+		switch bidiClass(r) {
+		case bidi.R, bidi.AL, bidi.AN:
+			return true
+		}
+	}
+	return false
+}
+
+func hasRTL(runes []rune) bool {
+	for _, r := range runes {
+		if isRTLRune(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolveBidiLevels returns the embedding level of each rune of a single line
+// of text. This implements the weak, neutral and implicit rules of UAX #9
+// (W1-W7, N1-N2, I1-I2) and the line rule L1. Explicit embedding, override
+// and isolate formatting characters are not supported: embeddings and
+// overrides are ignored and isolates are treated as neutrals.
+func resolveBidiLevels(runes []rune, rtl bool) (levels []uint8) {
+	n := len(runes)
+	base := uint8(0)
+	sos := bidi.L
+	if rtl {
+		base = 1
+		sos = bidi.R
+	}
+	original := make([]bidi.Class, n)
+	types := make([]bidi.Class, n)
+	for i, r := range runes {
+		c := bidiClass(r)
+		switch c {
+		case bidi.LRO, bidi.RLO, bidi.LRE, bidi.RLE, bidi.PDF:
+			c = bidi.BN
+		}
+		original[i] = c
+		switch c {
+		case bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI:
+			c = bidi.ON
+		}
+		types[i] = c
+	}
+
+	// W1: non-spacing marks take the type of the previous character
+	prev := sos
+	for i, t := range types {
+		switch t {
+		case bidi.BN:
+			continue
+		case bidi.NSM:
+			types[i] = prev
+		}
+		prev = types[i]
+	}
+	// W2: european numbers following arabic letters are arabic numbers
+	// W3: arabic letters are right-to-left
+	lastStrong := sos
+	for i, t := range types {
+		switch t {
+		case bidi.L, bidi.R:
+			lastStrong = t
+		case bidi.AL:
+			lastStrong = t
+			types[i] = bidi.R
+		case bidi.EN:
+			if lastStrong == bidi.AL {
+				types[i] = bidi.AN
+			}
+		}
+	}
+	// W4: a single separator between two numbers of the same type
+	for i := 1; i < n-1; i++ {
+		before, after := types[i-1], types[i+1]
+		switch types[i] {
+		case bidi.ES:
+			if before == bidi.EN && after == bidi.EN {
+				types[i] = bidi.EN
+			}
+		case bidi.CS:
+			if before == after && (before == bidi.EN || before == bidi.AN) {
+				types[i] = before
+			}
+		}
+	}
+	// W5: terminators adjacent to european numbers
+	for i := 0; i < n; i++ {
+		if types[i] != bidi.ET {
+			continue
+		}
+		end := i
+		for end < n && types[end] == bidi.ET {
+			end++
+		}
+		if (i > 0 && types[i-1] == bidi.EN) || (end < n && types[end] == bidi.EN) {
+			for j := i; j < end; j++ {
+				types[j] = bidi.EN
+			}
+		}
+		i = end - 1
+	}
+	// W6: remaining separators and terminators are neutral
+	// W7: european numbers following left-to-right text are left-to-right
+	lastStrong = sos
+	for i, t := range types {
+		switch t {
+		case bidi.ES, bidi.ET, bidi.CS:
+			types[i] = bidi.ON
+		case bidi.L, bidi.R:
+			lastStrong = t
+		case bidi.EN:
+			if lastStrong == bidi.L {
+				types[i] = bidi.L
+			}
+		}
+	}
+	// N1, N2: neutrals take the direction of the surrounding text when it
+	// agrees, otherwise the embedding direction
+	strong := func(t bidi.Class) (bidi.Class, bool) {
+		switch t {
+		case bidi.L:
+			return bidi.L, true
+		case bidi.R, bidi.EN, bidi.AN:
+			return bidi.R, true
+		}
+		return t, false
+	}
+	for i := 0; i < n; i++ {
+		if _, ok := strong(types[i]); ok {
+			continue
+		}
+		end := i
+		for end < n {
+			if _, ok := strong(types[end]); ok {
+				break
+			}
+			end++
+		}
+		before, after := sos, sos
+		if i > 0 {
+			before, _ = strong(types[i-1])
+		}
+		if end < n {
+			after, _ = strong(types[end])
+		}
+		resolved := sos
+		if before == after {
+			resolved = before
+		}
+		for j := i; j < end; j++ {
+			types[j] = resolved
+		}
+		i = end - 1
+	}
+	// I1, I2: implicit levels
+	levels = make([]uint8, n)
+	for i, t := range types {
+		levels[i] = base
+		if base%2 == 0 {
+			switch t {
+			case bidi.R:
+				levels[i] += 1
+			case bidi.AN, bidi.EN:
+				levels[i] += 2
+			}
+		} else {
+			switch t {
+			case bidi.L, bidi.EN, bidi.AN:
+				levels[i] += 1
+			}
+		}
+	}
+	// L1: separators and trailing whitespace are reset to the base level
+	trailing := true
+	for i := n - 1; i >= 0; i-- {
+		switch original[i] {
+		case bidi.S, bidi.B:
+			levels[i] = base
+			trailing = true
+		case bidi.WS, bidi.BN, bidi.LRI, bidi.RLI, bidi.FSI, bidi.PDI:
+			if trailing {
+				levels[i] = base
+			}
+		default:
+			trailing = false
+		}
+	}
+	return
+}
+
+// reorderBidiLevels returns the logical index of each visual position, rule
+// L2 of UAX #9
+func reorderBidiLevels(levels []uint8) (order []int) {
+	order = make([]int, len(levels))
+	var highest, lowestOdd uint8 = 0, 255
+	for i, level := range levels {
+		order[i] = i
+		if level > highest {
+			highest = level
+		}
+		if level%2 == 1 && level < lowestOdd {
+			lowestOdd = level
+		}
+	}
+	for level := highest; level >= lowestOdd && level > 0; level-- {
+		for i := 0; i < len(levels); i++ {
+			if levels[order[i]] < level {
+				continue
+			}
+			end := i
+			for end < len(levels) && levels[order[end]] >= level {
+				end++
+			}
+			for a, b := i, end-1; a < b; a, b = a+1, b-1 {
+				order[a], order[b] = order[b], order[a]
+			}
+			i = end
+		}
+	}
+	return
+}
+
+var bidiMirrors = map[rune]rune{
+	'(': ')', ')': '(',
+	'[': ']', ']': '[',
+	'{': '}', '}': '{',
+	'<': '>', '>': '<',
+	'«': '»', '»': '«',
+	'‹': '›', '›': '‹',
+	'⁅': '⁆', '⁆': '⁅',
+	'≤': '≥', '≥': '≤',
+}
+
+// mirrorRune returns the mirrored glyph of the rune, rule L4 of UAX #9
+func mirrorRune(r rune) rune {
+	if m, ok := bidiMirrors[r]; ok {
+		return m
+	}
+	return r
+}
+
+// BidiLine is the visual ordering of a single line of text, providing the
+// mapping between logical (stored) and visual (displayed) rune indexes used
+// for cursor movement and selection in editing widgets
+type BidiLine struct {
+	direction TextDirection
+	logical   []rune
+	visual    []rune
+	toLogical []int
+	toVisual  []int
+	levels    []uint8
+}
+
+// NewBidiLine resolves the visual order of the given single line of text,
+// with the given base direction
+func NewBidiLine(text string, direction TextDirection) (line *BidiLine) {
+	runes := []rune(text)
+	if direction == TextDirectionAuto {
+		direction = DetectTextDirection(text)
+	}
+	line = &BidiLine{
+		direction: direction,
+		logical:   runes,
+		visual:    make([]rune, len(runes)),
+		toVisual:  make([]int, len(runes)),
+	}
+	line.levels = resolveBidiLevels(runes, direction == TextDirectionRTL)
+	line.toLogical = reorderBidiLevels(line.levels)
+	for v, l := range line.toLogical {
+		line.toVisual[l] = v
+		r := runes[l]
+		if line.levels[l]%2 == 1 {
+			r = mirrorRune(r)
+		}
+		line.visual[v] = r
+	}
+	return
+}
+
+// Direction returns the resolved base direction of the line
+func (b *BidiLine) Direction() TextDirection {
+	return b.direction
+}
+
+// Len returns the number of runes in the line
+func (b *BidiLine) Len() int {
+	return len(b.logical)
+}
+
+// Visual returns the text in display order
+func (b *BidiLine) Visual() string {
+	return string(b.visual)
+}
+
+// Logical returns the text in stored order
+func (b *BidiLine) Logical() string {
+	return string(b.logical)
+}
+
+// IsRTL returns true if the rune at the logical index is displayed
+// right-to-left
+func (b *BidiLine) IsRTL(logical int) bool {
+	if logical < 0 || logical >= len(b.levels) {
+		return b.direction == TextDirectionRTL
+	}
+	return b.levels[logical]%2 == 1
+}
+
+// VisualIndex returns the display position of the rune at the given logical
+// index. The end of the line (logical == Len) is the right edge of
+// left-to-right lines and the left edge of right-to-left lines.
+func (b *BidiLine) VisualIndex(logical int) (visual int) {
+	if logical < 0 {
+		logical = 0
+	}
+	if logical >= len(b.toVisual) {
+		if b.direction == TextDirectionRTL {
+			return 0
+		}
+		return len(b.toVisual)
+	}
+	return b.toVisual[logical]
+}
+
+// LogicalIndex returns the logical index of the rune displayed at the given
+// position, positions beyond the end of the line map to the logical end
+func (b *BidiLine) LogicalIndex(visual int) (logical int) {
+	if visual < 0 {
+		visual = 0
+	}
+	if visual >= len(b.toLogical) {
+		return len(b.toLogical)
+	}
+	return b.toLogical[visual]
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestBidi(t *testing.T) {
+	Convey("Paragraph direction detection", t, func() {
+		So(DetectTextDirection("hello"), ShouldEqual, TextDirectionLTR)
+		So(DetectTextDirection("123 שלום"), ShouldEqual, TextDirectionRTL)
+		So(DetectTextDirection("مرحبا world"), ShouldEqual, TextDirectionRTL)
+		So(DetectTextDirection("  "), ShouldEqual, TextDirectionLTR)
+		So(TextDirectionRTL.String(), ShouldEqual, "rtl")
+	})
+	Convey("Visual ordering", t, func() {
+		So(NewBidiLine("plain text", TextDirectionAuto).Visual(), ShouldEqual, "plain text")
+		So(NewBidiLine("abc אבג def", TextDirectionAuto).Visual(), ShouldEqual, "abc גבא def")
+		So(NewBidiLine("אבג abc", TextDirectionAuto).Visual(), ShouldEqual, "abc גבא")
+		So(NewBidiLine("abc אבג", TextDirectionRTL).Visual(), ShouldEqual, "גבא abc")
+		So(NewBidiLine("אב 123", TextDirectionAuto).Visual(), ShouldEqual, "123 בא")
+		So(NewBidiLine("א(ב)", TextDirectionAuto).Visual(), ShouldEqual, "(ב)א")
+	})
+	Convey("Cursor position mapping", t, func() {
+		line := NewBidiLine("abc אבג", TextDirectionAuto)
+		So(line.Direction(), ShouldEqual, TextDirectionLTR)
+		So(line.Len(), ShouldEqual, 7)
+		So(line.VisualIndex(0), ShouldEqual, 0)
+		So(line.VisualIndex(4), ShouldEqual, 6)
+		So(line.VisualIndex(6), ShouldEqual, 4)
+		So(line.VisualIndex(7), ShouldEqual, 7)
+		So(line.LogicalIndex(6), ShouldEqual, 4)
+		So(line.LogicalIndex(10), ShouldEqual, 7)
+		So(line.IsRTL(4), ShouldBeTrue)
+		So(line.IsRTL(0), ShouldBeFalse)
+		rtl := NewBidiLine("אבג", TextDirectionAuto)
+		So(rtl.VisualIndex(0), ShouldEqual, 2)
+		So(rtl.VisualIndex(3), ShouldEqual, 0)
+		for idx := 0; idx < rtl.Len(); idx++ {
+			So(rtl.LogicalIndex(rtl.VisualIndex(idx)), ShouldEqual, idx)
+		}
+	})
+	Convey("Bidi text layout", t, func() {
+		style := paint.GetDefaultMonoStyle()
+		wl := NewWordLine("abc אבג\nאבג abc", style)
+		lines := wl.Make(false, enums.WRAP_NONE, false, enums.JUSTIFY_NONE, -1, style)
+		So(lines, ShouldHaveLength, 2)
+		So(lines[0].Value(), ShouldEqual, "abc גבא")
+		So(lines[1].Value(), ShouldEqual, "abc גבא")
+		wl.SetDirection(TextDirectionRTL)
+		lines = wl.Make(false, enums.WRAP_NONE, false, enums.JUSTIFY_NONE, -1, style)
+		So(lines[0].Value(), ShouldEqual, "גבא abc")
+		So(NewWordLine("left to right", style).Make(false, enums.WRAP_WORD, false, enums.JUSTIFY_LEFT, 5, style)[0].Value(), ShouldEqual, "left")
+		canvas := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(7, 1), style)
+		canvas.DrawTextDirected(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(7, 1), enums.JUSTIFY_NONE, true, enums.WRAP_NONE, false, style, false, false, TextDirectionRTL, "abc אבג")
+		So(canvas.GetContent(0, 0).Value(), ShouldEqual, 'ג')
+		So(canvas.GetContent(6, 0).Value(), ShouldEqual, 'c')
+	})
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"github.com/lucasb-eyer/go-colorful"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// FillGradient sets the background of the cells within the region to a
+// gradient between the given colors, blended in CIE L*a*b* space, running
+// left to right for ORIENTATION_HORIZONTAL and top to bottom otherwise. The
+// content and foreground of the cells are retained and empty cells are filled
+// with spaces. The gradient is made of 24-bit colors, terminals without true
+// color support approximate these according to the color downsampling policy
+// of the Screen (see: paint.ColorDitherOrdered for smoothing gradients).
+func (c *CSurface) FillGradient(region ptypes.Region, from, to paint.Color, orient enums.Orientation) {
+	steps := region.H
+	if orient == enums.ORIENTATION_HORIZONTAL {
+		steps = region.W
+	}
+	gradient := MakeGradient(from, to, steps)
+	c.Lock()
+	defer c.Unlock()
+	c.eachCellWithin(region, func(x, y int, cell TextCell) {
+		step := y - region.Y
+		if orient == enums.ORIENTATION_HORIZONTAL {
+			step = x - region.X
+		}
+		r := cell.Value()
+		if cell.IsNil() {
+			r = ' '
+		}
+		_ = c.buffer.SetCell(x, y, r, cell.Style().Background(gradient[step]))
+	})
+}
+
+// FillPattern sets the cells within the region to the given style and the
+// runes of the pattern, repeated along each line from the left of the region
+func (c *CSurface) FillPattern(region ptypes.Region, pattern []rune, style paint.Style) {
+	if len(pattern) == 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	c.eachCellWithin(region, func(x, y int, _ TextCell) {
+		_ = c.buffer.SetCell(x, y, pattern[(x-region.X)%len(pattern)], style)
+	})
+}
+
+// MakeGradient returns the given number of colors blending from one color to
+// the other, inclusive. Colors which are not valid are used as-is.
+func MakeGradient(from, to paint.Color, steps int) (colors []paint.Color) {
+	if steps <= 0 {
+		return
+	}
+	colors = make([]paint.Color, steps)
+	if !from.Valid() || !to.Valid() {
+		for idx := range colors {
+			colors[idx] = from
+		}
+		return
+	}
+	c1, c2 := colorfulColor(from), colorfulColor(to)
+	for idx := range colors {
+		t := 0.0
+		if steps > 1 {
+			t = float64(idx) / float64(steps-1)
+		}
+		r, g, b := c1.BlendLab(c2, t).Clamped().RGB255()
+		colors[idx] = paint.NewRGBColor(int32(r), int32(g), int32(b))
+	}
+	return
+}
+
+func colorfulColor(c paint.Color) colorful.Color {
+	r, g, b := c.RGB()
+	return colorful.Color{R: float64(r) / 255.0, G: float64(g) / 255.0, B: float64(b) / 255.0}
+}
+
+// eachCellWithin calls fn with the cells of the region which are within the
+// bounds of the surface, the caller must hold the lock
+func (c *CSurface) eachCellWithin(region ptypes.Region, fn func(x, y int, cell TextCell)) {
+	for y := region.Y; y < region.Y+region.H; y++ {
+		for x := region.X; x < region.X+region.W; x++ {
+			if cell := c.buffer.GetCell(x, y); cell != nil {
+				fn(x, y, cell)
+			}
+		}
+	}
+}
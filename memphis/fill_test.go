@@ -0,0 +1,77 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestFillGradient(t *testing.T) {
+	Convey("Gradient fills", t, func() {
+		black, white := paint.NewRGBColor(0, 0, 0), paint.NewRGBColor(255, 255, 255)
+		gradient := MakeGradient(black, white, 3)
+		So(gradient, ShouldHaveLength, 3)
+		So(gradient[0], ShouldEqual, black)
+		So(gradient[2], ShouldEqual, white)
+		r, g, b := gradient[1].RGB()
+		So(r, ShouldBeBetween, 0, 255)
+		So(r, ShouldEqual, g)
+		So(g, ShouldEqual, b)
+		So(MakeGradient(black, white, 1), ShouldResemble, []paint.Color{black})
+		So(MakeGradient(black, white, 0), ShouldBeEmpty)
+
+		s := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(4, 2), paint.StyleDefault)
+		_ = s.SetRune(1, 0, 'x', paint.StyleDefault.Foreground(paint.ColorRed))
+		s.FillGradient(ptypes.MakeRegion(1, 0, 5, 2), black, white, enums.ORIENTATION_HORIZONTAL)
+		So(s.GetContent(0, 0).Style().Equals(paint.StyleDefault), ShouldBeTrue)
+		cell := s.GetContent(1, 0)
+		So(cell.Value(), ShouldEqual, 'x')
+		fg, bg, _ := cell.Style().Decompose()
+		So(fg, ShouldEqual, paint.ColorRed)
+		So(bg, ShouldEqual, black)
+		So(s.GetContent(2, 1).Value(), ShouldEqual, ' ')
+		// the gradient spans the region, beyond the edge of the surface
+		_, bg, _ = s.GetContent(3, 1).Style().Decompose()
+		So(bg, ShouldEqual, MakeGradient(black, white, 5)[2])
+
+		s.FillGradient(ptypes.MakeRegion(0, 0, 4, 2), black, white, enums.ORIENTATION_VERTICAL)
+		_, bg, _ = s.GetContent(3, 0).Style().Decompose()
+		So(bg, ShouldEqual, black)
+		_, bg, _ = s.GetContent(3, 1).Style().Decompose()
+		So(bg, ShouldEqual, white)
+	})
+}
+
+func TestFillPattern(t *testing.T) {
+	Convey("Pattern fills", t, func() {
+		s := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(5, 2), paint.StyleDefault)
+		style := paint.StyleDefault.Foreground(paint.ColorBlue)
+		s.FillPattern(ptypes.MakeRegion(1, 1, 10, 5), []rune("ab"), style)
+		So(s.GetContent(0, 1).Value(), ShouldNotEqual, 'a')
+		So(s.GetContent(1, 1).Value(), ShouldEqual, 'a')
+		So(s.GetContent(2, 1).Value(), ShouldEqual, 'b')
+		So(s.GetContent(3, 1).Value(), ShouldEqual, 'a')
+		So(s.GetContent(3, 1).Style().Equals(style), ShouldBeTrue)
+		So(s.GetContent(1, 0).Value(), ShouldNotEqual, 'a')
+		s.FillPattern(ptypes.MakeRegion(0, 0, 5, 2), nil, style)
+		So(s.GetContent(0, 0).Value(), ShouldNotEqual, 'a')
+	})
+}
@@ -0,0 +1,219 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"image"
+	"math"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+const (
+	// HalfBlockUpper is drawn with the foreground as the top pixel and the
+	// background as the bottom pixel
+	HalfBlockUpper = '▀'
+	// HalfBlockLower is used when only the bottom pixel is opaque
+	HalfBlockLower = '▄'
+)
+
+// HalfBlock is one cell of an image rendered as two vertically stacked pixels
+type HalfBlock struct {
+	Top         paint.Color
+	Bottom      paint.Color
+	TopClear    bool
+	BottomClear bool
+}
+
+// FitImageSize returns the largest size, in cells, within max which preserves
+// the aspect ratio of the given image bounds, given that each cell is two
+// pixels tall
+func FitImageSize(bounds image.Rectangle, max ptypes.Rectangle) (size ptypes.Rectangle) {
+	iw, ih := bounds.Dx(), bounds.Dy()
+	if iw <= 0 || ih <= 0 || max.W <= 0 || max.H <= 0 {
+		return
+	}
+	scale := math.Min(float64(max.W)/float64(iw), float64(max.H*2)/float64(ih))
+	size.W = int(math.Max(1, math.Round(float64(iw)*scale)))
+	size.H = int(math.Max(1, math.Round(float64(ih)*scale/2)))
+	if size.W > max.W {
+		size.W = max.W
+	}
+	if size.H > max.H {
+		size.H = max.H
+	}
+	return
+}
+
+// MakeHalfBlocks scales the image to the given size, in cells, returning the
+// rows of HalfBlock cells. Each pixel is the average of the source pixels it
+// covers and pixels that are more than half transparent are clear. When dither
+// is true, colors are reduced to the 240 colors of the xterm 256-color palette
+// which are not theme dependent, using Floyd-Steinberg error diffusion, for
+// terminals without 24-bit color.
+func MakeHalfBlocks(img image.Image, size ptypes.Rectangle, dither bool) (blocks [][]HalfBlock) {
+	if img == nil || size.W <= 0 || size.H <= 0 || img.Bounds().Empty() {
+		return
+	}
+	pixels := sampleImage(img, size.W, size.H*2)
+	if dither {
+		ditherPixels(pixels, size.W, size.H*2)
+	}
+	blocks = make([][]HalfBlock, size.H)
+	for y := 0; y < size.H; y++ {
+		blocks[y] = make([]HalfBlock, size.W)
+		for x := 0; x < size.W; x++ {
+			top, bottom := pixels[y*2*size.W+x], pixels[(y*2+1)*size.W+x]
+			blocks[y][x] = HalfBlock{
+				Top:         top.color(dither),
+				Bottom:      bottom.color(dither),
+				TopClear:    top.clear(),
+				BottomClear: bottom.clear(),
+			}
+		}
+	}
+	return
+}
+
+// DrawImage renders the image scaled to the given size, in cells, using
+// half-block characters. Clear pixels retain the existing cell background.
+func (c *CSurface) DrawImage(pos ptypes.Point2I, size ptypes.Rectangle, img image.Image, dither bool) {
+	blocks := MakeHalfBlocks(img, size, dither)
+	c.Lock()
+	defer c.Unlock()
+	for y, row := range blocks {
+		for x, block := range row {
+			cx, cy := pos.X+x, pos.Y+y
+			cell := c.buffer.GetCell(cx, cy)
+			if cell == nil || (block.TopClear && block.BottomClear) {
+				continue
+			}
+			style := cell.Style()
+			switch {
+			case block.TopClear:
+				style = style.Foreground(block.Bottom)
+				_ = c.buffer.SetCell(cx, cy, HalfBlockLower, style)
+			case block.BottomClear:
+				style = style.Foreground(block.Top)
+				_ = c.buffer.SetCell(cx, cy, HalfBlockUpper, style)
+			default:
+				style = style.Foreground(block.Top).Background(block.Bottom)
+				_ = c.buffer.SetCell(cx, cy, HalfBlockUpper, style)
+			}
+		}
+	}
+}
+
+type imagePixel struct {
+	r, g, b float64 // 0-255
+	a       float64 // 0-1
+	index   int     // palette index, when dithered
+}
+
+func (p imagePixel) clear() bool {
+	return p.a < 0.5
+}
+
+func (p imagePixel) color(dithered bool) paint.Color {
+	if dithered {
+		return paint.PaletteColor(p.index)
+	}
+	return paint.NewRGBColor(clampChannel(p.r), clampChannel(p.g), clampChannel(p.b))
+}
+
+func clampChannel(v float64) int32 {
+	return int32(math.Max(0, math.Min(255, math.Round(v))))
+}
+
+// sampleImage averages the source pixels covered by each of the w x h pixels
+func sampleImage(img image.Image, w, h int) (pixels []imagePixel) {
+	bounds := img.Bounds()
+	sw, sh := bounds.Dx(), bounds.Dy()
+	pixels = make([]imagePixel, w*h)
+	for ty := 0; ty < h; ty++ {
+		y0 := bounds.Min.Y + ty*sh/h
+		y1 := bounds.Min.Y + (ty+1)*sh/h
+		if y1 <= y0 {
+			y1 = y0 + 1
+		}
+		for tx := 0; tx < w; tx++ {
+			x0 := bounds.Min.X + tx*sw/w
+			x1 := bounds.Min.X + (tx+1)*sw/w
+			if x1 <= x0 {
+				x1 = x0 + 1
+			}
+			var r, g, b, a, n float64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					pr, pg, pb, pa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+float64(pr), g+float64(pg), b+float64(pb), a+float64(pa)
+					n++
+				}
+			}
+			p := &pixels[ty*w+tx]
+			if a > 0 {
+				// un-premultiply the averaged color
+				p.r, p.g, p.b = r/a*255, g/a*255, b/a*255
+			}
+			p.a = a / n / 0xffff
+		}
+	}
+	return
+}
+
+// ditherPixels quantizes the opaque pixels to the palette, diffusing the error
+// to neighbouring pixels
+func ditherPixels(pixels []imagePixel, w, h int) {
+	diffuse := func(x, y int, er, eg, eb, weight float64) {
+		if x < 0 || x >= w || y >= h {
+			return
+		}
+		p := &pixels[y*w+x]
+		p.r += er * weight
+		p.g += eg * weight
+		p.b += eb * weight
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			p := &pixels[y*w+x]
+			if p.clear() {
+				continue
+			}
+			var pr, pg, pb float64
+			p.index, pr, pg, pb = nearestPaletteColor(p.r, p.g, p.b)
+			er, eg, eb := p.r-pr, p.g-pg, p.b-pb
+			diffuse(x+1, y, er, eg, eb, 7.0/16)
+			diffuse(x-1, y+1, er, eg, eb, 3.0/16)
+			diffuse(x, y+1, er, eg, eb, 5.0/16)
+			diffuse(x+1, y+1, er, eg, eb, 1.0/16)
+		}
+	}
+}
+
+// nearestPaletteColor searches the xterm color cube and grayscale ramp
+// (indexes 16-255) for the closest color
+func nearestPaletteColor(r, g, b float64) (index int, pr, pg, pb float64) {
+	best := math.MaxFloat64
+	for i := 16; i < 256; i++ {
+		cr, cg, cb := paint.PaletteColor(i).RGB()
+		dr, dg, db := r-float64(cr), g-float64(cg), b-float64(cb)
+		if d := dr*dr + dg*dg + db*db; d < best {
+			best = d
+			index, pr, pg, pb = i, float64(cr), float64(cg), float64(cb)
+		}
+	}
+	return
+}
@@ -0,0 +1,65 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestImageHalfBlocks(t *testing.T) {
+	Convey("Image half-block rendering", t, func() {
+		// 2x4 image: red over blue in the top half, green over transparent below
+		img := image.NewNRGBA(image.Rect(0, 0, 2, 4))
+		for x := 0; x < 2; x++ {
+			img.Set(x, 0, color.NRGBA{R: 255, A: 255})
+			img.Set(x, 1, color.NRGBA{B: 255, A: 255})
+			img.Set(x, 2, color.NRGBA{G: 255, A: 255})
+			img.Set(x, 3, color.NRGBA{})
+		}
+		So(FitImageSize(img.Bounds(), ptypes.MakeRectangle(10, 10)), ShouldResemble, ptypes.MakeRectangle(10, 10))
+		So(FitImageSize(img.Bounds(), ptypes.MakeRectangle(10, 4)), ShouldResemble, ptypes.MakeRectangle(4, 4))
+		So(FitImageSize(img.Bounds(), ptypes.MakeRectangle(2, 10)), ShouldResemble, ptypes.MakeRectangle(2, 2))
+		So(FitImageSize(image.Rectangle{}, ptypes.MakeRectangle(2, 10)), ShouldResemble, ptypes.Rectangle{})
+
+		blocks := MakeHalfBlocks(img, ptypes.MakeRectangle(2, 2), false)
+		So(blocks, ShouldHaveLength, 2)
+		So(blocks[0][0].Top, ShouldEqual, paint.NewRGBColor(255, 0, 0))
+		So(blocks[0][0].Bottom, ShouldEqual, paint.NewRGBColor(0, 0, 255))
+		So(blocks[1][1].Top, ShouldEqual, paint.NewRGBColor(0, 255, 0))
+		So(blocks[1][1].BottomClear, ShouldBeTrue)
+
+		dithered := MakeHalfBlocks(img, ptypes.MakeRectangle(2, 2), true)
+		So(dithered[0][0].Top, ShouldEqual, paint.PaletteColor(196))
+		So(dithered[0][0].Bottom, ShouldEqual, paint.PaletteColor(21))
+
+		surface := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(3, 2), paint.GetDefaultMonoStyle())
+		surface.DrawImage(ptypes.MakePoint2I(1, 0), ptypes.MakeRectangle(2, 2), img, false)
+		So(surface.GetContent(0, 0).Value(), ShouldEqual, ' ')
+		So(surface.GetContent(1, 0).Value(), ShouldEqual, HalfBlockUpper)
+		fg, bg, _ := surface.GetContent(1, 0).Style().Decompose()
+		So(fg, ShouldEqual, paint.NewRGBColor(255, 0, 0))
+		So(bg, ShouldEqual, paint.NewRGBColor(0, 0, 255))
+		So(surface.GetContent(2, 1).Value(), ShouldEqual, HalfBlockUpper)
+		fg, _, _ = surface.GetContent(2, 1).Style().Decompose()
+		So(fg, ShouldEqual, paint.NewRGBColor(0, 255, 0))
+	})
+}
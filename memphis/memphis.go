@@ -16,6 +16,7 @@ package memphis
 
 import (
 	"fmt"
+	"sort"
 	"sync"
 
 	"github.com/gofrs/uuid"
@@ -25,17 +26,46 @@ import (
 )
 
 var (
-	surfaces     = make(map[uuid.UUID]*CSurface)
+	surfaces     = make(map[uuid.UUID]*cSurfaceEntry)
 	surfacesLock = &sync.RWMutex{}
+	surfacesSeq  uint64
 )
 
+// cSurfaceEntry is a registered surface along with its ownership details
+type cSurfaceEntry struct {
+	surface *CSurface
+	owner   string
+	refs    int
+	seq     uint64
+}
+
+// SurfaceInfo describes a registered surface, see: ListSurfaces
+type SurfaceInfo struct {
+	ID     uuid.UUID
+	Owner  string
+	Refs   int
+	Origin ptypes.Point2I
+	Size   ptypes.Rectangle
+}
+
+func (i SurfaceInfo) String() string {
+	return fmt.Sprintf("{id=%v,owner=%q,refs=%d,origin=%v,size=%v}", i.ID, i.Owner, i.Refs, i.Origin, i.Size)
+}
+
+// MakeSurface registers a new surface for the given id, holding a single
+// reference which is released with UnrefSurface or RemoveSurface
 func MakeSurface(id uuid.UUID, origin ptypes.Point2I, size ptypes.Rectangle, style paint.Style) (err error) {
 	surfacesLock.Lock()
 	defer surfacesLock.Unlock()
 	if _, ok := surfaces[id]; ok {
 		return fmt.Errorf("surface exists for id: %v", id)
 	}
-	surfaces[id] = NewSurface(origin, size, style)
+	surfacesSeq++
+	surfaces[id] = &cSurfaceEntry{
+		surface: NewSurface(origin, size, style),
+		refs:    1,
+		seq:     surfacesSeq,
+	}
 	return nil
 }
 
@@ -49,12 +79,14 @@ func HasSurface(id uuid.UUID) (ok bool) {
 func GetSurface(id uuid.UUID) (*CSurface, error) {
 	surfacesLock.RLock()
 	defer surfacesLock.RUnlock()
-	if c, ok := surfaces[id]; ok {
-		return c, nil
+	if e, ok := surfaces[id]; ok {
+		return e.surface, nil
 	}
 	return nil, fmt.Errorf("surface not found: %v", id)
 }
 
+// RemoveSurface unregisters the surface for the given id regardless of the
+// references held
 func RemoveSurface(id uuid.UUID) {
 	surfacesLock.Lock()
 	defer surfacesLock.Unlock()
@@ -63,6 +95,68 @@ func RemoveSurface(id uuid.UUID) {
 	}
 }
 
+// RefSurface adds a reference to the surface for the given id, keeping it
+// registered until a matching UnrefSurface
+func RefSurface(id uuid.UUID) (refs int, err error) {
+	surfacesLock.Lock()
+	defer surfacesLock.Unlock()
+	if e, ok := surfaces[id]; ok {
+		e.refs += 1
+		return e.refs, nil
+	}
+	return 0, fmt.Errorf("surface not found: %v", id)
+}
+
+// UnrefSurface releases a reference to the surface for the given id, the
+// surface is unregistered once no references remain. The number of references
+// remaining is returned, zero if the surface was not found.
+func UnrefSurface(id uuid.UUID) (refs int) {
+	surfacesLock.Lock()
+	defer surfacesLock.Unlock()
+	if e, ok := surfaces[id]; ok {
+		if e.refs -= 1; e.refs <= 0 {
+			delete(surfaces, id)
+			return 0
+		}
+		return e.refs
+	}
+	return 0
+}
+
+// SetSurfaceOwner describes what the surface for the given id belongs to, for
+// the purpose of diagnosing leaked surfaces, see: ListSurfaces
+func SetSurfaceOwner(id uuid.UUID, owner string) (err error) {
+	surfacesLock.Lock()
+	defer surfacesLock.Unlock()
+	if e, ok := surfaces[id]; ok {
+		e.owner = owner
+		return nil
+	}
+	return fmt.Errorf("surface not found: %v", id)
+}
+
+// ListSurfaces returns the details of all registered surfaces, in the order
+// they were made
+func ListSurfaces() (list []SurfaceInfo) {
+	surfacesLock.RLock()
+	seqs := make(map[uuid.UUID]uint64, len(surfaces))
+	for id, e := range surfaces {
+		seqs[id] = e.seq
+		list = append(list, SurfaceInfo{
+			ID:     id,
+			Owner:  e.owner,
+			Refs:   e.refs,
+			Origin: e.surface.GetOrigin(),
+			Size:   e.surface.GetSize(),
+		})
+	}
+	surfacesLock.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return seqs[list[i].ID] < seqs[list[j].ID]
+	})
+	return
+}
+
 func FillSurface(id uuid.UUID, theme paint.Theme) (err error) {
 	var s Surface
 	if s, err = GetSurface(id); err == nil {
@@ -0,0 +1,60 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestSurfaceRegistry(t *testing.T) {
+	Convey("Reference counted surfaces", t, func() {
+		first, second := uuid.Must(uuid.NewV4()), uuid.Must(uuid.NewV4())
+		origin, size := ptypes.MakePoint2I(1, 2), ptypes.MakeRectangle(3, 4)
+		So(MakeSurface(first, origin, size, paint.StyleDefault), ShouldBeNil)
+		So(MakeSurface(first, origin, size, paint.StyleDefault), ShouldNotBeNil)
+		So(MakeSurface(second, origin, size, paint.StyleDefault), ShouldBeNil)
+		defer RemoveSurface(second)
+		So(SetSurfaceOwner(first, "testing"), ShouldBeNil)
+		refs, err := RefSurface(first)
+		So(err, ShouldBeNil)
+		So(refs, ShouldEqual, 2)
+		var found []SurfaceInfo
+		for _, info := range ListSurfaces() {
+			if info.ID == first || info.ID == second {
+				found = append(found, info)
+			}
+		}
+		So(found, ShouldHaveLength, 2)
+		So(found[0].ID, ShouldEqual, first)
+		So(found[0].Owner, ShouldEqual, "testing")
+		So(found[0].Refs, ShouldEqual, 2)
+		So(found[0].Size, ShouldResemble, size)
+		So(found[1].ID, ShouldEqual, second)
+		So(UnrefSurface(first), ShouldEqual, 1)
+		So(HasSurface(first), ShouldBeTrue)
+		So(UnrefSurface(first), ShouldEqual, 0)
+		So(HasSurface(first), ShouldBeFalse)
+		So(UnrefSurface(first), ShouldEqual, 0)
+		_, err = RefSurface(first)
+		So(err, ShouldNotBeNil)
+		So(SetSurfaceOwner(first, "gone"), ShouldNotBeNil)
+	})
+}
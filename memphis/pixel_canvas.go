@@ -0,0 +1,263 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"sync"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// PixelMode determines how many pixels each cell of a PixelCanvas holds
+type PixelMode uint8
+
+const (
+	// PixelBraille draws a 2x4 grid of pixels per cell using braille patterns,
+	// all pixels of a cell share a single color
+	PixelBraille PixelMode = iota
+	// PixelHalfBlock draws a 1x2 grid of pixels per cell using half-block
+	// characters, each pixel having its own color
+	PixelHalfBlock
+)
+
+// CellPixels returns the width and height, in pixels, of each cell
+func (m PixelMode) CellPixels() (w, h int) {
+	if m == PixelHalfBlock {
+		return 1, 2
+	}
+	return 2, 4
+}
+
+// BrailleBlank is the braille pattern with no dots raised, the dots of a cell
+// are added to it, see: brailleDots
+const BrailleBlank = '⠀'
+
+// brailleDots are the bits of each braille dot, indexed by [y][x]
+var brailleDots = [4][2]rune{
+	{0x01, 0x08},
+	{0x02, 0x10},
+	{0x04, 0x20},
+	{0x40, 0x80},
+}
+
+// PixelCanvas is a grid of pixels, several per cell, for drawing plots,
+// sparklines and other pseudo-graphics which are then drawn onto a Surface.
+// Pixel coordinates start at the top-left corner and pixels outside the canvas
+// are ignored.
+type PixelCanvas interface {
+	Mode() PixelMode
+	GetSize() ptypes.Rectangle
+	PixelSize() ptypes.Rectangle
+	Clear()
+	Pixel(x, y int) (color paint.Color, set bool)
+	Plot(x, y int, color paint.Color)
+	Erase(x, y int)
+	Line(x0, y0, x1, y1 int, color paint.Color)
+	Rect(x, y, w, h int, fill bool, color paint.Color)
+	Draw(surface Surface, pos ptypes.Point2I)
+}
+
+type canvasPixel struct {
+	color paint.Color
+	set   bool
+}
+
+// CPixelCanvas is the concrete implementation of the PixelCanvas interface
+type CPixelCanvas struct {
+	mode   PixelMode
+	size   ptypes.Rectangle
+	pixels ptypes.Rectangle
+	data   []canvasPixel
+
+	sync.RWMutex
+}
+
+// NewPixelCanvas creates a canvas of the given size, in cells, with the number
+// of pixels per cell determined by the mode
+func NewPixelCanvas(size ptypes.Rectangle, mode PixelMode) *CPixelCanvas {
+	if size.W < 0 || size.H < 0 {
+		size = ptypes.MakeRectangle(0, 0)
+	}
+	pw, ph := mode.CellPixels()
+	c := &CPixelCanvas{
+		mode:   mode,
+		size:   size,
+		pixels: ptypes.MakeRectangle(size.W*pw, size.H*ph),
+	}
+	c.data = make([]canvasPixel, c.pixels.W*c.pixels.H)
+	return c
+}
+
+// Mode returns the PixelMode of the canvas
+func (c *CPixelCanvas) Mode() PixelMode {
+	return c.mode
+}
+
+// GetSize returns the size of the canvas in cells
+func (c *CPixelCanvas) GetSize() ptypes.Rectangle {
+	return c.size
+}
+
+// PixelSize returns the size of the canvas in pixels
+func (c *CPixelCanvas) PixelSize() ptypes.Rectangle {
+	return c.pixels
+}
+
+// Clear unsets all pixels
+func (c *CPixelCanvas) Clear() {
+	c.Lock()
+	defer c.Unlock()
+	for i := range c.data {
+		c.data[i] = canvasPixel{}
+	}
+}
+
+// Pixel returns the color of the pixel and whether it is set
+func (c *CPixelCanvas) Pixel(x, y int) (color paint.Color, set bool) {
+	c.RLock()
+	defer c.RUnlock()
+	if i, ok := c.index(x, y); ok {
+		return c.data[i].color, c.data[i].set
+	}
+	return
+}
+
+// Plot sets the pixel to the given color
+func (c *CPixelCanvas) Plot(x, y int, color paint.Color) {
+	c.Lock()
+	defer c.Unlock()
+	c.plot(x, y, color)
+}
+
+// Erase unsets the pixel
+func (c *CPixelCanvas) Erase(x, y int) {
+	c.Lock()
+	defer c.Unlock()
+	if i, ok := c.index(x, y); ok {
+		c.data[i] = canvasPixel{}
+	}
+}
+
+// Line sets the pixels of a straight line between the two points, inclusive
+func (c *CPixelCanvas) Line(x0, y0, x1, y1 int, color paint.Color) {
+	c.Lock()
+	defer c.Unlock()
+	dx, dy := x1-x0, y1-y0
+	sx, sy := 1, 1
+	if dx < 0 {
+		dx, sx = -dx, -1
+	}
+	if dy < 0 {
+		dy, sy = -dy, -1
+	}
+	err := dx - dy
+	for {
+		c.plot(x0, y0, color)
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := err * 2
+		if e2 > -dy {
+			err -= dy
+			x0 += sx
+		}
+		if e2 < dx {
+			err += dx
+			y0 += sy
+		}
+	}
+}
+
+// Rect sets the pixels of the outline of the rectangle, or all the pixels
+// within it when fill is true
+func (c *CPixelCanvas) Rect(x, y, w, h int, fill bool, color paint.Color) {
+	c.Lock()
+	defer c.Unlock()
+	for py := y; py < y+h; py++ {
+		for px := x; px < x+w; px++ {
+			if fill || py == y || py == y+h-1 || px == x || px == x+w-1 {
+				c.plot(px, py, color)
+			}
+		}
+	}
+}
+
+// Draw renders the canvas onto the surface with the top-left cell at the given
+// position. Cells without any pixels set are left as they are and the
+// background of the existing cells shows through the pixels which are not set.
+func (c *CPixelCanvas) Draw(surface Surface, pos ptypes.Point2I) {
+	c.RLock()
+	defer c.RUnlock()
+	for cy := 0; cy < c.size.H; cy++ {
+		for cx := 0; cx < c.size.W; cx++ {
+			cell := surface.GetContent(pos.X+cx, pos.Y+cy)
+			if cell == nil {
+				continue
+			}
+			if r, fg, bg, ok := c.cellRune(cx, cy, cell.Style()); ok {
+				_ = surface.SetRune(pos.X+cx, pos.Y+cy, r, cell.Style().Foreground(fg).Background(bg))
+			}
+		}
+	}
+}
+
+// cellRune returns the rune and colors drawing the pixels of the cell, ok is
+// false if none are set
+func (c *CPixelCanvas) cellRune(cx, cy int, style paint.Style) (r rune, fg, bg paint.Color, ok bool) {
+	fg, bg, _ = style.Decompose()
+	pw, ph := c.mode.CellPixels()
+	if c.mode == PixelHalfBlock {
+		top, bottom := c.data[(cy*ph)*c.pixels.W+cx], c.data[(cy*ph+1)*c.pixels.W+cx]
+		switch {
+		case top.set && bottom.set:
+			return HalfBlockUpper, top.color, bottom.color, true
+		case top.set:
+			return HalfBlockUpper, top.color, bg, true
+		case bottom.set:
+			return HalfBlockLower, bottom.color, bg, true
+		}
+		return
+	}
+	// braille cells have one color, the most common of the pixels set
+	r = BrailleBlank
+	counts := make(map[paint.Color]int, pw*ph)
+	for y := 0; y < ph; y++ {
+		for x := 0; x < pw; x++ {
+			if p := c.data[(cy*ph+y)*c.pixels.W+cx*pw+x]; p.set {
+				r |= brailleDots[y][x]
+				counts[p.color]++
+				if !ok || counts[p.color] > counts[fg] {
+					fg = p.color
+				}
+				ok = true
+			}
+		}
+	}
+	return
+}
+
+func (c *CPixelCanvas) index(x, y int) (i int, ok bool) {
+	if x < 0 || y < 0 || x >= c.pixels.W || y >= c.pixels.H {
+		return 0, false
+	}
+	return y*c.pixels.W + x, true
+}
+
+func (c *CPixelCanvas) plot(x, y int, color paint.Color) {
+	if i, ok := c.index(x, y); ok {
+		c.data[i] = canvasPixel{color: color, set: true}
+	}
+}
@@ -0,0 +1,94 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestPixelCanvas(t *testing.T) {
+	Convey("Braille canvas", t, func() {
+		c := NewPixelCanvas(ptypes.MakeRectangle(2, 1), PixelBraille)
+		So(c.PixelSize(), ShouldResemble, ptypes.MakeRectangle(4, 4))
+		c.Plot(0, 0, paint.ColorRed)
+		c.Plot(1, 3, paint.ColorRed)
+		c.Plot(-1, 0, paint.ColorRed)
+		c.Plot(4, 0, paint.ColorRed)
+		color, set := c.Pixel(0, 0)
+		So(set, ShouldBeTrue)
+		So(color, ShouldEqual, paint.ColorRed)
+		_, set = c.Pixel(1, 0)
+		So(set, ShouldBeFalse)
+
+		s := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(3, 1), paint.StyleDefault.Background(paint.ColorNavy))
+		_ = s.SetRune(2, 0, 'x', paint.StyleDefault)
+		c.Draw(s, ptypes.MakePoint2I(0, 0))
+		cell := s.GetContent(0, 0)
+		So(cell.Value(), ShouldEqual, '⢁')
+		fg, bg, _ := cell.Style().Decompose()
+		So(fg, ShouldEqual, paint.ColorRed)
+		So(bg, ShouldEqual, paint.ColorNavy)
+		So(s.GetContent(1, 0).Value(), ShouldEqual, ' ')
+
+		c.Clear()
+		c.Line(0, 0, 3, 3, paint.ColorGreen)
+		for i := 0; i < 4; i++ {
+			_, set = c.Pixel(i, i)
+			So(set, ShouldBeTrue)
+		}
+		c.Draw(s, ptypes.MakePoint2I(1, 0))
+		So(s.GetContent(1, 0).Value(), ShouldEqual, '⠑')
+		So(s.GetContent(2, 0).Value(), ShouldEqual, '⢄')
+		c.Erase(3, 3)
+		_, set = c.Pixel(3, 3)
+		So(set, ShouldBeFalse)
+	})
+	Convey("Half-block canvas", t, func() {
+		c := NewPixelCanvas(ptypes.MakeRectangle(3, 2), PixelHalfBlock)
+		So(c.PixelSize(), ShouldResemble, ptypes.MakeRectangle(3, 4))
+		c.Rect(0, 0, 3, 4, false, paint.ColorRed)
+		_, set := c.Pixel(1, 1)
+		So(set, ShouldBeFalse)
+		c.Plot(1, 2, paint.ColorBlue)
+
+		s := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(3, 2), paint.StyleDefault.Background(paint.ColorNavy))
+		c.Draw(s, ptypes.MakePoint2I(0, 0))
+		cell := s.GetContent(0, 0)
+		So(cell.Value(), ShouldEqual, HalfBlockUpper)
+		fg, bg, _ := cell.Style().Decompose()
+		So(fg, ShouldEqual, paint.ColorRed)
+		So(bg, ShouldEqual, paint.ColorRed)
+		cell = s.GetContent(1, 0)
+		So(cell.Value(), ShouldEqual, HalfBlockUpper)
+		fg, bg, _ = cell.Style().Decompose()
+		So(fg, ShouldEqual, paint.ColorRed)
+		So(bg, ShouldEqual, paint.ColorNavy)
+		cell = s.GetContent(1, 1)
+		So(cell.Value(), ShouldEqual, HalfBlockUpper)
+		fg, bg, _ = cell.Style().Decompose()
+		So(fg, ShouldEqual, paint.ColorBlue)
+		So(bg, ShouldEqual, paint.ColorRed)
+
+		c.Clear()
+		c.Rect(0, 1, 3, 1, true, paint.ColorGreen)
+		c.Draw(s, ptypes.MakePoint2I(0, 0))
+		So(s.GetContent(2, 0).Value(), ShouldEqual, HalfBlockLower)
+	})
+}
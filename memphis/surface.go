@@ -16,6 +16,8 @@ package memphis
 
 import (
 	"fmt"
+	"image"
+	"time"
 	"unicode/utf8"
 
 	"github.com/gofrs/uuid"
@@ -45,12 +47,22 @@ type Surface interface {
 	Height() (height int)
 	GetRegion() (region ptypes.Region)
 	SetRegion(region ptypes.Region)
+	Snapshot() (snapshot *CSurface)
+	Lines() (lines []string)
 	Equals(onlyDirty bool, v *CSurface) bool
 	CompositeSurface(v *CSurface) error
 	Composite(id uuid.UUID) (err error)
 	Render(display Renderer) error
 	DrawText(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, markup, mnemonic bool, text string)
+	DrawTextE(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, markup, mnemonic bool, text string) (err error)
+	DrawTextDirected(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, markup, mnemonic bool, direction TextDirection, text string)
 	DrawSingleLineText(position ptypes.Point2I, maxChars int, ellipsize bool, justify enums.Justification, style paint.Style, markup, mnemonic bool, text string)
+	DrawTextIncremental(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, tb TextBuffer, budget time.Duration) (done bool)
+	DrawImage(pos ptypes.Point2I, size ptypes.Rectangle, img image.Image, dither bool)
+	FillGradient(region ptypes.Region, from, to paint.Color, orient enums.Orientation)
+	FillPattern(region ptypes.Region, pattern []rune, style paint.Style)
+	GetLineMerging() (enabled bool)
+	SetLineMerging(enabled bool)
 	DrawLine(pos ptypes.Point2I, length int, orient enums.Orientation, style paint.Style)
 	DrawHorizontalLine(pos ptypes.Point2I, length int, style paint.Style, lineRune rune)
 	DrawVerticalLine(pos ptypes.Point2I, length int, style paint.Style, lineRune rune)
@@ -67,6 +79,7 @@ type CSurface struct {
 	buffer *CSurfaceBuffer
 	origin ptypes.Point2I
 	fill   rune
+	merge  bool
 
 	sync.RWMutex
 }
@@ -197,6 +210,53 @@ func (c *CSurface) SetRegion(region ptypes.Region) {
 	c.Resize(region.Size())
 }
 
+// Snapshot returns a copy of the surface with its own buffer, unaffected by
+// any further changes to this surface
+func (c *CSurface) Snapshot() (snapshot *CSurface) {
+	c.RLock()
+	defer c.RUnlock()
+	size := c.buffer.Size()
+	snapshot = &CSurface{
+		buffer: &CSurfaceBuffer{
+			data:  make([][]*CTextCell, size.W),
+			style: c.buffer.Style(),
+		},
+		origin: c.origin.Clone(),
+		fill:   c.fill,
+	}
+	for x := 0; x < size.W; x++ {
+		snapshot.buffer.data[x] = make([]*CTextCell, size.H)
+		for y := 0; y < size.H; y++ {
+			if cell := c.buffer.GetCell(x, y); cell != nil {
+				snapshot.buffer.data[x][y] = NewTextCellFromRune(cell.Value(), cell.Style())
+			} else {
+				snapshot.buffer.data[x][y] = NewTextCellFromRune(' ', c.buffer.Style())
+			}
+		}
+	}
+	return
+}
+
+// Lines returns the runes of the surface as one string per row, without any
+// styling, with unset cells presented as spaces. Useful for debugging.
+func (c *CSurface) Lines() (lines []string) {
+	c.RLock()
+	defer c.RUnlock()
+	size := c.buffer.Size()
+	for y := 0; y < size.H; y++ {
+		row := make([]rune, size.W)
+		for x := 0; x < size.W; x++ {
+			if cell := c.buffer.GetCell(x, y); cell != nil && !cell.IsNil() {
+				row[x] = cell.Value()
+			} else {
+				row[x] = ' '
+			}
+		}
+		lines = append(lines, string(row))
+	}
+	return
+}
+
 // returns true if the given canvas is painted the same as this one, can compare
 // for only cells that were "set" (dirty) or compare every cell of the two
 // canvases
@@ -323,27 +383,75 @@ func (c *CSurface) Render(screen Renderer) error {
 // origin is the top-left coordinate for the text area being rendered
 // alignment is based on origin.X boxed by maxChars or canvas size.W
 func (c *CSurface) DrawText(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, markup, mnemonic bool, text string) {
+	if err := c.drawText(pos, size, justify, singleLineMode, wrap, ellipsize, style, markup, mnemonic, TextDirectionAuto, text); err != nil {
+		log.ErrorDF(1, "failed to parse markup: %v", err)
+	}
+}
+
+// DrawTextE is the same as DrawText except that a markup parse error is
+// returned instead of logged. Malformed markup is drawn as-is, with the
+// foreground of MarkupErrorColor, making it safe to draw untrusted text.
+func (c *CSurface) DrawTextE(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, markup, mnemonic bool, text string) (err error) {
+	return c.drawText(pos, size, justify, singleLineMode, wrap, ellipsize, style, markup, mnemonic, TextDirectionAuto, text)
+}
+
+// DrawTextDirected is the same as DrawText with an explicit base direction,
+// overriding the detection of each paragraph's direction from its first
+// strong character
+func (c *CSurface) DrawTextDirected(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, markup, mnemonic bool, direction TextDirection, text string) {
+	if err := c.drawText(pos, size, justify, singleLineMode, wrap, ellipsize, style, markup, mnemonic, direction, text); err != nil {
+		log.ErrorDF(1, "failed to parse markup: %v", err)
+	}
+}
+
+func (c *CSurface) drawText(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, markup, mnemonic bool, direction TextDirection, text string) (err error) {
 	var tb TextBuffer
 	if markup {
-		m, err := NewMarkup(text, style)
-		if err != nil {
-			log.FatalDF(1, "failed to parse markup: %v", err)
+		var m Tango
+		if m, err = NewMarkup(text, style); err != nil {
+			// draw the text as-is, showing where the markup is broken
+			tb = NewTextBuffer(text, style.Foreground(MarkupErrorColor), mnemonic)
+		} else {
+			tb = m.TextBuffer(mnemonic)
 		}
-		tb = m.TextBuffer(mnemonic)
 	} else {
 		tb = NewTextBuffer(text, style, mnemonic)
 	}
+	tb.SetDirection(direction)
 	cSize := c.GetSize()
 	if size.W == -1 || size.W >= cSize.W {
 		size.W = cSize.W
 	}
-	v := NewSurface(pos, size, style)
+	v := AcquireSurface(pos, size, style)
+	defer ReleaseSurface(v)
 	v.Fill(paint.MakeStyledColorFillTheme(style))
 
 	tb.Draw(v, singleLineMode, wrap, ellipsize, justify, enums.ALIGN_TOP)
+	if cErr := c.CompositeSurface(v); cErr != nil {
+		log.ErrorF("composite error: %v", cErr)
+	}
+	return
+}
+
+// Write a persistent text buffer to the canvas buffer, performing at most the
+// given budget of text layout. Returns false while the layout is incomplete,
+// in which case the caller should request another draw to continue. Large
+// texts, especially markup, should be parsed once into the TextBuffer (for
+// example with NewMarkup) and given to each subsequent call.
+func (c *CSurface) DrawTextIncremental(pos ptypes.Point2I, size ptypes.Rectangle, justify enums.Justification, singleLineMode bool, wrap enums.WrapMode, ellipsize bool, style paint.Style, tb TextBuffer, budget time.Duration) (done bool) {
+	cSize := c.GetSize()
+	if size.W == -1 || size.W >= cSize.W {
+		size.W = cSize.W
+	}
+	v := AcquireSurface(pos, size, style)
+	defer ReleaseSurface(v)
+	v.Fill(paint.MakeStyledColorFillTheme(style))
+
+	_, done = tb.DrawIncremental(v, singleLineMode, wrap, ellipsize, justify, enums.ALIGN_TOP, budget)
 	if err := c.CompositeSurface(v); err != nil {
 		log.ErrorF("composite error: %v", err)
 	}
+	return
 }
 
 // write a single line of text to the canvas at the given position, of at most
@@ -352,6 +460,24 @@ func (c *CSurface) DrawSingleLineText(position ptypes.Point2I, maxChars int, ell
 	c.DrawText(position, ptypes.MakeRectangle(maxChars, 1), justify, true, enums.WRAP_NONE, ellipsize, style, markup, mnemonic, text)
 }
 
+// GetLineMerging returns true if lines and box borders are joined with those
+// already drawn, see: SetLineMerging
+func (c *CSurface) GetLineMerging() (enabled bool) {
+	c.RLock()
+	defer c.RUnlock()
+	return c.merge
+}
+
+// SetLineMerging enables or disables line merging. When enabled, DrawLine,
+// DrawHorizontalLine, DrawVerticalLine and Box borders drawn over existing
+// line drawing runes of the same paint.BorderRuneSet are joined with them,
+// substituting the tee and cross runes of the set where the lines meet.
+func (c *CSurface) SetLineMerging(enabled bool) {
+	c.Lock()
+	defer c.Unlock()
+	c.merge = enabled
+}
+
 // draw a line vertically or horizontally with the given style
 func (c *CSurface) DrawLine(pos ptypes.Point2I, length int, orient enums.Orientation, style paint.Style) {
 	log.TraceF("c.DrawLine(%v,%v,%v,%v)", pos, length, orient, style)
@@ -368,10 +494,18 @@ func (c *CSurface) DrawHorizontalLine(pos ptypes.Point2I, length int, style pain
 	size := c.GetSize()
 	c.Lock()
 	defer c.Unlock()
-	length = math.ClampI(length, pos.X, size.W-pos.X)
+	border, _ := lineBorderRunes(lineRune, true)
+	length = math.ClampI(length, 0, size.W-pos.X)
 	end := pos.X + length
 	for i := pos.X; i < end; i++ {
-		_ = c.buffer.SetCell(i, pos.Y, lineRune, style)
+		joints := paint.BorderJointLeft | paint.BorderJointRight
+		if i == pos.X {
+			joints &^= paint.BorderJointLeft
+		}
+		if i == end-1 {
+			joints &^= paint.BorderJointRight
+		}
+		_ = c.setLineCell(i, pos.Y, lineRune, style, border, joints)
 	}
 }
 
@@ -380,15 +514,54 @@ func (c *CSurface) DrawVerticalLine(pos ptypes.Point2I, length int, style paint.
 	size := c.GetSize()
 	c.Lock()
 	defer c.Unlock()
-	length = math.ClampI(length, pos.Y, size.H-pos.Y)
+	border, _ := lineBorderRunes(lineRune, false)
+	length = math.ClampI(length, 0, size.H-pos.Y)
 	end := pos.Y + length
 	for i := pos.Y; i < end; i++ {
-		_ = c.buffer.SetCell(i, pos.Y, lineRune, style)
+		joints := paint.BorderJointUp | paint.BorderJointDown
+		if i == pos.Y {
+			joints &^= paint.BorderJointUp
+		}
+		if i == end-1 {
+			joints &^= paint.BorderJointDown
+		}
+		_ = c.setLineCell(pos.X, i, lineRune, style, border, joints)
+	}
+}
+
+// setLineCell sets the cell to the line drawing rune. When line merging is
+// enabled and the existing rune is part of the border set, the rune of the set
+// extending in the directions of both the existing rune and the given joints
+// is used instead
+func (c *CSurface) setLineCell(x, y int, r rune, style paint.Style, border paint.BorderRuneSet, joints paint.BorderJoint) error {
+	if c.merge && border.CanJoin() {
+		if cell := c.buffer.GetCell(x, y); cell != nil {
+			if have, ok := border.Joints(cell.Value()); ok {
+				if joined, ok := border.JointRune(have | joints); ok {
+					r = joined
+				}
+			}
+		}
+	}
+	return c.buffer.SetCell(x, y, r, style)
+}
+
+// lineBorderRunes returns the default border rune set which draws horizontal
+// or vertical lines with the given rune
+func lineBorderRunes(lineRune rune, horizontal bool) (border paint.BorderRuneSet, ok bool) {
+	for _, name := range []paint.BorderName{paint.StockBorder, paint.DoubleBorder} {
+		if border, ok = paint.GetDefaultBorderRunes(name); ok {
+			if (horizontal && border.Top == lineRune) || (!horizontal && border.Left == lineRune) {
+				return
+			}
+		}
 	}
+	return paint.BorderRuneSet{}, false
 }
 
 // draw a box, at position, of size, with or without a border, with or without
-// being filled in and following the given theme
+// being filled in and following the given theme. the border is joined with any
+// lines it crosses when line merging is enabled, see: SetLineMerging
 func (c *CSurface) Box(pos ptypes.Point2I, size ptypes.Rectangle, border, fill, overlay bool, fillRune rune, contentStyle, borderStyle paint.Style, borderRunes paint.BorderRuneSet) {
 	c.Lock()
 	defer c.Unlock()
@@ -413,14 +586,14 @@ func (c *CSurface) Box(pos ptypes.Point2I, size ptypes.Rectangle, border, fill,
 				switch {
 				case iy == pos.Y && border:
 					// top left corner
-					_ = c.buffer.SetCell(ix, iy, borderRunes.TopLeft, borderStyle)
+					_ = c.setLineCell(ix, iy, borderRunes.TopLeft, borderStyle, borderRunes, paint.BorderJointDown|paint.BorderJointRight)
 				case iy == yEnd && border:
 					// bottom left corner
-					_ = c.buffer.SetCell(ix, iy, borderRunes.BottomLeft, borderStyle)
+					_ = c.setLineCell(ix, iy, borderRunes.BottomLeft, borderStyle, borderRunes, paint.BorderJointUp|paint.BorderJointRight)
 				default:
 					// left border
 					if border {
-						_ = c.buffer.SetCell(ix, iy, borderRunes.Left, borderStyle)
+						_ = c.setLineCell(ix, iy, borderRunes.Left, borderStyle, borderRunes, paint.BorderJointUp|paint.BorderJointDown)
 					} else if fill {
 						_ = c.buffer.SetCell(ix, iy, fillRune, contentStyle)
 					}
@@ -430,14 +603,14 @@ func (c *CSurface) Box(pos ptypes.Point2I, size ptypes.Rectangle, border, fill,
 				switch {
 				case iy == pos.Y && border:
 					// top right corner
-					_ = c.buffer.SetCell(ix, iy, borderRunes.TopRight, borderStyle)
+					_ = c.setLineCell(ix, iy, borderRunes.TopRight, borderStyle, borderRunes, paint.BorderJointDown|paint.BorderJointLeft)
 				case iy == yEnd && border:
 					// bottom right corner
-					_ = c.buffer.SetCell(ix, iy, borderRunes.BottomRight, borderStyle)
+					_ = c.setLineCell(ix, iy, borderRunes.BottomRight, borderStyle, borderRunes, paint.BorderJointUp|paint.BorderJointLeft)
 				default:
 					// right border
 					if border {
-						_ = c.buffer.SetCell(ix, iy, borderRunes.Right, borderStyle)
+						_ = c.setLineCell(ix, iy, borderRunes.Right, borderStyle, borderRunes, paint.BorderJointUp|paint.BorderJointDown)
 					} else if fill {
 						_ = c.buffer.SetCell(ix, iy, fillRune, contentStyle)
 					}
@@ -447,10 +620,10 @@ func (c *CSurface) Box(pos ptypes.Point2I, size ptypes.Rectangle, border, fill,
 				switch {
 				case iy == pos.Y && border:
 					// top middle
-					_ = c.buffer.SetCell(ix, iy, borderRunes.Top, borderStyle)
+					_ = c.setLineCell(ix, iy, borderRunes.Top, borderStyle, borderRunes, paint.BorderJointLeft|paint.BorderJointRight)
 				case iy == yEnd && border:
 					// bottom middle
-					_ = c.buffer.SetCell(ix, iy, borderRunes.Bottom, borderStyle)
+					_ = c.setLineCell(ix, iy, borderRunes.Bottom, borderStyle, borderRunes, paint.BorderJointLeft|paint.BorderJointRight)
 				default:
 					// middle middle
 					if fill {
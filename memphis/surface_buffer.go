@@ -152,6 +152,13 @@ func (b *CSurfaceBuffer) GetBgColor(x, y int) (bg paint.Color) {
 func (b *CSurfaceBuffer) SetCell(x int, y int, r rune, style paint.Style) error {
 	b.Lock()
 	defer b.Unlock()
+	return b.setCell(x, y, r, style)
+}
+
+// setCell is SetCell without locking, which is the responsibility of the
+// caller. Cells on different rows may be set concurrently while holding the
+// read lock, as setting a cell only changes cells on the same row.
+func (b *CSurfaceBuffer) setCell(x int, y int, r rune, style paint.Style) error {
 	dxLen := len(b.data)
 	if dxLen == 0 {
 		return fmt.Errorf("surface has zero size")
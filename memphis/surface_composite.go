@@ -0,0 +1,118 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"fmt"
+	"sync"
+)
+
+// CompositeSurfaces applies each of the sources to the destination surface,
+// in the order given (the last source ends up on top). With more than one
+// worker, the destination is split into horizontal bands which are composited
+// concurrently, each band applying all sources in order so the result is the
+// same as with a single worker. The sources must not be changed until this
+// returns.
+func CompositeSurfaces(dst *CSurface, sources []*CSurface, workers int) (err error) {
+	if dst == nil || dst.buffer == nil {
+		return fmt.Errorf("canvas is nil")
+	}
+	height := dst.GetSize().H
+	if workers > height {
+		workers = height
+	}
+	if workers <= 1 {
+		for _, src := range sources {
+			if src == nil {
+				continue
+			}
+			if err = dst.CompositeSurface(src); err != nil {
+				return
+			}
+		}
+		return
+	}
+
+	dst.RLock()
+	defer dst.RUnlock()
+	// the read lock excludes resizing while bands write distinct rows
+	dst.buffer.RLock()
+	defer dst.buffer.RUnlock()
+
+	band := (height + workers - 1) / workers
+	errs := make([]error, workers)
+	wg := &sync.WaitGroup{}
+	for w := 0; w < workers; w++ {
+		top, bottom := w*band, (w+1)*band
+		if bottom > height {
+			bottom = height
+		}
+		if top >= bottom {
+			continue
+		}
+		wg.Add(1)
+		go func(w, top, bottom int) {
+			defer wg.Done()
+			for _, src := range sources {
+				if src == nil {
+					continue
+				}
+				if errs[w] = dst.compositeBand(src, top, bottom); errs[w] != nil {
+					return
+				}
+			}
+		}(w, top, bottom)
+	}
+	wg.Wait()
+	for _, err = range errs {
+		if err != nil {
+			return
+		}
+	}
+	return nil
+}
+
+// compositeBand applies the source to the rows of this surface from top up to
+// but excluding bottom. The caller must hold the read locks of this surface
+// and its buffer.
+func (c *CSurface) compositeBand(src *CSurface, top, bottom int) error {
+	dstOrigin := c.origin
+	dstW := len(c.buffer.data)
+
+	src.RLock()
+	defer src.RUnlock()
+	src.buffer.RLock()
+	defer src.buffer.RUnlock()
+
+	srcOrigin := src.origin.Clone()
+	srcOrigin.SubPoint(dstOrigin)
+	for x := 0; x < len(src.buffer.data); x++ {
+		column := src.buffer.data[x]
+		for y := 0; y < len(column); y++ {
+			local := srcOrigin.Clone()
+			local.Add(x, y)
+			local.ClampMin(0, 0)
+			if local.Y < top || local.Y >= bottom || local.X >= dstW {
+				continue
+			}
+			if cell := column[y]; cell != nil && !cell.IsNil() {
+				if err := c.buffer.setCell(local.X, local.Y, cell.Value(), cell.Style()); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func makeCompositeSources(count int, size ptypes.Rectangle) (sources []*CSurface) {
+	for i := 0; i < count; i++ {
+		origin := ptypes.MakePoint2I(i*3, i*2)
+		s := NewSurface(origin, ptypes.MakeRectangle(size.W/2, size.H/2), paint.StyleDefault)
+		for x := 0; x < size.W/2; x++ {
+			for y := 0; y < size.H/2; y++ {
+				_ = s.SetRune(x, y, rune('a'+i), paint.StyleDefault)
+			}
+		}
+		sources = append(sources, s)
+	}
+	return
+}
+
+func TestCompositeSurfaces(t *testing.T) {
+	Convey("Parallel compositing matches serial compositing", t, func() {
+		size := ptypes.MakeRectangle(40, 17)
+		sources := makeCompositeSources(5, size)
+		serial := NewSurface(ptypes.MakePoint2I(0, 0), size, paint.StyleDefault)
+		So(CompositeSurfaces(serial, sources, 1), ShouldBeNil)
+		for _, workers := range []int{2, 3, 4, 32} {
+			parallel := NewSurface(ptypes.MakePoint2I(0, 0), size, paint.StyleDefault)
+			So(CompositeSurfaces(parallel, sources, workers), ShouldBeNil)
+			for x := 0; x < size.W; x++ {
+				for y := 0; y < size.H; y++ {
+					So(parallel.GetContent(x, y).Value(), ShouldEqual, serial.GetContent(x, y).Value())
+				}
+			}
+		}
+		// the topmost source is the last one given
+		So(serial.GetContent(12, 8).Value(), ShouldEqual, 'e')
+		So(serial.GetContent(0, 0).Value(), ShouldEqual, 'a')
+		So(CompositeSurfaces(nil, sources, 2), ShouldNotBeNil)
+	})
+}
+
+func BenchmarkCompositeSurfaces(b *testing.B) {
+	size := ptypes.MakeRectangle(300, 100)
+	sources := makeCompositeSources(8, size)
+	dst := NewSurface(ptypes.MakePoint2I(0, 0), size, paint.StyleDefault)
+	for _, workers := range []int{1, 2, 4, 8} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				_ = CompositeSurfaces(dst, sources, workers)
+			}
+		})
+	}
+}
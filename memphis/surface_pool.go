@@ -0,0 +1,90 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"sync"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// surfacePool holds released scratch surfaces, along with their cells, for
+// reuse by AcquireSurface
+var surfacePool sync.Pool
+
+// AcquireSurface returns a blank surface with the given origin, size and
+// style, reusing a previously released surface (and its cells) if available.
+// Acquired surfaces are intended for short-lived drawing, such as rendering
+// text before compositing it, and are to be given to ReleaseSurface once done.
+// Surfaces acquired are never registered, see: MakeSurface
+func AcquireSurface(origin ptypes.Point2I, size ptypes.Rectangle, style paint.Style) (surface *CSurface) {
+	if v, ok := surfacePool.Get().(*CSurface); ok {
+		v.Lock()
+		v.origin = origin
+		v.fill = ' '
+		v.merge = false
+		v.buffer.reset(size, style)
+		v.Unlock()
+		return v
+	}
+	return NewSurface(origin, size, style)
+}
+
+// ReleaseSurface returns the given surface to the pool used by AcquireSurface,
+// the surface must not be used afterwards
+func ReleaseSurface(surface *CSurface) {
+	if surface != nil && surface.buffer != nil {
+		surfacePool.Put(surface)
+	}
+}
+
+// reset blanks the buffer with the given size and style, reusing the existing
+// cells wherever possible
+func (b *CSurfaceBuffer) reset(size ptypes.Rectangle, style paint.Style) {
+	b.Lock()
+	defer b.Unlock()
+	size.Floor(0, 0)
+	b.style = style
+	if size.W == 0 || size.H == 0 {
+		b.data = b.data[:0]
+		return
+	}
+	if cap(b.data) >= size.W {
+		b.data = b.data[:size.W]
+	} else {
+		data := make([][]*CTextCell, size.W)
+		copy(data, b.data[:cap(b.data)])
+		b.data = data
+	}
+	for x := 0; x < size.W; x++ {
+		column := b.data[x]
+		if cap(column) >= size.H {
+			column = column[:size.H]
+		} else {
+			grown := make([]*CTextCell, size.H)
+			copy(grown, column[:cap(column)])
+			column = grown
+		}
+		for y := 0; y < size.H; y++ {
+			if cell := column[y]; cell != nil {
+				cell.reset(style)
+			} else {
+				column[y] = NewTextCellFromRune(' ', style)
+			}
+		}
+		b.data[x] = column
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestSurfacePool(t *testing.T) {
+	Convey("Acquired surfaces are blank", t, func() {
+		bold := paint.StyleDefault.Bold(true)
+		s := AcquireSurface(ptypes.MakePoint2I(1, 1), ptypes.MakeRectangle(4, 3), bold)
+		So(s.GetSize(), ShouldResemble, ptypes.MakeRectangle(4, 3))
+		_ = s.SetRune(1, 1, 'x', bold)
+		s.SetLineMerging(true)
+		ReleaseSurface(s)
+		for _, size := range []ptypes.Rectangle{
+			ptypes.MakeRectangle(2, 2),
+			ptypes.MakeRectangle(6, 5),
+			ptypes.MakeRectangle(0, 0),
+			ptypes.MakeRectangle(3, 3),
+		} {
+			s = AcquireSurface(ptypes.MakePoint2I(2, 3), size, paint.StyleDefault)
+			So(s.GetSize(), ShouldResemble, size)
+			So(s.GetOrigin(), ShouldResemble, ptypes.MakePoint2I(2, 3))
+			So(s.GetLineMerging(), ShouldBeFalse)
+			for x := 0; x < size.W; x++ {
+				for y := 0; y < size.H; y++ {
+					cell := s.GetContent(x, y)
+					So(cell.Value(), ShouldEqual, ' ')
+					So(cell.Style(), ShouldEqual, paint.StyleDefault)
+					So(cell.Dirty(), ShouldBeTrue)
+				}
+			}
+			_ = s.SetRune(0, 0, 'y', bold)
+			ReleaseSurface(s)
+		}
+	})
+}
+
+func BenchmarkSurfaceDrawText(b *testing.B) {
+	text := strings.Repeat("lorem ipsum dolor sit amet ", 200)
+	s := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(120, 50), paint.StyleDefault)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		s.DrawText(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(120, 50), enums.JUSTIFY_LEFT, false, enums.WRAP_WORD, false, paint.StyleDefault, false, false, text)
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestSurfaceLineMerging(t *testing.T) {
+	Convey("Line merging", t, func() {
+		border, _ := paint.GetDefaultBorderRunes(paint.StockBorder)
+		style := paint.StyleDefault
+		row := func(s *CSurface, y int) (line string) {
+			for x := 0; x < s.Width(); x++ {
+				line += string(s.GetContent(x, y).Value())
+			}
+			return
+		}
+		s := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(7, 3), style)
+		So(s.GetLineMerging(), ShouldBeFalse)
+		s.Box(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(4, 3), true, false, false, ' ', style, style, border)
+		s.Box(ptypes.MakePoint2I(3, 0), ptypes.MakeRectangle(4, 3), true, false, false, ' ', style, style, border)
+		So(row(s, 0), ShouldEqual, "┌──┌──┐")
+		s.SetLineMerging(true)
+		So(s.GetLineMerging(), ShouldBeTrue)
+		s.Box(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(4, 3), true, false, false, ' ', style, style, border)
+		s.Box(ptypes.MakePoint2I(3, 0), ptypes.MakeRectangle(4, 3), true, false, false, ' ', style, style, border)
+		So(row(s, 0), ShouldEqual, "┌──┬──┐")
+		So(row(s, 1), ShouldEqual, "│  │  │")
+		So(row(s, 2), ShouldEqual, "└──┴──┘")
+
+		s.DrawLine(ptypes.MakePoint2I(0, 1), 7, enums.ORIENTATION_HORIZONTAL, style)
+		So(row(s, 1), ShouldEqual, "├──┼──┤")
+		s.DrawLine(ptypes.MakePoint2I(1, 0), 3, enums.ORIENTATION_VERTICAL, style)
+		So(row(s, 0), ShouldEqual, "┌┬─┬──┐")
+		So(row(s, 1), ShouldEqual, "├┼─┼──┤")
+		So(row(s, 2), ShouldEqual, "└┴─┴──┘")
+	})
+}
@@ -0,0 +1,74 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"fmt"
+)
+
+var (
+	// DefaultTabWidth is the distance between tab stops when a TabStops does
+	// not specify a Width
+	DefaultTabWidth = len(TapSpace)
+)
+
+// TabStops describes how tab characters are expanded into spaces during text
+// layout. Each tab advances to the next of the Stops (ascending columns,
+// starting from zero) and once past the last of the Stops, to the next
+// multiple of Width. The zero value uses DefaultTabWidth.
+type TabStops struct {
+	// Width is the distance between tab stops, zero for DefaultTabWidth and
+	// negative to leave tab characters as they are
+	Width int
+	// Stops are explicit tab stop columns, in ascending order
+	Stops []int
+}
+
+// MakeTabStops returns TabStops every width columns, with any explicit stops
+// given coming first
+func MakeTabStops(width int, stops ...int) TabStops {
+	return TabStops{Width: width, Stops: stops}
+}
+
+// Enabled returns true if tab characters are to be expanded
+func (t TabStops) Enabled() bool {
+	return t.Width >= 0
+}
+
+// Next returns the column of the next tab stop after the given column
+func (t TabStops) Next(column int) (next int) {
+	for _, stop := range t.Stops {
+		if stop > column {
+			return stop
+		}
+	}
+	width := t.Width
+	if width == 0 {
+		width = DefaultTabWidth
+	}
+	if width <= 0 {
+		return column + 1
+	}
+	return (column/width + 1) * width
+}
+
+// Expand returns the number of spaces a tab at the given column expands to
+func (t TabStops) Expand(column int) (spaces int) {
+	return t.Next(column) - column
+}
+
+func (t TabStops) String() string {
+	return fmt.Sprintf("{width=%d;stops=%v}", t.Width, t.Stops)
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestTabStops(t *testing.T) {
+	Convey("Tab stop columns", t, func() {
+		tabs := TabStops{}
+		So(tabs.Enabled(), ShouldBeTrue)
+		So(tabs.Next(0), ShouldEqual, DefaultTabWidth)
+		So(tabs.Expand(1), ShouldEqual, DefaultTabWidth-1)
+		tabs = MakeTabStops(8, 2, 5)
+		So(tabs.Next(0), ShouldEqual, 2)
+		So(tabs.Next(2), ShouldEqual, 5)
+		So(tabs.Next(5), ShouldEqual, 8)
+		So(tabs.Next(8), ShouldEqual, 16)
+		So(MakeTabStops(-1).Enabled(), ShouldBeFalse)
+	})
+	Convey("Expanding tabs during layout", t, func() {
+		style := paint.GetDefaultMonoStyle()
+		wl := NewWordLine("a\tbc\td\n\tindented", style)
+		wl.SetTabStops(MakeTabStops(4))
+		lines := wl.Make(false, enums.WRAP_NONE, false, enums.JUSTIFY_NONE, -1, style)
+		So(lines, ShouldHaveLength, 2)
+		So(lines[0].Value(), ShouldEqual, "a   bc  d")
+		So(lines[1].Value(), ShouldEqual, "    indented")
+		wl.SetTabStops(MakeTabStops(8, 3))
+		lines = wl.Make(false, enums.WRAP_NONE, false, enums.JUSTIFY_NONE, -1, style)
+		So(lines[0].Value(), ShouldEqual, "a  bc   d")
+		wl.SetTabStops(MakeTabStops(-1))
+		lines = wl.Make(false, enums.WRAP_NONE, false, enums.JUSTIFY_NONE, -1, style)
+		So(lines[0].Value(), ShouldEqual, "a\tbc\td")
+	})
+	Convey("Text buffer tab stops", t, func() {
+		style := paint.GetDefaultMonoStyle()
+		tb := NewTextBuffer("x\ty", style, false)
+		tb.SetTabStops(MakeTabStops(3))
+		So(tb.TabStops().Width, ShouldEqual, 3)
+		So(tb.PlainText(enums.WRAP_NONE, false, enums.JUSTIFY_NONE, -1), ShouldEqual, "x  y")
+		canvas := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(10, 1), style)
+		_, done := tb.DrawIncremental(canvas, true, enums.WRAP_NONE, false, enums.JUSTIFY_NONE, enums.ALIGN_TOP, 0)
+		So(done, ShouldBeTrue)
+		So(canvas.GetContent(3, 0).Value(), ShouldEqual, 'y')
+		So(tb.Clone().TabStops().Width, ShouldEqual, 3)
+	})
+}
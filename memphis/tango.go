@@ -20,10 +20,13 @@ package memphis
 <span
   style=[normal,italic]
   weight=[dim,normal,bold]
-  foreground=[color]
-  background=[color]
+  foreground=[color] (or fgcolor, color)
+  background=[color] (or bgcolor)
   underline=[bool]
   strikethrough=[bool]
+  reverse=[bool]
+  dim=[bool]
+  href=[link]
 >
  CONTENT
 </span>
@@ -33,10 +36,13 @@ package memphis
 <u></u>
 <d></d>
 
+entities: &amp; &lt; &gt; &quot; &apos; &#NNN; &#xHHHH; and the HTML named
+entities (&nbsp; &copy; &hellip; &mdash; &rarr; etc)
 */
 
 import (
 	"encoding/xml"
+	"fmt"
 	"io"
 	"strings"
 	"unicode"
@@ -46,18 +52,72 @@ import (
 	"github.com/go-curses/cdk/log"
 )
 
+// MarkupErrorColor is the foreground color of malformed markup, drawn as-is
+// by Surface.DrawText
+var MarkupErrorColor = paint.ColorRed
+
+// TangoEntities are the named entities recognized in markup, in addition to
+// the standard XML entities. Defaults to the HTML entities.
+var TangoEntities = func() (entities map[string]string) {
+	entities = make(map[string]string, len(xml.HTMLEntity))
+	for name, value := range xml.HTMLEntity {
+		entities[name] = value
+	}
+	return
+}()
+
 type Tango interface {
 	Raw() string
 	TextBuffer(mnemonic bool) TextBuffer
+	Links() (links []TangoLink)
+	LinkAt(index int) (link TangoLink, ok bool)
+	Diagnostics() (diagnostics []TangoDiagnostic)
+}
+
+// TangoLink is the text of a span with an href attribute
+type TangoLink struct {
+	Href string
+	// Start is the index of the first character of the link text
+	Start int
+	// End is the index of the character following the link text
+	End int
+}
+
+// TangoDiagnostic describes a problem found while parsing markup
+type TangoDiagnostic struct {
+	Line    int
+	Column  int
+	Message string
+	// Fatal is true when the markup could not be parsed past this point
+	Fatal bool
+}
+
+func (d TangoDiagnostic) String() string {
+	return fmt.Sprintf("line %d, column %d: %v", d.Line, d.Column, d.Message)
+}
+
+// TangoError is returned by NewMarkup when the markup is malformed
+type TangoError struct {
+	Diagnostic TangoDiagnostic
+}
+
+func (e *TangoError) Error() string {
+	return "markup " + e.Diagnostic.String()
 }
 
 type CTango struct {
-	raw    string
-	style  paint.Style
-	marked []TextCell
-	input  WordLine
+	raw         string
+	style       paint.Style
+	marked      []TextCell
+	input       WordLine
+	links       []TangoLink
+	diagnostics []TangoDiagnostic
 }
 
+// NewMarkup parses the given Tango markup text. Problems which do not prevent
+// parsing, such as unknown elements, attributes or colors, are ignored and
+// reported by Diagnostics. When the markup is malformed, a *TangoError is
+// returned along with the markup parsed up to that point.
 func NewMarkup(text string, style paint.Style) (markup Tango, err error) {
 	if !strings.HasPrefix(text, "<markup") {
 		text = "<markup>" + text + "</markup>"
@@ -66,11 +126,8 @@ func NewMarkup(text string, style paint.Style) (markup Tango, err error) {
 		raw:   text,
 		style: style,
 	}
-	if err = m.init(); err == nil {
-		markup = m
-	} else {
-		markup = nil
-	}
+	err = m.init()
+	markup = m
 	return
 }
 
@@ -84,16 +141,75 @@ func (m *CTango) TextBuffer(mnemonic bool) TextBuffer {
 	return tb
 }
 
+// Links returns the links found in the markup, in order
+func (m *CTango) Links() (links []TangoLink) {
+	links = make([]TangoLink, len(m.links))
+	copy(links, m.links)
+	return
+}
+
+// LinkAt returns the innermost link containing the character at the given
+// index
+func (m *CTango) LinkAt(index int) (link TangoLink, ok bool) {
+	for _, l := range m.links {
+		if index >= l.Start && index < l.End {
+			if !ok || l.Start >= link.Start {
+				link, ok = l, true
+			}
+		}
+	}
+	return
+}
+
+// Diagnostics returns the problems found while parsing the markup
+func (m *CTango) Diagnostics() (diagnostics []TangoDiagnostic) {
+	diagnostics = make([]TangoDiagnostic, len(m.diagnostics))
+	copy(diagnostics, m.diagnostics)
+	return
+}
+
+type tangoFrame struct {
+	style paint.Style
+	href  string
+	start int
+}
+
 func (m *CTango) init() error {
 	m.marked = []TextCell{}
 	m.input = NewEmptyWordLine()
 	r := strings.NewReader(m.raw)
 	parser := xml.NewDecoder(r)
+	parser.Entity = TangoEntities
+
+	diagnose := func(fatal bool, format string, argv ...interface{}) {
+		line, column := parser.InputPos()
+		m.diagnostics = append(m.diagnostics, TangoDiagnostic{
+			Line:    line,
+			Column:  column,
+			Message: fmt.Sprintf(format, argv...),
+			Fatal:   fatal,
+		})
+	}
 
 	wid := 0
 
-	cStyle := m.style         // current style
-	var pStyles []paint.Style // previous styles
+	cStyle := m.style       // current style
+	var frames []tangoFrame // previous styles and open links
+
+	push := func(attrs []xml.Attr) {
+		frames = append(frames, tangoFrame{style: cStyle, start: len(m.marked)})
+		var href string
+		cStyle, href = m.parseStyleAttrs(attrs, diagnose)
+		frames[len(frames)-1].href = href
+	}
+	pop := func() {
+		last := len(frames) - 1
+		if frame := frames[last]; frame.href != "" && len(m.marked) > frame.start {
+			m.links = append(m.links, TangoLink{Href: frame.href, Start: frame.start, End: len(m.marked)})
+		}
+		cStyle = frames[last].style
+		frames = frames[:last]
+	}
 
 	isWord := false
 	var err error
@@ -104,17 +220,18 @@ func (m *CTango) init() error {
 			if err == io.EOF {
 				break
 			}
-			return err
+			message := err.Error()
+			if se, ok := err.(*xml.SyntaxError); ok {
+				message = se.Msg
+			}
+			diagnose(true, "%v", message)
+			return &TangoError{Diagnostic: m.diagnostics[len(m.diagnostics)-1]}
 		}
 		switch t := token.(type) {
 		case xml.StartElement:
 			switch t.Name.Local {
-			case "markup":
-				pStyles = append(pStyles, cStyle)
-				cStyle = m.parseStyleAttrs(t.Attr)
-			case "span":
-				pStyles = append(pStyles, cStyle)
-				cStyle = m.parseStyleAttrs(t.Attr)
+			case "markup", "span":
+				push(t.Attr)
 			case "b":
 				cStyle = cStyle.Bold(true)
 			case "i":
@@ -125,17 +242,13 @@ func (m *CTango) init() error {
 				cStyle = cStyle.Underline(true)
 			case "d":
 				cStyle = cStyle.Dim(true)
+			default:
+				diagnose(false, "unknown element: %v", t.Name.Local)
 			}
 		case xml.EndElement:
 			switch t.Name.Local {
-			case "markup":
-				last := len(pStyles) - 1
-				cStyle = pStyles[last]
-				pStyles = pStyles[:last]
-			case "span":
-				last := len(pStyles) - 1
-				cStyle = pStyles[last]
-				pStyles = pStyles[:last]
+			case "markup", "span":
+				pop()
 			case "b":
 				cStyle = cStyle.Bold(false)
 			case "i":
@@ -148,8 +261,9 @@ func (m *CTango) init() error {
 				cStyle = cStyle.Dim(false)
 			}
 		case xml.CharData:
-			for idx := 0; idx < len(t); idx++ {
-				v, _ := utf8.DecodeRune(t[idx:])
+			for idx := 0; idx < len(t); {
+				v, size := utf8.DecodeRune(t[idx:])
+				idx += size
 				m.marked = append(m.marked, NewTextCellFromRune(v, cStyle))
 				if unicode.IsSpace(v) {
 					if isWord {
@@ -180,8 +294,25 @@ func (m *CTango) init() error {
 	return nil
 }
 
-func (m *CTango) parseStyleAttrs(attrs []xml.Attr) (style paint.Style) {
+func (m *CTango) parseStyleAttrs(attrs []xml.Attr, diagnose func(fatal bool, format string, argv ...interface{})) (style paint.Style, href string) {
 	style = m.style
+	parseBool := func(attr xml.Attr) (value bool) {
+		switch attr.Value {
+		case "true", "1":
+			return true
+		case "false", "0":
+			return false
+		}
+		diagnose(false, "invalid %v value: %q", attr.Name.Local, attr.Value)
+		return false
+	}
+	parseColor := func(attr xml.Attr) (color paint.Color, ok bool) {
+		if color, ok = paint.ParseColor(attr.Value); !ok {
+			diagnose(false, "invalid %v color: %q", attr.Name.Local, attr.Value)
+		}
+		return
+	}
+	underline := false
 	for _, attr := range attrs {
 		switch attr.Name.Local {
 		case "style":
@@ -190,6 +321,8 @@ func (m *CTango) parseStyleAttrs(attrs []xml.Attr) (style paint.Style) {
 				style = style.Italic(false)
 			case "italic":
 				style = style.Italic(true)
+			default:
+				diagnose(false, "invalid style value: %q", attr.Value)
 			}
 		case "weight":
 			switch attr.Value {
@@ -199,16 +332,35 @@ func (m *CTango) parseStyleAttrs(attrs []xml.Attr) (style paint.Style) {
 				style = style.Dim(false).Bold(false)
 			case "bold":
 				style = style.Bold(true)
+			default:
+				diagnose(false, "invalid weight value: %q", attr.Value)
+			}
+		case "foreground", "fgcolor", "color":
+			if color, ok := parseColor(attr); ok {
+				style = style.Foreground(color)
+			}
+		case "background", "bgcolor":
+			if color, ok := parseColor(attr); ok {
+				style = style.Background(color)
 			}
-		case "foreground":
-			style = style.Foreground(paint.GetColor(attr.Value))
-		case "background":
-			style = style.Background(paint.GetColor(attr.Value))
 		case "underline":
-			style = style.Underline(attr.Value == "true" || attr.Value == "1")
+			underline = true
+			style = style.Underline(parseBool(attr))
 		case "strikethrough":
-			style = style.Strike(attr.Value == "true" || attr.Value == "1")
+			style = style.Strike(parseBool(attr))
+		case "reverse":
+			style = style.Reverse(parseBool(attr))
+		case "dim":
+			style = style.Dim(parseBool(attr))
+		case "href":
+			href = attr.Value
+		default:
+			diagnose(false, "unknown attribute: %v", attr.Name.Local)
 		}
 	}
+	if href != "" && !underline {
+		// links are underlined unless the span says otherwise
+		style = style.Underline(true)
+	}
 	return
 }
@@ -0,0 +1,96 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestTango(t *testing.T) {
+	style := paint.GetDefaultMonoStyle()
+	attrsAt := func(m Tango, index int) (fg, bg paint.Color, attrs paint.AttrMask) {
+		c := m.(*CTango).marked[index]
+		return c.Style().Decompose()
+	}
+	Convey("Span attributes", t, func() {
+		m, err := NewMarkup(`<span bgcolor="red" fgcolor="blue">a</span><span reverse="true" strikethrough="1" dim="true">b</span>c`, style)
+		So(err, ShouldBeNil)
+		So(m.Diagnostics(), ShouldBeEmpty)
+		fg, bg, _ := attrsAt(m, 0)
+		So(fg, ShouldEqual, paint.ColorBlue)
+		So(bg, ShouldEqual, paint.ColorRed)
+		_, _, attrs := attrsAt(m, 1)
+		So(attrs.IsReverse(), ShouldBeTrue)
+		So(attrs&paint.AttrStrike, ShouldNotEqual, 0)
+		So(attrs&paint.AttrDim, ShouldNotEqual, 0)
+		_, _, attrs = attrsAt(m, 2)
+		So(attrs.IsReverse(), ShouldBeFalse)
+	})
+	Convey("Links", t, func() {
+		m, err := NewMarkup(`see <span href="https://example.com">the docs</span> and <span href="x" underline="false">more</span>`, style)
+		So(err, ShouldBeNil)
+		So(m.Links(), ShouldResemble, []TangoLink{
+			{Href: "https://example.com", Start: 4, End: 12},
+			{Href: "x", Start: 17, End: 21},
+		})
+		link, ok := m.LinkAt(5)
+		So(ok, ShouldBeTrue)
+		So(link.Href, ShouldEqual, "https://example.com")
+		_, ok = m.LinkAt(13)
+		So(ok, ShouldBeFalse)
+		_, _, attrs := attrsAt(m, 4)
+		So(attrs.IsUnderline(), ShouldBeTrue)
+		_, _, attrs = attrsAt(m, 17)
+		So(attrs.IsUnderline(), ShouldBeFalse)
+	})
+	Convey("Entities", t, func() {
+		m, err := NewMarkup(`a &amp; b&nbsp;&copy;&hellip;&#x263A;`, style)
+		So(err, ShouldBeNil)
+		So(m.TextBuffer(false).PlainText(enums.WRAP_NONE, false, enums.JUSTIFY_NONE, -1), ShouldEqual, "a & b ©…☺")
+	})
+	Convey("Recoverable diagnostics", t, func() {
+		m, err := NewMarkup(`<span color="nope" shiny="yes">a</span><blink>b</blink>`, style)
+		So(err, ShouldBeNil)
+		diagnostics := m.Diagnostics()
+		So(diagnostics, ShouldHaveLength, 3)
+		So(diagnostics[0].Message, ShouldContainSubstring, "invalid color color")
+		So(diagnostics[1].Message, ShouldContainSubstring, "unknown attribute: shiny")
+		So(diagnostics[2].Message, ShouldContainSubstring, "unknown element: blink")
+		So(diagnostics[2].Fatal, ShouldBeFalse)
+		m, err = NewMarkup(`good <b>bad`, style)
+		So(err, ShouldNotBeNil)
+		tangoErr, ok := err.(*TangoError)
+		So(ok, ShouldBeTrue)
+		So(tangoErr.Diagnostic.Fatal, ShouldBeTrue)
+		So(tangoErr.Diagnostic.Line, ShouldEqual, 1)
+		So(m, ShouldNotBeNil)
+		So(m.Diagnostics(), ShouldHaveLength, 1)
+		canvas := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(20, 1), style)
+		canvas.DrawSingleLineText(ptypes.MakePoint2I(0, 0), 20, false, enums.JUSTIFY_LEFT, style, true, false, `<b>broken`)
+		So(canvas.GetContent(0, 0).Value(), ShouldEqual, '<')
+		err = canvas.DrawTextE(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(20, 1), enums.JUSTIFY_LEFT, true, enums.WRAP_NONE, false, style, true, false, `<i>unclosed`)
+		So(err, ShouldHaveSameTypeAs, &TangoError{})
+		fg, _, _ := canvas.GetContent(1, 0).Style().Decompose()
+		So(canvas.GetContent(1, 0).Value(), ShouldEqual, 'i')
+		So(fg, ShouldEqual, MarkupErrorColor)
+		So(canvas.DrawTextE(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(20, 1), enums.JUSTIFY_LEFT, true, enums.WRAP_NONE, false, style, true, false, `<i>fine</i>`), ShouldBeNil)
+	})
+}
@@ -16,6 +16,7 @@ package memphis
 
 import (
 	"sync"
+	"time"
 
 	"github.com/go-curses/cdk/lib/enums"
 	"github.com/go-curses/cdk/lib/math"
@@ -38,6 +39,10 @@ type TextBuffer interface {
 	SetStyle(style paint.Style)
 	Mnemonic() (enabled bool)
 	SetMnemonic(enabled bool)
+	TabStops() (tabs TabStops)
+	SetTabStops(tabs TabStops)
+	Direction() (direction TextDirection)
+	SetDirection(direction TextDirection)
 	CharacterCount() (cellCount int)
 	WordCount() (wordCount int)
 	LineCount() (lineCount int)
@@ -45,6 +50,8 @@ type TextBuffer interface {
 	PlainText(wordWrap enums.WrapMode, ellipsize bool, justify enums.Justification, maxChars int) (plain string)
 	PlainTextInfo(wordWrap enums.WrapMode, ellipsize bool, justify enums.Justification, maxChars int) (longestLine, lineCount int)
 	Draw(canvas Surface, singleLine bool, wordWrap enums.WrapMode, ellipsize bool, justify enums.Justification, vAlign enums.VerticalAlignment) enums.EventFlag
+	DrawIncremental(canvas Surface, singleLine bool, wordWrap enums.WrapMode, ellipsize bool, justify enums.Justification, vAlign enums.VerticalAlignment, budget time.Duration) (flag enums.EventFlag, done bool)
+	CancelLayout()
 }
 
 type CTextBuffer struct {
@@ -52,7 +59,10 @@ type CTextBuffer struct {
 	input     WordLine
 	style     paint.Style
 	mnemonics bool
+	tabs      TabStops
+	direction TextDirection
 	selection *ptypes.Range
+	layout    TextLayout
 
 	sync.Mutex
 }
@@ -77,6 +87,8 @@ func (b *CTextBuffer) Clone() (cloned TextBuffer) {
 	b.Lock()
 	defer b.Unlock()
 	cloned = NewTextBuffer(b.raw, b.style, b.mnemonics)
+	cloned.SetTabStops(b.tabs)
+	cloned.SetDirection(b.direction)
 	return
 }
 
@@ -84,7 +96,10 @@ func (b *CTextBuffer) Set(input string, style paint.Style) {
 	b.Lock()
 	b.raw = input
 	b.input = NewWordLine(input, style)
+	b.input.SetTabStops(b.tabs)
+	b.input.SetDirection(b.direction)
 	b.selection = nil
+	b.cancelLayout()
 	b.Unlock()
 }
 
@@ -92,6 +107,7 @@ func (b *CTextBuffer) Select(start, end int) {
 	b.Lock()
 	defer b.Unlock()
 	b.selection = ptypes.NewRange(start, end)
+	b.cancelLayout()
 	for i := start; i <= end; i++ {
 		if style, ok := b.input.GetCharacterStyle(i); ok {
 			style = style.Reverse(true)
@@ -109,7 +125,10 @@ func (b *CTextBuffer) Input() (raw string) {
 func (b *CTextBuffer) SetInput(input WordLine) {
 	b.Lock()
 	b.input = input
+	b.input.SetTabStops(b.tabs)
+	b.input.SetDirection(b.direction)
 	b.raw = input.Value()
+	b.cancelLayout()
 	b.Unlock()
 }
 
@@ -124,7 +143,10 @@ func (b *CTextBuffer) SetStyle(style paint.Style) {
 	b.style = style
 	if b.input != nil {
 		b.input = NewWordLine(b.raw, style)
+		b.input.SetTabStops(b.tabs)
+		b.input.SetDirection(b.direction)
 	}
+	b.cancelLayout()
 	b.Unlock()
 }
 
@@ -137,6 +159,42 @@ func (b *CTextBuffer) Mnemonic() (enabled bool) {
 func (b *CTextBuffer) SetMnemonic(enabled bool) {
 	b.Lock()
 	b.mnemonics = enabled
+	b.cancelLayout()
+	b.Unlock()
+}
+
+func (b *CTextBuffer) TabStops() (tabs TabStops) {
+	b.Lock()
+	defer b.Unlock()
+	return b.tabs
+}
+
+// SetTabStops changes how tab characters are expanded when the text is laid
+// out, see: TabStops
+func (b *CTextBuffer) SetTabStops(tabs TabStops) {
+	b.Lock()
+	b.tabs = tabs
+	if b.input != nil {
+		b.input.SetTabStops(tabs)
+	}
+	b.cancelLayout()
+	b.Unlock()
+}
+
+func (b *CTextBuffer) Direction() (direction TextDirection) {
+	b.Lock()
+	defer b.Unlock()
+	return b.direction
+}
+
+// SetDirection changes the base direction of the text, see: TextDirection
+func (b *CTextBuffer) SetDirection(direction TextDirection) {
+	b.Lock()
+	b.direction = direction
+	if b.input != nil {
+		b.input.SetDirection(direction)
+	}
+	b.cancelLayout()
 	b.Unlock()
 }
 
@@ -236,6 +294,63 @@ func (b *CTextBuffer) Draw(canvas Surface, singleLine bool, wordWrap enums.WrapM
 
 	maxChars := canvas.Width()
 	lines := b.input.Make(b.mnemonics, wordWrap, ellipsize, justify, maxChars, b.style)
+	return b.drawLines(canvas, lines, characterCount, singleLine, vAlign)
+}
+
+// DrawIncremental is the same as Draw except that the text layout is performed
+// across multiple calls, spending at most the given budget (or
+// TextLayoutBudget if zero) on layout per call. The lines completed so far are
+// drawn and done is true once all lines are complete. Callers are expected to
+// request another draw until done is true. Changing the content of the buffer
+// or any of the layout arguments cancels the layout in progress and starts
+// over.
+func (b *CTextBuffer) DrawIncremental(canvas Surface, singleLine bool, wordWrap enums.WrapMode, ellipsize bool, justify enums.Justification, vAlign enums.VerticalAlignment, budget time.Duration) (flag enums.EventFlag, done bool) {
+	if b.input == nil {
+		return enums.EVENT_PASS, true
+	}
+	b.Lock()
+	defer b.Unlock()
+	characterCount := b.input.CharacterCount()
+	if b.input == nil || characterCount == 0 {
+		log.TraceDF(1, "text buffer input nil or zero length")
+		return enums.EVENT_PASS, true
+	}
+
+	if singleLine {
+		wordWrap = enums.WRAP_NONE
+	}
+	if budget <= 0 {
+		budget = TextLayoutBudget
+	}
+
+	maxChars := canvas.Width()
+	tag := MakeTag(b.mnemonics, wordWrap, ellipsize, justify, maxChars, b.style)
+	if b.layout == nil || b.layout.Tag() != tag {
+		b.cancelLayout()
+		b.layout = NewTextLayout(b.input, b.mnemonics, wordWrap, ellipsize, justify, maxChars, b.style)
+	}
+	if done = b.layout.Done(); !done {
+		done = b.layout.Step(budget)
+	}
+	flag = b.drawLines(canvas, b.layout.Lines(), characterCount, singleLine, vAlign)
+	return
+}
+
+// CancelLayout stops any incremental layout in progress
+func (b *CTextBuffer) CancelLayout() {
+	b.Lock()
+	defer b.Unlock()
+	b.cancelLayout()
+}
+
+func (b *CTextBuffer) cancelLayout() {
+	if b.layout != nil {
+		b.layout.Cancel()
+		b.layout = nil
+	}
+}
+
+func (b *CTextBuffer) drawLines(canvas Surface, lines []WordLine, characterCount int, singleLine bool, vAlign enums.VerticalAlignment) enums.EventFlag {
 	size := canvas.GetSize()
 	if size.W <= 0 || size.H <= 0 {
 		log.TraceDF(1, "text buffer zero canvas size")
@@ -59,6 +59,13 @@ func NewTextCell(char *CTextChar, style paint.Style) *CTextCell {
 	}
 }
 
+// reset makes the cell a dirty space with the given style
+func (t *CTextCell) reset(style paint.Style) {
+	t.char.value, t.char.width, t.char.count = ' ', 1, 1
+	t.style = style
+	t.dirty = true
+}
+
 func (t *CTextCell) Equals(mc rune, style paint.Style, width int) bool {
 	// t.RLock()
 	// defer t.RUnlock()
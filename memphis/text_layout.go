@@ -0,0 +1,165 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+var (
+	// TextLayoutBudget is the default amount of time spent laying out text
+	// per frame when drawing incrementally
+	TextLayoutBudget = time.Millisecond * 8
+)
+
+// TextLayout performs WordLine.Make in bounded slices of time, allowing very
+// large texts to be laid out progressively across multiple frames. The input
+// is split into paragraphs on hard line breaks and each Step lays out as many
+// paragraphs as fit within the given budget (and at least one).
+type TextLayout interface {
+	Tag() (tag string)
+	Step(budget time.Duration) (done bool)
+	Lines() (lines []WordLine)
+	Progress() (completed, total int)
+	Done() (done bool)
+	Cancel()
+	Cancelled() (cancelled bool)
+}
+
+type CTextLayout struct {
+	tag         string
+	paragraphs  []WordLine
+	next        int
+	lines       []WordLine
+	mnemonic    bool
+	wrap        enums.WrapMode
+	ellipsize   bool
+	justify     enums.Justification
+	maxChars    int
+	fillerStyle paint.Style
+	cancelled   bool
+
+	sync.RWMutex
+}
+
+// NewTextLayout prepares the incremental layout of the given input, with the
+// same arguments as WordLine.Make. No layout is performed until Step is called.
+func NewTextLayout(input WordLine, mnemonic bool, wrap enums.WrapMode, ellipsize bool, justify enums.Justification, maxChars int, fillerStyle paint.Style) (layout *CTextLayout) {
+	layout = &CTextLayout{
+		tag:         MakeTag(mnemonic, wrap, ellipsize, justify, maxChars, fillerStyle),
+		mnemonic:    mnemonic,
+		wrap:        wrap,
+		ellipsize:   ellipsize,
+		justify:     justify,
+		maxChars:    maxChars,
+		fillerStyle: fillerStyle,
+	}
+	tabs, direction := input.GetTabStops(), input.GetDirection()
+	newParagraph := func() (paragraph WordLine) {
+		paragraph = NewEmptyWordLine()
+		paragraph.SetTabStops(tabs)
+		paragraph.SetDirection(direction)
+		return
+	}
+	paragraph := newParagraph()
+	for _, word := range input.Words() {
+		if word.NewlineCount() == 0 {
+			paragraph.AppendWordCell(word)
+			continue
+		}
+		cell := NewEmptyWordCell()
+		for _, c := range word.Characters() {
+			if c.Value() == '\n' {
+				if cell.Len() > 0 {
+					paragraph.AppendWordCell(cell)
+					cell = NewEmptyWordCell()
+				}
+				layout.paragraphs = append(layout.paragraphs, paragraph)
+				paragraph = newParagraph()
+				continue
+			}
+			cell.AppendRune(c.Value(), c.Style())
+		}
+		if cell.Len() > 0 {
+			paragraph.AppendWordCell(cell)
+		}
+	}
+	layout.paragraphs = append(layout.paragraphs, paragraph)
+	return
+}
+
+// Tag returns the cache tag of the layout arguments, used to detect when the
+// arguments have changed and a new layout is required
+func (l *CTextLayout) Tag() (tag string) {
+	return l.tag
+}
+
+// Step lays out paragraphs until the budget is exhausted, returning true when
+// all paragraphs are complete or the layout was cancelled
+func (l *CTextLayout) Step(budget time.Duration) (done bool) {
+	l.Lock()
+	defer l.Unlock()
+	started := time.Now()
+	for !l.cancelled && l.next < len(l.paragraphs) {
+		paragraph := l.paragraphs[l.next]
+		lines := paragraph.Make(l.mnemonic, l.wrap, l.ellipsize, l.justify, l.maxChars, l.fillerStyle)
+		l.lines = append(l.lines, lines...)
+		l.next++
+		if time.Since(started) >= budget {
+			break
+		}
+	}
+	return l.cancelled || l.next >= len(l.paragraphs)
+}
+
+// Lines returns the lines laid out so far
+func (l *CTextLayout) Lines() (lines []WordLine) {
+	l.RLock()
+	defer l.RUnlock()
+	lines = make([]WordLine, len(l.lines))
+	copy(lines, l.lines)
+	return
+}
+
+// Progress returns the number of paragraphs completed and the total number of
+// paragraphs in the input
+func (l *CTextLayout) Progress() (completed, total int) {
+	l.RLock()
+	defer l.RUnlock()
+	return l.next, len(l.paragraphs)
+}
+
+func (l *CTextLayout) Done() (done bool) {
+	l.RLock()
+	defer l.RUnlock()
+	return l.next >= len(l.paragraphs)
+}
+
+// Cancel stops any further layout from being performed
+func (l *CTextLayout) Cancel() {
+	l.Lock()
+	defer l.Unlock()
+	l.cancelled = true
+}
+
+func (l *CTextLayout) Cancelled() (cancelled bool) {
+	l.RLock()
+	defer l.RUnlock()
+	return l.cancelled
+}
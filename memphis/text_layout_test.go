@@ -0,0 +1,68 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestTextLayout(t *testing.T) {
+	Convey("Incremental text layout", t, func() {
+		style := paint.GetDefaultMonoStyle()
+		text := "the quick brown fox\njumps over\n\nthe lazy dog"
+		wl := NewWordLine(text, style)
+		expected := wl.Make(false, enums.WRAP_WORD, false, enums.JUSTIFY_LEFT, 10, style)
+		layout := NewTextLayout(wl, false, enums.WRAP_WORD, false, enums.JUSTIFY_LEFT, 10, style)
+		completed, total := layout.Progress()
+		So(completed, ShouldEqual, 0)
+		So(total, ShouldEqual, 4)
+		steps := 0
+		for !layout.Step(0) {
+			steps++
+		}
+		So(steps, ShouldEqual, 3)
+		So(layout.Done(), ShouldBeTrue)
+		lines := layout.Lines()
+		So(lines, ShouldHaveLength, len(expected))
+		for idx := range expected {
+			So(lines[idx].Value(), ShouldEqual, expected[idx].Value())
+		}
+		layout = NewTextLayout(wl, false, enums.WRAP_WORD, false, enums.JUSTIFY_LEFT, 10, style)
+		layout.Cancel()
+		So(layout.Step(0), ShouldBeTrue)
+		So(layout.Cancelled(), ShouldBeTrue)
+		So(layout.Lines(), ShouldBeEmpty)
+	})
+	Convey("Incremental text buffer drawing", t, func() {
+		style := paint.GetDefaultMonoStyle()
+		tb := NewTextBuffer(strings.Repeat("line\n", 9)+"line", style, false)
+		canvas := NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(10, 10), style)
+		_, done := tb.DrawIncremental(canvas, false, enums.WRAP_WORD, false, enums.JUSTIFY_LEFT, enums.ALIGN_TOP, 1)
+		So(done, ShouldBeFalse)
+		So(canvas.GetContent(0, 0).Value(), ShouldEqual, 'l')
+		tb.Set("other", style)
+		for !done {
+			_, done = tb.DrawIncremental(canvas, false, enums.WRAP_WORD, false, enums.JUSTIFY_LEFT, enums.ALIGN_TOP, 1)
+		}
+		So(canvas.GetContent(0, 0).Value(), ShouldEqual, 'o')
+	})
+}
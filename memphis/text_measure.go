@@ -0,0 +1,148 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// TextMeasureOptions are the layout arguments given to MeasureText, the same
+// as those given to Surface.DrawText
+type TextMeasureOptions struct {
+	// Markup parses the text as Tango markup
+	Markup bool
+	// Mnemonic underlines the character following the first underscore
+	Mnemonic   bool
+	SingleLine bool
+	Wrap       enums.WrapMode
+	Ellipsize  bool
+	Justify    enums.Justification
+	// MaxChars is the available width, -1 for unlimited
+	MaxChars int
+	// Tabs controls the expansion of tab characters
+	Tabs TabStops
+	// Direction is the base direction of the text
+	Direction TextDirection
+}
+
+// TextMetrics describes the space occupied by laid out text
+type TextMetrics struct {
+	// Lines is the number of lines
+	Lines int
+	// Width is the length of the longest line
+	Width int
+	// HasMnemonic is true when a mnemonic character was found
+	HasMnemonic bool
+	// MnemonicRune is the mnemonic character
+	MnemonicRune rune
+	// MnemonicPos is the column and line of the mnemonic character
+	MnemonicPos ptypes.Point2I
+}
+
+// Size returns the width and number of lines as a rectangle
+func (m TextMetrics) Size() (size ptypes.Rectangle) {
+	return ptypes.MakeRectangle(m.Width, m.Lines)
+}
+
+// MeasureText lays out the text as Surface.DrawText would, without drawing
+// anything, and returns the space it occupies. This allows the size of text
+// to be known before allocating space for it. Malformed markup is measured as
+// plain text and the parse error returned.
+func MeasureText(text string, style paint.Style, options TextMeasureOptions) (metrics TextMetrics, err error) {
+	var input WordLine
+	if options.Markup {
+		var m Tango
+		if m, err = NewMarkup(text, style); err != nil {
+			// measured as drawn by Surface.DrawText
+			input = NewWordLine(text, style)
+		} else {
+			input = m.(*CTango).input
+		}
+	} else {
+		input = NewWordLine(text, style)
+	}
+	input.SetTabStops(options.Tabs)
+	input.SetDirection(options.Direction)
+	wrap := options.Wrap
+	if options.SingleLine {
+		wrap = enums.WRAP_NONE
+	}
+	metrics = input.Measure(options.Mnemonic, wrap, options.Ellipsize, options.Justify, options.MaxChars)
+	if options.SingleLine && metrics.Lines > 1 {
+		metrics.Lines = 1
+		if metrics.HasMnemonic && metrics.MnemonicPos.Y > 0 {
+			metrics.HasMnemonic = false
+			metrics.MnemonicRune = 0
+			metrics.MnemonicPos = ptypes.MakePoint2I(0, 0)
+		}
+	}
+	return
+}
+
+// Measure performs the same layout as Make and returns the space occupied by
+// the resulting lines, along with the position of the mnemonic character
+func (w *CWordLine) Measure(mnemonic bool, wrap enums.WrapMode, ellipsize bool, justify enums.Justification, maxChars int) (metrics TextMetrics) {
+	var source WordLine = w
+	if mnemonic {
+		// Make underlines the mnemonic, so measure a copy without any other
+		// underlined characters to find where it ends up
+		source = w.plainCopy()
+	}
+	lines := source.Make(mnemonic, wrap, ellipsize, justify, maxChars, paint.StyleDefault)
+	metrics.Lines = len(lines)
+	for y, line := range lines {
+		x := 0
+		for _, word := range line.Words() {
+			for _, c := range word.Characters() {
+				if mnemonic && !metrics.HasMnemonic && isUnderlined(c.Style()) {
+					metrics.HasMnemonic = true
+					metrics.MnemonicRune = c.Value()
+					metrics.MnemonicPos = ptypes.MakePoint2I(x, y)
+				}
+				x++
+			}
+		}
+		if x > metrics.Width {
+			metrics.Width = x
+		}
+	}
+	return
+}
+
+func isUnderlined(style paint.Style) bool {
+	_, _, attr := style.Decompose()
+	return attr.IsUnderline()
+}
+
+func (w *CWordLine) plainCopy() (plain *CWordLine) {
+	w.RLock()
+	defer w.RUnlock()
+	plain = &CWordLine{
+		words: make([]WordCell, len(w.words)),
+		tabs:  w.tabs,
+		dir:   w.dir,
+		cache: NewWordPageCache(),
+	}
+	for idx, word := range w.words {
+		cell := NewEmptyWordCell()
+		for _, c := range word.Characters() {
+			cell.AppendRune(c.Value(), paint.StyleDefault)
+		}
+		plain.words[idx] = cell
+	}
+	return
+}
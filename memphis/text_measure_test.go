@@ -0,0 +1,74 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestMeasureText(t *testing.T) {
+	style := paint.GetDefaultMonoStyle()
+	Convey("Measuring plain text", t, func() {
+		metrics, err := MeasureText("the quick brown fox\njumps", style, TextMeasureOptions{
+			Wrap:     enums.WRAP_WORD,
+			Justify:  enums.JUSTIFY_LEFT,
+			MaxChars: 10,
+		})
+		So(err, ShouldBeNil)
+		wl := NewWordLine("the quick brown fox\njumps", style)
+		lines := wl.Make(false, enums.WRAP_WORD, false, enums.JUSTIFY_LEFT, 10, style)
+		So(metrics.Lines, ShouldEqual, len(lines))
+		So(metrics.Width, ShouldBeLessThanOrEqualTo, 10)
+		So(metrics.HasMnemonic, ShouldBeFalse)
+		metrics, err = MeasureText("the quick brown fox\njumps", style, TextMeasureOptions{
+			Wrap:     enums.WRAP_NONE,
+			MaxChars: -1,
+		})
+		So(err, ShouldBeNil)
+		So(metrics.Size(), ShouldResemble, ptypes.MakeRectangle(19, 2))
+		metrics, _ = MeasureText("the quick brown fox\njumps", style, TextMeasureOptions{
+			SingleLine: true,
+			MaxChars:   -1,
+		})
+		So(metrics.Lines, ShouldEqual, 1)
+	})
+	Convey("Measuring mnemonics", t, func() {
+		metrics, err := MeasureText("first line\nthe _mnemonic", style, TextMeasureOptions{
+			Mnemonic: true,
+			Wrap:     enums.WRAP_WORD,
+			MaxChars: -1,
+		})
+		So(err, ShouldBeNil)
+		So(metrics.Size(), ShouldResemble, ptypes.MakeRectangle(12, 2))
+		So(metrics.HasMnemonic, ShouldBeTrue)
+		So(metrics.MnemonicRune, ShouldEqual, 'm')
+		So(metrics.MnemonicPos, ShouldResemble, ptypes.MakePoint2I(4, 1))
+		metrics, _ = MeasureText("<u>first</u> _second", style, TextMeasureOptions{
+			Markup:   true,
+			Mnemonic: true,
+			MaxChars: -1,
+		})
+		So(metrics.MnemonicRune, ShouldEqual, 's')
+		So(metrics.MnemonicPos, ShouldResemble, ptypes.MakePoint2I(6, 0))
+		_, err = MeasureText("<b>broken", style, TextMeasureOptions{Markup: true})
+		So(err, ShouldNotBeNil)
+	})
+}
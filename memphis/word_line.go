@@ -44,10 +44,17 @@ type WordLine interface {
 	Value() (s string)
 	String() (s string)
 	Make(mnemonic bool, wrap enums.WrapMode, ellipsize bool, justify enums.Justification, maxChars int, fillerStyle paint.Style) (formatted []WordLine)
+	SetTabStops(tabs TabStops)
+	GetTabStops() (tabs TabStops)
+	SetDirection(direction TextDirection)
+	GetDirection() (direction TextDirection)
+	Measure(mnemonic bool, wrap enums.WrapMode, ellipsize bool, justify enums.Justification, maxChars int) (metrics TextMetrics)
 }
 
 type CWordLine struct {
 	words []WordCell
+	tabs  TabStops
+	dir   TextDirection
 	cache *CWordLineCache
 
 	sync.RWMutex
@@ -275,14 +282,53 @@ func (w *CWordLine) String() (s string) {
 	return
 }
 
-// wrap, justify and align the set input, with filler style
+// SetTabStops changes how tab characters are expanded by Make
+func (w *CWordLine) SetTabStops(tabs TabStops) {
+	w.Lock()
+	defer w.Unlock()
+	w.cache.Clear()
+	w.tabs = tabs
+}
+
+func (w *CWordLine) GetTabStops() (tabs TabStops) {
+	w.RLock()
+	defer w.RUnlock()
+	return w.tabs
+}
+
+// SetDirection changes the base direction of the paragraphs laid out by Make,
+// lines are reordered for display following the Unicode Bidirectional
+// Algorithm
+func (w *CWordLine) SetDirection(direction TextDirection) {
+	w.Lock()
+	defer w.Unlock()
+	w.cache.Clear()
+	w.dir = direction
+}
+
+func (w *CWordLine) GetDirection() (direction TextDirection) {
+	w.RLock()
+	defer w.RUnlock()
+	return w.dir
+}
+
+// wrap, justify and align the set input, with filler style. tab characters
+// are expanded to the tab stops, counting columns from the start of each
+// line before wrapping
 func (w *CWordLine) Make(mnemonic bool, wrap enums.WrapMode, ellipsize bool, justify enums.Justification, maxChars int, fillerStyle paint.Style) (formatted []WordLine) {
 	tag := MakeTag(mnemonic, wrap, justify, maxChars, fillerStyle)
 	return w.cache.Hit(tag, func() []WordLine {
 		var lines []WordLine
 		lines = append(lines, NewEmptyWordLine())
-		cid, wid, lid := 0, 0, 0
+		cid, wid, lid, col := 0, 0, 0, 0
 		mnemonicFound := false
+		appendRune := func(r rune, style paint.Style) {
+			if wid >= lines[lid].Len() {
+				lines[lid].AppendWordCell(NewEmptyWordCell())
+			}
+			_ = lines[lid].AppendWordRune(wid, r, style)
+			col++
+		}
 		w.RLock()
 		defer w.RUnlock()
 		for _, word := range w.words {
@@ -292,6 +338,15 @@ func (w *CWordLine) Make(mnemonic bool, wrap enums.WrapMode, ellipsize bool, jus
 					lines = append(lines, NewEmptyWordLine())
 					lid = len(lines) - 1
 					wid = -1
+					col = 0
+				case '\t':
+					if !w.tabs.Enabled() {
+						appendRune(c.Value(), c.Style())
+						break
+					}
+					for spaces := w.tabs.Expand(col); spaces > 0; spaces-- {
+						appendRune(' ', c.Style())
+					}
 				case '_':
 					if mnemonic {
 						nextId := wcId + 1
@@ -307,14 +362,11 @@ func (w *CWordLine) Make(mnemonic bool, wrap enums.WrapMode, ellipsize bool, jus
 					}
 					fallthrough
 				default:
-					if wid >= lines[lid].Len() {
-						lines[lid].AppendWordCell(NewEmptyWordCell())
-					}
 					if mnemonicFound {
-						_ = lines[lid].AppendWordRune(wid, c.Value(), c.Style().Underline(true))
+						appendRune(c.Value(), c.Style().Underline(true))
 						mnemonicFound = false
 					} else {
-						_ = lines[lid].AppendWordRune(wid, c.Value(), c.Style())
+						appendRune(c.Value(), c.Style())
 					}
 				}
 				cid++
@@ -327,7 +379,13 @@ func (w *CWordLine) Make(mnemonic bool, wrap enums.WrapMode, ellipsize bool, jus
 }
 
 func (w *CWordLine) applyTypography(wrap enums.WrapMode, ellipsize bool, justify enums.Justification, maxChars int, fillerStyle paint.Style, input []WordLine) (output []WordLine) {
-	output = w.applyTypographicWrap(wrap, ellipsize, maxChars, input)
+	if needsBidi(w.dir, input) {
+		output = w.applyTypographicBidi(w.dir, func(paragraph []WordLine) []WordLine {
+			return w.applyTypographicWrap(wrap, ellipsize, maxChars, paragraph)
+		}, input)
+	} else {
+		output = w.applyTypographicWrap(wrap, ellipsize, maxChars, input)
+	}
 	output = w.applyTypographicJustify(justify, maxChars, fillerStyle, output)
 	return
 }
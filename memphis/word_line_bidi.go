@@ -0,0 +1,99 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memphis
+
+import (
+	"unicode"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// paragraphDirection returns the base direction of the paragraph, resolving
+// TextDirectionAuto from its first strong character
+func paragraphDirection(direction TextDirection, paragraph WordLine) TextDirection {
+	if direction != TextDirectionAuto {
+		return direction
+	}
+	for _, word := range paragraph.Words() {
+		for _, c := range word.Characters() {
+			if dir, ok := strongDirection(c.Value()); ok {
+				return dir
+			}
+		}
+	}
+	return TextDirectionLTR
+}
+
+// needsBidi returns true if any of the paragraphs is right-to-left or contains
+// right-to-left characters
+func needsBidi(direction TextDirection, paragraphs []WordLine) bool {
+	if direction == TextDirectionRTL {
+		return true
+	}
+	for _, paragraph := range paragraphs {
+		for _, word := range paragraph.Words() {
+			for _, c := range word.Characters() {
+				if isRTLRune(c.Value()) {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// wrap each paragraph separately and reorder the resulting lines for display
+// with the base direction of their paragraph
+func (w *CWordLine) applyTypographicBidi(direction TextDirection, wrap func(input []WordLine) []WordLine, input []WordLine) (output []WordLine) {
+	for _, paragraph := range input {
+		rtl := paragraphDirection(direction, paragraph) == TextDirectionRTL
+		for _, line := range wrap([]WordLine{paragraph}) {
+			output = append(output, reorderWordLine(line, rtl))
+		}
+	}
+	return
+}
+
+// reorderWordLine returns the line in visual order, regrouped into words
+func reorderWordLine(line WordLine, rtl bool) WordLine {
+	var runes []rune
+	var styles []paint.Style
+	for _, word := range line.Words() {
+		for _, c := range word.Characters() {
+			runes = append(runes, c.Value())
+			styles = append(styles, c.Style())
+		}
+	}
+	if len(runes) == 0 || (!rtl && !hasRTL(runes)) {
+		return line
+	}
+	levels := resolveBidiLevels(runes, rtl)
+	reordered := NewEmptyWordLine()
+	wid, wasSpace := -1, false
+	for _, l := range reorderBidiLevels(levels) {
+		r := runes[l]
+		if levels[l]%2 == 1 {
+			r = mirrorRune(r)
+		}
+		isSpace := unicode.IsSpace(r)
+		if wid < 0 || isSpace != wasSpace {
+			reordered.AppendWordCell(NewEmptyWordCell())
+			wid++
+		}
+		wasSpace = isSpace
+		_ = reordered.AppendWordRune(wid, r, styles[l])
+	}
+	return reordered
+}
@@ -27,6 +27,9 @@ import (
 const (
 	TypeMetaData      CTypeTag = "cdk-metadata"
 	SignalSetProperty Signal   = "set-property"
+	// SignalNotifyProperty is emitted after any property value changes, see
+	// NotifySignal for the per-property variant
+	SignalNotifyProperty Signal = "notify"
 )
 
 func init() {
@@ -73,6 +76,15 @@ type MetaData interface {
 	SetStructProperty(name Property, value interface{}) error
 	GetTimeProperty(name Property) (value time.Duration, err error)
 	SetTimeProperty(name Property, value time.Duration) error
+	GetStyleClassProperty(name Property) (value paint.StyleClass, err error)
+	SetStyleClassProperty(name Property, value paint.StyleClass) error
+	FreezeNotify()
+	ThawNotify()
+	IsNotifyFrozen() (frozen bool)
+	BindProperty(src Property, target MetaData, dst Property, flags BindingFlags) (binding *CPropertyBinding, err error)
+	BindPropertyFull(src Property, target MetaData, dst Property, flags BindingFlags, to, from BindingTransform) (binding *CPropertyBinding, err error)
+	MarshalProperties(format PropertyFormat) (data []byte, err error)
+	UnmarshalProperties(data []byte, format PropertyFormat) (err error)
 }
 
 type CMetaData struct {
@@ -80,6 +92,7 @@ type CMetaData struct {
 
 	properties   []*CProperty
 	propertyLock *sync.RWMutex
+	notify       *cPropertyNotifier
 }
 
 func (o *CMetaData) Init() (already bool) {
@@ -89,6 +102,7 @@ func (o *CMetaData) Init() (already bool) {
 	o.CSignaling.Init()
 	o.properties = make([]*CProperty, 0)
 	o.propertyLock = &sync.RWMutex{}
+	o.notify = &cPropertyNotifier{}
 	return false
 }
 
@@ -172,14 +186,21 @@ func (o *CMetaData) SetProperties(properties map[Property]string) (err error) {
 	for name, value := range properties {
 		if prop := o.GetProperty(name); prop != nil {
 			o.propertyLock.Lock()
+			old := prop.Value()
+			changed := false
 			if prop.Buildable() {
 				if err = prop.SetFromString(value); err != nil {
 					o.LogError("error setting \"%v\" property from string: \"%v\" - %v", name, value, err)
+				} else {
+					changed = true
 				}
 			} else {
 				o.LogTrace("property not buildable: %v", name)
 			}
 			o.propertyLock.Unlock()
+			if changed {
+				o.notifyProperty(name, old, prop.Value())
+			}
 		} else {
 			o.LogTrace("property not found: %v", name)
 		}
@@ -233,11 +254,13 @@ func (o *CMetaData) SetPropertyFromString(name Property, value string) error {
 		}
 		if f := o.Emit(SignalSetProperty, o, name, value); f == enums.EVENT_PASS {
 			o.propertyLock.Lock()
+			old := prop.Value()
 			if err := prop.SetFromString(value); err != nil {
 				o.propertyLock.Unlock()
 				return err
 			}
 			o.propertyLock.Unlock()
+			o.notifyProperty(name, old, prop.Value())
 		}
 	}
 	return nil
@@ -250,11 +273,13 @@ func (o *CMetaData) SetProperty(name Property, value interface{}) error {
 		}
 		if f := o.Emit(SignalSetProperty, o, name, value); f == enums.EVENT_PASS {
 			o.propertyLock.Lock()
+			old := prop.Value()
 			if err := prop.Set(value); err != nil {
 				o.propertyLock.Unlock()
 				return err
 			}
 			o.propertyLock.Unlock()
+			o.notifyProperty(name, old, prop.Value())
 		}
 	}
 	return nil
@@ -609,3 +634,32 @@ func (o *CMetaData) SetTimeProperty(name Property, value time.Duration) error {
 	}
 	return fmt.Errorf("property not found: %v", name)
 }
+
+func (o *CMetaData) GetStyleClassProperty(name Property) (value paint.StyleClass, err error) {
+	if prop := o.GetProperty(name); prop != nil {
+		o.propertyLock.RLock()
+		if prop.Type() == StyleClassProperty {
+			if v, ok := prop.Value().(paint.StyleClass); ok {
+				o.propertyLock.RUnlock()
+				return v, nil
+			}
+			if v, ok := prop.Default().(paint.StyleClass); ok {
+				o.propertyLock.RUnlock()
+				return v, nil
+			}
+		}
+		o.propertyLock.RUnlock()
+		return "", fmt.Errorf("%v.(%v) property is not a StyleClass", name, prop.Type())
+	}
+	return "", fmt.Errorf("property not found: %v", name)
+}
+
+func (o *CMetaData) SetStyleClassProperty(name Property, value paint.StyleClass) error {
+	if prop := o.GetProperty(name); prop != nil {
+		if prop.Type() == StyleClassProperty {
+			return o.SetProperty(name, value)
+		}
+		return fmt.Errorf("%v.(%v) property is not a StyleClass", name, prop.Type())
+	}
+	return fmt.Errorf("property not found: %v", name)
+}
@@ -17,6 +17,7 @@ package cdk
 import (
 	"github.com/go-curses/cdk/lib/enums"
 	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/sync"
 )
 
 const TypeObject CTypeTag = "cdk-object"
@@ -40,8 +41,43 @@ type Object interface {
 	SetTheme(theme paint.Theme)
 }
 
+// StyleClassHolder is implemented by objects styled through a style class of
+// the theme, which is any Object embedding CObject. Types implementing Object
+// themselves are not required to implement it, type assert for it where
+// needed.
+type StyleClassHolder interface {
+	GetStyleClass() (class paint.StyleClass)
+	SetStyleClass(class paint.StyleClass)
+	GetClassStyle(state ...string) (style paint.Style)
+}
+
+// ObjectNode is implemented by objects which take part in the tree of objects,
+// which is any Object embedding CObject. Types implementing Object themselves
+// are not required to implement it, type assert for it where needed.
+type ObjectNode interface {
+	SetParent(parent Object)
+	GetParent() (parent Object)
+	GetChildren() (children []Object)
+}
+
+var (
+	_ ObjectNode       = (*CObject)(nil)
+	_ StyleClassHolder = (*CObject)(nil)
+)
+
 type CObject struct {
 	CMetaData
+
+	parent   Object
+	children []Object
+	treeLock *sync.RWMutex
+}
+
+// objectTreeNode is implemented by any Object embedding CObject, giving
+// access to its place in the tree whatever the exported methods of the
+// embedding type are
+type objectTreeNode interface {
+	treeNode() *CObject
 }
 
 func (o *CObject) Init() (already bool) {
@@ -49,10 +85,12 @@ func (o *CObject) Init() (already bool) {
 		return true
 	}
 	o.CMetaData.Init()
+	o.treeLock = &sync.RWMutex{}
 	o.properties = make([]*CProperty, 0)
 	_ = o.InstallProperty(PropertyDebug, BoolProperty, true, false)
 	_ = o.InstallProperty(PropertyName, StringProperty, true, "")
 	_ = o.InstallProperty(PropertyTheme, ThemeProperty, true, paint.GetDefaultColorTheme())
+	_ = o.InstallBuildableProperty(PropertyStyleClass, StyleClassProperty, true, paint.StyleClass(""))
 	return false
 }
 
@@ -66,14 +104,109 @@ func (o *CObject) InitWithProperties(properties map[Property]string) (already bo
 	return false, nil
 }
 
+// Destroy emits SignalDestroy and, unless a listener returns EVENT_STOP,
+// destroys all children, removes the object from its parent and releases the
+// object. Children which refuse to be destroyed are left without a parent.
 func (o *CObject) Destroy() {
 	if f := o.Emit(SignalDestroy, o); f == enums.EVENT_PASS {
+		for _, child := range o.GetChildren() {
+			child.Destroy()
+		}
+		for _, child := range o.GetChildren() {
+			if node, ok := child.(objectTreeNode); ok {
+				node.treeNode().SetParent(nil)
+			}
+		}
+		o.SetParent(nil)
 		if err := o.DestroyObject(); err != nil {
 			o.LogErr(err)
 		}
 	}
 }
 
+// SetParent makes the object a child of the given parent, removing it from the
+// children of any previous parent. A nil parent only removes the object from
+// its current parent. An object cannot be made a child of itself or of one of
+// its descendants.
+func (o *CObject) SetParent(parent Object) {
+	self := o.self()
+	for ancestor := parent; ancestor != nil; ancestor = parentOf(ancestor) {
+		if ancestor == self {
+			o.LogError("cannot set parent to self or descendant: %v", parent.ObjectName())
+			return
+		}
+	}
+	o.treeLock.Lock()
+	previous := o.parent
+	if previous == parent {
+		o.treeLock.Unlock()
+		return
+	}
+	o.parent = parent
+	o.treeLock.Unlock()
+	if p, ok := previous.(objectTreeNode); ok {
+		p.treeNode().removeChild(self)
+	}
+	if p, ok := parent.(objectTreeNode); ok {
+		p.treeNode().addChild(self)
+	}
+}
+
+// parentOf returns the parent of the object, nil if there is none or the object
+// does not embed CObject
+func parentOf(object Object) (parent Object) {
+	if node, ok := object.(objectTreeNode); ok {
+		return node.treeNode().GetParent()
+	}
+	return nil
+}
+
+// GetParent returns the parent of the object, nil if there is none
+func (o *CObject) GetParent() (parent Object) {
+	o.treeLock.RLock()
+	defer o.treeLock.RUnlock()
+	return o.parent
+}
+
+// GetChildren returns the objects which have this object as their parent, in
+// the order they were added
+func (o *CObject) GetChildren() (children []Object) {
+	o.treeLock.RLock()
+	defer o.treeLock.RUnlock()
+	children = make([]Object, len(o.children))
+	copy(children, o.children)
+	return
+}
+
+func (o *CObject) treeNode() *CObject {
+	return o
+}
+
+func (o *CObject) addChild(child Object) {
+	o.treeLock.Lock()
+	o.children = append(o.children, child)
+	o.treeLock.Unlock()
+}
+
+func (o *CObject) removeChild(child Object) {
+	o.treeLock.Lock()
+	for idx, c := range o.children {
+		if c == child {
+			o.children = append(o.children[:idx:idx], o.children[idx+1:]...)
+			break
+		}
+	}
+	o.treeLock.Unlock()
+}
+
+// self returns the Object embedding this CObject
+func (o *CObject) self() Object {
+	if self, ok := o.Self().(Object); ok {
+		return self
+	}
+	return o
+}
+
 func (o *CObject) GetName() (name string) {
 	var err error
 	if name, err = o.GetStringProperty(PropertyName); err != nil {
@@ -107,7 +240,32 @@ func (o *CObject) SetTheme(theme paint.Theme) {
 	}
 }
 
-// emitted when the object instance is destroyed
+func (o *CObject) GetStyleClass() (class paint.StyleClass) {
+	var err error
+	if class, err = o.GetStyleClassProperty(PropertyStyleClass); err != nil {
+		o.LogErr(err)
+	}
+	return
+}
+
+func (o *CObject) SetStyleClass(class paint.StyleClass) {
+	if err := o.SetStyleClassProperty(PropertyStyleClass, class); err != nil {
+		o.LogErr(err)
+	}
+}
+
+// GetClassStyle resolves the style class of the object, or the given more
+// specific class of it (such as "focused"), through the object's theme
+func (o *CObject) GetClassStyle(state ...string) (style paint.Style) {
+	class := o.GetStyleClass()
+	for _, name := range state {
+		class = class.Child(name)
+	}
+	return o.GetTheme().Style(class)
+}
+
+// emitted when the object instance is destroyed, before any of its children
+// are destroyed
 const SignalDestroy Signal = "destroy"
 
 // request that the object be rendered with additional features useful to
@@ -119,4 +277,7 @@ const PropertyName Property = "name"
 
 const PropertyTheme Property = "theme"
 
+// the name of the style class used to style the object, see: paint.StyleSheet
+const PropertyStyleClass Property = "style-class"
+
 const ObjectSetPropertyHandle = "object-set-property-handle"
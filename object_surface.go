@@ -0,0 +1,53 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+const ObjectSurfaceHandle = "object-surface-handle"
+
+// MakeObjectSurface makes, or configures, the memphis surface of the given
+// object. The object holds a reference to the surface until either
+// ReleaseObjectSurface is called or the object is destroyed, at which point
+// the surface is unregistered unless referenced elsewhere, see:
+// memphis.RefSurface
+func MakeObjectSurface(object Object, origin ptypes.Point2I, size ptypes.Rectangle, style paint.Style) (err error) {
+	id := object.ObjectID()
+	if err = memphis.MakeConfigureSurface(id, origin, size, style); err != nil {
+		return
+	}
+	if !object.Handled(SignalDestroy, ObjectSurfaceHandle) {
+		_ = memphis.SetSurfaceOwner(id, object.ObjectName())
+		object.Connect(SignalDestroy, ObjectSurfaceHandle, func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			memphis.UnrefSurface(id)
+			return enums.EVENT_PASS
+		})
+	}
+	return
+}
+
+// ReleaseObjectSurface releases the reference to the memphis surface held by
+// the given object, see: MakeObjectSurface
+func ReleaseObjectSurface(object Object) {
+	if object.Handled(SignalDestroy, ObjectSurfaceHandle) {
+		_ = object.Disconnect(SignalDestroy, ObjectSurfaceHandle)
+		memphis.UnrefSurface(object.ObjectID())
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+func TestObjectSurface(t *testing.T) {
+	Convey("Object surfaces are released on destroy", t, func() {
+		parent, child := &CObject{}, &CObject{}
+		parent.Init()
+		child.Init()
+		child.SetParent(parent)
+		id := child.ObjectID()
+		So(MakeObjectSurface(child, ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(2, 2), paint.StyleDefault), ShouldBeNil)
+		So(MakeObjectSurface(child, ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(4, 2), paint.StyleDefault), ShouldBeNil)
+		So(memphis.HasSurface(id), ShouldBeTrue)
+		for _, info := range memphis.ListSurfaces() {
+			if info.ID == id {
+				So(info.Refs, ShouldEqual, 1)
+				So(info.Owner, ShouldEqual, child.ObjectName())
+			}
+		}
+		parent.Destroy()
+		So(memphis.HasSurface(id), ShouldBeFalse)
+	})
+	Convey("Referenced object surfaces outlive the object", t, func() {
+		o := &CObject{}
+		o.Init()
+		id := o.ObjectID()
+		So(MakeObjectSurface(o, ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(2, 2), paint.StyleDefault), ShouldBeNil)
+		_, _ = memphis.RefSurface(id)
+		ReleaseObjectSurface(o)
+		ReleaseObjectSurface(o)
+		So(memphis.HasSurface(id), ShouldBeTrue)
+		o.Destroy()
+		So(memphis.HasSurface(id), ShouldBeTrue)
+		So(memphis.UnrefSurface(id), ShouldEqual, 0)
+		So(memphis.HasSurface(id), ShouldBeFalse)
+	})
+	Convey("Unmapping a window releases its surface", t, WithDisplayManager(func(d Display) {
+		w := NewWindow("testing", d)
+		d.MapWindow(w)
+		So(memphis.HasSurface(w.ObjectID()), ShouldBeTrue)
+		d.UnmapWindow(w)
+		So(memphis.HasSurface(w.ObjectID()), ShouldBeFalse)
+		d.MapWindow(w)
+		id := w.ObjectID()
+		w.Destroy()
+		So(memphis.HasSurface(id), ShouldBeFalse)
+	}))
+}
@@ -15,12 +15,16 @@
 package cdk
 
 import (
+	"bytes"
+	"encoding/json"
+	"strings"
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
 
 	"github.com/go-curses/cdk/lib/enums"
 	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/log"
 )
 
 func TestObject(t *testing.T) {
@@ -61,3 +65,131 @@ func TestObject(t *testing.T) {
 		So(o.IsValid(), ShouldEqual, false)
 	})
 }
+
+func TestObjectTree(t *testing.T) {
+	Convey("Object Parenting", t, func() {
+		root, child, grandchild := &CObject{}, &CObject{}, &CObject{}
+		root.Init()
+		child.Init()
+		grandchild.Init()
+		child.SetParent(root)
+		grandchild.SetParent(child)
+		So(child.GetParent(), ShouldEqual, root)
+		So(root.GetChildren(), ShouldResemble, []Object{child})
+		So(child.GetChildren(), ShouldResemble, []Object{grandchild})
+		// cycles are refused
+		root.SetParent(grandchild)
+		So(root.GetParent(), ShouldBeNil)
+		// reparenting
+		grandchild.SetParent(root)
+		So(child.GetChildren(), ShouldBeEmpty)
+		So(root.GetChildren(), ShouldResemble, []Object{child, grandchild})
+		grandchild.SetParent(child)
+		So(root.GetChildren(), ShouldResemble, []Object{child})
+	})
+	Convey("Object Destroy Propagation", t, func() {
+		root, child, grandchild, stubborn := &CObject{}, &CObject{}, &CObject{}, &CObject{}
+		for _, o := range []*CObject{root, child, grandchild, stubborn} {
+			o.Init()
+		}
+		child.SetParent(root)
+		grandchild.SetParent(child)
+		stubborn.SetParent(root)
+		var destroyed []Object
+		for _, o := range []*CObject{root, child, grandchild} {
+			o.Connect(SignalDestroy, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				destroyed = append(destroyed, argv[0].(Object))
+				return enums.EVENT_PASS
+			})
+		}
+		stubborn.Connect(SignalDestroy, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return enums.EVENT_STOP
+		})
+		root.Destroy()
+		So(destroyed, ShouldResemble, []Object{root, child, grandchild})
+		So(root.IsValid(), ShouldBeFalse)
+		So(child.IsValid(), ShouldBeFalse)
+		So(grandchild.IsValid(), ShouldBeFalse)
+		So(stubborn.IsValid(), ShouldBeTrue)
+		So(stubborn.GetParent(), ShouldBeNil)
+		So(root.GetChildren(), ShouldBeEmpty)
+	})
+	Convey("Object Trees Of Embedding Types", t, func() {
+		root, child := &CObject{}, &testTreeObject{}
+		root.Init()
+		child.Init()
+		So(child, ShouldNotImplement, (*ObjectNode)(nil))
+		child.SetParent(root)
+		So(root.GetChildren(), ShouldHaveLength, 1)
+		root.Destroy()
+		So(child.IsValid(), ShouldBeFalse)
+		So(child.CObject.GetParent(), ShouldBeNil)
+	})
+}
+
+// testTreeObject has parenting methods of its own, as a widget toolkit may
+type testTreeObject struct {
+	CObject
+}
+
+func (o *testTreeObject) SetParent(parent *CObject) {
+	o.CObject.SetParent(parent)
+}
+
+func TestObjectStyleClass(t *testing.T) {
+	Convey("Object style classes", t, func() {
+		o := &CObject{}
+		o.Init()
+		So(o.GetStyleClass(), ShouldEqual, paint.StyleClass(""))
+		So(o.SetPropertyFromString(PropertyStyleClass, "entry"), ShouldBeNil)
+		So(o.GetStyleClass(), ShouldEqual, paint.StyleClass("entry"))
+		So(o.IsBuildableProperty(PropertyStyleClass), ShouldBeTrue)
+		So(PropertyTypeOf[paint.StyleClass](), ShouldEqual, StyleClassProperty)
+		sheet := paint.NewStyleSheet(nil)
+		sheet.Set("entry.focused", paint.StyleRule{Set: paint.AttrBold})
+		theme := o.GetTheme()
+		theme.Styles = sheet
+		o.SetTheme(theme)
+		So(o.GetClassStyle(), ShouldResemble, theme.Content.Normal)
+		So(o.GetClassStyle("focused"), ShouldResemble, theme.Content.Normal.Bold(true))
+		data, err := o.MarshalProperties(PropertyFormatJSON)
+		So(err, ShouldBeNil)
+		other := &CObject{}
+		other.Init()
+		So(other.UnmarshalProperties(data, PropertyFormatJSON), ShouldBeNil)
+		So(other.GetStyleClass(), ShouldEqual, paint.StyleClass("entry"))
+	})
+}
+
+func TestObjectLogFields(t *testing.T) {
+	Convey("Object log fields", t, func() {
+		o := &CObject{}
+		o.Init()
+		o.SetName("testing")
+		fields := o.LogFields()
+		So(fields[log.FieldObjectID], ShouldEqual, o.ObjectID().String())
+		So(fields[log.FieldObjectType], ShouldEqual, TypeObject.String())
+		So(fields[log.FieldObjectName], ShouldEqual, "testing")
+		So(fields, ShouldNotContainKey, log.FieldDisplay)
+		Convey("include the display name", WithDisplayManager(func(d Display) {
+			So(d.LogFields()[log.FieldDisplay], ShouldEqual, d.LogDisplayName())
+			So(d.SetStringProperty(PropertyDisplayName, "client-1"), ShouldBeNil)
+			So(d.LogDisplayName(), ShouldEqual, "client-1")
+			So(d.LogFields()[log.FieldDisplay], ShouldEqual, "client-1")
+			w := NewWindow("testing", d)
+			So(w.LogFields()[log.FieldDisplay], ShouldEqual, "client-1")
+		}))
+		Convey("logged as JSON", func() {
+			buf := &bytes.Buffer{}
+			So(log.AddSink("testing", log.Sink{Writer: buf, Level: log.LevelDebug, Format: log.FormatJson}), ShouldBeNil)
+			defer log.RemoveSink("testing")
+			o.LogWithFields(log.Fields{"extra": 1}).DebugF("message")
+			var entry map[string]interface{}
+			So(json.Unmarshal([]byte(strings.TrimSpace(buf.String())), &entry), ShouldBeNil)
+			So(entry["msg"], ShouldContainSubstring, "message")
+			So(entry[log.FieldObjectID], ShouldEqual, o.ObjectID().String())
+			So(entry[log.FieldObjectName], ShouldEqual, "testing")
+			So(entry["extra"], ShouldEqual, 1)
+		})
+	})
+}
@@ -17,7 +17,9 @@ package cdk
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"time"
 	"unicode/utf8"
 
 	"golang.org/x/text/transform"
@@ -113,23 +115,32 @@ type COffScreen struct {
 	finished bool
 	style    paint.Style
 	evCh     chan Event
+	overflow cEventOverflow
 	quit     chan struct{}
 
-	front     []OffscreenCell
-	back      *CellBuffer
-	clear     bool
-	cursorX   int
-	cursorY   int
-	cursorVis bool
-	mouse     bool
-	paste     bool
-	charset   string
-	encoder   transform.Transformer
-	decoder   transform.Transformer
-	fillChar  rune
-	fillStyle paint.Style
-	fallback  map[rune]string
-	fallcons  map[rune]rune
+	front      []OffscreenCell
+	back       *CellBuffer
+	clear      bool
+	cursorX    int
+	cursorY    int
+	cursorVis  bool
+	mouse      bool
+	mouseFlags MouseFlags
+	paste      bool
+	keyPhases  bool
+	imeArea    [4]int
+	imeSet     bool
+	notices    []string
+	beeps      int
+	flashes    int
+	drawn      int
+	charset    string
+	encoder    transform.Transformer
+	decoder    transform.Transformer
+	fillChar   rune
+	fillStyle  paint.Style
+	fallback   map[rune]string
+	fallcons   map[rune]rune
 
 	sync.Mutex
 }
@@ -161,6 +172,7 @@ func (o *COffScreen) Init() error {
 	o.fillChar = 'X'
 	o.fillStyle = paint.StyleDefault
 	o.mouse = false
+	o.mouseFlags = 0
 	o.physW = 80
 	o.physH = 25
 	o.cursorX = -1
@@ -254,6 +266,7 @@ func (o *COffScreen) drawCell(x, y int) int {
 	if x >= o.physW || y >= o.physH || x < 0 || y < 0 {
 		return width
 	}
+	o.drawn++
 	sc := &o.front[(y*o.physW)+x]
 
 	if style == paint.StyleDefault {
@@ -307,9 +320,10 @@ func (o *COffScreen) drawCell(x, y int) int {
 }
 
 func (o *COffScreen) ShowCursor(x, y int) {
+	o.Lock()
+	defer o.Unlock()
 	o.cursorX, o.cursorY = x, y
 	o.showCursor()
-	o.Unlock()
 }
 
 func (o *COffScreen) HideCursor() {
@@ -349,6 +363,7 @@ func (o *COffScreen) clearScreen() {
 }
 
 func (o *COffScreen) draw() {
+	o.drawn = 0
 	o.hideCursor()
 	if o.clear {
 		o.clearScreen()
@@ -364,12 +379,21 @@ func (o *COffScreen) draw() {
 	o.showCursor()
 }
 
-func (o *COffScreen) EnableMouse(_ ...MouseFlags) {
+func (o *COffScreen) EnableMouse(flags ...MouseFlags) {
+	var f MouseFlags
+	for _, flag := range flags {
+		f |= flag
+	}
+	if len(flags) == 0 {
+		f = MouseMotionEvents
+	}
 	o.mouse = true
+	o.mouseFlags = f
 }
 
 func (o *COffScreen) DisableMouse() {
 	o.mouse = false
+	o.mouseFlags = 0
 }
 
 func (o *COffScreen) EnablePaste() {
@@ -380,7 +404,89 @@ func (o *COffScreen) DisablePaste() {
 	o.paste = false
 }
 
+// GetDrawStats returns the number of cells drawn by the most recent Show or
+// Sync, nothing is written so bytes is always zero
+func (o *COffScreen) GetDrawStats() (cells, bytes int) {
+	o.Lock()
+	defer o.Unlock()
+	return o.drawn, 0
+}
+
+func (o *COffScreen) SetKeyTiming(_ time.Duration) {}
+
+func (o *COffScreen) SetEscapeDelay(_ time.Duration) {}
+
+func (o *COffScreen) SetRawInput(_ bool) {}
+
+func (o *COffScreen) RawInput() (enabled bool) {
+	return false
+}
+
+func (o *COffScreen) SetTrueColor(_ bool) {}
+
+func (o *COffScreen) SetColorDownsampling(_ paint.ColorDownsampling) {}
+
+func (o *COffScreen) SetOptimization(_ ScreenOptimization) {}
+
+func (o *COffScreen) SetCursorIndicator(_ CursorIndicator, _ paint.Color) {}
+
+func (o *COffScreen) SetBlinkMode(_ BlinkMode, _ time.Duration) {}
+
+func (o *COffScreen) TeeOutput(_ io.Writer) {}
+
+func (o *COffScreen) Detached() bool {
+	return false
+}
+
+func (o *COffScreen) Detach() error {
+	return ErrNoDisplay
+}
+
+func (o *COffScreen) Reattach(_ string) error {
+	return ErrNoDisplay
+}
+
+func (o *COffScreen) SetInputMethodArea(x, y, w, h int) {
+	o.imeArea = [4]int{x, y, w, h}
+	o.imeSet = true
+}
+
+func (o *COffScreen) ClearInputMethodArea() {
+	o.imeSet = false
+}
+
+// Notify records the desktop notification as an OSC 777 sequence
+func (o *COffScreen) Notify(title, body string) (err error) {
+	o.Lock()
+	defer o.Unlock()
+	o.notices = append(o.notices, encodeNotify(NotifyOSC777, title, body))
+	return nil
+}
+
+func (o *COffScreen) EnableKeyPhases() {
+	o.keyPhases = true
+}
+
+func (o *COffScreen) DisableKeyPhases() {
+	o.keyPhases = false
+}
+
+func (o *COffScreen) Modes() (modes ScreenModes) {
+	return ScreenModes{
+		Mouse:      o.mouse,
+		MouseFlags: o.mouseFlags,
+		Paste:      o.paste,
+		KeyPhases:  o.keyPhases,
+	}
+}
+
+func (o *COffScreen) ApplyModes(modes ScreenModes) {
+	applyScreenModes(o, modes)
+}
+
 func (o *COffScreen) Size() (w, h int) {
+	o.Lock()
+	defer o.Unlock()
 	w, h = o.back.Size()
 	return
 }
@@ -409,7 +515,7 @@ func (o *COffScreen) PollEvent() Event {
 }
 
 func (o *COffScreen) PollEventChan() (next chan Event) {
-	next <- o.PollEvent()
+	next = o.evCh
 	return
 }
 
@@ -418,12 +524,23 @@ func (o *COffScreen) PostEventWait(ev Event) {
 }
 
 func (o *COffScreen) PostEvent(ev Event) error {
-	select {
-	case o.evCh <- ev:
-		return nil
-	default:
-		return ErrEventQFull
-	}
+	return o.overflow.post(o.evCh, ev, o.quit)
+}
+
+func (o *COffScreen) SetEventOverflowPolicy(policy EventOverflowPolicy) {
+	o.overflow.setPolicy(policy)
+}
+
+func (o *COffScreen) GetEventOverflowPolicy() (policy EventOverflowPolicy) {
+	return o.overflow.getPolicy()
+}
+
+func (o *COffScreen) SetEventOverflowHandler(fn EventOverflowFn) {
+	o.overflow.setHandler(fn)
+}
+
+func (o *COffScreen) DroppedEvents() (dropped uint64) {
+	return o.overflow.getDropped()
 }
 
 func (o *COffScreen) InjectMouse(x, y int, buttons ButtonMask, mod ModMask) {
@@ -485,11 +602,12 @@ outer:
 }
 
 func (o *COffScreen) Sync() {
+	o.Lock()
+	defer o.Unlock()
 	o.clear = true
 	o.resize()
 	o.back.Invalidate()
 	o.draw()
-	o.Unlock()
 }
 
 func (o *COffScreen) CharacterSet() string {
@@ -582,6 +700,17 @@ func (o *COffScreen) HasKey(Key) bool {
 }
 
 func (o *COffScreen) Beep() error {
+	o.Lock()
+	defer o.Unlock()
+	o.beeps++
+	return nil
+}
+
+// VisualBell records the flash, nothing is drawn
+func (o *COffScreen) VisualBell(_ time.Duration) error {
+	o.Lock()
+	defer o.Unlock()
+	o.flashes++
 	return nil
 }
 
@@ -628,3 +757,28 @@ func (o *COffScreen) EnableHostClipboard(enabled bool) {
 func (o *COffScreen) EnableTermClipboard(enabled bool) {
 	log.WarnF("unimplemented")
 }
+
+func (o *COffScreen) RequestClipboard() (requested bool) {
+	return false
+}
+
+func (o *COffScreen) TermClipboardReadable() (readable bool) {
+	return false
+}
+
+func (o *COffScreen) CopyToPrimary(s string) {
+	log.WarnF("unimplemented")
+}
+
+func (o *COffScreen) PasteFromPrimary() (s string, ok bool) {
+	log.WarnF("unimplemented")
+	return
+}
+
+func (o *COffScreen) RequestPrimary() (requested bool) {
+	return false
+}
+
+func (o *COffScreen) Capabilities() (capabilities Capabilities) {
+	return
+}
@@ -141,3 +141,40 @@ func TestBeep(t *testing.T) {
 		}
 	}
 }
+
+func TestDrawStats(t *testing.T) {
+	s := NewTestingScreen(t, "")
+	defer s.Close()
+	s.Show()
+	s.SetCell(2, 5, paint.StyleDefault, '@')
+	s.SetCell(3, 5, paint.StyleDefault, '@')
+	s.Show()
+	if cells, bytes := s.GetDrawStats(); cells != 2 || bytes != 0 {
+		t.Fatalf("Draw stats (%v, %v) wrong", cells, bytes)
+	}
+	s.Show()
+	if cells, _ := s.GetDrawStats(); cells != 0 {
+		t.Fatalf("Draw stats (%v) should be zero without changes", cells)
+	}
+}
+
+func TestConcurrentAccess(t *testing.T) {
+	s := NewTestingScreen(t, "")
+	defer s.Close()
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 100; i++ {
+			s.SetSize(40+i%40, 10+i%10)
+		}
+		done <- true
+	}()
+	for i := 0; i < 100; i++ {
+		s.ShowCursor(i%10, i%5)
+		s.HideCursor()
+		s.Sync()
+		if w, h := s.Size(); w <= 0 || h <= 0 {
+			t.Fatalf("Size (%v, %v) wrong", w, h)
+		}
+	}
+	<-done
+}
@@ -42,6 +42,9 @@ type OffscreenWindow interface {
 	SetDisplay(d Display)
 	Draw() enums.EventFlag
 	ProcessEvent(evt Event) enums.EventFlag
+	GetInputFilter() InputFilterChain
+	GetGeometryHints() (hints WindowGeometryHints)
+	SetGeometryHints(hints WindowGeometryHints)
 }
 
 // Basic window type
@@ -49,6 +52,7 @@ type COffscreenWindow struct {
 	CObject
 
 	title   string
+	filter  InputFilterChain
 	display OffScreen
 }
 
@@ -70,7 +74,9 @@ func (w *COffscreenWindow) Init() bool {
 		return true
 	}
 	w.CObject.Init()
+	w.filter = NewInputFilterChain()
 	_ = w.InstallProperty(PropertyWindowType, StructProperty, true, enums.WINDOW_TOPLEVEL)
+	_ = w.InstallProperty(PropertyWindowGeometryHints, StructProperty, true, WindowGeometryHints{})
 	return false
 }
 
@@ -95,6 +101,28 @@ func (w *COffscreenWindow) SetWindowType(hint enums.WindowType) {
 	}
 }
 
+// GetGeometryHints returns the minimum size, maximum size and aspect ratio
+// hints of the window.
+// See: WindowGeometryHints
+func (w *COffscreenWindow) GetGeometryHints() (hints WindowGeometryHints) {
+	var ok bool
+	if v, err := w.GetStructProperty(PropertyWindowGeometryHints); err != nil {
+		w.LogErr(err)
+	} else if hints, ok = v.(WindowGeometryHints); !ok {
+		w.LogError("value stored in %v is not of WindowGeometryHints: %v (%T)", PropertyWindowGeometryHints, v, v)
+	}
+	return
+}
+
+// SetGeometryHints updates the geometry hints of the window, which are applied
+// by the Display the next time the window is mapped or the Display is resized.
+// See: WindowGeometryHints
+func (w *COffscreenWindow) SetGeometryHints(hints WindowGeometryHints) {
+	if err := w.SetStructProperty(PropertyWindowGeometryHints, hints); err != nil {
+		w.LogErr(err)
+	}
+}
+
 func (w *COffscreenWindow) SetTitle(title string) {
 	if f := w.Emit(SignalSetTitle, w, title); f == enums.EVENT_PASS {
 		w.title = title
@@ -128,3 +156,11 @@ func (w *COffscreenWindow) Draw() enums.EventFlag {
 func (w *COffscreenWindow) ProcessEvent(evt Event) enums.EventFlag {
 	return w.Emit(SignalEvent, w, evt)
 }
+
+// GetInputFilter returns the InputFilterChain applied by the Display to all
+// EventKey events before they are given to ProcessEvent.
+func (w *COffscreenWindow) GetInputFilter() InputFilterChain {
+	w.RLock()
+	defer w.RUnlock()
+	return w.filter
+}
@@ -0,0 +1,532 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+// PaneSplit is the direction in which a Pane is divided
+type PaneSplit int
+
+const (
+	// PaneSplitHorizontal places the two panes side by side, with a vertical
+	// divider between them
+	PaneSplitHorizontal PaneSplit = iota
+	// PaneSplitVertical places one pane above the other, with a horizontal
+	// divider between them
+	PaneSplitVertical
+)
+
+// PaneDrawFn draws the content of a Pane. The surface is the size of the
+// Pane, with its origin at the top-left of the Pane region, and is only valid
+// for the duration of the call.
+type PaneDrawFn func(pane *Pane, surface *memphis.CSurface)
+
+// PaneEventFn handles the key and mouse events routed to a Pane. Mouse events
+// are given in display coordinates, see: Pane.Region
+type PaneEventFn func(pane *Pane, evt Event) enums.EventFlag
+
+var (
+	// PaneResizeModMask is the modifier which, with the arrow keys, moves
+	// the dividers around the focused Pane
+	PaneResizeModMask = ModAlt
+	// PaneMinimumSize is the smallest width or height a divider can reduce
+	// a Pane to
+	PaneMinimumSize = 1
+)
+
+// Pane is a region of the Display with a draw callback and event handler,
+// a lighter weight alternative to mapping a Window for splitting the screen.
+// The Display has a single root Pane, covering the whole screen, which is
+// divided with Display.SplitPane. Only the leaves of the resulting tree are
+// drawn and receive events, the dividers between them can be moved with the
+// mouse or with PaneResizeModMask and the arrow keys. Panes are drawn beneath
+// any mapped Windows, are only given the mouse events at the points no Window
+// covers and are given the key events the focused Window does not handle.
+type Pane struct {
+	name    string
+	display *CDisplay
+	parent  *Pane
+	split   PaneSplit
+	ratio   float64
+	first   *Pane
+	second  *Pane
+	region  ptypes.Region
+	divider ptypes.Region
+	draw    PaneDrawFn
+	event   PaneEventFn
+}
+
+// Name returns the name given to the Pane
+func (p *Pane) Name() string {
+	p.display.RLock()
+	defer p.display.RUnlock()
+	return p.name
+}
+
+// Region returns the region of the display occupied by the Pane, as of the
+// most recent draw or resize
+func (p *Pane) Region() ptypes.Region {
+	p.display.RLock()
+	defer p.display.RUnlock()
+	return p.region
+}
+
+// Parent returns the Pane which was split to create this one, nil for the
+// root Pane
+func (p *Pane) Parent() *Pane {
+	p.display.RLock()
+	defer p.display.RUnlock()
+	return p.parent
+}
+
+// IsSplit returns true if the Pane has been divided into two others
+func (p *Pane) IsSplit() bool {
+	p.display.RLock()
+	defer p.display.RUnlock()
+	return p.first != nil
+}
+
+// Children returns the two panes this one is divided into, if any
+func (p *Pane) Children() (first, second *Pane) {
+	p.display.RLock()
+	defer p.display.RUnlock()
+	return p.first, p.second
+}
+
+// GetRatio returns the split direction and the fraction of the Pane given
+// to the first of its children
+func (p *Pane) GetRatio() (split PaneSplit, ratio float64) {
+	p.display.RLock()
+	defer p.display.RUnlock()
+	return p.split, p.ratio
+}
+
+// SetRatio changes the fraction of the Pane given to the first of its
+// children
+func (p *Pane) SetRatio(ratio float64) {
+	p.display.Lock()
+	p.ratio = math.Max(0, math.Min(1, ratio))
+	p.display.Unlock()
+	p.display.layoutPanes()
+	p.display.RequestDraw()
+	p.display.RequestShow()
+}
+
+// SetDrawFn changes the callback used to draw the content of the Pane
+func (p *Pane) SetDrawFn(fn PaneDrawFn) {
+	p.display.Lock()
+	defer p.display.Unlock()
+	p.draw = fn
+}
+
+// SetEventFn changes the handler for events routed to the Pane
+func (p *Pane) SetEventFn(fn PaneEventFn) {
+	p.display.Lock()
+	defer p.display.Unlock()
+	p.event = fn
+}
+
+func (p *Pane) leaves() (leaves []*Pane) {
+	if p.first == nil {
+		return []*Pane{p}
+	}
+	return append(p.first.leaves(), p.second.leaves()...)
+}
+
+func (p *Pane) dividers() (dividers []*Pane) {
+	if p.first == nil {
+		return nil
+	}
+	dividers = append(dividers, p)
+	dividers = append(dividers, p.first.dividers()...)
+	return append(dividers, p.second.dividers()...)
+}
+
+// span returns the length of the region along the split direction, less the
+// divider
+func (p *Pane) span() int {
+	if p.split == PaneSplitHorizontal {
+		return p.region.W - 1
+	}
+	return p.region.H - 1
+}
+
+// layout assigns the regions of the children of the Pane
+func (p *Pane) layout(region ptypes.Region) {
+	p.region = region
+	if p.first == nil {
+		p.divider = ptypes.Region{}
+		return
+	}
+	span := p.span()
+	if span < 0 {
+		span = 0
+	}
+	size := int(math.Round(float64(span) * p.ratio))
+	first, second := region, region
+	if p.split == PaneSplitHorizontal {
+		first.W = size
+		p.divider = ptypes.MakeRegion(region.X+size, region.Y, 1, region.H)
+		second.X, second.W = region.X+size+1, span-size
+	} else {
+		first.H = size
+		p.divider = ptypes.MakeRegion(region.X, region.Y+size, region.W, 1)
+		second.Y, second.H = region.Y+size+1, span-size
+	}
+	p.first.layout(first)
+	p.second.layout(second)
+}
+
+// moveDivider sets the ratio so that the divider is at the given offset from
+// the origin of the Pane, keeping both children at least PaneMinimumSize
+func (p *Pane) moveDivider(offset int) {
+	span := p.span()
+	if span <= 0 {
+		return
+	}
+	lo, hi := PaneMinimumSize, span-PaneMinimumSize
+	if lo > hi {
+		lo, hi = 0, span
+	}
+	if offset < lo {
+		offset = lo
+	} else if offset > hi {
+		offset = hi
+	}
+	p.ratio = float64(offset) / float64(span)
+}
+
+func (p *Pane) dividerOffset() int {
+	if p.split == PaneSplitHorizontal {
+		return p.divider.X - p.region.X
+	}
+	return p.divider.Y - p.region.Y
+}
+
+// GetRootPane returns the Pane covering the whole Display, creating it if
+// necessary
+func (d *CDisplay) GetRootPane() (root *Pane) {
+	d.Lock()
+	if d.panes == nil {
+		d.panes = &Pane{name: "root", display: d}
+		d.paneFocus = d.panes
+	}
+	root = d.panes
+	d.Unlock()
+	d.layoutPanes()
+	return
+}
+
+// SplitPane divides the given Pane in two, giving the first of the new panes
+// the ratio of the space available, and returns them. The first keeps the
+// name, draw callback and event handler of the Pane, and the focus if the Pane
+// had it, while the second is given the name provided.
+func (d *CDisplay) SplitPane(pane *Pane, split PaneSplit, ratio float64, name string) (first, second *Pane, err error) {
+	d.Lock()
+	if pane == nil || pane.display != d {
+		d.Unlock()
+		return nil, nil, fmt.Errorf("pane does not belong to display: %v", d.ObjectName())
+	}
+	if pane.first != nil {
+		d.Unlock()
+		return nil, nil, fmt.Errorf("pane is already split: %v", pane.name)
+	}
+	first = &Pane{name: pane.name, display: d, parent: pane, draw: pane.draw, event: pane.event}
+	second = &Pane{name: name, display: d, parent: pane}
+	pane.split = split
+	pane.ratio = math.Max(0, math.Min(1, ratio))
+	pane.first, pane.second = first, second
+	pane.draw, pane.event = nil, nil
+	if d.paneFocus == pane {
+		d.paneFocus = first
+	}
+	d.Unlock()
+	d.layoutPanes()
+	d.RequestDraw()
+	d.RequestShow()
+	return
+}
+
+// ClosePane removes the given Pane, the other half of its parent taking its
+// place. Closing the root Pane removes all panes.
+func (d *CDisplay) ClosePane(pane *Pane) {
+	d.Lock()
+	if pane == nil || pane.display != d {
+		d.Unlock()
+		return
+	}
+	if parent := pane.parent; parent == nil {
+		d.panes, d.paneFocus, d.paneDrag, d.paneGrab = nil, nil, nil, nil
+	} else {
+		other := parent.first
+		if other == pane {
+			other = parent.second
+		}
+		// the parent adopts the other pane's content and children
+		parent.name, parent.draw, parent.event = other.name, other.draw, other.event
+		parent.split, parent.ratio = other.split, other.ratio
+		parent.first, parent.second = other.first, other.second
+		if parent.first != nil {
+			parent.first.parent, parent.second.parent = parent, parent
+		}
+		if d.paneFocus != nil && (d.paneFocus == pane || d.paneFocus == other) {
+			d.paneFocus = parent.leaves()[0]
+		}
+		d.paneDrag, d.paneGrab = nil, nil
+	}
+	d.Unlock()
+	d.layoutPanes()
+	d.RequestDraw()
+	d.RequestShow()
+}
+
+// GetPanes returns the panes which are not split, in order from left to
+// right and top to bottom
+func (d *CDisplay) GetPanes() (panes []*Pane) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.panes != nil {
+		panes = d.panes.leaves()
+	}
+	return
+}
+
+// FocusPane makes the given Pane the recipient of key events
+func (d *CDisplay) FocusPane(pane *Pane) {
+	d.Lock()
+	if pane != nil && pane.first != nil {
+		pane = pane.leaves()[0]
+	}
+	d.paneFocus = pane
+	d.Unlock()
+	d.Emit(SignalFocusedPane, d, pane)
+}
+
+// GetFocusedPane returns the Pane receiving key events, if any
+func (d *CDisplay) GetFocusedPane() (pane *Pane) {
+	d.RLock()
+	defer d.RUnlock()
+	return d.paneFocus
+}
+
+// ResizePane moves the nearest divider of the given direction enclosing the
+// Pane by the given number of cells, positive deltas moving the divider right
+// (or down).
+func (d *CDisplay) ResizePane(pane *Pane, split PaneSplit, delta int) {
+	d.Lock()
+	for child := pane; child != nil && child.parent != nil; child = child.parent {
+		if parent := child.parent; parent.split == split {
+			parent.moveDivider(parent.dividerOffset() + delta)
+			break
+		}
+	}
+	d.Unlock()
+	d.layoutPanes()
+	d.RequestDraw()
+	d.RequestShow()
+}
+
+// layoutPanes assigns the regions of all panes to fill the display
+func (d *CDisplay) layoutPanes() {
+	d.Lock()
+	defer d.Unlock()
+	if d.panes == nil {
+		return
+	}
+	var w, h int
+	if d.screen != nil {
+		w, h = d.screen.Size()
+	}
+	d.panes.layout(ptypes.MakeRegion(0, 0, w, h))
+}
+
+// drawPanes draws the panes and their dividers onto the display surface
+func (d *CDisplay) drawPanes(surface *memphis.CSurface, theme paint.Theme) {
+	d.layoutPanes()
+	d.RLock()
+	if d.panes == nil {
+		d.RUnlock()
+		return
+	}
+	leaves, dividers := d.panes.leaves(), d.panes.dividers()
+	type paneDraw struct {
+		pane   *Pane
+		region ptypes.Region
+		draw   PaneDrawFn
+	}
+	var draws []paneDraw
+	for _, leaf := range leaves {
+		draws = append(draws, paneDraw{leaf, leaf.region, leaf.draw})
+	}
+	var lines []*Pane
+	for _, divider := range dividers {
+		clone := *divider
+		lines = append(lines, &clone)
+	}
+	d.RUnlock()
+	for _, pd := range draws {
+		if pd.draw == nil || pd.region.W <= 0 || pd.region.H <= 0 {
+			continue
+		}
+		ps := memphis.AcquireSurface(pd.region.Origin(), pd.region.Size(), theme.Content.Normal)
+		ps.Fill(theme)
+		pd.draw(pd.pane, ps)
+		if err := surface.CompositeSurface(ps); err != nil {
+			d.LogErr(err)
+		}
+		memphis.ReleaseSurface(ps)
+	}
+	for _, line := range lines {
+		if line.divider.W <= 0 || line.divider.H <= 0 {
+			continue
+		}
+		r := theme.Border.BorderRunes.Top
+		if line.split == PaneSplitHorizontal {
+			r = theme.Border.BorderRunes.Left
+		}
+		for y := line.divider.Y; y < line.divider.Y+line.divider.H; y++ {
+			for x := line.divider.X; x < line.divider.X+line.divider.W; x++ {
+				_ = surface.SetRune(x, y, r, theme.Border.Normal)
+			}
+		}
+	}
+}
+
+// processPaneKey moves dividers with PaneResizeModMask and the arrow keys,
+// otherwise gives the event to the focused Pane
+func (d *CDisplay) processPaneKey(e *EventKey) enums.EventFlag {
+	d.RLock()
+	focus := d.paneFocus
+	var handler PaneEventFn
+	if focus != nil {
+		handler = focus.event
+	}
+	d.RUnlock()
+	if focus == nil {
+		return enums.EVENT_PASS
+	}
+	if e.Modifiers() == PaneResizeModMask {
+		switch e.Key() {
+		case KeyLeft:
+			d.ResizePane(focus, PaneSplitHorizontal, -1)
+			return enums.EVENT_STOP
+		case KeyRight:
+			d.ResizePane(focus, PaneSplitHorizontal, 1)
+			return enums.EVENT_STOP
+		case KeyUp:
+			d.ResizePane(focus, PaneSplitVertical, -1)
+			return enums.EVENT_STOP
+		case KeyDown:
+			d.ResizePane(focus, PaneSplitVertical, 1)
+			return enums.EVENT_STOP
+		}
+	}
+	if handler != nil {
+		return handler(focus, e)
+	}
+	return enums.EVENT_PASS
+}
+
+// paneHasPointer returns true if the mouse event is for the panes, which is
+// while a divider is dragged or a Pane holds the implicit grab of a button
+// press, otherwise when no Window holds the implicit grab or covers the point
+func (d *CDisplay) paneHasPointer(e *EventMouse) bool {
+	d.RLock()
+	pane := d.paneDrag != nil || d.paneGrab != nil
+	window := d.grabImplicit && d.grabTarget != nil
+	d.RUnlock()
+	if pane || window {
+		return pane
+	}
+	return d.GetWindowAtPoint(e.Point2I()) == nil
+}
+
+// processPaneMouse drags dividers, otherwise focuses and gives the event to
+// the Pane under the mouse
+func (d *CDisplay) processPaneMouse(e *EventMouse) enums.EventFlag {
+	d.Lock()
+	if d.panes == nil {
+		d.Unlock()
+		return enums.EVENT_PASS
+	}
+	point := e.Point2I()
+	if d.paneDrag != nil {
+		drag := d.paneDrag
+		if drag.split == PaneSplitHorizontal {
+			drag.moveDivider(point.X - drag.region.X)
+		} else {
+			drag.moveDivider(point.Y - drag.region.Y)
+		}
+		if e.ButtonPressed() == ButtonNone {
+			d.paneDrag = nil
+		}
+		d.Unlock()
+		d.layoutPanes()
+		d.RequestDraw()
+		d.RequestShow()
+		return enums.EVENT_STOP
+	}
+	if e.IsPressed() && e.ButtonHas(Button1) {
+		for _, divider := range d.panes.dividers() {
+			if divider.divider.HasPoint(point) {
+				d.paneDrag = divider
+				d.Unlock()
+				return enums.EVENT_STOP
+			}
+		}
+	}
+	var target *Pane
+	if d.paneGrab != nil {
+		// implicitly grabbed by the button press
+		target = d.paneGrab
+	} else {
+		for _, leaf := range d.panes.leaves() {
+			if leaf.region.HasPoint(point) {
+				target = leaf
+				break
+			}
+		}
+	}
+	if e.ButtonPressed() != ButtonNone {
+		d.paneGrab = target
+	} else {
+		d.paneGrab = nil
+	}
+	var handler PaneEventFn
+	focused := false
+	if target != nil {
+		handler = target.event
+		if e.IsPressed() && d.paneFocus != target {
+			d.paneFocus = target
+			focused = true
+		}
+	}
+	d.Unlock()
+	if focused {
+		d.Emit(SignalFocusedPane, d, target)
+	}
+	if handler != nil {
+		return handler(target, e)
+	}
+	return enums.EVENT_PASS
+}
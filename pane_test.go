@@ -0,0 +1,154 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+func TestPanes(t *testing.T) {
+	Convey("Splitting the display into panes", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		w, h := d.Screen().Size()
+		So(d.GetPanes(), ShouldBeEmpty)
+
+		root := d.GetRootPane()
+		So(d.GetPanes(), ShouldResemble, []*Pane{root})
+		So(root.Region(), ShouldResemble, ptypes.MakeRegion(0, 0, w, h))
+		So(d.GetFocusedPane(), ShouldEqual, root)
+
+		var keys []string
+		root.SetDrawFn(func(pane *Pane, surface *memphis.CSurface) {
+			_ = surface.SetRune(0, 0, 'L', paint.StyleDefault)
+		})
+		root.SetEventFn(func(pane *Pane, evt Event) enums.EventFlag {
+			keys = append(keys, pane.Name())
+			return enums.EVENT_STOP
+		})
+
+		left, right, err := d.SplitPane(root, PaneSplitHorizontal, 0.5, "right")
+		So(err, ShouldBeNil)
+		_, _, err = d.SplitPane(root, PaneSplitVertical, 0.5, "again")
+		So(err, ShouldNotBeNil)
+		So(left.Name(), ShouldEqual, "root")
+		So(right.Name(), ShouldEqual, "right")
+		So(d.GetPanes(), ShouldResemble, []*Pane{left, right})
+		So(d.GetFocusedPane(), ShouldEqual, left)
+		span := w - 1
+		half := (span + 1) / 2
+		So(left.Region(), ShouldResemble, ptypes.MakeRegion(0, 0, half, h))
+		So(right.Region(), ShouldResemble, ptypes.MakeRegion(half+1, 0, span-half, h))
+
+		right.SetDrawFn(func(pane *Pane, surface *memphis.CSurface) {
+			_ = surface.SetRune(0, 0, 'R', paint.StyleDefault)
+		})
+		surface := memphis.NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(w, h), paint.StyleDefault)
+		theme := paint.GetDefaultColorTheme()
+		cd.drawPanes(surface, theme)
+		So(surface.GetContent(0, 0).Value(), ShouldEqual, 'L')
+		So(surface.GetContent(half, 0).Value(), ShouldEqual, theme.Border.BorderRunes.Left)
+		So(surface.GetContent(half+1, 0).Value(), ShouldEqual, 'R')
+
+		// keys go to the focused pane, or move its dividers
+		So(cd.processPaneKey(NewEventKey(KeyRune, 'x', ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(keys, ShouldResemble, []string{"root"})
+		So(cd.processPaneKey(NewEventKey(KeyRight, 0, PaneResizeModMask)), ShouldEqual, enums.EVENT_STOP)
+		So(left.Region().W, ShouldEqual, half+1)
+		d.ResizePane(right, PaneSplitHorizontal, -1)
+		So(left.Region().W, ShouldEqual, half)
+		// there is no vertical divider to move
+		d.ResizePane(left, PaneSplitVertical, 1)
+		So(left.Region().W, ShouldEqual, half)
+
+		// dividers can be dragged with the mouse
+		NewEventMouse(0, 0, ButtonNone, ModNone)
+		So(cd.processPaneMouse(NewEventMouse(half, 1, Button1, ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(cd.processPaneMouse(NewEventMouse(half-3, 1, Button1, ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(cd.processPaneMouse(NewEventMouse(half-3, 1, ButtonNone, ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(left.Region().W, ShouldEqual, half-3)
+
+		// clicking a pane focuses it
+		So(cd.processPaneMouse(NewEventMouse(w-1, 1, Button1, ModNone)), ShouldEqual, enums.EVENT_PASS)
+		So(d.GetFocusedPane(), ShouldEqual, right)
+		NewEventMouse(w-1, 1, ButtonNone, ModNone)
+
+		// closing a pane gives its space to the other
+		d.ClosePane(left)
+		So(d.GetPanes(), ShouldResemble, []*Pane{root})
+		So(root.Name(), ShouldEqual, "right")
+		So(root.Region(), ShouldResemble, ptypes.MakeRegion(0, 0, w, h))
+		So(d.GetFocusedPane(), ShouldEqual, root)
+		d.ClosePane(root)
+		So(d.GetPanes(), ShouldBeEmpty)
+		So(d.GetFocusedPane(), ShouldBeNil)
+	}))
+	Convey("Windows over panes", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.running = true
+		cd.started = true
+		cd.Unlock()
+		w, h := d.Screen().Size()
+		left, right, err := d.SplitPane(d.GetRootPane(), PaneSplitHorizontal, 0.5, "right")
+		So(err, ShouldBeNil)
+		var paneEvents []string
+		handler := func(pane *Pane, evt Event) enums.EventFlag {
+			paneEvents = append(paneEvents, pane.Name())
+			return enums.EVENT_STOP
+		}
+		left.SetEventFn(handler)
+		right.SetEventFn(handler)
+		divider := left.Region().W
+		window := NewOffscreenWindow("over")
+		d.MapWindowWithRegion(window, ptypes.MakeRegion(divider-2, 0, 5, 3))
+		var windowEvents int
+		window.Connect(SignalEvent, "pane-test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			if _, ok := argv[1].(*EventMouse); ok {
+				windowEvents++
+				return enums.EVENT_STOP
+			}
+			return enums.EVENT_PASS
+		})
+
+		// the window is given the clicks over the divider and panes
+		d.ProcessEvent(NewEventMouse(divider, 1, Button1, ModNone))
+		d.ProcessEvent(NewEventMouse(divider-4, 1, Button1, ModNone))
+		d.ProcessEvent(NewEventMouse(divider-4, 1, ButtonNone, ModNone))
+		So(left.Region().W, ShouldEqual, divider)
+		d.ProcessEvent(NewEventMouse(divider+1, 1, Button1, ModNone))
+		d.ProcessEvent(NewEventMouse(divider+1, 1, ButtonNone, ModNone))
+		So(windowEvents, ShouldEqual, 5)
+		So(paneEvents, ShouldBeEmpty)
+		So(d.GetFocusedPane(), ShouldEqual, left)
+
+		// the panes are given the clicks elsewhere
+		d.ProcessEvent(NewEventMouse(w-1, h-1, Button1, ModNone))
+		d.ProcessEvent(NewEventMouse(w-1, h-1, ButtonNone, ModNone))
+		So(d.GetFocusedPane(), ShouldEqual, right)
+		So(paneEvents, ShouldResemble, []string{"right", "right"})
+		So(windowEvents, ShouldEqual, 5)
+
+		// and the keys the focused window does not handle
+		d.ProcessEvent(NewEventKey(KeyRune, 'x', ModNone))
+		So(paneEvents, ShouldResemble, []string{"right", "right", "right"})
+	}))
+}
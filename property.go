@@ -18,6 +18,7 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/go-curses/cdk/lib/enums"
 	"github.com/go-curses/cdk/lib/paint"
@@ -116,6 +117,10 @@ func (p *CProperty) Set(value interface{}) error {
 		if _, ok := value.(paint.Color); !ok {
 			return fmt.Errorf("%v value is not of cdk.Color type: %v (%T)", p.name, value, value)
 		}
+	case StyleProperty:
+		if _, ok := value.(paint.Style); !ok {
+			return fmt.Errorf("%v value is not of cdk.Style type: %v (%T)", p.name, value, value)
+		}
 	case ThemeProperty:
 		if _, ok := value.(paint.Theme); !ok {
 			return fmt.Errorf("%v value is not of cdk.Theme type: %v (%T)", p.name, value, value)
@@ -132,10 +137,20 @@ func (p *CProperty) Set(value interface{}) error {
 		if _, ok := value.(ptypes.Region); !ok {
 			return fmt.Errorf("%v value is not of cdk.Region type: %v (%T)", p.name, value, value)
 		}
+	case TimeProperty:
+		if _, ok := value.(time.Duration); !ok {
+			return fmt.Errorf("%v value is not of time.Duration type: %v (%T)", p.name, value, value)
+		}
+	case StyleClassProperty:
+		if _, ok := value.(paint.StyleClass); !ok {
+			return fmt.Errorf("%v value is not of paint.StyleClass type: %v (%T)", p.name, value, value)
+		}
 	case StructProperty:
 		// no checks, just pass
 	default:
-		return fmt.Errorf("invalid property type for %v: %v", p.name, p.Type())
+		if err := checkTypedPropertyValue(p.name, t, value); err != nil {
+			return err
+		}
 	}
 	p.value = value
 	return nil
@@ -187,6 +202,8 @@ func (p *CProperty) SetFromString(value string) error {
 		}
 	case ThemeProperty:
 		return fmt.Errorf("theme property not supported by builder features")
+	case StyleClassProperty:
+		return p.Set(paint.StyleClass(value))
 	case PointProperty:
 		if v, ok := ptypes.ParsePoint2I(value); ok {
 			return p.Set(v)
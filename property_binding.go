@@ -0,0 +1,212 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+
+	cid "github.com/go-curses/cdk/id"
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// BindingFlags modify the behaviour of a property binding, see:
+// MetaData.BindProperty
+type BindingFlags uint64
+
+const (
+	// BindingDefault propagates changes from the source to the target only
+	BindingDefault BindingFlags = 0
+	// BindingBidirectional also propagates changes from the target to the
+	// source
+	BindingBidirectional BindingFlags = 1 << (iota - 1)
+	// BindingSyncCreate sets the target to the current source value when the
+	// binding is created
+	BindingSyncCreate
+	// BindingInvertBoolean negates bool values in both directions, and can
+	// only be used with BoolProperty properties and no transforms
+	BindingInvertBoolean
+)
+
+// check if the flags have the given flag(s)
+func (i BindingFlags) Has(m BindingFlags) bool {
+	return i&m != 0
+}
+
+// BindingTransform converts a value between the source and target properties
+// of a binding. Returning ok false skips the propagation of the value.
+type BindingTransform func(value interface{}) (transformed interface{}, ok bool)
+
+// CPropertyBinding propagates the changes of one property to another,
+// typically on a different object, using the property change notifications
+// of the objects. Bindings remain until Unbind is called or either object is
+// destroyed.
+type CPropertyBinding struct {
+	handle   string
+	source   MetaData
+	src      Property
+	target   MetaData
+	dst      Property
+	flags    BindingFlags
+	to       BindingTransform
+	from     BindingTransform
+	syncing  bool
+	unbound  bool
+	bindLock *sync.Mutex
+}
+
+// Source returns the source object and property of the binding
+func (b *CPropertyBinding) Source() (source MetaData, property Property) {
+	return b.source, b.src
+}
+
+// Target returns the target object and property of the binding
+func (b *CPropertyBinding) Target() (target MetaData, property Property) {
+	return b.target, b.dst
+}
+
+// Flags returns the flags the binding was created with
+func (b *CPropertyBinding) Flags() BindingFlags {
+	return b.flags
+}
+
+// Unbind stops the propagation of changes, it is safe to call more than once
+func (b *CPropertyBinding) Unbind() {
+	b.bindLock.Lock()
+	if b.unbound {
+		b.bindLock.Unlock()
+		return
+	}
+	b.unbound = true
+	b.bindLock.Unlock()
+	_ = b.source.Disconnect(NotifySignal(b.src), b.handle)
+	_ = b.source.Disconnect(SignalDestroy, b.handle)
+	if b.flags.Has(BindingBidirectional) {
+		_ = b.target.Disconnect(NotifySignal(b.dst), b.handle)
+	}
+	_ = b.target.Disconnect(SignalDestroy, b.handle)
+}
+
+// IsBound returns false once the binding has been unbound
+func (b *CPropertyBinding) IsBound() bool {
+	b.bindLock.Lock()
+	defer b.bindLock.Unlock()
+	return !b.unbound
+}
+
+// propagate sets the value on the other side of the binding, unless already
+// propagating a change (which prevents bidirectional feedback loops)
+func (b *CPropertyBinding) propagate(object MetaData, property Property, transform BindingTransform, value interface{}) {
+	b.bindLock.Lock()
+	if b.syncing || b.unbound {
+		b.bindLock.Unlock()
+		return
+	}
+	b.syncing = true
+	b.bindLock.Unlock()
+	defer func() {
+		b.bindLock.Lock()
+		b.syncing = false
+		b.bindLock.Unlock()
+	}()
+	if b.flags.Has(BindingInvertBoolean) {
+		if v, ok := value.(bool); ok {
+			value = !v
+		}
+	}
+	if transform != nil {
+		var ok bool
+		if value, ok = transform(value); !ok {
+			return
+		}
+	}
+	if err := object.SetProperty(property, value); err != nil {
+		object.LogErr(err)
+	}
+}
+
+func (b *CPropertyBinding) listener(object MetaData, property Property, transform BindingTransform) SignalListenerFn {
+	return func(data []interface{}, argv ...interface{}) enums.EventFlag {
+		if _, _, _, value, ok := ArgvSignalNotifyProperty(argv...); ok {
+			b.propagate(object, property, transform, value)
+		}
+		return enums.EVENT_PASS
+	}
+}
+
+func (b *CPropertyBinding) destroyed(data []interface{}, argv ...interface{}) enums.EventFlag {
+	b.Unbind()
+	return enums.EVENT_PASS
+}
+
+// BindProperty binds the src property of this object to the dst property of
+// the target, see: BindPropertyFull
+func (o *CMetaData) BindProperty(src Property, target MetaData, dst Property, flags BindingFlags) (binding *CPropertyBinding, err error) {
+	return o.BindPropertyFull(src, target, dst, flags, nil, nil)
+}
+
+// BindPropertyFull binds the src property of this object to the dst property
+// of the target, so that any change to the source value is set on the target.
+// The optional to transform converts source values for the target and the
+// optional from transform converts target values for the source, when the
+// binding is BindingBidirectional. Both properties must exist and the
+// receiving properties must be writable.
+func (o *CMetaData) BindPropertyFull(src Property, target MetaData, dst Property, flags BindingFlags, to, from BindingTransform) (binding *CPropertyBinding, err error) {
+	if target == nil {
+		return nil, fmt.Errorf("binding target is nil")
+	}
+	srcProp, dstProp := o.GetProperty(src), target.GetProperty(dst)
+	if srcProp == nil {
+		return nil, fmt.Errorf("property not found: %v", src)
+	}
+	if dstProp == nil {
+		return nil, fmt.Errorf("property not found: %v", dst)
+	}
+	if dstProp.ReadOnly() {
+		return nil, fmt.Errorf("cannot bind to read-only property: %v", dst)
+	}
+	if flags.Has(BindingBidirectional) && srcProp.ReadOnly() {
+		return nil, fmt.Errorf("cannot bind to read-only property: %v", src)
+	}
+	if flags.Has(BindingInvertBoolean) {
+		if srcProp.Type() != BoolProperty || dstProp.Type() != BoolProperty {
+			return nil, fmt.Errorf("cannot invert non-bool properties: %v, %v", src, dst)
+		}
+		if to != nil || from != nil {
+			return nil, fmt.Errorf("cannot invert bool properties with transforms")
+		}
+	}
+	binding = &CPropertyBinding{
+		handle:   fmt.Sprintf("property-binding-%v", cid.NewUUID()),
+		source:   o,
+		src:      src,
+		target:   target,
+		dst:      dst,
+		flags:    flags,
+		to:       to,
+		from:     from,
+		bindLock: &sync.Mutex{},
+	}
+	o.Connect(NotifySignal(src), binding.handle, binding.listener(target, dst, to))
+	o.Connect(SignalDestroy, binding.handle, binding.destroyed)
+	if flags.Has(BindingBidirectional) {
+		target.Connect(NotifySignal(dst), binding.handle, binding.listener(o, src, from))
+	}
+	target.Connect(SignalDestroy, binding.handle, binding.destroyed)
+	if flags.Has(BindingSyncCreate) {
+		binding.propagate(target, dst, to, srcProp.Value())
+	}
+	return
+}
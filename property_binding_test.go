@@ -0,0 +1,105 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"strconv"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPropertyBinding(t *testing.T) {
+	Convey("Property bindings", t, func() {
+		a, b := &CObject{}, &CObject{}
+		a.Init()
+		b.Init()
+		So(a.InstallProperty("count", IntProperty, true, 1), ShouldBeNil)
+		So(a.InstallProperty("visible", BoolProperty, true, true), ShouldBeNil)
+		So(b.InstallProperty("count", IntProperty, true, 0), ShouldBeNil)
+		So(b.InstallProperty("label", StringProperty, true, ""), ShouldBeNil)
+		So(b.InstallProperty("hidden", BoolProperty, true, false), ShouldBeNil)
+		So(b.InstallProperty("fixed", IntProperty, false, 0), ShouldBeNil)
+
+		_, err := a.BindProperty("missing", b, "count", BindingDefault)
+		So(err, ShouldNotBeNil)
+		_, err = a.BindProperty("count", b, "fixed", BindingDefault)
+		So(err, ShouldNotBeNil)
+		_, err = a.BindProperty("count", b, "label", BindingInvertBoolean)
+		So(err, ShouldNotBeNil)
+
+		Convey("one way", func() {
+			binding, err := a.BindProperty("count", b, "count", BindingSyncCreate)
+			So(err, ShouldBeNil)
+			count, _ := b.GetIntProperty("count")
+			So(count, ShouldEqual, 1)
+			So(a.SetIntProperty("count", 5), ShouldBeNil)
+			count, _ = b.GetIntProperty("count")
+			So(count, ShouldEqual, 5)
+			So(b.SetIntProperty("count", 7), ShouldBeNil)
+			count, _ = a.GetIntProperty("count")
+			So(count, ShouldEqual, 5)
+			binding.Unbind()
+			binding.Unbind()
+			So(binding.IsBound(), ShouldBeFalse)
+			So(a.SetIntProperty("count", 6), ShouldBeNil)
+			count, _ = b.GetIntProperty("count")
+			So(count, ShouldEqual, 7)
+		})
+
+		Convey("bidirectional with transforms", func() {
+			to := func(value interface{}) (interface{}, bool) {
+				return fmt.Sprintf("%d", value), true
+			}
+			from := func(value interface{}) (interface{}, bool) {
+				v, err := strconv.Atoi(value.(string))
+				return v, err == nil
+			}
+			_, err := a.BindPropertyFull("count", b, "label", BindingBidirectional|BindingSyncCreate, to, from)
+			So(err, ShouldBeNil)
+			label, _ := b.GetStringProperty("label")
+			So(label, ShouldEqual, "1")
+			So(a.SetIntProperty("count", 42), ShouldBeNil)
+			label, _ = b.GetStringProperty("label")
+			So(label, ShouldEqual, "42")
+			So(b.SetStringProperty("label", "12"), ShouldBeNil)
+			count, _ := a.GetIntProperty("count")
+			So(count, ShouldEqual, 12)
+			// values the transform rejects are not propagated
+			So(b.SetStringProperty("label", "twelve"), ShouldBeNil)
+			count, _ = a.GetIntProperty("count")
+			So(count, ShouldEqual, 12)
+		})
+
+		Convey("inverted booleans and destroy", func() {
+			binding, err := a.BindProperty("visible", b, "hidden", BindingBidirectional)
+			So(err, ShouldBeNil)
+			So(a.SetBoolProperty("visible", false), ShouldBeNil)
+			hidden, _ := b.GetBoolProperty("hidden")
+			So(hidden, ShouldBeFalse)
+			binding.Unbind()
+			binding, err = a.BindProperty("visible", b, "hidden", BindingInvertBoolean|BindingBidirectional|BindingSyncCreate)
+			So(err, ShouldBeNil)
+			hidden, _ = b.GetBoolProperty("hidden")
+			So(hidden, ShouldBeTrue)
+			So(b.SetBoolProperty("hidden", false), ShouldBeNil)
+			visible, _ := a.GetBoolProperty("visible")
+			So(visible, ShouldBeTrue)
+			b.Destroy()
+			So(binding.IsBound(), ShouldBeFalse)
+		})
+	})
+}
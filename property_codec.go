@@ -0,0 +1,235 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// PropertyFormat is the document format used to serialize properties
+type PropertyFormat string
+
+const (
+	PropertyFormatJSON PropertyFormat = "json"
+	PropertyFormatTOML PropertyFormat = "toml"
+)
+
+// MarshalProperties encodes the values of all writable properties in the
+// given format, keyed by property name. Colors and styles are encoded as
+// strings (see: paint.Color.MarshalText), durations as time.Duration strings
+// and points, rectangles, regions, themes and struct values as tables of
+// their exported fields.
+func (o *CMetaData) MarshalProperties(format PropertyFormat) (data []byte, err error) {
+	tree := make(map[string]interface{})
+	for _, prop := range o.propertyList() {
+		if prop.ReadOnly() || strings.HasSuffix(prop.Name().String(), "--overload") {
+			continue
+		}
+		var node interface{}
+		if node, err = encodePropertyValue(prop); err != nil {
+			return nil, err
+		}
+		if node != nil {
+			tree[prop.Name().String()] = node
+		}
+	}
+	switch format {
+	case PropertyFormatJSON:
+		return json.MarshalIndent(tree, "", "\t")
+	case PropertyFormatTOML:
+		buf := &bytes.Buffer{}
+		encoder := toml.NewEncoder(buf)
+		encoder.Indent = ""
+		if err = encoder.Encode(tree); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported property format: %v", format)
+}
+
+// UnmarshalProperties decodes a document produced by MarshalProperties and
+// sets each of the named properties, emitting the usual change notifications.
+// Names which are not properties of this object are ignored so that saved
+// state remains loadable as objects evolve. All values are attempted and the
+// first error encountered is returned.
+func (o *CMetaData) UnmarshalProperties(data []byte, format PropertyFormat) (err error) {
+	var tree map[string]interface{}
+	switch format {
+	case PropertyFormatJSON:
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.UseNumber()
+		if err = decoder.Decode(&tree); err != nil {
+			return
+		}
+	case PropertyFormatTOML:
+		if _, err = toml.Decode(string(data), &tree); err != nil {
+			return
+		}
+	default:
+		return fmt.Errorf("unsupported property format: %v", format)
+	}
+	return o.setPropertyTree(tree)
+}
+
+// setPropertyTree sets each of the named properties in the decoded tree, see:
+// UnmarshalProperties
+func (o *CMetaData) setPropertyTree(tree map[string]interface{}) (err error) {
+	o.FreezeNotify()
+	defer o.ThawNotify()
+	for _, prop := range o.propertyList() {
+		node, ok := tree[prop.Name().String()]
+		if !ok || prop.ReadOnly() {
+			continue
+		}
+		if value, e := decodePropertyValue(prop, node); e != nil {
+			if err == nil {
+				err = e
+			}
+		} else if e = o.SetProperty(prop.Name(), value); e != nil && err == nil {
+			err = e
+		}
+	}
+	return
+}
+
+func (o *CMetaData) propertyList() (properties []*CProperty) {
+	o.propertyLock.RLock()
+	defer o.propertyLock.RUnlock()
+	properties = make([]*CProperty, len(o.properties))
+	copy(properties, o.properties)
+	return
+}
+
+// propertyValueType returns the Go type stored by the property, or nil if
+// it cannot be determined
+func propertyValueType(prop *CProperty) reflect.Type {
+	switch kind := prop.Type(); kind {
+	case BoolProperty:
+		return reflect.TypeOf(false)
+	case StringProperty:
+		return reflect.TypeOf("")
+	case IntProperty:
+		return reflect.TypeOf(0)
+	case FloatProperty:
+		return reflect.TypeOf(0.0)
+	case ColorProperty:
+		return reflect.TypeOf(paint.Color(0))
+	case StyleProperty:
+		return reflect.TypeOf(paint.Style{})
+	case ThemeProperty:
+		return reflect.TypeOf(paint.Theme{})
+	case PointProperty:
+		return reflect.TypeOf(ptypes.Point2I{})
+	case RectangleProperty:
+		return reflect.TypeOf(ptypes.Rectangle{})
+	case RegionProperty:
+		return reflect.TypeOf(ptypes.Region{})
+	case TimeProperty:
+		return reflect.TypeOf(time.Duration(0))
+	case StyleClassProperty:
+		return reflect.TypeOf(paint.StyleClass(""))
+	default:
+		cTypedPropertiesLock.RLock()
+		typ, ok := cTypedProperties[kind]
+		cTypedPropertiesLock.RUnlock()
+		if ok {
+			return typ
+		}
+	}
+	if value := prop.Value(); value != nil {
+		return reflect.TypeOf(value)
+	}
+	return nil
+}
+
+// encodePropertyValue returns the generic (JSON compatible) representation of
+// the property value
+func encodePropertyValue(prop *CProperty) (node interface{}, err error) {
+	value := prop.Value()
+	if value == nil {
+		return nil, nil
+	}
+	if d, ok := value.(time.Duration); ok {
+		return d.String(), nil
+	}
+	var data []byte
+	if data, err = json.Marshal(value); err != nil {
+		return nil, fmt.Errorf("error encoding property %v: %w", prop.Name(), err)
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(&node); err != nil {
+		return nil, fmt.Errorf("error encoding property %v: %w", prop.Name(), err)
+	}
+	return jsonNumbers(node), nil
+}
+
+// decodePropertyValue converts the generic representation of a value back to
+// the type stored by the property
+func decodePropertyValue(prop *CProperty, node interface{}) (value interface{}, err error) {
+	typ := propertyValueType(prop)
+	if typ == nil {
+		return nil, fmt.Errorf("cannot decode property %v without a default value", prop.Name())
+	}
+	if typ == reflect.TypeOf(time.Duration(0)) {
+		if s, ok := node.(string); ok {
+			if value, err = time.ParseDuration(s); err != nil {
+				return nil, fmt.Errorf("error decoding property %v: %w", prop.Name(), err)
+			}
+			return
+		}
+	}
+	var data []byte
+	if data, err = json.Marshal(node); err != nil {
+		return nil, fmt.Errorf("error decoding property %v: %w", prop.Name(), err)
+	}
+	decoded := reflect.New(typ)
+	if err = json.Unmarshal(data, decoded.Interface()); err != nil {
+		return nil, fmt.Errorf("error decoding property %v: %w", prop.Name(), err)
+	}
+	return decoded.Elem().Interface(), nil
+}
+
+// jsonNumbers replaces json.Number values with int64 or float64 values
+func jsonNumbers(node interface{}) interface{} {
+	switch v := node.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}:
+		for idx := range v {
+			v[idx] = jsonNumbers(v[idx])
+		}
+	case map[string]interface{}:
+		for key := range v {
+			v[key] = jsonNumbers(v[key])
+		}
+	}
+	return node
+}
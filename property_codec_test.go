@@ -0,0 +1,116 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func newPropertyCodecObject() (o *CObject) {
+	o = &CObject{}
+	o.Init()
+	_ = o.InstallProperty("enabled", BoolProperty, true, false)
+	_ = o.InstallProperty("title", StringProperty, true, "")
+	_ = o.InstallProperty("count", IntProperty, true, 0)
+	_ = o.InstallProperty("ratio", FloatProperty, true, 0.0)
+	_ = o.InstallProperty("color", ColorProperty, true, paint.ColorDefault)
+	_ = o.InstallProperty("style", StyleProperty, true, paint.StyleDefault)
+	_ = o.InstallProperty("theme", ThemeProperty, true, paint.Theme{})
+	_ = o.InstallProperty("origin", PointProperty, true, ptypes.Point2I{})
+	_ = o.InstallProperty("size", RectangleProperty, true, ptypes.Rectangle{})
+	_ = o.InstallProperty("geometry", RegionProperty, true, ptypes.Region{})
+	_ = o.InstallProperty("delay", TimeProperty, true, time.Duration(0))
+	_ = InstallTypedProperty(o, "custom", true, testTypedProperty{})
+	_ = o.InstallProperty("fixed", IntProperty, false, 7)
+	return
+}
+
+func TestPropertyCodec(t *testing.T) {
+	Convey("Property serialization", t, func() {
+		src := newPropertyCodecObject()
+		So(src.SetBoolProperty("enabled", true), ShouldBeNil)
+		So(src.SetStringProperty("title", "Demo \"Title\""), ShouldBeNil)
+		So(src.SetIntProperty("count", 42), ShouldBeNil)
+		So(src.SetFloatProperty("ratio", 2.0), ShouldBeNil)
+		So(src.SetColorProperty("color", paint.NewRGBColor(1, 2, 3)), ShouldBeNil)
+		So(src.SetStyleProperty("style", paint.StyleDefault.Foreground(paint.ColorRed).Underline(true)), ShouldBeNil)
+		So(src.SetThemeProperty("theme", paint.GetDefaultColorTheme()), ShouldBeNil)
+		So(src.SetPointProperty("origin", ptypes.MakePoint2I(3, 4)), ShouldBeNil)
+		So(src.SetRectangleProperty("size", ptypes.MakeRectangle(80, 24)), ShouldBeNil)
+		So(src.SetRegionProperty("geometry", ptypes.MakeRegion(1, 2, 30, 10)), ShouldBeNil)
+		So(src.SetTimeProperty("delay", 1500*time.Millisecond), ShouldBeNil)
+		So(SetTypedProperty(src, "custom", testTypedProperty{Name: "saved", Count: 3}), ShouldBeNil)
+
+		check := func(dst *CObject) {
+			enabled, _ := dst.GetBoolProperty("enabled")
+			So(enabled, ShouldBeTrue)
+			title, _ := dst.GetStringProperty("title")
+			So(title, ShouldEqual, "Demo \"Title\"")
+			count, _ := dst.GetIntProperty("count")
+			So(count, ShouldEqual, 42)
+			ratio, _ := dst.GetFloatProperty("ratio")
+			So(ratio, ShouldEqual, 2.0)
+			color, _ := dst.GetColorProperty("color")
+			So(color, ShouldEqual, paint.NewRGBColor(1, 2, 3))
+			style, _ := dst.GetStyleProperty("style")
+			So(style, ShouldResemble, paint.StyleDefault.Foreground(paint.ColorRed).Underline(true))
+			theme, _ := dst.GetThemeProperty("theme")
+			So(theme, ShouldResemble, paint.GetDefaultColorTheme())
+			origin, _ := dst.GetPointProperty("origin")
+			So(origin, ShouldResemble, ptypes.MakePoint2I(3, 4))
+			size, _ := dst.GetRectangleProperty("size")
+			So(size, ShouldResemble, ptypes.MakeRectangle(80, 24))
+			geometry, _ := dst.GetRegionProperty("geometry")
+			So(geometry, ShouldResemble, ptypes.MakeRegion(1, 2, 30, 10))
+			delay, _ := dst.GetTimeProperty("delay")
+			So(delay, ShouldEqual, 1500*time.Millisecond)
+			custom, _ := GetTypedProperty[testTypedProperty](dst, "custom")
+			So(custom, ShouldResemble, testTypedProperty{Name: "saved", Count: 3})
+		}
+
+		for _, format := range []PropertyFormat{PropertyFormatJSON, PropertyFormatTOML} {
+			data, err := src.MarshalProperties(format)
+			So(err, ShouldBeNil)
+			So(string(data), ShouldNotContainSubstring, "fixed")
+			dst := newPropertyCodecObject()
+			So(dst.UnmarshalProperties(data, format), ShouldBeNil)
+			check(dst)
+		}
+
+		data, _ := src.MarshalProperties(PropertyFormatTOML)
+		So(string(data), ShouldContainSubstring, "delay = \"1.5s\"\n")
+		So(string(data), ShouldContainSubstring, "color = \"#010203\"\n")
+		So(strings.Contains(string(data), "[theme.Content]"), ShouldBeTrue)
+
+		dst := newPropertyCodecObject()
+		So(dst.UnmarshalProperties([]byte(`{"count":"many","title":"ok","unknown":1}`), PropertyFormatJSON), ShouldNotBeNil)
+		title, _ := dst.GetStringProperty("title")
+		So(title, ShouldEqual, "ok")
+		So(dst.UnmarshalProperties([]byte(`count = `), PropertyFormatTOML), ShouldNotBeNil)
+		_, err := dst.MarshalProperties("yaml")
+		So(err, ShouldNotBeNil)
+		So(dst.SetPropertyFromString("color", "hsl(120, 100%, 50%)"), ShouldBeNil)
+		color, _ := dst.GetColorProperty("color")
+		So(color, ShouldEqual, paint.NewRGBColor(0, 255, 0))
+		So(dst.SetPropertyFromString("color", "rgb(1, 2)"), ShouldNotBeNil)
+	})
+}
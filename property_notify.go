@@ -0,0 +1,121 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// NotifySignal returns the "notify::<name>" signal emitted when the value of
+// the named property changes. Listeners receive the same argv as for
+// SignalNotifyProperty, see: ArgvSignalNotifyProperty
+func NotifySignal(name Property) Signal {
+	return Signal(fmt.Sprintf("%v::%v", SignalNotifyProperty, name))
+}
+
+// ArgvSignalNotifyProperty unpacks the argv of SignalNotifyProperty and
+// NotifySignal listeners
+func ArgvSignalNotifyProperty(argv ...interface{}) (object MetaData, name Property, old, value interface{}, ok bool) {
+	if len(argv) == 4 {
+		if object, ok = argv[0].(MetaData); ok {
+			if name, ok = argv[1].(Property); ok {
+				old, value = argv[2], argv[3]
+				return
+			}
+			object = nil
+		}
+	}
+	return
+}
+
+type cPropertyNotification struct {
+	name  Property
+	old   interface{}
+	value interface{}
+}
+
+// cPropertyNotifier tracks the notification freeze count and the
+// notifications queued while frozen
+type cPropertyNotifier struct {
+	frozen int
+	queue  []*cPropertyNotification
+
+	sync.Mutex
+}
+
+// FreezeNotify suppresses property change notifications until ThawNotify is
+// called the same number of times. While frozen, changes to the same property
+// are combined into a single notification, from the first old value to the
+// last new value.
+func (o *CMetaData) FreezeNotify() {
+	o.notify.Lock()
+	o.notify.frozen++
+	o.notify.Unlock()
+}
+
+// ThawNotify reverses one call to FreezeNotify, emitting any queued
+// notifications, in order of first change, once no longer frozen.
+func (o *CMetaData) ThawNotify() {
+	o.notify.Lock()
+	if o.notify.frozen == 0 {
+		o.notify.Unlock()
+		o.LogWarn("ThawNotify called without FreezeNotify")
+		return
+	}
+	if o.notify.frozen--; o.notify.frozen > 0 {
+		o.notify.Unlock()
+		return
+	}
+	queue := o.notify.queue
+	o.notify.queue = nil
+	o.notify.Unlock()
+	for _, n := range queue {
+		o.emitNotifyProperty(n.name, n.old, n.value)
+	}
+}
+
+func (o *CMetaData) IsNotifyFrozen() (frozen bool) {
+	o.notify.Lock()
+	defer o.notify.Unlock()
+	return o.notify.frozen > 0
+}
+
+func (o *CMetaData) notifyProperty(name Property, old, value interface{}) {
+	o.notify.Lock()
+	if o.notify.frozen > 0 {
+		defer o.notify.Unlock()
+		for _, n := range o.notify.queue {
+			if n.name == name {
+				n.value = value
+				return
+			}
+		}
+		o.notify.queue = append(o.notify.queue, &cPropertyNotification{name: name, old: old, value: value})
+		return
+	}
+	o.notify.Unlock()
+	o.emitNotifyProperty(name, old, value)
+}
+
+func (o *CMetaData) emitNotifyProperty(name Property, old, value interface{}) {
+	if reflect.DeepEqual(old, value) {
+		return
+	}
+	o.Emit(SignalNotifyProperty, o, name, old, value)
+	o.Emit(NotifySignal(name), o, name, old, value)
+}
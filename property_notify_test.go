@@ -0,0 +1,76 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestPropertyNotify(t *testing.T) {
+	Convey("Property change notifications", t, func() {
+		o := &CObject{}
+		o.Init()
+		So(o.InstallProperty("count", IntProperty, true, 0), ShouldBeNil)
+		So(o.InstallProperty("label", StringProperty, true, ""), ShouldBeNil)
+		So(NotifySignal("count"), ShouldEqual, Signal("notify::count"))
+		var changes []interface{}
+		all := 0
+		o.Connect(NotifySignal("count"), "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			_, name, old, value, ok := ArgvSignalNotifyProperty(argv...)
+			So(ok, ShouldBeTrue)
+			So(name, ShouldEqual, Property("count"))
+			changes = append(changes, old, value)
+			return enums.EVENT_PASS
+		})
+		o.Connect(SignalNotifyProperty, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			all++
+			return enums.EVENT_PASS
+		})
+		So(o.SetIntProperty("count", 1), ShouldBeNil)
+		So(changes, ShouldResemble, []interface{}{0, 1})
+		// unchanged values are not notified
+		So(o.SetIntProperty("count", 1), ShouldBeNil)
+		So(changes, ShouldHaveLength, 2)
+		So(o.SetStringProperty("label", "text"), ShouldBeNil)
+		So(all, ShouldEqual, 2)
+		So(o.SetPropertyFromString("count", "5"), ShouldBeNil)
+		So(changes, ShouldResemble, []interface{}{0, 1, 1, 5})
+
+		Convey("frozen notifications are combined", func() {
+			changes = nil
+			o.FreezeNotify()
+			o.FreezeNotify()
+			So(o.IsNotifyFrozen(), ShouldBeTrue)
+			So(o.SetIntProperty("count", 6), ShouldBeNil)
+			So(o.SetIntProperty("count", 7), ShouldBeNil)
+			o.ThawNotify()
+			So(changes, ShouldHaveLength, 0)
+			o.ThawNotify()
+			So(o.IsNotifyFrozen(), ShouldBeFalse)
+			So(changes, ShouldResemble, []interface{}{5, 7})
+			// changes reverted while frozen are not notified
+			changes = nil
+			o.FreezeNotify()
+			So(o.SetIntProperty("count", 8), ShouldBeNil)
+			So(o.SetIntProperty("count", 7), ShouldBeNil)
+			o.ThawNotify()
+			So(changes, ShouldHaveLength, 0)
+		})
+	})
+}
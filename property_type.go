@@ -27,6 +27,8 @@ const (
 	RegionProperty    PropertyType = "region"
 	StructProperty    PropertyType = "struct"
 	TimeProperty      PropertyType = "time"
+	// StyleClassProperty holds a paint.StyleClass
+	StyleClassProperty PropertyType = "style-class"
 )
 
 type PropertyType string
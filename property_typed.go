@@ -0,0 +1,135 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+var (
+	cTypedProperties     = make(map[PropertyType]reflect.Type)
+	cTypedPropertiesLock = &sync.RWMutex{}
+)
+
+// PropertyTypeOf returns the PropertyType used to store values of type T. The
+// types supported by the fixed PropertyType set map to their existing kind,
+// any other type is registered as an application-defined "typed:<T>" kind
+// which only accepts values assignable to T.
+func PropertyTypeOf[T any]() (kind PropertyType) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	switch typ {
+	case reflect.TypeOf(false):
+		return BoolProperty
+	case reflect.TypeOf(""):
+		return StringProperty
+	case reflect.TypeOf(0):
+		return IntProperty
+	case reflect.TypeOf(0.0):
+		return FloatProperty
+	case reflect.TypeOf(paint.Color(0)):
+		return ColorProperty
+	case reflect.TypeOf(paint.Style{}):
+		return StyleProperty
+	case reflect.TypeOf(paint.Theme{}):
+		return ThemeProperty
+	case reflect.TypeOf(ptypes.Point2I{}):
+		return PointProperty
+	case reflect.TypeOf(ptypes.Rectangle{}):
+		return RectangleProperty
+	case reflect.TypeOf(ptypes.Region{}):
+		return RegionProperty
+	case reflect.TypeOf(time.Duration(0)):
+		return TimeProperty
+	case reflect.TypeOf(paint.StyleClass("")):
+		return StyleClassProperty
+	}
+	kind = PropertyType("typed:" + typ.String())
+	cTypedPropertiesLock.Lock()
+	cTypedProperties[kind] = typ
+	cTypedPropertiesLock.Unlock()
+	return
+}
+
+// IsTypedPropertyType returns true if the given kind was registered by
+// PropertyTypeOf for an application-defined type
+func IsTypedPropertyType(kind PropertyType) (typed bool) {
+	cTypedPropertiesLock.RLock()
+	defer cTypedPropertiesLock.RUnlock()
+	_, typed = cTypedProperties[kind]
+	return
+}
+
+// checkTypedPropertyValue returns an error if the kind is not a registered
+// application-defined type or if the value is not assignable to it
+func checkTypedPropertyValue(name Property, kind PropertyType, value interface{}) error {
+	cTypedPropertiesLock.RLock()
+	typ, ok := cTypedProperties[kind]
+	cTypedPropertiesLock.RUnlock()
+	if !ok {
+		return fmt.Errorf("invalid property type for %v: %v", name, kind)
+	}
+	if value == nil {
+		switch typ.Kind() {
+		case reflect.Interface, reflect.Pointer, reflect.Map, reflect.Slice, reflect.Func, reflect.Chan:
+			return nil
+		}
+	} else if reflect.TypeOf(value).AssignableTo(typ) {
+		return nil
+	}
+	return fmt.Errorf("%v value is not of %v type: %v (%T)", name, typ, value, value)
+}
+
+// InstallTypedProperty installs a new property on the MetaData with the
+// PropertyType of T, see: PropertyTypeOf
+func InstallTypedProperty[T any](m MetaData, name Property, write bool, def T) error {
+	return m.InstallProperty(name, PropertyTypeOf[T](), write, def)
+}
+
+// GetTypedProperty returns the value of the named property as type T, falling
+// back to the property default when the value is unset
+func GetTypedProperty[T any](m MetaData, name Property) (value T, err error) {
+	prop := m.GetProperty(name)
+	if prop == nil {
+		err = fmt.Errorf("property not found: %v", name)
+		return
+	}
+	if v, ok := prop.Value().(T); ok {
+		return v, nil
+	}
+	if v, ok := prop.Default().(T); ok {
+		return v, nil
+	}
+	err = fmt.Errorf("%v.(%v) property is not a %v", name, prop.Type(), reflect.TypeOf((*T)(nil)).Elem())
+	return
+}
+
+// SetTypedProperty updates the value of the named property, which must have
+// been installed with the PropertyType of T or as a StructProperty
+func SetTypedProperty[T any](m MetaData, name Property, value T) error {
+	prop := m.GetProperty(name)
+	if prop == nil {
+		return fmt.Errorf("property not found: %v", name)
+	}
+	if kind := prop.Type(); kind != StructProperty && kind != PropertyTypeOf[T]() {
+		return fmt.Errorf("%v.(%v) property is not a %v", name, kind, reflect.TypeOf((*T)(nil)).Elem())
+	}
+	return m.SetProperty(name, value)
+}
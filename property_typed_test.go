@@ -0,0 +1,84 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+type testTypedProperty struct {
+	Name  string
+	Count int
+}
+
+func TestTypedProperties(t *testing.T) {
+	Convey("Typed property accessors", t, func() {
+		So(PropertyTypeOf[bool](), ShouldEqual, BoolProperty)
+		So(PropertyTypeOf[paint.Style](), ShouldEqual, StyleProperty)
+		So(PropertyTypeOf[time.Duration](), ShouldEqual, TimeProperty)
+		kind := PropertyTypeOf[testTypedProperty]()
+		So(kind, ShouldEqual, PropertyType("typed:cdk.testTypedProperty"))
+		So(IsTypedPropertyType(kind), ShouldBeTrue)
+		So(IsTypedPropertyType(IntProperty), ShouldBeFalse)
+
+		o := &CObject{}
+		o.Init()
+		So(InstallTypedProperty(o, "count", true, 1), ShouldBeNil)
+		So(InstallTypedProperty(o, "delay", true, time.Second), ShouldBeNil)
+		So(InstallTypedProperty(o, "custom", true, testTypedProperty{Name: "one"}), ShouldBeNil)
+		So(InstallTypedProperty[fmt.Stringer](o, "stringer", true, nil), ShouldBeNil)
+		So(InstallTypedProperty(o, "fixed", false, "ro"), ShouldBeNil)
+
+		count, err := GetTypedProperty[int](o, "count")
+		So(err, ShouldBeNil)
+		So(count, ShouldEqual, 1)
+		So(SetTypedProperty(o, "count", 2), ShouldBeNil)
+		count, _ = o.GetIntProperty("count")
+		So(count, ShouldEqual, 2)
+		So(SetTypedProperty(o, "count", "two"), ShouldNotBeNil)
+		_, err = GetTypedProperty[string](o, "count")
+		So(err, ShouldNotBeNil)
+
+		So(SetTypedProperty(o, "delay", time.Minute), ShouldBeNil)
+		delay, err := o.GetTimeProperty("delay")
+		So(err, ShouldBeNil)
+		So(delay, ShouldEqual, time.Minute)
+
+		custom, err := GetTypedProperty[testTypedProperty](o, "custom")
+		So(err, ShouldBeNil)
+		So(custom.Name, ShouldEqual, "one")
+		So(SetTypedProperty(o, "custom", testTypedProperty{Name: "two", Count: 2}), ShouldBeNil)
+		custom, _ = GetTypedProperty[testTypedProperty](o, "custom")
+		So(custom, ShouldResemble, testTypedProperty{Name: "two", Count: 2})
+		// untyped setters are still checked against the registered type
+		So(o.SetProperty("custom", 10), ShouldNotBeNil)
+
+		So(SetTypedProperty[fmt.Stringer](o, "stringer", time.Second), ShouldBeNil)
+		stringer, err := GetTypedProperty[fmt.Stringer](o, "stringer")
+		So(err, ShouldBeNil)
+		So(stringer.String(), ShouldEqual, "1s")
+
+		So(SetTypedProperty(o, "fixed", "rw"), ShouldNotBeNil)
+		_, err = GetTypedProperty[int](o, "missing")
+		So(err, ShouldNotBeNil)
+		So(SetTypedProperty(o, "missing", 1), ShouldNotBeNil)
+	})
+}
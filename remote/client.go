@@ -0,0 +1,152 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// Client renders the frames of a RemoteScreen on a local cdk.Screen and sends
+// the input events of the local screen back to the server
+type Client struct {
+	peer    *peer
+	screen  cdk.Screen
+	version int
+	lock    sync.RWMutex
+}
+
+// NewClient returns a Client for the server on the other end of the given
+// connection, drawing on the given screen which must already be initialized
+func NewClient(conn net.Conn, screen cdk.Screen) *Client {
+	return &Client{
+		peer:   newPeer(conn),
+		screen: screen,
+	}
+}
+
+// Version returns the protocol version negotiated with the server
+func (c *Client) Version() int {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.version
+}
+
+// Run negotiates the protocol version and draws the frames sent by the server
+// until the server says goodbye, the connection is lost or the context is
+// done. Run returns nil when the server ends the session.
+func (c *Client) Run(ctx context.Context) (err error) {
+	if err = c.negotiate(); err != nil {
+		c.peer.close("")
+		return
+	}
+	go c.peer.keepalive()
+	go c.forward(ctx)
+	for {
+		var t messageType
+		var payload []byte
+		if t, payload, err = c.peer.receive(); err != nil {
+			c.peer.close("")
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			return
+		}
+		switch t {
+		case msgFrame:
+			var f frame
+			if f, err = decodeFrame(payload); err != nil {
+				c.peer.close(err.Error())
+				return
+			}
+			c.draw(f)
+		case msgBeep:
+			_ = c.screen.Beep()
+		case msgBye:
+			c.peer.close("")
+			return nil
+		}
+	}
+}
+
+func (c *Client) negotiate() (err error) {
+	_ = c.peer.conn.SetReadDeadline(time.Now().Add(NegotiationTimeout))
+	var t messageType
+	var payload []byte
+	if t, payload, err = readMessage(c.peer.r); err != nil {
+		return
+	}
+	if t != msgHello {
+		return ErrMalformed
+	}
+	var h hello
+	if h, err = decodeHello(payload); err != nil {
+		return
+	}
+	var version int
+	if version, err = negotiate(h.min, h.max); err != nil {
+		e := &encoder{}
+		e.string(err.Error())
+		_ = c.peer.sendWithin(msgBye, e.buf, byeTimeout)
+		return
+	}
+	c.lock.Lock()
+	c.version = version
+	c.lock.Unlock()
+	w, ht := c.screen.Size()
+	if err = c.peer.send(msgHello, encodeHello(hello{min: version, max: version, w: w, h: ht})); err != nil {
+		return fmt.Errorf("error sending hello: %w", err)
+	}
+	return
+}
+
+// forward sends the input and resize events of the local screen to the server
+func (c *Client) forward(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			c.peer.close("client closed")
+			return
+		case <-c.peer.done:
+			return
+		case ev := <-c.screen.PollEventChan():
+			if payload, ok := encodeEvent(ev); ok {
+				if err := c.peer.send(msgEvent, payload); err != nil {
+					c.peer.close("")
+					return
+				}
+			}
+		}
+	}
+}
+
+func (c *Client) draw(f frame) {
+	for _, s := range f.spans {
+		for i, cl := range s.cells {
+			c.screen.SetContent(s.x+i, s.y, cl.mainc, cl.combc, cl.style)
+		}
+	}
+	if f.cursor {
+		c.screen.ShowCursor(f.cx, f.cy)
+	} else {
+		c.screen.HideCursor()
+	}
+	c.screen.Show()
+}
@@ -0,0 +1,123 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bufio"
+	"net"
+	"time"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// byeTimeout limits how long a peer waits to deliver the bye message when
+// closing the connection
+const byeTimeout = time.Second
+
+// peer is one end of a connection, shared by the server and client sides for
+// sending messages, answering pings and closing the connection
+type peer struct {
+	conn     net.Conn
+	r        *bufio.Reader
+	interval time.Duration
+	timeout  time.Duration
+	wmu      sync.Mutex
+	done     chan struct{}
+	once     sync.Once
+}
+
+func newPeer(conn net.Conn) *peer {
+	return &peer{
+		conn:     conn,
+		r:        bufio.NewReader(conn),
+		interval: KeepaliveInterval,
+		timeout:  KeepaliveTimeout,
+		done:     make(chan struct{}),
+	}
+}
+
+func (p *peer) send(t messageType, payload []byte) error {
+	return p.sendWithin(t, payload, p.timeout)
+}
+
+func (p *peer) sendWithin(t messageType, payload []byte, timeout time.Duration) error {
+	p.wmu.Lock()
+	defer p.wmu.Unlock()
+	_ = p.conn.SetWriteDeadline(time.Now().Add(timeout))
+	return writeMessage(p.conn, t, payload)
+}
+
+// receive returns the next message which is not a keepalive, answering pings
+// along the way
+func (p *peer) receive() (t messageType, payload []byte, err error) {
+	for {
+		_ = p.conn.SetReadDeadline(time.Now().Add(p.timeout))
+		if t, payload, err = readMessage(p.r); err != nil {
+			if p.closed() {
+				err = ErrClosed
+			}
+			return
+		}
+		switch t {
+		case msgPing:
+			if err = p.send(msgPong, payload); err != nil {
+				return
+			}
+		case msgPong:
+		default:
+			return
+		}
+	}
+}
+
+// keepalive sends a ping every interval until the peer is closed
+func (p *peer) keepalive() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			if err := p.send(msgPing, nil); err != nil {
+				p.close("")
+				return
+			}
+		}
+	}
+}
+
+func (p *peer) closed() bool {
+	select {
+	case <-p.done:
+		return true
+	default:
+		return false
+	}
+}
+
+// close sends a bye message with the given reason, unless empty, and closes
+// the connection
+func (p *peer) close(reason string) {
+	p.once.Do(func() {
+		close(p.done)
+		if reason != "" {
+			e := &encoder{}
+			e.string(reason)
+			_ = p.sendWithin(msgBye, e.buf, byeTimeout)
+		}
+		_ = p.conn.Close()
+	})
+}
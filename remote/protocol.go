@@ -0,0 +1,427 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package remote provides a compact binary protocol for rendering a CDK
+// Display on a thin client over any net.Conn, without the SSH and pty stack
+// of the application server. The server side is a cdk.Screen, created with
+// NewRemoteScreen, which sends the dirty spans of cells on each Show. The
+// client side, created with NewClient, draws the frames on a local cdk.Screen
+// and sends its input events back in the opposite direction.
+//
+// Each message is a type byte, followed by the length of the payload as an
+// unsigned varint and the payload itself. The server opens with a hello
+// message giving the range of protocol versions it supports and the client
+// answers with the version chosen and its screen size. Both sides send a
+// ping every KeepaliveInterval and consider the connection lost when nothing
+// has been received for KeepaliveTimeout.
+package remote
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+const (
+	// ProtocolVersion is the newest version of the protocol supported
+	ProtocolVersion = 1
+	// MinProtocolVersion is the oldest version of the protocol supported
+	MinProtocolVersion = 1
+	// MaxMessageSize is the largest payload accepted in a single message
+	MaxMessageSize = 16 << 20
+	// MaxScreenSize is the largest width or height of a screen accepted from
+	// a peer
+	MaxScreenSize = 4096
+)
+
+// protocolMagic opens every hello message
+const protocolMagic = "CDKR"
+
+var (
+	// KeepaliveInterval is how often each side sends a ping
+	KeepaliveInterval = 15 * time.Second
+	// KeepaliveTimeout is how long either side waits for any message before
+	// considering the connection lost
+	KeepaliveTimeout = 45 * time.Second
+	// NegotiationTimeout is how long the server waits for the hello of the
+	// client
+	NegotiationTimeout = 10 * time.Second
+)
+
+var (
+	// ErrBadMagic indicates the peer does not speak this protocol
+	ErrBadMagic = errors.New("remote: not a cdk remote peer")
+	// ErrVersionMismatch indicates the peers share no protocol version
+	ErrVersionMismatch = errors.New("remote: no common protocol version")
+	// ErrMessageTooLarge indicates a payload larger than MaxMessageSize
+	ErrMessageTooLarge = errors.New("remote: message too large")
+	// ErrMalformed indicates a payload which could not be decoded
+	ErrMalformed = errors.New("remote: malformed message")
+	// ErrClosed indicates the connection was closed by either side
+	ErrClosed = errors.New("remote: connection closed")
+)
+
+type messageType uint8
+
+const (
+	msgHello messageType = iota + 1
+	msgFrame
+	msgEvent
+	msgPing
+	msgPong
+	msgBeep
+	msgBye
+)
+
+type eventType uint8
+
+const (
+	evKey eventType = iota + 1
+	evMouse
+	evResize
+	evPaste
+)
+
+const (
+	frameFull uint8 = 1 << iota
+	frameCursor
+)
+
+// cell is the content of one screen cell as sent in a frame
+type cell struct {
+	mainc rune
+	combc []rune
+	style paint.Style
+}
+
+func (c cell) equal(o cell) bool {
+	if c.mainc != o.mainc || c.style != o.style || len(c.combc) != len(o.combc) {
+		return false
+	}
+	for i := range c.combc {
+		if c.combc[i] != o.combc[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// span is a run of consecutive cells on one row
+type span struct {
+	x, y  int
+	cells []cell
+}
+
+// frame is the content of a single Show
+type frame struct {
+	w, h   int
+	full   bool
+	cursor bool
+	cx, cy int
+	spans  []span
+}
+
+// hello opens the connection, the server sends the range of versions it
+// supports and the client answers with the chosen version in both and the
+// size of its screen
+type hello struct {
+	min, max int
+	w, h     int
+}
+
+// encoder builds the payload of a message
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) byte(b uint8) {
+	e.buf = append(e.buf, b)
+}
+
+func (e *encoder) uint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *encoder) int(v int64) {
+	e.buf = binary.AppendVarint(e.buf, v)
+}
+
+func (e *encoder) string(s string) {
+	e.uint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// decoder reads the payload of a message, recording the first error
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) byte() (b uint8) {
+	if d.err != nil {
+		return
+	}
+	if len(d.buf) == 0 {
+		d.err = ErrMalformed
+		return
+	}
+	b, d.buf = d.buf[0], d.buf[1:]
+	return
+}
+
+func (d *decoder) uint() (v uint64) {
+	if d.err != nil {
+		return
+	}
+	var n int
+	if v, n = binary.Uvarint(d.buf); n <= 0 {
+		d.err = ErrMalformed
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return
+}
+
+func (d *decoder) int() (v int64) {
+	if d.err != nil {
+		return
+	}
+	var n int
+	if v, n = binary.Varint(d.buf); n <= 0 {
+		d.err = ErrMalformed
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return
+}
+
+// count reads a length which cannot exceed the remaining payload
+func (d *decoder) count() int {
+	n := d.uint()
+	if n > uint64(len(d.buf)) {
+		d.err = ErrMalformed
+		return 0
+	}
+	return int(n)
+}
+
+func (d *decoder) string() (s string) {
+	n := d.count()
+	if d.err != nil {
+		return
+	}
+	s, d.buf = string(d.buf[:n]), d.buf[n:]
+	return
+}
+
+func writeMessage(w io.Writer, t messageType, payload []byte) (err error) {
+	head := binary.AppendUvarint([]byte{byte(t)}, uint64(len(payload)))
+	_, err = w.Write(append(head, payload...))
+	return
+}
+
+func readMessage(r *bufio.Reader) (t messageType, payload []byte, err error) {
+	var b byte
+	if b, err = r.ReadByte(); err != nil {
+		return
+	}
+	var size uint64
+	if size, err = binary.ReadUvarint(r); err != nil {
+		return
+	}
+	if size > MaxMessageSize {
+		return 0, nil, ErrMessageTooLarge
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(r, payload); err != nil {
+		return
+	}
+	return messageType(b), payload, nil
+}
+
+func encodeHello(h hello) []byte {
+	e := &encoder{buf: []byte(protocolMagic)}
+	e.uint(uint64(h.min))
+	e.uint(uint64(h.max))
+	e.uint(uint64(h.w))
+	e.uint(uint64(h.h))
+	return e.buf
+}
+
+func decodeHello(payload []byte) (h hello, err error) {
+	if len(payload) < len(protocolMagic) || string(payload[:len(protocolMagic)]) != protocolMagic {
+		return h, ErrBadMagic
+	}
+	d := &decoder{buf: payload[len(protocolMagic):]}
+	h.min, h.max = int(d.uint()), int(d.uint())
+	// the hello of the server carries no size
+	if w, ht := d.uint(), d.uint(); w != 0 || ht != 0 {
+		if !validSize(w, ht) {
+			return h, ErrMalformed
+		}
+		h.w, h.h = int(w), int(ht)
+	}
+	return h, d.err
+}
+
+// validSize returns true if the screen size is within 1 and MaxScreenSize in
+// both dimensions
+func validSize(w, h uint64) bool {
+	return w > 0 && h > 0 && w <= MaxScreenSize && h <= MaxScreenSize
+}
+
+// negotiate returns the newest version within both the local and the remote
+// range of versions
+func negotiate(min, max int) (version int, err error) {
+	if max > ProtocolVersion {
+		max = ProtocolVersion
+	}
+	if min < MinProtocolVersion {
+		min = MinProtocolVersion
+	}
+	if min > max {
+		return 0, fmt.Errorf("%w: peer supports %d to %d", ErrVersionMismatch, min, max)
+	}
+	return max, nil
+}
+
+func encodeFrame(f frame) []byte {
+	e := &encoder{}
+	e.uint(uint64(f.w))
+	e.uint(uint64(f.h))
+	var flags uint8
+	if f.full {
+		flags |= frameFull
+	}
+	if f.cursor {
+		flags |= frameCursor
+	}
+	e.byte(flags)
+	e.int(int64(f.cx))
+	e.int(int64(f.cy))
+	e.uint(uint64(len(f.spans)))
+	for _, s := range f.spans {
+		e.uint(uint64(s.x))
+		e.uint(uint64(s.y))
+		e.uint(uint64(len(s.cells)))
+		for _, c := range s.cells {
+			e.uint(uint64(c.mainc))
+			e.uint(uint64(len(c.combc)))
+			for _, r := range c.combc {
+				e.uint(uint64(r))
+			}
+			fg, bg, attrs := c.style.Decompose()
+			e.uint(uint64(fg))
+			e.uint(uint64(bg))
+			e.uint(uint64(attrs))
+		}
+	}
+	return e.buf
+}
+
+func decodeFrame(payload []byte) (f frame, err error) {
+	d := &decoder{buf: payload}
+	f.w, f.h = int(d.uint()), int(d.uint())
+	flags := d.byte()
+	f.full, f.cursor = flags&frameFull != 0, flags&frameCursor != 0
+	f.cx, f.cy = int(d.int()), int(d.int())
+	spans := d.count()
+	for i := 0; i < spans && d.err == nil; i++ {
+		s := span{x: int(d.uint()), y: int(d.uint())}
+		cells := d.count()
+		for j := 0; j < cells && d.err == nil; j++ {
+			c := cell{mainc: rune(d.uint())}
+			if combining := d.count(); combining > 0 {
+				c.combc = make([]rune, 0, combining)
+				for k := 0; k < combining; k++ {
+					c.combc = append(c.combc, rune(d.uint()))
+				}
+			}
+			fg, bg := paint.Color(d.uint()), paint.Color(d.uint())
+			attrs := paint.AttrMask(d.uint())
+			c.style = paint.StyleDefault.Foreground(fg).Background(bg).Attributes(attrs)
+			s.cells = append(s.cells, c)
+		}
+		f.spans = append(f.spans, s)
+	}
+	return f, d.err
+}
+
+// encodeEvent returns the payload for the input events sent by the client,
+// all other events are not sent
+func encodeEvent(ev cdk.Event) (payload []byte, ok bool) {
+	e := &encoder{}
+	switch t := ev.(type) {
+	case *cdk.EventKey:
+		e.byte(uint8(evKey))
+		e.int(int64(t.Key()))
+		e.int(int64(t.Rune()))
+		e.int(int64(t.Modifiers()))
+	case *cdk.EventMouse:
+		x, y := t.Position()
+		e.byte(uint8(evMouse))
+		e.int(int64(x))
+		e.int(int64(y))
+		e.int(int64(t.Buttons()))
+		e.int(int64(t.Modifiers()))
+	case *cdk.EventResize:
+		w, h := t.Size()
+		e.byte(uint8(evResize))
+		e.uint(uint64(w))
+		e.uint(uint64(h))
+	case *cdk.EventPaste:
+		e.byte(uint8(evPaste))
+		if t.Start() {
+			e.byte(1)
+		} else {
+			e.byte(0)
+		}
+	default:
+		return nil, false
+	}
+	return e.buf, true
+}
+
+func decodeEvent(payload []byte) (ev cdk.Event, err error) {
+	d := &decoder{buf: payload}
+	switch eventType(d.byte()) {
+	case evKey:
+		k, r, mod := cdk.Key(d.int()), rune(d.int()), cdk.ModMask(d.int())
+		ev = cdk.NewEventKey(k, r, mod)
+	case evMouse:
+		x, y := int(d.int()), int(d.int())
+		buttons, mod := cdk.ButtonMask(d.int()), cdk.ModMask(d.int())
+		ev = cdk.NewEventMouse(x, y, buttons, mod)
+	case evResize:
+		w, h := d.uint(), d.uint()
+		if d.err == nil && !validSize(w, h) {
+			return nil, ErrMalformed
+		}
+		ev = cdk.NewEventResize(int(w), int(h))
+	case evPaste:
+		ev = cdk.NewEventPaste(d.byte() != 0)
+	default:
+		return nil, ErrMalformed
+	}
+	if d.err != nil {
+		return nil, d.err
+	}
+	return ev, nil
+}
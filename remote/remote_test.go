@@ -0,0 +1,266 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+func testConnPair(t *testing.T) (server, client net.Conn) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		conn, _ := l.Accept()
+		accepted <- conn
+	}()
+	if client, err = net.Dial("tcp", l.Addr().String()); err != nil {
+		t.Fatal(err)
+	}
+	server = <-accepted
+	return
+}
+
+func waitFor(check func() bool) bool {
+	deadline := time.Now().Add(2 * time.Second)
+	for time.Now().Before(deadline) {
+		if check() {
+			return true
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	return false
+}
+
+func encodeResize(w, h int) []byte {
+	payload, _ := encodeEvent(cdk.NewEventResize(w, h))
+	return payload
+}
+
+func TestRemote(t *testing.T) {
+	Convey("Remote protocol", t, func() {
+		Convey("frames survive encoding", func() {
+			style := paint.StyleDefault.Foreground(paint.ColorRed).Background(paint.ColorBlue).Attributes(paint.AttrBold)
+			f := frame{w: 10, h: 2, full: true, cursor: true, cx: 3, cy: 1, spans: []span{
+				{x: 2, y: 1, cells: []cell{{mainc: 'a', style: style}, {mainc: 'e', combc: []rune{'́'}, style: style}}},
+			}}
+			decoded, err := decodeFrame(encodeFrame(f))
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, f)
+			_, err = decodeFrame(encodeFrame(f)[:7])
+			So(err, ShouldEqual, ErrMalformed)
+		})
+		Convey("events survive encoding", func() {
+			for _, ev := range []cdk.Event{
+				cdk.NewEventKey(cdk.KeyRune, 'q', cdk.ModAlt),
+				cdk.NewEventMouse(4, 2, cdk.Button1, cdk.ModShift),
+				cdk.NewEventResize(100, 40),
+				cdk.NewEventPaste(true),
+			} {
+				payload, ok := encodeEvent(ev)
+				So(ok, ShouldBeTrue)
+				decoded, err := decodeEvent(payload)
+				So(err, ShouldBeNil)
+				again, _ := encodeEvent(decoded)
+				So(again, ShouldResemble, payload)
+			}
+			_, ok := encodeEvent(cdk.NewEventQuit())
+			So(ok, ShouldBeFalse)
+		})
+		Convey("a display renders on a client", func() {
+			serverConn, clientConn := testConnPair(t)
+			local, err := cdk.MakeOffScreen("UTF-8")
+			So(err, ShouldBeNil)
+			local.SetSize(20, 5)
+			client := NewClient(clientConn, local)
+			done := make(chan error, 1)
+			go func() { done <- client.Run(context.Background()) }()
+
+			screen := NewRemoteScreen(serverConn)
+			d := cdk.NewDisplayWithScreen("remote", screen)
+			So(d.CaptureDisplay(), ShouldBeNil)
+			So(d.Screen(), ShouldEqual, screen)
+			So(screen.Version(), ShouldEqual, ProtocolVersion)
+			So(client.Version(), ShouldEqual, ProtocolVersion)
+			w, h := screen.Size()
+			So(w, ShouldEqual, 20)
+			So(h, ShouldEqual, 5)
+
+			style := paint.StyleDefault.Foreground(paint.ColorYellow)
+			screen.SetContent(1, 2, 'H', nil, style)
+			screen.SetContent(2, 2, 'i', nil, style)
+			screen.ShowCursor(3, 2)
+			screen.Show()
+			So(waitFor(func() bool {
+				mc, _, st, _ := local.GetContent(2, 2)
+				return mc == 'i' && st == style
+			}), ShouldBeTrue)
+			So(waitFor(func() bool {
+				x, y, visible := local.GetCursor()
+				return x == 3 && y == 2 && visible
+			}), ShouldBeTrue)
+
+			screen.SetContent(1, 2, 'J', nil, style)
+			screen.Show()
+			So(waitFor(func() bool {
+				mc, _, _, _ := local.GetContent(1, 2)
+				return mc == 'J'
+			}), ShouldBeTrue)
+
+			local.InjectKey(cdk.KeyRune, 'a', cdk.ModNone)
+			var key *cdk.EventKey
+			timeout := time.After(2 * time.Second)
+			for key == nil {
+				select {
+				case ev := <-screen.PollEventChan():
+					key, _ = ev.(*cdk.EventKey)
+				case <-timeout:
+					t.Fatal("key event not received")
+				}
+			}
+			So(key.Rune(), ShouldEqual, 'a')
+
+			local.PostEvent(cdk.NewEventResize(30, 8))
+			So(waitFor(func() bool {
+				w, h := screen.Size()
+				return w == 30 && h == 8
+			}), ShouldBeTrue)
+
+			screen.Close()
+			select {
+			case err = <-done:
+				So(err, ShouldBeNil)
+			case <-time.After(2 * time.Second):
+				t.Fatal("client did not stop")
+			}
+		})
+		Convey("clients reject unsupported versions", func() {
+			serverConn, clientConn := testConnPair(t)
+			defer func() { _ = serverConn.Close() }()
+			local, _ := cdk.MakeOffScreen("UTF-8")
+			done := make(chan error, 1)
+			go func() { done <- NewClient(clientConn, local).Run(context.Background()) }()
+			So(writeMessage(serverConn, msgHello, encodeHello(hello{min: 99, max: 100})), ShouldBeNil)
+			mt, payload, err := readMessage(bufio.NewReader(serverConn))
+			So(err, ShouldBeNil)
+			So(mt, ShouldEqual, msgBye)
+			So(string(payload), ShouldContainSubstring, "no common protocol version")
+			So(errors.Is(<-done, ErrVersionMismatch), ShouldBeTrue)
+		})
+		Convey("servers reject unsupported versions", func() {
+			serverConn, clientConn := testConnPair(t)
+			defer func() { _ = clientConn.Close() }()
+			screen := NewRemoteScreen(serverConn)
+			done := make(chan error, 1)
+			go func() { done <- screen.Init() }()
+			r := bufio.NewReader(clientConn)
+			mt, _, err := readMessage(r)
+			So(err, ShouldBeNil)
+			So(mt, ShouldEqual, msgHello)
+			So(writeMessage(clientConn, msgHello, encodeHello(hello{min: 99, max: 99, w: 10, h: 10})), ShouldBeNil)
+			So(errors.Is(<-done, ErrVersionMismatch), ShouldBeTrue)
+		})
+		Convey("servers reject out of range sizes", func() {
+			serverConn, clientConn := testConnPair(t)
+			defer func() { _ = clientConn.Close() }()
+			screen := NewRemoteScreen(serverConn)
+			done := make(chan error, 1)
+			go func() { done <- screen.Init() }()
+			r := bufio.NewReader(clientConn)
+			_, _, err := readMessage(r)
+			So(err, ShouldBeNil)
+			So(writeMessage(clientConn, msgHello, encodeHello(hello{min: 1, max: 1, w: 1 << 31, h: 1 << 31})), ShouldBeNil)
+			So(errors.Is(<-done, ErrMalformed), ShouldBeTrue)
+
+			serverConn, clientConn = testConnPair(t)
+			defer func() { _ = clientConn.Close() }()
+			screen = NewRemoteScreen(serverConn)
+			go func() { done <- screen.Init() }()
+			r = bufio.NewReader(clientConn)
+			_, _, err = readMessage(r)
+			So(err, ShouldBeNil)
+			So(writeMessage(clientConn, msgHello, encodeHello(hello{min: 1, max: 1, w: 10, h: 10})), ShouldBeNil)
+			So(<-done, ShouldBeNil)
+			e := &encoder{}
+			e.byte(uint8(evResize))
+			e.uint(1<<64 - 1)
+			e.uint(10)
+			So(writeMessage(clientConn, msgEvent, e.buf), ShouldBeNil)
+			var quit bool
+			deadline := time.After(2 * time.Second)
+			for !quit {
+				select {
+				case ev := <-screen.PollEventChan():
+					_, isResize := ev.(*cdk.EventResize)
+					So(isResize, ShouldBeFalse)
+					_, quit = ev.(*cdk.EventQuit)
+				case <-deadline:
+					t.Fatal("malformed resize did not close the connection")
+				}
+			}
+			w, h := screen.Size()
+			So(w, ShouldEqual, 10)
+			So(h, ShouldEqual, 10)
+			_, err = decodeEvent(encodeResize(MaxScreenSize+1, 10))
+			So(err, ShouldEqual, ErrMalformed)
+			_, err = decodeEvent(encodeResize(0, 10))
+			So(err, ShouldEqual, ErrMalformed)
+			ev, err := decodeEvent(encodeResize(MaxScreenSize, MaxScreenSize))
+			So(err, ShouldBeNil)
+			w, h = ev.(*cdk.EventResize).Size()
+			So(w, ShouldEqual, MaxScreenSize)
+			So(h, ShouldEqual, MaxScreenSize)
+		})
+		Convey("silent clients time out", func() {
+			interval, timeout := KeepaliveInterval, KeepaliveTimeout
+			KeepaliveInterval, KeepaliveTimeout = 10*time.Millisecond, 50*time.Millisecond
+			defer func() { KeepaliveInterval, KeepaliveTimeout = interval, timeout }()
+			serverConn, clientConn := testConnPair(t)
+			defer func() { _ = clientConn.Close() }()
+			screen := NewRemoteScreen(serverConn)
+			done := make(chan error, 1)
+			go func() { done <- screen.Init() }()
+			r := bufio.NewReader(clientConn)
+			_, _, err := readMessage(r)
+			So(err, ShouldBeNil)
+			So(writeMessage(clientConn, msgHello, encodeHello(hello{min: 1, max: 1, w: 10, h: 10})), ShouldBeNil)
+			So(<-done, ShouldBeNil)
+			var quit bool
+			deadline := time.After(2 * time.Second)
+			for !quit {
+				select {
+				case ev := <-screen.PollEventChan():
+					_, quit = ev.(*cdk.EventQuit)
+				case <-deadline:
+					t.Fatal("keepalive did not time out")
+				}
+			}
+			So(quit, ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,230 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package remote
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"time"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// RemoteScreen is a cdk.Screen which renders to a thin client over a
+// net.Conn. Drawing happens on an embedded cdk.OffScreen and each Show sends
+// the cells which changed since the previous frame to the client. Input events
+// from the client are delivered through the usual event channel and the
+// screen is resized to the size of the client.
+type RemoteScreen interface {
+	cdk.OffScreen
+
+	// Version returns the protocol version negotiated with the client
+	Version() int
+
+	// RemoteAddr returns the address of the client
+	RemoteAddr() net.Addr
+}
+
+var _ RemoteScreen = (*CRemoteScreen)(nil)
+
+type CRemoteScreen struct {
+	cdk.OffScreen
+
+	peer    *peer
+	version int
+	sent    []cell
+	sentW   int
+	sentH   int
+	cursor  [3]int
+	lock    sync.Mutex
+}
+
+// NewRemoteScreen returns a RemoteScreen for the client on the other end of
+// the given connection. The protocol is negotiated by Init, which is called by
+// the Display when it is captured, see: cdk.NewDisplayWithScreen
+func NewRemoteScreen(conn net.Conn) RemoteScreen {
+	return &CRemoteScreen{
+		OffScreen: cdk.NewOffScreen("UTF-8"),
+		peer:      newPeer(conn),
+	}
+}
+
+func (s *CRemoteScreen) Version() int {
+	return s.version
+}
+
+func (s *CRemoteScreen) RemoteAddr() net.Addr {
+	return s.peer.conn.RemoteAddr()
+}
+
+func (s *CRemoteScreen) InitWithFilePath(_ string) error {
+	return s.Init()
+}
+
+func (s *CRemoteScreen) InitWithFileHandle(_ *os.File) error {
+	return s.Init()
+}
+
+// Init negotiates the protocol version with the client, sizes the screen to
+// that of the client and starts receiving events
+func (s *CRemoteScreen) Init() (err error) {
+	if err = s.OffScreen.Init(); err != nil {
+		return
+	}
+	if err = s.negotiate(); err != nil {
+		s.peer.close("")
+		return
+	}
+	go s.receiver()
+	go s.peer.keepalive()
+	return
+}
+
+func (s *CRemoteScreen) negotiate() (err error) {
+	if err = s.peer.send(msgHello, encodeHello(hello{min: MinProtocolVersion, max: ProtocolVersion})); err != nil {
+		return
+	}
+	_ = s.peer.conn.SetReadDeadline(time.Now().Add(NegotiationTimeout))
+	var t messageType
+	var payload []byte
+	if t, payload, err = readMessage(s.peer.r); err != nil {
+		return
+	}
+	switch t {
+	case msgHello:
+	case msgBye:
+		d := &decoder{buf: payload}
+		return fmt.Errorf("%w: %v", ErrClosed, d.string())
+	default:
+		return ErrMalformed
+	}
+	var h hello
+	if h, err = decodeHello(payload); err != nil {
+		return
+	}
+	if h.min != h.max || h.max < MinProtocolVersion || h.max > ProtocolVersion {
+		s.peer.close(ErrVersionMismatch.Error())
+		return fmt.Errorf("%w: client chose %d", ErrVersionMismatch, h.max)
+	}
+	s.version = h.max
+	if h.w > 0 && h.h > 0 {
+		s.OffScreen.SetSize(h.w, h.h)
+	}
+	return
+}
+
+// receiver delivers the events from the client until the connection is
+// closed, after which the Display is asked to quit
+func (s *CRemoteScreen) receiver() {
+	defer func() {
+		s.peer.close("")
+		_ = s.OffScreen.PostEvent(cdk.NewEventQuit())
+	}()
+	for {
+		t, payload, err := s.peer.receive()
+		if err != nil {
+			return
+		}
+		switch t {
+		case msgEvent:
+			ev, err := decodeEvent(payload)
+			if err != nil {
+				return
+			}
+			if resize, ok := ev.(*cdk.EventResize); ok {
+				w, h := resize.Size()
+				s.OffScreen.SetSize(w, h)
+			}
+			select {
+			case s.OffScreen.PollEventChan() <- ev:
+			case <-s.peer.done:
+				return
+			}
+		case msgBye:
+			return
+		}
+	}
+}
+
+// Show draws the screen and sends the cells which changed to the client
+func (s *CRemoteScreen) Show() {
+	s.OffScreen.Show()
+	s.sendFrame(false)
+}
+
+// Sync draws the screen and sends every cell to the client
+func (s *CRemoteScreen) Sync() {
+	s.OffScreen.Sync()
+	s.sendFrame(true)
+}
+
+func (s *CRemoteScreen) sendFrame(full bool) {
+	if s.peer.closed() {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	w, h := s.OffScreen.Size()
+	if full || s.sent == nil || w != s.sentW || h != s.sentH {
+		full = true
+		s.sent = make([]cell, w*h)
+		s.sentW, s.sentH = w, h
+	}
+	f := frame{w: w, h: h, full: full}
+	for y := 0; y < h; y++ {
+		var run *span
+		for x := 0; x < w; x++ {
+			var c cell
+			c.mainc, c.combc, c.style, _ = s.OffScreen.GetContent(x, y)
+			idx := (y * w) + x
+			if !full && c.equal(s.sent[idx]) {
+				run = nil
+				continue
+			}
+			s.sent[idx] = c
+			if run == nil {
+				f.spans = append(f.spans, span{x: x, y: y})
+				run = &f.spans[len(f.spans)-1]
+			}
+			run.cells = append(run.cells, c)
+		}
+	}
+	cx, cy, visible := s.OffScreen.GetCursor()
+	cursor := [3]int{cx, cy, 0}
+	if visible {
+		cursor[2] = 1
+	}
+	if !full && len(f.spans) == 0 && cursor == s.cursor {
+		return
+	}
+	s.cursor = cursor
+	f.cx, f.cy, f.cursor = cx, cy, visible
+	if err := s.peer.send(msgFrame, encodeFrame(f)); err != nil {
+		s.peer.close("")
+	}
+}
+
+// Beep asks the client to sound its bell
+func (s *CRemoteScreen) Beep() error {
+	return s.peer.send(msgBeep, nil)
+}
+
+// Close says goodbye to the client and closes the connection
+func (s *CRemoteScreen) Close() {
+	s.peer.close("screen closed")
+	s.OffScreen.Close()
+}
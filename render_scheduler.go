@@ -0,0 +1,158 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+var (
+	// DefaultFrameRate is the maximum number of frames per second rendered by
+	// a new Display, zero for unlimited
+	DefaultFrameRate = 60
+	// DefaultRenderWorkers is the number of goroutines compositing windows
+	// for a new Display, see: Display.SetRenderWorkers
+	DefaultRenderWorkers = 1
+)
+
+// FrameStats describes the rendering performance of a Display
+type FrameStats struct {
+	// Frames is the number of frames rendered
+	Frames uint64
+	// Dropped is the number of render requests coalesced into later frames
+	Dropped uint64
+	// LastFrame is how long the most recent frame took to render
+	LastFrame time.Duration
+	// AverageFrame is the mean time taken to render a frame
+	AverageFrame time.Duration
+}
+
+func (s FrameStats) String() string {
+	return fmt.Sprintf("{frames=%d,dropped=%d,last=%v,average=%v}", s.Frames, s.Dropped, s.LastFrame, s.AverageFrame)
+}
+
+// cRenderScheduler limits the rate at which EventRender requests are acted
+// upon. Requests arriving sooner than the frame interval are merged into a
+// single pending request, which is posted once the interval has elapsed.
+type cRenderScheduler struct {
+	interval time.Duration
+	last     time.Time
+	pending  *EventRender
+	timer    *time.Timer
+	stats    FrameStats
+	total    time.Duration
+
+	sync.Mutex
+}
+
+func newRenderScheduler(fps int) (s *cRenderScheduler) {
+	s = &cRenderScheduler{}
+	s.setFrameRate(fps)
+	return
+}
+
+func (s *cRenderScheduler) setFrameRate(fps int) {
+	s.Lock()
+	defer s.Unlock()
+	if fps > 0 {
+		s.interval = time.Second / time.Duration(fps)
+	} else {
+		s.interval = 0
+	}
+}
+
+func (s *cRenderScheduler) frameRate() (fps int) {
+	s.Lock()
+	defer s.Unlock()
+	if s.interval > 0 {
+		fps = int(time.Second / s.interval)
+	}
+	return
+}
+
+// schedule returns the request to render now, merged with any pending one, or
+// nil if the request has been deferred until the next frame, in which case the
+// post function is called with the merged request when the frame is due.
+// Forced requests are never deferred.
+func (s *cRenderScheduler) schedule(req *EventRender, now time.Time, post func(req *EventRender)) (render *EventRender) {
+	s.Lock()
+	defer s.Unlock()
+	render = mergeEventRender(s.pending, req)
+	since := now.Sub(s.last)
+	if s.interval <= 0 || since >= s.interval || render.Request().Has(renderForce) {
+		s.pending = nil
+		if s.timer != nil {
+			s.timer.Stop()
+			s.timer = nil
+		}
+		s.last = now
+		return
+	}
+	if s.pending != nil {
+		s.stats.Dropped++
+	}
+	s.pending = render
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.interval-since, func() {
+			s.Lock()
+			pending := s.pending
+			s.pending = nil
+			s.timer = nil
+			s.Unlock()
+			if pending != nil {
+				post(pending)
+			}
+		})
+	}
+	return nil
+}
+
+// frameDone records the time taken to render a frame
+func (s *cRenderScheduler) frameDone(duration time.Duration) {
+	s.Lock()
+	defer s.Unlock()
+	s.stats.Frames++
+	s.stats.LastFrame = duration
+	s.total += duration
+	s.stats.AverageFrame = s.total / time.Duration(s.stats.Frames)
+}
+
+func (s *cRenderScheduler) getStats() (stats FrameStats) {
+	s.Lock()
+	defer s.Unlock()
+	return s.stats
+}
+
+// stop discards any pending request
+func (s *cRenderScheduler) stop() {
+	s.Lock()
+	defer s.Unlock()
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.pending = nil
+}
+
+// mergeEventRender combines two render requests, either of which may be nil
+func mergeEventRender(a, b *EventRender) *EventRender {
+	if a == nil {
+		return b
+	}
+	return a.Merge(b)
+}
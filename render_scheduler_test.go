@@ -0,0 +1,84 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRenderScheduler(t *testing.T) {
+	Convey("Render scheduler", t, func() {
+		Convey("merging requests", func() {
+			So(mergeEventRender(nil, nil), ShouldBeNil)
+			draw := NewEventDraw()
+			So(mergeEventRender(nil, draw), ShouldEqual, draw)
+			So(mergeEventRender(draw, nil), ShouldEqual, draw)
+			merged := mergeEventRender(draw, NewEventShow())
+			So(merged.Draw(), ShouldBeTrue)
+			So(merged.Show(), ShouldBeTrue)
+			So(merged.Sync(), ShouldBeFalse)
+			merged = mergeEventRender(merged, NewEventSync())
+			So(merged.Show(), ShouldBeFalse)
+			So(merged.Sync(), ShouldBeTrue)
+		})
+		Convey("unlimited frame rate", func() {
+			s := newRenderScheduler(0)
+			So(s.frameRate(), ShouldEqual, 0)
+			now := time.Now()
+			So(s.schedule(NewEventDraw(), now, nil), ShouldNotBeNil)
+			So(s.schedule(NewEventDraw(), now, nil), ShouldNotBeNil)
+		})
+		Convey("coalescing requests", func() {
+			s := newRenderScheduler(20)
+			So(s.frameRate(), ShouldEqual, 20)
+			posted := make(chan *EventRender, 1)
+			post := func(req *EventRender) { posted <- req }
+			now := time.Now()
+			So(s.schedule(NewEventDraw(), now, post), ShouldNotBeNil)
+			s.frameDone(time.Millisecond * 2)
+			So(s.schedule(NewEventDraw(), now.Add(time.Millisecond), post), ShouldBeNil)
+			So(s.schedule(NewEventShow(), now.Add(time.Millisecond*2), post), ShouldBeNil)
+			var req *EventRender
+			select {
+			case req = <-posted:
+			case <-time.After(time.Second):
+			}
+			So(req, ShouldNotBeNil)
+			So(req.Draw(), ShouldBeTrue)
+			So(req.Show(), ShouldBeTrue)
+			So(s.schedule(req, now.Add(time.Millisecond*50), post), ShouldEqual, req)
+			s.frameDone(time.Millisecond * 4)
+			stats := s.getStats()
+			So(stats.Frames, ShouldEqual, 2)
+			So(stats.Dropped, ShouldEqual, 1)
+			So(stats.LastFrame, ShouldEqual, time.Millisecond*4)
+			So(stats.AverageFrame, ShouldEqual, time.Millisecond*3)
+			s.stop()
+		})
+		Convey("forced requests", func() {
+			s := newRenderScheduler(20)
+			now := time.Now()
+			So(s.schedule(NewEventDraw(), now, nil), ShouldNotBeNil)
+			So(s.schedule(NewEventShow(), now.Add(time.Millisecond), func(*EventRender) {}), ShouldBeNil)
+			req := s.schedule(NewEventRenderRequest(RenderSync|renderForce), now.Add(time.Millisecond*2), nil)
+			So(req, ShouldNotBeNil)
+			So(req.Request(), ShouldEqual, RenderSync|renderForce)
+			s.stop()
+		})
+	})
+}
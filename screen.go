@@ -17,13 +17,14 @@ package cdk
 
 import (
 	"bytes"
-	"encoding/base64"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 	"unicode/utf8"
 
@@ -125,9 +126,25 @@ type Screen interface {
 
 	// PostEvent tries to post an event into the event stream.  This
 	// can fail if the event queue is full.  In that case, the event
-	// is dropped, and ErrEventQFull is returned.
+	// overflow policy is applied and ErrEventQFull is returned if the
+	// event was dropped.
 	PostEvent(ev Event) error
 
+	// SetEventOverflowPolicy changes how PostEvent handles a full event
+	// queue, the default is to drop the event being posted.
+	SetEventOverflowPolicy(policy EventOverflowPolicy)
+
+	// GetEventOverflowPolicy returns the current event overflow policy.
+	GetEventOverflowPolicy() (policy EventOverflowPolicy)
+
+	// SetEventOverflowHandler sets the function called with each event
+	// discarded due to the event queue being full, nil to unset.
+	SetEventOverflowHandler(fn EventOverflowFn)
+
+	// DroppedEvents returns the number of events discarded due to the event
+	// queue being full.
+	DroppedEvents() (dropped uint64)
+
 	// EnableMouse enables the mouse.  (If your terminal supports it.)
 	// If no flags are specified, then all events are reported, if the
 	// terminal supports them.
@@ -142,6 +159,90 @@ type Screen interface {
 	// DisablePaste disables bracketed paste mode.
 	DisablePaste()
 
+	// EnableKeyPhases enables the reporting of key repeat and key release
+	// events, if supported by the terminal.
+	EnableKeyPhases()
+
+	// DisableKeyPhases disables the reporting of key repeat and key release
+	// events.
+	DisableKeyPhases()
+
+	// Modes returns the registry of terminal modes currently enabled.
+	Modes() (modes ScreenModes)
+
+	// ApplyModes enables or disables the terminal modes to match the given
+	// registry, see: ScreenModes.
+	ApplyModes(modes ScreenModes)
+
+	// SetInputMethodArea reports the screen area of the text being composed
+	// so that terminal input method popups can be placed near it. While the
+	// cursor is hidden, the terminal cursor is parked at the top-left of the
+	// area, which most terminals use for IME placement.
+	SetInputMethodArea(x, y, w, h int)
+
+	// ClearInputMethodArea stops reporting an input method area.
+	ClearInputMethodArea()
+
+	// SetKeyTiming changes how long to wait for the remainder of an escape
+	// sequence, zero restores the EventKeyTiming default.
+	SetKeyTiming(timing time.Duration)
+
+	// SetEscapeDelay changes how long a lone ESC waits for the remainder of
+	// an escape sequence before it is delivered as KeyEsc, zero delivers it
+	// immediately and a negative delay restores using the key timing. A
+	// short delay makes the escape key responsive, at the cost of breaking
+	// up sequences delayed by slow connections.
+	SetEscapeDelay(delay time.Duration)
+
+	// SetRawInput enables or disables the raw input mode. While enabled the
+	// key and mouse parsers are bypassed and everything read from the
+	// terminal is delivered as EventRaw, for applications interpreting the
+	// input themselves. Input buffered when enabling is parsed as usual.
+	SetRawInput(enabled bool)
+
+	// RawInput returns true if the raw input mode is enabled.
+	RawInput() (enabled bool)
+
+	// SetTrueColor enables or disables the use of 24-bit colors, if the
+	// terminal supports them.
+	SetTrueColor(enabled bool)
+
+	// SetColorDownsampling changes how 24-bit colors are matched to the
+	// terminal palette when true color is unavailable or disabled.
+	SetColorDownsampling(policy paint.ColorDownsampling)
+
+	// SetOptimization changes the techniques used to reduce the number of
+	// bytes written to the terminal, see: ScreenOptimization.
+	SetOptimization(optimization ScreenOptimization)
+
+	// SetCursorIndicator changes how the position given to ShowCursor is
+	// shown, see: CursorIndicator.
+	SetCursorIndicator(indicator CursorIndicator, color paint.Color)
+
+	// SetBlinkMode changes how cells with the blink attribute are shown and
+	// how often cells blinking in software are hidden or shown, see:
+	// BlinkMode.
+	SetBlinkMode(mode BlinkMode, rate time.Duration)
+
+	// TeeOutput duplicates everything written to the terminal to the given
+	// writer, nil stops the duplication, see: CAsciicastRecorder.
+	TeeOutput(w io.Writer)
+
+	// Detached returns true if the terminal has gone away, see: EventDetach.
+	Detached() bool
+
+	// Detach leaves and closes the terminal, keeping the content of the
+	// Screen until Reattach gives it a terminal again.
+	Detach() (err error)
+
+	// Reattach gives the Screen the terminal at the given path, or the
+	// terminal last used when the path is empty.
+	Reattach(ttyPath string) (err error)
+
+	// GetDrawStats returns the number of cells and bytes written to the
+	// terminal by the most recent Show or Sync.
+	GetDrawStats() (cells, bytes int)
+
 	// HasMouse returns true if the terminal (apparently) supports a
 	// mouse.  Note that the a return value of true doesn't guarantee that
 	// a mouse/pointing device is present; a false return definitely
@@ -234,6 +335,11 @@ type Screen interface {
 	// when unsuccessful.
 	Beep() error
 
+	// VisualBell flashes the screen in reverse video for the given duration,
+	// zero uses the VisualBellDuration. Flashing again while a flash is in
+	// progress extends it.
+	VisualBell(duration time.Duration) error
+
 	Export() *CellBuffer
 	Import(cb *CellBuffer)
 
@@ -242,8 +348,38 @@ type Screen interface {
 	PasteFromClipboard() (s string, ok bool)
 	EnableHostClipboard(enabled bool)
 	EnableTermClipboard(enabled bool)
+	RequestClipboard() (requested bool)
+	TermClipboardReadable() (readable bool)
+
+	// CopyToPrimary sets the primary selection, used for middle-click
+	// pasting, with xclip, xsel or wl-copy when the host clipboard is enabled
+	// and otherwise with OSC 52.
+	CopyToPrimary(s string)
+	// PasteFromPrimary returns the content of the host primary selection,
+	// ok is false if the host clipboard is not enabled or not available.
+	PasteFromPrimary() (s string, ok bool)
+	// RequestPrimary asks the terminal to report the content of the primary
+	// selection using OSC 52, see: RequestClipboard.
+	RequestPrimary() (requested bool)
+
+	// Notify sends a desktop notification with the given title and body, if
+	// supported by the terminal, see: NotifyProtocol.
+	Notify(title, body string) (err error)
+
+	// Capabilities returns what the terminal is known to support, updated
+	// as the terminal answers the queries sent by Init. An EventCapabilities
+	// is delivered once all of the answers have been received.
+	Capabilities() (capabilities Capabilities)
 }
 
+var (
+	// InputMethodAreaSequence is an optional fmt template, given the x, y,
+	// width and height of the input method area, written to the terminal by
+	// SetInputMethodArea. This is empty by default as there is no widely
+	// supported standard sequence.
+	InputMethodAreaSequence = ""
+)
+
 var (
 	EventQueueSize    = 1024
 	EventKeyQueueSize = 1024
@@ -251,6 +387,16 @@ var (
 	SignalQueueSize   = 100
 )
 
+// EscapeDisambiguation enables the kitty keyboard protocol escape code
+// disambiguation for terminals reporting support for it, see: Capabilities.
+// The escape key is then sent as an escape sequence of its own, and so is
+// delivered without waiting for the EventKeyTiming.
+var EscapeDisambiguation = true
+
+// VisualBellDuration is how long Screen.VisualBell flashes the screen when
+// given a zero duration
+var VisualBellDuration = time.Millisecond * 150
+
 // NewScreen returns a Screen that uses the stock TTY interface
 // and POSIX terminal control, combined with a terminfo description taken from
 // the $TERM environment variable.  It returns an error if the terminal
@@ -260,19 +406,68 @@ var (
 // $COLUMNS environment variables can be set to the actual window size,
 // otherwise defaults taken from the terminal database are used.
 func NewScreen() (Screen, error) {
-	ti, e := terminfo.LookupTerminfo(os.Getenv("TERM"))
+	return NewScreenWithEnv(os.LookupEnv)
+}
+
+// WindowsConsoleTerm is the terminal type of the Windows console when TERM is
+// not set, see: NewScreenWithEnv
+const WindowsConsoleTerm = "xterm-256color"
+
+// NewScreenWithEnv is the same as NewScreen, except that the environment
+// variables are found with the given lookup function instead of from the
+// process environment. This is how remote sessions use the $TERM, $COLORTERM
+// and $LANG of the client terminal rather than those of the server.
+func NewScreenWithEnv(lookup func(key string) (value string, ok bool)) (Screen, error) {
+	getenv := func(key string) (value string) {
+		value, _ = lookup(key)
+		return
+	}
+	termName, colorTerm := getenv("TERM"), getenv("COLORTERM")
+	if termName == "" && runtime.GOOS == "windows" {
+		// the console emulates an xterm with direct color once switched to
+		// virtual terminal processing, see: screen_windows.go
+		termName, colorTerm = WindowsConsoleTerm, "truecolor"
+	}
+	ti, e := terminfo.LookupTerminfo(termName)
 	if e != nil {
-		ti, e = loadDynamicTerminfo(os.Getenv("TERM"))
+		ti, e = loadDynamicTerminfo(termName)
 		if e != nil {
 			return nil, e
 		}
 		terminfo.AddTerminfo(ti)
 	}
+	multiplexer := DetectMultiplexer(getenv)
+	quirks, _ := DetectTerminalQuirks(getenv)
+	directColor := quirks.TrueColor
+	switch colorTerm {
+	case "truecolor", "24bit":
+		directColor = true
+	}
+	hasDirectColor := ti.SetFgBgRGB != "" || ti.SetFgRGB != "" || ti.SetBgRGB != ""
+	if quirks.NoTrueColor || multiplexer == MultiplexerScreen {
+		// the terminal, or GNU screen, has no direct color whatever the
+		// terminfo entry or environment says
+		if hasDirectColor {
+			palette := *ti
+			palette.SetFgRGB, palette.SetBgRGB, palette.SetFgBgRGB = "", "", ""
+			ti = &palette
+		}
+	} else if directColor && !hasDirectColor {
+		// the terminal supports direct color, even if the terminfo entry
+		// does not say so
+		ti = withDirectColor(ti)
+	}
 	t := &CScreen{
 		ti:          ti,
 		ttyPath:     "/dev/tty",
 		ttyReadLock: &sync.Mutex{},
 		ttyType:     cterm.InvalidTermType,
+		optimize:    DefaultScreenOptimization,
+		lookupEnv:   lookup,
+		multiplexer: multiplexer,
+		quirks:      quirks,
+		notify:      detectNotifyProtocol(getenv, quirks),
+		blinkRate:   DefaultBlinkRate,
 	}
 
 	t.keyExist = make(map[Key]bool)
@@ -283,6 +478,8 @@ func NewScreen() (Screen, error) {
 	t.prepareKeys()
 	t.buildAcsMap()
 	t.sigWinch = make(chan os.Signal, SignalQueueSize)
+	t.sigHup = make(chan os.Signal, 1)
+	t.reattached = make(chan struct{}, 1)
 	t.fallback = make(map[rune]string)
 	for k, v := range paint.RuneFallbacks {
 		t.fallback[k] = v
@@ -296,6 +493,16 @@ func NewScreen() (Screen, error) {
 	return t, nil
 }
 
+// withDirectColor returns a copy of the terminfo entry with the xterm direct
+// color sequences
+func withDirectColor(ti *terminfo.Terminfo) *terminfo.Terminfo {
+	rgb := *ti
+	rgb.SetFgRGB = "\x1b[38;2;%p1%d;%p2%d;%p3%dm"
+	rgb.SetBgRGB = "\x1b[48;2;%p1%d;%p2%d;%p3%dm"
+	rgb.SetFgBgRGB = "\x1b[38;2;%p1%d;%p2%d;%p3%d;48;2;%p4%d;%p5%d;%p6%dm"
+	return &rgb
+}
+
 // tKeyCode represents a combination of a key code and modifiers.
 type tKeyCode struct {
 	key Key
@@ -311,18 +518,28 @@ type CScreen struct {
 	ttyReading   bool        // is currently waiting for a term.Read
 	ttyReadLock  *sync.Mutex // thread-safe term.Read tracking
 	ttyType      cterm.TermType
+	multiplexer  Multiplexer
+	quirks       TerminalQuirk
+	notify       NotifyProtocol
+	bellTimer    *time.Timer
+	lookupEnv    func(key string) (value string, ok bool)
 	ti           *terminfo.Terminfo
 	h            int
 	w            int
 	finished     bool
 	cells        *CellBuffer
 	term         *term.Term
-	buffering    bool // true if we are collecting writes to buf instead of sending directly to out
+	tty          io.ReadWriter // the terminal input and output, see: initialize
+	buffering    bool          // true if we are collecting writes to buf instead of sending directly to out
 	buf          bytes.Buffer
 	curStyle     paint.Style
 	style        paint.Style
 	evCh         chan Event
+	evOverflow   cEventOverflow
 	sigWinch     chan os.Signal
+	sigHup       chan os.Signal
+	detached     int32
+	reattached   chan struct{}
 	quit         chan struct{}
 	inDoneQ      chan struct{}
 	keyExist     map[Key]bool
@@ -336,6 +553,13 @@ type CScreen struct {
 	clear        bool
 	cursorX      int
 	cursorY      int
+	indicator    CursorIndicator
+	cursorColor  paint.Color
+	indicated    []cIndicatorCell
+	blinkMode    BlinkMode
+	blinkRate    time.Duration
+	blinkHidden  bool
+	tee          io.Writer
 	wasBtn       bool
 	acs          map[rune]string
 	charset      string
@@ -344,20 +568,54 @@ type CScreen struct {
 	fallback     map[rune]string
 	fallcons     map[rune]rune
 	colors       map[paint.Color]paint.Color
+	dithered     map[cDitheredColor]paint.Color
 	palette      []paint.Color
+	downsampling paint.ColorDownsampling
 	trueColor    bool
+	trueCapable  bool
+	keyTiming    int64
+	escapeDelay  int64
 	escaped      bool
 	buttonDn     bool
 	finishOnce   sync.Once
 	enablePaste  string
 	disablePaste string
 	gpmRunning   bool
-
-	useHostClipboard bool
-	useTermClipboard bool
+	keyPhases    bool
+	disambiguate bool
+	modes        ScreenModes
+	rawInput     int32
+	imeArea      [4]int
+	imeAreaSet   bool
+	drawnCells   int
+	drawnBytes   int
+	optimize     ScreenOptimization
+	repeatable   string
+
+	useHostClipboard  bool
+	useTermClipboard  bool
+	termClipboardRead bool
+
+	caps      Capabilities
+	probing   bool
+	probeSent time.Time
 	sync.Mutex
 }
 
+// lookupEnvironment finds the environment variables of the terminal, see:
+// NewScreenWithEnv
+func (d *CScreen) lookupEnvironment(key string) (value string, ok bool) {
+	if d.lookupEnv != nil {
+		return d.lookupEnv(key)
+	}
+	return os.LookupEnv(key)
+}
+
+func (d *CScreen) getenv(key string) (value string) {
+	value, _ = d.lookupEnvironment(key)
+	return
+}
+
 func (d *CScreen) Init() error {
 	return d.initReal()
 }
@@ -420,14 +678,16 @@ func (d *CScreen) initReal() (err error) {
 	}
 
 	d.useHostClipboard = false
-	d.useTermClipboard = true
+	d.useTermClipboard = !d.quirks.NoOSC52
 	d.evCh = make(chan Event, EventQueueSize)
 	d.inDoneQ = make(chan struct{})
 	d.keyChan = make(chan []byte, EventKeyQueueSize)
+	d.keyTiming = int64(EventKeyTiming)
+	d.escapeDelay = -1
 	d.keyTimer = time.NewTimer(EventKeyTiming)
 	d.cells = NewCellBuffer()
 
-	d.charset = charset.Get()
+	d.charset = charset.GetFromEnv(d.lookupEnvironment)
 	if enc := GetEncoding(d.charset); enc != nil {
 		d.encoder = enc.NewEncoder()
 		d.decoder = enc.NewDecoder()
@@ -442,10 +702,10 @@ func (d *CScreen) initReal() (err error) {
 	} else if w == 0 && h == 0 {
 		// environment overrides
 		if w, h = ti.Columns, ti.Lines; w == 0 && h == 0 {
-			if i, _ := strconv.Atoi(os.Getenv("LINES")); i != 0 {
+			if i, _ := strconv.Atoi(d.getenv("LINES")); i != 0 {
 				h = i
 			}
-			if i, _ := strconv.Atoi(os.Getenv("COLUMNS")); i != 0 {
+			if i, _ := strconv.Atoi(d.getenv("COLUMNS")); i != 0 {
 				w = i
 			}
 		}
@@ -454,12 +714,15 @@ func (d *CScreen) initReal() (err error) {
 	if d.ti.SetFgBgRGB != "" || d.ti.SetFgRGB != "" || d.ti.SetBgRGB != "" {
 		d.trueColor = true
 	}
+	d.trueCapable = d.trueColor
+	d.caps = Capabilities{TrueColor: d.trueCapable, Hyperlinks: d.quirks.Hyperlinks, Notify: d.notify}
 	// A user who wants to have their themes honored can
 	// set this environment variable.
-	if os.Getenv("GO_CDK_TRUECOLOR") == "disable" {
+	if d.getenv("GO_CDK_TRUECOLOR") == "disable" {
 		d.trueColor = false
 	}
 	d.colors = make(map[paint.Color]paint.Color)
+	d.dithered = make(map[cDitheredColor]paint.Color)
 	d.palette = make([]paint.Color, d.nColors())
 	for i := 0; i < d.nColors(); i++ {
 		d.palette[i] = paint.Color(i) | paint.ColorValid
@@ -471,10 +734,18 @@ func (d *CScreen) initReal() (err error) {
 	d.TPuts(ti.HideCursor)
 	d.TPuts(ti.EnableAcs)
 	d.TPuts(ti.Clear)
+	if CapabilityProbing {
+		d.probing = true
+		d.probeSent = time.Now()
+		d.writeString(capabilityQueries())
+	}
 
 	d.quit = make(chan struct{})
 
 	d.Lock()
+	// a closed Screen may be initialized again, see: Display.Call
+	d.finished = false
+	d.finishOnce = sync.Once{}
 	d.cx = -1
 	d.cy = -1
 	d.style = paint.StyleDefault
@@ -489,6 +760,7 @@ func (d *CScreen) initReal() (err error) {
 
 	Go(d.mainLoop)
 	Go(d.inputLoop)
+	Go(d.blinkLoop)
 
 	return nil
 }
@@ -786,7 +1058,19 @@ func (d *CScreen) finish() {
 	d.TPuts(ti.ExitCA)
 	d.TPuts(ti.ExitKeypad)
 	d.TPuts(d.disablePaste)
-	d.DisableMouse()
+	if d.bellTimer != nil && d.bellTimer.Stop() {
+		d.bellTimer = nil
+		d.writeString("\x1b[?5l")
+	}
+	if d.keyPhases {
+		d.keyPhases = false
+		d.TPuts("\x1b[<u")
+	}
+	if d.disambiguate {
+		d.disambiguate = false
+		d.TPuts("\x1b[<u")
+	}
+	d.disableMouse()
 	d.curStyle = paint.StyleInvalid
 	d.clear = false
 	d.finished = true
@@ -879,7 +1163,7 @@ func (d *CScreen) encodeRune(r rune, buf []byte) []byte {
 	return buf
 }
 
-func (d *CScreen) sendFgBg(fg paint.Color, bg paint.Color) {
+func (d *CScreen) sendFgBg(fg paint.Color, bg paint.Color, x, y int) {
 	ti := d.ti
 	if ti.Colors == 0 {
 		return
@@ -912,23 +1196,11 @@ func (d *CScreen) sendFgBg(fg paint.Color, bg paint.Color) {
 	}
 
 	if fg.Valid() {
-		if v, ok := d.colors[fg]; ok {
-			fg = v
-		} else {
-			v = paint.FindColor(fg, d.palette)
-			d.colors[fg] = v
-			fg = v
-		}
+		fg = d.mapColor(fg, x, y)
 	}
 
 	if bg.Valid() {
-		if v, ok := d.colors[bg]; ok {
-			bg = v
-		} else {
-			v = paint.FindColor(bg, d.palette)
-			d.colors[bg] = v
-			bg = v
-		}
+		bg = d.mapColor(bg, x, y)
 	}
 
 	if fg.Valid() && bg.Valid() && ti.SetFgBg != "" {
@@ -951,9 +1223,11 @@ func (d *CScreen) drawCell(x, y int) int {
 	if !d.cells.Dirty(x, y) {
 		return width
 	}
+	d.drawnCells++
+	d.repeatable = ""
 
 	if d.cy != y || d.cx != x {
-		d.TPuts(ti.TGoto(x, y))
+		d.moveCursor(x, y)
 		d.cx = x
 		d.cy = y
 	}
@@ -961,34 +1235,43 @@ func (d *CScreen) drawCell(x, y int) int {
 	if style == paint.StyleDefault {
 		style = d.style
 	}
+	mc, style = d.indicate(x, y, mc, style)
+	mc, comb, style = d.animate(mc, comb, style)
 	if style != d.curStyle {
 		fg, bg, attrs := style.Decompose()
 
-		d.TPuts(ti.AttrOff)
+		if !d.sendStyleDelta(style, x, y) {
+			d.TPuts(ti.AttrOff)
 
-		d.sendFgBg(fg, bg)
-		if attrs&paint.AttrBold != 0 {
-			d.TPuts(ti.Bold)
-		}
-		if attrs&paint.AttrUnderline != 0 {
-			d.TPuts(ti.Underline)
-		}
-		if attrs&paint.AttrReverse != 0 {
-			d.TPuts(ti.Reverse)
-		}
-		if attrs&paint.AttrBlink != 0 {
-			d.TPuts(ti.Blink)
-		}
-		if attrs&paint.AttrDim != 0 {
-			d.TPuts(ti.Dim)
-		}
-		if attrs&paint.AttrItalic != 0 {
-			d.TPuts(ti.Italic)
+			d.sendFgBg(fg, bg, x, y)
+			if attrs&paint.AttrBold != 0 {
+				d.TPuts(ti.Bold)
+			}
+			if attrs&paint.AttrUnderline != 0 {
+				d.TPuts(ti.Underline)
+			}
+			if attrs&paint.AttrReverse != 0 {
+				d.TPuts(ti.Reverse)
+			}
+			if attrs&paint.AttrBlink != 0 {
+				d.TPuts(ti.Blink)
+			}
+			if attrs&paint.AttrDim != 0 {
+				d.TPuts(ti.Dim)
+			}
+			if attrs&paint.AttrItalic != 0 {
+				d.TPuts(ti.Italic)
+			}
+			if attrs&paint.AttrStrike != 0 {
+				d.TPuts(ti.StrikeThrough)
+			}
 		}
-		if attrs&paint.AttrStrike != 0 {
-			d.TPuts(ti.StrikeThrough)
+		if d.dithers(fg) || d.dithers(bg) {
+			// the colors sent depend upon the position of the cell
+			d.curStyle = paint.StyleInvalid
+		} else {
+			d.curStyle = style
 		}
-		d.curStyle = style
 	}
 	// now emit runes - taking care to not overrun width with a
 	// wide character, and to ensure that we emit exactly one regular
@@ -1024,6 +1307,9 @@ func (d *CScreen) drawCell(x, y int) int {
 	d.cells.SetDirty(x, y, false)
 	if width > 1 {
 		d.cx = -1
+	} else if d.curStyle != paint.StyleInvalid && !strings.ContainsRune(str, '\x1b') {
+		// a plain character, which can be repeated
+		d.repeatable = str
 	}
 
 	return width
@@ -1042,6 +1328,11 @@ func (d *CScreen) HideCursor() {
 
 func (d *CScreen) showCursor() {
 
+	if d.indicating() {
+		// the drawn indicator replaces the terminal cursor
+		d.hideCursor()
+		return
+	}
 	x, y := d.cursorX, d.cursorY
 	w, h := d.cells.Size()
 	if x < 0 || y < 0 || x >= w || y >= h {
@@ -1061,18 +1352,24 @@ func (d *CScreen) showCursor() {
 // with the intention that the entire buffer be sent to the terminal in one
 // write operation at some point later.
 func (d *CScreen) writeString(s string) {
+	if d.Detached() {
+		return
+	}
 	if d.buffering {
 		_, _ = io.WriteString(&d.buf, s)
 	} else {
-		_, _ = d.term.Write([]byte(s))
+		_ = d.output([]byte(s))
 	}
 }
 
 func (d *CScreen) TPuts(s string) {
+	if d.Detached() {
+		return
+	}
 	if d.buffering {
 		d.ti.TPuts(&d.buf, s)
 	} else {
-		_, _ = d.term.Write([]byte(s))
+		_ = d.output([]byte(s))
 	}
 }
 
@@ -1087,8 +1384,9 @@ func (d *CScreen) Show() {
 
 func (d *CScreen) clearDisplay() {
 	fg, bg, _ := d.style.Decompose()
-	d.sendFgBg(fg, bg)
+	d.sendFgBg(fg, bg, 0, 0)
 	d.TPuts(d.ti.Clear)
+	d.curStyle = paint.StyleInvalid
 	d.clear = false
 }
 
@@ -1096,6 +1394,11 @@ func (d *CScreen) hideCursor() {
 	// does not update cursor position
 	if d.ti.HideCursor != "" {
 		d.TPuts(d.ti.HideCursor)
+		if d.imeAreaSet {
+			// park the hidden cursor for input method placement
+			d.cx, d.cy = d.imeArea[0], d.imeArea[1]
+			d.TPuts(d.ti.TGoto(d.cx, d.cy))
+		}
 	} else {
 		// No way to hide cursor, stick it
 		// at bottom right of screen
@@ -1105,11 +1408,16 @@ func (d *CScreen) hideCursor() {
 }
 
 func (d *CScreen) draw() {
+	if d.Detached() {
+		// the cells remain dirty, to be drawn once reattached
+		return
+	}
 	// clobber cursor position, because we're gonna change it all
 	d.cx = -1
 	d.cy = -1
 
 	d.buf.Reset()
+	d.drawnCells = 0
 	d.buffering = true
 	defer func() {
 		d.buffering = false
@@ -1122,9 +1430,28 @@ func (d *CScreen) draw() {
 		d.clearDisplay()
 	}
 
+	d.prepareIndicator()
+	d.drawCells()
+
+	// restore the cursor
+	d.showCursor()
+
+	d.drawnBytes = d.buf.Len()
+	err := d.output(d.buf.Bytes())
+	d.buf.Reset()
+	if err != nil && ttyGone(err) {
+		d.detach(err)
+	}
+}
+
+// drawCells draws all the dirty cells
+func (d *CScreen) drawCells() {
 	for y := 0; y < d.h; y++ {
 		for x := 0; x < d.w; x++ {
 			width := d.drawCell(x, y)
+			if width == 1 {
+				x += d.repeatCell(x, y)
+			}
 			if width > 1 {
 				if x+1 < d.w {
 					// this is necessary so that if we ever
@@ -1136,11 +1463,109 @@ func (d *CScreen) draw() {
 			x += width - 1
 		}
 	}
+}
 
-	// restore the cursor
-	d.showCursor()
+func (d *CScreen) SetKeyTiming(timing time.Duration) {
+	if timing <= 0 {
+		timing = EventKeyTiming
+	}
+	atomic.StoreInt64(&d.keyTiming, int64(timing))
+}
+
+func (d *CScreen) getKeyTiming() time.Duration {
+	return time.Duration(atomic.LoadInt64(&d.keyTiming))
+}
+
+func (d *CScreen) SetEscapeDelay(delay time.Duration) {
+	if delay < 0 {
+		delay = -1
+	}
+	atomic.StoreInt64(&d.escapeDelay, int64(delay))
+}
+
+func (d *CScreen) SetRawInput(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&d.rawInput, 1)
+		return
+	}
+	d.Lock()
+	// no escape sequence continues from the raw input, and any mouse buttons
+	// pressed during it were released unseen
+	d.escaped = false
+	d.wasBtn = false
+	d.buttonDn = false
+	d.Unlock()
+	atomic.StoreInt32(&d.rawInput, 0)
+}
+
+func (d *CScreen) RawInput() (enabled bool) {
+	return atomic.LoadInt32(&d.rawInput) == 1
+}
+
+// inputDelay returns how long to wait for the remainder of the buffered input
+func (d *CScreen) inputDelay(buf *bytes.Buffer) time.Duration {
+	if b := buf.Bytes(); len(b) == 1 && b[0] == '\x1b' {
+		if delay := atomic.LoadInt64(&d.escapeDelay); delay >= 0 {
+			return time.Duration(delay)
+		}
+	}
+	return d.getKeyTiming()
+}
+
+func (d *CScreen) SetTrueColor(enabled bool) {
+	d.Lock()
+	defer d.Unlock()
+	d.trueColor = enabled && d.trueCapable
+	// cached color mappings depend on the color mode
+	d.colors = make(map[paint.Color]paint.Color)
+	d.dithered = make(map[cDitheredColor]paint.Color)
+}
+
+func (d *CScreen) SetColorDownsampling(policy paint.ColorDownsampling) {
+	d.Lock()
+	defer d.Unlock()
+	d.downsampling = policy
+	d.colors = make(map[paint.Color]paint.Color)
+	d.dithered = make(map[cDitheredColor]paint.Color)
+	d.curStyle = paint.StyleInvalid
+}
+
+// cDitheredColor is a color as dithered at a position of the dither matrix
+type cDitheredColor struct {
+	color paint.Color
+	cell  int
+}
 
-	_, _ = d.buf.WriteTo(d.term)
+// dithers returns true if the color is drawn differently depending upon the
+// position of the cell
+func (d *CScreen) dithers(c paint.Color) bool {
+	return !d.trueColor && c.Valid() && c.IsRGB() && d.downsampling.Dither != paint.ColorDitherNone
+}
+
+// mapColor returns the palette color to use for the color in the cell at the
+// given position
+func (d *CScreen) mapColor(c paint.Color, x, y int) paint.Color {
+	if d.dithers(c) {
+		key := cDitheredColor{color: c, cell: (y&3)<<2 | x&3}
+		if v, ok := d.dithered[key]; ok {
+			return v
+		}
+		v := paint.DitherColor(c, d.palette, d.downsampling.Distance, x, y)
+		d.dithered[key] = v
+		return v
+	}
+	if v, ok := d.colors[c]; ok {
+		return v
+	}
+	v := paint.FindColorWith(c, d.palette, d.downsampling.Distance)
+	d.colors[c] = v
+	return v
+}
+
+func (d *CScreen) GetDrawStats() (cells, bytes int) {
+	d.Lock()
+	defer d.Unlock()
+	return d.drawnCells, d.drawnBytes
 }
 
 func (d *CScreen) EnableGPM() {
@@ -1164,17 +1589,23 @@ func (d *CScreen) EnableMouse(flags ...MouseFlags) {
 	// pretty much *every* terminal that supports mouse tracking follows the
 	// XTerm standards (the modern ones).
 
+	if f&(MouseMotionEvents|MouseDragEvents|MouseButtonEvents) == 0 {
+		// No recognized tracking enabled.
+		return
+	}
+	d.Lock()
+	d.modes.Mouse = true
+	d.modes.MouseFlags = f
+	d.Unlock()
+
 	if len(d.mouse) != 0 {
 		var mm int
 		if f&MouseMotionEvents != 0 {
 			mm = 1003
 		} else if f&MouseDragEvents != 0 {
 			mm = 1002
-		} else if f&MouseButtonEvents != 0 {
-			mm = 1000
 		} else {
-			// No recognized tracking enabled.
-			return
+			mm = 1000
 		}
 
 		d.TPuts(fmt.Sprintf("\x1b[?%dh\x1b[?1006h", mm))
@@ -1184,6 +1615,14 @@ func (d *CScreen) EnableMouse(flags ...MouseFlags) {
 }
 
 func (d *CScreen) DisableMouse() {
+	d.Lock()
+	d.modes.Mouse = false
+	d.modes.MouseFlags = 0
+	d.Unlock()
+	d.disableMouse()
+}
+
+func (d *CScreen) disableMouse() {
 	if len(d.mouse) != 0 {
 		// This turns off everything.
 		d.TPuts("\x1b[?1000l\x1b[?1002l\x1b[?1003l\x1b[?1006l")
@@ -1191,13 +1630,73 @@ func (d *CScreen) DisableMouse() {
 }
 
 func (d *CScreen) EnablePaste() {
+	d.Lock()
+	d.modes.Paste = true
+	d.Unlock()
 	d.TPuts(d.enablePaste)
 }
 
 func (d *CScreen) DisablePaste() {
+	d.Lock()
+	d.modes.Paste = false
+	d.Unlock()
 	d.TPuts(d.disablePaste)
 }
 
+func (d *CScreen) SetInputMethodArea(x, y, w, h int) {
+	d.Lock()
+	defer d.Unlock()
+	d.imeArea = [4]int{x, y, w, h}
+	d.imeAreaSet = true
+	if InputMethodAreaSequence != "" {
+		d.TPuts(fmt.Sprintf(InputMethodAreaSequence, x, y, w, h))
+	}
+}
+
+func (d *CScreen) ClearInputMethodArea() {
+	d.Lock()
+	defer d.Unlock()
+	d.imeAreaSet = false
+}
+
+// EnableKeyPhases requests the kitty keyboard protocol with event types, which
+// reports key repeat and key release events. Terminals which do not support
+// the protocol ignore the request and continue to report key presses only.
+func (d *CScreen) EnableKeyPhases() {
+	d.Lock()
+	d.keyPhases = true
+	d.modes.KeyPhases = true
+	d.Unlock()
+	// disambiguate escape codes, report event types, report all keys as escape codes
+	d.TPuts("\x1b[>11u")
+}
+
+func (d *CScreen) DisableKeyPhases() {
+	d.Lock()
+	enabled := d.keyPhases
+	d.keyPhases = false
+	d.modes.KeyPhases = false
+	d.Unlock()
+	if enabled {
+		d.TPuts("\x1b[<u")
+	}
+}
+
+// Modes returns the registry of terminal modes currently enabled, the mode
+// registry is retained when the Screen is closed.
+func (d *CScreen) Modes() (modes ScreenModes) {
+	d.Lock()
+	defer d.Unlock()
+	modes = d.modes
+	return
+}
+
+// ApplyModes enables or disables the terminal modes to match the given
+// registry.
+func (d *CScreen) ApplyModes(modes ScreenModes) {
+	applyScreenModes(d, modes)
+}
+
 func (d *CScreen) Size() (w, h int) {
 	d.Lock()
 	w, h = d.w, d.h
@@ -1215,6 +1714,7 @@ func (d *CScreen) resize() {
 			d.cells.Invalidate()
 			d.h = h
 			d.w = w
+			d.teeResize()
 			ev := NewEventResize(w, h)
 			_ = d.PostEvent(ev)
 		}
@@ -1328,12 +1828,23 @@ func (d *CScreen) PostEventWait(ev Event) {
 }
 
 func (d *CScreen) PostEvent(ev Event) error {
-	select {
-	case d.evCh <- ev:
-		return nil
-	default:
-		return ErrEventQFull
-	}
+	return d.evOverflow.post(d.evCh, ev, d.quit)
+}
+
+func (d *CScreen) SetEventOverflowPolicy(policy EventOverflowPolicy) {
+	d.evOverflow.setPolicy(policy)
+}
+
+func (d *CScreen) GetEventOverflowPolicy() (policy EventOverflowPolicy) {
+	return d.evOverflow.getPolicy()
+}
+
+func (d *CScreen) SetEventOverflowHandler(fn EventOverflowFn) {
+	d.evOverflow.setHandler(fn)
+}
+
+func (d *CScreen) DroppedEvents() (dropped uint64) {
+	return d.evOverflow.getDropped()
 }
 
 func (d *CScreen) clip(x, y int) (int, int) {
@@ -1627,6 +2138,162 @@ func (d *CScreen) parseFunctionKey(buf *bytes.Buffer, evs *[]Event) (bool, bool)
 	return partial, false
 }
 
+var kittyFunctionalKeys = map[byte]map[int]Key{
+	'~': {
+		2: KeyInsert, 3: KeyDelete, 5: KeyPgUp, 6: KeyPgDn, 7: KeyHome, 8: KeyEnd,
+		11: KeyF1, 12: KeyF2, 13: KeyF3, 14: KeyF4, 15: KeyF5, 17: KeyF6,
+		18: KeyF7, 19: KeyF8, 20: KeyF9, 21: KeyF10, 23: KeyF11, 24: KeyF12,
+	},
+	'A': {1: KeyUp},
+	'B': {1: KeyDown},
+	'C': {1: KeyRight},
+	'D': {1: KeyLeft},
+	'E': {1: KeyCenter},
+	'F': {1: KeyEnd},
+	'H': {1: KeyHome},
+	'P': {1: KeyF1},
+	'Q': {1: KeyF2},
+	'S': {1: KeyF4},
+}
+
+var kittyKeypadRunes = map[int]rune{
+	57399: '0', 57400: '1', 57401: '2', 57402: '3', 57403: '4',
+	57404: '5', 57405: '6', 57406: '7', 57407: '8', 57408: '9',
+	57409: '.', 57410: '/', 57411: '*', 57412: '-', 57413: '+', 57415: '=',
+}
+
+// parseKittyKeySequence parses a kitty keyboard protocol sequence from the
+// start of the given input. The number of bytes consumed is returned when the
+// sequence is complete, partial is true when more input is needed and evt is
+// nil for complete sequences which have no EventKey equivalent (such as the
+// modifier keys themselves). Legacy sequences without an event type are left
+// for the terminfo key codes to handle.
+func parseKittyKeySequence(b []byte) (evt *EventKey, n int, partial bool) {
+	if len(b) < 2 || b[0] != '\x1b' || b[1] != '[' {
+		partial = len(b) == 1 && b[0] == '\x1b'
+		return
+	}
+	end := -1
+	for i := 2; i < len(b); i++ {
+		if c := b[i]; (c >= '0' && c <= '9') || c == ';' || c == ':' {
+			continue
+		}
+		end = i
+		break
+	}
+	if end == -1 {
+		partial = true
+		return
+	}
+	final := b[end]
+	var fields [][]int
+	for _, field := range strings.Split(string(b[2:end]), ";") {
+		var values []int
+		for _, value := range strings.Split(field, ":") {
+			if value == "" {
+				values = append(values, 0)
+			} else if v, err := strconv.Atoi(value); err == nil {
+				values = append(values, v)
+			} else {
+				return
+			}
+		}
+		fields = append(fields, values)
+	}
+	if final != 'u' && (len(fields) < 2 || len(fields[1]) < 2) {
+		// no event type, not a kitty sequence
+		return
+	}
+	code, mods, event := 1, 1, 1
+	if len(fields) > 0 && fields[0][0] > 0 {
+		code = fields[0][0]
+	}
+	if len(fields) > 1 {
+		if fields[1][0] > 0 {
+			mods = fields[1][0]
+		}
+		if len(fields[1]) > 1 && fields[1][1] > 0 {
+			event = fields[1][1]
+		}
+	}
+	var phase KeyPhase
+	switch event {
+	case 2:
+		phase = KeyRepeat
+	case 3:
+		phase = KeyRelease
+	default:
+		phase = KeyPress
+	}
+	var mod ModMask
+	if bits := mods - 1; bits > 0 {
+		if bits&1 != 0 {
+			mod |= ModShift
+		}
+		if bits&2 != 0 {
+			mod |= ModAlt
+		}
+		if bits&4 != 0 {
+			mod |= ModCtrl
+		}
+		if bits&(8|32) != 0 {
+			mod |= ModMeta
+		}
+	}
+	n = end + 1
+	if final != 'u' {
+		if keys, ok := kittyFunctionalKeys[final]; ok {
+			if key, ok := keys[code]; ok {
+				evt = NewEventKeyWithPhase(key, 0, mod, phase)
+				return
+			}
+		}
+		if _, ok := kittyFunctionalKeys[final]; !ok {
+			n = 0
+		}
+		return
+	}
+	switch {
+	case code == 9:
+		evt = NewEventKeyWithPhase(KeyTab, 0, mod, phase)
+	case code == 13 || code == 57414:
+		evt = NewEventKeyWithPhase(KeyEnter, 0, mod, phase)
+	case code == 27:
+		evt = NewEventKeyWithPhase(KeyEsc, 0, mod, phase)
+	case code == 127:
+		evt = NewEventKeyWithPhase(KeyBackspace2, 0, mod, phase)
+	case code >= 57344 && code <= 63743:
+		if r, ok := kittyKeypadRunes[code]; ok {
+			evt = NewEventKeyWithPhase(KeyRune, r, mod, phase)
+		}
+	case mod.Has(ModCtrl) && code >= 'a' && code <= 'z':
+		// match the legacy encoding of control keys
+		evt = NewEventKeyWithPhase(KeyRune, rune(code-'a'+1), mod, phase)
+	case mod.Has(ModShift) && code >= 'a' && code <= 'z':
+		// match the legacy reporting of capital letters
+		evt = NewEventKeyWithPhase(KeyRune, rune(code-'a'+'A'), mod&^ModShift, phase)
+	default:
+		evt = NewEventKeyWithPhase(KeyRune, rune(code), mod, phase)
+	}
+	return
+}
+
+func (d *CScreen) parseKittyKey(buf *bytes.Buffer, evs *[]Event) (bool, bool) {
+	evt, n, partial := parseKittyKeySequence(buf.Bytes())
+	if n == 0 {
+		return partial, false
+	}
+	if evt != nil {
+		if d.escaped {
+			evt.mod |= ModAlt
+			d.escaped = false
+		}
+		*evs = append(*evs, evt)
+	}
+	buf.Next(n)
+	return true, true
+}
+
 func (d *CScreen) parseRune(buf *bytes.Buffer, evs *[]Event) (bool, bool) {
 	b := buf.Bytes()
 	if b[0] >= ' ' && b[0] < 0x7F {
@@ -1701,6 +2368,30 @@ func (d *CScreen) collectEventsFromInput(buf *bytes.Buffer, expire bool) []Event
 
 		partials := 0
 
+		if d.keyPhases || d.disambiguate {
+			if part, comp := d.parseKittyKey(buf, &res); comp {
+				continue
+			} else if part {
+				partials++
+			}
+		}
+
+		if d.probing {
+			if part, comp := d.parseCapabilities(buf, &res); comp {
+				continue
+			} else if part {
+				partials++
+			}
+		}
+
+		if d.useTermClipboard {
+			if part, comp := d.parseClipboard(buf, &res); comp {
+				continue
+			} else if part {
+				partials++
+			}
+		}
+
 		if part, comp := d.parseRune(buf, &res); comp {
 			continue
 		} else if part {
@@ -1814,6 +2505,15 @@ func (d *CScreen) mainLoop() {
 		case <-d.quit:
 			close(d.inDoneQ)
 			return
+		case <-d.sigHup:
+			// a hangup may be followed by a new session on the same tty, while
+			// a hangup with the terminal still present requests a reload
+			if err := d.probeTty(); err != nil {
+				d.detach(err)
+			} else {
+				_ = d.PostEvent(NewEventReload())
+			}
+			continue
 		case <-d.sigWinch:
 			d.Lock()
 			d.cx = -1
@@ -1841,12 +2541,25 @@ func (d *CScreen) mainLoop() {
 					default:
 					}
 				}
-				d.keyTimer.Reset(EventKeyTiming)
+				d.keyTimer.Reset(d.inputDelay(buf))
 			}
 		case chunk := <-d.keyChan:
+			if d.RawInput() {
+				if buf.Len() > 0 {
+					// input from before raw mode was enabled
+					d.scanInput(buf, true)
+				}
+				_ = d.PostEvent(NewEventRaw(chunk))
+				continue
+			}
 			buf.Write(chunk)
-			d.keyExpire = time.Now().Add(EventKeyTiming)
 			d.scanInput(buf, false)
+			delay := d.inputDelay(buf)
+			if buf.Len() > 0 && delay <= 0 {
+				// a lone ESC is not to wait for anything more
+				d.scanInput(buf, true)
+			}
+			d.keyExpire = time.Now().Add(delay)
 			if !d.keyTimer.Stop() {
 				select {
 				case <-d.keyTimer.C:
@@ -1854,26 +2567,48 @@ func (d *CScreen) mainLoop() {
 				}
 			}
 			if buf.Len() > 0 {
-				d.keyTimer.Reset(EventKeyTiming)
+				d.keyTimer.Reset(delay)
 			}
 		}
 	}
 }
 
 func (d *CScreen) inputLoop() {
+	d.Lock()
+	tty := d.tty
+	d.Unlock()
 	for {
 		chunk := make([]byte, 128)
 		d.ttyReadLock.Lock()
 		d.ttyReading = true
 		d.ttyReadLock.Unlock()
-		n, e := d.term.Read(chunk)
+		n, e := tty.Read(chunk)
 		d.ttyReadLock.Lock()
 		d.ttyReading = false
 		d.ttyReadLock.Unlock()
+		if d.released(tty) {
+			// read by the input loop of the new terminal, see: Reattach
+			return
+		}
 		switch e {
 		case io.EOF:
+			// reading a hung up terminal returns nothing
+			if err := d.probeTty(); err != nil {
+				d.detach(err)
+				if !d.awaitInput(tty) {
+					return
+				}
+				continue
+			}
 		case nil:
 		default:
+			if ttyGone(e) {
+				d.detach(e)
+				if !d.awaitInput(tty) {
+					return
+				}
+				continue
+			}
 			if strings.Index(e.Error(), "bad file descriptor") > -1 {
 				if err := d.reengage(); err != nil {
 					log.ErrorF("Screen reengage error handling \"bad file descriptor\": %v", err)
@@ -2003,11 +2738,88 @@ func (d *CScreen) CopyToClipboard(s string) {
 			return
 		}
 	}
-	if d.useTermClipboard {
-		b64 := base64.StdEncoding.EncodeToString([]byte(s))
-		d.TPuts("\x1b]52;c;" + b64 + "\x07")
-		log.DebugF("copy sent (OSC-52 terminal sequence): %v", s)
+	d.copyWithOSC52("c", s)
+}
+
+// CopyToPrimary sets the primary selection of the host, using xclip, xsel or
+// wl-copy, falling back to the terminal primary selection with OSC 52. Not
+// all terminals which support OSC 52 support the primary selection.
+func (d *CScreen) CopyToPrimary(s string) {
+	if d.useHostClipboard {
+		if err := writeHostPrimary(s); err != nil {
+			log.Error(err)
+		} else {
+			log.DebugF("primary sent (host): %v", s)
+			return
+		}
+	}
+	d.copyWithOSC52("p", s)
+}
+
+func (d *CScreen) copyWithOSC52(selection, s string) {
+	if !d.useTermClipboard {
+		return
+	}
+	sequence, err := encodeOSC52Selection(selection, s)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	d.Lock()
+	for _, chunk := range chunkOSC52(d.multiplexer.Passthrough(sequence)) {
+		d.writeString(chunk)
+	}
+	d.Unlock()
+	log.DebugF("copy sent (OSC-52 terminal sequence, %v): %v", selection, s)
+}
+
+// VisualBell flashes the screen by switching the terminal to reverse video,
+// DECSCNM, for the duration. The terminal redraws the screen itself so no
+// cells need to be drawn again.
+func (d *CScreen) VisualBell(duration time.Duration) error {
+	if duration <= 0 {
+		duration = VisualBellDuration
+	}
+	d.Lock()
+	defer d.Unlock()
+	if d.finished {
+		return ErrNoDisplay
+	}
+	if d.bellTimer != nil && d.bellTimer.Stop() {
+		d.bellTimer.Reset(duration)
+		return nil
+	}
+	d.writeString("\x1b[?5h")
+	var timer *time.Timer
+	timer = time.AfterFunc(duration, func() {
+		d.Lock()
+		defer d.Unlock()
+		if d.bellTimer != timer {
+			// superseded by a later flash
+			return
+		}
+		d.bellTimer = nil
+		if !d.finished {
+			d.writeString("\x1b[?5l")
+		}
+	})
+	d.bellTimer = timer
+	return nil
+}
+
+// Notify sends a desktop notification with the NotifyProtocol of the
+// terminal, returning ErrNotifyUnsupported if it has none. Multiplexers are
+// passed through to reach the outer terminal.
+func (d *CScreen) Notify(title, body string) (err error) {
+	d.Lock()
+	defer d.Unlock()
+	if d.notify == NotifyNone {
+		return ErrNotifyUnsupported
+	}
+	if !d.finished {
+		d.writeString(d.multiplexer.Passthrough(encodeNotify(d.notify, title, body)))
 	}
+	return nil
 }
 
 func (d *CScreen) PasteFromClipboard() (s string, ok bool) {
@@ -2023,6 +2835,21 @@ func (d *CScreen) PasteFromClipboard() (s string, ok bool) {
 	return
 }
 
+// PasteFromPrimary returns the content of the primary selection of the host,
+// read with xclip, xsel or wl-paste
+func (d *CScreen) PasteFromPrimary() (s string, ok bool) {
+	if d.useHostClipboard {
+		var err error
+		if s, err = readHostPrimary(); err != nil {
+			log.Error(err)
+		} else {
+			log.DebugF("primary received (host): %v", s)
+			ok = true
+		}
+	}
+	return
+}
+
 func (d *CScreen) EnableHostClipboard(enabled bool) {
 	d.Lock()
 	defer d.Unlock()
@@ -2034,3 +2861,99 @@ func (d *CScreen) EnableTermClipboard(enabled bool) {
 	defer d.Unlock()
 	d.useTermClipboard = enabled
 }
+
+// RequestClipboard asks the terminal to report the content of the clipboard
+// using OSC 52, the report is delivered as an EventClipboard. Many terminals
+// do not support, or are configured to deny, clipboard reads and will not
+// respond. Returns false if the terminal clipboard is not enabled.
+func (d *CScreen) RequestClipboard() (requested bool) {
+	d.Lock()
+	defer d.Unlock()
+	if !d.useTermClipboard || d.finished {
+		return false
+	}
+	d.writeString(d.multiplexer.Passthrough(OSC52QuerySequence))
+	return true
+}
+
+// RequestPrimary asks the terminal to report the content of the primary
+// selection using OSC 52, the report is delivered as an EventClipboard with
+// the "p" selection. Returns false if the terminal clipboard is not enabled.
+func (d *CScreen) RequestPrimary() (requested bool) {
+	d.Lock()
+	defer d.Unlock()
+	if !d.useTermClipboard || d.finished {
+		return false
+	}
+	d.writeString(d.multiplexer.Passthrough(OSC52PrimaryQuerySequence))
+	return true
+}
+
+// TermClipboardReadable returns true once the terminal has responded to a
+// RequestClipboard, indicating that OSC 52 is supported in both directions
+func (d *CScreen) TermClipboardReadable() (readable bool) {
+	d.Lock()
+	defer d.Unlock()
+	return d.termClipboardRead
+}
+
+func (d *CScreen) parseClipboard(buf *bytes.Buffer, evs *[]Event) (bool, bool) {
+	evt, n, partial := parseOSC52Sequence(buf.Bytes())
+	if n == 0 {
+		return partial, false
+	}
+	if evt != nil {
+		d.termClipboardRead = true
+		*evs = append(*evs, evt)
+	}
+	buf.Next(n)
+	return true, true
+}
+
+// Capabilities returns what the terminal is known to support
+func (d *CScreen) Capabilities() (capabilities Capabilities) {
+	d.Lock()
+	defer d.Unlock()
+	capabilities = d.caps
+	capabilities.DeviceAttributes = append([]int(nil), d.caps.DeviceAttributes...)
+	return
+}
+
+func (d *CScreen) parseCapabilities(buf *bytes.Buffer, evs *[]Event) (bool, bool) {
+	if time.Since(d.probeSent) > CapabilityProbeTimeout {
+		// the terminal did not answer all the queries
+		d.probing = false
+		return false, false
+	}
+	report, n, partial := parseCapabilityReport(buf.Bytes())
+	if n == 0 {
+		return partial, false
+	}
+	buf.Next(n)
+	done := d.caps.update(report)
+	if report.kind == reportKittyKeyboard && EscapeDisambiguation && !d.disambiguate && !d.keyPhases {
+		// disambiguate escape codes, EnableKeyPhases pushes its flags on top
+		// of these and pops them again when disabled
+		d.disambiguate = true
+		d.TPuts("\x1b[>1u")
+	}
+	if d.caps.TrueColor && !d.trueCapable {
+		if d.quirks.NoTrueColor || d.multiplexer == MultiplexerScreen {
+			// known better than the terminal, see: NewScreenWithEnv
+			d.caps.TrueColor = false
+		} else {
+			// the terminfo entry did not include direct color
+			d.ti = withDirectColor(d.ti)
+			d.trueCapable = true
+			d.trueColor = d.getenv("GO_CDK_TRUECOLOR") != "disable"
+			d.colors = make(map[paint.Color]paint.Color)
+			d.dithered = make(map[cDitheredColor]paint.Color)
+			d.curStyle = paint.StyleInvalid
+		}
+	}
+	if done {
+		d.probing = false
+		*evs = append(*evs, NewEventCapabilities(d.caps))
+	}
+	return true, true
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strings"
+	"sync"
+)
+
+// ScreenBackend creates the Screen of a Display whose tty path names the
+// backend, the address is the remainder of the tty path after the name and
+// colon. The Screen is initialized by CaptureDisplay.
+type ScreenBackend func(address string) (Screen, error)
+
+var (
+	screenBackends   = make(map[string]ScreenBackend)
+	screenBackendsLk sync.RWMutex
+)
+
+// RegisterScreenBackend makes the backend available to Displays created with
+// a tty path of the form "name:address". Backends are usually registered by
+// the init function of the package providing them, see: cdk/web
+func RegisterScreenBackend(name string, backend ScreenBackend) {
+	screenBackendsLk.Lock()
+	screenBackends[strings.ToLower(name)] = backend
+	screenBackendsLk.Unlock()
+}
+
+func UnregisterScreenBackend(name string) {
+	screenBackendsLk.Lock()
+	delete(screenBackends, strings.ToLower(name))
+	screenBackendsLk.Unlock()
+}
+
+// LookupScreenBackend returns the registered backend named by the given tty
+// path and the address given to it, ok is false for tty paths which do not
+// name a registered backend
+func LookupScreenBackend(ttyPath string) (backend ScreenBackend, address string, ok bool) {
+	name, address, found := strings.Cut(ttyPath, ":")
+	if !found {
+		return nil, "", false
+	}
+	screenBackendsLk.RLock()
+	backend, ok = screenBackends[strings.ToLower(name)]
+	screenBackendsLk.RUnlock()
+	return
+}
@@ -0,0 +1,47 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScreenBackend(t *testing.T) {
+	Convey("Screen backends", t, func() {
+		var given string
+		RegisterScreenBackend("Testing", func(address string) (Screen, error) {
+			given = address
+			return NewOffScreen("UTF-8"), nil
+		})
+		defer UnregisterScreenBackend("testing")
+
+		_, _, ok := LookupScreenBackend("/dev/tty")
+		So(ok, ShouldBeFalse)
+		_, _, ok = LookupScreenBackend("unknown:somewhere")
+		So(ok, ShouldBeFalse)
+		backend, address, ok := LookupScreenBackend("testing:host:1234")
+		So(ok, ShouldBeTrue)
+		So(address, ShouldEqual, "host:1234")
+
+		d := NewDisplay("backend", "TESTING:host:1234")
+		So(d.CaptureDisplay(), ShouldBeNil)
+		So(given, ShouldEqual, "host:1234")
+		_, isOffScreen := d.Screen().(OffScreen)
+		So(isOffScreen, ShouldBeTrue)
+		So(backend, ShouldNotBeNil)
+	})
+}
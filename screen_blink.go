@@ -0,0 +1,139 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"time"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// BlinkMode selects how cells with the blink attribute are shown
+type BlinkMode string
+
+const (
+	// BlinkModeDefault blinks in software when the terminal lacks the blink
+	// attribute, otherwise the terminal blinks the cells
+	BlinkModeDefault BlinkMode = ""
+	// BlinkModeNative leaves blinking to the terminal
+	BlinkModeNative BlinkMode = "native"
+	// BlinkModeSoftware hides and shows the content of blinking cells at the
+	// blink rate, instead of using the blink attribute of the terminal
+	BlinkModeSoftware BlinkMode = "software"
+)
+
+// DefaultBlinkRate is how often cells blinking in software are hidden or shown
+// when no rate is given to Screen.SetBlinkMode
+var DefaultBlinkRate = time.Millisecond * 500
+
+// SetBlinkMode changes how cells with the blink attribute are shown, the rate
+// is how often cells blinking in software are hidden or shown
+func (d *CScreen) SetBlinkMode(mode BlinkMode, rate time.Duration) {
+	if rate <= 0 {
+		rate = DefaultBlinkRate
+	}
+	d.Lock()
+	d.blinkMode = mode
+	d.blinkRate = rate
+	d.Unlock()
+}
+
+// softBlink returns true if blinking cells are animated by the Screen, the
+// Screen must be locked by the caller
+func (d *CScreen) softBlink() bool {
+	switch d.blinkMode {
+	case BlinkModeSoftware:
+		return true
+	case BlinkModeNative:
+		return false
+	}
+	return d.ti != nil && d.ti.Blink == ""
+}
+
+// blinkLoop is the animation service of software blinking, toggling the
+// blinking cells between hidden and shown at the blink rate until the Screen
+// is finalized
+func (d *CScreen) blinkLoop() {
+	timer := time.NewTimer(DefaultBlinkRate)
+	defer timer.Stop()
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-timer.C:
+		}
+		d.Lock()
+		if !d.finished && d.blinkFrame() {
+			d.draw()
+		}
+		rate := d.blinkRate
+		d.Unlock()
+		if rate <= 0 {
+			rate = DefaultBlinkRate
+		}
+		timer.Reset(rate)
+	}
+}
+
+// blinkFrame toggles the blinking cells between hidden and shown, returning
+// true if there are cells to draw, the Screen must be locked by the caller
+func (d *CScreen) blinkFrame() (redraw bool) {
+	if !d.softBlink() {
+		if !d.blinkHidden {
+			return false
+		}
+		// show the cells hidden before software blinking stopped
+		d.blinkHidden = false
+	} else {
+		d.blinkHidden = !d.blinkHidden
+	}
+	return d.markBlinking()
+}
+
+// markBlinking marks all the cells with the blink attribute as dirty,
+// returning true if there are any
+func (d *CScreen) markBlinking() (found bool) {
+	for y := 0; y < d.h; y++ {
+		for x := 0; x < d.w; x++ {
+			if _, _, style, _ := d.cells.GetCell(x, y); isBlinking(style) {
+				d.cells.SetDirty(x, y, true)
+				found = true
+			}
+		}
+	}
+	return
+}
+
+func isBlinking(style paint.Style) bool {
+	_, _, attrs := style.Decompose()
+	return attrs.IsBlink()
+}
+
+// animate returns the content and style to draw for the cell, drawing cells
+// with the cursor attribute as the cursor is drawn and hiding the content of
+// blinking cells while blinking in software
+func (d *CScreen) animate(mc rune, comb []rune, style paint.Style) (rune, []rune, paint.Style) {
+	_, _, attrs := style.Decompose()
+	if attrs.IsCursor() {
+		style = d.cursorCell(style)
+	}
+	if attrs.IsBlink() && d.softBlink() {
+		style = style.Blink(false)
+		if d.blinkHidden {
+			mc, comb = ' ', nil
+		}
+	}
+	return mc, comb, style
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+func TestScreenBlink(t *testing.T) {
+	Convey("Blinking cells", t, func() {
+		d := newWireTestScreen(0)
+		d.SetBlinkMode(BlinkModeDefault, 0)
+		So(d.blinkRate, ShouldEqual, DefaultBlinkRate)
+		blink := paint.StyleDefault.Blink(true)
+		d.cells.SetCell(1, 1, 'b', nil, blink)
+		d.cells.SetCell(2, 1, 'n', nil, paint.StyleDefault)
+		cursorTestDraw(d, -1, -1)
+
+		Convey("left to a terminal with the blink attribute", func() {
+			So(d.ti.Blink, ShouldNotBeEmpty)
+			So(d.softBlink(), ShouldBeFalse)
+			So(d.blinkFrame(), ShouldBeFalse)
+			So(d.blinkHidden, ShouldBeFalse)
+			So(d.cells.Dirty(1, 1), ShouldBeFalse)
+		})
+
+		Convey("animated in software", func() {
+			d.SetBlinkMode(BlinkModeSoftware, 0)
+			So(d.softBlink(), ShouldBeTrue)
+			So(d.blinkFrame(), ShouldBeTrue)
+			So(d.blinkHidden, ShouldBeTrue)
+			// only the blinking cells are drawn again
+			out := cursorTestDraw(d, -1, -1)
+			So(d.drawnCells, ShouldEqual, 1)
+			So(out, ShouldNotContainSubstring, d.ti.Blink)
+			So(out, ShouldNotContainSubstring, "b")
+			mc, _, style := d.animate('b', nil, blink)
+			So(mc, ShouldEqual, ' ')
+			So(style, ShouldEqual, paint.StyleDefault)
+			So(d.blinkFrame(), ShouldBeTrue)
+			So(d.blinkHidden, ShouldBeFalse)
+			So(cursorTestDraw(d, -1, -1), ShouldContainSubstring, "b")
+			Convey("shown once stopped", func() {
+				So(d.blinkFrame(), ShouldBeTrue)
+				So(d.blinkHidden, ShouldBeTrue)
+				cursorTestDraw(d, -1, -1)
+				d.SetBlinkMode(BlinkModeNative, 0)
+				So(d.blinkFrame(), ShouldBeTrue)
+				So(d.blinkHidden, ShouldBeFalse)
+				So(cursorTestDraw(d, -1, -1), ShouldContainSubstring, d.ti.Blink)
+			})
+		})
+
+		Convey("for terminals lacking the blink attribute", func() {
+			ti := *d.ti
+			ti.Blink = ""
+			d.ti = &ti
+			So(d.softBlink(), ShouldBeTrue)
+		})
+	})
+}
+
+func TestScreenCursorCells(t *testing.T) {
+	Convey("Cells with the cursor attribute", t, func() {
+		d := newWireTestScreen(0)
+		cursor := paint.StyleDefault.Cursor(true)
+		d.cells.SetCell(3, 1, 'c', nil, cursor)
+		out := cursorTestDraw(d, -1, -1)
+		So(out, ShouldContainSubstring, d.ti.Reverse)
+		_, _, style := d.animate('c', nil, cursor)
+		_, _, attrs := style.Decompose()
+		So(attrs.IsCursor(), ShouldBeFalse)
+		So(attrs.IsReverse(), ShouldBeTrue)
+		d.SetCursorIndicator(CursorIndicatorBlock, paint.ColorRed)
+		_, _, style = d.animate('c', nil, cursor)
+		_, bg, attrs := style.Decompose()
+		So(bg, ShouldEqual, paint.ColorRed)
+		So(attrs.IsReverse(), ShouldBeFalse)
+	})
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// CursorIndicator selects how the position given to Screen.ShowCursor is
+// shown. Other than CursorIndicatorNative, the terminal cursor is kept hidden
+// and the Screen draws its own indicator over the content when rendering, for
+// terminals with native cursors which are difficult to see.
+type CursorIndicator string
+
+const (
+	// CursorIndicatorDefault defers to the application, or the native cursor
+	CursorIndicatorDefault CursorIndicator = ""
+	// CursorIndicatorNative uses the terminal cursor
+	CursorIndicatorNative CursorIndicator = "native"
+	// CursorIndicatorInverse reverses the colors of the cursor cell
+	CursorIndicatorInverse CursorIndicator = "inverse"
+	// CursorIndicatorBrackets draws a blinking pair of brackets in the cells
+	// either side of the cursor cell
+	CursorIndicatorBrackets CursorIndicator = "brackets"
+	// CursorIndicatorBlock fills the background of the cursor cell with the
+	// indicator color
+	CursorIndicatorBlock CursorIndicator = "block"
+)
+
+// DefaultCursorIndicatorColor is used by the brackets and block indicators
+// when no color is given to Screen.SetCursorIndicator
+var DefaultCursorIndicatorColor = paint.ColorYellow
+
+// cIndicatorCell is a cell drawn differently to indicate the cursor
+type cIndicatorCell struct {
+	x, y int
+	r    rune
+}
+
+// SetCursorIndicator changes how the cursor is shown, the color is used by
+// the brackets and block indicators
+func (d *CScreen) SetCursorIndicator(indicator CursorIndicator, color paint.Color) {
+	if color == paint.ColorDefault {
+		color = DefaultCursorIndicatorColor
+	}
+	d.Lock()
+	d.indicator = indicator
+	d.cursorColor = color
+	d.Unlock()
+}
+
+// indicating returns true if the cursor is visible and shown with a drawn
+// indicator instead of the terminal cursor
+func (d *CScreen) indicating() bool {
+	switch d.indicator {
+	case CursorIndicatorDefault, CursorIndicatorNative:
+		return false
+	}
+	x, y := d.cursorX, d.cursorY
+	return x >= 0 && y >= 0 && x < d.w && y < d.h
+}
+
+// prepareIndicator marks the cells of the previous and current cursor
+// indicators as dirty, so that a moved indicator leaves no trace
+func (d *CScreen) prepareIndicator() {
+	for _, cell := range d.indicated {
+		d.cells.SetDirty(cell.x, cell.y, true)
+	}
+	d.indicated = d.indicated[:0]
+	if !d.indicating() {
+		return
+	}
+	x, y := d.cursorX, d.cursorY
+	if d.indicator == CursorIndicatorBrackets {
+		if x > 0 {
+			d.indicated = append(d.indicated, cIndicatorCell{x: x - 1, y: y, r: '['})
+		}
+		if x+1 < d.w {
+			d.indicated = append(d.indicated, cIndicatorCell{x: x + 1, y: y, r: ']'})
+		}
+	} else {
+		d.indicated = append(d.indicated, cIndicatorCell{x: x, y: y})
+	}
+	for _, cell := range d.indicated {
+		d.cells.SetDirty(cell.x, cell.y, true)
+	}
+}
+
+// indicatedAt returns true if the cell is part of the cursor indicator
+func (d *CScreen) indicatedAt(x, y int) bool {
+	for _, cell := range d.indicated {
+		if cell.x == x && cell.y == y {
+			return true
+		}
+	}
+	return false
+}
+
+// indicate returns the rune and style to draw for the cell, which differ from
+// those given when the cell is part of the cursor indicator
+func (d *CScreen) indicate(x, y int, mc rune, style paint.Style) (rune, paint.Style) {
+	for _, cell := range d.indicated {
+		if cell.x != x || cell.y != y {
+			continue
+		}
+		switch d.indicator {
+		case CursorIndicatorInverse:
+			_, _, attrs := style.Decompose()
+			style = style.Reverse(!attrs.IsReverse())
+		case CursorIndicatorBrackets:
+			mc = cell.r
+			style = style.Foreground(d.cursorColor).Bold(true).Blink(true)
+		case CursorIndicatorBlock:
+			style = style.Background(d.cursorColor).Foreground(paint.ColorBlack).Reverse(false)
+		}
+		break
+	}
+	return mc, style
+}
+
+// cursorCell returns the style to draw a cell with the cursor attribute, as a
+// block when the cursor indicator is a block and otherwise in reverse, like the
+// block cursor of most terminals
+func (d *CScreen) cursorCell(style paint.Style) paint.Style {
+	style = style.Cursor(false)
+	if d.indicator == CursorIndicatorBlock {
+		return style.Background(d.cursorColor).Foreground(paint.ColorBlack).Reverse(false)
+	}
+	_, _, attrs := style.Decompose()
+	return style.Reverse(!attrs.IsReverse())
+}
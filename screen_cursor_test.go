@@ -0,0 +1,97 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// cursorTestDraw draws the screen with the cursor at the given position,
+// returning the bytes written
+func cursorTestDraw(d *CScreen, x, y int) string {
+	d.ShowCursor(x, y)
+	d.cx, d.cy = -1, -1
+	d.buf.Reset()
+	d.drawnCells = 0
+	d.buffering = true
+	d.prepareIndicator()
+	d.drawCells()
+	d.showCursor()
+	d.buffering = false
+	return d.buf.String()
+}
+
+func TestScreenCursorIndicator(t *testing.T) {
+	Convey("Cursor indicators", t, func() {
+		d := newWireTestScreen(0)
+		for x := 0; x < d.w; x++ {
+			d.cells.SetCell(x, 1, 'a', nil, paint.StyleDefault)
+		}
+		cursorTestDraw(d, -1, -1)
+		Convey("native", func() {
+			d.SetCursorIndicator(CursorIndicatorNative, paint.ColorDefault)
+			So(d.indicating(), ShouldBeFalse)
+			out := cursorTestDraw(d, 2, 1)
+			So(out, ShouldEndWith, d.ti.ShowCursor)
+			So(d.indicated, ShouldBeEmpty)
+		})
+		Convey("inverse", func() {
+			d.SetCursorIndicator(CursorIndicatorInverse, paint.ColorDefault)
+			out := cursorTestDraw(d, 2, 1)
+			So(out, ShouldContainSubstring, d.ti.Reverse)
+			So(out, ShouldNotContainSubstring, d.ti.ShowCursor)
+			So(d.indicatedAt(2, 1), ShouldBeTrue)
+			// moving the cursor redraws the previous position
+			out = cursorTestDraw(d, 5, 1)
+			So(d.indicatedAt(2, 1), ShouldBeFalse)
+			So(d.indicatedAt(5, 1), ShouldBeTrue)
+			So(d.drawnCells, ShouldEqual, 2)
+			So(out, ShouldContainSubstring, d.ti.TGoto(2, 1))
+			So(out, ShouldContainSubstring, d.ti.TGoto(5, 1))
+			// hiding the cursor removes the indicator
+			cursorTestDraw(d, -1, -1)
+			So(d.indicated, ShouldBeEmpty)
+			So(d.cells.Dirty(5, 1), ShouldBeFalse)
+		})
+		Convey("brackets", func() {
+			d.SetCursorIndicator(CursorIndicatorBrackets, paint.ColorDefault)
+			So(d.cursorColor, ShouldEqual, DefaultCursorIndicatorColor)
+			out := cursorTestDraw(d, 2, 1)
+			So(out, ShouldContainSubstring, "[")
+			So(out, ShouldContainSubstring, "]")
+			So(out, ShouldContainSubstring, d.ti.Blink)
+			So(d.indicatedAt(1, 1), ShouldBeTrue)
+			So(d.indicatedAt(3, 1), ShouldBeTrue)
+			So(d.indicatedAt(2, 1), ShouldBeFalse)
+			cursorTestDraw(d, 0, 1)
+			So(d.indicated, ShouldHaveLength, 1)
+		})
+		Convey("block", func() {
+			d.SetCursorIndicator(CursorIndicatorBlock, paint.ColorRed)
+			mc, style := d.indicate(2, 1, 'a', paint.StyleDefault)
+			So(mc, ShouldEqual, 'a')
+			So(style, ShouldEqual, paint.StyleDefault)
+			cursorTestDraw(d, 2, 1)
+			mc, style = d.indicate(2, 1, 'a', paint.StyleDefault)
+			_, bg, _ := style.Decompose()
+			So(mc, ShouldEqual, 'a')
+			So(bg, ShouldEqual, paint.ColorRed)
+		})
+	})
+}
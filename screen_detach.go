@@ -0,0 +1,214 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/log"
+)
+
+// DetachPollInterval is how often a detached terminal is checked for its
+// return, see: EventDetach
+var DetachPollInterval = time.Millisecond * 250
+
+// Detached returns true if the terminal has gone away and nothing is being
+// written to it, see: EventDetach
+func (d *CScreen) Detached() bool {
+	return atomic.LoadInt32(&d.detached) == 1
+}
+
+// probeTty returns an error if the terminal no longer responds
+func (d *CScreen) probeTty() (err error) {
+	d.Lock()
+	defer d.Unlock()
+	if d.tty == nil {
+		return ErrNoDisplay
+	}
+	_, _, err = d.getWinSize()
+	return
+}
+
+// detach stops all output to the terminal, posts an EventDetach and starts
+// waiting for the terminal to return
+func (d *CScreen) detach(cause error) {
+	if !atomic.CompareAndSwapInt32(&d.detached, 0, 1) {
+		return
+	}
+	log.WarnF("terminal detached: %v", cause)
+	_ = d.PostEvent(NewEventDetach(cause))
+	Go(d.awaitReattach)
+}
+
+// awaitReattach polls the terminal until it responds again, restores the
+// terminal modes and posts an EventDetach for the reattachment. The content
+// of the screen is not drawn, the Display performs a Sync once notified.
+func (d *CScreen) awaitReattach() {
+	ticker := time.NewTicker(DetachPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-d.quit:
+			return
+		case <-ticker.C:
+		}
+		if d.probeTty() != nil {
+			continue
+		}
+		d.Lock()
+		if !d.Detached() {
+			// given another terminal, see: Reattach
+			d.Unlock()
+			return
+		}
+		if err := d.engage(); err != nil {
+			d.Unlock()
+			log.ErrorF("error reattaching terminal: %v", err)
+			continue
+		}
+		d.resume()
+		select {
+		case d.reattached <- struct{}{}:
+		default:
+		}
+		d.Unlock()
+		log.InfoF("terminal reattached")
+		_ = d.PostEvent(NewEventReattach())
+		return
+	}
+}
+
+// Detach leaves the terminal as if the application had finished and closes
+// it, while the application and the content of the Screen are kept. Nothing is
+// written until Reattach gives the Screen a terminal again. An EventDetach is
+// posted with ErrDetached as the cause, unless the terminal had already gone
+// away. A read of the terminal already waiting for input is only interrupted
+// when TtyCloseWithStiRead is enabled.
+func (d *CScreen) Detach() (err error) {
+	d.Lock()
+	if d.finished {
+		d.Unlock()
+		return ErrNoDisplay
+	}
+	gone := d.Detached()
+	if !gone {
+		d.leave()
+		atomic.StoreInt32(&d.detached, 1)
+	}
+	d.release()
+	d.Unlock()
+	if !gone {
+		log.InfoF("terminal detached on request")
+		_ = d.PostEvent(NewEventDetach(ErrDetached))
+	}
+	return
+}
+
+// Reattach gives the Screen the terminal at the given path, or the terminal
+// last used when the path is empty, and posts an EventDetach for the
+// reattachment. An attached Screen leaves its terminal first, moving to the
+// new one, and is left detached when the new terminal cannot be opened. The
+// content of the Screen is not drawn, the Display performs a Sync once
+// notified.
+func (d *CScreen) Reattach(ttyPath string) (err error) {
+	d.Lock()
+	if d.finished {
+		d.Unlock()
+		return ErrNoDisplay
+	}
+	moving := !d.Detached()
+	if moving {
+		d.leave()
+		atomic.StoreInt32(&d.detached, 1)
+	}
+	d.release()
+	if err = d.reopen(ttyPath); err != nil {
+		d.Unlock()
+		if moving {
+			// left without a terminal
+			_ = d.PostEvent(NewEventDetach(ErrDetached))
+		}
+		return
+	}
+	d.resume()
+	// the input loop of the previous terminal stops, see: awaitInput
+	close(d.reattached)
+	d.reattached = make(chan struct{}, 1)
+	d.Unlock()
+	log.InfoF("terminal reattached: %v", ttyPath)
+	Go(d.inputLoop)
+	_ = d.PostEvent(NewEventReattach())
+	return
+}
+
+// leave restores the terminal modes changed by the Screen, as when finished,
+// without changing the modes to restore once reattached
+func (d *CScreen) leave() {
+	d.TPuts(d.ti.ShowCursor)
+	d.TPuts(d.ti.AttrOff)
+	d.TPuts(d.ti.Clear)
+	d.TPuts(d.ti.ExitCA)
+	d.TPuts(d.ti.ExitKeypad)
+	d.TPuts(d.disablePaste)
+	if d.keyPhases {
+		// enabled again with the modes of the Display
+		d.keyPhases = false
+		d.TPuts("\x1b[<u")
+	}
+	if d.disambiguate {
+		d.disambiguate = false
+		d.TPuts("\x1b[<u")
+	}
+	d.disableMouse()
+}
+
+// resume prepares the terminal for drawing once reattached
+func (d *CScreen) resume() {
+	d.cx, d.cy = -1, -1
+	d.curStyle = paint.StyleInvalid
+	atomic.StoreInt32(&d.detached, 0)
+	d.TPuts(d.ti.EnterCA)
+	d.TPuts(d.ti.HideCursor)
+	d.TPuts(d.ti.EnableAcs)
+}
+
+// released returns true if the terminal is no longer the one given, see:
+// Detach
+func (d *CScreen) released(tty io.ReadWriter) bool {
+	d.Lock()
+	defer d.Unlock()
+	return d.tty != tty
+}
+
+// awaitInput blocks the input loop of the given terminal while detached,
+// returning false if the screen is finished or the terminal was replaced
+func (d *CScreen) awaitInput(tty io.ReadWriter) bool {
+	d.Lock()
+	reattached := d.reattached
+	replaced := d.tty != tty
+	d.Unlock()
+	if replaced {
+		return false
+	}
+	select {
+	case <-reattached:
+		return !d.released(tty)
+	case <-d.quit:
+		return false
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+func TestScreenDetach(t *testing.T) {
+	Convey("Detached terminals", t, func() {
+		d := newWireTestScreen(0)
+		d.evCh = make(chan Event, 4)
+		d.quit = make(chan struct{})
+		d.reattached = make(chan struct{}, 1)
+		So(d.Detached(), ShouldBeFalse)
+		So(ttyGone(errors.New("other")), ShouldBeFalse)
+		cause := &os.PathError{Op: "write", Path: "/dev/tty", Err: syscall.EIO}
+		So(ttyGone(cause), ShouldBeTrue)
+		d.detach(cause)
+		So(d.Detached(), ShouldBeTrue)
+		evt, ok := (<-d.evCh).(*EventDetach)
+		So(ok, ShouldBeTrue)
+		So(evt.Attached(), ShouldBeFalse)
+		So(evt.Cause(), ShouldEqual, cause)
+		// detaching again posts nothing
+		d.detach(cause)
+		So(d.evCh, ShouldHaveLength, 0)
+		// nothing is written while detached and the cells remain dirty
+		d.cells.SetCell(0, 0, 'x', nil, paint.StyleDefault)
+		d.buffering = true
+		d.TPuts("test")
+		d.draw()
+		So(d.buf.Len(), ShouldEqual, 0)
+		So(d.cells.Dirty(0, 0), ShouldBeTrue)
+		// the terminal never returns
+		close(d.quit)
+		So(d.awaitInput(d.tty), ShouldBeFalse)
+	})
+}
+
+func TestScreenDetachRequest(t *testing.T) {
+	Convey("Terminals detached on request", t, func() {
+		d := newWireTestScreen(0)
+		d.evCh = make(chan Event, 4)
+		d.quit = make(chan struct{})
+		d.reattached = make(chan struct{}, 1)
+		tty := &bytes.Buffer{}
+		d.tty = tty
+		d.disablePaste = "\x1b[?2004l"
+		So(d.Detach(), ShouldBeNil)
+		So(d.Detached(), ShouldBeTrue)
+		So(d.released(tty), ShouldBeTrue)
+		So(d.probeTty(), ShouldEqual, ErrNoDisplay)
+		// the terminal is left as if finished
+		So(tty.String(), ShouldContainSubstring, d.ti.ExitCA)
+		So(tty.String(), ShouldEndWith, d.disablePaste)
+		evt, ok := (<-d.evCh).(*EventDetach)
+		So(ok, ShouldBeTrue)
+		So(evt.Attached(), ShouldBeFalse)
+		So(evt.Cause(), ShouldEqual, ErrDetached)
+		// detaching again posts nothing
+		So(d.Detach(), ShouldBeNil)
+		So(d.evCh, ShouldHaveLength, 0)
+		d.cells.SetCell(0, 0, 'x', nil, paint.StyleDefault)
+		d.draw()
+		So(d.cells.Dirty(0, 0), ShouldBeTrue)
+		close(d.quit)
+		d.finished = true
+		So(d.Detach(), ShouldEqual, ErrNoDisplay)
+		So(d.Reattach(""), ShouldEqual, ErrNoDisplay)
+	})
+}
+
+func TestDisplayDetach(t *testing.T) {
+	Convey("Display detach and reattach", t, WithDisplayManager(func(d Display) {
+		So(d.IsDetached(), ShouldBeFalse)
+		So(d.GetDetachTimeout(), ShouldEqual, DefaultDetachTimeout)
+		d.SetDetachTimeout(time.Millisecond)
+		var signals []Signal
+		for _, signal := range []Signal{SignalDisplayDetached, SignalDisplayReattached} {
+			signal := signal
+			d.Connect(signal, "test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				signals = append(signals, signal)
+				return enums.EVENT_PASS
+			})
+		}
+		cd := d.(*CDisplay)
+		So(cd.processDetach(NewEventDetach(syscall.EIO)), ShouldEqual, enums.EVENT_STOP)
+		So(cd.detachTimer, ShouldNotBeNil)
+		So(cd.processDetach(NewEventReattach()), ShouldEqual, enums.EVENT_STOP)
+		So(cd.detachTimer, ShouldBeNil)
+		So(signals, ShouldResemble, []Signal{SignalDisplayDetached, SignalDisplayReattached})
+		// detached on request, the Display waits indefinitely
+		So(cd.processDetach(NewEventDetach(ErrDetached)), ShouldEqual, enums.EVENT_STOP)
+		So(cd.detachTimer, ShouldBeNil)
+		So(d.Detach(), ShouldEqual, ErrNoDisplay) // offscreen
+		So(d.Reattach(""), ShouldEqual, ErrNoDisplay)
+	}))
+}
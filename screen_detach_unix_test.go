@@ -0,0 +1,96 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris zos
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/creack/pty"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+func TestScreenReattach(t *testing.T) {
+	Convey("Reattaching to another terminal", t, func() {
+		ptmx, tts, err := pty.Open()
+		So(err, ShouldBeNil)
+		defer func() { _ = ptmx.Close() }()
+		defer func() { _ = tts.Close() }()
+		So(pty.Setsize(ptmx, &pty.Winsize{Rows: 5, Cols: 30}), ShouldBeNil)
+
+		d := newWireTestScreen(0)
+		d.evCh = make(chan Event, 8)
+		d.quit = make(chan struct{})
+		d.reattached = make(chan struct{}, 1)
+		d.keyChan = make(chan []byte, 8)
+		d.ttyReadLock = &sync.Mutex{}
+		d.sigWinch = make(chan os.Signal, 1)
+		d.sigHup = make(chan os.Signal, 1)
+		d.tty = &bytes.Buffer{}
+		So(d.Detach(), ShouldBeNil)
+		So(d.Reattach(filepath.Join(t.TempDir(), "missing")), ShouldNotBeNil)
+		So(d.Detached(), ShouldBeTrue)
+		So(d.Reattach(tts.Name()), ShouldBeNil)
+		defer func() {
+			// the input loop waits once the terminal is gone
+			_ = ptmx.Close()
+			for !d.Detached() {
+				time.Sleep(time.Millisecond)
+			}
+			close(d.quit)
+			d.Lock()
+			d.release()
+			d.Unlock()
+		}()
+		So(d.Detached(), ShouldBeFalse)
+		So(d.ttyPath, ShouldEqual, tts.Name())
+		var reattached bool
+		for !reattached {
+			if evt, ok := (<-d.evCh).(*EventDetach); ok {
+				reattached = evt.Attached()
+			}
+		}
+		// drawn to the new terminal
+		d.Lock()
+		d.resize()
+		So(d.w, ShouldEqual, 30)
+		So(d.h, ShouldEqual, 5)
+		d.cells.SetCell(0, 0, 'x', nil, paint.StyleDefault)
+		d.draw()
+		d.Unlock()
+		output := make([]byte, 4096)
+		_ = ptmx.SetReadDeadline(time.Now().Add(time.Second))
+		n, _ := ptmx.Read(output)
+		So(string(output[:n]), ShouldContainSubstring, d.ti.EnterCA)
+		// read from the new terminal
+		_, err = ptmx.Write([]byte("k"))
+		So(err, ShouldBeNil)
+		select {
+		case chunk := <-d.keyChan:
+			So(string(chunk), ShouldEqual, "k")
+		case <-time.After(time.Second):
+			So("no input", ShouldBeEmpty)
+		}
+	})
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	"github.com/go-curses/terminfo"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScreenEnv(t *testing.T) {
+	Convey("Screen environment", t, func() {
+		env := map[string]string{"TERM": "xterm", "LINES": "42"}
+		lookup := func(key string) (value string, ok bool) {
+			value, ok = env[key]
+			return
+		}
+		ti, err := terminfo.LookupTerminfo("xterm")
+		So(err, ShouldBeNil)
+		So(ti.SetFgRGB, ShouldEqual, "")
+		s, err := NewScreenWithEnv(lookup)
+		So(err, ShouldBeNil)
+		cs := s.(*CScreen)
+		So(cs.ti.Name, ShouldEqual, ti.Name)
+		So(cs.ti.SetFgRGB, ShouldEqual, "")
+		So(cs.getenv("LINES"), ShouldEqual, "42")
+		// the client terminal supports direct color
+		env["COLORTERM"] = "truecolor"
+		s, err = NewScreenWithEnv(lookup)
+		So(err, ShouldBeNil)
+		cs = s.(*CScreen)
+		So(cs.ti.SetFgRGB, ShouldNotEqual, "")
+		So(cs.ti.SetFgBgRGB, ShouldNotEqual, "")
+		So(ti.SetFgRGB, ShouldEqual, "")
+		// unknown terminals are not supported
+		env["TERM"] = "not-a-real-terminal"
+		_, err = NewScreenWithEnv(lookup)
+		So(err, ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newInputTestScreen() *CScreen {
+	s, err := NewScreenWithEnv(func(key string) (value string, ok bool) {
+		if key == "TERM" {
+			return "xterm-256color", true
+		}
+		return "", false
+	})
+	So(err, ShouldBeNil)
+	return s.(*CScreen)
+}
+
+func TestScreenEscapeDelay(t *testing.T) {
+	Convey("Escape delay", t, func() {
+		screen := newInputTestScreen()
+		screen.SetKeyTiming(time.Second)
+		screen.SetEscapeDelay(-time.Second)
+		So(screen.inputDelay(bytes.NewBufferString("\x1b")), ShouldEqual, time.Second)
+		screen.SetEscapeDelay(time.Millisecond)
+		So(screen.inputDelay(bytes.NewBufferString("\x1b")), ShouldEqual, time.Millisecond)
+		So(screen.inputDelay(bytes.NewBufferString("\x1b[")), ShouldEqual, time.Second)
+
+		// a lone ESC is delivered without waiting for the key timing
+		screen.SetEscapeDelay(0)
+		screen.evCh = make(chan Event, EventQueueSize)
+		screen.keyChan = make(chan []byte, EventKeyQueueSize)
+		screen.keyTimer = time.NewTimer(time.Second)
+		screen.quit = make(chan struct{})
+		screen.inDoneQ = make(chan struct{})
+		go screen.mainLoop()
+		defer func() {
+			close(screen.quit)
+			<-screen.inDoneQ
+		}()
+		screen.keyChan <- []byte("\x1b")
+		var evt Event
+		select {
+		case evt = <-screen.evCh:
+		case <-time.After(time.Second / 2):
+		}
+		So(evt, ShouldNotBeNil)
+		So(evt.(*EventKey).Key(), ShouldEqual, KeyEsc)
+		// while sequences still wait for their remainder
+		screen.keyChan <- []byte("\x1b[1;5")
+		screen.keyChan <- []byte("A")
+		select {
+		case evt = <-screen.evCh:
+		case <-time.After(time.Second / 2):
+		}
+		So(evt.(*EventKey).Key(), ShouldEqual, KeyUp)
+		So(evt.(*EventKey).Modifiers(), ShouldEqual, ModCtrl)
+	})
+
+	Convey("Escape disambiguation", t, func() {
+		screen := newInputTestScreen()
+		output := &bytes.Buffer{}
+		screen.tty = output
+		screen.probing, screen.probeSent = true, time.Now()
+		var evs []Event
+		buf := bytes.NewBufferString("\x1b[?0u")
+		_, comp := screen.parseCapabilities(buf, &evs)
+		So(comp, ShouldBeTrue)
+		So(screen.disambiguate, ShouldBeTrue)
+		So(output.String(), ShouldEqual, "\x1b[>1u")
+		evs = screen.collectEventsFromInput(bytes.NewBufferString("\x1b[27u"), false)
+		So(evs, ShouldHaveLength, 1)
+		So(evs[0].(*EventKey).Key(), ShouldEqual, KeyEsc)
+	})
+	Convey("Raw input", t, func() {
+		screen := newInputTestScreen()
+		screen.evCh = make(chan Event, EventQueueSize)
+		screen.keyChan = make(chan []byte, EventKeyQueueSize)
+		screen.keyTimer = time.NewTimer(time.Second)
+		screen.quit = make(chan struct{})
+		screen.inDoneQ = make(chan struct{})
+		go screen.mainLoop()
+		defer func() {
+			close(screen.quit)
+			<-screen.inDoneQ
+		}()
+		next := func() (evt Event) {
+			select {
+			case evt = <-screen.evCh:
+			case <-time.After(time.Second / 2):
+			}
+			return
+		}
+
+		// a partial sequence is parsed as is before raw input starts
+		screen.keyChan <- []byte("\x1b")
+		time.Sleep(10 * time.Millisecond)
+		screen.SetRawInput(true)
+		So(screen.RawInput(), ShouldBeTrue)
+		screen.keyChan <- []byte("\x1b[A")
+		evt := next()
+		So(evt.(*EventKey).Key(), ShouldEqual, KeyEsc)
+		evt = next()
+		So(evt, ShouldHaveSameTypeAs, &EventRaw{})
+		So(evt.(*EventRaw).Data(), ShouldResemble, []byte("\x1b[A"))
+
+		screen.SetRawInput(false)
+		So(screen.RawInput(), ShouldBeFalse)
+		screen.keyChan <- []byte("\x1b[A")
+		evt = next()
+		So(evt.(*EventKey).Key(), ShouldEqual, KeyUp)
+	})
+}
@@ -0,0 +1,49 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+// ScreenModes is the registry of terminal modes enabled on a Screen. The
+// Display records the modes of its Screen when released and re-applies them
+// once captured again, so that modes enabled at runtime survive Display.Call
+// and Display.Command even though the terminal was reset in between.
+type ScreenModes struct {
+	// Mouse is true when mouse reporting is enabled with MouseFlags.
+	Mouse      bool
+	MouseFlags MouseFlags
+	// Paste is true when bracketed paste mode is enabled.
+	Paste bool
+	// KeyPhases is true when the kitty keyboard protocol reporting key
+	// repeat and release events is enabled.
+	KeyPhases bool
+}
+
+// applyScreenModes enables or disables each of the modes on the given Screen
+func applyScreenModes(screen Screen, modes ScreenModes) {
+	if modes.Mouse {
+		screen.EnableMouse(modes.MouseFlags)
+	} else {
+		screen.DisableMouse()
+	}
+	if modes.Paste {
+		screen.EnablePaste()
+	} else {
+		screen.DisablePaste()
+	}
+	if modes.KeyPhases {
+		screen.EnableKeyPhases()
+	} else {
+		screen.DisableKeyPhases()
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestScreenModes(t *testing.T) {
+	Convey("Screen mode registry", t, func() {
+		s := NewTestingScreen(t, "UTF-8")
+		So(s.Init(), ShouldBeNil)
+		defer s.Close()
+		So(s.Modes(), ShouldResemble, ScreenModes{})
+		s.EnableMouse()
+		s.EnablePaste()
+		s.EnableKeyPhases()
+		So(s.Modes(), ShouldResemble, ScreenModes{
+			Mouse:      true,
+			MouseFlags: MouseMotionEvents,
+			Paste:      true,
+			KeyPhases:  true,
+		})
+		s.ApplyModes(ScreenModes{Mouse: true, MouseFlags: MouseButtonEvents})
+		So(s.Modes(), ShouldResemble, ScreenModes{Mouse: true, MouseFlags: MouseButtonEvents})
+		s.DisableMouse()
+		So(s.Modes(), ShouldResemble, ScreenModes{})
+	})
+	Convey("Display capture re-applies modes", t, WithDisplayManager(func(d Display) {
+		So(d.Screen().Modes().Paste, ShouldBeTrue)
+		before := d.Screen()
+		before.EnableMouse(MouseDragEvents)
+		before.DisablePaste()
+		d.EnableKeyPhases()
+		expected := before.Modes()
+		So(expected, ShouldResemble, ScreenModes{
+			Mouse:      true,
+			MouseFlags: MouseDragEvents,
+			KeyPhases:  true,
+		})
+		d.ReleaseDisplay()
+		So(d.Screen(), ShouldBeNil)
+		So(d.CaptureDisplay(), ShouldBeNil)
+		after := d.Screen()
+		So(after, ShouldNotEqual, before)
+		So(after.Modes(), ShouldResemble, expected)
+		// modes disabled at runtime stay disabled
+		after.DisableMouse()
+		d.ReleaseDisplay()
+		So(d.CaptureDisplay(), ShouldBeNil)
+		So(d.Screen().Modes(), ShouldResemble, ScreenModes{KeyPhases: true})
+	}))
+}
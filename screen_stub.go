@@ -1,5 +1,5 @@
-//go:build js || nacl || plan9 || windows
-// +build js nacl plan9 windows
+//go:build js || nacl || plan9
+// +build js nacl plan9
 
 // Copyright (c) 2022-2023  The Go-Curses Authors
 // Copyright 2021 The TCell Authors
@@ -18,32 +18,39 @@
 
 package cdk
 
-// NB: We might someday wish to move Windows to this model.   However,
-// that would probably mean sacrificing some of the richer key reporting
-// that we can obtain with the console API present on Windows.
-
 func (d *CScreen) engage() error {
-	return ErrNoScreen
+	return ErrNoDisplay
 }
 
 func (d *CScreen) reengage() error {
-	return ErrNoScreen
+	return ErrNoDisplay
 }
 
 func (d *CScreen) disengage() {
 }
 
-func (d *CScreen) initialize() error {
-	return ErrNoScreen
+func (d *CScreen) release() {
+}
+
+func (d *CScreen) reopen(_ string) error {
+	return ErrNoDisplay
+}
+
+func (d *CScreen) initialize() (w, h int, err error) {
+	return 0, 0, ErrNoDisplay
 }
 
 func (d *CScreen) finalize() {
 }
 
 func (d *CScreen) getWinSize() (int, int, error) {
-	return 0, 0, ErrNoScreen
+	return 0, 0, ErrNoDisplay
 }
 
 func (d *CScreen) Beep() error {
-	return ErrNoScreen
+	return ErrNoDisplay
+}
+
+func ttyGone(err error) bool {
+	return false
 }
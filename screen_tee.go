@@ -0,0 +1,131 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"github.com/go-curses/cdk/log"
+)
+
+// TeeRecorder is implemented by writers given to Screen.TeeOutput that need
+// the terminal type and size of the Screen, see: CAsciicastRecorder
+type TeeRecorder interface {
+	io.Writer
+	// SetTerm is given the terminal type of the Screen
+	SetTerm(term string)
+	// Resize is given the size of the Screen, when teeing starts and each
+	// time the Screen is resized
+	Resize(w, h int)
+}
+
+// TeeOutput duplicates everything written to the terminal to the given writer,
+// as the buffered writes are sent to the terminal. The whole Screen is drawn
+// again, so that the output to the writer starts with a complete frame. If the
+// writer fails, the output is no longer duplicated. A nil writer stops the
+// duplication of output.
+func (d *CScreen) TeeOutput(w io.Writer) {
+	d.Lock()
+	defer d.Unlock()
+	d.tee = w
+	if w == nil {
+		return
+	}
+	if recorder, ok := w.(TeeRecorder); ok {
+		if d.ti != nil {
+			recorder.SetTerm(d.ti.Name)
+		}
+		recorder.Resize(d.w, d.h)
+	}
+	d.cx = -1
+	d.cy = -1
+	d.clear = true
+	d.cells.Invalidate()
+	if d.tty != nil && !d.finished {
+		d.draw()
+	}
+}
+
+// output sends the data to the terminal and to the writer given to TeeOutput,
+// the Screen must be locked by the caller
+func (d *CScreen) output(data []byte) (err error) {
+	_, err = d.tty.Write(data)
+	if d.tee != nil {
+		if _, e := d.tee.Write(data); e != nil {
+			log.ErrorF("error duplicating screen output, duplication stopped: %v", e)
+			d.tee = nil
+		}
+	}
+	return
+}
+
+// teeResize gives the new size of the Screen to the writer given to TeeOutput,
+// the Screen must be locked by the caller
+func (d *CScreen) teeResize() {
+	if recorder, ok := d.tee.(TeeRecorder); ok {
+		recorder.Resize(d.w, d.h)
+	}
+}
+
+// CAsciicastRecorder records the terminal output written to it as an asciicast
+// v2 file, with the time of each write, for replay with tools such as
+// asciinema. Given to Screen.TeeOutput, the recording follows the terminal
+// type and size of the Screen.
+type CAsciicastRecorder struct {
+	recorder *cSessionRecorder
+}
+
+// NewAsciicastRecorder returns a recorder writing an asciicast v2 file to the
+// given writer, which is closed when the recorder is closed. The header of the
+// recording is written with the first output.
+func NewAsciicastRecorder(out io.WriteCloser, title string) *CAsciicastRecorder {
+	return &CAsciicastRecorder{
+		recorder: newSessionRecorder(out, false, title, time.Now()),
+	}
+}
+
+// CreateAsciicastRecording creates the file at the given path and returns a
+// recorder writing an asciicast v2 file to it, see: NewAsciicastRecorder
+func CreateAsciicastRecording(path, title string) (recorder *CAsciicastRecorder, err error) {
+	var file *os.File
+	if file, err = os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600); err != nil {
+		return nil, err
+	}
+	return NewAsciicastRecorder(file, title), nil
+}
+
+// Write records the data as terminal output
+func (r *CAsciicastRecorder) Write(p []byte) (n int, err error) {
+	r.recorder.record("o", p)
+	return len(p), nil
+}
+
+// SetTerm records the terminal type in the header of the recording
+func (r *CAsciicastRecorder) SetTerm(term string) {
+	r.recorder.setTerm(term)
+}
+
+// Resize records the new size of the terminal, as the size given in the
+// header when nothing has been recorded yet
+func (r *CAsciicastRecorder) Resize(w, h int) {
+	r.recorder.resize(w, h)
+}
+
+// Close writes any pending output and closes the recording
+func (r *CAsciicastRecorder) Close() (err error) {
+	return r.recorder.close()
+}
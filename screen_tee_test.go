@@ -0,0 +1,108 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+type testFailingWriter struct{}
+
+func (w *testFailingWriter) Write(_ []byte) (n int, err error) {
+	return 0, errors.New("failed")
+}
+
+func TestScreenTeeOutput(t *testing.T) {
+	Convey("Screen output duplication", t, func() {
+		d := newWireTestScreen(0)
+		tty := &bytes.Buffer{}
+		d.tty = tty
+		d.cells.SetCell(1, 1, 'a', nil, paint.StyleDefault)
+		d.draw()
+		tty.Reset()
+
+		Convey("starts with a complete frame", func() {
+			tee := &bytes.Buffer{}
+			d.TeeOutput(tee)
+			So(tee.String(), ShouldEqual, tty.String())
+			So(tee.String(), ShouldStartWith, d.ti.HideCursor)
+			So(tee.String(), ShouldContainSubstring, d.ti.Clear)
+			So(tee.String(), ShouldContainSubstring, "a")
+			d.cells.SetCell(2, 1, 'b', nil, paint.StyleDefault)
+			d.draw()
+			d.TPuts(d.ti.Bell)
+			So(tee.String(), ShouldEqual, tty.String())
+			So(tee.String(), ShouldEndWith, d.ti.Bell)
+
+			Convey("until stopped", func() {
+				d.TeeOutput(nil)
+				size := tee.Len()
+				d.cells.SetCell(3, 1, 'c', nil, paint.StyleDefault)
+				d.draw()
+				So(tee.Len(), ShouldEqual, size)
+				So(tty.Len(), ShouldBeGreaterThan, size)
+			})
+		})
+
+		Convey("stops when the writer fails", func() {
+			d.TeeOutput(&testFailingWriter{})
+			So(d.tee, ShouldBeNil)
+			So(tty.String(), ShouldContainSubstring, "a")
+		})
+
+		Convey("recorded as an asciicast", func() {
+			path := filepath.Join(t.TempDir(), "screen.cast")
+			recorder, err := CreateAsciicastRecording(path, "test")
+			So(err, ShouldBeNil)
+			d.TeeOutput(recorder)
+			d.w, d.h = 20, 2
+			d.teeResize()
+			So(recorder.Close(), ShouldBeNil)
+			file, err := os.Open(path)
+			So(err, ShouldBeNil)
+			defer func() { _ = file.Close() }()
+			var lines []string
+			scanner := bufio.NewScanner(file)
+			for scanner.Scan() {
+				lines = append(lines, scanner.Text())
+			}
+			So(lines, ShouldHaveLength, 3)
+			var header map[string]interface{}
+			So(json.Unmarshal([]byte(lines[0]), &header), ShouldBeNil)
+			So(header["version"], ShouldEqual, 2)
+			So(header["width"], ShouldEqual, 40)
+			So(header["height"], ShouldEqual, 3)
+			So(header["title"], ShouldEqual, "test")
+			So(header["env"], ShouldResemble, map[string]interface{}{"TERM": d.ti.Name})
+			var output, resize []interface{}
+			So(json.Unmarshal([]byte(lines[1]), &output), ShouldBeNil)
+			So(output[1], ShouldEqual, "o")
+			So(output[2], ShouldEqual, tty.String())
+			So(json.Unmarshal([]byte(lines[2]), &resize), ShouldBeNil)
+			So(resize[1], ShouldEqual, "r")
+			So(resize[2], ShouldEqual, "20x2")
+		})
+	})
+}
@@ -19,13 +19,14 @@ package cdk
 
 import (
 	// "fmt"
-	"os"
+	"errors"
 	"os/signal"
 	"strconv"
 	"syscall"
 
 	"github.com/go-curses/term"
 
+	cterm "github.com/go-curses/cdk/lib/term"
 	"github.com/go-curses/cdk/log"
 )
 
@@ -68,6 +69,57 @@ func (d *CScreen) reengage() (err error) {
 	return
 }
 
+// release restores the terminal and closes it, leaving the Screen without a
+// terminal until it is reopened, see: Detach
+func (d *CScreen) release() {
+	if d.term != nil {
+		if err := term.CBreakMode(d.term); err != nil {
+			log.ErrorF("error setting CBreakMode: %v", err)
+		}
+		if err := d.term.Restore(); err != nil {
+			log.ErrorF("error restoring terminal: %v", err)
+		}
+		if d.ttyReadSti {
+			d.ttyReadLock.Lock()
+			if d.ttyReading {
+				if e := d.term.Tiocsti(" "); e != nil {
+					log.Error(e)
+				}
+			}
+			d.ttyReadLock.Unlock()
+		}
+		released := d.term
+		Go(func() {
+			if err := released.Close(); err != nil {
+				log.ErrorF("error closing terminal: %v", err)
+			}
+		})
+	}
+	if d.ttyFile != nil && !d.ttyKeepFH {
+		if err := d.ttyFile.Close(); err != nil {
+			log.ErrorF("error closing ttyFile: %v", err)
+		}
+	}
+	d.ttyFile = nil
+	d.term = nil
+	d.tty = nil
+}
+
+// reopen opens the terminal at the given path, or the terminal last used when
+// the path is empty, see: Reattach
+func (d *CScreen) reopen(ttyPath string) (err error) {
+	if ttyPath != "" {
+		d.ttyPath = ttyPath
+	}
+	_, _, d.ttyType, _ = cterm.CharDeviceInfo(d.ttyPath)
+	if _, _, err = d.initialize(); err != nil && d.term != nil {
+		_ = d.term.Close()
+		d.term = nil
+		d.tty = nil
+	}
+	return
+}
+
 // initialize is used at application startup, and sets up the initial values
 // including file descriptors used for terminals and saving the initial state
 // so that it can be restored when the application terminates.
@@ -81,10 +133,12 @@ func (d *CScreen) initialize() (w, h int, err error) {
 			return
 		}
 	}
+	d.tty = d.term
 	if err = term.RawMode(d.term); err != nil {
 		return
 	}
 	signal.Notify(d.sigWinch, syscall.SIGWINCH)
+	signal.Notify(d.sigHup, syscall.SIGHUP)
 	if wsx, wsy, e := d.getWinSize(); e == nil && wsx != 0 && wsy != 0 {
 		w, h = wsx, wsy
 		d.cells.Resize(wsx, wsy)
@@ -97,6 +151,7 @@ func (d *CScreen) initialize() (w, h int, err error) {
 // to it's initial state.  It should not be called more than once.
 func (d *CScreen) finalize() {
 	signal.Stop(d.sigWinch)
+	signal.Stop(d.sigHup)
 	<-d.inDoneQ
 	if d.term != nil {
 		if err := term.CBreakMode(d.term); err != nil {
@@ -129,15 +184,24 @@ func (d *CScreen) finalize() {
 	}
 }
 
+// ttyGone returns true if the error is due to the terminal having gone away,
+// such as when the session is hung up
+func ttyGone(err error) bool {
+	return errors.Is(err, syscall.EIO) || errors.Is(err, syscall.ENXIO)
+}
+
 // getWinSize is called to obtain the terminal dimensions.
 func (d *CScreen) getWinSize() (w, h int, err error) {
+	if d.term == nil {
+		return -1, -1, ErrNoDisplay
+	}
 	w, h, err = d.term.Winsz()
 	if err != nil {
 		w, h = -1, -1
 		return
 	}
 	if w == 0 {
-		colsEnv := os.Getenv("COLUMNS")
+		colsEnv := d.getenv("COLUMNS")
 		if colsEnv != "" {
 			if w, err = strconv.Atoi(colsEnv); err != nil {
 				w, h = -1, -1
@@ -148,7 +212,7 @@ func (d *CScreen) getWinSize() (w, h int, err error) {
 		}
 	}
 	if h == 0 {
-		rowsEnv := os.Getenv("LINES")
+		rowsEnv := d.getenv("LINES")
 		if rowsEnv != "" {
 			if h, err = strconv.Atoi(rowsEnv); err != nil {
 				w, h = -1, -1
@@ -0,0 +1,301 @@
+//go:build windows
+// +build windows
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"errors"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/sys/windows"
+)
+
+// ConsoleResizePollInterval is how often the size of the Windows console is
+// checked, resizes are not reported to virtual terminal input
+var ConsoleResizePollInterval = time.Millisecond * 100
+
+const (
+	consoleInputMode  = windows.ENABLE_VIRTUAL_TERMINAL_INPUT | windows.ENABLE_EXTENDED_FLAGS
+	consoleOutputMode = windows.ENABLE_PROCESSED_OUTPUT | windows.ENABLE_VIRTUAL_TERMINAL_PROCESSING | windows.DISABLE_NEWLINE_AUTO_RETURN
+	consoleUTF8       = 65001
+)
+
+var (
+	kernel32               = windows.NewLazySystemDLL("kernel32.dll")
+	procGetConsoleCP       = kernel32.NewProc("GetConsoleCP")
+	procSetConsoleCP       = kernel32.NewProc("SetConsoleCP")
+	procGetConsoleOutputCP = kernel32.NewProc("GetConsoleOutputCP")
+	procSetConsoleOutputCP = kernel32.NewProc("SetConsoleOutputCP")
+)
+
+// consoleSignal is sent on the sigWinch channel when the console is resized
+type consoleSignal struct{}
+
+func (consoleSignal) String() string { return "console resized" }
+func (consoleSignal) Signal()        {}
+
+// cConsole is the Windows console switched to virtual terminal processing,
+// which accepts and produces the same control sequences as an xterm so that
+// the terminfo screen drives it without translation
+type cConsole struct {
+	in      windows.Handle
+	out     windows.Handle
+	cancel  windows.Handle
+	inMode  uint32
+	outMode uint32
+	inCP    uintptr
+	outCP   uintptr
+	stop    chan struct{}
+}
+
+func openConsole() (c *cConsole, err error) {
+	c = &cConsole{stop: make(chan struct{})}
+	if c.in, err = openConsoleHandle("CONIN$"); err != nil {
+		return nil, err
+	}
+	if c.out, err = openConsoleHandle("CONOUT$"); err != nil {
+		_ = windows.CloseHandle(c.in)
+		return nil, err
+	}
+	if c.cancel, err = windows.CreateEvent(nil, 1, 0, nil); err != nil {
+		_ = windows.CloseHandle(c.in)
+		_ = windows.CloseHandle(c.out)
+		return nil, err
+	}
+	_ = windows.GetConsoleMode(c.in, &c.inMode)
+	_ = windows.GetConsoleMode(c.out, &c.outMode)
+	c.inCP, _, _ = procGetConsoleCP.Call()
+	c.outCP, _, _ = procGetConsoleOutputCP.Call()
+	return c, nil
+}
+
+func openConsoleHandle(name string) (windows.Handle, error) {
+	return windows.CreateFile(
+		windows.StringToUTF16Ptr(name),
+		windows.GENERIC_READ|windows.GENERIC_WRITE,
+		windows.FILE_SHARE_READ|windows.FILE_SHARE_WRITE,
+		nil, windows.OPEN_EXISTING, 0, 0,
+	)
+}
+
+// raw switches the console to virtual terminal input and output in UTF-8
+func (c *cConsole) raw() (err error) {
+	if err = windows.SetConsoleMode(c.in, consoleInputMode); err != nil {
+		return
+	}
+	if err = windows.SetConsoleMode(c.out, consoleOutputMode); err != nil {
+		// consoles before Windows 10 have no virtual terminal processing
+		_ = windows.SetConsoleMode(c.in, c.inMode)
+		return
+	}
+	_, _, _ = procSetConsoleCP.Call(consoleUTF8)
+	_, _, _ = procSetConsoleOutputCP.Call(consoleUTF8)
+	return
+}
+
+// restore returns the console to the modes and code pages it had when opened
+func (c *cConsole) restore() {
+	_ = windows.SetConsoleMode(c.in, c.inMode)
+	_ = windows.SetConsoleMode(c.out, c.outMode)
+	_, _, _ = procSetConsoleCP.Call(c.inCP)
+	_, _, _ = procSetConsoleOutputCP.Call(c.outCP)
+}
+
+func (c *cConsole) size() (w, h int, err error) {
+	var info windows.ConsoleScreenBufferInfo
+	if err = windows.GetConsoleScreenBufferInfo(c.out, &info); err != nil {
+		return
+	}
+	w = int(info.Window.Right-info.Window.Left) + 1
+	h = int(info.Window.Bottom-info.Window.Top) + 1
+	return
+}
+
+// Read waits for input, returning os.ErrClosed once the console is closed
+func (c *cConsole) Read(b []byte) (n int, err error) {
+	for {
+		var event uint32
+		if event, err = windows.WaitForMultipleObjects([]windows.Handle{c.in, c.cancel}, false, windows.INFINITE); err != nil {
+			return
+		}
+		if event != windows.WAIT_OBJECT_0 {
+			return 0, os.ErrClosed
+		}
+		var done uint32
+		if err = windows.ReadFile(c.in, b, &done, nil); err != nil {
+			return
+		}
+		if done > 0 {
+			return int(done), nil
+		}
+	}
+}
+
+func (c *cConsole) Write(b []byte) (n int, err error) {
+	var done uint32
+	err = windows.WriteFile(c.out, b, &done, nil)
+	return int(done), err
+}
+
+// watch sends a consoleSignal whenever the size of the console changes
+func (c *cConsole) watch(resized chan os.Signal) {
+	w, h, _ := c.size()
+	ticker := time.NewTicker(ConsoleResizePollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.stop:
+			return
+		case <-ticker.C:
+			if nw, nh, err := c.size(); err == nil && (nw != w || nh != h) {
+				w, h = nw, nh
+				select {
+				case resized <- consoleSignal{}:
+				default:
+				}
+			}
+		}
+	}
+}
+
+func (c *cConsole) close() {
+	close(c.stop)
+	_ = windows.SetEvent(c.cancel)
+	c.restore()
+	_ = windows.CloseHandle(c.in)
+	_ = windows.CloseHandle(c.out)
+	_ = windows.CloseHandle(c.cancel)
+}
+
+// console returns the console opened by initialize, if any
+func (d *CScreen) console() (c *cConsole) {
+	c, _ = d.tty.(*cConsole)
+	return
+}
+
+// engage places the console in virtual terminal mode and establishes the
+// screen size
+func (d *CScreen) engage() error {
+	console := d.console()
+	if console == nil {
+		return ErrNoDisplay
+	}
+	if err := console.raw(); err != nil {
+		return err
+	}
+	if w, h, err := console.size(); err == nil && w > 0 && h > 0 {
+		d.cells.Resize(w, h)
+		_ = d.PostEvent(NewEventResize(w, h))
+	}
+	return nil
+}
+
+// disengage restores the console modes present when the application started
+func (d *CScreen) disengage() {
+	if console := d.console(); console != nil {
+		console.restore()
+	}
+}
+
+func (d *CScreen) reengage() (err error) {
+	if console := d.console(); console != nil {
+		console.close()
+		d.tty = nil
+	}
+	_, _, err = d.initialize()
+	return
+}
+
+// release restores the console modes and closes the console, leaving the
+// Screen without a terminal until it is reopened, see: Detach
+func (d *CScreen) release() {
+	if console := d.console(); console != nil {
+		console.restore()
+		console.close()
+	}
+	d.tty = nil
+}
+
+// reopen opens the console again, the path is ignored as the console is
+// always the terminal on Windows, see: Reattach
+func (d *CScreen) reopen(_ string) (err error) {
+	_, _, err = d.initialize()
+	return
+}
+
+// initialize opens the console, which is always the terminal on Windows
+// whatever the tty path, and switches it to virtual terminal mode
+func (d *CScreen) initialize() (w, h int, err error) {
+	var console *cConsole
+	if console, err = openConsole(); err != nil {
+		return
+	}
+	d.tty = console
+	if err = console.raw(); err != nil {
+		return
+	}
+	Go(func() { console.watch(d.sigWinch) })
+	if wsx, wsy, e := d.getWinSize(); e == nil && wsx != 0 && wsy != 0 {
+		w, h = wsx, wsy
+		d.cells.Resize(wsx, wsy)
+		_ = d.PostEvent(NewEventResize(wsx, wsy))
+	}
+	return
+}
+
+// finalize restores and closes the console
+func (d *CScreen) finalize() {
+	<-d.inDoneQ
+	if console := d.console(); console != nil {
+		console.close()
+	}
+}
+
+// ttyGone returns true if the error is due to the console having gone away
+func ttyGone(err error) bool {
+	return errors.Is(err, windows.ERROR_BROKEN_PIPE) || errors.Is(err, windows.ERROR_INVALID_HANDLE)
+}
+
+// getWinSize is called to obtain the console dimensions
+func (d *CScreen) getWinSize() (w, h int, err error) {
+	console := d.console()
+	if console == nil {
+		return -1, -1, ErrNoDisplay
+	}
+	if w, h, err = console.size(); err != nil {
+		return -1, -1, err
+	}
+	if w == 0 {
+		if w, err = strconv.Atoi(d.getenv("COLUMNS")); err != nil {
+			w = d.ti.Columns
+		}
+	}
+	if h == 0 {
+		if h, err = strconv.Atoi(d.getenv("LINES")); err != nil {
+			h = d.ti.Lines
+		}
+	}
+	return w, h, nil
+}
+
+// Beep emits a beep to the console
+func (d *CScreen) Beep() error {
+	d.writeString(string(byte(7)))
+	return nil
+}
@@ -0,0 +1,44 @@
+//go:build windows
+// +build windows
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/sys/windows"
+)
+
+func TestScreenWindows(t *testing.T) {
+	Convey("Windows console", t, func() {
+		So(ttyGone(errors.New("other")), ShouldBeFalse)
+		So(ttyGone(fmt.Errorf("read: %w", windows.ERROR_BROKEN_PIPE)), ShouldBeTrue)
+		So(ttyGone(windows.ERROR_INVALID_HANDLE), ShouldBeTrue)
+
+		screen, err := NewScreenWithEnv(func(string) (string, bool) { return "", false })
+		So(err, ShouldBeNil)
+		So(screen.(*CScreen).ti.Name, ShouldEqual, WindowsConsoleTerm)
+		So(screen.(*CScreen).ti.SetFgRGB, ShouldNotBeEmpty)
+
+		d := &CScreen{}
+		_, _, err = d.getWinSize()
+		So(err, ShouldEqual, ErrNoDisplay)
+	})
+}
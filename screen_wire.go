@@ -0,0 +1,193 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// ScreenOptimization is a set of techniques used to reduce the number of bytes
+// written to the terminal. Each requires a terminal using ECMA-48 (ANSI)
+// control sequences and is ignored otherwise.
+type ScreenOptimization uint8
+
+const (
+	// OptimizeStyleDelta changes only the attributes and colors which differ
+	// from those of the previous cell, instead of resetting all attributes and
+	// sending the complete style
+	OptimizeStyleDelta ScreenOptimization = 1 << iota
+	// OptimizeCursorMoves moves the cursor with the shortest of absolute
+	// positioning (CUP), carriage return (CR) and relative forward movement
+	// (CUF)
+	OptimizeCursorMoves
+	// OptimizeRepeat draws runs of the same character with the repeat (REP)
+	// control sequence, which not all terminals support
+	OptimizeRepeat
+)
+
+// DefaultScreenOptimization is the ScreenOptimization of new screens
+var DefaultScreenOptimization = OptimizeStyleDelta | OptimizeCursorMoves
+
+// sgrAttrs are the SGR parameters which enable and disable each attribute,
+// bold and dim share the same parameter to disable them
+var sgrAttrs = []struct {
+	attr    paint.AttrMask
+	on, off string
+}{
+	{paint.AttrBold, "1", "22"},
+	{paint.AttrDim, "2", "22"},
+	{paint.AttrItalic, "3", "23"},
+	{paint.AttrUnderline, "4", "24"},
+	{paint.AttrBlink, "5", "25"},
+	{paint.AttrReverse, "7", "27"},
+	{paint.AttrStrike, "9", "29"},
+}
+
+func (d *CScreen) SetOptimization(optimization ScreenOptimization) {
+	d.Lock()
+	defer d.Unlock()
+	d.optimize = optimization
+	d.curStyle = paint.StyleInvalid
+}
+
+// optimizing returns true if the optimization is enabled and the terminal
+// uses ECMA-48 control sequences
+func (d *CScreen) optimizing(optimization ScreenOptimization) bool {
+	return d.optimize&optimization != 0 && d.ti != nil && strings.HasPrefix(d.ti.SetCursor, "\x1b[")
+}
+
+// sendStyleDelta changes the terminal from the current style to the given
+// style, returning false if the change cannot be made incrementally
+func (d *CScreen) sendStyleDelta(style paint.Style, x, y int) bool {
+	if d.curStyle == paint.StyleInvalid || !d.optimizing(OptimizeStyleDelta) {
+		return false
+	}
+	pfg, pbg, pattrs := d.curStyle.Decompose()
+	fg, bg, attrs := style.Decompose()
+	for _, c := range []paint.Color{pfg, pbg, fg, bg} {
+		if c == paint.ColorReset {
+			// resetting either color resets both
+			return false
+		}
+	}
+	var params []string
+	for _, sgr := range sgrAttrs {
+		if pattrs&sgr.attr != 0 && attrs&sgr.attr == 0 {
+			params = appendSGR(params, sgr.off)
+		}
+	}
+	for _, sgr := range sgrAttrs {
+		if attrs&sgr.attr != 0 && (pattrs&sgr.attr == 0 || containsSGR(params, sgr.off)) {
+			params = appendSGR(params, sgr.on)
+		}
+	}
+	if d.ti.Colors > 0 {
+		if fg != pfg && !fg.Valid() {
+			params = append(params, "39")
+		}
+		if bg != pbg && !bg.Valid() {
+			params = append(params, "49")
+		}
+	}
+	if len(params) > 0 {
+		d.writeString("\x1b[" + strings.Join(params, ";") + "m")
+	}
+	// unchanged colors are not sent again
+	if fg == pfg {
+		fg = paint.ColorDefault
+	}
+	if bg == pbg {
+		bg = paint.ColorDefault
+	}
+	if fg.Valid() || bg.Valid() {
+		d.sendFgBg(fg, bg, x, y)
+	}
+	return true
+}
+
+func appendSGR(params []string, param string) []string {
+	if containsSGR(params, param) {
+		return params
+	}
+	return append(params, param)
+}
+
+func containsSGR(params []string, param string) bool {
+	for _, p := range params {
+		if p == param {
+			return true
+		}
+	}
+	return false
+}
+
+// moveCursor moves the cursor to the given position using the shortest
+// sequence available
+func (d *CScreen) moveCursor(x, y int) {
+	move := d.ti.TGoto(x, y)
+	if d.optimizing(OptimizeCursorMoves) && d.cy == y && d.cx >= 0 && d.cx < d.w {
+		var relative string
+		switch {
+		case x == 0:
+			relative = "\r"
+		case x > d.cx:
+			relative = cursorForward(x - d.cx)
+		default:
+			relative = "\r" + cursorForward(x)
+		}
+		if len(relative) < len(move) {
+			move = relative
+		}
+	}
+	d.TPuts(move)
+}
+
+func cursorForward(n int) string {
+	if n == 1 {
+		return "\x1b[C"
+	}
+	return "\x1b[" + strconv.Itoa(n) + "C"
+}
+
+// repeatCell draws the dirty cells following the one at the given position
+// which are identical to it using a single repeat sequence, returning the
+// number of cells drawn. The cell must have just been drawn by drawCell.
+func (d *CScreen) repeatCell(x, y int) (count int) {
+	if d.repeatable == "" || !d.optimizing(OptimizeRepeat) || d.indicatedAt(x, y) {
+		return 0
+	}
+	mc, _, style, _ := d.cells.GetCell(x, y)
+	for nx := x + 1; nx < d.w; nx++ {
+		nmc, ncomb, nstyle, nwidth := d.cells.GetCell(nx, y)
+		if !d.cells.Dirty(nx, y) || d.indicatedAt(nx, y) || nmc != mc || len(ncomb) > 0 || nstyle != style || nwidth > 1 {
+			break
+		}
+		count++
+	}
+	sequence := "\x1b[" + strconv.Itoa(count) + "b"
+	if len(sequence) >= count*len(d.repeatable) {
+		return 0
+	}
+	d.writeString(sequence)
+	for i := 1; i <= count; i++ {
+		d.cells.SetDirty(x+i, y, false)
+	}
+	d.drawnCells += count
+	d.cx += count
+	return
+}
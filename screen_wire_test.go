@@ -0,0 +1,123 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/go-curses/terminfo"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+func newWireTestScreen(optimization ScreenOptimization) (d *CScreen) {
+	ti, _ := terminfo.LookupTerminfo("xterm-256color")
+	d = &CScreen{ti: ti, cells: NewCellBuffer(), optimize: optimization, w: 40, h: 3}
+	d.cells.Resize(d.w, d.h)
+	d.encoder = GetEncoding("UTF-8").NewEncoder()
+	d.colors = make(map[paint.Color]paint.Color)
+	d.dithered = make(map[cDitheredColor]paint.Color)
+	d.palette = make([]paint.Color, 256)
+	for i := range d.palette {
+		d.palette[i] = paint.PaletteColor(i)
+	}
+	return
+}
+
+// wireTestDraw draws the busy test content, returning the bytes written
+func wireTestDraw(d *CScreen) string {
+	plain := paint.StyleDefault.Foreground(paint.ColorWhite).Background(paint.ColorNavy)
+	bold := plain.Bold(true)
+	for x := 0; x < d.w; x++ {
+		d.cells.SetCell(x, 0, '─', nil, plain)
+		if x%2 == 0 {
+			d.cells.SetCell(x, 1, 'a', nil, bold)
+		} else {
+			d.cells.SetCell(x, 1, 'b', nil, plain.Foreground(paint.ColorYellow))
+		}
+	}
+	for x := 0; x < d.w; x += 4 {
+		d.cells.SetCell(x, 2, 'x', nil, plain)
+	}
+	d.cx, d.cy = -1, -1
+	d.buf.Reset()
+	d.buffering = true
+	d.drawCells()
+	d.buffering = false
+	return d.buf.String()
+}
+
+func TestScreenWireOptimization(t *testing.T) {
+	Convey("Style delta encoding", t, func() {
+		d := newWireTestScreen(OptimizeStyleDelta)
+		d.buffering = true
+		plain := paint.StyleDefault.Foreground(paint.ColorWhite).Background(paint.ColorNavy)
+		d.curStyle = plain.Bold(true).Underline(true)
+		So(d.sendStyleDelta(plain.Underline(true).Italic(true), 0, 0), ShouldBeTrue)
+		So(d.buf.String(), ShouldEqual, "\x1b[22;3m")
+		d.buf.Reset()
+		d.curStyle = plain.Bold(true).Dim(true)
+		So(d.sendStyleDelta(plain.Dim(true), 0, 0), ShouldBeTrue)
+		So(d.buf.String(), ShouldEqual, "\x1b[22;2m")
+		d.buf.Reset()
+		d.curStyle = plain
+		So(d.sendStyleDelta(plain.Foreground(paint.ColorDefault), 0, 0), ShouldBeTrue)
+		So(d.buf.String(), ShouldEqual, "\x1b[39m")
+		d.curStyle = paint.StyleInvalid
+		So(d.sendStyleDelta(plain, 0, 0), ShouldBeFalse)
+		d.curStyle = plain
+		So(d.sendStyleDelta(plain.Foreground(paint.ColorReset), 0, 0), ShouldBeFalse)
+	})
+	Convey("Cursor movement", t, func() {
+		d := newWireTestScreen(OptimizeCursorMoves)
+		d.buffering = true
+		d.cx, d.cy = 5, 1
+		d.moveCursor(0, 1)
+		So(d.buf.String(), ShouldEqual, "\r")
+		d.buf.Reset()
+		d.moveCursor(8, 1)
+		So(d.buf.String(), ShouldEqual, "\x1b[3C")
+		d.buf.Reset()
+		d.cx = 20
+		d.moveCursor(2, 1)
+		So(d.buf.String(), ShouldEqual, "\r\x1b[2C")
+		d.buf.Reset()
+		d.moveCursor(2, 2)
+		So(d.buf.String(), ShouldEqual, d.ti.TGoto(2, 2))
+	})
+	Convey("Reduced output", t, func() {
+		plain := wireTestDraw(newWireTestScreen(0))
+		optimized := wireTestDraw(newWireTestScreen(DefaultScreenOptimization))
+		repeated := wireTestDraw(newWireTestScreen(DefaultScreenOptimization | OptimizeRepeat))
+		So(len(optimized), ShouldBeLessThan, len(plain))
+		So(len(repeated), ShouldBeLessThan, len(optimized))
+		attrOff := newWireTestScreen(0).ti.AttrOff
+		So(strings.Count(plain, attrOff), ShouldBeGreaterThan, 0)
+		So(strings.Count(optimized, attrOff), ShouldEqual, 0)
+		So(repeated, ShouldContainSubstring, "─\x1b[39b")
+		So(optimized, ShouldContainSubstring, "\x1b[3C")
+	})
+	Convey("Non-ANSI terminals", t, func() {
+		d := newWireTestScreen(DefaultScreenOptimization | OptimizeRepeat)
+		ti := *d.ti
+		ti.SetCursor = "\x1bY%p1%' '%+%c%p2%' '%+%c"
+		d.ti = &ti
+		So(d.optimizing(OptimizeStyleDelta), ShouldBeFalse)
+		d.curStyle = paint.StyleDefault
+		So(d.sendStyleDelta(paint.StyleDefault.Bold(true), 0, 0), ShouldBeFalse)
+	})
+}
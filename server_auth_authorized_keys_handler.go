@@ -0,0 +1,110 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+
+	cpaths "github.com/go-curses/cdk/lib/paths"
+)
+
+var (
+	DefaultServerAuthAuthorizedKeysPath = "./authorized_keys"
+)
+
+// CServerAuthAuthorizedKeysHandler authenticates clients with the public keys
+// listed in an OpenSSH authorized_keys file. The keys are accepted for any
+// user name and the comment of the key is given to the client application with
+// the ServerAuthExtensionComment permissions extension.
+type CServerAuthAuthorizedKeysHandler struct {
+	CServerAuthHandler
+
+	defPath string
+	keys    map[string]string
+}
+
+func NewServerAuthAuthorizedKeysHandler(defaultAuthorizedKeysPath string) (handler *CServerAuthAuthorizedKeysHandler) {
+	handler = &CServerAuthAuthorizedKeysHandler{
+		defPath: defaultAuthorizedKeysPath,
+	}
+	handler.Init()
+	return
+}
+
+func (h *CServerAuthAuthorizedKeysHandler) Init() (already bool) {
+	if h.CServerAuthHandler.Init() {
+		return true
+	}
+	h.Lock()
+	h.keys = make(map[string]string)
+	if h.defPath == "" {
+		h.defPath = DefaultServerAuthAuthorizedKeysPath
+	}
+	h.Unlock()
+	h.RegisterArgument(&cli.PathFlag{
+		Name:        "authorized-keys",
+		Usage:       "sets the path to the authorized_keys file",
+		Value:       h.defPath,
+		DefaultText: h.defPath,
+	})
+	return
+}
+
+func (h *CServerAuthAuthorizedKeysHandler) Reload(ctx *cli.Context) (err error) {
+	if path := ctx.String("authorized-keys"); cpaths.IsFile(path) {
+		return h.Load(path)
+	}
+	return
+}
+
+// Load replaces the authorized keys with those listed in the given file
+func (h *CServerAuthAuthorizedKeysHandler) Load(path string) (err error) {
+	var data []byte
+	if data, err = os.ReadFile(path); err != nil {
+		return fmt.Errorf("failed to load authorized_keys file: %v", err)
+	}
+	// like sshd, lines which are not valid keys are ignored
+	keys := make(map[string]string)
+	for len(data) > 0 {
+		key, comment, _, rest, e := ssh.ParseAuthorizedKey(data)
+		if e != nil {
+			break
+		}
+		keys[string(key.Marshal())] = comment
+		data = rest
+	}
+	h.Lock()
+	h.keys = keys
+	h.Unlock()
+	return nil
+}
+
+func (h *CServerAuthAuthorizedKeysHandler) PublicKeyCallback(c ssh.ConnMetadata, key ssh.PublicKey) (*ssh.Permissions, error) {
+	h.RLock()
+	comment, ok := h.keys[string(key.Marshal())]
+	h.RUnlock()
+	if ok {
+		return &ssh.Permissions{
+			Extensions: map[string]string{
+				ServerAuthExtensionComment: comment,
+			},
+		}, nil
+	}
+	return nil, fmt.Errorf("public key rejected: %q", c.User())
+}
@@ -15,11 +15,31 @@
 package cdk
 
 import (
+	"strings"
+
 	"github.com/gofrs/uuid"
 	"github.com/urfave/cli/v2"
 	"golang.org/x/crypto/ssh"
 
+	cid "github.com/go-curses/cdk/id"
 	"github.com/go-curses/cdk/lib/sync"
+	"github.com/go-curses/cdk/log"
+)
+
+// The permissions extensions set by the ApplicationServer when a client is
+// authenticated. Handlers may add their own extensions, all of which are
+// available to the client application with the PropertyDisplayAuthExtensions
+// property of the Display.
+const (
+	// ServerAuthExtensionMethod is the authentication method used, one of
+	// "password", "publickey" or "keyboard-interactive"
+	ServerAuthExtensionMethod = "cdk-auth-method"
+	// ServerAuthExtensionFingerprint is the SHA256 fingerprint of the public
+	// key, when authenticated with a public key
+	ServerAuthExtensionFingerprint = "cdk-auth-fingerprint"
+	// ServerAuthExtensionComment is the comment of the authorized key, when
+	// authenticated with a CServerAuthAuthorizedKeysHandler
+	ServerAuthExtensionComment = "cdk-auth-comment"
 )
 
 type ServerAuthHandler interface {
@@ -33,8 +53,9 @@ type ServerAuthHandler interface {
 }
 
 // CServerAuthHandler is the base type for application server authentication
-// handler implementations. This handler does no authentication and just accepts
-// all connections.
+// handler implementations. This handler does no authentication, implementations
+// add one or more of the PasswordCallback, PublicKeyCallback and
+// KeyboardInteractiveCallback methods, see: CServerAuthDefaultHandler
 type CServerAuthHandler struct {
 	id          uuid.UUID
 	server      ApplicationServer
@@ -44,8 +65,15 @@ type CServerAuthHandler struct {
 	sync.RWMutex
 }
 
+// CServerAuthDefaultHandler is the handler installed by default, which accepts
+// any password. The ApplicationServer removes it when another handler is
+// installed, see: ApplicationServer.InstallAuthHandler
+type CServerAuthDefaultHandler struct {
+	CServerAuthHandler
+}
+
 func NewDefaultServerAuthHandler() (handler ServerAuthHandler) {
-	handler = &CServerAuthHandler{}
+	handler = &CServerAuthDefaultHandler{}
 	handler.Init()
 	return
 }
@@ -55,7 +83,7 @@ func (h *CServerAuthHandler) Init() (already bool) {
 		return
 	}
 	h.Lock()
-	h.id, _ = uuid.NewV4()
+	h.id = cid.NewUUID()
 	h.server = nil
 	h.arguments = make([]cli.Flag, 0)
 	h.initialized = true
@@ -82,7 +110,9 @@ func (h *CServerAuthHandler) Attach(server ApplicationServer) (err error) {
 	h.Lock()
 	h.server = server
 	for _, flag := range h.arguments {
-		h.server.App().AddFlag(flag)
+		if ee := h.server.App().AddFlag(flag); ee != nil && err == nil {
+			err = ee
+		}
 	}
 	h.Unlock()
 	return
@@ -105,11 +135,15 @@ func (h *CServerAuthHandler) Reload(ctx *cli.Context) (err error) {
 	return
 }
 
+// HasArgument returns true if the name, with or without leading dashes, is a
+// name of one of the command line flags registered by the handler
 func (h *CServerAuthHandler) HasArgument(arg string) (has bool) {
+	arg = strings.TrimLeft(arg, "-")
 	for _, flag := range h.arguments {
-		if flag.String() == arg {
-			has = true
-			break
+		for _, name := range flag.Names() {
+			if name == arg {
+				return true
+			}
 		}
 	}
 	return
@@ -121,14 +155,12 @@ func (h *CServerAuthHandler) RegisterArgument(flag cli.Flag) {
 	attached := h.server != nil
 	h.Unlock()
 	if attached {
-		h.server.App().AddFlag(flag)
+		if err := h.server.App().AddFlag(flag); err != nil {
+			log.Error(err)
+		}
 	}
 }
 
-func (h *CServerAuthHandler) PasswordCallback(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
-	// if h.server.htpasswd.Match(conn.User(), string(password)) {
-	// 	return nil, nil
-	// }
-	// return nil, fmt.Errorf("password rejected for %q", c.User())
+func (h *CServerAuthDefaultHandler) PasswordCallback(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
 	return nil, nil
 }
@@ -76,8 +76,27 @@ func (h *CServerAuthHtpasswdHandler) Reload(ctx *cli.Context) (err error) {
 }
 
 func (s *CServerAuthHtpasswdHandler) PasswordCallback(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
-	if s.htpasswd.Match(c.User(), string(pass)) {
+	if s.match(c.User(), string(pass)) {
 		return nil, nil
 	}
 	return nil, fmt.Errorf("username or password rejected: %q", c.User())
 }
+
+// KeyboardInteractiveCallback prompts the client for the password, for clients
+// which do not offer password authentication
+func (s *CServerAuthHtpasswdHandler) KeyboardInteractiveCallback(c ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+	answers, err := client(c.User(), "", []string{"Password: "}, []bool{false})
+	if err != nil {
+		return nil, err
+	}
+	if len(answers) == 1 && s.match(c.User(), answers[0]) {
+		return nil, nil
+	}
+	return nil, fmt.Errorf("username or password rejected: %q", c.User())
+}
+
+func (s *CServerAuthHtpasswdHandler) match(user, pass string) bool {
+	s.RLock()
+	defer s.RUnlock()
+	return s.htpasswd != nil && s.htpasswd.Match(user, pass)
+}
@@ -0,0 +1,28 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"golang.org/x/crypto/ssh"
+)
+
+// ServerAuthKeyboardInteractiveHandler is a ServerAuthHandler which
+// authenticates clients by asking them questions, such as a password or
+// one-time code
+type ServerAuthKeyboardInteractiveHandler interface {
+	ServerAuthHandler
+
+	KeyboardInteractiveCallback(conn ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error)
+}
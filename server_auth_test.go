@@ -0,0 +1,280 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ssh"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+type testConnMetadata struct {
+	user string
+}
+
+func (m testConnMetadata) User() string          { return m.user }
+func (m testConnMetadata) SessionID() []byte     { return nil }
+func (m testConnMetadata) ClientVersion() []byte { return nil }
+func (m testConnMetadata) ServerVersion() []byte { return nil }
+func (m testConnMetadata) RemoteAddr() net.Addr  { return &net.TCPAddr{} }
+func (m testConnMetadata) LocalAddr() net.Addr   { return &net.TCPAddr{} }
+
+type testPasswordHandler struct {
+	CServerAuthHandler
+	password string
+}
+
+func (h *testPasswordHandler) PasswordCallback(conn ssh.ConnMetadata, password []byte) (*ssh.Permissions, error) {
+	if string(password) == h.password {
+		return &ssh.Permissions{Extensions: map[string]string{"password": "ok"}}, nil
+	}
+	return nil, fmt.Errorf("rejected")
+}
+
+type testKeyboardInteractiveHandler struct {
+	CServerAuthHandler
+	answer string
+}
+
+func (h *testKeyboardInteractiveHandler) KeyboardInteractiveCallback(conn ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (*ssh.Permissions, error) {
+	answers, err := client(conn.User(), "", []string{"Code: "}, []bool{true})
+	if err == nil && len(answers) == 1 && answers[0] == h.answer {
+		return &ssh.Permissions{Extensions: map[string]string{"code": h.answer}}, nil
+	}
+	return nil, fmt.Errorf("rejected")
+}
+
+func testPublicKey() ssh.PublicKey {
+	public, _, _ := ed25519.GenerateKey(rand.Reader)
+	key, _ := ssh.NewPublicKey(public)
+	return key
+}
+
+func TestServerAuth(t *testing.T) {
+	Convey("Authorized keys files", t, func() {
+		accepted, rejected := testPublicKey(), testPublicKey()
+		path := filepath.Join(t.TempDir(), "authorized_keys")
+		content := "# comment\n\nnot a key\n" + string(ssh.MarshalAuthorizedKey(accepted))[:len(ssh.MarshalAuthorizedKey(accepted))-1] + " someone@example\n"
+		So(os.WriteFile(path, []byte(content), 0600), ShouldBeNil)
+		handler := NewServerAuthAuthorizedKeysHandler("")
+		So(handler.HasArgument("--"+handler.arguments[0].Names()[0]), ShouldBeTrue)
+		So(handler.Load(path), ShouldBeNil)
+		permissions, err := handler.PublicKeyCallback(testConnMetadata{"user"}, accepted)
+		So(err, ShouldBeNil)
+		So(permissions.Extensions[ServerAuthExtensionComment], ShouldEqual, "someone@example")
+		_, err = handler.PublicKeyCallback(testConnMetadata{"user"}, rejected)
+		So(err, ShouldNotBeNil)
+		So(handler.Load(filepath.Join(t.TempDir(), "missing")), ShouldNotBeNil)
+	})
+	Convey("Handlers consulted in order", t, func() {
+		s := &CApplicationServer{}
+		ki := &testKeyboardInteractiveHandler{answer: "42"}
+		ki.Init()
+		keys := NewServerAuthAuthorizedKeysHandler("")
+		key := testPublicKey()
+		keys.keys[string(key.Marshal())] = "comment"
+		htpasswd := NewServerAuthHtpasswdHandler("")
+		s.handlers = []ServerAuthHandler{htpasswd, keys, ki}
+		config := s.newServerConfig()
+		So(config.PasswordCallback, ShouldNotBeNil)
+		So(config.PublicKeyCallback, ShouldNotBeNil)
+		So(config.KeyboardInteractiveCallback, ShouldNotBeNil)
+
+		// htpasswd has no file loaded
+		_, err := config.PasswordCallback(testConnMetadata{"user"}, []byte("secret"))
+		So(err, ShouldNotBeNil)
+
+		permissions, err := config.PublicKeyCallback(testConnMetadata{"user"}, key)
+		So(err, ShouldBeNil)
+		So(permissions.Extensions[ServerAuthExtensionMethod], ShouldEqual, "publickey")
+		So(permissions.Extensions[ServerAuthExtensionFingerprint], ShouldEqual, ssh.FingerprintSHA256(key))
+		So(permissions.Extensions[ServerAuthExtensionComment], ShouldEqual, "comment")
+
+		var prompts []string
+		challenge := func(answer string) ssh.KeyboardInteractiveChallenge {
+			return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+				prompts = append(prompts, questions...)
+				return []string{answer}, nil
+			}
+		}
+		permissions, err = config.KeyboardInteractiveCallback(testConnMetadata{"user"}, challenge("42"))
+		So(err, ShouldBeNil)
+		So(prompts, ShouldResemble, []string{"Password: ", "Code: "})
+		So(permissions.Extensions[ServerAuthExtensionMethod], ShouldEqual, "keyboard-interactive")
+		So(permissions.Extensions["code"], ShouldEqual, "42")
+		_, err = config.KeyboardInteractiveCallback(testConnMetadata{"user"}, challenge("nope"))
+		So(err, ShouldNotBeNil)
+
+		s.handlers = []ServerAuthHandler{NewDefaultServerAuthHandler()}
+		config = s.newServerConfig()
+		So(config.PublicKeyCallback, ShouldBeNil)
+		permissions, err = config.PasswordCallback(testConnMetadata{"user"}, []byte("anything"))
+		So(err, ShouldBeNil)
+		So(permissions.Extensions[ServerAuthExtensionMethod], ShouldEqual, "password")
+	})
+	Convey("Installing a handler removes the default handler", t, func() {
+		s := NewApplicationServer("test", "usage", "description", "0.0.1", "test", "Test", nil, nil, "")
+		So(s.handlers, ShouldHaveLength, 1)
+		_, err := s.newServerConfig().PasswordCallback(testConnMetadata{"user"}, []byte("anything"))
+		So(err, ShouldBeNil)
+		keys := NewServerAuthAuthorizedKeysHandler("")
+		So(s.InstallAuthHandler(keys), ShouldBeNil)
+		So(s.handlers, ShouldResemble, []ServerAuthHandler{keys})
+		So(s.newServerConfig().PasswordCallback, ShouldBeNil)
+		password := &testPasswordHandler{password: "secret"}
+		password.Init()
+		So(s.InstallAuthHandler(password), ShouldBeNil)
+		So(s.handlers, ShouldResemble, []ServerAuthHandler{keys, password})
+		_, err = s.newServerConfig().PasswordCallback(testConnMetadata{"user"}, []byte("anything"))
+		So(err, ShouldNotBeNil)
+	})
+	Convey("TOTP verification codes", t, func() {
+		// RFC 6238 test secret, "12345678901234567890"
+		secret := "gezd gnbv gy3t qojq gezd gnbv gy3t qojq"
+		key, err := decodeTotpSecret(secret)
+		So(err, ShouldBeNil)
+		So(string(key), ShouldEqual, "12345678901234567890")
+		So(totpCode(key, 1), ShouldEqual, "287082")
+		So(totpCode(key, uint64(1111111109/30)), ShouldEqual, "081804")
+		_, err = decodeTotpSecret("not base32!")
+		So(err, ShouldNotBeNil)
+
+		path := filepath.Join(t.TempDir(), "totp_secrets")
+		So(os.WriteFile(path, []byte("# users\n\nuser:"+secret+"\n"), 0600), ShouldBeNil)
+		handler := NewServerAuthTotpHandler("", nil)
+		So(handler.Load(path), ShouldBeNil)
+		now := time.Unix(59, 0)
+		So(handler.Verify("user", "000000", now), ShouldBeFalse)
+		So(handler.Verify("other", "287082", now), ShouldBeFalse)
+		So(handler.Verify("user", "287082", now), ShouldBeTrue)
+		// codes are not accepted twice
+		So(handler.Verify("user", "287082", now), ShouldBeFalse)
+		// the previous period is accepted, to allow for clock drift
+		So(handler.Verify("user", totpCode(key, 2), time.Unix(95, 0)), ShouldBeTrue)
+
+		handler.SetSecretFn(func(user string) (string, bool) {
+			return secret, user == "callback"
+		})
+		So(handler.Verify("callback", totpCode(key, 3), time.Unix(95, 0)), ShouldBeTrue)
+		So(handler.Verify("user", totpCode(key, 3), time.Unix(95, 0)), ShouldBeFalse)
+
+		bad := filepath.Join(t.TempDir(), "bad")
+		So(os.WriteFile(bad, []byte("user\n"), 0600), ShouldBeNil)
+		So(handler.Load(bad), ShouldNotBeNil)
+	})
+	Convey("TOTP with passwords", t, func() {
+		secret := "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+		key, _ := decodeTotpSecret(secret)
+		password := &testPasswordHandler{password: "secret"}
+		password.Init()
+		handler := NewServerAuthTotpHandler("", password)
+		handler.SetSecretFn(func(user string) (string, bool) { return secret, true })
+		code := func() string {
+			return totpCode(key, uint64(time.Now().Unix()/int64(ServerAuthTotpPeriod/time.Second)))
+		}
+		var prompts []string
+		challenge := func(answers ...string) ssh.KeyboardInteractiveChallenge {
+			return func(name, instruction string, questions []string, echos []bool) ([]string, error) {
+				prompts = questions
+				return answers, nil
+			}
+		}
+		_, err := handler.KeyboardInteractiveCallback(testConnMetadata{"user"}, challenge("wrong", code()))
+		So(err, ShouldNotBeNil)
+		So(prompts, ShouldResemble, []string{"Password: ", "Verification code: "})
+		_, err = handler.KeyboardInteractiveCallback(testConnMetadata{"user"}, challenge("secret", "000000"))
+		So(err, ShouldNotBeNil)
+		permissions, err := handler.KeyboardInteractiveCallback(testConnMetadata{"user"}, challenge("secret", code()))
+		So(err, ShouldBeNil)
+		So(permissions.Extensions["password"], ShouldEqual, "ok")
+	})
+	Convey("Connection lifecycle", t, func() {
+		s := NewApplicationServer("test", "usage", "description", "0.0.1", "test", "Test", nil, nil, "")
+		var events []string
+		var reason string
+		for _, signal := range []Signal{SignalServerAuthSucceeded, SignalServerAuthFailed, SignalServerClientConnected, SignalServerClientDisconnected} {
+			signal := signal
+			s.Connect(signal, "test", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				if _, method, _, ok := ApplicationServerSignalAuthArgv(argv...); ok {
+					events = append(events, string(signal)+":"+method)
+				} else if _, ok := ApplicationServerSignalClientArgv(argv...); ok {
+					events = append(events, string(signal))
+					if len(argv) == 3 {
+						reason, _ = argv[2].(string)
+					}
+				}
+				return enums.EVENT_PASS
+			})
+		}
+		_, private, _ := ed25519.GenerateKey(rand.Reader)
+		signer, _ := ssh.NewSignerFromKey(private)
+		config := s.newServerConfig()
+		config.AddHostKey(signer)
+		listener, err := net.Listen("tcp", "127.0.0.1:0")
+		So(err, ShouldBeNil)
+		defer listener.Close()
+		accepted := make(chan *ssh.ServerConn, 1)
+		Go(func() {
+			serverConn, err := listener.Accept()
+			if err != nil {
+				accepted <- nil
+				return
+			}
+			conn, channels, requests, err := ssh.NewServerConn(serverConn, config)
+			if err == nil {
+				asc, _ := s.newClient(conn, channels, requests)
+				s.Emit(SignalServerClientConnected, s, asc)
+				go ssh.DiscardRequests(requests)
+			}
+			accepted <- conn
+		})
+		client, err := ssh.Dial("tcp", listener.Addr().String(), &ssh.ClientConfig{
+			User:            "user",
+			Auth:            []ssh.AuthMethod{ssh.Password("anything")},
+			HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		})
+		So(err, ShouldBeNil)
+		So(<-accepted, ShouldNotBeNil)
+		clients := s.GetClients()
+		So(clients, ShouldHaveLength, 1)
+		asc, _ := s.GetClient(clients[0])
+		So(asc.User(), ShouldEqual, "user")
+		So(asc.Permissions().Extensions[ServerAuthExtensionMethod], ShouldEqual, "password")
+		So(asc.Application(), ShouldBeNil)
+		So(s.DisconnectClient(clients[0], "testing"), ShouldBeNil)
+		So(s.GetClients(), ShouldBeEmpty)
+		So(s.DisconnectClient(clients[0], "again"), ShouldNotBeNil)
+		So(client.Wait(), ShouldNotBeNil)
+		So(events, ShouldResemble, []string{
+			"server-auth-succeeded:password",
+			"server-client-connected",
+			"server-client-disconnected",
+		})
+		So(reason, ShouldEqual, "testing")
+		// closing again does not emit the signal again
+		s.closeClient(asc, "closed")
+		So(events, ShouldHaveLength, 3)
+	})
+}
@@ -0,0 +1,256 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+	"golang.org/x/crypto/ssh"
+
+	cpaths "github.com/go-curses/cdk/lib/paths"
+)
+
+var (
+	DefaultServerAuthTotpSecretsPath = "./totp_secrets"
+)
+
+// The RFC 6238 parameters used by the CServerAuthTotpHandler, which are those
+// expected by common authenticator apps
+var (
+	ServerAuthTotpPeriod = 30 * time.Second
+	ServerAuthTotpDigits = 6
+	// ServerAuthTotpSkew is the number of periods either side of the current
+	// one for which codes are accepted, to allow for clock drift
+	ServerAuthTotpSkew = 1
+)
+
+// ServerAuthTotpSecretFn returns the base32 encoded secret of the user, ok is
+// false if the user has no secret
+type ServerAuthTotpSecretFn = func(user string) (secret string, ok bool)
+
+// CServerAuthTotpHandler authenticates clients with keyboard-interactive
+// prompts for RFC 6238 time-based one-time passwords. The secrets are loaded
+// from a file with a "user:secret" line for each user, or are provided by a
+// callback, see: SetSecretFn.
+//
+// When given a password handler, the client is prompted for both the password
+// and the verification code, and both must be accepted. The password handler
+// must not be installed with the server itself, as that would allow clients to
+// authenticate with only the password.
+type CServerAuthTotpHandler struct {
+	CServerAuthHandler
+
+	defPath  string
+	secrets  map[string]string
+	secretFn ServerAuthTotpSecretFn
+	password ServerAuthPasswordHandler
+	used     map[string]uint64
+}
+
+func NewServerAuthTotpHandler(defaultSecretsPath string, password ServerAuthPasswordHandler) (handler *CServerAuthTotpHandler) {
+	handler = &CServerAuthTotpHandler{
+		defPath:  defaultSecretsPath,
+		password: password,
+	}
+	handler.Init()
+	return
+}
+
+func (h *CServerAuthTotpHandler) Init() (already bool) {
+	if h.CServerAuthHandler.Init() {
+		return true
+	}
+	h.Lock()
+	h.secrets = make(map[string]string)
+	h.used = make(map[string]uint64)
+	if h.defPath == "" {
+		h.defPath = DefaultServerAuthTotpSecretsPath
+	}
+	h.Unlock()
+	h.RegisterArgument(&cli.PathFlag{
+		Name:        "totp-secrets",
+		Usage:       "sets the path to the TOTP secrets file",
+		Value:       h.defPath,
+		DefaultText: h.defPath,
+	})
+	return
+}
+
+func (h *CServerAuthTotpHandler) Attach(server ApplicationServer) (err error) {
+	if err = h.CServerAuthHandler.Attach(server); err == nil && h.password != nil {
+		err = h.password.Attach(server)
+	}
+	return
+}
+
+func (h *CServerAuthTotpHandler) Detach() (err error) {
+	if err = h.CServerAuthHandler.Detach(); err == nil && h.password != nil {
+		err = h.password.Detach()
+	}
+	return
+}
+
+func (h *CServerAuthTotpHandler) HasArgument(arg string) (has bool) {
+	return h.CServerAuthHandler.HasArgument(arg) || (h.password != nil && h.password.HasArgument(arg))
+}
+
+func (h *CServerAuthTotpHandler) Reload(ctx *cli.Context) (err error) {
+	if h.password != nil {
+		if err = h.password.Reload(ctx); err != nil {
+			return
+		}
+	}
+	if path := ctx.String("totp-secrets"); cpaths.IsFile(path) {
+		return h.Load(path)
+	}
+	return
+}
+
+// Load replaces the secrets with those listed in the given file. Blank lines
+// and lines starting with a "#" are ignored.
+func (h *CServerAuthTotpHandler) Load(path string) (err error) {
+	var fh *os.File
+	if fh, err = os.Open(path); err != nil {
+		return fmt.Errorf("failed to load TOTP secrets file: %v", err)
+	}
+	defer fh.Close()
+	secrets := make(map[string]string)
+	scanner := bufio.NewScanner(fh)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+		user, secret, found := strings.Cut(text, ":")
+		if !found {
+			return fmt.Errorf("%v:%d: expected user:secret", path, line)
+		}
+		if _, err = decodeTotpSecret(secret); err != nil {
+			return fmt.Errorf("%v:%d: %v", path, line, err)
+		}
+		secrets[strings.TrimSpace(user)] = secret
+	}
+	if err = scanner.Err(); err != nil {
+		return fmt.Errorf("failed to load TOTP secrets file: %v", err)
+	}
+	h.Lock()
+	h.secrets = secrets
+	h.Unlock()
+	return
+}
+
+// SetSecretFn sets the function used to look up secrets, which takes
+// precedence over the secrets file. Use nil to only use the secrets file.
+func (h *CServerAuthTotpHandler) SetSecretFn(fn ServerAuthTotpSecretFn) {
+	h.Lock()
+	h.secretFn = fn
+	h.Unlock()
+}
+
+func (h *CServerAuthTotpHandler) KeyboardInteractiveCallback(c ssh.ConnMetadata, client ssh.KeyboardInteractiveChallenge) (permissions *ssh.Permissions, err error) {
+	questions, echos := []string{"Verification code: "}, []bool{false}
+	if h.password != nil {
+		questions, echos = []string{"Password: ", "Verification code: "}, []bool{false, false}
+	}
+	var answers []string
+	if answers, err = client(c.User(), "", questions, echos); err != nil {
+		return nil, err
+	}
+	if len(answers) != len(questions) {
+		return nil, fmt.Errorf("verification code rejected: %q", c.User())
+	}
+	if h.password != nil {
+		if permissions, err = h.password.PasswordCallback(c, []byte(answers[0])); err != nil {
+			return nil, err
+		}
+	}
+	if !h.Verify(c.User(), answers[len(answers)-1], time.Now()) {
+		return nil, fmt.Errorf("verification code rejected: %q", c.User())
+	}
+	return
+}
+
+// Verify returns true if the code is valid for the user at the given time.
+// Each code is accepted only once, to prevent replay of an observed code.
+func (h *CServerAuthTotpHandler) Verify(user, code string, now time.Time) (valid bool) {
+	h.RLock()
+	fn := h.secretFn
+	secret, ok := h.secrets[user]
+	h.RUnlock()
+	if fn != nil {
+		secret, ok = fn(user)
+	}
+	if !ok {
+		return false
+	}
+	key, err := decodeTotpSecret(secret)
+	if err != nil {
+		return false
+	}
+	code = strings.TrimSpace(code)
+	counter := uint64(now.Unix() / int64(ServerAuthTotpPeriod/time.Second))
+	h.Lock()
+	defer h.Unlock()
+	for skew := -ServerAuthTotpSkew; skew <= ServerAuthTotpSkew; skew++ {
+		step := counter + uint64(skew)
+		if last, used := h.used[user]; used && step <= last {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(totpCode(key, step)), []byte(code)) == 1 {
+			h.used[user] = step
+			return true
+		}
+	}
+	return false
+}
+
+// decodeTotpSecret decodes the base32 secret, ignoring case, spaces and
+// padding as commonly found in provisioning URIs and authenticator apps
+func decodeTotpSecret(secret string) (key []byte, err error) {
+	secret = strings.ToUpper(strings.Join(strings.Fields(secret), ""))
+	secret = strings.TrimRight(secret, "=")
+	if key, err = base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret); err != nil {
+		return nil, fmt.Errorf("invalid TOTP secret: %v", err)
+	} else if len(key) == 0 {
+		return nil, fmt.Errorf("invalid TOTP secret: empty")
+	}
+	return
+}
+
+// totpCode is the RFC 4226 HOTP value for the counter
+func totpCode(key []byte, counter uint64) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], counter)
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	modulo := uint32(1)
+	for i := 0; i < ServerAuthTotpDigits; i++ {
+		modulo *= 10
+	}
+	return fmt.Sprintf("%0*d", ServerAuthTotpDigits, value%modulo)
+}
@@ -0,0 +1,475 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/go-curses/cdk/env"
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/lib/sync"
+	"github.com/go-curses/cdk/log"
+)
+
+const SessionManagerHandle = "session-manager-handler"
+
+// SessionState is the state persisted by a SessionManager for one display
+// size of an application
+type SessionState struct {
+	// Tag is the application tag the state belongs to
+	Tag string `json:"tag"`
+	// Size is the size of the display the state was saved with
+	Size ptypes.Rectangle `json:"size"`
+	// Focused is the name of the focused window
+	Focused string `json:"focused,omitempty"`
+	// Windows are the named windows mapped, in stacking order
+	Windows []SessionWindowState `json:"windows,omitempty"`
+	// Properties are the values of each registered property set, keyed by
+	// set name and then property name
+	Properties map[string]map[string]interface{} `json:"properties,omitempty"`
+	// Blobs are the custom states contributed with RegisterState
+	Blobs map[string][]byte `json:"blobs,omitempty"`
+}
+
+// SessionWindowState is the geometry of a window persisted by a
+// SessionManager
+type SessionWindowState struct {
+	Name   string        `json:"name"`
+	Region ptypes.Region `json:"region"`
+	Fill   bool          `json:"fill,omitempty"`
+	State  WindowState   `json:"state,omitempty"`
+}
+
+// SessionSaveFn returns custom state to persist, see: RegisterState
+type SessionSaveFn = func() (data []byte, err error)
+
+// SessionRestoreFn restores the custom state saved by a SessionSaveFn
+type SessionRestoreFn = func(data []byte) (err error)
+
+type SessionManager interface {
+	Attach(d Display) (err error)
+	Detach()
+	GetDirectory() (dir string)
+	SetDirectory(dir string)
+	GetPath(size ptypes.Rectangle) (path string)
+	RegisterPropertySet(name string, object Object, properties ...Property)
+	UnregisterPropertySet(name string)
+	RegisterState(name string, save SessionSaveFn, restore SessionRestoreFn)
+	UnregisterState(name string)
+	Save() (err error)
+	Restore() (err error)
+	RestoreWindow(w Window) (restored bool)
+}
+
+type cSessionPropertySet struct {
+	object     Object
+	properties []Property
+}
+
+type cSessionBlob struct {
+	save    SessionSaveFn
+	restore SessionRestoreFn
+}
+
+// CSessionManager persists the layout of the windows of a Display, which
+// window is focused, the values of registered property sets and custom state
+// blobs of the application when the Display shuts down, restoring them when
+// the application next starts. State is kept separately for each size of the
+// display as a layout made for one terminal size rarely suits another.
+// Windows are matched by name, unnamed windows are not persisted.
+type CSessionManager struct {
+	tag     string
+	dir     string
+	display *CDisplay
+	size    ptypes.Rectangle
+	saved   *SessionState
+	sets    map[string]*cSessionPropertySet
+	blobs   map[string]*cSessionBlob
+
+	sync.RWMutex
+}
+
+// NewSessionManager returns a session manager for the application tag, the
+// session files are kept within $XDG_STATE_HOME/<tag> (or
+// ~/.local/state/<tag>). Attach it to a Display to opt-in to having the
+// session persisted.
+func NewSessionManager(tag string) (sm *CSessionManager) {
+	sm = &CSessionManager{
+		tag:   tag,
+		sets:  make(map[string]*cSessionPropertySet),
+		blobs: make(map[string]*cSessionBlob),
+	}
+	if dir := env.Get("XDG_STATE_HOME", ""); dir != "" {
+		sm.dir = filepath.Join(dir, tag)
+	} else if home, err := os.UserHomeDir(); err == nil {
+		sm.dir = filepath.Join(home, ".local", "state", tag)
+	}
+	return
+}
+
+// Attach restores the session when the startup of the Display completes,
+// after the application has mapped its windows, and saves the session when
+// the Display shuts down
+func (sm *CSessionManager) Attach(d Display) (err error) {
+	display, ok := d.(*CDisplay)
+	if !ok || display == nil {
+		return fmt.Errorf("cannot attach session manager to %T", d)
+	}
+	sm.Detach()
+	sm.Lock()
+	sm.display = display
+	sm.Unlock()
+	display.Connect(SignalStartupComplete, sm.handle(), func(data []interface{}, argv ...interface{}) enums.EventFlag {
+		sm.setSize(display.screenSize())
+		if err := sm.Restore(); err != nil {
+			display.LogErr(err)
+		}
+		return enums.EVENT_PASS
+	})
+	display.Connect(SignalEventResize, sm.handle(), func(data []interface{}, argv ...interface{}) enums.EventFlag {
+		if len(argv) == 2 {
+			if e, ok := argv[1].(*EventResize); ok {
+				w, h := e.Size()
+				sm.setSize(ptypes.MakeRectangle(w, h))
+			}
+		}
+		return enums.EVENT_PASS
+	})
+	display.Connect(SignalDisplayShutdown, sm.handle(), func(data []interface{}, argv ...interface{}) enums.EventFlag {
+		if err := sm.Save(); err != nil {
+			display.LogErr(err)
+		}
+		sm.Detach()
+		return enums.EVENT_PASS
+	})
+	return
+}
+
+// Detach stops persisting the session of the Display
+func (sm *CSessionManager) Detach() {
+	sm.Lock()
+	display := sm.display
+	sm.display = nil
+	sm.Unlock()
+	if display != nil {
+		_ = display.Disconnect(SignalStartupComplete, sm.handle())
+		_ = display.Disconnect(SignalEventResize, sm.handle())
+		_ = display.Disconnect(SignalDisplayShutdown, sm.handle())
+	}
+}
+
+// GetDirectory returns the directory the session files are kept in
+func (sm *CSessionManager) GetDirectory() (dir string) {
+	sm.RLock()
+	defer sm.RUnlock()
+	return sm.dir
+}
+
+// SetDirectory changes the directory the session files are kept in
+func (sm *CSessionManager) SetDirectory(dir string) {
+	sm.Lock()
+	defer sm.Unlock()
+	sm.dir = dir
+}
+
+// GetPath returns the path of the session file for the display size
+func (sm *CSessionManager) GetPath(size ptypes.Rectangle) (path string) {
+	return filepath.Join(sm.GetDirectory(), fmt.Sprintf("session-%dx%d.json", size.W, size.H))
+}
+
+// RegisterPropertySet persists the values of the properties of the object
+// under the given name, all writable properties if none are given
+func (sm *CSessionManager) RegisterPropertySet(name string, object Object, properties ...Property) {
+	sm.Lock()
+	defer sm.Unlock()
+	sm.sets[name] = &cSessionPropertySet{object: object, properties: properties}
+}
+
+// UnregisterPropertySet stops persisting the named property set
+func (sm *CSessionManager) UnregisterPropertySet(name string) {
+	sm.Lock()
+	defer sm.Unlock()
+	delete(sm.sets, name)
+}
+
+// RegisterState persists the custom state returned by the save function under
+// the given name, which is given to the restore function when the session is
+// restored
+func (sm *CSessionManager) RegisterState(name string, save SessionSaveFn, restore SessionRestoreFn) {
+	sm.Lock()
+	defer sm.Unlock()
+	sm.blobs[name] = &cSessionBlob{save: save, restore: restore}
+}
+
+// UnregisterState stops persisting the named custom state
+func (sm *CSessionManager) UnregisterState(name string) {
+	sm.Lock()
+	defer sm.Unlock()
+	delete(sm.blobs, name)
+}
+
+// Save writes the session state of the Display to the session file for the
+// current display size
+func (sm *CSessionManager) Save() (err error) {
+	sm.RLock()
+	display, size := sm.display, sm.size
+	sm.RUnlock()
+	if display == nil {
+		return fmt.Errorf("session manager is not attached")
+	}
+	state := &SessionState{Tag: sm.tag, Size: size}
+	if focused := display.FocusedWindow(); focused != nil {
+		state.Focused = focused.GetName()
+	}
+	for _, w := range display.GetWindows() {
+		if name := w.GetName(); name != "" {
+			display.RLock()
+			geometry, ok := display.geometry[w.ObjectID()]
+			if ok {
+				state.Windows = append(state.Windows, SessionWindowState{
+					Name:   name,
+					Region: geometry.region,
+					Fill:   geometry.fill,
+					State:  geometry.state,
+				})
+			}
+			display.RUnlock()
+		}
+	}
+	if state.Properties, err = sm.saveProperties(); err != nil {
+		return
+	}
+	if state.Blobs, err = sm.saveBlobs(); err != nil {
+		return
+	}
+	var data []byte
+	if data, err = json.MarshalIndent(state, "", "\t"); err != nil {
+		return
+	}
+	path := sm.GetPath(size)
+	if err = os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return
+	}
+	// write the session atomically so a crash cannot leave a partial file
+	temp := path + ".tmp"
+	if err = os.WriteFile(temp, data, 0600); err != nil {
+		return
+	}
+	return os.Rename(temp, path)
+}
+
+// Restore reads the session file for the current display size and restores
+// the windows mapped, the focused window, the property sets and the custom
+// states registered. Windows mapped after the session is restored can be
+// restored with RestoreWindow. A missing session file is not an error.
+func (sm *CSessionManager) Restore() (err error) {
+	sm.RLock()
+	display, size := sm.display, sm.size
+	sm.RUnlock()
+	if display == nil {
+		return fmt.Errorf("session manager is not attached")
+	}
+	var data []byte
+	if data, err = os.ReadFile(sm.GetPath(size)); err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return
+	}
+	state := &SessionState{}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	if err = decoder.Decode(state); err != nil {
+		return fmt.Errorf("error decoding session %v: %w", sm.GetPath(size), err)
+	}
+	if state.Tag != sm.tag {
+		return fmt.Errorf("session belongs to %q, not %q", state.Tag, sm.tag)
+	}
+	sm.Lock()
+	sm.saved = state
+	sm.Unlock()
+	for _, w := range display.GetWindows() {
+		sm.RestoreWindow(w)
+	}
+	if state.Focused != "" {
+		for _, w := range display.GetWindows() {
+			if w.GetName() == state.Focused {
+				display.FocusWindow(w)
+				break
+			}
+		}
+	}
+	err = sm.restoreProperties(state.Properties)
+	if ee := sm.restoreBlobs(state.Blobs); ee != nil && err == nil {
+		err = ee
+	}
+	return
+}
+
+// RestoreWindow maps the window to its region and state from the session
+// restored, returning false if the session has no state for the window
+func (sm *CSessionManager) RestoreWindow(w Window) (restored bool) {
+	sm.RLock()
+	display, state := sm.display, sm.saved
+	sm.RUnlock()
+	name := w.GetName()
+	if display == nil || state == nil || name == "" || !display.IsMappedWindow(w) {
+		return false
+	}
+	for _, saved := range state.Windows {
+		if saved.Name != name {
+			continue
+		}
+		if !saved.Fill {
+			display.setWindowRegion(w, saved.Region)
+		}
+		if display.GetWindowState(w) != saved.State {
+			display.SetWindowState(w, saved.State)
+		}
+		return true
+	}
+	return false
+}
+
+func (sm *CSessionManager) setSize(size ptypes.Rectangle) {
+	sm.Lock()
+	defer sm.Unlock()
+	sm.size = size
+}
+
+func (sm *CSessionManager) handle() string {
+	return fmt.Sprintf("%v-%p", SessionManagerHandle, sm)
+}
+
+func (sm *CSessionManager) propertySets() (names []string, sets map[string]*cSessionPropertySet) {
+	sm.RLock()
+	defer sm.RUnlock()
+	sets = make(map[string]*cSessionPropertySet, len(sm.sets))
+	for name, set := range sm.sets {
+		names = append(names, name)
+		sets[name] = set
+	}
+	sort.Strings(names)
+	return
+}
+
+func (sm *CSessionManager) saveProperties() (tree map[string]map[string]interface{}, err error) {
+	names, sets := sm.propertySets()
+	for _, name := range names {
+		set := sets[name]
+		properties := set.properties
+		if len(properties) == 0 {
+			properties = set.object.ListProperties()
+		}
+		values := make(map[string]interface{})
+		for _, property := range properties {
+			prop := set.object.GetProperty(property)
+			if prop == nil || prop.ReadOnly() {
+				continue
+			}
+			var node interface{}
+			if node, err = encodePropertyValue(prop); err != nil {
+				return nil, err
+			}
+			if node != nil {
+				values[property.String()] = node
+			}
+		}
+		if tree == nil {
+			tree = make(map[string]map[string]interface{})
+		}
+		tree[name] = values
+	}
+	return
+}
+
+func (sm *CSessionManager) restoreProperties(tree map[string]map[string]interface{}) (err error) {
+	_, sets := sm.propertySets()
+	for name, values := range tree {
+		set, ok := sets[name]
+		if !ok {
+			continue
+		}
+		for key, node := range values {
+			property := Property(key)
+			if len(set.properties) > 0 && !propertyListed(set.properties, property) {
+				continue
+			}
+			prop := set.object.GetProperty(property)
+			if prop == nil || prop.ReadOnly() {
+				continue
+			}
+			if value, e := decodePropertyValue(prop, jsonNumbers(node)); e != nil {
+				if err == nil {
+					err = e
+				}
+			} else if e = set.object.SetProperty(property, value); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return
+}
+
+func (sm *CSessionManager) saveBlobs() (blobs map[string][]byte, err error) {
+	sm.RLock()
+	registered := make(map[string]*cSessionBlob, len(sm.blobs))
+	for name, blob := range sm.blobs {
+		registered[name] = blob
+	}
+	sm.RUnlock()
+	for name, blob := range registered {
+		var data []byte
+		if data, err = blob.save(); err != nil {
+			return nil, fmt.Errorf("error saving session state %v: %w", name, err)
+		}
+		if blobs == nil {
+			blobs = make(map[string][]byte)
+		}
+		blobs[name] = data
+	}
+	return
+}
+
+func (sm *CSessionManager) restoreBlobs(blobs map[string][]byte) (err error) {
+	for name, data := range blobs {
+		sm.RLock()
+		blob, ok := sm.blobs[name]
+		sm.RUnlock()
+		if !ok {
+			log.DebugF("session state not registered: %v", name)
+			continue
+		}
+		if e := blob.restore(data); e != nil && err == nil {
+			err = fmt.Errorf("error restoring session state %v: %w", name, e)
+		}
+	}
+	return
+}
+
+func propertyListed(properties []Property, property Property) bool {
+	for _, p := range properties {
+		if p == property {
+			return true
+		}
+	}
+	return false
+}
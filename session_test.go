@@ -0,0 +1,112 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paths"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestSessionManager(t *testing.T) {
+	Convey("Session state persistence", t, func() {
+		dir := t.TempDir()
+		var size ptypes.Rectangle
+		var blob string
+		newSession := func(d Display, settings Object) *CSessionManager {
+			cd := d.(*CDisplay)
+			cd.Lock()
+			cd.running = true
+			cd.started = true
+			cd.Unlock()
+			w, h := d.Screen().Size()
+			size = ptypes.MakeRectangle(w, h)
+			sm := NewSessionManager("session-test")
+			sm.SetDirectory(dir)
+			sm.RegisterPropertySet("settings", settings, "volume")
+			sm.RegisterState("blob", func() ([]byte, error) {
+				return []byte(blob), nil
+			}, func(data []byte) error {
+				blob = string(data)
+				return nil
+			})
+			So(sm.Attach(d), ShouldBeNil)
+			return sm
+		}
+		newWindows := func(d Display) (a, b, unnamed Window) {
+			a, b, unnamed = NewOffscreenWindow("Alpha"), NewOffscreenWindow("Beta"), NewOffscreenWindow("Other")
+			a.SetName("alpha")
+			b.SetName("beta")
+			d.MapWindow(a)
+			d.MapWindow(b)
+			d.MapWindow(unnamed)
+			return
+		}
+		newSettings := func() Object {
+			settings := &CObject{}
+			settings.Init()
+			So(settings.InstallProperty("volume", IntProperty, true, 5), ShouldBeNil)
+			So(settings.InstallProperty("mute", BoolProperty, true, false), ShouldBeNil)
+			return settings
+		}
+
+		WithDisplayManager(func(d Display) {
+			settings := newSettings()
+			sm := newSession(d, settings)
+			a, b, _ := newWindows(d)
+			d.Emit(SignalStartupComplete)
+			d.(*CDisplay).setWindowRegion(a, ptypes.MakeRegion(1, 2, 20, 10))
+			d.SetWindowState(b, WindowStateShaded)
+			d.FocusWindow(a)
+			So(settings.SetIntProperty("volume", 9), ShouldBeNil)
+			So(settings.SetBoolProperty("mute", true), ShouldBeNil)
+			blob = "custom"
+			d.Emit(SignalDisplayShutdown)
+			So(paths.IsFile(sm.GetPath(size)), ShouldBeTrue)
+			So(sm.GetPath(size), ShouldEndWith, fmt.Sprintf("session-%dx%d.json", size.W, size.H))
+			So(sm.Save(), ShouldNotBeNil) // detached at shutdown
+		})()
+
+		blob = ""
+		WithDisplayManager(func(d Display) {
+			settings := newSettings()
+			newSession(d, settings)
+			a, b, unnamed := newWindows(d)
+			So(d.FocusedWindow(), ShouldEqual, unnamed)
+			d.Emit(SignalStartupComplete)
+			So(d.FocusedWindow(), ShouldEqual, a)
+			So(d.(*CDisplay).mappedWindowRegion(a, size), ShouldResemble, ptypes.MakeRegion(1, 2, 20, 10))
+			So(d.GetWindowState(b), ShouldEqual, WindowStateShaded)
+			So(d.GetWindowState(unnamed), ShouldEqual, WindowStateNormal)
+			volume, _ := settings.GetIntProperty("volume")
+			So(volume, ShouldEqual, 9)
+			mute, _ := settings.GetBoolProperty("mute")
+			So(mute, ShouldBeFalse) // not within the property set
+			So(blob, ShouldEqual, "custom")
+		})()
+
+		Convey("kept for each display size", func() {
+			sm := NewSessionManager("session-test")
+			sm.SetDirectory(dir)
+			So(sm.GetPath(ptypes.MakeRectangle(10, 10)), ShouldNotEqual, sm.GetPath(size))
+			So(sm.Restore(), ShouldNotBeNil) // not attached
+			So(sm.Attach(nil), ShouldNotBeNil)
+		})
+	})
+}
@@ -22,11 +22,40 @@ type SignalListenerFn func(data []interface{}, argv ...interface{}) enums.EventF
 
 type SignalListenerData []interface{}
 
+// SignalPhase is the stage of a signal emission during which a listener is
+// invoked. As with GTK, listeners connected normally run first, followed by
+// the default handlers and lastly by the listeners connected after.
+type SignalPhase uint8
+
+const (
+	// SignalPhaseNormal listeners are connected with Connect and
+	// ConnectWithPriority
+	SignalPhaseNormal SignalPhase = iota
+	// SignalPhaseDefault listeners are connected with ConnectDefault
+	SignalPhaseDefault
+	// SignalPhaseAfter listeners are connected with ConnectAfter
+	SignalPhaseAfter
+)
+
+func (p SignalPhase) String() string {
+	switch p {
+	case SignalPhaseNormal:
+		return "normal"
+	case SignalPhaseDefault:
+		return "default"
+	case SignalPhaseAfter:
+		return "after"
+	}
+	return "unknown"
+}
+
 type CSignalListener struct {
 	s Signal
 	n string
 	c SignalListenerFn
 	d SignalListenerData
+	p SignalPhase
+	r int
 }
 
 func newSignalListener(s Signal, n string, c SignalListenerFn, d SignalListenerData) *CSignalListener {
@@ -38,6 +67,15 @@ func newSignalListener(s Signal, n string, c SignalListenerFn, d SignalListenerD
 	}
 }
 
+// runsBefore returns true if the listener is to be invoked before other, when
+// both are connected at the same time
+func (l *CSignalListener) runsBefore(other *CSignalListener) bool {
+	if l.p != other.p {
+		return l.p < other.p
+	}
+	return l.r >= other.r
+}
+
 func (l *CSignalListener) Signal() Signal {
 	return l.s
 }
@@ -53,3 +91,11 @@ func (l *CSignalListener) Func() SignalListenerFn {
 func (l *CSignalListener) Data() SignalListenerData {
 	return l.d
 }
+
+func (l *CSignalListener) Phase() SignalPhase {
+	return l.p
+}
+
+func (l *CSignalListener) Priority() int {
+	return l.r
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-curses/cdk/env"
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+var (
+	// SignalTraceCapacity is the number of emissions kept by the signal trace,
+	// changes take effect the next time tracing is enabled
+	SignalTraceCapacity = 1024
+)
+
+// SignalTraceRecord describes a single signal emission
+type SignalTraceRecord struct {
+	// When the emission started
+	When time.Time
+	// Source is the name of the object emitting the signal
+	Source string
+	Signal Signal
+	// Argv are the types of the emission arguments
+	Argv []string
+	// Listeners are the handles of the listeners invoked, in order
+	Listeners []string
+	// Handler is the handle of the listener which returned EVENT_STOP, if any
+	Handler string
+	// Reason explains an emission ended without invoking all listeners other
+	// than by a listener returning EVENT_STOP: frozen, stopped, passed or cycle
+	Reason string
+	// Depth is the number of emissions of the signal on the source in progress
+	Depth int
+	Flag  enums.EventFlag
+}
+
+func (r SignalTraceRecord) String() string {
+	handled := r.Handler
+	if handled == "" {
+		handled = r.Reason
+	}
+	return fmt.Sprintf(
+		"%v %v %v(%v) [%v] %v %v",
+		r.When.Format("15:04:05.000000"), r.Source, r.Signal,
+		strings.Join(r.Argv, ","), strings.Join(r.Listeners, ","),
+		handled, r.Flag,
+	)
+}
+
+type cSignalTrace struct {
+	records []SignalTraceRecord
+	next    int
+	full    bool
+
+	sync.Mutex
+}
+
+var (
+	signalTracing int32
+	signalTrace   = &cSignalTrace{}
+)
+
+func init() {
+	if v := env.Get("GO_CDK_SIGNAL_TRACE", "false"); v == "true" {
+		EnableSignalTrace(true)
+	}
+}
+
+// EnableSignalTrace starts or stops the recording of every signal emission,
+// the trace is also enabled by setting GO_CDK_SIGNAL_TRACE=true in the
+// environment. The most recent SignalTraceCapacity emissions are kept,
+// starting a trace discards those previously recorded. See: GetSignalTrace
+func EnableSignalTrace(enabled bool) {
+	signalTrace.Lock()
+	if enabled {
+		signalTrace.records = make([]SignalTraceRecord, 0, SignalTraceCapacity)
+		signalTrace.next = 0
+		signalTrace.full = false
+		atomic.StoreInt32(&signalTracing, 1)
+	} else {
+		atomic.StoreInt32(&signalTracing, 0)
+	}
+	signalTrace.Unlock()
+}
+
+// IsSignalTraceEnabled returns true if signal emissions are being recorded
+func IsSignalTraceEnabled() (enabled bool) {
+	return atomic.LoadInt32(&signalTracing) == 1
+}
+
+// GetSignalTrace returns the signal emissions recorded, oldest first. Nested
+// emissions complete, and so are recorded, before the emission which caused
+// them.
+func GetSignalTrace() (records []SignalTraceRecord) {
+	signalTrace.Lock()
+	defer signalTrace.Unlock()
+	if signalTrace.full {
+		records = append(records, signalTrace.records[signalTrace.next:]...)
+		records = append(records, signalTrace.records[:signalTrace.next]...)
+		return
+	}
+	records = append(records, signalTrace.records...)
+	return
+}
+
+// ClearSignalTrace discards the signal emissions recorded so far
+func ClearSignalTrace() {
+	signalTrace.Lock()
+	signalTrace.records = signalTrace.records[:0]
+	signalTrace.next = 0
+	signalTrace.full = false
+	signalTrace.Unlock()
+}
+
+func newSignalTraceRecord(o *CSignaling, signal Signal, argv []interface{}) (record *SignalTraceRecord) {
+	record = &SignalTraceRecord{
+		When:   time.Now(),
+		Source: o.ObjectName(),
+		Signal: signal,
+		Argv:   make([]string, len(argv)),
+	}
+	for idx, arg := range argv {
+		record.Argv[idx] = fmt.Sprintf("%T", arg)
+	}
+	return
+}
+
+func recordSignalTrace(record *SignalTraceRecord) {
+	if !IsSignalTraceEnabled() {
+		return
+	}
+	signalTrace.Lock()
+	defer signalTrace.Unlock()
+	if capacity := cap(signalTrace.records); capacity == 0 {
+		return
+	} else if len(signalTrace.records) < capacity {
+		signalTrace.records = append(signalTrace.records, *record)
+		return
+	}
+	signalTrace.records[signalTrace.next] = *record
+	signalTrace.next = (signalTrace.next + 1) % len(signalTrace.records)
+	signalTrace.full = true
+}
+
+// SignalConnection describes a listener connected to a signal, see:
+// Signaling.ListConnections
+type SignalConnection struct {
+	Signal   Signal
+	Handle   string
+	Phase    SignalPhase
+	Priority int
+}
+
+func (c SignalConnection) String() string {
+	return fmt.Sprintf("%v:%v(%v,%d)", c.Signal, c.Handle, c.Phase, c.Priority)
+}
@@ -0,0 +1,88 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestSignalTrace(t *testing.T) {
+	Convey("Signal tracing", t, func() {
+		s := new(CSignaling)
+		s.Init()
+		s.Connect(SignalEventKey, "passing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return enums.EVENT_PASS
+		})
+		s.ConnectWithPriority(SignalEventKey, "stopping", -1, func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return enums.EVENT_STOP
+		})
+		s.Emit(SignalEventKey, "untraced")
+		So(IsSignalTraceEnabled(), ShouldBeFalse)
+		EnableSignalTrace(true)
+		defer EnableSignalTrace(false)
+		So(s.Emit(SignalEventKey, "traced", 10), ShouldEqual, enums.EVENT_STOP)
+		s.StopSignal(SignalEventMouse)
+		So(s.Emit(SignalEventMouse), ShouldEqual, enums.EVENT_STOP)
+		records := GetSignalTrace()
+		So(records, ShouldHaveLength, 2)
+		So(records[0].Signal, ShouldEqual, SignalEventKey)
+		So(records[0].Source, ShouldEqual, s.ObjectName())
+		So(records[0].Argv, ShouldResemble, []string{"string", "int"})
+		So(records[0].Listeners, ShouldResemble, []string{"passing", "stopping"})
+		So(records[0].Handler, ShouldEqual, "stopping")
+		So(records[0].Flag, ShouldEqual, enums.EVENT_STOP)
+		So(records[0].Depth, ShouldEqual, 1)
+		So(records[1].Reason, ShouldEqual, "stopped")
+		So(records[1].Listeners, ShouldBeEmpty)
+		So(records[0].String(), ShouldContainSubstring, "stopping")
+		ClearSignalTrace()
+		So(GetSignalTrace(), ShouldBeEmpty)
+	})
+	Convey("Signal trace capacity", t, func() {
+		capacity := SignalTraceCapacity
+		SignalTraceCapacity = 3
+		defer func() { SignalTraceCapacity = capacity }()
+		EnableSignalTrace(true)
+		defer EnableSignalTrace(false)
+		s := new(CSignaling)
+		s.Init()
+		for i := 0; i < 5; i++ {
+			s.Emit(SignalEventKey, i)
+		}
+		records := GetSignalTrace()
+		So(records, ShouldHaveLength, 3)
+		So(records[0].Argv, ShouldResemble, []string{"int"})
+		So(records[2].When.Before(records[0].When), ShouldBeFalse)
+	})
+	Convey("Listing connections", t, func() {
+		s := new(CSignaling)
+		s.Init()
+		fn := func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return enums.EVENT_PASS
+		}
+		s.ConnectAfter(SignalEventMouse, "after", fn)
+		s.Connect(SignalEventMouse, "normal", fn)
+		s.ConnectWithPriority(SignalEventKey, "key", 5, fn)
+		So(s.ListConnections(), ShouldResemble, []SignalConnection{
+			{Signal: SignalEventKey, Handle: "key", Phase: SignalPhaseNormal, Priority: 5},
+			{Signal: SignalEventMouse, Handle: "normal", Phase: SignalPhaseNormal},
+			{Signal: SignalEventMouse, Handle: "after", Phase: SignalPhaseAfter},
+		})
+	})
+}
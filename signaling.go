@@ -16,8 +16,10 @@ package cdk
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/sync"
 	"github.com/go-curses/cdk/log"
 )
 
@@ -29,14 +31,29 @@ func init() {
 	_ = TypesManager.AddType(TypeSignaling, nil)
 }
 
+var (
+	// MaxSignalEmissionDepth limits how deeply the emissions of a signal may
+	// nest on a single object within one goroutine, listeners emitting the
+	// signal they are handling (directly or through other signals) beyond this
+	// depth are considered a cycle. Concurrent emissions from other goroutines
+	// are not counted. The cycle is logged and the emission stopped with EVENT_STOP.
+	// Zero disables the detection.
+	MaxSignalEmissionDepth = 64
+)
+
 type Signaling interface {
 	TypeItem
 
 	Init() (already bool)
 	Handled(signal Signal, handle string) (found bool)
 	Connect(signal Signal, handle string, c SignalListenerFn, data ...interface{})
+	ConnectWithPriority(signal Signal, handle string, priority int, c SignalListenerFn, data ...interface{})
+	ConnectDefault(signal Signal, handle string, c SignalListenerFn, data ...interface{})
+	ConnectAfter(signal Signal, handle string, c SignalListenerFn, data ...interface{})
 	Disconnect(signal Signal, handle string) error
 	Emit(signal Signal, argv ...interface{}) enums.EventFlag
+	IsEmitting(signal Signal) (emitting bool)
+	ListConnections() (connections []SignalConnection)
 	HasListeners(signal Signal) (has bool)
 	DisconnectAll()
 	StopSignal(signals ...Signal)
@@ -56,6 +73,10 @@ type CSignaling struct {
 	stopped   []Signal
 	passed    []Signal
 	listeners map[Signal][]*CSignalListener
+	emitting  map[emission]int
+	// sigLock guards the signal state, separate from the lock of the object
+	// so that signals may be emitted while the object is locked
+	sigLock sync.RWMutex
 }
 
 func (o *CSignaling) Init() (already bool) {
@@ -69,6 +90,7 @@ func (o *CSignaling) Init() (already bool) {
 	if o.listeners == nil {
 		o.listeners = make(map[Signal][]*CSignalListener)
 	}
+	o.emitting = make(map[emission]int)
 	return false
 }
 
@@ -77,112 +99,279 @@ func (o *CSignaling) Init() (already bool) {
 //
 // Locking: read
 func (o *CSignaling) Handled(signal Signal, handle string) (found bool) {
-	o.RLock()
+	o.sigLock.RLock()
 	if listeners, ok := o.listeners[signal]; ok {
 		for _, listener := range listeners {
 			if listener.n == handle {
-				o.RUnlock()
+				o.sigLock.RUnlock()
 				return true
 			}
 		}
 	}
-	o.RUnlock()
+	o.sigLock.RUnlock()
 	return false
 }
 
-// Connect callback to signal, identified by handle
+// Connect callback to signal, identified by handle. Listeners are invoked in
+// reverse connection order, before any default handlers or listeners connected
+// after, see: ConnectWithPriority, ConnectDefault and ConnectAfter.
 //
 // Locking: write
 func (o *CSignaling) Connect(signal Signal, handle string, c SignalListenerFn, data ...interface{}) {
-	o.Lock()
+	o.connect(signal, handle, SignalPhaseNormal, 0, c, data)
+}
+
+// ConnectWithPriority connects callback to signal like Connect, with listeners
+// of a higher priority being invoked before those of a lower priority. Connect
+// uses a priority of zero.
+//
+// Locking: write
+func (o *CSignaling) ConnectWithPriority(signal Signal, handle string, priority int, c SignalListenerFn, data ...interface{}) {
+	o.connect(signal, handle, SignalPhaseNormal, priority, c, data)
+}
+
+// ConnectDefault connects the default handler of signal, identified by handle.
+// Default handlers are invoked after the listeners connected with Connect,
+// allowing those to prevent the default behaviour by returning EVENT_STOP.
+//
+// Locking: write
+func (o *CSignaling) ConnectDefault(signal Signal, handle string, c SignalListenerFn, data ...interface{}) {
+	o.connect(signal, handle, SignalPhaseDefault, 0, c, data)
+}
+
+// ConnectAfter connects callback to signal, identified by handle, to be
+// invoked after the default handlers.
+//
+// Locking: write
+func (o *CSignaling) ConnectAfter(signal Signal, handle string, c SignalListenerFn, data ...interface{}) {
+	o.connect(signal, handle, SignalPhaseAfter, 0, c, data)
+}
+
+// connect inserts the listener keeping the listeners of the signal in their
+// order of emission, replacing any existing listener with the same handle
+func (o *CSignaling) connect(signal Signal, handle string, phase SignalPhase, priority int, c SignalListenerFn, data []interface{}) {
+	o.sigLock.Lock()
+	defer o.sigLock.Unlock()
 	if o.listeners == nil {
 		o.listeners = make(map[Signal][]*CSignalListener)
 	}
-	if _, ok := o.listeners[signal]; !ok {
-		o.listeners[signal] = make([]*CSignalListener, 0)
-	}
-	if listeners, ok := o.listeners[signal]; ok {
-		for idx, listener := range listeners {
-			if listener.n == handle {
-				log.TraceDF(1, "replacing %v listener for handler: %v", signal, handle)
-				o.listeners[signal][idx].c = c
-				o.listeners[signal][idx].d = data
-				o.Unlock()
+	listeners := o.listeners[signal]
+	for idx, listener := range listeners {
+		if listener.n == handle {
+			log.TraceDF(2, "replacing %v listener for handler: %v", signal, handle)
+			if listener.p == phase && listener.r == priority {
+				listener.c = c
+				listener.d = data
 				return
 			}
+			listeners = append(listeners[:idx:idx], listeners[idx+1:]...)
+			break
 		}
 	}
-	log.TraceDF(1, "connecting %v listener with handler: %v", signal, handle)
-	o.listeners[signal] = append(o.listeners[signal], newSignalListener(signal, handle, c, data))
-	o.Unlock()
+	log.TraceDF(2, "connecting %v listener with handler: %v", signal, handle)
+	listener := newSignalListener(signal, handle, c, data)
+	listener.p = phase
+	listener.r = priority
+	at := len(listeners)
+	for idx, other := range listeners {
+		if listener.runsBefore(other) {
+			at = idx
+			break
+		}
+	}
+	inserted := make([]*CSignalListener, 0, len(listeners)+1)
+	inserted = append(inserted, listeners[:at]...)
+	inserted = append(inserted, listener)
+	inserted = append(inserted, listeners[at:]...)
+	o.listeners[signal] = inserted
 }
 
 // Disconnect callback from signal identified by handle
 //
 // Locking: write
 func (o *CSignaling) Disconnect(signal Signal, handle string) error {
-	o.Lock()
+	o.sigLock.Lock()
 	if listeners, ok := o.listeners[signal]; ok {
 		for idx, listener := range listeners {
 			if listener.n == handle {
-				o.listeners[signal] = append(o.listeners[signal][:idx], o.listeners[signal][idx+1:]...)
+				// listeners are copied on write, emissions in progress
+				// continue with the listeners they started with
+				o.listeners[signal] = append(listeners[:idx:idx], listeners[idx+1:]...)
 				o.LogTrace("disconnected %v listener: %v", signal, handle)
-				o.Unlock()
+				o.sigLock.Unlock()
 				return nil
 			}
 		}
-		o.Unlock()
+		o.sigLock.Unlock()
 		return fmt.Errorf("%v signal handler not found: %v", signal, handle)
 	}
-	o.Unlock()
+	o.sigLock.Unlock()
 	return fmt.Errorf("signal not found: %v", signal)
 }
 
-// Emit a signal event to all connected listener callbacks
+// Emit a signal event to all connected listener callbacks, in order of their
+// phase and priority. The emission ends with the first listener to return
+// EVENT_STOP.
+//
+// The listeners are invoked without holding the lock, allowing them to connect
+// and disconnect listeners or to emit signals themselves. Changes made to the
+// listeners of the signal take effect from the next emission. Nested emissions
+// deeper than MaxSignalEmissionDepth are logged and stopped.
 //
-// Locking: none
-func (o *CSignaling) Emit(signal Signal, argv ...interface{}) enums.EventFlag {
-	if o.frozen > 0 {
+// Locking: read, write
+func (o *CSignaling) Emit(signal Signal, argv ...interface{}) (flag enums.EventFlag) {
+	var trace *SignalTraceRecord
+	if IsSignalTraceEnabled() {
+		trace = newSignalTraceRecord(o, signal, argv)
+		defer func() {
+			trace.Flag = flag
+			recordSignalTrace(trace)
+		}()
+	}
+	o.sigLock.RLock()
+	frozen := o.frozen > 0
+	stopped := o.getSignalStopIndex(signal) >= 0
+	passed := o.getSignalPassIndex(signal) >= 0
+	listeners := o.listeners[signal]
+	o.sigLock.RUnlock()
+	if frozen {
+		if trace != nil {
+			trace.Reason = "frozen"
+		}
 		return enums.EVENT_PASS
 	}
-	if o.IsSignalStopped(signal) {
+	if stopped {
+		if trace != nil {
+			trace.Reason = "stopped"
+		}
 		return enums.EVENT_STOP
 	}
-	if o.IsSignalPassed(signal) {
+	if passed {
+		if trace != nil {
+			trace.Reason = "passed"
+		}
 		return enums.EVENT_PASS
 	}
-	if listeners, ok := o.listeners[signal]; ok {
-		if max := len(listeners); max > 0 {
-			for i := max - 1; i > -1; i-- {
-				listener := listeners[i]
-				if r := listener.c(listener.d, argv...); r == enums.EVENT_STOP {
-					o.LogTrace("%v signal stopped by listener: %v", signal, listener.n)
-					return enums.EVENT_STOP
-				}
+	if len(listeners) == 0 {
+		return enums.EVENT_PASS
+	}
+	gid := currentGoroutineID()
+	depth := o.enterEmission(signal, gid)
+	defer o.leaveEmission(signal, gid)
+	if trace != nil {
+		trace.Depth = depth
+	}
+	if MaxSignalEmissionDepth > 0 && depth > MaxSignalEmissionDepth {
+		if trace != nil {
+			trace.Reason = "cycle"
+		}
+		o.LogError("%v signal emission cycle detected, stopped at depth: %d", signal, depth)
+		return enums.EVENT_STOP
+	}
+	for _, listener := range listeners {
+		if trace != nil {
+			trace.Listeners = append(trace.Listeners, listener.n)
+		}
+		if r := listener.c(listener.d, argv...); r == enums.EVENT_STOP {
+			if trace != nil {
+				trace.Handler = listener.n
 			}
+			o.LogTrace("%v signal stopped by listener: %v", signal, listener.n)
+			return enums.EVENT_STOP
 		}
 	}
 	return enums.EVENT_PASS
 }
 
+// emission identifies the emissions of a signal made by one goroutine
+type emission struct {
+	signal Signal
+	gid    uint64
+}
+
+// enterEmission records the start of an emission, returning the number of
+// emissions of the signal in progress on the given goroutine
+func (o *CSignaling) enterEmission(signal Signal, gid uint64) (depth int) {
+	key := emission{signal: signal, gid: gid}
+	o.sigLock.Lock()
+	if o.emitting == nil {
+		o.emitting = make(map[emission]int)
+	}
+	o.emitting[key] += 1
+	depth = o.emitting[key]
+	o.sigLock.Unlock()
+	return
+}
+
+func (o *CSignaling) leaveEmission(signal Signal, gid uint64) {
+	key := emission{signal: signal, gid: gid}
+	o.sigLock.Lock()
+	if o.emitting[key] <= 1 {
+		delete(o.emitting, key)
+	} else {
+		o.emitting[key] -= 1
+	}
+	o.sigLock.Unlock()
+}
+
+// IsEmitting returns TRUE if the given signal is currently being emitted by
+// the calling goroutine.
+//
+// Locking: read
+func (o *CSignaling) IsEmitting(signal Signal) (emitting bool) {
+	key := emission{signal: signal, gid: currentGoroutineID()}
+	o.sigLock.RLock()
+	emitting = o.emitting[key] > 0
+	o.sigLock.RUnlock()
+	return
+}
+
+// ListConnections returns the listeners connected to all signals, sorted by
+// signal and then in the order they are invoked.
+//
+// Locking: read
+func (o *CSignaling) ListConnections() (connections []SignalConnection) {
+	o.sigLock.RLock()
+	signals := make([]string, 0, len(o.listeners))
+	for signal := range o.listeners {
+		signals = append(signals, string(signal))
+	}
+	sort.Strings(signals)
+	for _, signal := range signals {
+		for _, listener := range o.listeners[Signal(signal)] {
+			connections = append(connections, SignalConnection{
+				Signal:   listener.s,
+				Handle:   listener.n,
+				Phase:    listener.p,
+				Priority: listener.r,
+			})
+		}
+	}
+	o.sigLock.RUnlock()
+	return
+}
+
 // HasListeners returns true if there are one or more listeners connected to the
 // given Signal.
+//
+// Locking: read
 func (o *CSignaling) HasListeners(signal Signal) (has bool) {
-	if listeners, ok := o.listeners[signal]; ok {
-		has = len(listeners) > 0
-	}
+	o.sigLock.RLock()
+	has = len(o.listeners[signal]) > 0
+	o.sigLock.RUnlock()
 	return
 }
 
+// DisconnectAll removes all listeners from all signals.
+//
+// Locking: write
 func (o *CSignaling) DisconnectAll() {
-	o.RLock()
-	signalListeners := o.listeners
-	o.RUnlock()
-	for signal, listeners := range signalListeners {
-		for _, listener := range listeners {
-			_ = o.Disconnect(signal, listener.n)
-		}
+	o.sigLock.Lock()
+	for signal := range o.listeners {
+		o.LogTrace("disconnected all %v listeners", signal)
 	}
+	o.listeners = make(map[Signal][]*CSignalListener)
+	o.sigLock.Unlock()
 }
 
 // StopSignal disables propagation of the given signal with an EVENT_STOP
@@ -192,17 +381,19 @@ func (o *CSignaling) StopSignal(signals ...Signal) {
 	for _, signal := range signals {
 		if !o.IsSignalStopped(signal) {
 			o.LogTrace("stopping %v signal", signal)
-			o.Lock()
+			o.sigLock.Lock()
 			o.stopped = append(o.stopped, signal)
-			o.Unlock()
+			o.sigLock.Unlock()
 		}
 	}
 }
 
 // IsSignalStopped returns TRUE if the given signal is currently stopped.
 //
-// Locking: none
+// Locking: read
 func (o *CSignaling) IsSignalStopped(signals ...Signal) (stopped bool) {
+	o.sigLock.RLock()
+	defer o.sigLock.RUnlock()
 	for _, signal := range signals {
 		if o.getSignalStopIndex(signal) < 0 {
 			return
@@ -228,17 +419,19 @@ func (o *CSignaling) PassSignal(signals ...Signal) {
 	for _, signal := range signals {
 		if !o.IsSignalPassed(signal) {
 			o.LogTrace("passing %v signal", signal)
-			o.Lock()
+			o.sigLock.Lock()
 			o.passed = append(o.passed, signal)
-			o.Unlock()
+			o.sigLock.Unlock()
 		}
 	}
 }
 
 // IsSignalPassed returns TRUE if the given signal is currently passed.
 //
-// Locking: none
+// Locking: read
 func (o *CSignaling) IsSignalPassed(signals ...Signal) (passed bool) {
+	o.sigLock.RLock()
+	defer o.sigLock.RUnlock()
 	for _, signal := range signals {
 		if o.getSignalPassIndex(signal) < 0 {
 			return
@@ -262,7 +455,7 @@ func (o *CSignaling) getSignalPassIndex(signal Signal) int {
 //
 // Locking: write
 func (o *CSignaling) ResumeSignal(signals ...Signal) {
-	o.Lock()
+	o.sigLock.Lock()
 	for _, signal := range signals {
 		if sid := o.getSignalStopIndex(signal); sid >= 0 {
 			o.LogTrace("resuming %v stopped signal", signal)
@@ -287,38 +480,38 @@ func (o *CSignaling) ResumeSignal(signals ...Signal) {
 			}
 		}
 	}
-	o.Unlock()
+	o.sigLock.Unlock()
 }
 
 // Freeze pauses all signal emissions until a corresponding Thaw is called.
 //
 // Locking: write
 func (o *CSignaling) Freeze() {
-	o.Lock()
+	o.sigLock.Lock()
 	o.frozen += 1
-	o.Unlock()
+	o.sigLock.Unlock()
 }
 
 // Thaw restores all signal emissions after a Freeze call.
 //
 // Locking: write
 func (o *CSignaling) Thaw() {
-	o.Lock()
+	o.sigLock.Lock()
 	if o.frozen <= 0 {
 		o.frozen = 0
 		o.LogError("Thaw() called too many times")
 	} else {
 		o.frozen -= 1
 	}
-	o.Unlock()
+	o.sigLock.Unlock()
 }
 
 // IsFrozen returns TRUE if Thaw has been called at least once.
 //
 // Locking: read, signal read
 func (o *CSignaling) IsFrozen() (frozen bool) {
-	o.RLock()
+	o.sigLock.RLock()
 	frozen = o.frozen > 0
-	o.RUnlock()
+	o.sigLock.RUnlock()
 	return
 }
@@ -137,3 +137,138 @@ func TestSignalingPatterns(t *testing.T) {
 		s.ResumeSignal(SignalEventError)
 	})
 }
+
+func TestSignalingPhases(t *testing.T) {
+	Convey("Signaling Priorities and Phases", t, func() {
+		s := new(CSignaling)
+		s.Init()
+		var order []string
+		listener := func(name string, flag enums.EventFlag) SignalListenerFn {
+			return func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				order = append(order, name)
+				return flag
+			}
+		}
+		s.ConnectAfter(SignalEventKey, "after", listener("after", enums.EVENT_PASS))
+		s.ConnectDefault(SignalEventKey, "default", listener("default", enums.EVENT_PASS))
+		s.Connect(SignalEventKey, "first", listener("first", enums.EVENT_PASS))
+		s.Connect(SignalEventKey, "second", listener("second", enums.EVENT_PASS))
+		s.ConnectWithPriority(SignalEventKey, "low", -10, listener("low", enums.EVENT_PASS))
+		s.ConnectWithPriority(SignalEventKey, "high", 10, listener("high", enums.EVENT_PASS))
+		So(s.Emit(SignalEventKey), ShouldEqual, enums.EVENT_PASS)
+		So(order, ShouldResemble, []string{"high", "second", "first", "low", "default", "after"})
+		order = nil
+		// user listeners prevent the default handlers
+		s.ConnectWithPriority(SignalEventKey, "first", -20, listener("first", enums.EVENT_STOP))
+		So(s.Emit(SignalEventKey), ShouldEqual, enums.EVENT_STOP)
+		So(order, ShouldResemble, []string{"high", "second", "low", "first"})
+		order = nil
+		So(s.Disconnect(SignalEventKey, "first"), ShouldBeNil)
+		s.ConnectAfter(SignalEventKey, "high", listener("high", enums.EVENT_PASS))
+		So(s.Emit(SignalEventKey), ShouldEqual, enums.EVENT_PASS)
+		So(order, ShouldResemble, []string{"second", "low", "default", "high", "after"})
+		So(SignalPhaseDefault.String(), ShouldEqual, "default")
+	})
+}
+
+func TestSignalingReentrancy(t *testing.T) {
+	Convey("Signaling Re-entrant Emission", t, func() {
+		s := new(CSignaling)
+		s.Init()
+		calls := 0
+		s.Connect(SignalEventKey, "connecting", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			calls++
+			// connecting during an emission only affects later emissions
+			s.Connect(SignalEventKey, fmt.Sprintf("connected-%d", calls), func(data []interface{}, argv ...interface{}) enums.EventFlag {
+				return enums.EVENT_PASS
+			})
+			So(s.IsEmitting(SignalEventKey), ShouldBeTrue)
+			return enums.EVENT_PASS
+		})
+		So(s.Emit(SignalEventKey), ShouldEqual, enums.EVENT_PASS)
+		So(calls, ShouldEqual, 1)
+		So(s.Handled(SignalEventKey, "connected-1"), ShouldBeTrue)
+		So(s.IsEmitting(SignalEventKey), ShouldBeFalse)
+		s.DisconnectAll()
+		So(s.HasListeners(SignalEventKey), ShouldBeFalse)
+	})
+	Convey("Signaling While Locked", t, func() {
+		s := new(CSignaling)
+		s.Init()
+		s.Connect(SignalEventKey, "locked", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return enums.EVENT_STOP
+		})
+		// emitting does not take the lock of the object
+		s.Lock()
+		So(s.Emit(SignalEventKey), ShouldEqual, enums.EVENT_STOP)
+		So(s.HasListeners(SignalEventKey), ShouldBeTrue)
+		s.Unlock()
+	})
+	Convey("Signaling Concurrent Emission", t, func() {
+		s := new(CSignaling)
+		s.Init()
+		done := make(chan bool)
+		go func() {
+			for i := 0; i < 100; i++ {
+				handle := fmt.Sprintf("concurrent-%d", i%4)
+				s.Connect(SignalEventKey, handle, func(data []interface{}, argv ...interface{}) enums.EventFlag {
+					return enums.EVENT_PASS
+				})
+				_ = s.Disconnect(SignalEventKey, handle)
+			}
+			done <- true
+		}()
+		for i := 0; i < 100; i++ {
+			So(s.Emit(SignalEventKey), ShouldEqual, enums.EVENT_PASS)
+		}
+		<-done
+	})
+	Convey("Signaling Emission Cycles", t, func() {
+		s := new(CSignaling)
+		s.Init()
+		depth := 0
+		s.Connect(SignalEventKey, "cycle", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			depth++
+			return s.Emit(SignalEventMouse)
+		})
+		s.Connect(SignalEventMouse, "cycle", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			return s.Emit(SignalEventKey)
+		})
+		So(s.Emit(SignalEventKey), ShouldEqual, enums.EVENT_STOP)
+		So(depth, ShouldEqual, MaxSignalEmissionDepth)
+		So(s.IsEmitting(SignalEventKey), ShouldBeFalse)
+		So(s.IsEmitting(SignalEventMouse), ShouldBeFalse)
+	})
+	Convey("Signaling Concurrent Emission Depth", t, func() {
+		s := new(CSignaling)
+		s.Init()
+		entered := make(chan bool)
+		release := make(chan bool)
+		s.Connect(SignalEventKey, "waiting", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			entered <- true
+			<-release
+			return enums.EVENT_PASS
+		})
+		count := MaxSignalEmissionDepth + 1
+		results := make(chan enums.EventFlag, count)
+		waiting := 0
+		for i := 0; i < count; i++ {
+			go func() {
+				results <- s.Emit(SignalEventKey)
+			}()
+			select {
+			case <-entered:
+				waiting++
+			case flag := <-results:
+				So(flag, ShouldEqual, enums.EVENT_PASS)
+			}
+		}
+		// emissions of other goroutines are neither nested nor visible
+		So(waiting, ShouldEqual, count)
+		So(s.IsEmitting(SignalEventKey), ShouldBeFalse)
+		close(release)
+		for i := 0; i < waiting; i++ {
+			So(<-results, ShouldEqual, enums.EVENT_PASS)
+		}
+	})
+}
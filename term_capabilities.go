@@ -0,0 +1,239 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	// CapabilityProbing enables sending the capability queries to the
+	// terminal when a Screen is initialized, see: Screen.Capabilities
+	CapabilityProbing = true
+
+	// CapabilityProbeTimeout is how long to wait for the terminal to answer
+	// the capability queries, replies arriving later are not recognized
+	CapabilityProbeTimeout = time.Second
+
+	// CapabilityNames are the terminfo capabilities requested with XTGETTCAP
+	CapabilityNames = []string{"RGB", "Tc", "Sync"}
+)
+
+const (
+	// da1Query requests the primary device attributes, every terminal
+	// answers this so it is sent last to mark the end of the replies
+	da1Query = "\x1b[c"
+	// da2Query requests the secondary device attributes
+	da2Query = "\x1b[>c"
+	// syncOutputQuery requests the state of synchronized output, DEC mode 2026
+	syncOutputQuery = "\x1b[?2026$p"
+	// kittyKeyboardQuery requests the kitty keyboard protocol flags
+	kittyKeyboardQuery = "\x1b[?u"
+	// sixelAttribute is the DA1 attribute of terminals supporting sixel
+	sixelAttribute = 4
+)
+
+// Capabilities describes what the terminal can do. These are first derived
+// from the terminfo entry and environment, then updated from the replies to
+// the queries sent when the Screen is initialized.
+type Capabilities struct {
+	// Probed is true once the terminal has answered the queries
+	Probed bool
+	// TrueColor is true if 24-bit colors are supported
+	TrueColor bool
+	// SyncOutput is true if synchronized output, DEC mode 2026, is supported
+	SyncOutput bool
+	// KittyKeyboard is true if the kitty keyboard protocol is supported
+	KittyKeyboard bool
+	// Hyperlinks is true if OSC 8 hyperlinks are supported
+	Hyperlinks bool
+	// Sixel is true if sixel graphics are supported
+	Sixel bool
+	// Notify is the desktop notification sequence supported, known only for
+	// the TerminalQuirks or set with $GO_CDK_NOTIFY
+	Notify NotifyProtocol
+	// DeviceAttributes are the parameters of the primary device attributes
+	// reply, starting with the conformance level
+	DeviceAttributes []int
+	// TerminalID and TerminalVersion are reported by the secondary device
+	// attributes reply
+	TerminalID      int
+	TerminalVersion int
+}
+
+// capabilityQueries returns the queries to send to the terminal
+func capabilityQueries() (queries string) {
+	for _, name := range CapabilityNames {
+		// some terminals stop at the first unknown name so each is sent
+		// separately
+		queries += "\x1bP+q" + hex.EncodeToString([]byte(name)) + "\x1b\\"
+	}
+	return queries + syncOutputQuery + kittyKeyboardQuery + da2Query + da1Query
+}
+
+type capabilityReportKind uint8
+
+const (
+	reportPrimaryAttributes capabilityReportKind = iota + 1
+	reportSecondaryAttributes
+	reportMode
+	reportKittyKeyboard
+	reportTermcap
+)
+
+// capabilityReport is a reply to one of the capability queries
+type capabilityReport struct {
+	kind   capabilityReportKind
+	params []int
+	// name and valid are the XTGETTCAP capability and whether the terminal
+	// recognized it
+	name  string
+	valid bool
+}
+
+// parseCapabilityReport parses a reply to the capability queries at the start
+// of the buffer. Returns the number of bytes consumed and partial true if the
+// buffer holds an incomplete reply.
+func parseCapabilityReport(b []byte) (report *capabilityReport, n int, partial bool) {
+	switch {
+	case len(b) < 3:
+		return nil, 0, bytes.HasPrefix([]byte("\x1b["), b) || bytes.HasPrefix([]byte("\x1bP"), b)
+	case b[0] != '\x1b':
+		return nil, 0, false
+	case b[1] == '[':
+		return parseCapabilityCSI(b)
+	case b[1] == 'P':
+		return parseCapabilityDCS(b)
+	}
+	return nil, 0, false
+}
+
+// parseCapabilityCSI parses the device attributes, mode and kitty keyboard
+// replies: CSI ? Ps ; ... c, CSI > Ps ; ... c, CSI ? Ps ; Pm $ y and
+// CSI ? Ps u
+func parseCapabilityCSI(b []byte) (report *capabilityReport, n int, partial bool) {
+	private := b[2]
+	if private != '?' && private != '>' {
+		return nil, 0, false
+	}
+	for i := 3; i < len(b); i++ {
+		c := b[i]
+		switch {
+		case c >= '0' && c <= '9', c == ';':
+			continue
+		case c == 'c':
+			report = &capabilityReport{kind: reportPrimaryAttributes}
+			if private == '>' {
+				report.kind = reportSecondaryAttributes
+			}
+		case c == 'u' && private == '?':
+			report = &capabilityReport{kind: reportKittyKeyboard}
+		case c == '$' && private == '?':
+			if i+1 == len(b) {
+				return nil, 0, true
+			} else if b[i+1] != 'y' {
+				return nil, 0, false
+			}
+			report = &capabilityReport{kind: reportMode}
+			report.params = parseCapabilityParams(string(b[3:i]))
+			return report, i + 2, false
+		default:
+			return nil, 0, false
+		}
+		report.params = parseCapabilityParams(string(b[3:i]))
+		return report, i + 1, false
+	}
+	return nil, 0, true
+}
+
+// parseCapabilityDCS parses the XTGETTCAP replies: DCS 1 + r Pt ST, where Pt
+// is the hex encoded name and value separated by '=', or DCS 0 + r Pt ST for
+// unknown capabilities
+func parseCapabilityDCS(b []byte) (report *capabilityReport, n int, partial bool) {
+	prefix := []byte{'\x1b', 'P', b[2], '+', 'r'}
+	if b[2] != '0' && b[2] != '1' || !bytes.HasPrefix(prefix, b[:min(len(b), len(prefix))]) {
+		return nil, 0, false
+	} else if len(b) < len(prefix) {
+		return nil, 0, true
+	}
+	end := bytes.Index(b, []byte("\x1b\\"))
+	if end < 0 {
+		return nil, 0, true
+	}
+	report = &capabilityReport{kind: reportTermcap, valid: b[2] == '1'}
+	for _, item := range strings.Split(string(b[len(prefix):end]), ";") {
+		key, _, _ := strings.Cut(item, "=")
+		if name, err := hex.DecodeString(key); err == nil && len(name) > 0 {
+			// only the first of the names is of interest, as each query
+			// requests only one
+			report.name = string(name)
+			break
+		}
+	}
+	return report, end + 2, false
+}
+
+func parseCapabilityParams(s string) (params []int) {
+	for _, field := range strings.Split(s, ";") {
+		if v, err := strconv.Atoi(field); err == nil {
+			params = append(params, v)
+		}
+	}
+	return
+}
+
+// update applies the reply to the capabilities, returning true once all of
+// the replies have been received
+func (c *Capabilities) update(report *capabilityReport) (done bool) {
+	switch report.kind {
+	case reportPrimaryAttributes:
+		c.DeviceAttributes = report.params
+		for idx, attribute := range report.params {
+			if idx > 0 && attribute == sixelAttribute {
+				c.Sixel = true
+			}
+		}
+		c.Probed = true
+		return true
+	case reportSecondaryAttributes:
+		if len(report.params) > 1 {
+			c.TerminalID, c.TerminalVersion = report.params[0], report.params[1]
+		}
+	case reportMode:
+		// 1 is set and 2 is reset, 0 and 4 are not recognized and
+		// permanently reset
+		if len(report.params) == 2 && report.params[0] == 2026 {
+			if state := report.params[1]; state == 1 || state == 2 {
+				c.SyncOutput = true
+			}
+		}
+	case reportKittyKeyboard:
+		c.KittyKeyboard = true
+	case reportTermcap:
+		if report.valid {
+			switch report.name {
+			case "RGB", "Tc":
+				c.TrueColor = true
+			case "Sync":
+				c.SyncOutput = true
+			}
+		}
+	}
+	return false
+}
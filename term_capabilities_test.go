@@ -0,0 +1,119 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTermCapabilities(t *testing.T) {
+	Convey("Capability queries", t, func() {
+		queries := capabilityQueries()
+		So(queries, ShouldStartWith, "\x1bP+q524742\x1b\\")
+		So(queries, ShouldContainSubstring, syncOutputQuery)
+		So(queries, ShouldEndWith, da2Query+da1Query)
+	})
+
+	Convey("Capability reports", t, func() {
+		report, n, partial := parseCapabilityReport([]byte("\x1b[?62;4;22cx"))
+		So(report, ShouldNotBeNil)
+		So(report.kind, ShouldEqual, reportPrimaryAttributes)
+		So(report.params, ShouldResemble, []int{62, 4, 22})
+		So(n, ShouldEqual, 11)
+		So(partial, ShouldBeFalse)
+		report, n, _ = parseCapabilityReport([]byte("\x1b[>41;370;0c"))
+		So(report.kind, ShouldEqual, reportSecondaryAttributes)
+		So(report.params, ShouldResemble, []int{41, 370, 0})
+		So(n, ShouldEqual, 12)
+		report, n, _ = parseCapabilityReport([]byte("\x1b[?2026;2$y"))
+		So(report.kind, ShouldEqual, reportMode)
+		So(report.params, ShouldResemble, []int{2026, 2})
+		So(n, ShouldEqual, 11)
+		report, _, _ = parseCapabilityReport([]byte("\x1b[?0u"))
+		So(report.kind, ShouldEqual, reportKittyKeyboard)
+		report, n, _ = parseCapabilityReport([]byte("\x1bP1+r524742=382F382F38\x1b\\"))
+		So(report.kind, ShouldEqual, reportTermcap)
+		So(report.valid, ShouldBeTrue)
+		So(report.name, ShouldEqual, "RGB")
+		So(n, ShouldEqual, 24)
+		report, _, _ = parseCapabilityReport([]byte("\x1bP0+r5463\x1b\\"))
+		So(report.valid, ShouldBeFalse)
+		So(report.name, ShouldEqual, "Tc")
+
+		for _, incomplete := range []string{"\x1b", "\x1b[", "\x1b[?62;4", "\x1b[?2026;2$", "\x1bP1", "\x1bP1+r5247"} {
+			report, n, partial = parseCapabilityReport([]byte(incomplete))
+			So(report, ShouldBeNil)
+			So(n, ShouldEqual, 0)
+			So(partial, ShouldBeTrue)
+		}
+		for _, other := range []string{"\x1b[A", "\x1b[1;5A", "\x1b[?62;4x", "\x1bPq", "x"} {
+			_, n, partial = parseCapabilityReport([]byte(other))
+			So(n, ShouldEqual, 0)
+			So(partial, ShouldBeFalse)
+		}
+	})
+
+	Convey("Screen capability probing", t, func() {
+		env := map[string]string{"TERM": "xterm-256color"}
+		s, err := NewScreenWithEnv(func(key string) (value string, ok bool) {
+			value, ok = env[key]
+			return
+		})
+		So(err, ShouldBeNil)
+		screen := s.(*CScreen)
+		screen.probing = true
+		screen.probeSent = time.Now()
+		So(screen.ti.SetFgRGB, ShouldEqual, "")
+		var evs []Event
+		buf := bytes.NewBufferString(strings.Join([]string{
+			"\x1bP1+r524742=382F382F38\x1b\\",
+			"\x1bP0+r5463\x1b\\",
+			"\x1b[?2026;2$y",
+			"\x1b[>1;10;0c",
+			"\x1b[?64;4;6c",
+		}, "") + "x")
+		for buf.Len() > 1 {
+			part, comp := screen.parseCapabilities(buf, &evs)
+			So(part, ShouldBeTrue)
+			So(comp, ShouldBeTrue)
+		}
+		So(buf.String(), ShouldEqual, "x")
+		So(screen.probing, ShouldBeFalse)
+		So(screen.ti.SetFgRGB, ShouldNotEqual, "")
+		So(evs, ShouldHaveLength, 1)
+		caps := evs[0].(*EventCapabilities).Capabilities()
+		So(caps.Probed, ShouldBeTrue)
+		So(caps.TrueColor, ShouldBeTrue)
+		So(caps.SyncOutput, ShouldBeTrue)
+		So(caps.Sixel, ShouldBeTrue)
+		So(caps.KittyKeyboard, ShouldBeFalse)
+		So(caps.TerminalID, ShouldEqual, 1)
+		So(caps.TerminalVersion, ShouldEqual, 10)
+		So(screen.Capabilities(), ShouldResemble, caps)
+
+		// replies arriving too late are left for the other parsers
+		screen.probeSent = time.Now().Add(-CapabilityProbeTimeout * 2)
+		buf = bytes.NewBufferString("\x1b[?64c")
+		part, comp := screen.parseCapabilities(buf, &evs)
+		So(part, ShouldBeFalse)
+		So(comp, ShouldBeFalse)
+		So(screen.probing, ShouldBeFalse)
+	})
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+)
+
+var (
+	// OSC52ChunkSize is the number of bytes of an OSC 52 clipboard sequence
+	// written to the terminal at a time, so that large copies do not stall
+	// slow connections with a single oversized write
+	OSC52ChunkSize = 4096
+
+	// OSC52MaxSize is the largest OSC 52 clipboard sequence sent to the
+	// terminal, larger copies are not sent as most terminals discard them
+	OSC52MaxSize = 100000
+
+	// OSC52QuerySequence asks the terminal to report the clipboard content
+	OSC52QuerySequence = "\x1b]52;c;?\x07"
+
+	// OSC52PrimaryQuerySequence asks the terminal to report the content of
+	// the primary selection
+	OSC52PrimaryQuerySequence = "\x1b]52;p;?\x07"
+)
+
+const osc52Prefix = "\x1b]52;"
+
+// encodeOSC52 returns the OSC 52 sequence setting the clipboard to the text
+func encodeOSC52(text string) (sequence string, err error) {
+	return encodeOSC52Selection("c", text)
+}
+
+// encodeOSC52Selection returns the OSC 52 sequence setting the selection,
+// "c" for the clipboard or "p" for the primary selection, to the text
+func encodeOSC52Selection(selection, text string) (sequence string, err error) {
+	sequence = osc52Prefix + selection + ";" + base64.StdEncoding.EncodeToString([]byte(text)) + "\x07"
+	if len(sequence) > OSC52MaxSize {
+		return "", fmt.Errorf("clipboard content too large for OSC 52: %d > %d bytes", len(sequence), OSC52MaxSize)
+	}
+	return
+}
+
+// chunkOSC52 splits the sequence into OSC52ChunkSize pieces
+func chunkOSC52(sequence string) (chunks []string) {
+	size := OSC52ChunkSize
+	if size <= 0 {
+		return []string{sequence}
+	}
+	for len(sequence) > size {
+		chunks = append(chunks, sequence[:size])
+		sequence = sequence[size:]
+	}
+	return append(chunks, sequence)
+}
+
+// parseOSC52Sequence parses an OSC 52 clipboard report, terminated by either
+// BEL or ST, at the start of the buffer. Returns the number of bytes consumed
+// and partial true if the buffer holds an incomplete report. Queries and
+// undecodable reports are consumed without an event.
+func parseOSC52Sequence(b []byte) (evt *EventClipboard, n int, partial bool) {
+	if len(b) < len(osc52Prefix) {
+		return nil, 0, bytes.HasPrefix([]byte(osc52Prefix), b)
+	}
+	if !bytes.HasPrefix(b, []byte(osc52Prefix)) {
+		return nil, 0, false
+	}
+	body := b[len(osc52Prefix):]
+	end, size := bytes.IndexByte(body, '\x07'), 1
+	if st := bytes.Index(body, []byte("\x1b\\")); st > -1 && (end < 0 || st < end) {
+		end, size = st, 2
+	}
+	if end < 0 {
+		return nil, 0, true
+	}
+	n = len(osc52Prefix) + end + size
+	params := bytes.SplitN(body[:end], []byte(";"), 2)
+	if len(params) != 2 || string(params[1]) == "?" {
+		return nil, n, false
+	}
+	text, err := base64.StdEncoding.DecodeString(string(params[1]))
+	if err != nil {
+		return nil, n, false
+	}
+	return NewEventClipboard(string(params[0]), string(text)), n, false
+}
@@ -0,0 +1,100 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTermClipboard(t *testing.T) {
+	Convey("OSC 52 clipboard sequences", t, func() {
+		sequence, err := encodeOSC52("hello")
+		So(err, ShouldBeNil)
+		So(sequence, ShouldEqual, "\x1b]52;c;aGVsbG8=\x07")
+		sequence, err = encodeOSC52Selection("p", "hello")
+		So(err, ShouldBeNil)
+		So(sequence, ShouldEqual, "\x1b]52;p;aGVsbG8=\x07")
+		_, err = encodeOSC52(strings.Repeat("x", OSC52MaxSize))
+		So(err, ShouldNotBeNil)
+
+		chunks := chunkOSC52(strings.Repeat("y", OSC52ChunkSize*2+1))
+		So(chunks, ShouldHaveLength, 3)
+		So(chunks[2], ShouldEqual, "y")
+		So(chunkOSC52("short"), ShouldResemble, []string{"short"})
+
+		evt, n, partial := parseOSC52Sequence([]byte("\x1b]52;c;aGVsbG8=\x07rest"))
+		So(evt, ShouldNotBeNil)
+		So(evt.Selection(), ShouldEqual, "c")
+		So(evt.Text(), ShouldEqual, "hello")
+		So(n, ShouldEqual, 16)
+		So(partial, ShouldBeFalse)
+		evt, n, _ = parseOSC52Sequence([]byte("\x1b]52;p;aGVsbG8=\x1b\\"))
+		So(evt.Selection(), ShouldEqual, "p")
+		So(n, ShouldEqual, 17)
+		_, n, partial = parseOSC52Sequence([]byte("\x1b]5"))
+		So(n, ShouldEqual, 0)
+		So(partial, ShouldBeTrue)
+		_, n, partial = parseOSC52Sequence([]byte("\x1b]52;c;aGVs"))
+		So(n, ShouldEqual, 0)
+		So(partial, ShouldBeTrue)
+		_, n, partial = parseOSC52Sequence([]byte("\x1b[A"))
+		So(n, ShouldEqual, 0)
+		So(partial, ShouldBeFalse)
+		evt, n, _ = parseOSC52Sequence([]byte(OSC52QuerySequence))
+		So(evt, ShouldBeNil)
+		So(n, ShouldEqual, len(OSC52QuerySequence))
+		evt, n, _ = parseOSC52Sequence([]byte("\x1b]52;c;!!!\x07"))
+		So(evt, ShouldBeNil)
+		So(n, ShouldEqual, 11)
+	})
+	Convey("Screen clipboard reports", t, func() {
+		screen := &CScreen{useTermClipboard: true}
+		So(screen.TermClipboardReadable(), ShouldBeFalse)
+		var evs []Event
+		buf := bytes.NewBufferString("\x1b]52;c;aGVsbG8=\x07x")
+		part, comp := screen.parseClipboard(buf, &evs)
+		So(part, ShouldBeTrue)
+		So(comp, ShouldBeTrue)
+		So(evs, ShouldHaveLength, 1)
+		So(evs[0].(*EventClipboard).Text(), ShouldEqual, "hello")
+		So(buf.String(), ShouldEqual, "x")
+		So(screen.TermClipboardReadable(), ShouldBeTrue)
+	})
+	Convey("Host primary selection commands", t, func() {
+		commands := hostPrimaryCommands(true)
+		So(commands, ShouldHaveLength, 3)
+		So(commands[0].copy, ShouldResemble, []string{"wl-copy", "--primary"})
+		So(hostPrimaryCommands(false)[0].paste, ShouldResemble, []string{"xclip", "-out", "-selection", "primary"})
+		installed := map[string]bool{"xsel": true, "wl-copy": true}
+		lookPath := func(file string) (string, error) {
+			if installed[file] {
+				return "/usr/bin/" + file, nil
+			}
+			return "", errors.New("not found")
+		}
+		command, err := findHostPrimaryCommand(commands, lookPath)
+		So(err, ShouldBeNil)
+		So(command.copy[0], ShouldEqual, "xsel")
+		So(command.paste, ShouldResemble, []string{"xsel", "--output", "--primary"})
+		installed["xsel"] = false
+		_, err = findHostPrimaryCommand(commands, lookPath)
+		So(err, ShouldEqual, ErrNoHostPrimary)
+	})
+}
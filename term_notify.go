@@ -0,0 +1,99 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotifyUnsupported is returned by Screen.Notify when the terminal is not
+// known to support desktop notifications
+var ErrNotifyUnsupported = errors.New("terminal does not support notifications")
+
+// NotifyProtocol is the escape sequence used to send desktop notifications
+type NotifyProtocol uint8
+
+const (
+	// NotifyNone is a terminal without desktop notifications
+	NotifyNone NotifyProtocol = iota
+	// NotifyOSC9 is the iTerm2 notification, which has a body only
+	NotifyOSC9
+	// NotifyOSC777 is the rxvt notification, which has a title and body
+	NotifyOSC777
+)
+
+func (p NotifyProtocol) String() string {
+	switch p {
+	case NotifyOSC9:
+		return "osc9"
+	case NotifyOSC777:
+		return "osc777"
+	}
+	return "none"
+}
+
+// ParseNotifyProtocol returns the NotifyProtocol with the given name, as
+// returned by NotifyProtocol.String
+func ParseNotifyProtocol(name string) (protocol NotifyProtocol, err error) {
+	switch strings.ToLower(name) {
+	case "none", "":
+		return NotifyNone, nil
+	case "osc9":
+		return NotifyOSC9, nil
+	case "osc777":
+		return NotifyOSC777, nil
+	}
+	return NotifyNone, fmt.Errorf("unknown notify protocol: %q", name)
+}
+
+// detectNotifyProtocol returns the protocol of the detected terminal, which
+// the GO_CDK_NOTIFY environment variable overrides
+func detectNotifyProtocol(getenv func(key string) string, quirks TerminalQuirk) NotifyProtocol {
+	if value := getenv("GO_CDK_NOTIFY"); value != "" {
+		if protocol, err := ParseNotifyProtocol(value); err == nil {
+			return protocol
+		}
+	}
+	return quirks.Notify
+}
+
+// encodeNotify returns the notification sequence of the protocol, removing
+// the control characters and field separators which would corrupt it
+func encodeNotify(protocol NotifyProtocol, title, body string) string {
+	title, body = sanitizeOSC(title), sanitizeOSC(body)
+	switch protocol {
+	case NotifyOSC9:
+		if title != "" {
+			body = title + ": " + body
+		}
+		return "\x1b]9;" + body + "\x07"
+	case NotifyOSC777:
+		return "\x1b]777;notify;" + strings.ReplaceAll(title, ";", ":") + ";" + body + "\x1b\\"
+	}
+	return ""
+}
+
+// sanitizeOSC removes the control characters which would terminate or
+// corrupt an OSC sequence
+func sanitizeOSC(text string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || (r >= 0x80 && r < 0xa0) {
+			return -1
+		}
+		return r
+	}, text)
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+)
+
+func TestTerminalNotify(t *testing.T) {
+	Convey("Notify sequences", t, func() {
+		So(encodeNotify(NotifyNone, "title", "body"), ShouldEqual, "")
+		So(encodeNotify(NotifyOSC9, "title", "body"), ShouldEqual, "\x1b]9;title: body\x07")
+		So(encodeNotify(NotifyOSC9, "", "body"), ShouldEqual, "\x1b]9;body\x07")
+		So(encodeNotify(NotifyOSC777, "a;b\x1b", "c;d\x07"), ShouldEqual, "\x1b]777;notify;a:b;c;d\x1b\\")
+		for _, p := range []NotifyProtocol{NotifyNone, NotifyOSC9, NotifyOSC777} {
+			parsed, err := ParseNotifyProtocol(p.String())
+			So(err, ShouldBeNil)
+			So(parsed, ShouldEqual, p)
+		}
+		_, err := ParseNotifyProtocol("growl")
+		So(err, ShouldNotBeNil)
+	})
+	Convey("Notify detection", t, func() {
+		env := map[string]string{}
+		getenv := func(key string) string { return env[key] }
+		quirks, _ := DetectTerminalQuirks(getenv)
+		So(detectNotifyProtocol(getenv, quirks), ShouldEqual, NotifyNone)
+		env["TERM"] = "foot-extra"
+		quirks, _ = DetectTerminalQuirks(getenv)
+		So(quirks.Name, ShouldEqual, "foot")
+		So(detectNotifyProtocol(getenv, quirks), ShouldEqual, NotifyOSC777)
+		env["TERM_PROGRAM"] = "iTerm.app"
+		quirks, _ = DetectTerminalQuirks(getenv)
+		So(detectNotifyProtocol(getenv, quirks), ShouldEqual, NotifyOSC9)
+		env["GO_CDK_NOTIFY"] = "none"
+		So(detectNotifyProtocol(getenv, quirks), ShouldEqual, NotifyNone)
+		env["GO_CDK_NOTIFY"] = "invalid"
+		So(detectNotifyProtocol(getenv, quirks), ShouldEqual, NotifyOSC9)
+	})
+	Convey("Screen notifications", t, func() {
+		env := map[string]string{"TERM": "xterm", "TMUX": "/tmp/tmux"}
+		lookup := func(key string) (value string, ok bool) {
+			value, ok = env[key]
+			return
+		}
+		s, err := NewScreenWithEnv(lookup)
+		So(err, ShouldBeNil)
+		So(s.Notify("title", "body"), ShouldEqual, ErrNotifyUnsupported)
+		env["GO_CDK_NOTIFY"] = "osc9"
+		s, err = NewScreenWithEnv(lookup)
+		So(err, ShouldBeNil)
+		cs := s.(*CScreen)
+		cs.buffering = true
+		So(s.Notify("title", "body"), ShouldBeNil)
+		So(cs.buf.String(), ShouldEqual, "\x1bPtmux;\x1b\x1b]9;title: body\x07\x1b\\")
+	})
+	Convey("Display notifications", t, WithDisplayManager(func(d Display) {
+		So(d.Notify("title", "body"), ShouldBeNil)
+		screen := d.Screen().(*COffScreen)
+		So(screen.notices, ShouldResemble, []string{"\x1b]777;notify;title;body\x1b\\"})
+		var body string
+		d.Connect(SignalNotify, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			body = argv[2].(string)
+			return enums.EVENT_STOP
+		})
+		So(d.Notify("title", "handled"), ShouldBeNil)
+		So(body, ShouldEqual, "handled")
+		So(screen.notices, ShouldHaveLength, 1)
+		_ = d.Disconnect(SignalNotify, "testing")
+		d.ReleaseDisplay()
+		So(d.Notify("title", "released"), ShouldNotBeNil)
+	}))
+}
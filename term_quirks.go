@@ -0,0 +1,165 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Multiplexer is a terminal multiplexer the application is running inside of
+type Multiplexer uint8
+
+const (
+	// MultiplexerNone is a terminal without a multiplexer
+	MultiplexerNone Multiplexer = iota
+	// MultiplexerTmux is tmux, detected by $TMUX or a $TERM of tmux*
+	MultiplexerTmux
+	// MultiplexerScreen is GNU screen, detected by $STY or a $TERM of screen*
+	MultiplexerScreen
+)
+
+func (m Multiplexer) String() string {
+	switch m {
+	case MultiplexerTmux:
+		return "tmux"
+	case MultiplexerScreen:
+		return "screen"
+	}
+	return "none"
+}
+
+// ScreenPassthroughChunk is the largest sequence GNU screen passes through in
+// a single DCS string, longer sequences are split across several
+var ScreenPassthroughChunk = 768
+
+// DetectMultiplexer returns the multiplexer described by the environment
+func DetectMultiplexer(getenv func(key string) string) Multiplexer {
+	termName := getenv("TERM")
+	switch {
+	case getenv("TMUX") != "", strings.HasPrefix(termName, "tmux"):
+		return MultiplexerTmux
+	case getenv("STY") != "", strings.HasPrefix(termName, "screen"):
+		return MultiplexerScreen
+	}
+	return MultiplexerNone
+}
+
+// Passthrough wraps a control sequence meant for the outer terminal in the
+// DCS string the multiplexer forwards unmodified. Sequences which the
+// multiplexer understands itself, such as SGR, must not be wrapped as the
+// multiplexer would not know of their effect when redrawing. Tmux only
+// forwards passthrough sequences with the allow-passthrough option set.
+func (m Multiplexer) Passthrough(sequence string) string {
+	switch m {
+	case MultiplexerTmux:
+		// every ESC within the string is doubled
+		return "\x1bPtmux;" + strings.ReplaceAll(sequence, "\x1b", "\x1b\x1b") + "\x1b\\"
+	case MultiplexerScreen:
+		size := ScreenPassthroughChunk
+		if size <= 0 {
+			size = len(sequence)
+		}
+		var b strings.Builder
+		for len(sequence) > 0 {
+			n := size
+			if n > len(sequence) {
+				n = len(sequence)
+			}
+			b.WriteString("\x1bP" + sequence[:n] + "\x1b\\")
+			sequence = sequence[n:]
+		}
+		return b.String()
+	}
+	return sequence
+}
+
+// TerminalQuirk describes a terminal emulator which does not match what its
+// terminfo entry, usually xterm-256color, says it can do
+type TerminalQuirk struct {
+	// Name of the terminal emulator
+	Name string
+	// Detect returns true if the environment belongs to the terminal
+	Detect func(getenv func(key string) string) bool
+	// TrueColor is true if 24-bit colors are supported without $COLORTERM
+	TrueColor bool
+	// NoTrueColor is true if 24-bit colors are not supported, even when
+	// $COLORTERM says so
+	NoTrueColor bool
+	// NoOSC52 is true if the OSC 52 clipboard sequence is not supported
+	NoOSC52 bool
+	// Hyperlinks is true if OSC 8 hyperlinks are supported, there is no
+	// query for this so it is known only for these terminals
+	Hyperlinks bool
+	// Notify is the desktop notification sequence supported, if any
+	Notify NotifyProtocol
+}
+
+// TerminalQuirks are the known terminal emulators, checked in order. These
+// environment variables are inherited by multiplexers so the outer terminal
+// is usually still detected inside of one.
+var TerminalQuirks = []TerminalQuirk{
+	{Name: "kitty", Detect: envSet("KITTY_WINDOW_ID"), TrueColor: true, Hyperlinks: true, Notify: NotifyOSC9},
+	{Name: "wezterm", Detect: envEquals("TERM_PROGRAM", "WezTerm"), TrueColor: true, Hyperlinks: true, Notify: NotifyOSC777},
+	{Name: "iterm2", Detect: envEquals("TERM_PROGRAM", "iTerm.app"), TrueColor: true, Hyperlinks: true, Notify: NotifyOSC9},
+	{Name: "ghostty", Detect: envEquals("TERM_PROGRAM", "ghostty"), TrueColor: true, Hyperlinks: true, Notify: NotifyOSC777},
+	{Name: "foot", Detect: termPrefix("foot"), TrueColor: true, Hyperlinks: true, Notify: NotifyOSC777},
+	{Name: "rxvt-unicode", Detect: termPrefix("rxvt-unicode"), Notify: NotifyOSC777},
+	{Name: "alacritty", Detect: envSet("ALACRITTY_WINDOW_ID"), TrueColor: true, Hyperlinks: true},
+	{Name: "windows-terminal", Detect: envSet("WT_SESSION"), TrueColor: true, Hyperlinks: true},
+	{Name: "vscode", Detect: envEquals("TERM_PROGRAM", "vscode"), TrueColor: true, Hyperlinks: true},
+	{Name: "konsole", Detect: envSet("KONSOLE_VERSION"), TrueColor: true, NoOSC52: true, Hyperlinks: true},
+	{Name: "apple-terminal", Detect: envEquals("TERM_PROGRAM", "Apple_Terminal"), NoTrueColor: true, NoOSC52: true},
+	{Name: "vte", Detect: vteVersion(3600), TrueColor: true, NoOSC52: true, Hyperlinks: true},
+	{Name: "linux-console", Detect: envEquals("TERM", "linux"), NoTrueColor: true, NoOSC52: true},
+}
+
+// DetectTerminalQuirks returns the first of the TerminalQuirks matching the
+// environment, ok is false for unknown terminals
+func DetectTerminalQuirks(getenv func(key string) string) (quirk TerminalQuirk, ok bool) {
+	for _, quirk = range TerminalQuirks {
+		if quirk.Detect != nil && quirk.Detect(getenv) {
+			return quirk, true
+		}
+	}
+	return TerminalQuirk{}, false
+}
+
+func envSet(key string) func(getenv func(key string) string) bool {
+	return func(getenv func(key string) string) bool {
+		return getenv(key) != ""
+	}
+}
+
+func envEquals(key, value string) func(getenv func(key string) string) bool {
+	return func(getenv func(key string) string) bool {
+		return getenv(key) == value
+	}
+}
+
+func termPrefix(prefix string) func(getenv func(key string) string) bool {
+	return func(getenv func(key string) string) bool {
+		return strings.HasPrefix(getenv("TERM"), prefix)
+	}
+}
+
+// vteVersion detects VTE based terminals, such as gnome-terminal, from the
+// given version onwards
+func vteVersion(minimum int) func(getenv func(key string) string) bool {
+	return func(getenv func(key string) string) bool {
+		version, err := strconv.Atoi(getenv("VTE_VERSION"))
+		return err == nil && version >= minimum
+	}
+}
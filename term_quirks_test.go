@@ -0,0 +1,108 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strings"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestTermQuirks(t *testing.T) {
+	Convey("Multiplexers", t, func() {
+		env := map[string]string{}
+		getenv := func(key string) string { return env[key] }
+		So(DetectMultiplexer(getenv), ShouldEqual, MultiplexerNone)
+		env["TERM"] = "screen-256color"
+		So(DetectMultiplexer(getenv), ShouldEqual, MultiplexerScreen)
+		env["TMUX"] = "/tmp/tmux-0/default,1,0"
+		So(DetectMultiplexer(getenv), ShouldEqual, MultiplexerTmux)
+		delete(env, "TMUX")
+		env["TERM"] = "tmux-256color"
+		So(DetectMultiplexer(getenv), ShouldEqual, MultiplexerTmux)
+		env["TERM"] = "xterm"
+		env["STY"] = "1234.pts-0.host"
+		So(DetectMultiplexer(getenv), ShouldEqual, MultiplexerScreen)
+		So(MultiplexerTmux.String(), ShouldEqual, "tmux")
+
+		sequence := "\x1b]52;c;aGVsbG8=\a"
+		So(MultiplexerNone.Passthrough(sequence), ShouldEqual, sequence)
+		So(
+			MultiplexerTmux.Passthrough(sequence),
+			ShouldEqual,
+			"\x1bPtmux;\x1b\x1b]52;c;aGVsbG8=\a\x1b\\",
+		)
+		So(MultiplexerScreen.Passthrough(sequence), ShouldEqual, "\x1bP"+sequence+"\x1b\\")
+		long := strings.Repeat("x", ScreenPassthroughChunk+1)
+		So(
+			MultiplexerScreen.Passthrough(long),
+			ShouldEqual,
+			"\x1bP"+long[:ScreenPassthroughChunk]+"\x1b\\\x1bPx\x1b\\",
+		)
+	})
+
+	Convey("Terminal quirks", t, func() {
+		env := map[string]string{"TERM": "xterm-256color"}
+		getenv := func(key string) string { return env[key] }
+		_, ok := DetectTerminalQuirks(getenv)
+		So(ok, ShouldBeFalse)
+		env["VTE_VERSION"] = "3405"
+		_, ok = DetectTerminalQuirks(getenv)
+		So(ok, ShouldBeFalse)
+		env["VTE_VERSION"] = "6800"
+		quirk, ok := DetectTerminalQuirks(getenv)
+		So(ok, ShouldBeTrue)
+		So(quirk.Name, ShouldEqual, "vte")
+		So(quirk.NoOSC52, ShouldBeTrue)
+		env["KITTY_WINDOW_ID"] = "1"
+		quirk, _ = DetectTerminalQuirks(getenv)
+		So(quirk.Name, ShouldEqual, "kitty")
+		So(quirk.TrueColor, ShouldBeTrue)
+	})
+
+	Convey("Screen direct color", t, func() {
+		env := map[string]string{"TERM": "xterm-256color"}
+		lookup := func(key string) (value string, ok bool) {
+			value, ok = env[key]
+			return
+		}
+		newScreen := func() *CScreen {
+			s, err := NewScreenWithEnv(lookup)
+			So(err, ShouldBeNil)
+			return s.(*CScreen)
+		}
+		So(newScreen().ti.SetFgRGB, ShouldEqual, "")
+		// known terminals need no $COLORTERM
+		env["KITTY_WINDOW_ID"] = "1"
+		cs := newScreen()
+		So(cs.ti.SetFgRGB, ShouldNotEqual, "")
+		So(cs.quirks.Name, ShouldEqual, "kitty")
+		// GNU screen does not forward direct color
+		env["TERM"] = "screen-256color"
+		env["COLORTERM"] = "truecolor"
+		cs = newScreen()
+		So(cs.multiplexer, ShouldEqual, MultiplexerScreen)
+		So(cs.ti.SetFgRGB, ShouldEqual, "")
+		So(cs.ti.SetFgBgRGB, ShouldEqual, "")
+		// nor does Terminal.app support it
+		delete(env, "KITTY_WINDOW_ID")
+		env["TERM"] = "xterm-256color"
+		env["TERM_PROGRAM"] = "Apple_Terminal"
+		cs = newScreen()
+		So(cs.ti.SetFgRGB, ShouldEqual, "")
+		So(cs.quirks.NoOSC52, ShouldBeTrue)
+	})
+}
@@ -0,0 +1,227 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termemu
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/go-curses/cdk"
+)
+
+// cursorKeys are the final bytes of the cursor keys, sent as CSI or, in
+// application mode, SS3 sequences
+var cursorKeys = map[cdk.Key]byte{
+	cdk.KeyUp:    'A',
+	cdk.KeyDown:  'B',
+	cdk.KeyRight: 'C',
+	cdk.KeyLeft:  'D',
+	cdk.KeyHome:  'H',
+	cdk.KeyEnd:   'F',
+}
+
+// functionKeys are the finals of F1 to F4, sent as SS3 sequences
+var functionKeys = map[cdk.Key]byte{
+	cdk.KeyF1: 'P',
+	cdk.KeyF2: 'Q',
+	cdk.KeyF3: 'R',
+	cdk.KeyF4: 'S',
+}
+
+// tildeKeys are the parameters of the keys sent as CSI n ~ sequences
+var tildeKeys = map[cdk.Key]int{
+	cdk.KeyInsert: 2,
+	cdk.KeyDelete: 3,
+	cdk.KeyPgUp:   5,
+	cdk.KeyPgDn:   6,
+	cdk.KeyF5:     15,
+	cdk.KeyF6:     17,
+	cdk.KeyF7:     18,
+	cdk.KeyF8:     19,
+	cdk.KeyF9:     20,
+	cdk.KeyF10:    21,
+	cdk.KeyF11:    23,
+	cdk.KeyF12:    24,
+}
+
+// modifierParam returns the xterm modifier parameter, 1 for none
+func modifierParam(mod cdk.ModMask) (param int) {
+	param = 1
+	if mod.Has(cdk.ModShift) {
+		param += 1
+	}
+	if mod.Has(cdk.ModAlt) || mod.Has(cdk.ModMeta) {
+		param += 2
+	}
+	if mod.Has(cdk.ModCtrl) {
+		param += 4
+	}
+	return
+}
+
+// EncodeKey returns the bytes xterm sends for the key press, nil for key
+// releases and keys which have no encoding
+func EncodeKey(evt *cdk.EventKey, appCursorKeys bool) []byte {
+	if evt.Phase() == cdk.KeyRelease {
+		return nil
+	}
+	key, mod := evt.Key(), evt.Modifiers()
+	param := modifierParam(mod)
+	alt := mod.Has(cdk.ModAlt) || mod.Has(cdk.ModMeta)
+	prefix := func(b []byte) []byte {
+		if alt {
+			return append([]byte{0x1b}, b...)
+		}
+		return b
+	}
+	if final, ok := cursorKeys[key]; ok {
+		switch {
+		case param > 1:
+			return []byte(fmt.Sprintf("\x1b[1;%d%c", param, final))
+		case appCursorKeys:
+			return []byte{0x1b, 'O', final}
+		}
+		return []byte{0x1b, '[', final}
+	}
+	if final, ok := functionKeys[key]; ok {
+		if param > 1 {
+			return []byte(fmt.Sprintf("\x1b[1;%d%c", param, final))
+		}
+		return []byte{0x1b, 'O', final}
+	}
+	if code, ok := tildeKeys[key]; ok {
+		if param > 1 {
+			return []byte(fmt.Sprintf("\x1b[%d;%d~", code, param))
+		}
+		return []byte(fmt.Sprintf("\x1b[%d~", code))
+	}
+	switch {
+	case key == cdk.KeyBacktab:
+		return []byte("\x1b[Z")
+	case key == cdk.KeyBackspace, key == cdk.KeyBackspace2:
+		return prefix([]byte{0x7f})
+	case key == cdk.KeyRune:
+		r := evt.Rune()
+		if r < ' ' || r == 0x7f {
+			return prefix([]byte{byte(r)})
+		}
+		return prefix(utf8.AppendRune(nil, r))
+	case key >= cdk.KeySmallA && key <= cdk.KeySmallZ && mod.Has(cdk.ModCtrl):
+		return prefix([]byte{byte(key-cdk.KeySmallA) + 1})
+	case key < ' ' || key == cdk.KeyDEL:
+		return prefix([]byte{byte(key)})
+	case key < cdk.KeyRune:
+		if mod.Has(cdk.ModCtrl) {
+			switch key {
+			case cdk.KeySpace, cdk.KeyAtSign:
+				return prefix([]byte{0})
+			case cdk.KeyLeftSquareBracket, cdk.KeyBackslash, cdk.KeyRightSquareBracket, cdk.KeyCaretCircumflex, cdk.KeyUnderscore:
+				return prefix([]byte{byte(key) - '@'})
+			}
+		}
+		return prefix([]byte{byte(key)})
+	}
+	return nil
+}
+
+const (
+	// PasteStart and PasteEnd surround pasted text when the program enables
+	// bracketed paste
+	PasteStart = "\x1b[200~"
+	PasteEnd   = "\x1b[201~"
+)
+
+// mouseButtonCode returns the xterm button number of the button mask
+func mouseButtonCode(buttons cdk.ButtonMask) (code int, ok bool) {
+	switch {
+	case buttons&cdk.Button1 != 0:
+		return 0, true
+	case buttons&cdk.Button3 != 0:
+		return 1, true
+	case buttons&cdk.Button2 != 0:
+		return 2, true
+	case buttons&cdk.WheelUp != 0:
+		return 64, true
+	case buttons&cdk.WheelDown != 0:
+		return 65, true
+	case buttons&cdk.WheelLeft != 0:
+		return 66, true
+	case buttons&cdk.WheelRight != 0:
+		return 67, true
+	}
+	return 0, false
+}
+
+// mouseEncoder tracks the buttons held to report their releases, which cdk
+// reports without the button
+type mouseEncoder struct {
+	held int
+	down bool
+}
+
+// encode returns the mouse report for the event at the given cell of the
+// terminal, nil if the mode does not report it
+func (m *mouseEncoder) encode(evt *cdk.EventMouse, x, y int, mode MouseMode, sgr bool) []byte {
+	if mode == MouseNone {
+		return nil
+	}
+	code, pressed := mouseButtonCode(evt.Buttons())
+	release := false
+	switch {
+	case pressed && code >= 64:
+		// wheel events have no release
+	case pressed && !m.down:
+		m.held, m.down = code, true
+	case pressed:
+		// motion with the button held
+		if mode < MouseDrag {
+			return nil
+		}
+		code = m.held + 32
+	case m.down:
+		code, release = m.held, true
+		m.down = false
+	default:
+		if mode < MouseMotion {
+			return nil
+		}
+		code = 3 + 32
+	}
+	mod := evt.Modifiers()
+	if mod.Has(cdk.ModShift) {
+		code += 4
+	}
+	if mod.Has(cdk.ModAlt) || mod.Has(cdk.ModMeta) {
+		code += 8
+	}
+	if mod.Has(cdk.ModCtrl) {
+		code += 16
+	}
+	if sgr {
+		final := 'M'
+		if release {
+			final = 'm'
+		}
+		return []byte(fmt.Sprintf("\x1b[<%d;%d;%d%c", code, x+1, y+1, final))
+	}
+	if release {
+		code = 3 | code&^3
+	}
+	if x > 222 || y > 222 {
+		// beyond the range of the legacy encoding
+		return nil
+	}
+	return []byte{0x1b, '[', 'M', byte(32 + code), byte(33 + x), byte(33 + y)}
+}
@@ -0,0 +1,356 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termemu
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// csi performs the control sequence with the given final byte
+func (t *CTerminal) csi(final byte) {
+	raw := string(t.params)
+	private := byte(0)
+	if raw != "" && strings.IndexByte("?<=>", raw[0]) >= 0 {
+		private, raw = raw[0], raw[1:]
+	}
+	if len(t.inter) > 0 {
+		// none of the sequences with intermediates are supported
+		return
+	}
+	values := parseParams(raw)
+	n := param(values, 0, 1)
+	switch private {
+	case 0:
+	case '?':
+		switch final {
+		case 'h':
+			t.setModes(values, true)
+		case 'l':
+			t.setModes(values, false)
+		}
+		return
+	case '>':
+		if final == 'c' {
+			// secondary device attributes: a VT220 of no particular version
+			t.respond("\x1b[>1;10;0c")
+		}
+		return
+	default:
+		return
+	}
+	switch final {
+	case '@':
+		row := t.rows[t.y]
+		n = min(n, t.w-t.x)
+		copy(row[t.x+n:], row[t.x:t.w-n])
+		blank := t.blank()
+		for x := t.x; x < t.x+n; x++ {
+			row[x] = blank
+		}
+		t.fixWide(t.y)
+	case 'A':
+		t.moveTo(t.x, max(t.y-n, min(t.y, t.top)))
+	case 'B', 'e':
+		t.moveTo(t.x, min(t.y+n, max(t.y, t.bottom)))
+	case 'C', 'a':
+		t.moveTo(t.x+n, t.y)
+	case 'D':
+		t.moveTo(t.x-n, t.y)
+	case 'E':
+		t.moveTo(0, min(t.y+n, max(t.y, t.bottom)))
+	case 'F':
+		t.moveTo(0, max(t.y-n, min(t.y, t.top)))
+	case 'G', '`':
+		t.moveTo(n-1, t.y)
+	case 'H', 'f':
+		t.moveTo(param(values, 1, 1)-1, n-1)
+	case 'I':
+		for i := 0; i < n && t.x < t.w-1; i++ {
+			t.tab()
+		}
+		t.pendingWrap = false
+	case 'J':
+		switch param(values, 0, 0) {
+		case 0:
+			t.eraseCells(t.y, t.x, t.w)
+			for y := t.y + 1; y < t.h; y++ {
+				t.eraseCells(y, 0, t.w)
+			}
+		case 1:
+			for y := 0; y < t.y; y++ {
+				t.eraseCells(y, 0, t.w)
+			}
+			t.eraseCells(t.y, 0, t.x+1)
+		case 2, 3:
+			for y := 0; y < t.h; y++ {
+				t.eraseCells(y, 0, t.w)
+			}
+		}
+	case 'K':
+		switch param(values, 0, 0) {
+		case 0:
+			t.eraseCells(t.y, t.x, t.w)
+		case 1:
+			t.eraseCells(t.y, 0, t.x+1)
+		case 2:
+			t.eraseCells(t.y, 0, t.w)
+		}
+	case 'L':
+		if t.y >= t.top && t.y <= t.bottom {
+			t.insertRows(t.y, n)
+			t.x, t.pendingWrap = 0, false
+		}
+	case 'M':
+		if t.y >= t.top && t.y <= t.bottom {
+			t.deleteRows(t.y, n)
+			t.x, t.pendingWrap = 0, false
+		}
+	case 'P':
+		row := t.rows[t.y]
+		n = min(n, t.w-t.x)
+		copy(row[t.x:], row[t.x+n:])
+		blank := t.blank()
+		for x := t.w - n; x < t.w; x++ {
+			row[x] = blank
+		}
+		t.fixWide(t.y)
+	case 'S':
+		t.scrollUp(n)
+	case 'T':
+		t.scrollDown(n)
+	case 'X':
+		t.eraseCells(t.y, t.x, t.x+n)
+	case 'Z':
+		for i := 0; i < n && t.x > 0; i++ {
+			t.x--
+			for t.x > 0 && !t.tabs[t.x] {
+				t.x--
+			}
+		}
+		t.pendingWrap = false
+	case 'c':
+		// primary device attributes: a VT220 with ANSI colors
+		t.respond("\x1b[?62;22c")
+	case 'd':
+		t.moveTo(t.x, n-1)
+	case 'g':
+		switch param(values, 0, 0) {
+		case 0:
+			t.tabs[t.x] = false
+		case 3:
+			t.tabs = make([]bool, t.w)
+		}
+	case 'm':
+		t.sgr(raw)
+	case 'n':
+		switch param(values, 0, 0) {
+		case 5:
+			t.respond("\x1b[0n")
+		case 6:
+			t.respond("\x1b[%d;%dR", t.y+1, t.x+1)
+		}
+	case 'r':
+		top, bottom := param(values, 0, 1)-1, param(values, 1, t.h)-1
+		if bottom > t.h-1 {
+			bottom = t.h - 1
+		}
+		if top < bottom {
+			t.top, t.bottom = top, bottom
+			t.moveTo(0, 0)
+		}
+	case 's':
+		t.saveCursor()
+	case 'u':
+		t.restoreCursor()
+	}
+}
+
+// fixWide blanks any half of a wide character left behind by moving cells
+func (t *CTerminal) fixWide(y int) {
+	row := t.rows[y]
+	for x := range row {
+		switch {
+		case row[x].width == 0 && (x == 0 || row[x-1].width < 2):
+			row[x] = cell{ch: " ", width: 1, style: row[x].style}
+		case row[x].width > 1 && (x+1 == t.w || row[x+1].width != 0):
+			row[x] = cell{ch: " ", width: 1, style: row[x].style}
+		}
+	}
+}
+
+// setModes sets or resets the DEC private modes
+func (t *CTerminal) setModes(modes []int, set bool) {
+	for _, mode := range modes {
+		switch mode {
+		case 1:
+			t.appCursorKeys = set
+		case 7:
+			t.autoWrap = set
+			t.pendingWrap = false
+		case 25:
+			t.cursorVisible = set
+		case 47, 1047:
+			t.setAltScreen(set)
+		case 1048:
+			if set {
+				t.saveCursor()
+			} else {
+				t.restoreCursor()
+			}
+		case 1049:
+			if set {
+				t.saveCursor()
+				t.setAltScreen(true)
+			} else {
+				t.setAltScreen(false)
+				t.restoreCursor()
+			}
+		case 1000, 1002, 1003:
+			if set {
+				t.mouseMode = MouseMode(mode)
+			} else if t.mouseMode == MouseMode(mode) {
+				t.mouseMode = MouseNone
+			}
+		case 1006:
+			t.mouseSGR = set
+		case 2004:
+			t.bracketedPaste = set
+		}
+	}
+}
+
+// sgr applies the select graphic rendition parameters
+func (t *CTerminal) sgr(raw string) {
+	if raw == "" {
+		t.style = paint.StyleDefault
+		return
+	}
+	fields := strings.Split(raw, ";")
+	for i := 0; i < len(fields); i++ {
+		var sub []string
+		if strings.Contains(fields[i], ":") {
+			// ITU T.416 colors: 38:2::r:g:b or 38:5:n
+			sub = strings.Split(fields[i], ":")
+		}
+		code := 0
+		if sub != nil {
+			code, _ = strconv.Atoi(sub[0])
+		} else {
+			code, _ = strconv.Atoi(fields[i])
+		}
+		switch {
+		case code == 0:
+			t.style = paint.StyleDefault
+		case code == 1:
+			t.style = t.style.Bold(true)
+		case code == 2:
+			t.style = t.style.Dim(true)
+		case code == 3:
+			t.style = t.style.Italic(true)
+		case code == 4:
+			t.style = t.style.Underline(sub == nil || len(sub) < 2 || sub[1] != "0")
+		case code == 5 || code == 6:
+			t.style = t.style.Blink(true)
+		case code == 7:
+			t.style = t.style.Reverse(true)
+		case code == 9:
+			t.style = t.style.Strike(true)
+		case code == 21:
+			t.style = t.style.Underline(true)
+		case code == 22:
+			t.style = t.style.Bold(false).Dim(false)
+		case code == 23:
+			t.style = t.style.Italic(false)
+		case code == 24:
+			t.style = t.style.Underline(false)
+		case code == 25:
+			t.style = t.style.Blink(false)
+		case code == 27:
+			t.style = t.style.Reverse(false)
+		case code == 29:
+			t.style = t.style.Strike(false)
+		case code >= 30 && code <= 37:
+			t.style = t.style.Foreground(paint.PaletteColor(code - 30))
+		case code == 38 || code == 48:
+			var color paint.Color
+			var ok bool
+			if sub != nil {
+				color, ok = extendedColor(sub[1:], true)
+			} else {
+				var used int
+				color, used, ok = extendedColorFields(fields[i+1:])
+				i += used
+			}
+			if ok && code == 38 {
+				t.style = t.style.Foreground(color)
+			} else if ok {
+				t.style = t.style.Background(color)
+			}
+		case code == 39:
+			t.style = t.style.Foreground(paint.ColorDefault)
+		case code >= 40 && code <= 47:
+			t.style = t.style.Background(paint.PaletteColor(code - 40))
+		case code == 49:
+			t.style = t.style.Background(paint.ColorDefault)
+		case code >= 90 && code <= 97:
+			t.style = t.style.Foreground(paint.PaletteColor(code - 90 + 8))
+		case code >= 100 && code <= 107:
+			t.style = t.style.Background(paint.PaletteColor(code - 100 + 8))
+		}
+	}
+}
+
+// extendedColorFields parses the semicolon separated 5;n and 2;r;g;b color
+// parameters, returning the number of fields used
+func extendedColorFields(fields []string) (color paint.Color, used int, ok bool) {
+	if len(fields) == 0 {
+		return
+	}
+	switch fields[0] {
+	case "5":
+		used = min(2, len(fields))
+	case "2":
+		used = min(4, len(fields))
+	default:
+		return paint.ColorDefault, 1, false
+	}
+	color, ok = extendedColor(fields[:used], false)
+	return
+}
+
+// extendedColor parses the 5,n and 2,r,g,b color parameters, the colon form
+// of the latter optionally having a color space identifier before the values
+func extendedColor(values []string, colons bool) (color paint.Color, ok bool) {
+	var numbers []int
+	for _, value := range values {
+		v, _ := strconv.Atoi(value)
+		numbers = append(numbers, v)
+	}
+	switch {
+	case len(numbers) == 2 && numbers[0] == 5:
+		return paint.PaletteColor(max(0, min(numbers[1], 255))), true
+	case len(numbers) >= 4 && numbers[0] == 2:
+		rgb := numbers[1:]
+		if colons && len(rgb) > 3 {
+			rgb = rgb[len(rgb)-3:]
+		}
+		clamp := func(v int) int32 { return int32(max(0, min(v, 255))) }
+		return paint.NewRGBColor(clamp(rgb[0]), clamp(rgb[1]), clamp(rgb[2])), true
+	}
+	return paint.ColorDefault, false
+}
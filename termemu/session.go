@@ -0,0 +1,286 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termemu
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"sync"
+
+	"github.com/creack/pty"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+var (
+	// ErrNotStarted is returned when using a Session before Start
+	ErrNotStarted = errors.New("session not started")
+	// ErrStarted is returned when starting a Session more than once
+	ErrStarted = errors.New("session already started")
+)
+
+// ReadBufferSize is the size of the reads of the output of the command
+var ReadBufferSize = 4096
+
+// Session runs a command on a pseudo-terminal, interpreting its output with a
+// Terminal and sending it the input events of the application. A Session is
+// typically shown in a cdk.Pane, using Draw and ProcessPaneEvent as the draw
+// callback and event handler of the Pane, or drawn by a widget forwarding its
+// events to ProcessEvent.
+type Session interface {
+	cdk.Sensitive
+
+	// Start runs the command on a pseudo-terminal of the given size
+	Start(w, h int) (err error)
+	// Terminal returns the Terminal interpreting the output of the command
+	Terminal() Terminal
+	// Write sends input to the command
+	Write(p []byte) (n int, err error)
+	// Resize changes the size of the Terminal and the pseudo-terminal
+	Resize(w, h int) (err error)
+	// SetOrigin changes the position of the Terminal on the display, used to
+	// translate the coordinates of mouse events
+	SetOrigin(origin ptypes.Point2I)
+	// OnUpdate sets the function called after output of the command has
+	// been interpreted, typically requesting the display be drawn
+	OnUpdate(fn func())
+	// Draw renders the Terminal on the surface, resizing it to the surface
+	// first, and matches cdk.PaneDrawFn
+	Draw(pane *cdk.Pane, surface *memphis.CSurface)
+	// ProcessPaneEvent gives the event to the Session with the origin of the
+	// pane, and matches cdk.PaneEventFn
+	ProcessPaneEvent(pane *cdk.Pane, evt cdk.Event) enums.EventFlag
+	// Close ends the command and releases the pseudo-terminal
+	Close() (err error)
+	// Done is closed once the command has exited
+	Done() <-chan struct{}
+	// Err returns the error the command exited with, once Done
+	Err() (err error)
+}
+
+// CSession is a Session running an exec.Cmd
+type CSession struct {
+	cmd    *exec.Cmd
+	ptmx   *os.File
+	term   *CTerminal
+	origin ptypes.Point2I
+	update func()
+	mouse  mouseEncoder
+	done   chan struct{}
+	err    error
+
+	sync.Mutex
+}
+
+// NewSession returns a Session for the command, which is not started. The
+// environment of the command is given the TermName as $TERM.
+func NewSession(cmd *exec.Cmd) *CSession {
+	return &CSession{
+		cmd:  cmd,
+		done: make(chan struct{}),
+	}
+}
+
+func (s *CSession) Start(w, h int) (err error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.ptmx != nil {
+		return ErrStarted
+	}
+	w, h = max(w, 1), max(h, 1)
+	if s.cmd.Env == nil {
+		s.cmd.Env = os.Environ()
+	}
+	s.cmd.Env = append(s.cmd.Env, "TERM="+TermName)
+	if s.ptmx, err = pty.StartWithSize(s.cmd, &pty.Winsize{Cols: uint16(w), Rows: uint16(h)}); err != nil {
+		return
+	}
+	s.term = NewTerminal(w, h, s.ptmx)
+	go s.readLoop(s.ptmx, s.term)
+	return
+}
+
+func (s *CSession) readLoop(ptmx *os.File, term *CTerminal) {
+	buf := make([]byte, ReadBufferSize)
+	for {
+		n, err := ptmx.Read(buf)
+		if n > 0 {
+			_, _ = term.Write(buf[:n])
+			s.Lock()
+			update := s.update
+			s.Unlock()
+			if update != nil {
+				update()
+			}
+		}
+		if err != nil {
+			break
+		}
+	}
+	err := s.cmd.Wait()
+	s.Lock()
+	s.err = err
+	s.Unlock()
+	close(s.done)
+}
+
+func (s *CSession) Terminal() Terminal {
+	s.Lock()
+	defer s.Unlock()
+	if s.term == nil {
+		return nil
+	}
+	return s.term
+}
+
+func (s *CSession) Write(p []byte) (n int, err error) {
+	s.Lock()
+	ptmx := s.ptmx
+	s.Unlock()
+	if ptmx == nil {
+		return 0, ErrNotStarted
+	}
+	return ptmx.Write(p)
+}
+
+func (s *CSession) Resize(w, h int) (err error) {
+	s.Lock()
+	defer s.Unlock()
+	if s.ptmx == nil {
+		return ErrNotStarted
+	}
+	w, h = max(w, 1), max(h, 1)
+	if tw, th := s.term.Size(); tw == w && th == h {
+		return nil
+	}
+	s.term.Resize(w, h)
+	return pty.Setsize(s.ptmx, &pty.Winsize{Cols: uint16(w), Rows: uint16(h)})
+}
+
+func (s *CSession) SetOrigin(origin ptypes.Point2I) {
+	s.Lock()
+	defer s.Unlock()
+	s.origin = origin
+}
+
+func (s *CSession) OnUpdate(fn func()) {
+	s.Lock()
+	defer s.Unlock()
+	s.update = fn
+}
+
+func (s *CSession) Draw(pane *cdk.Pane, surface *memphis.CSurface) {
+	if pane != nil {
+		s.SetOrigin(pane.Region().Origin())
+	}
+	if err := s.Resize(surface.Width(), surface.Height()); err != nil && !errors.Is(err, ErrNotStarted) {
+		return
+	}
+	term, ok := s.Terminal().(*CTerminal)
+	if !ok {
+		return
+	}
+	term.Render(surface)
+	if x, y, visible := term.Cursor(); visible {
+		// the display has one cursor, the position of which panes cannot set
+		if cell := surface.GetContent(x, y); cell != nil {
+			_ = surface.SetRuneStyle(x, y, cell.Style().Reverse(true))
+		}
+	}
+}
+
+func (s *CSession) ProcessPaneEvent(pane *cdk.Pane, evt cdk.Event) enums.EventFlag {
+	if pane != nil {
+		s.SetOrigin(pane.Region().Origin())
+	}
+	return s.ProcessEvent(evt)
+}
+
+// ProcessEvent sends the key, paste, raw and mouse events to the command
+func (s *CSession) ProcessEvent(evt cdk.Event) enums.EventFlag {
+	term, ok := s.Terminal().(*CTerminal)
+	if !ok {
+		return enums.EVENT_PASS
+	}
+	var input []byte
+	switch e := evt.(type) {
+	case *cdk.EventKey:
+		input = EncodeKey(e, term.AppCursorKeys())
+	case *cdk.EventRaw:
+		input = e.Data()
+	case *cdk.EventPaste:
+		// the pasted text follows the start as key events
+		if term.BracketedPaste() {
+			if e.Start() {
+				input = []byte(PasteStart)
+			} else {
+				input = []byte(PasteEnd)
+			}
+		}
+	case *cdk.EventMouse:
+		mode, sgr := term.Mouse()
+		s.Lock()
+		x, y := e.Position()
+		x, y = x-s.origin.X, y-s.origin.Y
+		if w, h := term.Size(); x >= 0 && y >= 0 && x < w && y < h {
+			input = s.mouse.encode(e, x, y, mode, sgr)
+		}
+		s.Unlock()
+		if mode == MouseNone {
+			return enums.EVENT_PASS
+		}
+	default:
+		return enums.EVENT_PASS
+	}
+	if len(input) > 0 {
+		if _, err := s.Write(input); err != nil {
+			return enums.EVENT_PASS
+		}
+	}
+	return enums.EVENT_STOP
+}
+
+func (s *CSession) Close() (err error) {
+	s.Lock()
+	ptmx, cmd := s.ptmx, s.cmd
+	s.Unlock()
+	if ptmx == nil {
+		return ErrNotStarted
+	}
+	select {
+	case <-s.done:
+	default:
+		if cmd.Process != nil {
+			_ = cmd.Process.Kill()
+		}
+	}
+	err = ptmx.Close()
+	<-s.done
+	return
+}
+
+func (s *CSession) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *CSession) Err() (err error) {
+	s.Lock()
+	defer s.Unlock()
+	return s.err
+}
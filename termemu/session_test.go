@@ -0,0 +1,100 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris || zos
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris zos
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termemu
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+func waitForLine(term Terminal, line int, text string) bool {
+	for deadline := time.Now().Add(5 * time.Second); time.Now().Before(deadline); {
+		if lines := term.Lines(); line < len(lines) && strings.Contains(lines[line], text) {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+func TestSession(t *testing.T) {
+	Convey("Session", t, func() {
+		session := NewSession(exec.Command("/bin/sh", "-c", `printf "size %s term %s\n" "$(stty size)" "$TERM"; read line; echo "got $line"`))
+		So(session.Terminal(), ShouldBeNil)
+		_, err := session.Write([]byte("x"))
+		So(err, ShouldEqual, ErrNotStarted)
+		updates := make(chan struct{}, 100)
+		session.OnUpdate(func() {
+			select {
+			case updates <- struct{}{}:
+			default:
+			}
+		})
+		So(session.Start(40, 5), ShouldBeNil)
+		So(session.Start(40, 5), ShouldEqual, ErrStarted)
+		term := session.Terminal()
+		So(waitForLine(term, 0, "size 5 40 term "+TermName), ShouldBeTrue)
+		So(len(updates), ShouldBeGreaterThan, 0)
+
+		session.SetOrigin(ptypes.MakePoint2I(10, 10))
+		for _, r := range "hi" {
+			So(session.ProcessEvent(cdk.NewEventKey(cdk.KeyRune, r, cdk.ModNone)), ShouldEqual, enums.EVENT_STOP)
+		}
+		So(session.ProcessEvent(cdk.NewEventKey(cdk.KeyEnter, '\r', cdk.ModNone)), ShouldEqual, enums.EVENT_STOP)
+		// the program has not enabled mouse reporting
+		So(session.ProcessEvent(cdk.NewEventMouse(11, 11, cdk.Button1, cdk.ModNone)), ShouldEqual, enums.EVENT_PASS)
+		So(waitForLine(term, 2, "got hi"), ShouldBeTrue)
+
+		select {
+		case <-session.Done():
+		case <-time.After(5 * time.Second):
+		}
+		So(session.Err(), ShouldBeNil)
+
+		surface := memphis.NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(20, 3), paint.StyleDefault)
+		session.Draw(nil, surface)
+		w, h := term.Size()
+		So([]int{w, h}, ShouldResemble, []int{20, 3})
+		// the first row is scrolled off to keep the cursor on screen
+		So(term.Lines()[1], ShouldEqual, "got hi")
+		So(surface.GetContent(0, 1).Value(), ShouldEqual, 'g')
+		So(session.Close(), ShouldBeNil)
+	})
+
+	Convey("Session closing", t, func() {
+		session := NewSession(exec.Command("/bin/sh", "-c", "sleep 30"))
+		So(session.Close(), ShouldEqual, ErrNotStarted)
+		So(session.Start(10, 2), ShouldBeNil)
+		So(session.Close(), ShouldBeNil)
+		select {
+		case <-session.Done():
+		case <-time.After(5 * time.Second):
+		}
+		So(session.Err(), ShouldNotBeNil)
+	})
+}
@@ -0,0 +1,660 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package termemu embeds terminal programs within CDK applications. A
+// Terminal interprets the VT100 and xterm control sequences written by a
+// program into a grid of cells which is drawn on a memphis Surface, and a
+// Session runs a command on a pseudo-terminal feeding its output to a
+// Terminal and the input events of the application to the command.
+package termemu
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/mattn/go-runewidth"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/memphis"
+)
+
+// TermName is the $TERM given to the programs run in a Session, the control
+// sequences of which a Terminal interprets
+var TermName = "xterm-256color"
+
+// MaxStringSize is the longest OSC string, such as a window title, a
+// Terminal accepts, the remainder is discarded
+var MaxStringSize = 4096
+
+// MouseMode is the mouse reporting requested by the program
+type MouseMode int
+
+const (
+	// MouseNone reports no mouse events
+	MouseNone MouseMode = 0
+	// MouseButtons reports button presses and releases, mode 1000
+	MouseButtons MouseMode = 1000
+	// MouseDrag also reports motion while a button is pressed, mode 1002
+	MouseDrag MouseMode = 1002
+	// MouseMotion reports all motion, mode 1003
+	MouseMotion MouseMode = 1003
+)
+
+// Terminal interprets the output of a program into a grid of cells
+type Terminal interface {
+	io.Writer
+
+	// Resize changes the number of columns and rows, keeping the content
+	// above and to the left of the cursor
+	Resize(w, h int)
+	// Size returns the number of columns and rows
+	Size() (w, h int)
+	// Cursor returns the position of the cursor and if it is visible
+	Cursor() (x, y int, visible bool)
+	// Title returns the window title set by the program
+	Title() string
+	// AppCursorKeys returns true if the cursor keys are to be sent in
+	// application mode
+	AppCursorKeys() bool
+	// BracketedPaste returns true if pasted text is to be bracketed
+	BracketedPaste() bool
+	// Mouse returns the mouse reporting mode and if SGR encoding is used
+	Mouse() (mode MouseMode, sgr bool)
+	// Lines returns the text of each row
+	Lines() (lines []string)
+	// Render draws the cells onto the surface
+	Render(surface memphis.Surface)
+}
+
+type cell struct {
+	ch    string
+	width int // 0 for the right half of a wide character
+	style paint.Style
+}
+
+type parserState uint8
+
+const (
+	stateGround parserState = iota
+	stateEscape
+	stateCharset
+	stateCSI
+	stateOSC
+	stateString // DCS, SOS, PM and APC strings are ignored
+	stateStringEscape
+)
+
+// CTerminal is a Terminal emulating the commonly used subset of xterm
+type CTerminal struct {
+	w, h      int
+	primary   [][]cell
+	alternate [][]cell
+	rows      [][]cell
+	altScreen bool
+
+	x, y        int
+	pendingWrap bool
+	style       paint.Style
+	saved       savedCursor
+	top, bottom int
+	tabs        []bool
+
+	autoWrap       bool
+	cursorVisible  bool
+	appCursorKeys  bool
+	bracketedPaste bool
+	mouseMode      MouseMode
+	mouseSGR       bool
+	lineDrawing    bool
+	title          string
+
+	state  parserState
+	utf8   []byte
+	params []byte
+	inter  []byte
+	str    []byte
+	strEsc bool
+
+	reply io.Writer
+
+	sync.RWMutex
+}
+
+type savedCursor struct {
+	x, y        int
+	style       paint.Style
+	lineDrawing bool
+}
+
+// NewTerminal returns a Terminal of the given size. Replies to the queries of
+// the program, such as the cursor position report, are written to reply which
+// may be nil to discard them.
+func NewTerminal(w, h int, reply io.Writer) *CTerminal {
+	t := &CTerminal{reply: reply}
+	t.reset(max(w, 1), max(h, 1))
+	return t
+}
+
+func (t *CTerminal) reset(w, h int) {
+	t.w, t.h = w, h
+	t.style = paint.StyleDefault
+	t.primary = t.newRows(w, h)
+	t.alternate = t.newRows(w, h)
+	t.rows = t.primary
+	t.altScreen = false
+	t.x, t.y, t.pendingWrap = 0, 0, false
+	t.saved = savedCursor{style: paint.StyleDefault}
+	t.top, t.bottom = 0, h-1
+	t.resetTabs()
+	t.autoWrap = true
+	t.cursorVisible = true
+	t.appCursorKeys = false
+	t.bracketedPaste = false
+	t.mouseMode = MouseNone
+	t.mouseSGR = false
+	t.lineDrawing = false
+	t.title = ""
+}
+
+func (t *CTerminal) resetTabs() {
+	t.tabs = make([]bool, t.w)
+	for x := 8; x < t.w; x += 8 {
+		t.tabs[x] = true
+	}
+}
+
+func (t *CTerminal) blank() cell {
+	_, bg, _ := t.style.Decompose()
+	return cell{ch: " ", width: 1, style: paint.StyleDefault.Background(bg)}
+}
+
+func (t *CTerminal) newRow(w int) (row []cell) {
+	row = make([]cell, w)
+	blank := t.blank()
+	for x := range row {
+		row[x] = blank
+	}
+	return
+}
+
+func (t *CTerminal) newRows(w, h int) (rows [][]cell) {
+	rows = make([][]cell, h)
+	for y := range rows {
+		rows[y] = t.newRow(w)
+	}
+	return
+}
+
+func (t *CTerminal) Resize(w, h int) {
+	t.Lock()
+	defer t.Unlock()
+	w, h = max(w, 1), max(h, 1)
+	if w == t.w && h == t.h {
+		return
+	}
+	// rows scrolled off the top keep the cursor on screen
+	shift := max(t.y-h+1, 0)
+	resize := func(rows [][]cell) [][]cell {
+		rows = rows[min(shift, len(rows)):]
+		if len(rows) > h {
+			rows = rows[:h]
+		}
+		for y := range rows {
+			if len(rows[y]) > w {
+				rows[y] = rows[y][:w]
+			} else {
+				for len(rows[y]) < w {
+					rows[y] = append(rows[y], cell{ch: " ", width: 1, style: paint.StyleDefault})
+				}
+			}
+			if last := rows[y][w-1]; last.width > 1 {
+				// half of a wide character remains
+				rows[y][w-1] = cell{ch: " ", width: 1, style: last.style}
+			}
+		}
+		for len(rows) < h {
+			rows = append(rows, t.newRow(w))
+		}
+		return rows
+	}
+	t.primary = resize(t.primary)
+	t.alternate = resize(t.alternate)
+	if t.altScreen {
+		t.rows = t.alternate
+	} else {
+		t.rows = t.primary
+	}
+	t.w, t.h = w, h
+	t.x, t.y = min(t.x, w-1), min(t.y-shift, h-1)
+	t.pendingWrap = false
+	t.top, t.bottom = 0, h-1
+	t.resetTabs()
+}
+
+func (t *CTerminal) Size() (w, h int) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.w, t.h
+}
+
+func (t *CTerminal) Cursor() (x, y int, visible bool) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.x, t.y, t.cursorVisible
+}
+
+func (t *CTerminal) Title() string {
+	t.RLock()
+	defer t.RUnlock()
+	return t.title
+}
+
+func (t *CTerminal) AppCursorKeys() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.appCursorKeys
+}
+
+func (t *CTerminal) BracketedPaste() bool {
+	t.RLock()
+	defer t.RUnlock()
+	return t.bracketedPaste
+}
+
+func (t *CTerminal) Mouse() (mode MouseMode, sgr bool) {
+	t.RLock()
+	defer t.RUnlock()
+	return t.mouseMode, t.mouseSGR
+}
+
+func (t *CTerminal) Lines() (lines []string) {
+	t.RLock()
+	defer t.RUnlock()
+	for _, row := range t.rows {
+		var b strings.Builder
+		for _, c := range row {
+			if c.width > 0 {
+				b.WriteString(c.ch)
+			}
+		}
+		lines = append(lines, strings.TrimRight(b.String(), " "))
+	}
+	return
+}
+
+func (t *CTerminal) Render(surface memphis.Surface) {
+	t.RLock()
+	defer t.RUnlock()
+	w, h := min(t.w, surface.Width()), min(t.h, surface.Height())
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := t.rows[y][x]
+			r, _ := utf8.DecodeRuneInString(c.ch)
+			if c.width == 0 {
+				// the screen skips the cells covered by wide characters
+				r = ' '
+			}
+			_ = surface.SetRune(x, y, r, c.style)
+		}
+	}
+}
+
+// Write interprets the output of the program
+func (t *CTerminal) Write(p []byte) (n int, err error) {
+	t.Lock()
+	defer t.Unlock()
+	for _, b := range p {
+		t.advance(b)
+	}
+	return len(p), nil
+}
+
+func (t *CTerminal) advance(b byte) {
+	switch t.state {
+	case stateGround:
+		t.ground(b)
+	case stateEscape:
+		t.escape(b)
+	case stateCharset:
+		// only G0 is supported, with the DEC special graphics or ASCII
+		if t.inter[0] == '(' {
+			t.lineDrawing = b == '0'
+		}
+		t.state = stateGround
+	case stateCSI:
+		switch {
+		case b >= 0x30 && b <= 0x3f:
+			t.params = append(t.params, b)
+		case b >= 0x20 && b <= 0x2f:
+			t.inter = append(t.inter, b)
+		case b >= 0x40 && b <= 0x7e:
+			t.state = stateGround
+			t.csi(b)
+		case b == 0x1b:
+			t.enterEscape()
+		case b < 0x20:
+			t.control(b)
+		default:
+			t.state = stateGround
+		}
+	case stateOSC:
+		switch {
+		case b == 0x07:
+			t.state = stateGround
+			t.osc()
+		case b == 0x1b:
+			t.strEsc = true
+		case t.strEsc:
+			// ESC \ is the string terminator, anything else is an escape
+			t.strEsc = false
+			if b == '\\' {
+				t.state = stateGround
+				t.osc()
+			} else {
+				t.enterEscape()
+				t.escape(b)
+			}
+		case len(t.str) < MaxStringSize:
+			t.str = append(t.str, b)
+		}
+	case stateString:
+		if b == 0x1b {
+			t.state = stateStringEscape
+		}
+	case stateStringEscape:
+		if b == '\\' {
+			t.state = stateGround
+		} else {
+			t.state = stateString
+		}
+	}
+}
+
+func (t *CTerminal) enterEscape() {
+	t.state = stateEscape
+	t.params = t.params[:0]
+	t.inter = t.inter[:0]
+}
+
+func (t *CTerminal) ground(b byte) {
+	if len(t.utf8) > 0 || b >= 0x80 {
+		t.utf8 = append(t.utf8, b)
+		if !utf8.FullRune(t.utf8) {
+			return
+		}
+		r, _ := utf8.DecodeRune(t.utf8)
+		t.utf8 = t.utf8[:0]
+		t.print(r)
+		return
+	}
+	switch {
+	case b == 0x1b:
+		t.enterEscape()
+	case b < 0x20:
+		t.control(b)
+	case b == 0x7f:
+	default:
+		t.print(rune(b))
+	}
+}
+
+func (t *CTerminal) control(b byte) {
+	switch b {
+	case '\a', 0x0e, 0x0f:
+	case '\b':
+		if t.x > 0 {
+			t.x--
+		}
+		t.pendingWrap = false
+	case '\t':
+		t.tab()
+	case '\n', '\v', '\f':
+		t.index()
+	case '\r':
+		t.x, t.pendingWrap = 0, false
+	}
+}
+
+func (t *CTerminal) escape(b byte) {
+	t.state = stateGround
+	switch b {
+	case '[':
+		t.state = stateCSI
+	case ']':
+		t.state = stateOSC
+		t.str, t.strEsc = t.str[:0], false
+	case 'P', 'X', '^', '_':
+		t.state = stateString
+	case '(', ')', '*', '+':
+		t.inter = append(t.inter[:0], b)
+		t.state = stateCharset
+	case '7':
+		t.saveCursor()
+	case '8':
+		t.restoreCursor()
+	case 'D':
+		t.index()
+	case 'E':
+		t.x = 0
+		t.index()
+	case 'H':
+		t.tabs[t.x] = true
+	case 'M':
+		t.reverseIndex()
+	case 'c':
+		t.reset(t.w, t.h)
+	}
+}
+
+func (t *CTerminal) osc() {
+	command, text, _ := strings.Cut(string(t.str), ";")
+	switch command {
+	case "0", "2":
+		t.title = text
+	}
+}
+
+func (t *CTerminal) respond(format string, argv ...interface{}) {
+	if t.reply != nil {
+		_, _ = fmt.Fprintf(t.reply, format, argv...)
+	}
+}
+
+// lineDrawingRunes are the DEC special graphics characters
+var lineDrawingRunes = map[rune]rune{
+	'`': '◆', 'a': '▒', 'f': '°', 'g': '±', 'j': '┘', 'k': '┐', 'l': '┌', 'm': '└',
+	'n': '┼', 'o': '⎺', 'p': '⎻', 'q': '─', 'r': '⎼', 's': '⎽', 't': '├', 'u': '┤',
+	'v': '┴', 'w': '┬', 'x': '│', 'y': '≤', 'z': '≥', '{': 'π', '|': '≠', '}': '£',
+	'~': '·',
+}
+
+func (t *CTerminal) print(r rune) {
+	if t.lineDrawing {
+		if v, ok := lineDrawingRunes[r]; ok {
+			r = v
+		}
+	}
+	width := runewidth.RuneWidth(r)
+	if width == 0 {
+		// combining characters join the previous one
+		x := t.x
+		if !t.pendingWrap {
+			x--
+		}
+		for x > 0 && t.rows[t.y][x].width == 0 {
+			x--
+		}
+		if x >= 0 {
+			t.rows[t.y][x].ch += string(r)
+		}
+		return
+	}
+	if t.pendingWrap {
+		t.x = 0
+		t.index()
+	}
+	if width > 1 && t.x == t.w-1 {
+		if !t.autoWrap || t.w < 2 {
+			return
+		}
+		t.clearCell(t.x, t.y)
+		t.x = 0
+		t.index()
+	}
+	t.clearCell(t.x, t.y)
+	t.rows[t.y][t.x] = cell{ch: string(r), width: width, style: t.style}
+	if width > 1 {
+		t.clearCell(t.x+1, t.y)
+		t.rows[t.y][t.x+1] = cell{width: 0, style: t.style}
+	}
+	if t.x+width < t.w {
+		t.x += width
+	} else if t.autoWrap {
+		t.x = t.w - 1
+		t.pendingWrap = true
+	} else {
+		t.x = t.w - 1
+	}
+}
+
+// clearCell blanks the cell, and the other half of a wide character it is
+// part of
+func (t *CTerminal) clearCell(x, y int) {
+	row := t.rows[y]
+	if row[x].width == 0 && x > 0 {
+		row[x-1] = cell{ch: " ", width: 1, style: row[x-1].style}
+	} else if row[x].width > 1 && x+1 < t.w {
+		row[x+1] = cell{ch: " ", width: 1, style: row[x+1].style}
+	}
+	row[x] = cell{ch: " ", width: 1, style: row[x].style}
+}
+
+func (t *CTerminal) tab() {
+	for t.x < t.w-1 {
+		t.x++
+		if t.tabs[t.x] {
+			break
+		}
+	}
+	t.pendingWrap = false
+}
+
+func (t *CTerminal) index() {
+	t.pendingWrap = false
+	if t.y == t.bottom {
+		t.scrollUp(1)
+	} else if t.y < t.h-1 {
+		t.y++
+	}
+}
+
+func (t *CTerminal) reverseIndex() {
+	t.pendingWrap = false
+	if t.y == t.top {
+		t.scrollDown(1)
+	} else if t.y > 0 {
+		t.y--
+	}
+}
+
+// scrollUp moves the rows of the scrolling region up, adding blank rows at
+// the bottom
+func (t *CTerminal) scrollUp(n int) {
+	t.deleteRows(t.top, n)
+}
+
+// scrollDown moves the rows of the scrolling region down, adding blank rows
+// at the top
+func (t *CTerminal) scrollDown(n int) {
+	t.insertRows(t.top, n)
+}
+
+func (t *CTerminal) deleteRows(y, n int) {
+	n = min(n, t.bottom-y+1)
+	copy(t.rows[y:t.bottom+1], t.rows[y+n:t.bottom+1])
+	for i := t.bottom - n + 1; i <= t.bottom; i++ {
+		t.rows[i] = t.newRow(t.w)
+	}
+}
+
+func (t *CTerminal) insertRows(y, n int) {
+	n = min(n, t.bottom-y+1)
+	copy(t.rows[y+n:t.bottom+1], t.rows[y:t.bottom+1-n])
+	for i := y; i < y+n; i++ {
+		t.rows[i] = t.newRow(t.w)
+	}
+}
+
+func (t *CTerminal) saveCursor() {
+	t.saved = savedCursor{x: t.x, y: t.y, style: t.style, lineDrawing: t.lineDrawing}
+}
+
+func (t *CTerminal) restoreCursor() {
+	t.x, t.y = min(t.saved.x, t.w-1), min(t.saved.y, t.h-1)
+	t.style, t.lineDrawing = t.saved.style, t.saved.lineDrawing
+	t.pendingWrap = false
+}
+
+func (t *CTerminal) moveTo(x, y int) {
+	t.x, t.y = max(0, min(x, t.w-1)), max(0, min(y, t.h-1))
+	t.pendingWrap = false
+}
+
+func (t *CTerminal) eraseCells(y, from, to int) {
+	blank := t.blank()
+	for x := max(from, 0); x < min(to, t.w); x++ {
+		t.clearCell(x, y)
+		t.rows[y][x] = blank
+	}
+}
+
+func (t *CTerminal) setAltScreen(enabled bool) {
+	if enabled == t.altScreen {
+		return
+	}
+	t.altScreen = enabled
+	if enabled {
+		t.alternate = t.newRows(t.w, t.h)
+		t.rows = t.alternate
+	} else {
+		t.rows = t.primary
+	}
+}
+
+// parseParams returns the numeric parameters of a control sequence, missing
+// ones being zero
+func parseParams(params string) (values []int) {
+	if params == "" {
+		return nil
+	}
+	for _, field := range strings.Split(params, ";") {
+		// sub-parameters are only used by SGR, see: sgr
+		field, _, _ = strings.Cut(field, ":")
+		v, _ := strconv.Atoi(field)
+		values = append(values, v)
+	}
+	return
+}
+
+// param returns the numbered parameter, or the default if missing or zero
+func param(values []int, idx, def int) int {
+	if idx < len(values) && values[idx] > 0 {
+		return values[idx]
+	}
+	return def
+}
@@ -0,0 +1,150 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package termemu
+
+import (
+	"bytes"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+func TestTerminal(t *testing.T) {
+	Convey("Terminal output", t, func() {
+		reply := &bytes.Buffer{}
+		term := NewTerminal(10, 4, reply)
+		write := func(s string) {
+			_, err := term.Write([]byte(s))
+			So(err, ShouldBeNil)
+		}
+
+		write("hello\r\nworld")
+		So(term.Lines(), ShouldResemble, []string{"hello", "world", "", ""})
+		x, y, visible := term.Cursor()
+		So([]int{x, y}, ShouldResemble, []int{5, 1})
+		So(visible, ShouldBeTrue)
+
+		// cursor movement and erasing
+		write("\x1b[1;3H\x1b[K\x1b[2;2H\x1b[1P")
+		So(term.Lines()[:2], ShouldResemble, []string{"he", "wrld"})
+		write("\x1b[2J\x1b[H")
+		So(term.Lines(), ShouldResemble, []string{"", "", "", ""})
+
+		// wrapping and scrolling
+		write("0123456789abc\r\nd\r\ne\r\nf")
+		So(term.Lines(), ShouldResemble, []string{"abc", "d", "e", "f"})
+		write("\x1b[2;3r\x1b[3;1H\n")
+		So(term.Lines(), ShouldResemble, []string{"abc", "e", "", "f"})
+		write("\x1b[r")
+
+		// styles
+		write("\x1b[H\x1b[1;31;48;2;1;2;3mX\x1b[0m")
+		fg, bg, attrs := term.rows[0][0].style.Decompose()
+		So(fg, ShouldEqual, paint.PaletteColor(1))
+		So(bg, ShouldEqual, paint.NewRGBColor(1, 2, 3))
+		So(attrs&paint.AttrBold, ShouldNotEqual, 0)
+		write("\x1b[38:5:200mY")
+		fg, _, _ = term.rows[0][1].style.Decompose()
+		So(fg, ShouldEqual, paint.PaletteColor(200))
+
+		// wide characters and line drawing
+		write("\x1b[2J\x1b[H世界\x1b(0qx\x1b(B")
+		So(term.Lines()[0], ShouldEqual, "世界─│")
+		x, _, _ = term.Cursor()
+		So(x, ShouldEqual, 6)
+
+		// tabs stop at the right margin
+		write("\x1b[1;1H\x1b[999999999I")
+		x, _, _ = term.Cursor()
+		So(x, ShouldEqual, 9)
+		write("\x1b[999999999Z")
+		x, _, _ = term.Cursor()
+		So(x, ShouldEqual, 0)
+
+		// alternate screen
+		write("\x1b[?1049h\x1b[Halt")
+		So(term.Lines()[0], ShouldEqual, "alt")
+		write("\x1b[?1049l")
+		So(term.Lines()[0], ShouldEqual, "世界─│")
+
+		// modes, title and replies
+		write("\x1b[?1h\x1b[?2004h\x1b[?1002h\x1b[?1006h\x1b[?25l\x1b]2;title\a")
+		So(term.AppCursorKeys(), ShouldBeTrue)
+		So(term.BracketedPaste(), ShouldBeTrue)
+		mode, sgr := term.Mouse()
+		So(mode, ShouldEqual, MouseDrag)
+		So(sgr, ShouldBeTrue)
+		_, _, visible = term.Cursor()
+		So(visible, ShouldBeFalse)
+		So(term.Title(), ShouldEqual, "title")
+		write("\x1b[2;3H\x1b[6n\x1b[c\x1bP+q544e\x1b\\")
+		So(reply.String(), ShouldEqual, "\x1b[2;3R\x1b[?62;22c")
+
+		// resizing keeps the cursor on screen
+		write("\x1b[4;1Hbottom")
+		term.Resize(4, 2)
+		So(term.Lines(), ShouldResemble, []string{"", "bott"})
+		w, h := term.Size()
+		So([]int{w, h}, ShouldResemble, []int{4, 2})
+	})
+
+	Convey("Terminal rendering", t, func() {
+		term := NewTerminal(4, 2, nil)
+		_, _ = term.Write([]byte("ab\r\n\x1b[7mc"))
+		surface := memphis.NewSurface(ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(4, 2), paint.StyleDefault)
+		term.Render(surface)
+		So(surface.GetContent(1, 0).Value(), ShouldEqual, 'b')
+		So(surface.GetContent(0, 1).Value(), ShouldEqual, 'c')
+		_, _, attrs := surface.GetContent(0, 1).Style().Decompose()
+		So(attrs&paint.AttrReverse, ShouldNotEqual, 0)
+	})
+
+	Convey("Terminal input", t, func() {
+		key := func(k cdk.Key, r rune, mod cdk.ModMask, app bool) string {
+			return string(EncodeKey(cdk.NewEventKey(k, r, mod), app))
+		}
+		So(key(cdk.KeyRune, 'a', cdk.ModNone, false), ShouldEqual, "a")
+		So(key(cdk.KeyRune, 'é', cdk.ModAlt, false), ShouldEqual, "\x1bé")
+		So(key(cdk.KeyRune, 3, cdk.ModNone, false), ShouldEqual, "\x03")
+		So(key(cdk.KeyEnter, 0, cdk.ModNone, false), ShouldEqual, "\r")
+		So(key(cdk.KeyBackspace2, 0, cdk.ModNone, false), ShouldEqual, "\x7f")
+		So(key(cdk.KeyUp, 0, cdk.ModNone, false), ShouldEqual, "\x1b[A")
+		So(key(cdk.KeyUp, 0, cdk.ModNone, true), ShouldEqual, "\x1bOA")
+		So(key(cdk.KeyLeft, 0, cdk.ModCtrl, true), ShouldEqual, "\x1b[1;5D")
+		So(key(cdk.KeyF1, 0, cdk.ModNone, false), ShouldEqual, "\x1bOP")
+		So(key(cdk.KeyF5, 0, cdk.ModShift, false), ShouldEqual, "\x1b[15;2~")
+		So(key(cdk.KeyDelete, 0, cdk.ModNone, false), ShouldEqual, "\x1b[3~")
+		So(key(cdk.KeyBacktab, 0, cdk.ModNone, false), ShouldEqual, "\x1b[Z")
+
+		var mouse mouseEncoder
+		press := cdk.NewEventMouse(2, 3, cdk.Button1, cdk.ModNone)
+		So(mouse.encode(press, 2, 3, MouseNone, true), ShouldBeNil)
+		So(string(mouse.encode(press, 2, 3, MouseButtons, true)), ShouldEqual, "\x1b[<0;3;4M")
+		drag := cdk.NewEventMouse(3, 3, cdk.Button1, cdk.ModNone)
+		So(mouse.encode(drag, 3, 3, MouseButtons, true), ShouldBeNil)
+		So(string(mouse.encode(drag, 3, 3, MouseDrag, true)), ShouldEqual, "\x1b[<32;4;4M")
+		release := cdk.NewEventMouse(3, 3, cdk.ButtonNone, cdk.ModNone)
+		So(string(mouse.encode(release, 3, 3, MouseDrag, true)), ShouldEqual, "\x1b[<0;4;4m")
+		So(mouse.encode(release, 3, 3, MouseDrag, true), ShouldBeNil)
+		So(mouse.encode(press, 0, 0, MouseButtons, false), ShouldResemble, []byte("\x1b[M !!"))
+		wheel := cdk.NewEventMouse(0, 0, cdk.WheelUp, cdk.ModNone)
+		So(string(mouse.encode(wheel, 0, 0, MouseButtons, true)), ShouldEqual, "\x1b[<64;1;1M")
+	})
+}
@@ -0,0 +1,223 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-curses/cdk/env"
+	cpaths "github.com/go-curses/cdk/lib/paths"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// TerminalColorMode is the user's choice of color support for a terminal
+type TerminalColorMode string
+
+const (
+	// ColorModeAuto uses 24-bit colors if the terminal supports them
+	ColorModeAuto TerminalColorMode = ""
+	// ColorModeTrueColor is the same as ColorModeAuto, stated explicitly
+	ColorModeTrueColor TerminalColorMode = "truecolor"
+	// ColorModePalette restricts colors to the terminal's palette
+	ColorModePalette TerminalColorMode = "palette"
+)
+
+// TerminalPrefs are the mouse, keyboard, color and cursor preferences for a terminal
+// profile. The zero value uses the CDK defaults.
+type TerminalPrefs struct {
+	// DisableMouse prevents mouse reporting from being enabled
+	DisableMouse bool `json:"disable-mouse,omitempty"`
+	// MouseFlags are given to Screen.EnableMouse, zero for the default
+	MouseFlags MouseFlags `json:"mouse-flags,omitempty"`
+	// InvertWheel swaps the WheelUp and WheelDown buttons
+	InvertWheel bool `json:"invert-wheel,omitempty"`
+	// DisablePrimaryPaste stops unhandled middle-clicks from pasting the
+	// primary selection, see: Display.PastePrimary
+	DisablePrimaryPaste bool `json:"disable-primary-paste,omitempty"`
+	// KeyTiming is given to Screen.SetKeyTiming, zero for EventKeyTiming
+	KeyTiming time.Duration `json:"key-timing,omitempty"`
+	// ColorMode selects between 24-bit and palette colors
+	ColorMode TerminalColorMode `json:"color-mode,omitempty"`
+	// CursorIndicator overrides how applications show the cursor
+	CursorIndicator CursorIndicator `json:"cursor-indicator,omitempty"`
+	// CursorColor is the name or hex code of the cursor indicator color
+	CursorColor string `json:"cursor-color,omitempty"`
+	// BlinkMode selects how blinking cells are shown, see: Screen.SetBlinkMode
+	BlinkMode BlinkMode `json:"blink-mode,omitempty"`
+	// BlinkRate is how often cells blinking in software are hidden or shown,
+	// zero for DefaultBlinkRate
+	BlinkRate time.Duration `json:"blink-rate,omitempty"`
+}
+
+// apply configures the screen with the preferences
+func (p TerminalPrefs) apply(screen Screen) {
+	if p.DisableMouse {
+		screen.DisableMouse()
+	} else if p.MouseFlags != 0 {
+		screen.EnableMouse(p.MouseFlags)
+	} else {
+		screen.EnableMouse()
+	}
+	screen.SetKeyTiming(p.KeyTiming)
+	screen.SetBlinkMode(p.BlinkMode, p.BlinkRate)
+	switch p.ColorMode {
+	case ColorModePalette:
+		screen.SetTrueColor(false)
+	default:
+		screen.SetTrueColor(os.Getenv("GO_CDK_TRUECOLOR") != "disable")
+	}
+}
+
+// TerminalProfile returns the key used to store the preferences of the
+// terminal with the given capabilities, described by the given environment
+// lookup function. The key is made of the TERM value with the terminal id and
+// version of the secondary device attributes appended, once probed. Until then,
+// or when the terminal did not identify itself, the TERM_PROGRAM and
+// TERM_PROGRAM_VERSION values are appended when present, which are not passed
+// through ssh and are those of the outer terminal within tmux or screen.
+func TerminalProfile(capabilities Capabilities, lookup func(key string) (value string, ok bool)) (profile string) {
+	if profile, _ = lookup("TERM"); profile == "" {
+		profile = "unknown"
+	}
+	if capabilities.Probed && (capabilities.TerminalID != 0 || capabilities.TerminalVersion != 0) {
+		return fmt.Sprintf("%v/da2-%d@%d", profile, capabilities.TerminalID, capabilities.TerminalVersion)
+	}
+	if program, ok := lookup("TERM_PROGRAM"); ok && program != "" {
+		profile += "/" + program
+		if version, ok := lookup("TERM_PROGRAM_VERSION"); ok && version != "" {
+			profile += "@" + version
+		}
+	}
+	return
+}
+
+// TerminalPrefsStore persists TerminalPrefs by terminal profile
+type TerminalPrefsStore interface {
+	LoadTerminalPrefs(profile string) (prefs TerminalPrefs, found bool, err error)
+	SaveTerminalPrefs(profile string, prefs TerminalPrefs) (err error)
+}
+
+var (
+	// DefaultTerminalPrefsStore is used by new displays, saving to the
+	// TerminalPrefsPath, nil when there is no such path
+	DefaultTerminalPrefsStore TerminalPrefsStore = newDefaultTerminalPrefsStore()
+)
+
+// TerminalPrefsPath returns the path of the terminal preferences file within
+// the XDG state directory ($XDG_STATE_HOME, or ~/.local/state), an empty
+// string when there is neither
+func TerminalPrefsPath() (path string) {
+	state := env.Get("XDG_STATE_HOME", "")
+	if state == "" {
+		home, err := os.UserHomeDir()
+		if err != nil || home == "" {
+			return ""
+		}
+		state = filepath.Join(home, ".local", "state")
+	}
+	return filepath.Join(state, "cdk", "terminals.json")
+}
+
+// newDefaultTerminalPrefsStore returns the store saving to the
+// TerminalPrefsPath, or nil, which disables persistence, without one
+func newDefaultTerminalPrefsStore() TerminalPrefsStore {
+	if path := TerminalPrefsPath(); path != "" {
+		return NewFileTerminalPrefsStore(path)
+	}
+	return nil
+}
+
+// CFileTerminalPrefsStore saves all profiles to a single JSON file
+type CFileTerminalPrefsStore struct {
+	path string
+
+	sync.Mutex
+}
+
+func NewFileTerminalPrefsStore(path string) (store *CFileTerminalPrefsStore) {
+	return &CFileTerminalPrefsStore{path: path}
+}
+
+func (s *CFileTerminalPrefsStore) read() (profiles map[string]TerminalPrefs, err error) {
+	profiles = make(map[string]TerminalPrefs)
+	if !cpaths.IsFile(s.path) {
+		return
+	}
+	var content []byte
+	if content, err = os.ReadFile(s.path); err != nil {
+		return
+	}
+	err = json.Unmarshal(content, &profiles)
+	return
+}
+
+func (s *CFileTerminalPrefsStore) LoadTerminalPrefs(profile string) (prefs TerminalPrefs, found bool, err error) {
+	s.Lock()
+	defer s.Unlock()
+	var profiles map[string]TerminalPrefs
+	if profiles, err = s.read(); err == nil {
+		prefs, found = profiles[profile]
+	}
+	return
+}
+
+func (s *CFileTerminalPrefsStore) SaveTerminalPrefs(profile string, prefs TerminalPrefs) (err error) {
+	s.Lock()
+	defer s.Unlock()
+	var profiles map[string]TerminalPrefs
+	if profiles, err = s.read(); err != nil {
+		return
+	}
+	profiles[profile] = prefs
+	var content []byte
+	if content, err = json.MarshalIndent(profiles, "", "  "); err != nil {
+		return
+	}
+	if dir := filepath.Dir(s.path); !cpaths.IsDir(dir) {
+		if err = cpaths.MakeDir(dir, 0700); err != nil {
+			return
+		}
+	}
+	return os.WriteFile(s.path, content, 0600)
+}
+
+// CMemoryTerminalPrefsStore keeps preferences for the life of the process
+type CMemoryTerminalPrefsStore struct {
+	profiles map[string]TerminalPrefs
+
+	sync.Mutex
+}
+
+func NewMemoryTerminalPrefsStore() (store *CMemoryTerminalPrefsStore) {
+	return &CMemoryTerminalPrefsStore{profiles: make(map[string]TerminalPrefs)}
+}
+
+func (s *CMemoryTerminalPrefsStore) LoadTerminalPrefs(profile string) (prefs TerminalPrefs, found bool, err error) {
+	s.Lock()
+	defer s.Unlock()
+	prefs, found = s.profiles[profile]
+	return
+}
+
+func (s *CMemoryTerminalPrefsStore) SaveTerminalPrefs(profile string, prefs TerminalPrefs) (err error) {
+	s.Lock()
+	defer s.Unlock()
+	s.profiles[profile] = prefs
+	return
+}
@@ -0,0 +1,129 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/env"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+func TestTerminalPrefs(t *testing.T) {
+	Convey("Terminal preferences", t, func() {
+		Convey("profiles", func() {
+			lookup := func(values map[string]string) func(key string) (string, bool) {
+				return func(key string) (value string, ok bool) {
+					value, ok = values[key]
+					return
+				}
+			}
+			So(TerminalProfile(Capabilities{}, lookup(nil)), ShouldEqual, "unknown")
+			So(TerminalProfile(Capabilities{}, lookup(map[string]string{"TERM": "xterm"})), ShouldEqual, "xterm")
+			environ := lookup(map[string]string{
+				"TERM":                 "xterm-256color",
+				"TERM_PROGRAM":         "WezTerm",
+				"TERM_PROGRAM_VERSION": "20230712",
+			})
+			So(TerminalProfile(Capabilities{}, environ), ShouldEqual, "xterm-256color/WezTerm@20230712")
+			// the terminal identifying itself takes precedence
+			probed := Capabilities{Probed: true, TerminalID: 41, TerminalVersion: 379}
+			So(TerminalProfile(probed, environ), ShouldEqual, "xterm-256color/da2-41@379")
+			So(TerminalProfile(probed, lookup(map[string]string{"TERM": "xterm"})), ShouldEqual, "xterm/da2-41@379")
+			So(TerminalProfile(Capabilities{Probed: true}, environ), ShouldEqual, "xterm-256color/WezTerm@20230712")
+		})
+		Convey("file store", func() {
+			path := filepath.Join(t.TempDir(), "state", "terminals.json")
+			store := NewFileTerminalPrefsStore(path)
+			_, found, err := store.LoadTerminalPrefs("xterm")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeFalse)
+			prefs := TerminalPrefs{InvertWheel: true, KeyTiming: time.Millisecond * 10, ColorMode: ColorModePalette}
+			So(store.SaveTerminalPrefs("xterm", prefs), ShouldBeNil)
+			So(store.SaveTerminalPrefs("linux", TerminalPrefs{DisableMouse: true}), ShouldBeNil)
+			loaded, found, err := NewFileTerminalPrefsStore(path).LoadTerminalPrefs("xterm")
+			So(err, ShouldBeNil)
+			So(found, ShouldBeTrue)
+			So(loaded, ShouldResemble, prefs)
+		})
+		Convey("store path", func() {
+			previous := env.Get("XDG_STATE_HOME", "")
+			t.Setenv("XDG_STATE_HOME", previous)
+			defer env.Set("XDG_STATE_HOME", previous)
+			env.Set("XDG_STATE_HOME", "/tmp/cdk-state")
+			So(TerminalPrefsPath(), ShouldEqual, filepath.Join("/tmp/cdk-state", "cdk", "terminals.json"))
+			So(newDefaultTerminalPrefsStore(), ShouldNotBeNil)
+			env.Set("XDG_STATE_HOME", "")
+			t.Setenv("HOME", "")
+			So(TerminalPrefsPath(), ShouldEqual, "")
+			So(newDefaultTerminalPrefsStore(), ShouldBeNil)
+		})
+		Convey("display preferences", WithDisplayManager(func(d Display) {
+			store := NewMemoryTerminalPrefsStore()
+			d.SetTerminalPrefsStore(store)
+			d.Setenv("TERM", "xterm")
+			So(d.GetTerminalProfile(), ShouldStartWith, "xterm")
+			So(d.GetTerminalPrefs(), ShouldResemble, TerminalPrefs{})
+			prefs := TerminalPrefs{InvertWheel: true}
+			So(d.SetTerminalPrefs(prefs), ShouldBeNil)
+			So(d.GetTerminalPrefs(), ShouldResemble, prefs)
+			saved, found, _ := store.LoadTerminalPrefs(d.GetTerminalProfile())
+			So(found, ShouldBeTrue)
+			So(saved, ShouldResemble, prefs)
+
+			// the preferences of the probed terminal replace those of the
+			// environment once known
+			probed := TerminalPrefs{DisableMouse: true}
+			So(store.SaveTerminalPrefs("xterm/da2-41@379", probed), ShouldBeNil)
+			cd := d.(*CDisplay)
+			screen := newWireTestScreen(0)
+			screen.caps = Capabilities{Probed: true, TerminalID: 41, TerminalVersion: 379}
+			cd.Lock()
+			previousScreen, previousPath := cd.screen, cd.ttyPath
+			cd.screen, cd.ttyPath = screen, "/dev/tty"
+			cd.Unlock()
+			defer func() {
+				cd.Lock()
+				cd.screen, cd.ttyPath = previousScreen, previousPath
+				cd.Unlock()
+			}()
+			cd.reloadTerminalPrefs()
+			So(d.GetTerminalProfile(), ShouldEqual, "xterm/da2-41@379")
+			So(d.GetTerminalPrefs(), ShouldResemble, probed)
+			// without preferences for the probed terminal, those already loaded
+			// are kept
+			screen.caps.TerminalVersion = 380
+			cd.reloadTerminalPrefs()
+			So(d.GetTerminalPrefs(), ShouldResemble, probed)
+		}))
+		Convey("cursor indicator", WithDisplayManager(func(d Display) {
+			d.SetTerminalPrefsStore(nil)
+			indicator, color := d.GetCursorIndicator()
+			So(indicator, ShouldEqual, CursorIndicatorDefault)
+			So(color, ShouldEqual, DefaultCursorIndicatorColor)
+			d.SetCursorIndicator(CursorIndicatorInverse, paint.ColorDefault)
+			indicator, _ = d.GetCursorIndicator()
+			So(indicator, ShouldEqual, CursorIndicatorInverse)
+			So(d.SetTerminalPrefs(TerminalPrefs{CursorIndicator: CursorIndicatorBlock, CursorColor: "lime"}), ShouldBeNil)
+			indicator, color = d.GetCursorIndicator()
+			So(indicator, ShouldEqual, CursorIndicatorBlock)
+			So(color, ShouldEqual, paint.ColorLime)
+		}))
+	})
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-curses/terminfo"
+
+	"github.com/go-curses/cdk/env"
+	cpaths "github.com/go-curses/cdk/lib/paths"
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+var (
+	// TerminfoCacheDir is where terminfo entries loaded with infocmp are
+	// cached, an empty string disables the cache
+	TerminfoCacheDir = TerminfoCachePath()
+
+	// TerminfoCacheMaxAge is how long a cached terminfo entry is used before
+	// infocmp is consulted again
+	TerminfoCacheMaxAge = 30 * 24 * time.Hour
+)
+
+// terminfoCacheVersion is increased whenever the cached entries can no longer
+// be decoded as a terminfo.Terminfo
+const terminfoCacheVersion = 1
+
+// TerminfoCachePath returns the path of the terminfo cache within the XDG
+// cache directory ($XDG_CACHE_HOME, or ~/.cache), an empty string when there is
+// neither, which disables the cache
+func TerminfoCachePath() (path string) {
+	cache := env.Get("XDG_CACHE_HOME", "")
+	if cache == "" {
+		home, err := os.UserHomeDir()
+		if err != nil || home == "" {
+			return ""
+		}
+		cache = filepath.Join(home, ".cache")
+	}
+	return filepath.Join(cache, "cdk", "terminfo")
+}
+
+// ClearTerminfoCache removes all cached terminfo entries
+func ClearTerminfoCache() (err error) {
+	if TerminfoCacheDir == "" {
+		return nil
+	}
+	return os.RemoveAll(TerminfoCacheDir)
+}
+
+var (
+	terminfoPaths     []string
+	terminfoPathsLock sync.RWMutex
+)
+
+// AddTerminfoPath adds a directory of compiled terminfo entries to search,
+// before the system ones, when a $TERM is not built into CDK
+func AddTerminfoPath(dir string) {
+	terminfoPathsLock.Lock()
+	defer terminfoPathsLock.Unlock()
+	for _, known := range terminfoPaths {
+		if known == dir {
+			return
+		}
+	}
+	terminfoPaths = append(terminfoPaths, dir)
+}
+
+// RemoveTerminfoPath removes a directory added with AddTerminfoPath
+func RemoveTerminfoPath(dir string) {
+	terminfoPathsLock.Lock()
+	defer terminfoPathsLock.Unlock()
+	for idx, known := range terminfoPaths {
+		if known == dir {
+			terminfoPaths = append(terminfoPaths[:idx], terminfoPaths[idx+1:]...)
+			return
+		}
+	}
+}
+
+// TerminfoPaths returns the directories added with AddTerminfoPath
+func TerminfoPaths() (dirs []string) {
+	terminfoPathsLock.RLock()
+	defer terminfoPathsLock.RUnlock()
+	return append(dirs, terminfoPaths...)
+}
+
+// RegisterTerminfo adds an inline terminfo definition, used for its name and
+// aliases in preference to any other source
+func RegisterTerminfo(ti *terminfo.Terminfo) {
+	terminfo.AddTerminfo(ti)
+}
+
+// terminfoSearchPaths returns the directories searched for compiled terminfo
+// entries, in the order ncurses does, with the AddTerminfoPath ones first
+func terminfoSearchPaths() (dirs []string) {
+	dirs = TerminfoPaths()
+	if dir := os.Getenv("TERMINFO"); dir != "" {
+		dirs = append(dirs, dir)
+	}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, ".terminfo"))
+	}
+	for _, dir := range filepath.SplitList(os.Getenv("TERMINFO_DIRS")) {
+		if dir != "" {
+			dirs = append(dirs, dir)
+		}
+	}
+	return append(dirs, "/etc/terminfo", "/lib/terminfo", "/usr/share/terminfo", "/usr/lib/terminfo")
+}
+
+// terminfoModTime returns the modification time of the compiled terminfo
+// entry for the name, found in either the letter or hexadecimal layout
+func terminfoModTime(name string) (modified time.Time, found bool) {
+	if name == "" {
+		return
+	}
+	for _, dir := range terminfoSearchPaths() {
+		for _, sub := range []string{name[:1], strconv.FormatInt(int64(name[0]), 16)} {
+			if info, err := os.Stat(filepath.Join(dir, sub, name)); err == nil {
+				return info.ModTime(), true
+			}
+		}
+	}
+	return
+}
+
+// cTerminfoCacheEntry is the content of a cached terminfo file
+type cTerminfoCacheEntry struct {
+	Version  int                `json:"version"`
+	Created  time.Time          `json:"created"`
+	Paths    []string           `json:"paths,omitempty"`
+	Terminfo *terminfo.Terminfo `json:"terminfo"`
+}
+
+// terminfoCacheFile returns the cache file of the named terminal, ok is false
+// when caching is disabled or the name is not a valid file name
+func terminfoCacheFile(name string) (path string, ok bool) {
+	if TerminfoCacheDir == "" || name == "" || name[0] == '.' || strings.ContainsAny(name, `/\`) {
+		return "", false
+	}
+	return filepath.Join(TerminfoCacheDir, name+".json"), true
+}
+
+// readTerminfoCache returns the cached terminfo entry of the named terminal.
+// Entries are invalid once older than TerminfoCacheMaxAge, when the compiled
+// terminfo entry has since changed or when the AddTerminfoPath directories
+// differ from those used to load it.
+func readTerminfoCache(name string) (ti *terminfo.Terminfo, ok bool) {
+	path, ok := terminfoCacheFile(name)
+	if !ok || !cpaths.IsFile(path) {
+		return nil, false
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	var entry cTerminfoCacheEntry
+	if err = json.Unmarshal(content, &entry); err != nil || entry.Version != terminfoCacheVersion || entry.Terminfo == nil {
+		return nil, false
+	}
+	if time.Since(entry.Created) > TerminfoCacheMaxAge {
+		return nil, false
+	}
+	if modified, found := terminfoModTime(name); found && modified.After(entry.Created) {
+		return nil, false
+	}
+	paths := TerminfoPaths()
+	if len(paths) != len(entry.Paths) {
+		return nil, false
+	}
+	for idx, dir := range paths {
+		if entry.Paths[idx] != dir {
+			return nil, false
+		}
+	}
+	return entry.Terminfo, true
+}
+
+// writeTerminfoCache saves the terminfo entry of the named terminal
+func writeTerminfoCache(name string, ti *terminfo.Terminfo) (err error) {
+	path, ok := terminfoCacheFile(name)
+	if !ok {
+		return nil
+	}
+	entry := cTerminfoCacheEntry{
+		Version:  terminfoCacheVersion,
+		Created:  time.Now(),
+		Paths:    TerminfoPaths(),
+		Terminfo: ti,
+	}
+	var content []byte
+	if content, err = json.Marshal(entry); err != nil {
+		return
+	}
+	if !cpaths.IsDir(TerminfoCacheDir) {
+		if err = cpaths.MakeDir(TerminfoCacheDir, 0700); err != nil {
+			return
+		}
+	}
+	// concurrent processes never see a partial entry
+	temp := path + ".tmp" + strconv.Itoa(os.Getpid())
+	if err = os.WriteFile(temp, content, 0600); err != nil {
+		return
+	}
+	if err = os.Rename(temp, path); err != nil {
+		_ = os.Remove(temp)
+	}
+	return
+}
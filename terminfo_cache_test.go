@@ -0,0 +1,103 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-curses/terminfo"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/env"
+)
+
+func TestTerminfoCache(t *testing.T) {
+	Convey("Terminfo cache", t, func() {
+		previous := TerminfoCacheDir
+		TerminfoCacheDir = t.TempDir()
+		defer func() { TerminfoCacheDir = previous }()
+
+		_, ok := readTerminfoCache("cdk-testing")
+		So(ok, ShouldBeFalse)
+		ti := &terminfo.Terminfo{Name: "cdk-testing", Colors: 256, Clear: "\x1b[H\x1b[2J"}
+		So(writeTerminfoCache("cdk-testing", ti), ShouldBeNil)
+		cached, ok := readTerminfoCache("cdk-testing")
+		So(ok, ShouldBeTrue)
+		So(cached, ShouldResemble, ti)
+
+		// invalid names are never cached
+		_, ok = terminfoCacheFile("../cdk-testing")
+		So(ok, ShouldBeFalse)
+		So(writeTerminfoCache("../cdk-testing", ti), ShouldBeNil)
+		_, err := os.Stat(filepath.Join(TerminfoCacheDir, "..", "cdk-testing.json"))
+		So(os.IsNotExist(err), ShouldBeTrue)
+
+		// entries loaded with other terminfo paths are invalid
+		dir := t.TempDir()
+		AddTerminfoPath(dir)
+		AddTerminfoPath(dir)
+		So(TerminfoPaths(), ShouldResemble, []string{dir})
+		_, ok = readTerminfoCache("cdk-testing")
+		So(ok, ShouldBeFalse)
+		So(writeTerminfoCache("cdk-testing", ti), ShouldBeNil)
+		_, ok = readTerminfoCache("cdk-testing")
+		So(ok, ShouldBeTrue)
+
+		// as are those older than the compiled entry
+		compiled := filepath.Join(dir, "c", "cdk-testing")
+		So(os.MkdirAll(filepath.Dir(compiled), 0700), ShouldBeNil)
+		So(os.WriteFile(compiled, []byte{}, 0600), ShouldBeNil)
+		future := time.Now().Add(time.Hour)
+		So(os.Chtimes(compiled, future, future), ShouldBeNil)
+		_, ok = readTerminfoCache("cdk-testing")
+		So(ok, ShouldBeFalse)
+		So(os.Remove(compiled), ShouldBeNil)
+		RemoveTerminfoPath(dir)
+		So(TerminfoPaths(), ShouldBeEmpty)
+
+		// and those older than the maximum age
+		So(writeTerminfoCache("cdk-testing", ti), ShouldBeNil)
+		maxAge := TerminfoCacheMaxAge
+		TerminfoCacheMaxAge = -time.Second
+		_, ok = readTerminfoCache("cdk-testing")
+		TerminfoCacheMaxAge = maxAge
+		So(ok, ShouldBeFalse)
+
+		So(ClearTerminfoCache(), ShouldBeNil)
+		_, err = os.Stat(TerminfoCacheDir)
+		So(os.IsNotExist(err), ShouldBeTrue)
+	})
+
+	Convey("Terminfo cache path", t, func() {
+		previous := env.Get("XDG_CACHE_HOME", "")
+		t.Setenv("XDG_CACHE_HOME", previous)
+		defer env.Set("XDG_CACHE_HOME", previous)
+		env.Set("XDG_CACHE_HOME", "/tmp/cdk-cache")
+		So(TerminfoCachePath(), ShouldEqual, filepath.Join("/tmp/cdk-cache", "cdk", "terminfo"))
+		env.Set("XDG_CACHE_HOME", "")
+		t.Setenv("HOME", "")
+		So(TerminfoCachePath(), ShouldEqual, "")
+	})
+
+	Convey("Inline terminfo definitions", t, func() {
+		RegisterTerminfo(&terminfo.Terminfo{Name: "cdk-inline", Aliases: []string{"cdk-inline-alias"}, Colors: 8})
+		ti, err := terminfo.LookupTerminfo("cdk-inline-alias")
+		So(err, ShouldBeNil)
+		So(ti.Name, ShouldEqual, "cdk-inline")
+	})
+}
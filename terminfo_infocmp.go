@@ -0,0 +1,351 @@
+//go:build !cdk_minimal && !nacl && !js && !zos && !plan9 && !windows && !android
+// +build !cdk_minimal,!nacl,!js,!zos,!plan9,!windows,!android
+
+// Copyright (c) 2022-2023  The Go-Curses Authors
+// Copyright 2019 The TCell Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/go-curses/terminfo"
+)
+
+var errInfocmpNotAddressable = errors.New("terminal not cursor addressable")
+
+// infocmpStrings maps the string capabilities read from infocmp to the fields
+// of terminfo.Terminfo they are stored in, the function keys are added by init
+var infocmpStrings = map[string]string{
+	"bel":   "Bell",
+	"clear": "Clear",
+	"smcup": "EnterCA",
+	"rmcup": "ExitCA",
+	"cnorm": "ShowCursor",
+	"civis": "HideCursor",
+	"sgr0":  "AttrOff",
+	"smul":  "Underline",
+	"bold":  "Bold",
+	"blink": "Blink",
+	"dim":   "Dim",
+	"sitm":  "Italic",
+	"rev":   "Reverse",
+	"smkx":  "EnterKeypad",
+	"rmkx":  "ExitKeypad",
+	"setaf": "SetFg",
+	"setab": "SetBg",
+	"cup":   "SetCursor",
+	"cub1":  "CursorBack1",
+	"cuu1":  "CursorUp1",
+	"kich1": "KeyInsert",
+	"kdch1": "KeyDelete",
+	"kbs":   "KeyBackspace",
+	"khome": "KeyHome",
+	"kend":  "KeyEnd",
+	"kcuu1": "KeyUp",
+	"kcud1": "KeyDown",
+	"kcuf1": "KeyRight",
+	"kcub1": "KeyLeft",
+	"knp":   "KeyPgDn",
+	"kpp":   "KeyPgUp",
+	"kcbt":  "KeyBacktab",
+	"kext":  "KeyExit",
+	"kcan":  "KeyCancel",
+	"kprt":  "KeyPrint",
+	"khlp":  "KeyHelp",
+	"kclr":  "KeyClear",
+	"acsc":  "AltChars",
+	"smacs": "EnterAcs",
+	"rmacs": "ExitAcs",
+	"enacs": "EnableAcs",
+	"kmous": "Mouse",
+	"kRIT":  "KeyShfRight",
+	"kLFT":  "KeyShfLeft",
+	"kHOM":  "KeyShfHome",
+	"kEND":  "KeyShfEnd",
+}
+
+func init() {
+	for i := 1; i <= 64; i++ {
+		infocmpStrings[fmt.Sprintf("kf%d", i)] = fmt.Sprintf("KeyF%d", i)
+	}
+}
+
+// infocmpCaps are the capabilities of a terminfo entry as listed by infocmp
+type infocmpCaps struct {
+	name    string
+	aliases []string
+	bools   map[string]bool
+	nums    map[string]int
+	strs    map[string]string
+}
+
+// loadInfocmpTerminfoFrom loads the terminfo entry for the term with infocmp,
+// searching the given directories before $TERMINFO_DIRS. The directories are
+// given to infocmp alone, the environment of the process is left untouched.
+func loadInfocmpTerminfoFrom(term string, dirs []string) (*terminfo.Terminfo, error) {
+	// an empty entry is where infocmp searches the system directories
+	search := append(append([]string{}, dirs...), os.Getenv("TERMINFO_DIRS"))
+	cmd := exec.Command("infocmp", "-1", term)
+	cmd.Env = append(os.Environ(), "TERMINFO_DIRS="+strings.Join(search, string(os.PathListSeparator)))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+	tc, err := parseInfocmp(output)
+	if err != nil {
+		return nil, err
+	}
+	return newInfocmpTerminfo(term, tc)
+}
+
+// parseInfocmp parses the output of "infocmp -1": comment lines, a header of
+// the names of the entry separated by "|" ending with a description, followed
+// by one capability per line, each indented with a tab and ending with a comma
+func parseInfocmp(output []byte) (tc *infocmpCaps, err error) {
+	tc = &infocmpCaps{
+		bools: make(map[string]bool),
+		nums:  make(map[string]int),
+		strs:  make(map[string]string),
+	}
+	header := true
+	scanner := bufio.NewScanner(bytes.NewReader(output))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if header {
+			if line == "" || strings.HasPrefix(line, "#") {
+				continue
+			}
+			names := strings.Split(strings.TrimSuffix(line, ","), "|")
+			tc.name = names[0]
+			if len(names) > 2 {
+				tc.aliases = names[1 : len(names)-1]
+			}
+			header = false
+			continue
+		}
+		if line == "" {
+			continue
+		}
+		if !strings.HasPrefix(line, "\t") || !strings.HasSuffix(line, ",") {
+			return nil, errors.New("malformed infocmp: " + line)
+		}
+		line = line[1 : len(line)-1]
+		if k := strings.SplitN(line, "=", 2); len(k) == 2 {
+			tc.strs[k[0]] = unescapeInfocmp(k[1])
+		} else if k := strings.SplitN(line, "#", 2); len(k) == 2 {
+			u, e := strconv.ParseUint(k[1], 0, 0)
+			if e != nil {
+				return nil, e
+			}
+			tc.nums[k[0]] = int(u)
+		} else {
+			tc.bools[line] = true
+		}
+	}
+	if header {
+		return nil, errors.New("malformed infocmp: no entry")
+	}
+	return tc, scanner.Err()
+}
+
+// unescapeInfocmp decodes the escapes of a string capability: \e and \E for
+// escape, \0 and octal values, the C escapes \n \r \t \b \f \s and
+// ^X for control characters
+func unescapeInfocmp(s string) string {
+	buf := &bytes.Buffer{}
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '^' && i+1 < len(s):
+			i++
+			buf.WriteByte(s[i] ^ 1<<6)
+		case c == '\\' && i+1 < len(s):
+			i++
+			switch c = s[i]; c {
+			case 'E', 'e':
+				buf.WriteByte(0x1b)
+			case '0', '1', '2', '3', '4', '5', '6', '7':
+				if i+2 < len(s) && s[i+1] >= '0' && s[i+1] <= '7' && s[i+2] >= '0' && s[i+2] <= '7' {
+					buf.WriteByte(((c - '0') * 64) + ((s[i+1] - '0') * 8) + (s[i+2] - '0'))
+					i = i + 2
+				} else if c == '0' {
+					buf.WriteByte(0)
+				}
+			case 'n':
+				buf.WriteByte('\n')
+			case 'r':
+				buf.WriteByte('\r')
+			case 't':
+				buf.WriteByte('\t')
+			case 'b':
+				buf.WriteByte('\b')
+			case 'f':
+				buf.WriteByte('\f')
+			case 's':
+				buf.WriteByte(' ')
+			default:
+				buf.WriteByte(c)
+			}
+		default:
+			buf.WriteByte(c)
+		}
+	}
+	return buf.String()
+}
+
+// newInfocmpTerminfo builds the terminfo.Terminfo for the capabilities the same
+// way the terminfo/dynamic package does
+func newInfocmpTerminfo(term string, tc *infocmpCaps) (*terminfo.Terminfo, error) {
+	t := &terminfo.Terminfo{Name: tc.name}
+	// alias records only name the terminal they are an alias of
+	if t.Name != term {
+		return t, nil
+	}
+	t.Aliases = tc.aliases
+	t.Colors = tc.nums["colors"]
+	t.Columns = tc.nums["cols"]
+	t.Lines = tc.nums["lines"]
+	fields := reflect.ValueOf(t).Elem()
+	for capability, field := range infocmpStrings {
+		fields.FieldByName(field).SetString(tc.strs[capability])
+	}
+
+	// Terminfo lacks descriptions for a bunch of modified keys,
+	// but modern XTerm and emulators often have them.  Let's add them,
+	// if the shifted right and left arrows are defined.
+	if t.KeyShfRight == "\x1b[1;2C" && t.KeyShfLeft == "\x1b[1;2D" {
+		t.Modifiers = terminfo.ModifiersXTerm
+
+		t.KeyShfUp = "\x1b[1;2A"
+		t.KeyShfDown = "\x1b[1;2B"
+		t.KeyMetaUp = "\x1b[1;9A"
+		t.KeyMetaDown = "\x1b[1;9B"
+		t.KeyMetaRight = "\x1b[1;9C"
+		t.KeyMetaLeft = "\x1b[1;9D"
+		t.KeyAltUp = "\x1b[1;3A"
+		t.KeyAltDown = "\x1b[1;3B"
+		t.KeyAltRight = "\x1b[1;3C"
+		t.KeyAltLeft = "\x1b[1;3D"
+		t.KeyCtrlUp = "\x1b[1;5A"
+		t.KeyCtrlDown = "\x1b[1;5B"
+		t.KeyCtrlRight = "\x1b[1;5C"
+		t.KeyCtrlLeft = "\x1b[1;5D"
+		t.KeyAltShfUp = "\x1b[1;4A"
+		t.KeyAltShfDown = "\x1b[1;4B"
+		t.KeyAltShfRight = "\x1b[1;4C"
+		t.KeyAltShfLeft = "\x1b[1;4D"
+
+		t.KeyMetaShfUp = "\x1b[1;10A"
+		t.KeyMetaShfDown = "\x1b[1;10B"
+		t.KeyMetaShfRight = "\x1b[1;10C"
+		t.KeyMetaShfLeft = "\x1b[1;10D"
+
+		t.KeyCtrlShfUp = "\x1b[1;6A"
+		t.KeyCtrlShfDown = "\x1b[1;6B"
+		t.KeyCtrlShfRight = "\x1b[1;6C"
+		t.KeyCtrlShfLeft = "\x1b[1;6D"
+
+		t.KeyShfPgUp = "\x1b[5;2~"
+		t.KeyShfPgDn = "\x1b[6;2~"
+	}
+	// And also for Home and End
+	if t.KeyShfHome == "\x1b[1;2H" && t.KeyShfEnd == "\x1b[1;2F" {
+		t.KeyCtrlHome = "\x1b[1;5H"
+		t.KeyCtrlEnd = "\x1b[1;5F"
+		t.KeyAltHome = "\x1b[1;9H"
+		t.KeyAltEnd = "\x1b[1;9F"
+		t.KeyCtrlShfHome = "\x1b[1;6H"
+		t.KeyCtrlShfEnd = "\x1b[1;6F"
+		t.KeyAltShfHome = "\x1b[1;4H"
+		t.KeyAltShfEnd = "\x1b[1;4F"
+		t.KeyMetaShfHome = "\x1b[1;10H"
+		t.KeyMetaShfEnd = "\x1b[1;10F"
+	}
+
+	// And the same thing for rxvt and workalikes (Eterm, aterm, etc.)
+	// It seems that urxvt at least send escaped as ALT prefix for these,
+	// although some places seem to indicate a separate ALT key sesquence.
+	if t.KeyShfRight == "\x1b[c" && t.KeyShfLeft == "\x1b[d" {
+		t.KeyShfUp = "\x1b[a"
+		t.KeyShfDown = "\x1b[b"
+		t.KeyCtrlUp = "\x1b[Oa"
+		t.KeyCtrlDown = "\x1b[Ob"
+		t.KeyCtrlRight = "\x1b[Oc"
+		t.KeyCtrlLeft = "\x1b[Od"
+	}
+	if t.KeyShfHome == "\x1b[7$" && t.KeyShfEnd == "\x1b[8$" {
+		t.KeyCtrlHome = "\x1b[7^"
+		t.KeyCtrlEnd = "\x1b[8^"
+	}
+
+	// Technically the RGB flag that is provided for xterm-direct is not
+	// quite right.  The problem is that the -direct flag that was introduced
+	// with ncurses 6.1 requires a parsing for the parameters that we lack.
+	// For this case we'll just assume it's XTerm compatible.  Someday this
+	// may be incorrect, but right now it is correct, and nobody uses it
+	// anyway.
+	if tc.bools["Tc"] {
+		// This presumes XTerm 24-bit true color.
+		t.TrueColor = true
+	} else if tc.bools["RGB"] {
+		// This is for xterm-direct, which uses a different scheme entirely.
+		// (ncurses went a very different direction from everyone else, and
+		// so it's unlikely anything is using this definition.)
+		t.TrueColor = true
+		t.SetBg = "\x1b[%?%p1%{8}%<%t4%p1%d%e%p1%{16}%<%t10%p1%{8}%-%d%e48;5;%p1%d%;m"
+		t.SetFg = "\x1b[%?%p1%{8}%<%t3%p1%d%e%p1%{16}%<%t9%p1%{8}%-%d%e38;5;%p1%d%;m"
+	}
+
+	// We only support colors in ANSI 8 or 256 color mode.
+	if t.Colors < 8 || t.SetFg == "" {
+		t.Colors = 0
+	}
+	if t.SetCursor == "" {
+		return nil, errInfocmpNotAddressable
+	}
+
+	// For padding, we lookup the pad char.  If that isn't present,
+	// and npc is *not* set, then we assume a null byte.
+	t.PadChar = tc.strs["pad"]
+	if t.PadChar == "" {
+		if !tc.bools["npc"] {
+			t.PadChar = "\u0000"
+		}
+	}
+
+	// For terminals that use "standard" SGR sequences, lets combine the
+	// foreground and background together.
+	if strings.HasPrefix(t.SetFg, "\x1b[") &&
+		strings.HasPrefix(t.SetBg, "\x1b[") &&
+		strings.HasSuffix(t.SetFg, "m") &&
+		strings.HasSuffix(t.SetBg, "m") {
+		fg := t.SetFg[:len(t.SetFg)-1]
+		r := regexp.MustCompile("%p1")
+		bg := r.ReplaceAllString(t.SetBg[2:], "%p2")
+		t.SetFgBg = fg + ";" + bg
+	}
+
+	return t, nil
+}
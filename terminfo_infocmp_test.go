@@ -0,0 +1,91 @@
+//go:build !cdk_minimal && !nacl && !js && !zos && !plan9 && !windows && !android
+// +build !cdk_minimal,!nacl,!js,!zos,!plan9,!windows,!android
+
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use file except in compliance with the License.
+// You may obtain a copy of the license at
+//
+//    http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+const testInfocmpSource = `#	Reconstructed via infocmp from file: /tmp/c/cdk-testing
+cdk-testing|cdk-testing-alias|cdk testing terminal,
+	am,
+	colors#256,
+	cols#80,
+	bel=^G,
+	clear=\E[H\E[2J,
+	cup=\E[%i%p1%d;%p2%dH,
+	kf1=\EOP,
+	kLFT=\E[1;2D,
+	kRIT=\E[1;2C,
+	setab=\E[4%p1%dm,
+	setaf=\E[3%p1%dm,
+`
+
+func TestInfocmpTerminfo(t *testing.T) {
+	Convey("Parsing infocmp", t, func() {
+		tc, err := parseInfocmp([]byte(testInfocmpSource))
+		So(err, ShouldBeNil)
+		So(tc.name, ShouldEqual, "cdk-testing")
+		So(tc.aliases, ShouldResemble, []string{"cdk-testing-alias"})
+		So(tc.bools["am"], ShouldBeTrue)
+		So(tc.nums["colors"], ShouldEqual, 256)
+		So(tc.strs["bel"], ShouldEqual, "\x07")
+		ti, err := newInfocmpTerminfo("cdk-testing", tc)
+		So(err, ShouldBeNil)
+		So(ti.Colors, ShouldEqual, 256)
+		So(ti.Columns, ShouldEqual, 80)
+		So(ti.Clear, ShouldEqual, "\x1b[H\x1b[2J")
+		So(ti.KeyF1, ShouldEqual, "\x1bOP")
+		So(ti.KeyCtrlUp, ShouldEqual, "\x1b[1;5A")
+		So(ti.SetFgBg, ShouldEqual, "\x1b[3%p1%d;4%p2%dm")
+		So(ti.PadChar, ShouldEqual, "\x00")
+		alias, err := newInfocmpTerminfo("cdk-testing-alias", tc)
+		So(err, ShouldBeNil)
+		So(alias.Name, ShouldEqual, "cdk-testing")
+		So(alias.Clear, ShouldEqual, "")
+		_, err = parseInfocmp([]byte("cdk-testing|cdk,\nbroken\n"))
+		So(err, ShouldNotBeNil)
+	})
+	Convey("Loading with terminfo paths", t, func() {
+		if _, err := exec.LookPath("tic"); err != nil {
+			SkipSo("tic is not installed")
+			return
+		}
+		if _, err := exec.LookPath("infocmp"); err != nil {
+			SkipSo("infocmp is not installed")
+			return
+		}
+		dir := t.TempDir()
+		source := filepath.Join(dir, "cdk-testing.src")
+		So(os.WriteFile(source, []byte(testInfocmpSource), 0600), ShouldBeNil)
+		So(exec.Command("tic", "-o", dir, source).Run(), ShouldBeNil)
+		previous, set := os.LookupEnv("TERMINFO_DIRS")
+		ti, err := loadInfocmpTerminfoFrom("cdk-testing", []string{dir})
+		So(err, ShouldBeNil)
+		So(ti.Name, ShouldEqual, "cdk-testing")
+		So(ti.Clear, ShouldEqual, "\x1b[H\x1b[2J")
+		current, stillSet := os.LookupEnv("TERMINFO_DIRS")
+		So(stillSet, ShouldEqual, set)
+		So(current, ShouldEqual, previous)
+	})
+}
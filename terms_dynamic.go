@@ -28,9 +28,35 @@ import (
 	// will be automatically included anyway.
 	"github.com/go-curses/terminfo"
 	"github.com/go-curses/terminfo/dynamic"
+
+	"github.com/go-curses/cdk/log"
 )
 
+// loadDynamicTerminfo returns the cached terminfo entry for the term, loading
+// and caching it with infocmp when not cached, see: TerminfoCacheDir
 func loadDynamicTerminfo(term string) (*terminfo.Terminfo, error) {
+	if ti, ok := readTerminfoCache(term); ok {
+		return ti, nil
+	}
+	ti, e := loadInfocmpTerminfo(term)
+	if e != nil {
+		return nil, e
+	}
+	if ti.Name != term {
+		// alias records only name the terminal they are an alias of
+		return ti, nil
+	} else if e = writeTerminfoCache(term, ti); e != nil {
+		log.WarnF("error caching terminfo: %v", e)
+	}
+	return ti, nil
+}
+
+// loadInfocmpTerminfo runs infocmp, searching the AddTerminfoPath directories
+// before $TERMINFO_DIRS when there are any
+func loadInfocmpTerminfo(term string) (*terminfo.Terminfo, error) {
+	if paths := TerminfoPaths(); len(paths) > 0 {
+		return loadInfocmpTerminfoFrom(term, paths)
+	}
 	ti, _, e := dynamic.LoadTerminfo(term)
 	if e != nil {
 		return nil, e
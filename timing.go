@@ -20,6 +20,7 @@ import (
 
 	"github.com/gofrs/uuid"
 
+	cid "github.com/go-curses/cdk/id"
 	"github.com/go-curses/cdk/lib/enums"
 	"github.com/go-curses/cdk/lib/sync"
 	"github.com/go-curses/cdk/log"
@@ -38,7 +39,7 @@ type timers struct {
 func (t *timers) Add(n *timer) (id uuid.UUID) {
 	t.Lock()
 	if n.id == uuid.Nil {
-		n.id, _ = uuid.NewV4()
+		n.id = cid.NewUUID()
 	}
 	t.timers[n.id] = n
 	t.Unlock()
@@ -0,0 +1,181 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"strconv"
+	"unicode"
+	"unicode/utf8"
+)
+
+var (
+	// DefaultUnicodeInputTrigger is the key sequence which starts the ISO 14755
+	// unicode input mode of a Display, see: Display.SetUnicodeInputTrigger
+	DefaultUnicodeInputTrigger = "<Ctrl><Shift>u"
+
+	// UnicodeInputMaxDigits is the maximum number of hexadecimal digits
+	// accepted in unicode input mode
+	UnicodeInputMaxDigits = 6
+)
+
+// UnicodeInputState describes the progress of a unicode input sequence and is
+// given to SignalUnicodeInput listeners so that a preview can be presented.
+type UnicodeInputState struct {
+	// Active is true while hex digits are being accepted
+	Active bool
+	// Digits are the hex digits entered so far
+	Digits string
+	// Committed is true when the sequence has been completed successfully
+	Committed bool
+	// Rune is the value of the Digits, if valid
+	Rune rune
+}
+
+// Preview returns the conventional "u1f600" presentation of the state
+func (s UnicodeInputState) Preview() string {
+	return "u" + s.Digits
+}
+
+func (s UnicodeInputState) String() string {
+	return fmt.Sprintf("{active=%v,digits=%q,committed=%v,rune=%q}", s.Active, s.Digits, s.Committed, s.Rune)
+}
+
+// cUnicodeInput is the compose-mode state machine used by CDisplay. The
+// trigger sequence starts accumulating hex digits, Enter or Space commits,
+// Backspace removes the last digit and Escape cancels. Any other key cancels
+// and is processed normally. The keys of a trigger sequence which is not
+// completed are replayed.
+type cUnicodeInput struct {
+	trigger  []KeyStroke
+	pending  int
+	buffered []*EventKey
+	replay   []*EventKey
+	active   bool
+	digits   []rune
+}
+
+func newUnicodeInput() (u *cUnicodeInput) {
+	u = new(cUnicodeInput)
+	u.trigger, _ = ParseKeySequence(DefaultUnicodeInputTrigger)
+	return
+}
+
+func (u *cUnicodeInput) setTrigger(accelerator string) (err error) {
+	if accelerator == "" {
+		u.trigger = nil
+		u.reset()
+		return
+	}
+	var trigger []KeyStroke
+	if trigger, err = ParseKeySequence(accelerator); err != nil {
+		return
+	}
+	u.trigger = trigger
+	u.reset()
+	return
+}
+
+func (u *cUnicodeInput) reset() {
+	u.pending = 0
+	u.buffered = nil
+	u.active = false
+	u.digits = nil
+}
+
+// takeReplay returns the keys of a trigger sequence which was not completed,
+// to be processed normally before the key which broke the sequence
+func (u *cUnicodeInput) takeReplay() (replay []*EventKey) {
+	replay, u.replay = u.replay, nil
+	return
+}
+
+func (u *cUnicodeInput) state() (state UnicodeInputState) {
+	state.Active = u.active
+	state.Digits = string(u.digits)
+	if v, err := strconv.ParseUint(state.Digits, 16, 32); err == nil {
+		if r := rune(v); utf8.ValidRune(r) {
+			state.Rune = r
+		}
+	}
+	return
+}
+
+// processKey returns consumed true when the event is part of a unicode input
+// sequence, with changed true when the state has been updated. The state is
+// Committed when a valid rune has been entered.
+func (u *cUnicodeInput) processKey(evt *EventKey) (state UnicodeInputState, consumed, changed bool) {
+	if len(u.trigger) == 0 {
+		return
+	}
+	if evt.Phase() == KeyRelease {
+		// releases are consumed only while the sequence is in progress
+		if u.pending > 0 {
+			u.buffered = append(u.buffered, evt)
+		}
+		return u.state(), u.active || u.pending > 0, false
+	}
+	if !u.active {
+		stroke := MakeKeyStroke(evt)
+		if u.pending > 0 && !stroke.Equals(u.trigger[u.pending]) {
+			// a broken trigger is replayed, the key may still start a new one
+			u.replay = append(u.replay, u.buffered...)
+			u.buffered = nil
+			u.pending = 0
+		}
+		if stroke.Equals(u.trigger[u.pending]) {
+			if u.pending++; u.pending >= len(u.trigger) {
+				u.pending = 0
+				u.buffered = nil
+				u.active = true
+				u.digits = nil
+				return u.state(), true, true
+			}
+			u.buffered = append(u.buffered, evt)
+			return u.state(), true, false
+		}
+		return
+	}
+	key, mods := evt.Key(), evt.Modifiers()
+	switch {
+	case key == KeyEnter || evt.Rune() == '\r' || evt.Rune() == ' ' || (key == KeySmallM && mods == ModCtrl):
+		// NewEventKey decodes KeyEnter as <Ctrl>m
+		state = u.state()
+		state.Active = false
+		state.Committed = state.Rune > 0
+		u.reset()
+		return state, true, true
+	case key == KeyRune:
+		if r := unicode.ToLower(evt.Rune()); (r >= '0' && r <= '9') || (r >= 'a' && r <= 'f') {
+			if len(u.digits) < UnicodeInputMaxDigits {
+				u.digits = append(u.digits, r)
+				return u.state(), true, true
+			}
+			return u.state(), true, false
+		}
+	case key == KeyBackspace || key == KeyBackspace2 || (key == KeySmallH && mods == ModCtrl):
+		// NewEventKey decodes KeyBackspace as <Ctrl>h
+		if len(u.digits) > 0 {
+			u.digits = u.digits[:len(u.digits)-1]
+		}
+		return u.state(), true, true
+	case key == KeyEscape:
+		u.reset()
+		return u.state(), true, true
+	}
+	// any other key cancels and is processed normally
+	u.reset()
+	return u.state(), false, true
+}
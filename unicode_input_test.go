@@ -0,0 +1,125 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	"github.com/go-curses/cdk/lib/enums"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnicodeInput(t *testing.T) {
+	Convey("Unicode input mode", t, func() {
+		u := newUnicodeInput()
+		trigger := NewEventKey(KeyRune, 'u', ModCtrl|ModShift)
+		state, consumed, changed := u.processKey(trigger)
+		So(consumed, ShouldBeTrue)
+		So(changed, ShouldBeTrue)
+		So(state.Active, ShouldBeTrue)
+		for _, r := range "1F60" {
+			_, consumed, _ = u.processKey(NewEventKey(KeyRune, r, ModNone))
+			So(consumed, ShouldBeTrue)
+		}
+		// x is not a hex digit, cancels and is processed normally
+		state, consumed, changed = u.processKey(NewEventKey(KeyRune, 'x', ModNone))
+		So(consumed, ShouldBeFalse)
+		So(changed, ShouldBeTrue)
+		So(state.Active, ShouldBeFalse)
+		_, _, _ = u.processKey(trigger)
+		for _, r := range "1f601" {
+			_, _, _ = u.processKey(NewEventKey(KeyRune, r, ModNone))
+		}
+		_, _, _ = u.processKey(NewEventKey(KeyBackspace2, 0, ModNone))
+		state, _, _ = u.processKey(NewEventKey(KeyRune, '0', ModNone))
+		So(state.Preview(), ShouldEqual, "u1f600")
+		state, consumed, _ = u.processKey(NewEventKey(KeyEnter, 0, ModNone))
+		So(consumed, ShouldBeTrue)
+		So(state.Committed, ShouldBeTrue)
+		So(state.Rune, ShouldEqual, '😀')
+		// escape cancels without committing
+		_, _, _ = u.processKey(trigger)
+		_, _, _ = u.processKey(NewEventKey(KeyRune, '4', ModNone))
+		state, consumed, _ = u.processKey(NewEventKey(KeyEscape, 0, ModNone))
+		So(consumed, ShouldBeTrue)
+		So(state.Committed, ShouldBeFalse)
+		So(state.Active, ShouldBeFalse)
+		// disabled trigger
+		So(u.setTrigger(""), ShouldBeNil)
+		_, consumed, _ = u.processKey(trigger)
+		So(consumed, ShouldBeFalse)
+		So(u.setTrigger("<Nope>u"), ShouldNotBeNil)
+	})
+	Convey("Unicode input trigger sequences", t, func() {
+		u := newUnicodeInput()
+		So(u.setTrigger("<Ctrl>x u"), ShouldBeNil)
+		ctrlX := NewEventKey(KeyRune, 'x', ModCtrl)
+		_, consumed, _ := u.processKey(ctrlX)
+		So(consumed, ShouldBeTrue)
+		So(u.takeReplay(), ShouldBeEmpty)
+		// a key breaking the trigger replays the keys before it
+		a := NewEventKey(KeyRune, 'a', ModNone)
+		_, consumed, _ = u.processKey(a)
+		So(consumed, ShouldBeFalse)
+		So(u.takeReplay(), ShouldResemble, []*EventKey{ctrlX})
+		So(u.takeReplay(), ShouldBeEmpty)
+		// and may start the trigger again
+		_, _, _ = u.processKey(ctrlX)
+		_, consumed, _ = u.processKey(ctrlX)
+		So(consumed, ShouldBeTrue)
+		So(u.takeReplay(), ShouldResemble, []*EventKey{ctrlX})
+		state, consumed, _ := u.processKey(NewEventKey(KeyRune, 'u', ModNone))
+		So(consumed, ShouldBeTrue)
+		So(state.Active, ShouldBeTrue)
+		So(u.takeReplay(), ShouldBeEmpty)
+	})
+	Convey("Display unicode input", t, WithDisplayManager(func(d Display) {
+		cancelled := false
+		d.Connect(SignalUnicodeInput, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			cancelled = !argv[1].(UnicodeInputState).Active
+			return enums.EVENT_PASS
+		})
+		So(d.SetUnicodeInputTrigger("<Alt>u"), ShouldBeNil)
+		So(d.GetUnicodeInputState().Active, ShouldBeFalse)
+		d.(*CDisplay).unicodeInput.processKey(NewEventKey(KeyRune, 'u', ModAlt))
+		So(d.GetUnicodeInputState().Active, ShouldBeTrue)
+		d.CancelUnicodeInput()
+		So(cancelled, ShouldBeTrue)
+		So(d.GetUnicodeInputState().Active, ShouldBeFalse)
+	}))
+	Convey("Display replays broken unicode input triggers", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		cd.Lock()
+		cd.started = true
+		cd.Unlock()
+		defer func() {
+			cd.Lock()
+			cd.started = false
+			cd.Unlock()
+		}()
+		var keys []rune
+		d.Connect(SignalEventKey, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			if e, ok := argv[1].(*EventKey); ok {
+				keys = append(keys, e.Rune())
+			}
+			return enums.EVENT_STOP
+		})
+		So(d.SetUnicodeInputTrigger("<Alt>x u"), ShouldBeNil)
+		So(d.ProcessEvent(NewEventKey(KeyRune, 'x', ModAlt)), ShouldEqual, enums.EVENT_STOP)
+		So(keys, ShouldBeEmpty)
+		So(d.ProcessEvent(NewEventKey(KeyRune, 'a', ModNone)), ShouldEqual, enums.EVENT_STOP)
+		So(keys, ShouldResemble, []rune{'x', 'a'})
+	}))
+}
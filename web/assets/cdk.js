@@ -0,0 +1,196 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// CDK.connect(element, url) renders the display served by a cdk/web screen
+// inside the element, which should be sized by the page, and sends the
+// keyboard, mouse, paste and resize events of the element back.
+var CDK = (function () {
+  "use strict";
+
+  // attribute bits, see: lib/paint/attr.go
+  var AttrBold = 1, AttrReverse = 4, AttrUnderline = 8,
+    AttrDim = 16, AttrItalic = 32, AttrStrike = 64;
+
+  var defaultFg = "#c0c0c0", defaultBg = "#000000";
+
+  function connect(element, url) {
+    var screen = document.createElement("pre");
+    screen.tabIndex = 0;
+    screen.style.cssText = "margin:0;font-family:monospace;line-height:1.2;" +
+      "overflow:hidden;cursor:default;outline:none;white-space:pre;" +
+      "color:" + defaultFg + ";background:" + defaultBg;
+    element.appendChild(screen);
+
+    var cols = 0, rows = 0, cells = [], cursor = null, last = null;
+    var socket = new WebSocket(url);
+
+    function send(msg) {
+      if (socket.readyState === WebSocket.OPEN) {
+        socket.send(JSON.stringify(msg));
+      }
+    }
+
+    function cellSize() {
+      var probe = document.createElement("span");
+      probe.textContent = "MMMMMMMMMM";
+      screen.appendChild(probe);
+      var rect = probe.getBoundingClientRect();
+      screen.removeChild(probe);
+      return { w: rect.width / 10 || 8, h: rect.height || 16 };
+    }
+
+    function layout(w, h) {
+      cols = w;
+      rows = h;
+      cells = [];
+      screen.textContent = "";
+      for (var y = 0; y < h; y++) {
+        var row = document.createElement("div");
+        for (var x = 0; x < w; x++) {
+          var span = document.createElement("span");
+          span.textContent = " ";
+          row.appendChild(span);
+          cells.push(span);
+        }
+        screen.appendChild(row);
+      }
+      cursor = null;
+    }
+
+    function paint(span, cell) {
+      var fg = cell[1] || defaultFg, bg = cell[2] || defaultBg, attrs = cell[3];
+      if (attrs & AttrReverse) {
+        var swap = fg;
+        fg = bg;
+        bg = swap;
+      }
+      var decoration = [];
+      if (attrs & AttrUnderline) decoration.push("underline");
+      if (attrs & AttrStrike) decoration.push("line-through");
+      span.textContent = cell[0] === "\u0000" ? " " : cell[0];
+      span.style.color = fg;
+      span.style.backgroundColor = bg;
+      span.style.fontWeight = attrs & AttrBold ? "bold" : "";
+      span.style.fontStyle = attrs & AttrItalic ? "italic" : "";
+      span.style.opacity = attrs & AttrDim ? "0.6" : "";
+      span.style.textDecoration = decoration.join(" ");
+    }
+
+    function draw(frame) {
+      if (frame.w !== cols || frame.h !== rows) {
+        layout(frame.w, frame.h);
+      }
+      if (cursor) {
+        cursor.style.outline = "";
+      }
+      frame.spans.forEach(function (span) {
+        span.cells.forEach(function (cell, i) {
+          var target = cells[span.y * cols + span.x + i];
+          if (target) {
+            paint(target, cell);
+          }
+        });
+      });
+      cursor = null;
+      if (frame.cursor.visible) {
+        cursor = cells[frame.cursor.y * cols + frame.cursor.x] || null;
+        if (cursor) {
+          cursor.style.outline = "1px solid " + defaultFg;
+        }
+      }
+    }
+
+    function resize() {
+      var size = cellSize();
+      var w = Math.max(1, Math.floor(element.clientWidth / size.w));
+      var h = Math.max(1, Math.floor(element.clientHeight / size.h));
+      send({ t: "resize", w: w, h: h });
+    }
+
+    function position(ev) {
+      var rect = screen.getBoundingClientRect(), size = cellSize();
+      return {
+        x: Math.min(cols - 1, Math.max(0, Math.floor((ev.clientX - rect.left) / size.w))),
+        y: Math.min(rows - 1, Math.max(0, Math.floor((ev.clientY - rect.top) / size.h)))
+      };
+    }
+
+    function modifiers(msg, ev) {
+      msg.shift = ev.shiftKey;
+      msg.ctrl = ev.ctrlKey;
+      msg.alt = ev.altKey;
+      msg.meta = ev.metaKey;
+      return msg;
+    }
+
+    function mouse(ev, buttons, wheel) {
+      var p = position(ev);
+      send(modifiers({ t: "mouse", x: p.x, y: p.y, buttons: buttons, wheel: wheel }, ev));
+    }
+
+    socket.onopen = resize;
+    socket.onmessage = function (ev) {
+      var msg = JSON.parse(ev.data);
+      if (msg.t === "frame") {
+        draw(msg);
+      } else if (msg.t === "beep") {
+        screen.style.filter = "invert(1)";
+        setTimeout(function () { screen.style.filter = ""; }, 100);
+      }
+    };
+    socket.onclose = function () {
+      screen.style.opacity = "0.5";
+    };
+
+    window.addEventListener("resize", resize);
+    screen.addEventListener("keydown", function (ev) {
+      if (ev.isComposing || ((ev.ctrlKey || ev.metaKey) && ev.key === "v")) {
+        return;
+      }
+      var text = Array.from(ev.key).length === 1 ? ev.key : "";
+      send(modifiers({ t: "key", key: ev.key, text: text }, ev));
+      ev.preventDefault();
+    });
+    screen.addEventListener("paste", function (ev) {
+      send({ t: "paste", text: ev.clipboardData.getData("text/plain") });
+      ev.preventDefault();
+    });
+    screen.addEventListener("mousedown", function (ev) {
+      screen.focus();
+      mouse(ev, ev.buttons, 0);
+      ev.preventDefault();
+    });
+    screen.addEventListener("mouseup", function (ev) { mouse(ev, ev.buttons, 0); });
+    screen.addEventListener("mousemove", function (ev) {
+      // only report moves between cells
+      var p = position(ev);
+      if (!last || last.x !== p.x || last.y !== p.y) {
+        last = p;
+        mouse(ev, ev.buttons, 0);
+      }
+    });
+    screen.addEventListener("wheel", function (ev) {
+      mouse(ev, ev.buttons, ev.deltaY < 0 ? -1 : 1);
+      ev.preventDefault();
+    });
+    screen.addEventListener("contextmenu", function (ev) { ev.preventDefault(); });
+    screen.focus();
+
+    return {
+      close: function () { socket.close(); }
+    };
+  }
+
+  return { connect: connect };
+})();
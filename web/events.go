@@ -0,0 +1,148 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"github.com/go-curses/cdk"
+)
+
+// webKeys maps the KeyboardEvent.key values of browsers to named keys
+var webKeys = map[string]cdk.Key{
+	"ArrowUp":    cdk.KeyUp,
+	"ArrowDown":  cdk.KeyDown,
+	"ArrowLeft":  cdk.KeyLeft,
+	"ArrowRight": cdk.KeyRight,
+	"PageUp":     cdk.KeyPgUp,
+	"PageDown":   cdk.KeyPgDn,
+	"Home":       cdk.KeyHome,
+	"End":        cdk.KeyEnd,
+	"Insert":     cdk.KeyInsert,
+	"Delete":     cdk.KeyDelete,
+	"Help":       cdk.KeyHelp,
+	"Clear":      cdk.KeyClear,
+	"Pause":      cdk.KeyPause,
+	"F1":         cdk.KeyF1,
+	"F2":         cdk.KeyF2,
+	"F3":         cdk.KeyF3,
+	"F4":         cdk.KeyF4,
+	"F5":         cdk.KeyF5,
+	"F6":         cdk.KeyF6,
+	"F7":         cdk.KeyF7,
+	"F8":         cdk.KeyF8,
+	"F9":         cdk.KeyF9,
+	"F10":        cdk.KeyF10,
+	"F11":        cdk.KeyF11,
+	"F12":        cdk.KeyF12,
+}
+
+// webRunes maps the KeyboardEvent.key values of browsers to the control
+// characters a terminal would send for them
+var webRunes = map[string]rune{
+	"Enter":     '\r',
+	"Tab":       '\t',
+	"Escape":    0x1b,
+	"Backspace": 0x7f,
+}
+
+// webEvent is a message from the browser
+type webEvent struct {
+	Type    string `json:"t"`
+	Key     string `json:"key"`
+	Text    string `json:"text"`
+	X       int    `json:"x"`
+	Y       int    `json:"y"`
+	W       int    `json:"w"`
+	H       int    `json:"h"`
+	Buttons int    `json:"buttons"`
+	Wheel   int    `json:"wheel"`
+	Shift   bool   `json:"shift"`
+	Ctrl    bool   `json:"ctrl"`
+	Alt     bool   `json:"alt"`
+	Meta    bool   `json:"meta"`
+}
+
+func (e webEvent) modifiers() (mod cdk.ModMask) {
+	if e.Shift {
+		mod |= cdk.ModShift
+	}
+	if e.Ctrl {
+		mod |= cdk.ModCtrl
+	}
+	if e.Alt {
+		mod |= cdk.ModAlt
+	}
+	if e.Meta {
+		mod |= cdk.ModMeta
+	}
+	return
+}
+
+// events returns the cdk events for the message, which may be none for keys
+// which are not understood
+func (e webEvent) events() (events []cdk.Event) {
+	switch e.Type {
+	case "key":
+		if ev := e.keyEvent(); ev != nil {
+			events = append(events, ev)
+		}
+	case "mouse":
+		// browsers number the buttons as cdk does: primary, secondary, middle
+		buttons := cdk.ButtonMask(e.Buttons) & (cdk.Button1 | cdk.Button2 | cdk.Button3)
+		switch {
+		case e.Wheel < 0:
+			buttons |= cdk.WheelUp
+		case e.Wheel > 0:
+			buttons |= cdk.WheelDown
+		}
+		events = append(events, cdk.NewEventMouse(e.X, e.Y, buttons, e.modifiers()))
+	case "resize":
+		if validSize(e.W, e.H) {
+			events = append(events, cdk.NewEventResize(e.W, e.H))
+		}
+	case "paste":
+		events = append(events, cdk.NewEventPaste(true))
+		for _, r := range e.Text {
+			if r == '\n' {
+				r = '\r'
+			}
+			events = append(events, cdk.NewEventKey(cdk.KeyRune, r, cdk.ModNone))
+		}
+		events = append(events, cdk.NewEventPaste(false))
+	}
+	return
+}
+
+func (e webEvent) keyEvent() *cdk.EventKey {
+	mod := e.modifiers()
+	if key, ok := webKeys[e.Key]; ok {
+		return cdk.NewEventKey(key, 0, mod)
+	}
+	if r, ok := webRunes[e.Key]; ok {
+		if r == '\t' && e.Shift {
+			return cdk.NewEventKey(cdk.KeyBacktab, 0, mod&^cdk.ModShift)
+		}
+		return cdk.NewEventKey(cdk.KeyRune, r, mod)
+	}
+	runes := []rune(e.Text)
+	if len(runes) != 1 {
+		return nil
+	}
+	r := runes[0]
+	if e.Ctrl && r >= '@' && r <= '~' {
+		// control characters as a terminal would send them
+		return cdk.NewEventKey(cdk.KeyRune, r&0x1f, mod&^cdk.ModShift)
+	}
+	return cdk.NewEventKey(cdk.KeyRune, r, mod&^cdk.ModShift)
+}
@@ -0,0 +1,434 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package web provides a cdk.Screen which serves the Display to a browser.
+// The screen listens for HTTP requests, serves a page with a small JavaScript
+// renderer and exchanges JSON messages with it over a WebSocket: frames of
+// the cells which changed are sent to the browser and keyboard, mouse, paste
+// and resize events are sent back.
+//
+// Importing the package registers the "web" screen backend, so that a Display
+// created with a tty path of "web:127.0.0.1:8080" serves itself on that
+// address when captured and stops serving when the screen is closed. An
+// address without a host, such as ":8080", listens on the loopback interface
+// only.
+//
+// Browsers must present the token of the screen to connect, the page is opened
+// as "http://127.0.0.1:8080/?token=..." and the URL is logged when the screen
+// starts listening. SetAuthenticator replaces the token check with one of the
+// application's own.
+package web
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/sync"
+	"github.com/go-curses/cdk/log"
+)
+
+// BackendName is the name of the screen backend registered by this package
+const BackendName = "web"
+
+// TokenParam is the query parameter browsers present the token of the screen
+// with, both when opening the page and when connecting the websocket
+const TokenParam = "token"
+
+// MaxScreenSize is the largest width or height of a screen accepted from a
+// browser, larger resizes are ignored
+const MaxScreenSize = 4096
+
+// Authenticator reports whether the websocket request of a browser may
+// connect to the screen
+type Authenticator func(r *http.Request) bool
+
+//go:embed assets
+var assets embed.FS
+
+func init() {
+	cdk.RegisterScreenBackend(BackendName, func(address string) (cdk.Screen, error) {
+		return NewWebScreen(address), nil
+	})
+}
+
+// Assets returns the files served to the browser, index.html and cdk.js, for
+// embedding the renderer in pages served by the application itself
+func Assets() fs.FS {
+	sub, _ := fs.Sub(assets, "assets")
+	return sub
+}
+
+// WebScreen is a cdk.Screen which is drawn by a browser. Drawing happens on
+// an embedded cdk.OffScreen and each Show sends the cells which changed to the
+// connected browser. An authenticated browser connecting replaces the
+// previous one.
+type WebScreen interface {
+	cdk.OffScreen
+
+	// Addr returns the address being listened on, nil until initialized
+	Addr() net.Addr
+
+	// Token returns the token browsers must present to connect
+	Token() string
+
+	// SetToken replaces the randomly generated token of the screen
+	SetToken(token string)
+
+	// SetAuthenticator replaces the token check with the given function, nil
+	// restores the token check
+	SetAuthenticator(fn Authenticator)
+
+	// Handler returns the http.Handler serving the renderer page at "/",
+	// the renderer script at "/cdk.js" and the websocket at "/ws"
+	Handler() http.Handler
+}
+
+var _ WebScreen = (*CWebScreen)(nil)
+
+type CWebScreen struct {
+	cdk.OffScreen
+
+	address  string
+	token    string
+	auth     Authenticator
+	listener net.Listener
+	server   *http.Server
+	conn     *wsConn
+	sent     []webCell
+	sentW    int
+	sentH    int
+	cursor   webCursor
+	lock     sync.Mutex
+}
+
+// NewWebScreen returns a WebScreen which listens on the given TCP address
+// once initialized. An empty address only serves through Handler and an
+// address without a host listens on the loopback interface.
+func NewWebScreen(address string) WebScreen {
+	return &CWebScreen{
+		OffScreen: cdk.NewOffScreen("UTF-8"),
+		address:   loopbackAddress(address),
+		token:     newToken(),
+	}
+}
+
+// loopbackAddress returns the address with the loopback interface as the host
+// when the address has none, instead of listening on every interface
+func loopbackAddress(address string) string {
+	if host, port, err := net.SplitHostPort(address); err == nil && host == "" {
+		return net.JoinHostPort("127.0.0.1", port)
+	}
+	return address
+}
+
+func newToken() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		log.Error(err)
+	}
+	return hex.EncodeToString(b[:])
+}
+
+func (s *CWebScreen) Addr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.listener != nil {
+		return s.listener.Addr()
+	}
+	return nil
+}
+
+func (s *CWebScreen) Token() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.token
+}
+
+func (s *CWebScreen) SetToken(token string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.token = token
+}
+
+func (s *CWebScreen) SetAuthenticator(fn Authenticator) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.auth = fn
+}
+
+// authenticate reports whether the request may connect, using the
+// authenticator when set and the token of the screen otherwise. An empty
+// token refuses every browser.
+func (s *CWebScreen) authenticate(r *http.Request) bool {
+	s.lock.Lock()
+	auth, token := s.auth, s.token
+	s.lock.Unlock()
+	if auth != nil {
+		return auth(r)
+	}
+	given := r.URL.Query().Get(TokenParam)
+	return token != "" && subtle.ConstantTimeCompare([]byte(given), []byte(token)) == 1
+}
+
+func (s *CWebScreen) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", http.FileServer(http.FS(Assets())))
+	mux.HandleFunc("/ws", s.serveSocket)
+	return mux
+}
+
+func (s *CWebScreen) InitWithFilePath(_ string) error {
+	return s.Init()
+}
+
+func (s *CWebScreen) InitWithFileHandle(_ *os.File) error {
+	return s.Init()
+}
+
+// Init starts listening for browsers on the address of the screen
+func (s *CWebScreen) Init() (err error) {
+	if err = s.OffScreen.Init(); err != nil {
+		return
+	}
+	if s.address == "" {
+		return
+	}
+	var listener net.Listener
+	if listener, err = net.Listen("tcp", s.address); err != nil {
+		return fmt.Errorf("error listening for browsers: %w", err)
+	}
+	server := &http.Server{Handler: s.Handler()}
+	s.lock.Lock()
+	s.listener, s.server = listener, server
+	if s.auth == nil {
+		log.InfoF("serving display on http://%v/?%v=%v", listener.Addr(), TokenParam, s.token)
+	}
+	s.lock.Unlock()
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err)
+		}
+	}()
+	return
+}
+
+func (s *CWebScreen) serveSocket(w http.ResponseWriter, r *http.Request) {
+	if !s.authenticate(r) {
+		log.DebugF("websocket refused from %v: %v", r.RemoteAddr, ErrUnauthorized)
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	conn, err := upgrade(w, r)
+	if err != nil {
+		log.DebugF("websocket refused from %v: %v", r.RemoteAddr, err)
+		return
+	}
+	s.lock.Lock()
+	if s.conn != nil {
+		s.conn.close()
+	}
+	s.conn = conn
+	s.sent = nil
+	s.lock.Unlock()
+	s.sendFrame(true)
+
+	defer func() {
+		s.lock.Lock()
+		if s.conn == conn {
+			s.conn = nil
+		}
+		s.lock.Unlock()
+		_ = conn.conn.Close()
+	}()
+	for {
+		op, message, err := conn.readMessage()
+		if err != nil {
+			return
+		}
+		if op != opText {
+			continue
+		}
+		var msg webEvent
+		if err = json.Unmarshal(message, &msg); err != nil {
+			log.DebugF("malformed message from %v: %v", r.RemoteAddr, err)
+			continue
+		}
+		if msg.Type == "resize" && validSize(msg.W, msg.H) {
+			s.OffScreen.SetSize(msg.W, msg.H)
+		}
+		for _, ev := range msg.events() {
+			_ = s.OffScreen.PostEvent(ev)
+		}
+	}
+}
+
+// validSize returns true if the screen size is within 1 and MaxScreenSize in
+// both dimensions
+func validSize(w, h int) bool {
+	return w > 0 && h > 0 && w <= MaxScreenSize && h <= MaxScreenSize
+}
+
+// Show draws the screen and sends the cells which changed to the browser
+func (s *CWebScreen) Show() {
+	s.OffScreen.Show()
+	s.sendFrame(false)
+}
+
+// Sync draws the screen and sends every cell to the browser
+func (s *CWebScreen) Sync() {
+	s.OffScreen.Sync()
+	s.sendFrame(true)
+}
+
+func (s *CWebScreen) sendFrame(full bool) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn == nil {
+		return
+	}
+	w, h := s.OffScreen.Size()
+	if full || s.sent == nil || w != s.sentW || h != s.sentH {
+		full = true
+		s.sent = make([]webCell, w*h)
+		s.sentW, s.sentH = w, h
+	}
+	f := webFrame{Type: "frame", W: w, H: h, Full: full, Spans: []webSpan{}}
+	for y := 0; y < h; y++ {
+		var run *webSpan
+		for x := 0; x < w; x++ {
+			mainc, combc, style, _ := s.OffScreen.GetContent(x, y)
+			c := newWebCell(mainc, combc, style)
+			idx := (y * w) + x
+			if !full && c == s.sent[idx] {
+				run = nil
+				continue
+			}
+			s.sent[idx] = c
+			if run == nil {
+				f.Spans = append(f.Spans, webSpan{X: x, Y: y})
+				run = &f.Spans[len(f.Spans)-1]
+			}
+			run.Cells = append(run.Cells, c)
+		}
+	}
+	f.Cursor.X, f.Cursor.Y, f.Cursor.Visible = s.OffScreen.GetCursor()
+	if !full && len(f.Spans) == 0 && f.Cursor == s.cursor {
+		return
+	}
+	s.cursor = f.Cursor
+	s.send(f)
+}
+
+// send writes the message to the browser, dropping the browser on failure,
+// the screen lock must be held
+func (s *CWebScreen) send(msg interface{}) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		log.Error(err)
+		return
+	}
+	if err = s.conn.writeMessage(opText, data); err != nil {
+		s.conn.close()
+		s.conn = nil
+	}
+}
+
+// Beep asks the browser to flash the screen
+func (s *CWebScreen) Beep() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.conn != nil {
+		s.send(map[string]string{"t": "beep"})
+	}
+	return nil
+}
+
+// Close disconnects the browser and stops listening
+func (s *CWebScreen) Close() {
+	s.lock.Lock()
+	if s.conn != nil {
+		s.conn.close()
+		s.conn = nil
+	}
+	server := s.server
+	s.server, s.listener = nil, nil
+	s.lock.Unlock()
+	if server != nil {
+		_ = server.Close()
+	}
+	s.OffScreen.Close()
+}
+
+// webCell is a cell as sent to the browser: the text, the foreground and
+// background as CSS colors, empty for the default, and the attributes
+type webCell struct {
+	text  string
+	fg    string
+	bg    string
+	attrs paint.AttrMask
+}
+
+func newWebCell(mainc rune, combc []rune, style paint.Style) webCell {
+	fg, bg, attrs := style.Decompose()
+	return webCell{
+		text:  string(append([]rune{mainc}, combc...)),
+		fg:    cssColor(fg),
+		bg:    cssColor(bg),
+		attrs: attrs,
+	}
+}
+
+// MarshalJSON encodes the cell as an array, keeping frames compact
+func (c webCell) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{c.text, c.fg, c.bg, int(c.attrs)})
+}
+
+func cssColor(c paint.Color) string {
+	if v := c.Hex(); v >= 0 {
+		return fmt.Sprintf("#%06x", v)
+	}
+	return ""
+}
+
+type webSpan struct {
+	X     int       `json:"x"`
+	Y     int       `json:"y"`
+	Cells []webCell `json:"cells"`
+}
+
+type webCursor struct {
+	X       int  `json:"x"`
+	Y       int  `json:"y"`
+	Visible bool `json:"visible"`
+}
+
+type webFrame struct {
+	Type   string    `json:"t"`
+	W      int       `json:"w"`
+	H      int       `json:"h"`
+	Full   bool      `json:"full"`
+	Cursor webCursor `json:"cursor"`
+	Spans  []webSpan `json:"spans"`
+}
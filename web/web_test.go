@@ -0,0 +1,274 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk"
+	"github.com/go-curses/cdk/lib/paint"
+)
+
+// testBrowser is the client side of a websocket, as a browser would speak it
+type testBrowser struct {
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func dialTestBrowser(addr, token, origin string) (b *testBrowser, status string, err error) {
+	var conn net.Conn
+	if conn, err = net.Dial("tcp", addr); err != nil {
+		return
+	}
+	request := "GET /ws?" + TokenParam + "=" + token + " HTTP/1.1\r\nHost: " + addr + "\r\n" +
+		"Upgrade: websocket\r\nConnection: keep-alive, Upgrade\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\nSec-WebSocket-Version: 13\r\n"
+	if origin != "" {
+		request += "Origin: " + origin + "\r\n"
+	}
+	if _, err = conn.Write([]byte(request + "\r\n")); err != nil {
+		return
+	}
+	b = &testBrowser{conn: conn, r: bufio.NewReader(conn)}
+	if status, err = b.r.ReadString('\n'); err != nil {
+		return
+	}
+	for {
+		var line string
+		if line, err = b.r.ReadString('\n'); err != nil || line == "\r\n" {
+			return
+		}
+		status += line
+	}
+}
+
+func (b *testBrowser) send(msg interface{}) error {
+	payload, _ := json.Marshal(msg)
+	mask := []byte{1, 2, 3, 4}
+	frame := []byte{0x80 | opText, 0x80 | 126}
+	frame = binary.BigEndian.AppendUint16(frame, uint16(len(payload)))
+	frame = append(frame, mask...)
+	for i, c := range payload {
+		frame = append(frame, c^mask[i%4])
+	}
+	_, err := b.conn.Write(frame)
+	return err
+}
+
+func (b *testBrowser) receive(msg interface{}) (err error) {
+	_ = b.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var head [2]byte
+	if _, err = io.ReadFull(b.r, head[:]); err != nil {
+		return
+	}
+	size := int(head[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		_, err = io.ReadFull(b.r, ext[:])
+		size = int(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		_, err = io.ReadFull(b.r, ext[:])
+		size = int(binary.BigEndian.Uint64(ext[:]))
+	}
+	payload := make([]byte, size)
+	if _, err = io.ReadFull(b.r, payload); err != nil {
+		return
+	}
+	return json.Unmarshal(payload, msg)
+}
+
+type testFrame struct {
+	W      int       `json:"w"`
+	H      int       `json:"h"`
+	Full   bool      `json:"full"`
+	Cursor webCursor `json:"cursor"`
+	Spans  []struct {
+		X     int             `json:"x"`
+		Y     int             `json:"y"`
+		Cells [][]interface{} `json:"cells"`
+	} `json:"spans"`
+}
+
+func nextEvent(s cdk.Screen) cdk.Event {
+	select {
+	case ev := <-s.PollEventChan():
+		return ev
+	case <-time.After(2 * time.Second):
+		return nil
+	}
+}
+
+func TestWebScreen(t *testing.T) {
+	Convey("Web screen", t, func() {
+		screen := NewWebScreen("127.0.0.1:0")
+		So(screen.Init(), ShouldBeNil)
+		defer screen.Close()
+		addr := screen.Addr().String()
+
+		Convey("is registered as a screen backend", func() {
+			backend, address, ok := cdk.LookupScreenBackend("web:127.0.0.1:0")
+			So(ok, ShouldBeTrue)
+			So(address, ShouldEqual, "127.0.0.1:0")
+			s, err := backend(address)
+			So(err, ShouldBeNil)
+			So(s, ShouldHaveSameTypeAs, screen)
+			d := cdk.NewDisplay("web", "web:127.0.0.1:0")
+			So(d.CaptureDisplay(), ShouldBeNil)
+			So(d.Screen(), ShouldHaveSameTypeAs, screen)
+			So(d.Screen().(WebScreen).Addr(), ShouldNotBeNil)
+			d.Screen().Close()
+		})
+		Convey("serves the renderer", func() {
+			res, err := http.Get("http://" + addr + "/")
+			So(err, ShouldBeNil)
+			body, _ := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			So(string(body), ShouldContainSubstring, "CDK.connect")
+			res, err = http.Get("http://" + addr + "/cdk.js")
+			So(err, ShouldBeNil)
+			body, _ = io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			So(string(body), ShouldContainSubstring, "function connect")
+		})
+		Convey("refuses other origins", func() {
+			b, status, err := dialTestBrowser(addr, screen.Token(), "http://example.com")
+			So(err, ShouldBeNil)
+			defer func() { _ = b.conn.Close() }()
+			So(status, ShouldStartWith, "HTTP/1.1 403")
+		})
+		Convey("listens on loopback without a host", func() {
+			s := NewWebScreen(":0")
+			So(s.Init(), ShouldBeNil)
+			defer s.Close()
+			So(s.Addr().(*net.TCPAddr).IP.IsLoopback(), ShouldBeTrue)
+		})
+		Convey("refuses browsers without the token", func() {
+			So(screen.Token(), ShouldHaveLength, 32)
+			b, status, err := dialTestBrowser(addr, "", "http://"+addr)
+			So(err, ShouldBeNil)
+			_ = b.conn.Close()
+			So(status, ShouldStartWith, "HTTP/1.1 401")
+			b, status, err = dialTestBrowser(addr, "wrong", "http://"+addr)
+			So(err, ShouldBeNil)
+			_ = b.conn.Close()
+			So(status, ShouldStartWith, "HTTP/1.1 401")
+			screen.SetToken("")
+			b, status, err = dialTestBrowser(addr, "", "http://"+addr)
+			So(err, ShouldBeNil)
+			_ = b.conn.Close()
+			So(status, ShouldStartWith, "HTTP/1.1 401")
+		})
+		Convey("authenticates with the authenticator when set", func() {
+			screen.SetAuthenticator(func(r *http.Request) bool {
+				return r.URL.Query().Get(TokenParam) == "custom"
+			})
+			b, status, err := dialTestBrowser(addr, screen.Token(), "http://"+addr)
+			So(err, ShouldBeNil)
+			_ = b.conn.Close()
+			So(status, ShouldStartWith, "HTTP/1.1 401")
+			b, status, err = dialTestBrowser(addr, "custom", "http://"+addr)
+			So(err, ShouldBeNil)
+			_ = b.conn.Close()
+			So(status, ShouldStartWith, "HTTP/1.1 101")
+		})
+		Convey("exchanges frames and events with a browser", func() {
+			b, status, err := dialTestBrowser(addr, screen.Token(), "http://"+addr)
+			So(err, ShouldBeNil)
+			defer func() { _ = b.conn.Close() }()
+			So(status, ShouldStartWith, "HTTP/1.1 101")
+			So(status, ShouldContainSubstring, "Sec-WebSocket-Accept: s3pPLMBiTxaQ9kYGzzhZRbK+xOo=")
+
+			var f testFrame
+			So(b.receive(&f), ShouldBeNil)
+			So(f.Full, ShouldBeTrue)
+			So(f.W, ShouldEqual, 80)
+
+			So(b.send(map[string]interface{}{"t": "resize", "w": 100000, "h": 100000}), ShouldBeNil)
+			So(b.send(map[string]interface{}{"t": "resize", "w": MaxScreenSize + 1, "h": 3}), ShouldBeNil)
+			So(b.send(map[string]interface{}{"t": "resize", "w": 12, "h": 3}), ShouldBeNil)
+			ev, ok := nextEvent(screen).(*cdk.EventResize)
+			So(ok, ShouldBeTrue)
+			w, h := ev.Size()
+			So(w, ShouldEqual, 12)
+			So(h, ShouldEqual, 3)
+
+			screen.Show()
+			f = testFrame{}
+			So(b.receive(&f), ShouldBeNil)
+			So(f.Full, ShouldBeTrue)
+			So(f.W, ShouldEqual, 12)
+
+			screen.SetContent(2, 1, 'x', nil, paint.StyleDefault.Foreground(paint.ColorRed).Attributes(paint.AttrBold))
+			screen.ShowCursor(3, 1)
+			screen.Show()
+			f = testFrame{}
+			So(b.receive(&f), ShouldBeNil)
+			So(f.Full, ShouldBeFalse)
+			So(f.Spans, ShouldHaveLength, 1)
+			So(f.Spans[0].X, ShouldEqual, 2)
+			So(f.Spans[0].Cells, ShouldResemble, [][]interface{}{{"x", "#ff0000", "", float64(paint.AttrBold)}})
+			So(f.Cursor, ShouldResemble, webCursor{X: 3, Y: 1, Visible: true})
+
+			So(b.send(map[string]interface{}{"t": "key", "key": "c", "text": "c", "ctrl": true}), ShouldBeNil)
+			key, ok := nextEvent(screen).(*cdk.EventKey)
+			So(ok, ShouldBeTrue)
+			So(key.Key(), ShouldEqual, cdk.KeySmallC)
+			So(key.Modifiers(), ShouldEqual, cdk.ModCtrl)
+
+			So(b.send(map[string]interface{}{"t": "key", "key": "ArrowUp"}), ShouldBeNil)
+			key, _ = nextEvent(screen).(*cdk.EventKey)
+			So(key, ShouldNotBeNil)
+			So(key.Key(), ShouldEqual, cdk.KeyUp)
+
+			So(b.send(map[string]interface{}{"t": "mouse", "x": 4, "y": 2, "buttons": 1}), ShouldBeNil)
+			mouse, ok := nextEvent(screen).(*cdk.EventMouse)
+			So(ok, ShouldBeTrue)
+			x, y := mouse.Position()
+			So(x, ShouldEqual, 4)
+			So(y, ShouldEqual, 2)
+			So(mouse.Buttons(), ShouldEqual, cdk.Button1)
+
+			So(b.send(map[string]interface{}{"t": "paste", "text": "hi"}), ShouldBeNil)
+			paste, _ := nextEvent(screen).(*cdk.EventPaste)
+			So(paste, ShouldNotBeNil)
+			So(paste.Start(), ShouldBeTrue)
+			var text strings.Builder
+			for {
+				ev := nextEvent(screen)
+				if k, ok := ev.(*cdk.EventKey); ok {
+					text.WriteRune(k.Rune())
+					continue
+				}
+				paste, _ = ev.(*cdk.EventPaste)
+				break
+			}
+			So(text.String(), ShouldEqual, "hi")
+			So(paste, ShouldNotBeNil)
+			So(paste.End(), ShouldBeTrue)
+		})
+	})
+}
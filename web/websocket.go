@@ -0,0 +1,235 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package web
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-curses/cdk/lib/sync"
+)
+
+// websocketGUID is appended to the key of the client to compute the accept
+// value of the handshake, see: RFC 6455 section 1.3
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+const (
+	opContinuation = 0x0
+	opText         = 0x1
+	opBinary       = 0x2
+	opClose        = 0x8
+	opPing         = 0x9
+	opPong         = 0xa
+)
+
+var (
+	// ErrNotWebSocket indicates a request which is not a websocket handshake
+	ErrNotWebSocket = errors.New("web: not a websocket handshake")
+	// ErrCrossOrigin indicates a handshake from a page of another origin
+	ErrCrossOrigin = errors.New("web: cross origin websocket refused")
+	// ErrUnauthorized indicates a handshake without the token of the screen
+	ErrUnauthorized = errors.New("web: unauthorized websocket refused")
+	// ErrMessageTooLarge indicates a message larger than MaxMessageSize
+	ErrMessageTooLarge = errors.New("web: message too large")
+	// ErrProtocol indicates a frame which violates the websocket protocol
+	ErrProtocol = errors.New("web: websocket protocol error")
+)
+
+var (
+	// MaxMessageSize is the largest message accepted from a browser
+	MaxMessageSize = 1 << 20
+	// WriteTimeout is how long sending a message to a browser may take
+	// before the connection is considered lost
+	WriteTimeout = 10 * time.Second
+)
+
+// wsConn is the server side of a websocket connection, only the parts of RFC
+// 6455 needed by the renderer are implemented and extensions are not
+// negotiated
+type wsConn struct {
+	conn net.Conn
+	r    *bufio.Reader
+	wmu  sync.Mutex
+}
+
+func headerContains(h http.Header, name, token string) bool {
+	for _, value := range h.Values(name) {
+		for _, field := range strings.Split(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(field), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// upgrade completes the websocket handshake of the request, refusing pages
+// from other origins so that other sites cannot drive the display
+func upgrade(w http.ResponseWriter, r *http.Request) (c *wsConn, err error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if r.Method != http.MethodGet || key == "" ||
+		!headerContains(r.Header, "Connection", "upgrade") ||
+		!headerContains(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" {
+		http.Error(w, "websocket handshake expected", http.StatusBadRequest)
+		return nil, ErrNotWebSocket
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, e := url.Parse(origin); e != nil || !strings.EqualFold(u.Host, r.Host) {
+			http.Error(w, "cross origin request refused", http.StatusForbidden)
+			return nil, ErrCrossOrigin
+		}
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return nil, ErrNotWebSocket
+	}
+	var conn net.Conn
+	var rw *bufio.ReadWriter
+	if conn, rw, err = hijacker.Hijack(); err != nil {
+		return
+	}
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	response := "HTTP/1.1 101 Switching Protocols\r\n" +
+		"Upgrade: websocket\r\n" +
+		"Connection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + base64.StdEncoding.EncodeToString(sum[:]) + "\r\n\r\n"
+	if _, err = conn.Write([]byte(response)); err != nil {
+		_ = conn.Close()
+		return
+	}
+	return &wsConn{conn: conn, r: rw.Reader}, nil
+}
+
+// readMessage returns the next text or binary message, answering pings and
+// reassembling fragmented messages. A close from the browser is answered and
+// returned as io.EOF.
+func (c *wsConn) readMessage() (op byte, message []byte, err error) {
+	for {
+		var fin bool
+		var frameOp byte
+		var payload []byte
+		if fin, frameOp, payload, err = c.readFrame(); err != nil {
+			return
+		}
+		switch frameOp {
+		case opPing:
+			if err = c.writeMessage(opPong, payload); err != nil {
+				return
+			}
+			continue
+		case opPong:
+			continue
+		case opClose:
+			_ = c.writeMessage(opClose, payload)
+			return 0, nil, io.EOF
+		case opContinuation:
+			if op == 0 {
+				return 0, nil, ErrProtocol
+			}
+		case opText, opBinary:
+			if op != 0 {
+				return 0, nil, ErrProtocol
+			}
+			op = frameOp
+		default:
+			return 0, nil, ErrProtocol
+		}
+		if len(message)+len(payload) > MaxMessageSize {
+			return 0, nil, ErrMessageTooLarge
+		}
+		message = append(message, payload...)
+		if fin {
+			return
+		}
+	}
+}
+
+func (c *wsConn) readFrame() (fin bool, op byte, payload []byte, err error) {
+	var head [2]byte
+	if _, err = io.ReadFull(c.r, head[:]); err != nil {
+		return
+	}
+	fin, op = head[0]&0x80 != 0, head[0]&0x0f
+	if head[0]&0x70 != 0 || head[1]&0x80 == 0 {
+		// no extensions are negotiated and browsers always mask
+		return false, 0, nil, ErrProtocol
+	}
+	size := uint64(head[1] & 0x7f)
+	switch size {
+	case 126:
+		var ext [2]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+			return
+		}
+		size = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err = io.ReadFull(c.r, ext[:]); err != nil {
+			return
+		}
+		size = binary.BigEndian.Uint64(ext[:])
+	}
+	if size > uint64(MaxMessageSize) {
+		return false, 0, nil, ErrMessageTooLarge
+	}
+	var mask [4]byte
+	if _, err = io.ReadFull(c.r, mask[:]); err != nil {
+		return
+	}
+	payload = make([]byte, size)
+	if _, err = io.ReadFull(c.r, payload); err != nil {
+		return
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return
+}
+
+// writeMessage sends the message as a single unmasked frame
+func (c *wsConn) writeMessage(op byte, payload []byte) (err error) {
+	frame := []byte{0x80 | op}
+	switch size := len(payload); {
+	case size < 126:
+		frame = append(frame, byte(size))
+	case size <= 0xffff:
+		frame = append(frame, 126)
+		frame = binary.BigEndian.AppendUint16(frame, uint16(size))
+	default:
+		frame = append(frame, 127)
+		frame = binary.BigEndian.AppendUint64(frame, uint64(size))
+	}
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+	_ = c.conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
+	_, err = c.conn.Write(append(frame, payload...))
+	return
+}
+
+func (c *wsConn) close() {
+	_ = c.writeMessage(opClose, []byte{0x03, 0xe9}) // 1001, going away
+	_ = c.conn.Close()
+}
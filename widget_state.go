@@ -0,0 +1,170 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+// WidgetState is a mask of the visual states of a Sensitive object. Widget
+// kits track a WidgetState per widget, update it with ProcessMouse and
+// ProcessKey and draw with the styles from ThemeForState.
+type WidgetState uint64
+
+const (
+	StateNormal      WidgetState = 0
+	StateHover       WidgetState = 1 << (iota - 1) // pointer is over the widget
+	StatePressed                                   // widget is being activated
+	StateFocused                                   // widget has keyboard focus
+	StateSelected                                  // widget is selected
+	StateInsensitive                               // widget does not accept input
+)
+
+type IWidgetState interface {
+	Has(m WidgetState) bool
+	Set(m WidgetState) WidgetState
+	Clear(m WidgetState) WidgetState
+	Toggle(m WidgetState) WidgetState
+	String() string
+}
+
+// check if the state has the given flag(s)
+func (i WidgetState) Has(m WidgetState) bool {
+	return i&m != 0
+}
+
+// return a state with the given flags set, does not modify itself
+func (i WidgetState) Set(m WidgetState) WidgetState {
+	return i | m
+}
+
+// return a state with the given flags cleared, does not modify itself
+func (i WidgetState) Clear(m WidgetState) WidgetState {
+	return i &^ m
+}
+
+// return a state with the given flags reversed, does not modify itself
+func (i WidgetState) Toggle(m WidgetState) WidgetState {
+	return i ^ m
+}
+
+var widgetStateNames = []struct {
+	state WidgetState
+	name  string
+}{
+	{StateHover, "StateHover"},
+	{StatePressed, "StatePressed"},
+	{StateFocused, "StateFocused"},
+	{StateSelected, "StateSelected"},
+	{StateInsensitive, "StateInsensitive"},
+}
+
+func (i WidgetState) String() string {
+	if i == StateNormal {
+		return "StateNormal"
+	}
+	var names []string
+	remaining := i
+	for _, entry := range widgetStateNames {
+		if i.Has(entry.state) {
+			names = append(names, entry.name)
+			remaining = remaining.Clear(entry.state)
+		}
+	}
+	if remaining != 0 {
+		names = append(names, "WidgetState("+strconv.FormatUint(uint64(remaining), 10)+")")
+	}
+	return strings.Join(names, "|")
+}
+
+// ProcessMouse returns the state updated for the given mouse event, where the
+// widget occupies the given region. StateHover follows the pointer, a button
+// press within the region sets StatePressed and any button release clears it.
+// Releasing the button within the region while pressed is a click and returns
+// activate true. Insensitive widgets never hover or press.
+func (i WidgetState) ProcessMouse(evt *EventMouse, region ptypes.Region) (state WidgetState, activate bool) {
+	state = i
+	if state.Has(StateInsensitive) {
+		return state.Clear(StateHover | StatePressed), false
+	}
+	inside := region.HasPoint(evt.Point2I())
+	if inside {
+		state = state.Set(StateHover)
+	} else {
+		state = state.Clear(StateHover)
+	}
+	switch {
+	case evt.IsPressed() || evt.IsDragStarted():
+		if inside && !state.Has(StatePressed) {
+			state = state.Set(StatePressed)
+		}
+	case evt.IsReleased() || evt.IsDragStopped():
+		activate = inside && state.Has(StatePressed)
+		state = state.Clear(StatePressed)
+	}
+	return
+}
+
+// ProcessKey returns the state updated for the given key event. Pressing Enter
+// or Space while focused sets StatePressed and returns activate true, releasing
+// it (or pressing any other key, for terminals which do not report key
+// releases) clears StatePressed. Insensitive widgets never press.
+func (i WidgetState) ProcessKey(evt *EventKey) (state WidgetState, activate bool) {
+	state = i
+	if state.Has(StateInsensitive) || !state.Has(StateFocused) {
+		return state.Clear(StatePressed), false
+	}
+	key, mods := evt.Key(), evt.Modifiers()
+	// NewEventKey decodes KeyEnter as <Ctrl>m
+	activation := key == KeyEnter || (key == KeySmallM && mods == ModCtrl) || (key == KeyRune && evt.Rune() == ' ')
+	switch {
+	case activation && evt.Phase() == KeyPress:
+		return state.Set(StatePressed), true
+	case activation && evt.Phase() == KeyRepeat:
+		return state.Set(StatePressed), false
+	}
+	return state.Clear(StatePressed), false
+}
+
+// StyleForState returns the style of the theme aspect which presents the
+// given state. Insensitive takes precedence, followed by pressed (Active),
+// hover (Prelight) and then focused or selected (Selected).
+func StyleForState(aspect paint.ThemeAspect, state WidgetState) paint.Style {
+	switch {
+	case state.Has(StateInsensitive):
+		return aspect.Insensitive
+	case state.Has(StatePressed):
+		return aspect.Active
+	case state.Has(StateHover):
+		return aspect.Prelight
+	case state.Has(StateFocused | StateSelected):
+		return aspect.Selected
+	}
+	return aspect.Normal
+}
+
+// ThemeForState returns a copy of the theme with the Normal style of both the
+// Content and Border aspects replaced with the style for the given state, see:
+// StyleForState
+func ThemeForState(theme paint.Theme, state WidgetState) paint.Theme {
+	resolved := theme.Clone()
+	resolved.Content.Normal = StyleForState(theme.Content, state)
+	resolved.Border.Normal = StyleForState(theme.Border, state)
+	return resolved
+}
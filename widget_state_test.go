@@ -0,0 +1,79 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+)
+
+func TestWidgetState(t *testing.T) {
+	Convey("Widget state transitions", t, func() {
+		So(StateNormal.String(), ShouldEqual, "StateNormal")
+		So(StateHover.Set(StateFocused).String(), ShouldEqual, "StateHover|StateFocused")
+		So(StateInsensitive.Has(StateHover), ShouldBeFalse)
+
+		region := ptypes.MakeRegion(0, 0, 5, 1)
+		state := StateNormal
+		var activate bool
+		state, activate = state.ProcessMouse(NewEventMouse(1, 0, ButtonNone, ModNone), region)
+		So(state, ShouldEqual, StateHover)
+		So(activate, ShouldBeFalse)
+		state, activate = state.ProcessMouse(NewEventMouse(1, 0, Button1, ModNone), region)
+		So(state, ShouldEqual, StateHover|StatePressed)
+		state, activate = state.ProcessMouse(NewEventMouse(1, 0, ButtonNone, ModNone), region)
+		So(state, ShouldEqual, StateHover)
+		So(activate, ShouldBeTrue)
+		state, activate = state.ProcessMouse(NewEventMouse(10, 5, ButtonNone, ModNone), region)
+		So(state, ShouldEqual, StateNormal)
+		So(activate, ShouldBeFalse)
+
+		state, _ = StateInsensitive.ProcessMouse(NewEventMouse(1, 0, Button1, ModNone), region)
+		So(state, ShouldEqual, StateInsensitive)
+		// release the button so the shared mouse state is left idle
+		_ = NewEventMouse(1, 0, ButtonNone, ModNone)
+
+		state, activate = StateNormal.ProcessKey(NewEventKey(KeyEnter, 0, ModNone))
+		So(state, ShouldEqual, StateNormal)
+		So(activate, ShouldBeFalse)
+		state, activate = StateFocused.ProcessKey(NewEventKey(KeyEnter, 0, ModNone))
+		So(state, ShouldEqual, StateFocused|StatePressed)
+		So(activate, ShouldBeTrue)
+		state, activate = state.ProcessKey(NewEventKeyWithPhase(KeyEnter, 0, ModNone, KeyRelease))
+		So(state, ShouldEqual, StateFocused)
+		So(activate, ShouldBeFalse)
+		state, activate = state.ProcessKey(NewEventKey(KeyRune, ' ', ModNone))
+		So(activate, ShouldBeTrue)
+		state, activate = state.ProcessKey(NewEventKey(KeyRune, 'x', ModNone))
+		So(state, ShouldEqual, StateFocused)
+		So(activate, ShouldBeFalse)
+	})
+	Convey("Theme styles for widget states", t, func() {
+		theme := paint.GetDefaultColorTheme()
+		So(StyleForState(theme.Content, StateNormal), ShouldResemble, theme.Content.Normal)
+		So(StyleForState(theme.Content, StateFocused), ShouldResemble, theme.Content.Selected)
+		So(StyleForState(theme.Content, StateFocused|StateHover), ShouldResemble, theme.Content.Prelight)
+		So(StyleForState(theme.Content, StateHover|StatePressed), ShouldResemble, theme.Content.Active)
+		So(StyleForState(theme.Content, StatePressed|StateInsensitive), ShouldResemble, theme.Content.Insensitive)
+		resolved := ThemeForState(theme, StatePressed)
+		So(resolved.Content.Normal, ShouldResemble, theme.Content.Active)
+		So(resolved.Border.Normal, ShouldResemble, theme.Border.Active)
+		So(resolved.Content.Selected, ShouldResemble, theme.Content.Selected)
+	})
+}
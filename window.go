@@ -15,16 +15,24 @@
 package cdk
 
 import (
+	"sync/atomic"
+
 	"github.com/go-curses/cdk/lib/enums"
 	"github.com/go-curses/cdk/memphis"
 )
 
 const (
-	TypeWindow         CTypeTag = "cdk-window"
-	PropertyWindowType Property = "window-type"
-	SignalDraw         Signal   = "draw"
-	SignalSetTitle     Signal   = "set-title"
-	SignalSetDisplay   Signal   = "set-display"
+	TypeWindow                      CTypeTag = "cdk-window"
+	PropertyWindowType              Property = "window-type"
+	PropertyWindowGeometryHints     Property = "window-geometry-hints"
+	SignalDraw                      Signal   = "draw"
+	SignalSetTitle                  Signal   = "set-title"
+	SignalSetDisplay                Signal   = "set-display"
+	SignalWindowConstraintViolation Signal   = "window-constraint-violation"
+	SignalDragEnter                 Signal   = "drag-enter"
+	SignalDragMotion                Signal   = "drag-motion"
+	SignalDragLeave                 Signal   = "drag-leave"
+	SignalDrop                      Signal   = "drop"
 )
 
 func init() {
@@ -45,13 +53,23 @@ type Window interface {
 	SetDisplay(d Display)
 	Draw() enums.EventFlag
 	ProcessEvent(evt Event) enums.EventFlag
+	GetInputFilter() InputFilterChain
+	GetGeometryHints() (hints WindowGeometryHints)
+	SetGeometryHints(hints WindowGeometryHints)
 }
 
 // Basic window type
 type CWindow struct {
 	CObject
 
-	title   string
+	title      string
+	filter     InputFilterChain
+	display    Display
+	logDisplay atomic.Value
+}
+
+// cLogDisplay holds the Display of a Window for logging without locking
+type cLogDisplay struct {
 	display Display
 }
 
@@ -69,7 +87,10 @@ func (w *CWindow) Init() bool {
 		return true
 	}
 	w.CObject.Init()
+	w.logDisplay.Store(cLogDisplay{w.display})
+	w.filter = NewInputFilterChain()
 	_ = w.InstallProperty(PropertyWindowType, StructProperty, true, enums.WINDOW_TOPLEVEL)
+	_ = w.InstallProperty(PropertyWindowGeometryHints, StructProperty, true, WindowGeometryHints{})
 	return false
 }
 
@@ -101,6 +122,28 @@ func (w *CWindow) SetWindowType(hint enums.WindowType) {
 	}
 }
 
+// GetGeometryHints returns the minimum size, maximum size and aspect ratio
+// hints of the window.
+// See: WindowGeometryHints
+func (w *CWindow) GetGeometryHints() (hints WindowGeometryHints) {
+	var ok bool
+	if v, err := w.GetStructProperty(PropertyWindowGeometryHints); err != nil {
+		w.LogErr(err)
+	} else if hints, ok = v.(WindowGeometryHints); !ok {
+		w.LogError("value stored in %v is not of WindowGeometryHints: %v (%T)", PropertyWindowGeometryHints, v, v)
+	}
+	return
+}
+
+// SetGeometryHints updates the geometry hints of the window, which are applied
+// by the Display the next time the window is mapped or the Display is resized.
+// See: WindowGeometryHints
+func (w *CWindow) SetGeometryHints(hints WindowGeometryHints) {
+	if err := w.SetStructProperty(PropertyWindowGeometryHints, hints); err != nil {
+		w.LogErr(err)
+	}
+}
+
 func (w *CWindow) SetTitle(title string) {
 	if f := w.Emit(SignalSetTitle, w, title); f == enums.EVENT_PASS {
 		w.Lock()
@@ -125,10 +168,20 @@ func (w *CWindow) SetDisplay(d Display) {
 	if f := w.Emit(SignalSetDisplay, w, d); f == enums.EVENT_PASS {
 		w.Lock()
 		w.display = d
+		w.logDisplay.Store(cLogDisplay{d})
 		w.Unlock()
 	}
 }
 
+// LogDisplayName returns the name of the Display of the window, included in
+// the messages logged by the window, see: TypeItem.LogFields
+func (w *CWindow) LogDisplayName() (name string) {
+	if v, ok := w.logDisplay.Load().(cLogDisplay); ok && v.display != nil {
+		name = v.display.LogDisplayName()
+	}
+	return
+}
+
 func (w *CWindow) Draw() enums.EventFlag {
 	if !w.IsFrozen() {
 		if surface, err := memphis.GetSurface(w.ObjectID()); err != nil {
@@ -143,3 +196,11 @@ func (w *CWindow) Draw() enums.EventFlag {
 func (w *CWindow) ProcessEvent(evt Event) enums.EventFlag {
 	return w.Emit(SignalEvent, w, evt)
 }
+
+// GetInputFilter returns the InputFilterChain applied by the Display to all
+// EventKey events before they are given to ProcessEvent.
+func (w *CWindow) GetInputFilter() InputFilterChain {
+	w.RLock()
+	defer w.RUnlock()
+	return w.filter
+}
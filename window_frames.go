@@ -0,0 +1,202 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gofrs/uuid"
+
+	"github.com/go-curses/cdk/memphis"
+)
+
+// WindowFrame is a snapshot of the surface of a Window, taken each time the
+// Window is drawn while frame history is enabled for it, see:
+// Display.SetWindowFrameHistory
+type WindowFrame struct {
+	// Sequence is the number of frames recorded for the Window before this one
+	Sequence uint64
+	// When is the time the frame was drawn
+	When time.Time
+	// Surface is the content of the Window surface as drawn
+	Surface *memphis.CSurface
+}
+
+// cWindowFrames is a ring of the most recent frames of a Window
+type cWindowFrames struct {
+	frames []*WindowFrame
+	next   int
+	count  int
+	seq    uint64
+}
+
+func newWindowFrames(limit int) *cWindowFrames {
+	return &cWindowFrames{frames: make([]*WindowFrame, limit)}
+}
+
+func (f *cWindowFrames) record(surface *memphis.CSurface) {
+	f.frames[f.next] = &WindowFrame{
+		Sequence: f.seq,
+		When:     time.Now(),
+		Surface:  surface.Snapshot(),
+	}
+	f.seq++
+	f.next = (f.next + 1) % len(f.frames)
+	if f.count < len(f.frames) {
+		f.count++
+	}
+}
+
+// get returns the frame the given number of frames before the latest
+func (f *cWindowFrames) get(back int) (frame *WindowFrame, ok bool) {
+	if back < 0 || back >= f.count {
+		return nil, false
+	}
+	idx := (f.next - 1 - back + len(f.frames)) % len(f.frames)
+	return f.frames[idx], true
+}
+
+// list returns the recorded frames, oldest first
+func (f *cWindowFrames) list() (frames []*WindowFrame) {
+	for back := f.count - 1; back >= 0; back-- {
+		frame, _ := f.get(back)
+		frames = append(frames, frame)
+	}
+	return
+}
+
+// tracks the frame currently presented in place of a Window's live content
+type cWindowFrameInspect struct {
+	window Window
+	back   int
+}
+
+// SetWindowFrameHistory enables recording of the last given number of frames
+// drawn by the Window, for diagnosing rendering issues. Changing the number
+// of frames discards any recorded frames and zero disables recording.
+func (d *CDisplay) SetWindowFrameHistory(w Window, frames int) {
+	d.Lock()
+	if frames <= 0 {
+		delete(d.frameHistory, w.ObjectID())
+	} else if existing, ok := d.frameHistory[w.ObjectID()]; !ok || len(existing.frames) != frames {
+		d.frameHistory[w.ObjectID()] = newWindowFrames(frames)
+	}
+	inspecting := d.inspect != nil && d.inspect.window.ObjectID() == w.ObjectID()
+	d.Unlock()
+	if inspecting && frames <= 0 {
+		d.StopInspectingWindowFrame()
+	}
+}
+
+// GetWindowFrameHistory returns the recorded frames of the Window, oldest
+// first
+func (d *CDisplay) GetWindowFrameHistory(w Window) (frames []*WindowFrame) {
+	d.RLock()
+	defer d.RUnlock()
+	if history, ok := d.frameHistory[w.ObjectID()]; ok {
+		frames = history.list()
+	}
+	return
+}
+
+// GetWindowFrame returns the recorded frame of the Window the given number of
+// frames before the most recent, which is zero
+func (d *CDisplay) GetWindowFrame(w Window, back int) (frame *WindowFrame, ok bool) {
+	d.RLock()
+	defer d.RUnlock()
+	if history, found := d.frameHistory[w.ObjectID()]; found {
+		frame, ok = history.get(back)
+	}
+	return
+}
+
+// InspectWindowFrame presents the recorded frame of the Window, the given
+// number of frames before the most recent, in place of the live content of
+// the Window. The Window is not drawn, and no frames are recorded, until
+// StopInspectingWindowFrame is called.
+func (d *CDisplay) InspectWindowFrame(w Window, back int) (err error) {
+	frame, ok := d.GetWindowFrame(w, back)
+	if !ok {
+		return fmt.Errorf("frame not recorded: %v frames before the latest of %v", back, w.ObjectName())
+	}
+	d.Lock()
+	d.inspect = &cWindowFrameInspect{window: w, back: back}
+	d.Unlock()
+	d.Emit(SignalWindowFrameInspect, d, w, frame)
+	d.RequestDraw()
+	d.RequestShow()
+	return
+}
+
+// StepWindowFrame moves the inspected frame the given number of frames
+// backwards in time, or forwards when negative, see: InspectWindowFrame
+func (d *CDisplay) StepWindowFrame(delta int) (err error) {
+	d.RLock()
+	inspect := d.inspect
+	d.RUnlock()
+	if inspect == nil {
+		return fmt.Errorf("not inspecting a window frame")
+	}
+	return d.InspectWindowFrame(inspect.window, inspect.back+delta)
+}
+
+// GetInspectedWindowFrame returns the Window and frame being inspected, if any
+func (d *CDisplay) GetInspectedWindowFrame() (w Window, back int, inspecting bool) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.inspect != nil {
+		return d.inspect.window, d.inspect.back, true
+	}
+	return nil, 0, false
+}
+
+// StopInspectingWindowFrame resumes drawing the live content of the inspected
+// Window
+func (d *CDisplay) StopInspectingWindowFrame() {
+	d.Lock()
+	inspect := d.inspect
+	d.inspect = nil
+	d.Unlock()
+	if inspect != nil {
+		d.Emit(SignalWindowFrameInspect, d, inspect.window, (*WindowFrame)(nil))
+		d.RequestDraw()
+		d.RequestShow()
+	}
+}
+
+// inspectedFrame returns the frame to present in place of the given Window
+func (d *CDisplay) inspectedFrame(id uuid.UUID) (frame *WindowFrame, ok bool) {
+	d.RLock()
+	defer d.RUnlock()
+	if d.inspect == nil || d.inspect.window.ObjectID() != id {
+		return nil, false
+	}
+	if history, found := d.frameHistory[id]; found {
+		return history.get(d.inspect.back)
+	}
+	return nil, false
+}
+
+// recordFrame snapshots the Window surface if frame history is enabled
+func (d *CDisplay) recordFrame(id uuid.UUID) {
+	d.Lock()
+	defer d.Unlock()
+	if history, ok := d.frameHistory[id]; ok {
+		if surface, err := memphis.GetSurface(id); err == nil {
+			history.record(surface)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+func TestWindowFrameHistory(t *testing.T) {
+	Convey("Window frame history", t, WithDisplayManager(func(d Display) {
+		cd := d.(*CDisplay)
+		w := NewOffscreenWindow("frames")
+		d.MapWindowWithRegion(w, ptypes.MakeRegion(0, 0, 3, 1))
+		surface, err := memphis.GetSurface(w.ObjectID())
+		So(err, ShouldBeNil)
+		draw := func(r rune) {
+			_ = surface.SetRune(0, 0, r, paint.StyleDefault)
+			cd.recordFrame(w.ObjectID())
+		}
+
+		// nothing is recorded until enabled
+		draw('a')
+		So(d.GetWindowFrameHistory(w), ShouldBeEmpty)
+		_, ok := d.GetWindowFrame(w, 0)
+		So(ok, ShouldBeFalse)
+
+		d.SetWindowFrameHistory(w, 2)
+		draw('b')
+		draw('c')
+		draw('d')
+		frames := d.GetWindowFrameHistory(w)
+		So(frames, ShouldHaveLength, 2)
+		So(frames[0].Sequence, ShouldEqual, 1)
+		So(frames[0].Surface.Lines(), ShouldResemble, []string{"c  "})
+		So(frames[1].Surface.Lines(), ShouldResemble, []string{"d  "})
+		frame, ok := d.GetWindowFrame(w, 1)
+		So(ok, ShouldBeTrue)
+		So(frame.Sequence, ShouldEqual, 1)
+		// snapshots are not affected by later drawing
+		_ = surface.SetRune(1, 0, 'x', paint.StyleDefault)
+		So(frame.Surface.Lines(), ShouldResemble, []string{"c  "})
+
+		inspected := 0
+		d.Connect(SignalWindowFrameInspect, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			inspected++
+			return enums.EVENT_PASS
+		})
+		So(d.InspectWindowFrame(w, 5), ShouldNotBeNil)
+		So(d.StepWindowFrame(1), ShouldNotBeNil)
+		So(d.InspectWindowFrame(w, 0), ShouldBeNil)
+		So(d.StepWindowFrame(1), ShouldBeNil)
+		iw, back, inspecting := d.GetInspectedWindowFrame()
+		So(inspecting, ShouldBeTrue)
+		So(iw, ShouldEqual, w)
+		So(back, ShouldEqual, 1)
+		frame, ok = cd.inspectedFrame(w.ObjectID())
+		So(ok, ShouldBeTrue)
+		So(frame.Surface.Lines(), ShouldResemble, []string{"c  "})
+		So(d.StepWindowFrame(1), ShouldNotBeNil)
+		d.StopInspectingWindowFrame()
+		_, _, inspecting = d.GetInspectedWindowFrame()
+		So(inspecting, ShouldBeFalse)
+		So(inspected, ShouldEqual, 3)
+
+		So(d.InspectWindowFrame(w, 0), ShouldBeNil)
+		d.UnmapWindow(w)
+		_, _, inspecting = d.GetInspectedWindowFrame()
+		So(inspecting, ShouldBeFalse)
+		So(d.GetWindowFrameHistory(w), ShouldBeEmpty)
+	}))
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"github.com/gofrs/uuid"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+// WindowGeometryHints describe the sizes a Window can be mapped with. Zero
+// values are not constrained.
+type WindowGeometryHints struct {
+	// MinSize is the smallest usable size of the Window
+	MinSize ptypes.Rectangle
+	// MaxSize is the largest usable size of the Window
+	MaxSize ptypes.Rectangle
+	// Aspect is the fixed ratio of width to height, in cells
+	Aspect float64
+}
+
+// IsSet returns true if any of the hints are non-zero
+func (h WindowGeometryHints) IsSet() bool {
+	return h.MinSize.W > 0 || h.MinSize.H > 0 || h.MaxSize.W > 0 || h.MaxSize.H > 0 || h.Aspect > 0
+}
+
+// Constrain returns the given region adjusted to satisfy the hints and fit
+// within the available space, moving the origin if necessary. When the
+// available space is smaller than the minimum size, ok is false and the
+// region is clamped to the available space. When the available space is zero
+// (unknown), only the hints are applied.
+func (h WindowGeometryHints) Constrain(region ptypes.Region, available ptypes.Rectangle) (constrained ptypes.Region, ok bool) {
+	ok = true
+	known := available.W > 0 && available.H > 0
+	size := region.Size()
+	limit := h.MaxSize
+	if known {
+		if limit.W <= 0 || limit.W > available.W {
+			limit.W = available.W
+		}
+		if limit.H <= 0 || limit.H > available.H {
+			limit.H = available.H
+		}
+	}
+	if limit.W > 0 && size.W > limit.W {
+		size.W = limit.W
+	}
+	if limit.H > 0 && size.H > limit.H {
+		size.H = limit.H
+	}
+	if h.Aspect > 0 && size.W > 0 && size.H > 0 {
+		if float64(size.W)/float64(size.H) > h.Aspect {
+			size.W = int(float64(size.H) * h.Aspect)
+		} else {
+			size.H = int(float64(size.W) / h.Aspect)
+		}
+	}
+	if size.W < h.MinSize.W {
+		if size.W = h.MinSize.W; known && size.W > available.W {
+			size.W, ok = available.W, false
+		}
+	}
+	if size.H < h.MinSize.H {
+		if size.H = h.MinSize.H; known && size.H > available.H {
+			size.H, ok = available.H, false
+		}
+	}
+	origin := region.Origin()
+	if known {
+		if origin.X+size.W > available.W {
+			origin.X = available.W - size.W
+		}
+		if origin.Y+size.H > available.H {
+			origin.Y = available.H - size.H
+		}
+		if origin.X < 0 {
+			origin.X = 0
+		}
+		if origin.Y < 0 {
+			origin.Y = 0
+		}
+	}
+	constrained = ptypes.MakeRegion(origin.X, origin.Y, size.W, size.H)
+	return
+}
+
+// DrawTerminalTooSmall renders the standard placeholder shown in place of a
+// Window when the terminal is smaller than the Window's minimum size
+func DrawTerminalTooSmall(surface memphis.Surface, need, have ptypes.Rectangle, theme paint.Theme) {
+	surface.Fill(theme)
+	size := surface.GetSize()
+	if size.W <= 0 || size.H <= 0 {
+		return
+	}
+	lines := []string{
+		Tr("terminal too small"),
+		Tr("need %dx%d, have %dx%d", need.W, need.H, have.W, have.H),
+	}
+	if size.H < len(lines) {
+		lines = lines[:size.H]
+	}
+	top := (size.H - len(lines)) / 2
+	for idx, line := range lines {
+		surface.DrawSingleLineText(ptypes.MakePoint2I(0, top+idx), size.W, true, enums.JUSTIFY_CENTER, theme.Content.Normal, false, false, line)
+	}
+}
+
+// tracks how a Window was mapped, so that it can be constrained again when
+// the Display is resized
+type cWindowGeometry struct {
+	region   ptypes.Region
+	fill     bool
+	violated bool
+	state    WindowState
+}
+
+// tracks the layout of a Display prior to zooming a Window
+type cWindowZoom struct {
+	window  Window
+	windows []Window
+	regions map[uuid.UUID]cWindowGeometry
+}
+
+func (z *cWindowZoom) recorded(w Window) bool {
+	for _, window := range z.windows {
+		if window.ObjectID() == w.ObjectID() {
+			return true
+		}
+	}
+	return false
+}
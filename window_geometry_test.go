@@ -0,0 +1,124 @@
+// Copyright (c) 2023  The Go-Curses Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cdk
+
+import (
+	"testing"
+
+	"github.com/gofrs/uuid"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/go-curses/cdk/lib/enums"
+	"github.com/go-curses/cdk/lib/paint"
+	"github.com/go-curses/cdk/lib/ptypes"
+	"github.com/go-curses/cdk/memphis"
+)
+
+func TestWindowGeometry(t *testing.T) {
+	Convey("Window geometry hints", t, func() {
+		hints := WindowGeometryHints{}
+		So(hints.IsSet(), ShouldBeFalse)
+		region, ok := hints.Constrain(ptypes.MakeRegion(0, 0, 80, 24), ptypes.MakeRectangle(80, 24))
+		So(ok, ShouldBeTrue)
+		So(region, ShouldResemble, ptypes.MakeRegion(0, 0, 80, 24))
+		hints.MinSize = ptypes.MakeRectangle(40, 10)
+		hints.MaxSize = ptypes.MakeRectangle(60, 20)
+		So(hints.IsSet(), ShouldBeTrue)
+		region, ok = hints.Constrain(ptypes.MakeRegion(0, 0, 80, 24), ptypes.MakeRectangle(80, 24))
+		So(ok, ShouldBeTrue)
+		So(region, ShouldResemble, ptypes.MakeRegion(0, 0, 60, 20))
+		// origin moves to fit
+		region, ok = hints.Constrain(ptypes.MakeRegion(30, 10, 60, 20), ptypes.MakeRectangle(80, 24))
+		So(ok, ShouldBeTrue)
+		So(region, ShouldResemble, ptypes.MakeRegion(20, 4, 60, 20))
+		// too small
+		region, ok = hints.Constrain(ptypes.MakeRegion(0, 0, 30, 8), ptypes.MakeRectangle(30, 8))
+		So(ok, ShouldBeFalse)
+		So(region, ShouldResemble, ptypes.MakeRegion(0, 0, 30, 8))
+		// unknown available size
+		region, ok = hints.Constrain(ptypes.MakeRegion(0, 0, 10, 5), ptypes.MakeRectangle(0, 0))
+		So(ok, ShouldBeTrue)
+		So(region, ShouldResemble, ptypes.MakeRegion(0, 0, 40, 10))
+		// aspect ratio
+		hints = WindowGeometryHints{Aspect: 2.0}
+		region, ok = hints.Constrain(ptypes.MakeRegion(0, 0, 80, 24), ptypes.MakeRectangle(80, 24))
+		So(ok, ShouldBeTrue)
+		So(region.Size(), ShouldResemble, ptypes.MakeRectangle(48, 24))
+	})
+	Convey("Window geometry accessors", t, func() {
+		w := NewWindow("testing", nil)
+		So(w.GetGeometryHints(), ShouldResemble, WindowGeometryHints{})
+		hints := WindowGeometryHints{MinSize: ptypes.MakeRectangle(10, 5)}
+		w.SetGeometryHints(hints)
+		So(w.GetGeometryHints(), ShouldResemble, hints)
+	})
+	Convey("Terminal too small placeholder", t, func() {
+		id, _ := uuid.NewV4()
+		_ = memphis.MakeSurface(id, ptypes.MakePoint2I(0, 0), ptypes.MakeRectangle(30, 4), paint.GetDefaultMonoStyle())
+		defer memphis.RemoveSurface(id)
+		surface, err := memphis.GetSurface(id)
+		So(err, ShouldBeNil)
+		DrawTerminalTooSmall(surface, ptypes.MakeRectangle(40, 10), ptypes.MakeRectangle(30, 4), paint.GetDefaultMonoTheme())
+		So(surface.GetContent(6, 1).Value(), ShouldEqual, 't')
+	})
+}
+
+func TestWindowZoom(t *testing.T) {
+	Convey("Zooming windows", t, WithDisplayManager(func(d Display) {
+		zoomed := 0
+		d.Connect(SignalWindowZoomed, "testing", func(data []interface{}, argv ...interface{}) enums.EventFlag {
+			if argv[2].(bool) {
+				zoomed++
+			} else {
+				zoomed--
+			}
+			return enums.EVENT_PASS
+		})
+		a := NewOffscreenWindow("a")
+		b := NewOffscreenWindow("b")
+		d.MapWindowWithRegion(a, ptypes.MakeRegion(1, 1, 10, 5))
+		d.MapWindowWithRegion(b, ptypes.MakeRegion(2, 2, 10, 5))
+		So(d.GetWindows(), ShouldResemble, []Window{b, a})
+		So(d.GetZoomedWindow(), ShouldBeNil)
+		d.ZoomWindow(a)
+		So(zoomed, ShouldEqual, 1)
+		So(d.GetZoomedWindow(), ShouldEqual, a)
+		So(d.GetWindows()[0], ShouldEqual, a)
+		c := NewOffscreenWindow("c")
+		d.MapWindowWithRegion(c, ptypes.MakeRegion(3, 3, 4, 4))
+		d.Unzoom()
+		So(zoomed, ShouldEqual, 0)
+		So(d.GetZoomedWindow(), ShouldBeNil)
+		So(d.GetWindows(), ShouldResemble, []Window{c, b, a})
+		surface, err := memphis.GetSurface(a.ObjectID())
+		So(err, ShouldBeNil)
+		So(surface.GetRegion(), ShouldResemble, ptypes.MakeRegion(1, 1, 10, 5))
+		Convey("unmapping the zoomed window restores the layout", func() {
+			z := NewOffscreenWindow("z")
+			d.ZoomWindow(z)
+			So(d.GetWindows()[0], ShouldEqual, z)
+			d.UnmapWindow(z)
+			So(d.GetZoomedWindow(), ShouldBeNil)
+			So(d.GetWindows(), ShouldResemble, []Window{c, b, a})
+			So(zoomed, ShouldEqual, 0)
+		})
+		Convey("zooming a window not previously mapped", func() {
+			z := NewOffscreenWindow("z")
+			d.ZoomWindow(z)
+			d.Unzoom()
+			So(d.GetWindows(), ShouldResemble, []Window{c, b, a})
+		})
+	}))
+}